@@ -0,0 +1,210 @@
+/*
+Package admin serves debugging endpoints for a runtime component, such as a
+proxy, which serves a document:
+
+	h := admin.Handler(p, p, admin.Options{
+		Authorize: func(r *http.Request) error {
+			if r.Header.Get("Authorization") != "Bearer "+token {
+				return errors.New("invalid token")
+			}
+			return nil
+		},
+		Reload: func(ctx context.Context) error {
+			doc, err := load("petstore.yaml")
+			if err != nil {
+				return err
+			}
+			return p.Reload(doc)
+		},
+	})
+
+The endpoints are served under Options.Prefix and respond with JSON:
+
+	GET  /debug/swaggopher/documents  the loaded documents, their versions, hashes and footprints
+	GET  /debug/swaggopher/routes     the route table
+	GET  /debug/swaggopher/counters   the component's counters and operation coverage
+	POST /debug/swaggopher/reload     reloads the document with Options.Reload
+
+Other requests are passed to the next handler.
+*/
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultPrefix is the path the endpoints are served under if Options.Prefix
+// isn't set.
+const DefaultPrefix = "/debug/swaggopher"
+
+// Component is a runtime component whose state the endpoints expose.
+type Component interface {
+	Status() Status
+}
+
+// Status is a snapshot of a component's state.
+type Status struct {
+	// Documents lists the documents the component is serving, newest first.
+	// There's more than one while requests are still being served by an old
+	// version.
+	Documents []Document `json:"documents"`
+	Routes    []Route    `json:"routes"`
+	// Counters holds the component's counters, encoded as JSON.
+	Counters interface{} `json:"counters,omitempty"`
+}
+
+// Document describes a document loaded by a component.
+type Document struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+	// Hash identifies the document's contents.
+	Hash   string    `json:"hash"`
+	Loaded time.Time `json:"loaded"`
+	// Generation counts the documents the component loaded before this one.
+	Generation int `json:"generation"`
+	// InFlight counts the requests the document is serving.
+	InFlight int `json:"inFlight"`
+	// Footprint estimates the bytes of memory the document retains, as
+	// reported by spec.Footprint.
+	Footprint int64 `json:"footprint,omitempty"`
+}
+
+// Route is an operation a component serves.
+type Route struct {
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+	OperationID string `json:"operationId,omitempty"`
+	// Upstreams lists where the component sends the operation's requests,
+	// if it forwards them.
+	Upstreams []string `json:"upstreams,omitempty"`
+	// Canary lists the upstreams of the operation's canary, if it has one.
+	Canary []string `json:"canary,omitempty"`
+	// Requests counts the requests the operation has served.
+	Requests int64 `json:"requests"`
+}
+
+// Coverage summarizes which of a document's operations have served requests.
+type Coverage struct {
+	Operations int `json:"operations"`
+	Exercised  int `json:"exercised"`
+	// Unexercised lists the operations which haven't served a request, such
+	// as "GET /pets/{petId}".
+	Unexercised []string `json:"unexercised,omitempty"`
+}
+
+// CoverageOf computes the coverage of a component's routes.
+func CoverageOf(routes []Route) Coverage {
+	c := Coverage{Operations: len(routes)}
+	for _, r := range routes {
+		if r.Requests > 0 {
+			c.Exercised++
+			continue
+		}
+		c.Unexercised = append(c.Unexercised, r.Method+" "+r.Path)
+	}
+	sort.Strings(c.Unexercised)
+	return c
+}
+
+// Options configures Handler. The zero value serves every endpoint other than
+// reload to anyone.
+type Options struct {
+	// Prefix is the path the endpoints are served under. It defaults to
+	// DefaultPrefix.
+	Prefix string
+	// Authorize, if set, is called for every request to the endpoints. If it
+	// returns an error the request is rejected with a 403. It should be set
+	// unless the handler is only reachable by operators.
+	Authorize func(r *http.Request) error
+	// Reload, if set, is called for requests to the reload endpoint.
+	Reload func(ctx context.Context) error
+}
+
+// Handler returns a handler serving the endpoints for a component, and passing
+// other requests to next. If next is nil, other requests receive a 404.
+func Handler(c Component, next http.Handler, opts Options) http.Handler {
+	if opts.Prefix == "" {
+		opts.Prefix = DefaultPrefix
+	}
+	opts.Prefix = "/" + strings.Trim(opts.Prefix, "/")
+	if next == nil {
+		next = http.NotFoundHandler()
+	}
+	return &handler{c: c, next: next, opts: opts}
+}
+
+type handler struct {
+	c    Component
+	next http.Handler
+	opts Options
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != h.opts.Prefix && !strings.HasPrefix(r.URL.Path, h.opts.Prefix+"/") {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+	if h.opts.Authorize != nil {
+		if err := h.opts.Authorize(r); err != nil {
+			writeError(w, http.StatusForbidden, err.Error())
+			return
+		}
+	}
+
+	endpoint := strings.Trim(strings.TrimPrefix(r.URL.Path, h.opts.Prefix), "/")
+	method := "GET"
+	if endpoint == "reload" {
+		method = "POST"
+	}
+	if r.Method != method {
+		w.Header().Set("Allow", method)
+		writeError(w, http.StatusMethodNotAllowed, "method "+r.Method+" not allowed")
+		return
+	}
+
+	switch endpoint {
+	case "":
+		writeJSON(w, http.StatusOK, map[string][]string{
+			"endpoints": {"documents", "routes", "counters", "reload"},
+		})
+	case "documents":
+		writeJSON(w, http.StatusOK, map[string]interface{}{"documents": h.c.Status().Documents})
+	case "routes":
+		writeJSON(w, http.StatusOK, map[string]interface{}{"routes": h.c.Status().Routes})
+	case "counters":
+		s := h.c.Status()
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"counters": s.Counters,
+			"coverage": CoverageOf(s.Routes),
+		})
+	case "reload":
+		if h.opts.Reload == nil {
+			writeError(w, http.StatusNotImplemented, "reloading is not configured")
+			return
+		}
+		if err := h.opts.Reload(r.Context()); err != nil {
+			writeError(w, http.StatusInternalServerError, "reload failed: "+err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"documents": h.c.Status().Documents})
+	default:
+		writeError(w, http.StatusNotFound, "unknown endpoint "+r.URL.Path)
+	}
+}
+
+func writeError(w http.ResponseWriter, code int, msg string) {
+	writeJSON(w, code, map[string]string{"message": msg})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
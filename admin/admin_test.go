@@ -0,0 +1,106 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+type component struct {
+	status Status
+}
+
+func (c *component) Status() Status { return c.status }
+
+func TestHandler(t *testing.T) {
+	c := &component{status: Status{
+		Documents: []Document{{Title: "Pets", Version: "1.0", Hash: "abc"}},
+		Routes: []Route{
+			{Method: "GET", Path: "/pets", Requests: 3},
+			{Method: "GET", Path: "/pets/{petId}"},
+		},
+		Counters: map[string]int{"requests": 3},
+	}}
+	reloads := 0
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("next\n"))
+	})
+	h := Handler(c, next, Options{
+		Prefix: "/admin/",
+		Authorize: func(r *http.Request) error {
+			if r.Header.Get("Authorization") != "secret" {
+				return errors.New("not an operator")
+			}
+			return nil
+		},
+		Reload: func(ctx context.Context) error {
+			reloads++
+			if reloads > 1 {
+				return errors.New("bad document")
+			}
+			c.status.Documents[0].Version = "2.0"
+			return nil
+		},
+	})
+
+	tests := []struct {
+		method, path string
+		noAuth       bool
+		wantCode     int
+		wantBody     string
+	}{
+		{method: "GET", path: "/pets", noAuth: true, wantCode: 200, wantBody: "next"},
+		{method: "GET", path: "/admin/routes", noAuth: true, wantCode: 403, wantBody: `"message": "not an operator"`},
+		{method: "GET", path: "/admin/documents", wantCode: 200, wantBody: `"hash": "abc"`},
+		{method: "GET", path: "/admin/routes", wantCode: 200, wantBody: `"path": "/pets/{petId}"`},
+		{method: "GET", path: "/admin/counters", wantCode: 200, wantBody: `"unexercised": [
+      "GET /pets/{petId}"
+    ]`},
+		{method: "GET", path: "/admin/reload", wantCode: 405},
+		{method: "POST", path: "/admin/reload", wantCode: 200, wantBody: `"version": "2.0"`},
+		{method: "POST", path: "/admin/reload", wantCode: 500, wantBody: "reload failed: bad document"},
+		{method: "GET", path: "/admin/nope", wantCode: 404},
+	}
+	for i, tt := range tests {
+		r := httptest.NewRequest(tt.method, tt.path, nil)
+		if !tt.noAuth {
+			r.Header.Set("Authorization", "secret")
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != tt.wantCode || !strings.Contains(w.Body.String(), tt.wantBody) {
+			t.Errorf("case %d: %s %s: want %d containing %q, got %d %s", i, tt.method, tt.path, tt.wantCode, tt.wantBody, w.Code, w.Body)
+		}
+	}
+}
+
+func TestHandlerWithoutReload(t *testing.T) {
+	h := Handler(&component{}, nil, Options{})
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", DefaultPrefix+"/reload", nil))
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("expected status 501, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/pets", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", w.Code)
+	}
+}
+
+func TestCoverageOf(t *testing.T) {
+	got := CoverageOf([]Route{
+		{Method: "POST", Path: "/pets"},
+		{Method: "GET", Path: "/pets", Requests: 1},
+		{Method: "DELETE", Path: "/pets/{petId}"},
+	})
+	want := Coverage{Operations: 3, Exercised: 1, Unexercised: []string{"DELETE /pets/{petId}", "POST /pets"}}
+	if diff := pretty.Compare(got, want); diff != "" {
+		t.Errorf("want != got: %s", diff)
+	}
+}
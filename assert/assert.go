@@ -0,0 +1,375 @@
+/*
+Package assert checks decoded JSON values, such as response bodies, against
+compact assertions.
+
+An assertion is a JSON path, a matcher and the matcher's argument, separated
+by spaces:
+
+	$.id type integer
+	$.name matches ^[A-Z][a-z]+$
+	$.age range 0..120
+	$.owner is #/definitions/Owner
+	$.tags[*] type string
+
+Paths start at the value, "$", and select object properties with ".name" or
+"['name']", list elements with "[0]", and every property or element with
+".*" or "[*]". An assertion fails if its path selects nothing, and otherwise
+checks every value it selects. The matchers are:
+
+	type     the JSON type: string, number, integer, boolean, array, object
+	         or null
+	matches  a regular expression strings must match
+	range    an inclusive range of numbers, "min..max", where either end may
+	         be omitted
+	is       a reference to a definition the value must conform to
+
+Assertions are compiled once, by Compile, then checked against any number of
+values. Package contract checks them against the responses it receives.
+*/
+package assert
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/internal/conform"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// Assertion is a compiled assertion.
+type Assertion struct {
+	expr     string
+	pathExpr string
+	path     []segment
+	matcher  string
+
+	typ     string
+	re      *regexp.Regexp
+	min     *float64
+	max     *float64
+	ref     string
+	refName string
+}
+
+// segment is a step of a path: a property name, a list index, or every child
+// if wildcard is set.
+type segment struct {
+	name     string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+var types = []string{"array", "boolean", "integer", "null", "number", "object", "string"}
+
+// Compile parses an assertion.
+func Compile(expr string) (*Assertion, error) {
+	pathExpr, rest := cutPath(strings.TrimSpace(expr))
+	fields := strings.Fields(rest)
+	if pathExpr == "" || len(fields) < 2 {
+		return nil, fmt.Errorf("assert: %q: expected a path, matcher and argument", expr)
+	}
+	path, err := parsePath(pathExpr)
+	if err != nil {
+		return nil, fmt.Errorf("assert: %q: %v", expr, err)
+	}
+	a := &Assertion{expr: expr, pathExpr: pathExpr, path: path, matcher: fields[0]}
+	// The argument is the rest of the expression, which may hold spaces.
+	arg := strings.TrimSpace(strings.TrimSpace(rest)[len(fields[0]):])
+
+	switch a.matcher {
+	case "type":
+		if i := sort.SearchStrings(types, arg); i == len(types) || types[i] != arg {
+			return nil, fmt.Errorf("assert: %q: unknown type %q", expr, arg)
+		}
+		a.typ = arg
+	case "matches":
+		if a.re, err = regexp.Compile(arg); err != nil {
+			return nil, fmt.Errorf("assert: %q: %v", expr, err)
+		}
+	case "range":
+		if a.min, a.max, err = parseRange(arg); err != nil {
+			return nil, fmt.Errorf("assert: %q: %v", expr, err)
+		}
+	case "is":
+		if !strings.HasPrefix(arg, "#/definitions/") || len(arg) == len("#/definitions/") {
+			return nil, fmt.Errorf("assert: %q: expected a reference such as #/definitions/Pet", expr)
+		}
+		a.ref, a.refName = arg, strings.TrimPrefix(arg, "#/definitions/")
+	default:
+		return nil, fmt.Errorf("assert: %q: unknown matcher %q", expr, a.matcher)
+	}
+	return a, nil
+}
+
+// MustCompile is like Compile but panics if the assertion can't be parsed.
+func MustCompile(expr string) *Assertion {
+	a, err := Compile(expr)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+func (a *Assertion) String() string {
+	return a.expr
+}
+
+// cutPath splits an expression after its path: at the first space which isn't
+// within a quoted property name.
+func cutPath(expr string) (path, rest string) {
+	var quote byte
+	for i := 0; i < len(expr); i++ {
+		switch c := expr[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == ' ' || c == '\t':
+			return expr[:i], expr[i:]
+		}
+	}
+	return expr, ""
+}
+
+func parsePath(s string) ([]segment, error) {
+	if !strings.HasPrefix(s, "$") {
+		return nil, fmt.Errorf("path %q must start with $", s)
+	}
+	var path []segment
+	rest := s[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			n := strings.IndexAny(rest, ".[")
+			if n < 0 {
+				n = len(rest)
+			}
+			if n == 0 {
+				return nil, fmt.Errorf("path %q has an empty property name", s)
+			}
+			if rest[:n] == "*" {
+				path = append(path, segment{wildcard: true})
+			} else {
+				path = append(path, segment{name: rest[:n]})
+			}
+			rest = rest[n:]
+		case '[':
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("path %q has an unterminated [", s)
+			}
+			inner := rest[1:end]
+			switch {
+			case inner == "*":
+				path = append(path, segment{wildcard: true})
+			case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+				path = append(path, segment{name: inner[1 : len(inner)-1]})
+			default:
+				i, err := strconv.Atoi(inner)
+				if err != nil || i < 0 {
+					return nil, fmt.Errorf("path %q has an invalid index %q", s, inner)
+				}
+				path = append(path, segment{index: i, isIndex: true})
+			}
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("path %q: unexpected %q", s, rest[:1])
+		}
+	}
+	return path, nil
+}
+
+func parseRange(s string) (min, max *float64, err error) {
+	i := strings.Index(s, "..")
+	if i < 0 || s == ".." {
+		return nil, nil, fmt.Errorf("range %q must be min..max, min.. or ..max", s)
+	}
+	bound := func(b string) (*float64, error) {
+		if b == "" {
+			return nil, nil
+		}
+		f, err := strconv.ParseFloat(b, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bound %q in range %q", b, s)
+		}
+		return &f, nil
+	}
+	if min, err = bound(s[:i]); err != nil {
+		return nil, nil, err
+	}
+	if max, err = bound(s[i+2:]); err != nil {
+		return nil, nil, err
+	}
+	if min != nil && max != nil && *min > *max {
+		return nil, nil, fmt.Errorf("range %q is empty", s)
+	}
+	return min, max, nil
+}
+
+// selected is a value a path selects, and the path to it.
+type selected struct {
+	path  string
+	value interface{}
+}
+
+func (a *Assertion) selectValues(v interface{}) []selected {
+	values := []selected{{"$", v}}
+	for _, seg := range a.path {
+		var next []selected
+		for _, s := range values {
+			switch val := s.value.(type) {
+			case map[string]interface{}:
+				if seg.wildcard {
+					keys := make([]string, 0, len(val))
+					for k := range val {
+						keys = append(keys, k)
+					}
+					sort.Strings(keys)
+					for _, k := range keys {
+						next = append(next, selected{s.path + property(k), val[k]})
+					}
+				} else if elem, ok := val[seg.name]; ok && !seg.isIndex {
+					next = append(next, selected{s.path + property(seg.name), elem})
+				}
+			case []interface{}:
+				if seg.wildcard {
+					for i, elem := range val {
+						next = append(next, selected{s.path + "[" + strconv.Itoa(i) + "]", elem})
+					}
+				} else if seg.isIndex && seg.index < len(val) {
+					next = append(next, selected{s.path + "[" + strconv.Itoa(seg.index) + "]", val[seg.index]})
+				}
+			}
+		}
+		values = next
+	}
+	return values
+}
+
+// property returns the path segment selecting a property.
+func property(name string) string {
+	if name != "" && !strings.ContainsAny(name, ".[]'\" *") {
+		return "." + name
+	}
+	return "['" + name + "']"
+}
+
+// Check checks a decoded JSON value, returning a message for each failure
+// prefixed by the path of the offending value. The document resolves the
+// references of "is" matchers.
+func (a *Assertion) Check(doc *spec.Swagger, v interface{}) []string {
+	values := a.selectValues(v)
+	if len(values) == 0 {
+		return []string{a.pathExpr + ": not found"}
+	}
+	var msgs []string
+	for _, s := range values {
+		msgs = append(msgs, a.check(doc, s)...)
+	}
+	return msgs
+}
+
+func (a *Assertion) check(doc *spec.Swagger, s selected) []string {
+	fail := func(format string, args ...interface{}) []string {
+		return []string{s.path + ": " + fmt.Sprintf(format, args...)}
+	}
+	switch a.matcher {
+	case "type":
+		if !hasType(s.value, a.typ) {
+			return fail("expected type %s, got %s", a.typ, conform.Type(s.value))
+		}
+	case "matches":
+		str, ok := s.value.(string)
+		if !ok {
+			return fail("expected a string, got %s", conform.Type(s.value))
+		}
+		if !a.re.MatchString(str) {
+			return fail("%q does not match %s", str, a.re)
+		}
+	case "range":
+		f, ok := s.value.(float64)
+		if !ok {
+			return fail("expected a number, got %s", conform.Type(s.value))
+		}
+		if (a.min != nil && f < *a.min) || (a.max != nil && f > *a.max) {
+			return fail("%v is outside the range %s", f, rangeString(a.min, a.max))
+		}
+	case "is":
+		if doc == nil {
+			return fail("no document to resolve %s", a.ref)
+		}
+		if _, ok := doc.Definitions[a.refName]; !ok {
+			return fail("%s is not defined", a.ref)
+		}
+		var msgs []string
+		for _, msg := range conform.Value(doc, &spec.Schema{Ref: a.ref}, s.value, "") {
+			msgs = append(msgs, s.path+": "+strings.TrimPrefix(msg, ": "))
+		}
+		return msgs
+	}
+	return nil
+}
+
+func hasType(v interface{}, typ string) bool {
+	switch v := v.(type) {
+	case nil:
+		return typ == "null"
+	case map[string]interface{}:
+		return typ == "object"
+	case []interface{}:
+		return typ == "array"
+	case string:
+		return typ == "string"
+	case bool:
+		return typ == "boolean"
+	case float64:
+		return typ == "number" || (typ == "integer" && v == math.Trunc(v))
+	}
+	return false
+}
+
+func rangeString(min, max *float64) string {
+	var s string
+	if min != nil {
+		s = strconv.FormatFloat(*min, 'g', -1, 64)
+	}
+	s += ".."
+	if max != nil {
+		s += strconv.FormatFloat(*max, 'g', -1, 64)
+	}
+	return s
+}
+
+// Assertions is a list of compiled assertions.
+type Assertions []*Assertion
+
+// CompileAll compiles a list of assertions.
+func CompileAll(exprs []string) (Assertions, error) {
+	list := make(Assertions, 0, len(exprs))
+	for _, expr := range exprs {
+		a, err := Compile(expr)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, a)
+	}
+	return list, nil
+}
+
+// Check checks a value against every assertion, returning the failures of each
+// in order.
+func (l Assertions) Check(doc *spec.Swagger, v interface{}) []string {
+	var msgs []string
+	for _, a := range l {
+		msgs = append(msgs, a.Check(doc, v)...)
+	}
+	return msgs
+}
@@ -0,0 +1,89 @@
+package assert
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+const doc = `
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+paths: {}
+definitions:
+  Owner:
+    type: object
+    required: [name]
+    properties:
+      name: {type: string}
+`
+
+func TestCheck(t *testing.T) {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(doc), &s); err != nil {
+		t.Fatal(err)
+	}
+	body := `{"id": 7, "name": "Rex", "age": 3.5, "tags": ["a", 2], "owner": {"id": 1}, "odd key": true}`
+	var v interface{}
+	if err := json.Unmarshal([]byte(body), &v); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{"$.id type integer", nil},
+		{"$.age type integer", []string{"$.age: expected type integer, got a number"}},
+		{"$ type object", nil},
+		{"$['odd key'] type boolean", nil},
+		{"$.name matches ^[A-Z][a-z]+$", nil},
+		{"$.name matches ^r", []string{`$.name: "Rex" does not match ^r`}},
+		{"$.id matches \\d", []string{"$.id: expected a string, got an integer"}},
+		{"$.id range 1..10", nil},
+		{"$.age range ..3", []string{"$.age: 3.5 is outside the range ..3"}},
+		{"$.id range 8..", []string{"$.id: 7 is outside the range 8.."}},
+		{"$.tags[0] type string", nil},
+		{"$.tags[*] type string", []string{"$.tags[1]: expected type string, got an integer"}},
+		{"$.owner.* type integer", nil},
+		{"$.missing type string", []string{"$.missing: not found"}},
+		{"$.tags[5] type string", []string{"$.tags[5]: not found"}},
+		{"$.owner is #/definitions/Owner", []string{`$.owner: missing required property "name"`}},
+		{"$.owner is #/definitions/Pet", []string{"$.owner: #/definitions/Pet is not defined"}},
+	}
+	for _, tc := range tests {
+		a, err := Compile(tc.expr)
+		if err != nil {
+			t.Errorf("%s: %v", tc.expr, err)
+			continue
+		}
+		if diff := pretty.Compare(tc.want, a.Check(&s, v)); diff != "" {
+			t.Errorf("%s: want != got: %s", tc.expr, diff)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, expr := range []string{
+		"$.id type",
+		"id type integer",
+		"$.id type int",
+		"$.id equals 1",
+		"$.name matches [",
+		"$.id range 1",
+		"$.id range 5..1",
+		"$.id range a..",
+		"$.owner is Owner",
+		"$.tags[x] type string",
+		"$.tags[0 type string",
+		"$..id type integer",
+	} {
+		if _, err := Compile(expr); err == nil {
+			t.Errorf("%s: expected an error", expr)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+/*
+Package asyncapi defines Go mappings for version 2 of the AsyncAPI
+Specification, which describes event-driven APIs.
+
+https://www.asyncapi.com/docs/reference/specification/v2.6.0
+
+Objects which are the same as OpenAPI 3.0's are shared with package spec3.
+Schemas are OpenAPI 3.0 Schema Objects, which the AsyncAPI Schema Object is a
+superset of.
+*/
+package asyncapi
+
+import "github.com/ericchiang/swaggopher/spec3"
+
+// This is the root document object of the AsyncAPI document.
+type AsyncAPI struct {
+	// The semantic version number of the AsyncAPI Specification the document
+	// uses, such as "2.6.0".
+	AsyncAPI string `json:"asyncapi" yaml:"asyncapi"`
+	// Identifier of the application the AsyncAPI document is defining, in the
+	// form of a URI.
+	Id string `json:"id,omitempty" yaml:"id,omitempty"`
+	// Provides metadata about the API. The metadata can be used by the clients if
+	// needed.
+	Info *spec3.Info `json:"info" yaml:"info"`
+	// Provides connection details of servers, by name.
+	Servers map[string]Server `json:"servers,omitempty" yaml:"servers,omitempty"`
+	// Default content type to use when encoding/decoding a message's payload.
+	DefaultContentType string `json:"defaultContentType,omitempty" yaml:"defaultContentType,omitempty"`
+	// The available channels and messages for the API.
+	Channels Channels `json:"channels" yaml:"channels"`
+	// An element to hold various schemas for the specification.
+	Components *Components `json:"components,omitempty" yaml:"components,omitempty"`
+	// A list of tags used by the specification with additional metadata.
+	Tags []spec3.Tag `json:"tags,omitempty" yaml:"tags,omitempty"`
+	// Additional external documentation.
+	ExternalDocs *spec3.ExternalDocumentation `json:"externalDocs,omitempty" yaml:"externalDocs,omitempty"`
+}
+
+// An object representing a message broker, a server or any other kind of
+// computer program capable of sending and/or receiving data.
+type Server struct {
+	// A URL to the target host, which supports Server Variables.
+	Url string `json:"url" yaml:"url"`
+	// The protocol this URL supports for connection, such as "http", "kafka" or
+	// "mqtt".
+	Protocol string `json:"protocol" yaml:"protocol"`
+	// The version of the protocol used for connection.
+	ProtocolVersion string `json:"protocolVersion,omitempty" yaml:"protocolVersion,omitempty"`
+	// An optional string describing the host designated by the URL.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// A map between a variable name and its value, used for substitution in the
+	// server's URL template.
+	Variables map[string]spec3.ServerVariable `json:"variables,omitempty" yaml:"variables,omitempty"`
+	// A declaration of which security mechanisms can be used with this server.
+	Security []spec3.SecurityRequirement `json:"security,omitempty" yaml:"security,omitempty"`
+	// A list of tags for logical grouping and categorization of servers.
+	Tags []spec3.Tag `json:"tags,omitempty" yaml:"tags,omitempty"`
+}
+
+// Holds the relative paths to the individual channels and their operations.
+type Channels map[string]ChannelItem
+
+// Describes the operations available on a single channel.
+type ChannelItem struct {
+	// Allows for an external definition of this channel item.
+	Ref string `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	// An optional description of this channel item.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// The names of the servers this channel is available on. If omitted, it's
+	// available on all servers.
+	Servers []string `json:"servers,omitempty" yaml:"servers,omitempty"`
+	// A definition of the SUBSCRIBE operation, which defines the messages
+	// produced by the application and sent to the channel.
+	Subscribe *Operation `json:"subscribe,omitempty" yaml:"subscribe,omitempty"`
+	// A definition of the PUBLISH operation, which defines the messages consumed
+	// by the application from the channel.
+	Publish *Operation `json:"publish,omitempty" yaml:"publish,omitempty"`
+	// A map of the parameters included in the channel name, without the curly
+	// braces.
+	Parameters map[string]Parameter `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+}
+
+// Describes a publish or a subscribe operation.
+type Operation struct {
+	// Unique string used to identify the operation.
+	OperationId string `json:"operationId,omitempty" yaml:"operationId,omitempty"`
+	// A short summary of what the operation is about.
+	Summary string `json:"summary,omitempty" yaml:"summary,omitempty"`
+	// A verbose explanation of the operation. CommonMark syntax can be used for
+	// rich text representation.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// A declaration of which security mechanisms are associated with this
+	// operation.
+	Security []spec3.SecurityRequirement `json:"security,omitempty" yaml:"security,omitempty"`
+	// A list of tags for API documentation control.
+	Tags []spec3.Tag `json:"tags,omitempty" yaml:"tags,omitempty"`
+	// Additional external documentation for this operation.
+	ExternalDocs *spec3.ExternalDocumentation `json:"externalDocs,omitempty" yaml:"externalDocs,omitempty"`
+	// A definition of the message that will be published or received by this
+	// operation.
+	Message *Message `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+// Describes a parameter included in a channel name.
+type Parameter struct {
+	// A reference to a parameter defined in components.
+	Ref string `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	// A verbose explanation of the parameter.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// Definition of the parameter.
+	Schema *spec3.Schema `json:"schema,omitempty" yaml:"schema,omitempty"`
+	// A runtime expression that specifies the location of the parameter value.
+	Location string `json:"location,omitempty" yaml:"location,omitempty"`
+}
+
+// Describes a message received on a given channel and operation.
+type Message struct {
+	// A reference to a message defined in components.
+	Ref string `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	// Unique string used to identify the message.
+	MessageId string `json:"messageId,omitempty" yaml:"messageId,omitempty"`
+	// Schema definition of the application headers. Schema MUST be of type
+	// "object".
+	Headers *spec3.Schema `json:"headers,omitempty" yaml:"headers,omitempty"`
+	// Definition of the message payload.
+	Payload *spec3.Schema `json:"payload,omitempty" yaml:"payload,omitempty"`
+	// Definition of the correlation ID used for message tracing or matching.
+	CorrelationId *CorrelationId `json:"correlationId,omitempty" yaml:"correlationId,omitempty"`
+	// A string containing the name of the schema format used to define the
+	// message payload. If omitted, it's the AsyncAPI Schema format.
+	SchemaFormat string `json:"schemaFormat,omitempty" yaml:"schemaFormat,omitempty"`
+	// The content type to use when encoding/decoding a message's payload, such as
+	// "application/json". If omitted, it's the document's defaultContentType.
+	ContentType string `json:"contentType,omitempty" yaml:"contentType,omitempty"`
+	// A machine-friendly name for the message.
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// A human-friendly title for the message.
+	Title string `json:"title,omitempty" yaml:"title,omitempty"`
+	// A short summary of what the message is about.
+	Summary string `json:"summary,omitempty" yaml:"summary,omitempty"`
+	// A verbose explanation of the message.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// A list of tags for API documentation control.
+	Tags []spec3.Tag `json:"tags,omitempty" yaml:"tags,omitempty"`
+	// Additional external documentation for this message.
+	ExternalDocs *spec3.ExternalDocumentation `json:"externalDocs,omitempty" yaml:"externalDocs,omitempty"`
+	// List of examples.
+	Examples []MessageExample `json:"examples,omitempty" yaml:"examples,omitempty"`
+	// Alternative messages, of which the operation sends or receives one.
+	OneOf []Message `json:"oneOf,omitempty" yaml:"oneOf,omitempty"`
+}
+
+// An object that specifies an identifier at design time that can be used for
+// message tracing and correlation.
+type CorrelationId struct {
+	// An optional description of the identifier.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// A runtime expression that specifies the location of the correlation ID.
+	Location string `json:"location" yaml:"location"`
+}
+
+// Message Example Object represents an example of a Message Object.
+type MessageExample struct {
+	// The value of the example's headers.
+	Headers map[string]interface{} `json:"headers,omitempty" yaml:"headers,omitempty"`
+	// The value of the example's payload.
+	Payload interface{} `json:"payload,omitempty" yaml:"payload,omitempty"`
+	// A machine-friendly name.
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// A short summary of what the example is about.
+	Summary string `json:"summary,omitempty" yaml:"summary,omitempty"`
+}
+
+// Holds a set of reusable objects for different aspects of the AsyncAPI
+// specification.
+type Components struct {
+	// An object to hold reusable Schema Objects.
+	Schemas map[string]spec3.Schema `json:"schemas,omitempty" yaml:"schemas,omitempty"`
+	// An object to hold reusable Message Objects.
+	Messages map[string]Message `json:"messages,omitempty" yaml:"messages,omitempty"`
+	// An object to hold reusable Security Scheme Objects.
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty" yaml:"securitySchemes,omitempty"`
+	// An object to hold reusable Parameter Objects.
+	Parameters map[string]Parameter `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	// An object to hold reusable Correlation ID Objects.
+	CorrelationIds map[string]CorrelationId `json:"correlationIds,omitempty" yaml:"correlationIds,omitempty"`
+}
+
+// Defines a security scheme that can be used by the operations.
+type SecurityScheme struct {
+	// The type of the security scheme. Valid values include "userPassword",
+	// "apiKey", "X509", "httpApiKey", "http", "oauth2" and "openIdConnect".
+	Type string `json:"type" yaml:"type"`
+	// A short description for security scheme.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// The name of the header, query or cookie parameter to be used. Applies to
+	// "httpApiKey".
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// The location of the API key. Valid values are "user" and "password" for
+	// "apiKey" and "query", "header" or "cookie" for "httpApiKey".
+	In string `json:"in,omitempty" yaml:"in,omitempty"`
+	// The name of the HTTP Authorization scheme to be used in the Authorization
+	// header as defined in RFC7235. Applies to "http".
+	Scheme string `json:"scheme,omitempty" yaml:"scheme,omitempty"`
+	// A hint to the client to identify how the bearer token is formatted. Applies
+	// to "http".
+	BearerFormat string `json:"bearerFormat,omitempty" yaml:"bearerFormat,omitempty"`
+	// An object containing configuration information for the flow types
+	// supported. Applies to "oauth2".
+	Flows *spec3.OAuthFlows `json:"flows,omitempty" yaml:"flows,omitempty"`
+	// OpenId Connect URL to discover OAuth2 configuration values. Applies to
+	// "openIdConnect".
+	OpenIdConnectUrl string `json:"openIdConnectUrl,omitempty" yaml:"openIdConnectUrl,omitempty"`
+}
@@ -0,0 +1,89 @@
+package asyncapi
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/ericchiang/swaggopher/spec3"
+)
+
+func TestParse(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/streetlights.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got AsyncAPI
+	if err := yaml.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	zero := 0.0
+	want := AsyncAPI{
+		AsyncAPI: "2.6.0",
+		Info:     &spec3.Info{Title: "Streetlights API", Version: "1.0.0"},
+		Servers: map[string]Server{
+			"production": {
+				Url:      "api.streetlights.example.com:{port}",
+				Protocol: "mqtt",
+				Variables: map[string]spec3.ServerVariable{
+					"port": {Enum: []string{"1883", "8883"}, Default: "1883"},
+				},
+				Security: []spec3.SecurityRequirement{{"apiKey": {}}},
+			},
+		},
+		DefaultContentType: "application/json",
+		Channels: Channels{
+			"light/measured/{streetlightId}": {
+				Parameters: map[string]Parameter{
+					"streetlightId": {Description: "The ID of the streetlight.", Schema: &spec3.Schema{Type: "string"}},
+				},
+				Subscribe: &Operation{
+					OperationId: "onLightMeasured",
+					Summary:     "Inform about environmental lighting conditions.",
+					Message:     &Message{Ref: "#/components/messages/lightMeasured"},
+				},
+			},
+		},
+		Components: &Components{
+			Messages: map[string]Message{
+				"lightMeasured": {
+					Name:     "lightMeasured",
+					Title:    "Light measured",
+					Payload:  &spec3.Schema{Ref: "#/components/schemas/lightMeasuredPayload"},
+					Examples: []MessageExample{{Name: "dim", Payload: map[interface{}]interface{}{"lumens": 3}}},
+				},
+			},
+			Schemas: map[string]spec3.Schema{
+				"lightMeasuredPayload": {
+					Type: "object",
+					Properties: map[string]spec3.Schema{
+						"lumens": {Type: "integer", Minimum: &zero},
+					},
+				},
+			},
+			SecuritySchemes: map[string]SecurityScheme{
+				"apiKey": {Type: "apiKey", In: "user"},
+			},
+		},
+	}
+	if diff := pretty.Compare(got, want); diff != "" {
+		t.Errorf("want != got: %s", diff)
+	}
+
+	out, err := json.Marshal(got.Channels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var again Channels
+	if err := json.Unmarshal(out, &again); err != nil {
+		t.Fatal(err)
+	}
+	if diff := pretty.Compare(again, want.Channels); diff != "" {
+		t.Errorf("json round trip: want != got: %s", diff)
+	}
+}
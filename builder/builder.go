@@ -0,0 +1,426 @@
+/*
+Package builder constructs documents with chained calls, rather than nested
+spec literals:
+
+	doc, err := builder.New("Petstore", "1.0").
+		Host("pets.example.com").
+		BasePath("/v1").
+		Path("/pets/{petId}", func(p *builder.Path) {
+			p.Get(func(op *builder.Operation) {
+				op.ID("getPet").
+					Summary("Get a pet.").
+					Response(200, "A pet.", builder.Ref("Pet")).
+					Response(404, "", nil)
+			})
+		}).
+		Definition("Pet", builder.Object(
+			builder.Required("id", builder.Integer()),
+			builder.Optional("name", builder.String()),
+		)).
+		Build()
+
+Builders fill in what the specification requires but is easily forgotten: the
+"swagger" version, a path parameter of type string for each variable of a path
+template which no parameter declares, a 200 response for operations without
+any, and descriptions of responses, which default to their status text. Build
+validates the result, so a document it returns passes ValidateDocument and
+ValidateSemantics of package validate.
+*/
+package builder
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/spec"
+	"github.com/ericchiang/swaggopher/validate"
+)
+
+// Builder constructs a document.
+type Builder struct {
+	doc  *spec.Swagger
+	errs []string
+}
+
+// New returns a builder for a document describing an API with a title and
+// version.
+func New(title, version string) *Builder {
+	return &Builder{doc: &spec.Swagger{
+		Swagger: "2.0",
+		Info:    &spec.Info{Title: title, Version: version},
+		Paths:   spec.Paths{},
+	}}
+}
+
+func (b *Builder) errorf(format string, args ...interface{}) {
+	b.errs = append(b.errs, fmt.Sprintf(format, args...))
+}
+
+// Description sets the description of the API.
+func (b *Builder) Description(description string) *Builder {
+	b.doc.Info.Description = description
+	return b
+}
+
+// Host sets the host, and optionally the port, serving the API.
+func (b *Builder) Host(host string) *Builder {
+	b.doc.Host = host
+	return b
+}
+
+// BasePath sets the path the API's paths are relative to.
+func (b *Builder) BasePath(basePath string) *Builder {
+	b.doc.BasePath = basePath
+	return b
+}
+
+// Schemes sets the transfer protocols of the API, such as "https".
+func (b *Builder) Schemes(schemes ...string) *Builder {
+	b.doc.Schemes = schemes
+	return b
+}
+
+// Consumes sets the media types the API's operations consume by default.
+func (b *Builder) Consumes(mediaTypes ...string) *Builder {
+	b.doc.Consumes = mediaTypes
+	return b
+}
+
+// Produces sets the media types the API's operations produce by default.
+func (b *Builder) Produces(mediaTypes ...string) *Builder {
+	b.doc.Produces = mediaTypes
+	return b
+}
+
+// Tag declares a tag used to group operations.
+func (b *Builder) Tag(name, description string) *Builder {
+	b.doc.Tags = append(b.doc.Tags, spec.Tag{Name: name, Description: description})
+	return b
+}
+
+// SecurityDefinition declares a security scheme operations may require.
+func (b *Builder) SecurityDefinition(name string, scheme spec.SecurityScheme) *Builder {
+	if _, ok := b.doc.SecurityDefinitions[name]; ok {
+		b.errorf("security definition %s is declared twice", name)
+		return b
+	}
+	if b.doc.SecurityDefinitions == nil {
+		b.doc.SecurityDefinitions = spec.SecurityDefinitions{}
+	}
+	b.doc.SecurityDefinitions[name] = scheme
+	return b
+}
+
+// Security requires a security scheme, with the given scopes, for every
+// operation which doesn't declare its own requirements.
+func (b *Builder) Security(name string, scopes ...string) *Builder {
+	b.doc.Security = append(b.doc.Security, requirement(name, scopes))
+	return b
+}
+
+// Definition declares a schema which others may refer to with Ref.
+func (b *Builder) Definition(name string, schema *spec.Schema) *Builder {
+	if _, ok := b.doc.Definitions[name]; ok {
+		b.errorf("definition %s is declared twice", name)
+		return b
+	}
+	if b.doc.Definitions == nil {
+		b.doc.Definitions = spec.Definitions{}
+	}
+	b.doc.Definitions[name] = *schema
+	return b
+}
+
+// Path declares a path, calling f to declare its operations.
+func (b *Builder) Path(path string, f func(p *Path)) *Builder {
+	if _, ok := b.doc.Paths[path]; ok {
+		b.errorf("path %s is declared twice", path)
+		return b
+	}
+	p := &Path{b: b, path: path, item: &spec.PathItem{}}
+	f(p)
+	b.doc.Paths[path] = *p.item
+	return b
+}
+
+// Build returns the document, or an error describing every problem with it.
+// The builder shouldn't be used afterwards.
+func (b *Builder) Build() (*spec.Swagger, error) {
+	for path, item := range b.doc.Paths {
+		pathParameters(path, &item)
+		b.doc.Paths[path] = item
+	}
+	problems := b.errs
+	for _, err := range validate.ValidateDocument(b.doc) {
+		problems = append(problems, err.Error())
+	}
+	for _, err := range validate.ValidateSemantics(b.doc) {
+		problems = append(problems, err.Error())
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("builder: %s", strings.Join(problems, "; "))
+	}
+	return b.doc, nil
+}
+
+var pathVariable = regexp.MustCompile(`\{([^{}/]+)\}`)
+
+// pathParameters declares the variables of a path template which no
+// parameter of the path item, or of one of its operations, declares.
+func pathParameters(path string, item *spec.PathItem) {
+	declared := func(params []spec.Parameter, name string) bool {
+		for _, p := range params {
+			if p.In == "path" && p.Name == name {
+				return true
+			}
+		}
+		return false
+	}
+	for _, m := range pathVariable.FindAllStringSubmatch(path, -1) {
+		name := m[1]
+		if declared(item.Parameters, name) {
+			continue
+		}
+		missing := !item.RangeOperations(func(_ string, op *spec.Operation) bool {
+			return declared(op.Parameters, name)
+		})
+		if missing {
+			item.Parameters = append(item.Parameters, spec.Parameter{Name: name, In: "path", Required: true, Type: "string"})
+		}
+	}
+}
+
+func requirement(name string, scopes []string) spec.SecurityRequirement {
+	if scopes == nil {
+		scopes = []string{}
+	}
+	return spec.SecurityRequirement{name: scopes}
+}
+
+// Path declares the operations of a path.
+type Path struct {
+	b    *Builder
+	path string
+	item *spec.PathItem
+}
+
+// Parameter declares a parameter shared by the path's operations.
+func (p *Path) Parameter(param spec.Parameter) *Path {
+	p.item.Parameters = append(p.item.Parameters, param)
+	return p
+}
+
+// Get declares the GET operation of the path, calling f to describe it.
+func (p *Path) Get(f func(op *Operation)) *Path { return p.operation("get", &p.item.Get, f) }
+
+// Put declares the PUT operation of the path, calling f to describe it.
+func (p *Path) Put(f func(op *Operation)) *Path { return p.operation("put", &p.item.Put, f) }
+
+// Post declares the POST operation of the path, calling f to describe it.
+func (p *Path) Post(f func(op *Operation)) *Path { return p.operation("post", &p.item.Post, f) }
+
+// Delete declares the DELETE operation of the path, calling f to describe it.
+func (p *Path) Delete(f func(op *Operation)) *Path { return p.operation("delete", &p.item.Delete, f) }
+
+// Options declares the OPTIONS operation of the path, calling f to describe
+// it.
+func (p *Path) Options(f func(op *Operation)) *Path {
+	return p.operation("options", &p.item.Options, f)
+}
+
+// Head declares the HEAD operation of the path, calling f to describe it.
+func (p *Path) Head(f func(op *Operation)) *Path { return p.operation("head", &p.item.Head, f) }
+
+// Patch declares the PATCH operation of the path, calling f to describe it.
+func (p *Path) Patch(f func(op *Operation)) *Path { return p.operation("patch", &p.item.Patch, f) }
+
+func (p *Path) operation(method string, dst **spec.Operation, f func(op *Operation)) *Path {
+	if *dst != nil {
+		p.b.errorf("%s %s is declared twice", strings.ToUpper(method), p.path)
+		return p
+	}
+	op := &Operation{op: &spec.Operation{}}
+	f(op)
+	if len(op.op.Responses) == 0 {
+		op.Response(http.StatusOK, "", nil)
+	}
+	*dst = op.op
+	return p
+}
+
+// Operation describes an operation.
+type Operation struct {
+	op *spec.Operation
+}
+
+// ID sets the operation's operationId.
+func (o *Operation) ID(id string) *Operation {
+	o.op.OperationId = id
+	return o
+}
+
+// Summary sets a short summary of what the operation does.
+func (o *Operation) Summary(summary string) *Operation {
+	o.op.Summary = summary
+	return o
+}
+
+// Description sets a longer description of the operation.
+func (o *Operation) Description(description string) *Operation {
+	o.op.Description = description
+	return o
+}
+
+// Tags adds tags to the operation.
+func (o *Operation) Tags(tags ...string) *Operation {
+	o.op.Tags = append(o.op.Tags, tags...)
+	return o
+}
+
+// Deprecated marks the operation as deprecated.
+func (o *Operation) Deprecated() *Operation {
+	o.op.Deprecated = true
+	return o
+}
+
+// Consumes sets the media types the operation consumes.
+func (o *Operation) Consumes(mediaTypes ...string) *Operation {
+	o.op.Consumes = mediaTypes
+	return o
+}
+
+// Produces sets the media types the operation produces.
+func (o *Operation) Produces(mediaTypes ...string) *Operation {
+	o.op.Produces = mediaTypes
+	return o
+}
+
+// Security requires a security scheme, with the given scopes, for the
+// operation. Call NoSecurity to exempt it from the document's requirements.
+func (o *Operation) Security(name string, scopes ...string) *Operation {
+	o.op.Security = append(o.op.Security, requirement(name, scopes))
+	return o
+}
+
+// NoSecurity exempts the operation from the document's security requirements.
+func (o *Operation) NoSecurity() *Operation {
+	o.op.Security = []spec.SecurityRequirement{}
+	return o
+}
+
+// Parameter declares a parameter of the operation.
+func (o *Operation) Parameter(p spec.Parameter) *Operation {
+	o.op.Parameters = append(o.op.Parameters, p)
+	return o
+}
+
+// PathParam declares a path parameter of a primitive type, such as "integer".
+func (o *Operation) PathParam(name, typ string) *Operation {
+	return o.Parameter(spec.Parameter{Name: name, In: "path", Required: true, Type: typ})
+}
+
+// Query declares a query parameter of a primitive type.
+func (o *Operation) Query(name, typ string, required bool) *Operation {
+	return o.Parameter(spec.Parameter{Name: name, In: "query", Required: required, Type: typ})
+}
+
+// Header declares a header parameter of a primitive type.
+func (o *Operation) Header(name, typ string, required bool) *Operation {
+	return o.Parameter(spec.Parameter{Name: name, In: "header", Required: required, Type: typ})
+}
+
+// Body declares the required body parameter of the operation.
+func (o *Operation) Body(name string, schema *spec.Schema) *Operation {
+	return o.Parameter(spec.Parameter{Name: name, In: "body", Required: true, Schema: schema})
+}
+
+// Response declares the response with a status code. If description is empty,
+// the status text is used, and schema may be nil for responses without a
+// body.
+func (o *Operation) Response(code int, description string, schema *spec.Schema) *Operation {
+	if description == "" {
+		description = http.StatusText(code)
+	}
+	return o.response(strconv.Itoa(code), description, schema)
+}
+
+// Default declares the response for statuses without their own.
+func (o *Operation) Default(description string, schema *spec.Schema) *Operation {
+	if description == "" {
+		description = "Unexpected error."
+	}
+	return o.response("default", description, schema)
+}
+
+func (o *Operation) response(code, description string, schema *spec.Schema) *Operation {
+	if o.op.Responses == nil {
+		o.op.Responses = spec.Responses{}
+	}
+	o.op.Responses[code] = spec.Response{Description: description, Schema: schema}
+	return o
+}
+
+// String returns a string schema.
+func String() *spec.Schema { return &spec.Schema{Type: "string"} }
+
+// Integer returns an integer schema.
+func Integer() *spec.Schema { return &spec.Schema{Type: "integer"} }
+
+// Number returns a number schema.
+func Number() *spec.Schema { return &spec.Schema{Type: "number"} }
+
+// Boolean returns a boolean schema.
+func Boolean() *spec.Schema { return &spec.Schema{Type: "boolean"} }
+
+// Format returns a copy of a schema with a format, such as "int64" or
+// "date-time".
+func Format(s *spec.Schema, format string) *spec.Schema {
+	c := *s
+	c.Format = format
+	return &c
+}
+
+// ArrayOf returns an array schema whose items match items.
+func ArrayOf(items *spec.Schema) *spec.Schema {
+	return &spec.Schema{Type: "array", Items: items}
+}
+
+// Ref returns a schema referring to a definition.
+func Ref(definition string) *spec.Schema {
+	return &spec.Schema{Ref: "#/definitions/" + definition}
+}
+
+// Property is a property of an object schema.
+type Property struct {
+	Name     string
+	Schema   *spec.Schema
+	Required bool
+}
+
+// Required returns a property which objects must have.
+func Required(name string, schema *spec.Schema) Property {
+	return Property{Name: name, Schema: schema, Required: true}
+}
+
+// Optional returns a property which objects may have.
+func Optional(name string, schema *spec.Schema) Property {
+	return Property{Name: name, Schema: schema}
+}
+
+// Object returns an object schema with the given properties.
+func Object(props ...Property) *spec.Schema {
+	s := &spec.Schema{Type: "object"}
+	for _, p := range props {
+		if s.Properties == nil {
+			s.Properties = map[string]spec.Schema{}
+		}
+		s.Properties[p.Name] = *p.Schema
+		if p.Required {
+			s.Required = append(s.Required, p.Name)
+		}
+	}
+	return s
+}
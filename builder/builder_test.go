@@ -0,0 +1,117 @@
+package builder
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+func TestBuild(t *testing.T) {
+	got, err := New("Petstore", "1.0").
+		Host("pets.example.com").
+		BasePath("/v1").
+		Produces("application/json").
+		SecurityDefinition("key", spec.SecurityScheme{Type: "apiKey", Name: "X-Key", In: "header"}).
+		Security("key").
+		Path("/pets", func(p *Path) {
+			p.Get(func(op *Operation) {
+				op.ID("listPets").
+					Query("limit", "integer", false).
+					Response(200, "The pets.", ArrayOf(Ref("Pet")))
+			}).Post(func(op *Operation) {
+				op.ID("createPet").
+					Body("pet", Ref("Pet")).
+					Response(201, "", Ref("Pet"))
+			})
+		}).
+		Path("/pets/{petId}", func(p *Path) {
+			p.Get(func(op *Operation) {
+				op.ID("getPet").NoSecurity()
+			})
+		}).
+		Definition("Pet", Object(
+			Required("id", Format(Integer(), "int64")),
+			Optional("name", String()),
+		)).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var want spec.Swagger
+	if err := yaml.Unmarshal([]byte(`
+swagger: "2.0"
+info: {title: Petstore, version: "1.0"}
+host: pets.example.com
+basePath: /v1
+produces: [application/json]
+securityDefinitions:
+  key: {type: apiKey, name: X-Key, in: header}
+security:
+- key: []
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      parameters:
+      - {name: limit, in: query, type: integer}
+      responses:
+        200:
+          description: The pets.
+          schema: {type: array, items: {$ref: '#/definitions/Pet'}}
+    post:
+      operationId: createPet
+      parameters:
+      - {name: pet, in: body, required: true, schema: {$ref: '#/definitions/Pet'}}
+      responses:
+        201: {description: Created, schema: {$ref: '#/definitions/Pet'}}
+  /pets/{petId}:
+    parameters:
+    - {name: petId, in: path, required: true, type: string}
+    get:
+      operationId: getPet
+      security: []
+      responses:
+        200: {description: OK}
+definitions:
+  Pet:
+    type: object
+    required: [id]
+    properties:
+      id: {type: integer, format: int64}
+      name: {type: string}
+`), &want); err != nil {
+		t.Fatal(err)
+	}
+	if diff := pretty.Compare(&want, got); diff != "" {
+		t.Errorf("want != got: %s", diff)
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	_, err := New("Petstore", "1.0").
+		Path("/pets", func(p *Path) {
+			p.Get(func(op *Operation) {
+				op.ID("listPets").Response(200, "", ArrayOf(Ref("Pet")))
+			}).Get(func(op *Operation) {})
+		}).
+		Path("/pets", func(p *Path) {}).
+		Build()
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{
+		"GET /pets is declared twice",
+		"path /pets is declared twice",
+		"/paths/~1pets/get/responses/200/schema/items/$ref: ",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q doesn't contain %q", err, want)
+		}
+	}
+}
@@ -0,0 +1,381 @@
+/*
+Package bundle combines a document split across several files into a single,
+self-contained document.
+
+Unlike resolver.Resolve, which replaces every reference with a copy of its
+target, Bundle keeps schemas, parameters and responses shared: each one
+referenced from another file is copied into the root document's definitions,
+parameters or responses once, and references to it are rewritten to point
+there. Path items, which have no such section, are copied in place.
+
+Copied values are named after the last token of the reference, such as
+"Error" for "common.yaml#/definitions/Error", or the file's name for
+references to a whole file. A name already used by the root document or by
+another file's value is given a numeric suffix, such as "Error2".
+*/
+package bundle
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/internal/jsonpointer"
+	"github.com/ericchiang/swaggopher/internal/logutil"
+	"github.com/ericchiang/swaggopher/internal/mapkeys"
+	"github.com/ericchiang/swaggopher/internal/refs"
+	"github.com/ericchiang/swaggopher/resolver"
+	"github.com/ericchiang/swaggopher/runtime"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// Options configures how documents are bundled.
+type Options struct {
+	// Loader fetches the root document and those it refers to. It defaults to
+	// resolver.DefaultLoader.
+	Loader runtime.Loader
+	// Logger, if set, receives a line for each document loaded and each value
+	// copied into the root document.
+	Logger spec.Logger
+	// Progress, if non-nil, is called after each definition, parameter,
+	// response and path of the root document is bundled.
+	Progress spec.ProgressFunc
+	// DryRun loads every document and reports the values which would be
+	// copied to the Logger, but returns the root document as it was loaded.
+	DryRun bool
+}
+
+// Bundle loads the document at root, a file path or URL, along with every
+// document it refers to, and returns a single document with only local
+// references.
+func Bundle(root string) (*spec.Swagger, error) {
+	return Options{}.Bundle(root)
+}
+
+// Bundle loads the document at root, a file path or URL, along with every
+// document it refers to, and returns a single document with only local
+// references.
+func (o Options) Bundle(root string) (*spec.Swagger, error) {
+	b := &bundler{
+		load:     o.Loader,
+		logger:   o.Logger,
+		progress: o.Progress,
+		dryRun:   o.DryRun,
+		root:     root,
+		names:    make(map[string]string),
+		taken:    make(map[string]bool),
+	}
+	if b.load == nil {
+		b.load = resolver.Loader(resolver.DefaultLoader)
+	}
+	b.docs = refs.Documents{Load: b.load.Load, Logger: b.logger, Prefix: "bundle"}
+	if !refs.IsURL(root) {
+		b.root = filepath.Clean(root)
+	}
+	raw, err := b.docs.Fetch(b.root)
+	if err != nil {
+		return nil, fmt.Errorf("bundle: %v", err)
+	}
+	doc := new(spec.Swagger)
+	if err := refs.Convert(raw, doc); err != nil {
+		return nil, fmt.Errorf("bundle: %s: %v", root, err)
+	}
+	b.doc = doc
+	if err := b.document(); err != nil {
+		return nil, fmt.Errorf("bundle: %v", err)
+	}
+	if o.DryRun {
+		loaded := new(spec.Swagger)
+		if err := refs.Convert(raw, loaded); err != nil {
+			return nil, fmt.Errorf("bundle: %s: %v", root, err)
+		}
+		return loaded, nil
+	}
+	return doc, nil
+}
+
+type bundler struct {
+	load     runtime.Loader
+	logger   spec.Logger
+	progress spec.ProgressFunc
+	dryRun   bool
+	root     string
+	doc      *spec.Swagger
+	// docs caches decoded documents by location.
+	docs refs.Documents
+	// names holds the names values from other files were copied under, keyed
+	// by the section of the root document they were copied into and their
+	// location and fragment.
+	names map[string]string
+	// taken holds the names used in each section, keyed by the section and
+	// name.
+	taken map[string]bool
+}
+
+func (b *bundler) document() error {
+	doc := b.doc
+	// Names are reserved first, so values copied from other files never
+	// replace the root document's own. They're then visited in order, so
+	// copies are named the same way every time.
+	defs := mapkeys.Sorted(doc.Definitions)
+	params := mapkeys.Sorted(doc.Parameters)
+	resps := mapkeys.Sorted(doc.Responses)
+	for _, name := range defs {
+		b.taken["definitions "+name] = true
+	}
+	for _, name := range params {
+		b.taken["parameters "+name] = true
+	}
+	for _, name := range resps {
+		b.taken["responses "+name] = true
+	}
+	paths := mapkeys.Sorted(doc.Paths)
+
+	done, total := 0, len(defs)+len(params)+len(resps)+len(paths)
+	progress := func(item string) {
+		done++
+		if b.progress != nil {
+			b.progress(done, total, item)
+		}
+	}
+	for _, name := range defs {
+		s := doc.Definitions[name]
+		if err := b.schema(b.root, &s); err != nil {
+			return fmt.Errorf("definitions %s: %v", name, err)
+		}
+		doc.Definitions[name] = s
+		progress("definitions " + name)
+	}
+	for _, name := range params {
+		p := doc.Parameters[name]
+		if err := b.parameter(b.root, &p); err != nil {
+			return fmt.Errorf("parameters %s: %v", name, err)
+		}
+		doc.Parameters[name] = p
+		progress("parameters " + name)
+	}
+	for _, name := range resps {
+		resp := doc.Responses[name]
+		if err := b.response(b.root, &resp); err != nil {
+			return fmt.Errorf("responses %s: %v", name, err)
+		}
+		doc.Responses[name] = resp
+		progress("responses " + name)
+	}
+	for _, path := range paths {
+		item := doc.Paths[path]
+		if err := b.pathItem(b.root, &item, make(map[string]bool)); err != nil {
+			return fmt.Errorf("paths %s: %v", path, err)
+		}
+		doc.Paths[path] = item
+		progress("paths " + path)
+	}
+	return nil
+}
+
+// pathItem copies path items from other files in place. Active holds the
+// path items being copied, to detect cycles.
+func (b *bundler) pathItem(base string, item *spec.PathItem, active map[string]bool) error {
+	if item.Ref != "" {
+		loc, fragment, err := refs.Locate(base, item.Ref)
+		if err != nil {
+			return err
+		}
+		if loc == b.root {
+			item.Ref = "#" + fragment
+			return nil
+		}
+		key := loc + "#" + fragment
+		if active[key] {
+			return fmt.Errorf("circular reference %q", item.Ref)
+		}
+		var target spec.PathItem
+		if err := b.docs.Get(loc, fragment, item.Ref, &target); err != nil {
+			return err
+		}
+		active[key] = true
+		err = b.pathItem(loc, &target, active)
+		delete(active, key)
+		if err != nil {
+			return err
+		}
+		*item = target
+		return nil
+	}
+	for i := range item.Parameters {
+		if err := b.parameter(base, &item.Parameters[i]); err != nil {
+			return err
+		}
+	}
+	for _, method := range spec.Methods {
+		op := item.Operation(method)
+		if op == nil {
+			continue
+		}
+		for i := range op.Parameters {
+			if err := b.parameter(base, &op.Parameters[i]); err != nil {
+				return err
+			}
+		}
+		for _, code := range mapkeys.Sorted(op.Responses) {
+			resp := op.Responses[code]
+			if err := b.response(base, &resp); err != nil {
+				return err
+			}
+			op.Responses[code] = resp
+		}
+	}
+	return nil
+}
+
+func (b *bundler) parameter(base string, p *spec.Parameter) error {
+	if p.Ref != "" {
+		var target spec.Parameter
+		ref, err := b.copy(base, p.Ref, "parameters", &target, func(name, loc string) error {
+			if err := b.parameter(loc, &target); err != nil {
+				return err
+			}
+			if b.doc.Parameters == nil {
+				b.doc.Parameters = make(spec.ParametersDefinitions)
+			}
+			b.doc.Parameters[name] = target
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		p.Ref = ref
+		return nil
+	}
+	if p.Schema != nil {
+		return b.schema(base, p.Schema)
+	}
+	return nil
+}
+
+func (b *bundler) response(base string, resp *spec.Response) error {
+	if resp.Ref != "" {
+		var target spec.Response
+		ref, err := b.copy(base, resp.Ref, "responses", &target, func(name, loc string) error {
+			if err := b.response(loc, &target); err != nil {
+				return err
+			}
+			if b.doc.Responses == nil {
+				b.doc.Responses = make(spec.ResponsesDefinitions)
+			}
+			b.doc.Responses[name] = target
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		resp.Ref = ref
+		return nil
+	}
+	if resp.Schema != nil {
+		return b.schema(base, resp.Schema)
+	}
+	return nil
+}
+
+func (b *bundler) schema(base string, s *spec.Schema) error {
+	if s.Ref != "" {
+		ref := refs.SchemaRef(s.Ref)
+		var target spec.Schema
+		local, err := b.copy(base, ref, "definitions", &target, func(name, loc string) error {
+			if err := b.schema(loc, &target); err != nil {
+				return err
+			}
+			if b.doc.Definitions == nil {
+				b.doc.Definitions = make(spec.Definitions)
+			}
+			b.doc.Definitions[name] = target
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		s.Ref = local
+		return nil
+	}
+
+	if s.Items != nil {
+		if err := b.schema(base, s.Items); err != nil {
+			return err
+		}
+	}
+	for i := range s.AllOf {
+		if err := b.schema(base, &s.AllOf[i]); err != nil {
+			return err
+		}
+	}
+	for _, name := range mapkeys.Sorted(s.Properties) {
+		prop := s.Properties[name]
+		if err := b.schema(base, &prop); err != nil {
+			return err
+		}
+		s.Properties[name] = prop
+	}
+	if ap := s.AdditionalProperties; ap != nil && ap.Schema != nil {
+		if err := b.schema(base, ap.Schema); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copy returns the local reference to use in place of ref. References within
+// the root document are kept. The first reference to a value in another file
+// decodes it into target and reserves a name for it in a section of the root
+// document, then calls add to bundle the references within the value, relative
+// to its location, and add it to the root document.
+func (b *bundler) copy(base, ref, section string, target interface{}, add func(name, loc string) error) (string, error) {
+	loc, fragment, err := refs.Locate(base, ref)
+	if err != nil {
+		return "", err
+	}
+	if loc == b.root {
+		return "#" + fragment, nil
+	}
+	key := section + " " + loc + "#" + fragment
+	if name, ok := b.names[key]; ok {
+		return jsonpointer.Join("#/"+section, name), nil
+	}
+
+	if err := b.docs.Get(loc, fragment, ref, target); err != nil {
+		return "", err
+	}
+	name := b.name(section, loc, fragment)
+	// The name is recorded before the value is bundled, so that recursive
+	// references to it terminate.
+	b.names[key] = name
+	if b.dryRun {
+		logutil.Printf(b.logger, "bundle: would copy %s#%s to #/%s/%s", loc, fragment, section, name)
+	} else {
+		logutil.Printf(b.logger, "bundle: copied %s#%s to #/%s/%s", loc, fragment, section, name)
+	}
+	if err := add(name, loc); err != nil {
+		return "", err
+	}
+	return jsonpointer.Join("#/"+section, name), nil
+}
+
+// name reserves a unique name in a section for the value at a location and
+// fragment.
+func (b *bundler) name(section, loc, fragment string) string {
+	name := ""
+	if tokens := jsonpointer.Split(fragment); len(tokens) > 0 {
+		name = tokens[len(tokens)-1]
+	} else {
+		name = path.Base(filepath.ToSlash(loc))
+		name = strings.TrimSuffix(name, path.Ext(name))
+	}
+	unique := name
+	for i := 2; b.taken[section+" "+unique]; i++ {
+		unique = name + strconv.Itoa(i)
+	}
+	b.taken[section+" "+unique] = true
+	return unique
+}
@@ -0,0 +1,184 @@
+package bundle
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/ericchiang/swaggopher/resolver"
+	"github.com/ericchiang/swaggopher/spec"
+	"github.com/ericchiang/swaggopher/spectest"
+)
+
+func TestBundle(t *testing.T) {
+	got, err := Bundle("testdata/swagger.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want spec.Swagger
+	if err := yaml.Unmarshal([]byte(`
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+paths:
+  /pets:
+    get:
+      parameters:
+      - $ref: '#/parameters/limit'
+      responses:
+        200:
+          description: A list of pets.
+          schema: {type: array, items: {$ref: '#/definitions/pet'}}
+        default:
+          $ref: '#/responses/Error'
+  /owners:
+    get:
+      responses:
+        200:
+          description: A list of owners.
+          schema: {type: array, items: {$ref: '#/definitions/Owner'}}
+parameters:
+  limit: {name: limit, in: query, type: integer}
+responses:
+  Error:
+    description: An error.
+    schema: {$ref: '#/definitions/Error2'}
+definitions:
+  Error:
+    type: object
+    properties:
+      code: {type: integer}
+  Error2:
+    type: object
+    properties:
+      message: {type: string}
+  Tree: {$ref: '#/definitions/Node'}
+  Node:
+    type: object
+    properties:
+      children: {type: array, items: {$ref: '#/definitions/Node'}}
+  Owner:
+    type: object
+    properties:
+      name: {type: string}
+      pets: {type: array, items: {$ref: '#/definitions/pet'}}
+  pet:
+    type: object
+    properties:
+      name: {type: string}
+      owner: {$ref: '#/definitions/Owner'}
+`), &want); err != nil {
+		t.Fatal(err)
+	}
+	if diff := spectest.Diff(&want, got); diff != "" {
+		t.Errorf("bundled document (-want +got):\n%s", diff)
+	}
+
+	data, err := json.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), ".yaml") {
+		t.Errorf("expected only local references, got %s", data)
+	}
+}
+
+func TestBundleLogger(t *testing.T) {
+	var logs bytes.Buffer
+	if _, err := (Options{Logger: log.New(&logs, "", 0)}).Bundle("testdata/swagger.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"bundle: loaded testdata/swagger.yaml (",
+		"bundle: copied testdata/common.yaml#/definitions/Error to #/definitions/Error2\n",
+	} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("expected log output to contain %q, got:\n%s", want, logs.String())
+		}
+	}
+}
+
+func TestBundleDryRun(t *testing.T) {
+	var logs bytes.Buffer
+	var progress []string
+	opts := Options{
+		Logger: log.New(&logs, "", 0),
+		Progress: func(done, total int, item string) {
+			progress = append(progress, fmt.Sprintf("%d/%d %s", done, total, item))
+		},
+		DryRun: true,
+	}
+	got, err := opts.Bundle("testdata/swagger.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"1/4 definitions Error", "2/4 definitions Tree", "3/4 paths /owners", "4/4 paths /pets"}
+	if diff := pretty.Compare(progress, want); diff != "" {
+		t.Errorf("progress: want != got: %s", diff)
+	}
+	if ref := got.Paths["/pets"].Get.Responses["default"].Ref; ref != "common.yaml#/responses/Error" {
+		t.Errorf("dry run rewrote a reference to %q", ref)
+	}
+	if len(got.Responses) != 0 {
+		t.Errorf("dry run copied responses %v", got.Responses)
+	}
+	if want := "bundle: would copy testdata/common.yaml#/responses/Error to #/responses/Error\n"; !strings.Contains(logs.String(), want) {
+		t.Errorf("expected log output to contain %q, got:\n%s", want, logs.String())
+	}
+}
+
+func TestBundleErrors(t *testing.T) {
+	files := map[string]string{
+		"missing.yaml": `
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+paths: {}
+definitions:
+  Pet: {$ref: 'models.yaml#/definitions/Pet'}
+`,
+		"models.yaml": `
+definitions:
+  Owner: {type: object}
+`,
+		"cycle.yaml": `
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+paths:
+  /a: {$ref: 'paths.yaml#/a'}
+`,
+		"paths.yaml": `
+a: {$ref: '#/b'}
+b: {$ref: '#/a'}
+`,
+	}
+	opts := Options{Loader: resolver.Loader(func(location string) ([]byte, error) {
+		data, ok := files[location]
+		if !ok {
+			return nil, fmt.Errorf("no such file")
+		}
+		return []byte(data), nil
+	})}
+
+	tests := []struct {
+		root string
+		want string
+	}{
+		{root: "missing.yaml", want: "bundle: definitions Pet: models.yaml#/definitions/Pet: not found"},
+		{root: "cycle.yaml", want: `bundle: paths /a: circular reference "#/a"`},
+		{root: "none.yaml", want: "bundle: no such file"},
+	}
+	for _, test := range tests {
+		_, err := opts.Bundle(test.root)
+		if err == nil {
+			t.Errorf("%s: expected error %q", test.root, test.want)
+		} else if err.Error() != test.want {
+			t.Errorf("%s: want error %q, got %q", test.root, test.want, err)
+		}
+	}
+}
@@ -0,0 +1,460 @@
+/*
+Package cache caches the responses to an API's GET requests according to its
+document.
+
+A response is cached if the document declares how long for, either with the
+"x-cache-ttl" extension of its response, operation or path item, or with the
+default value of a Cache-Control header the response declares:
+
+	paths:
+	  /pets/{petId}:
+	    get:
+	      x-cache-ttl: 30s
+	      responses:
+	        200:
+	          headers:
+	            Cache-Control:
+	              type: string
+	              default: max-age=60
+
+If the response itself carries a Cache-Control header, its max-age or s-maxage
+takes the place of the declared header, and no-store, no-cache or private
+prevent it being cached at all. An extension takes precedence over either
+header. Requests with credentials, an Authorization header or the key of an
+apiKey scheme the operation's security accepts, are only cached if an
+extension applies.
+
+Responses are keyed by the request's path, query, documented header
+parameters and credentials, so a response cached for one client is never
+served to another. A successful request with a method other than GET, HEAD or OPTIONS
+invalidates the responses cached for its path, the paths above it and the paths
+below it, so a PUT to /pets/7 invalidates /pets, /pets/7 and /pets/7/photos.
+*/
+package cache
+
+import (
+	"bytes"
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ericchiang/swaggopher/internal/httpcheck"
+	"github.com/ericchiang/swaggopher/internal/jsonpointer"
+	"github.com/ericchiang/swaggopher/internal/mapkeys"
+	"github.com/ericchiang/swaggopher/runtime"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// TTLExtension is the vendor extension declaring how long the responses of a
+// response, operation or path item are cached for. Its value is a duration
+// such as "30s" or a number of seconds. A TTL of zero disables caching.
+const TTLExtension = "x-cache-ttl"
+
+// Entry is a cached response.
+type Entry = runtime.CacheEntry
+
+// Backend stores cached responses. Implementations must be safe for concurrent
+// use. See runtime.Cache.
+type Backend = runtime.Cache
+
+// Memory is a Backend which holds entries in memory, evicting the least
+// recently used once it's full.
+type Memory struct {
+	max int
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type memoryEntry struct {
+	key   string
+	entry *Entry
+}
+
+// NewMemory returns a backend holding up to max entries. If max is zero or
+// less, the number of entries is unbounded.
+func NewMemory(max int) *Memory {
+	return &Memory{max: max, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+// Get implements Backend.
+func (m *Memory) Get(key string) (*Entry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	el, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	m.order.MoveToFront(el)
+	return el.Value.(*memoryEntry).entry, true
+}
+
+// Set implements Backend.
+func (m *Memory) Set(key string, e *Entry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if el, ok := m.entries[key]; ok {
+		el.Value.(*memoryEntry).entry = e
+		m.order.MoveToFront(el)
+		return
+	}
+	m.entries[key] = m.order.PushFront(&memoryEntry{key: key, entry: e})
+	if m.max > 0 && m.order.Len() > m.max {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.entries, oldest.Value.(*memoryEntry).key)
+	}
+}
+
+// DeletePrefix implements Backend.
+func (m *Memory) DeletePrefix(prefix string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, el := range m.entries {
+		if strings.HasPrefix(key, prefix) {
+			m.order.Remove(el)
+			delete(m.entries, key)
+		}
+	}
+}
+
+// Len returns the number of entries held.
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.order.Len()
+}
+
+// Options configures Handler.
+type Options struct {
+	// Backend stores the responses. If nil, an unbounded Memory is used.
+	Backend Backend
+	// OnHit, if set, is called with the method and path template of the
+	// operation, such as "GET /pets/{petId}", whenever a request is served
+	// from the cache.
+	OnHit func(op string)
+	// Now returns the current time. If nil, time.Now is used.
+	Now func() time.Time
+	// Router matches requests to operations. If nil, runtime.DefaultRouter
+	// is used.
+	Router runtime.Router
+	// Vary names request headers, besides the operation's header
+	// parameters, whose values are part of every key, for handlers whose
+	// responses depend on headers such as Accept.
+	Vary []string
+}
+
+// Handler returns a handler which serves GET requests from the cache when it
+// can, calling next otherwise. Requests which don't match an operation of the
+// document are passed to next untouched. The document's extensions are
+// checked when the handler is created.
+func Handler(doc *spec.Swagger, next http.Handler, opts Options) (http.Handler, error) {
+	for template, item := range doc.Paths {
+		item := item
+		op := httpcheck.Operation(&item, "GET")
+		if op == nil {
+			continue
+		}
+		for code, resp := range op.Responses {
+			if _, _, err := extensionTTL(resp.Extensions); err != nil {
+				return nil, fmt.Errorf("cache: GET %s: response %s: %s: %v", template, code, TTLExtension, err)
+			}
+		}
+		for _, exts := range []map[string]interface{}{op.Extensions, item.Extensions} {
+			if _, _, err := extensionTTL(exts); err != nil {
+				return nil, fmt.Errorf("cache: GET %s: %s: %v", template, TTLExtension, err)
+			}
+		}
+	}
+	h := &handler{doc: doc, next: next, opts: opts}
+	if h.opts.Backend == nil {
+		h.opts.Backend = NewMemory(0)
+	}
+	if h.opts.Now == nil {
+		h.opts.Now = time.Now
+	}
+	if h.opts.Router == nil {
+		h.opts.Router = runtime.DefaultRouter
+	}
+	return h, nil
+}
+
+type handler struct {
+	doc  *spec.Swagger
+	next http.Handler
+	opts Options
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m, err := h.opts.Router.Route(h.doc, r)
+	if err != nil {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+	switch m.Method {
+	case "HEAD", "OPTIONS":
+		h.next.ServeHTTP(w, r)
+		return
+	case "GET":
+	default:
+		rec := &recorder{ResponseWriter: w}
+		h.next.ServeHTTP(rec, r)
+		if rec.status < 400 {
+			h.invalidate(r.URL.EscapedPath())
+		}
+		return
+	}
+
+	key := h.key(m, r)
+	bypass := strings.Contains(r.Header.Get("Cache-Control"), "no-cache")
+	if e, ok := h.opts.Backend.Get(key); ok && !bypass {
+		if now := h.opts.Now(); now.Before(e.Expires) {
+			if h.opts.OnHit != nil {
+				h.opts.OnHit(m.String())
+			}
+			for k, v := range e.Header {
+				w.Header()[k] = v
+			}
+			w.Header().Set("X-Cache", "HIT")
+			w.WriteHeader(e.Status)
+			w.Write(e.Body)
+			return
+		}
+	}
+
+	rec := &recorder{ResponseWriter: w, buffer: true}
+	h.next.ServeHTTP(rec, r)
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	if rec.status < 200 || rec.status >= 300 {
+		return
+	}
+	ttl, ok := h.ttl(m, rec.status, rec.Header(), r)
+	if !ok || ttl <= 0 {
+		return
+	}
+	h.opts.Backend.Set(key, &Entry{
+		Status:  rec.status,
+		Header:  cloneHeader(rec.Header()),
+		Body:    rec.body.Bytes(),
+		Expires: h.opts.Now().Add(ttl),
+	})
+}
+
+// key returns the cache key of a GET request: its path, query, the values of
+// the operation's header parameters and the Vary headers, and its credentials.
+func (h *handler) key(m *httpcheck.Match, r *http.Request) string {
+	var b bytes.Buffer
+	b.WriteString(r.URL.EscapedPath())
+	b.WriteString("?")
+	b.WriteString(r.URL.Query().Encode())
+	for _, p := range h.doc.OperationParameters(m.Item, m.Operation) {
+		if p.In == "header" {
+			fmt.Fprintf(&b, "\n%s: %s", http.CanonicalHeaderKey(p.Name), strings.Join(r.Header[http.CanonicalHeaderKey(p.Name)], ", "))
+		}
+	}
+	for _, name := range h.opts.Vary {
+		fmt.Fprintf(&b, "\n%s: %s", http.CanonicalHeaderKey(name), strings.Join(r.Header[http.CanonicalHeaderKey(name)], ", "))
+	}
+	for _, c := range h.credentials(m, r) {
+		b.WriteString("\n")
+		b.WriteString(c)
+	}
+	return b.String()
+}
+
+// credentials returns the credentials a request carries, one per line: its
+// Authorization header and the keys of the apiKey schemes the operation's
+// security accepts, in a stable order.
+func (h *handler) credentials(m *httpcheck.Match, r *http.Request) []string {
+	var creds []string
+	if v := r.Header.Get("Authorization"); v != "" {
+		creds = append(creds, "Authorization: "+v)
+	}
+	seen := make(map[string]bool)
+	for _, req := range h.doc.EffectiveSecurity(m.Operation) {
+		for _, name := range mapkeys.Sorted(req) {
+			scheme := h.doc.SecurityDefinitions[name]
+			in, key, ok := scheme.APIKey()
+			if !ok {
+				continue
+			}
+			if in == "header" {
+				key = http.CanonicalHeaderKey(key)
+			}
+			if seen[in+" "+key] {
+				continue
+			}
+			seen[in+" "+key] = true
+			var v string
+			switch in {
+			case "header":
+				v = strings.Join(r.Header[key], ", ")
+			case "query":
+				v = r.URL.Query().Get(key)
+			}
+			if v != "" {
+				creds = append(creds, in+" "+key+": "+v)
+			}
+		}
+	}
+	return creds
+}
+
+// invalidate removes the entries for a path, the paths above it and those
+// below it.
+func (h *handler) invalidate(path string) {
+	path = strings.TrimSuffix(path, "/")
+	h.opts.Backend.DeletePrefix(path + "/")
+	for p := path; p != ""; p = p[:strings.LastIndex(p, "/")] {
+		h.opts.Backend.DeletePrefix(p + "?")
+	}
+	h.opts.Backend.DeletePrefix("/?")
+}
+
+// ttl returns how long a response may be cached for, and false if it must not
+// be.
+func (h *handler) ttl(m *httpcheck.Match, status int, header http.Header, r *http.Request) (time.Duration, bool) {
+	if header.Get("Vary") != "" {
+		return 0, false
+	}
+	cc := header.Get("Cache-Control")
+	if directive(cc, "no-store") || directive(cc, "private") || directive(cc, "no-cache") {
+		return 0, false
+	}
+
+	resp, ok := m.Operation.Responses[strconv.Itoa(status)]
+	if !ok {
+		resp, ok = m.Operation.Responses["default"]
+	}
+	if ok && resp.Ref != "" {
+		resp, ok = h.doc.Responses[jsonpointer.Unescape(strings.TrimPrefix(resp.Ref, "#/responses/"))]
+	}
+	for _, exts := range []map[string]interface{}{resp.Extensions, m.Operation.Extensions, m.Item.Extensions} {
+		if ttl, declared, _ := extensionTTL(exts); declared {
+			return ttl, true
+		}
+	}
+	// Requests with credentials are only cached where the document says so.
+	if len(h.credentials(m, r)) > 0 {
+		return 0, false
+	}
+	if cc == "" && ok {
+		for name, decl := range resp.Headers {
+			if s, isString := decl.Default.(string); isString && http.CanonicalHeaderKey(name) == "Cache-Control" {
+				cc = s
+			}
+		}
+	}
+	return maxAge(cc)
+}
+
+// extensionTTL returns the TTL declared by the extension in a set of extensions,
+// if there is one.
+func extensionTTL(exts map[string]interface{}) (time.Duration, bool, error) {
+	ext, ok := exts[TTLExtension]
+	if !ok {
+		return 0, false, nil
+	}
+	switch v := ext.(type) {
+	case string:
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return 0, false, err
+		}
+		return d, true, nil
+	case float64:
+		return time.Duration(v * float64(time.Second)), true, nil
+	case int:
+		return time.Duration(v) * time.Second, true, nil
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return 0, false, err
+		}
+		return time.Duration(f * float64(time.Second)), true, nil
+	}
+	return 0, false, fmt.Errorf("expected a duration or number of seconds, got %v", ext)
+}
+
+// directive reports if a Cache-Control header has a directive.
+func directive(cc, name string) bool {
+	for _, d := range strings.Split(cc, ",") {
+		d = strings.TrimSpace(d)
+		if i := strings.Index(d, "="); i >= 0 {
+			d = d[:i]
+		}
+		if strings.EqualFold(d, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// maxAge returns the lifetime a Cache-Control header allows shared caches,
+// preferring s-maxage to max-age.
+func maxAge(cc string) (time.Duration, bool) {
+	var age, shared string
+	for _, d := range strings.Split(cc, ",") {
+		d = strings.TrimSpace(d)
+		i := strings.Index(d, "=")
+		if i < 0 {
+			continue
+		}
+		switch strings.ToLower(d[:i]) {
+		case "max-age":
+			age = d[i+1:]
+		case "s-maxage":
+			shared = d[i+1:]
+		}
+	}
+	if shared != "" {
+		age = shared
+	}
+	n, err := strconv.Atoi(strings.Trim(age, `"`))
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	return time.Duration(n) * time.Second, true
+}
+
+func cloneHeader(h http.Header) http.Header {
+	c := make(http.Header, len(h))
+	for k, v := range h {
+		c[k] = append([]string(nil), v...)
+	}
+	return c
+}
+
+// recorder passes a response through while recording its status, and its body
+// if buffer is set.
+type recorder struct {
+	http.ResponseWriter
+	status int
+	buffer bool
+	body   bytes.Buffer
+}
+
+func (r *recorder) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *recorder) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	if r.buffer {
+		r.body.Write(p)
+	}
+	return r.ResponseWriter.Write(p)
+}
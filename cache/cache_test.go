@@ -0,0 +1,192 @@
+package cache
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+const petstore = `
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+basePath: /v1
+paths:
+  /pets:
+    x-cache-ttl: 1m
+    get:
+      parameters:
+      - {name: X-Tenant, in: header, type: string}
+      responses:
+        200: {description: Pets.}
+    post:
+      responses:
+        201: {description: Created.}
+  /pets/{petId}:
+    parameters:
+    - {name: petId, in: path, required: true, type: integer}
+    get:
+      responses:
+        200:
+          description: A pet.
+          headers:
+            Cache-Control: {type: string, default: max-age=30}
+    put:
+      responses:
+        200: {description: Updated.}
+  /toys:
+    get:
+      security: [{key: []}]
+      responses:
+        200: {description: Toys.}
+  /owners:
+    x-cache-ttl: 1m
+    get:
+      security: [{key: []}]
+      responses:
+        200: {description: Owners.}
+securityDefinitions:
+  key: {type: apiKey, in: header, name: X-Api-Key}
+`
+
+func TestHandler(t *testing.T) {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(petstore), &s); err != nil {
+		t.Fatal(err)
+	}
+	calls := 0
+	upstream := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Query().Get("private") != "" {
+			w.Header().Set("Cache-Control", "private")
+		}
+		if r.URL.Path == "/v1/toys" {
+			w.Header().Set("Cache-Control", "max-age=10")
+		}
+		fmt.Fprintf(w, "%s %s %d", r.Method, r.URL, calls)
+	})
+	now := time.Now()
+	var hits []string
+	backend := NewMemory(0)
+	h, err := Handler(&s, upstream, Options{
+		Backend: backend,
+		OnHit:   func(op string) { hits = append(hits, op) },
+		Now:     func() time.Time { return now },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		method, path string
+		header       http.Header
+		advance      time.Duration
+		want         string
+	}{
+		// The path item's extension applies.
+		{method: "GET", path: "/v1/pets", want: "GET /v1/pets 1"},
+		{method: "GET", path: "/v1/pets", want: "GET /v1/pets 1"},
+		// Header parameters and the query are part of the key.
+		{method: "GET", path: "/v1/pets", header: http.Header{"X-Tenant": {"a"}}, want: "GET /v1/pets 2"},
+		{method: "GET", path: "/v1/pets?limit=1", want: "GET /v1/pets?limit=1 3"},
+		{method: "GET", path: "/v1/pets", header: http.Header{"Cache-Control": {"no-cache"}}, want: "GET /v1/pets 4"},
+		// The declared Cache-Control header applies.
+		{method: "GET", path: "/v1/pets/7", want: "GET /v1/pets/7 5"},
+		{method: "GET", path: "/v1/pets/7", advance: 20 * time.Second, want: "GET /v1/pets/7 5"},
+		{method: "GET", path: "/v1/pets/7", advance: 20 * time.Second, want: "GET /v1/pets/7 6"},
+		{method: "GET", path: "/v1/pets/8?private=1", want: "GET /v1/pets/8?private=1 7"},
+		{method: "GET", path: "/v1/pets/8?private=1", want: "GET /v1/pets/8?private=1 8"},
+		// Requests with credentials are only cached by extension.
+		{method: "GET", path: "/v1/toys", header: http.Header{"Authorization": {"secret"}}, want: "GET /v1/toys 9"},
+		{method: "GET", path: "/v1/toys", want: "GET /v1/toys 10"},
+		{method: "GET", path: "/v1/toys", want: "GET /v1/toys 10"},
+		// Updating a pet invalidates it and the collection, but not others.
+		{method: "GET", path: "/v1/pets/9", want: "GET /v1/pets/9 11"},
+		{method: "PUT", path: "/v1/pets/7", want: "PUT /v1/pets/7 12"},
+		{method: "GET", path: "/v1/pets/7", want: "GET /v1/pets/7 13"},
+		{method: "GET", path: "/v1/pets/9", want: "GET /v1/pets/9 11"},
+		{method: "GET", path: "/v1/pets", want: "GET /v1/pets 14"},
+		{method: "GET", path: "/v1/toys", want: "GET /v1/toys 10"},
+		{method: "GET", path: "/v1/toys", header: http.Header{"X-Api-Key": {"a"}}, want: "GET /v1/toys 15"},
+		{method: "GET", path: "/v1/toys", header: http.Header{"X-Api-Key": {"a"}}, want: "GET /v1/toys 16"},
+		// Credentials are part of the key.
+		{method: "GET", path: "/v1/owners", header: http.Header{"Authorization": {"a"}}, want: "GET /v1/owners 17"},
+		{method: "GET", path: "/v1/owners", header: http.Header{"Authorization": {"a"}}, want: "GET /v1/owners 17"},
+		{method: "GET", path: "/v1/owners", header: http.Header{"Authorization": {"b"}}, want: "GET /v1/owners 18"},
+		{method: "GET", path: "/v1/owners", header: http.Header{"X-Api-Key": {"a"}}, want: "GET /v1/owners 19"},
+		{method: "GET", path: "/v1/owners", header: http.Header{"X-Api-Key": {"b"}}, want: "GET /v1/owners 20"},
+		{method: "GET", path: "/v1/owners", header: http.Header{"X-Api-Key": {"a"}}, want: "GET /v1/owners 19"},
+	}
+	for i, tt := range tests {
+		now = now.Add(tt.advance)
+		r := httptest.NewRequest(tt.method, tt.path, nil)
+		for k, v := range tt.header {
+			r.Header[k] = v
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if got := w.Body.String(); got != tt.want {
+			t.Errorf("case %d: %s %s: want %q, got %q", i, tt.method, tt.path, tt.want, got)
+		}
+	}
+	wantHits := []string{"GET /pets", "GET /pets/{petId}", "GET /toys", "GET /pets/{petId}", "GET /toys", "GET /owners", "GET /owners"}
+	if diff := pretty.Compare(hits, wantHits); diff != "" {
+		t.Errorf("hits: want != got: %s", diff)
+	}
+}
+
+func TestHandlerInvalidExtension(t *testing.T) {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(strings.Replace(petstore, "x-cache-ttl: 1m", "x-cache-ttl: soon", 1)), &s); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Handler(&s, http.NotFoundHandler(), Options{}); err == nil {
+		t.Errorf("expected an error for an invalid TTL")
+	}
+}
+
+func TestMemory(t *testing.T) {
+	m := NewMemory(2)
+	m.Set("/a?", &Entry{Body: []byte("a")})
+	m.Set("/b?", &Entry{Body: []byte("b")})
+	m.Get("/a?")
+	m.Set("/c?", &Entry{Body: []byte("c")})
+	if _, ok := m.Get("/b?"); ok {
+		t.Errorf("expected the least recently used entry to be evicted")
+	}
+	if _, ok := m.Get("/a?"); !ok {
+		t.Errorf("expected a recently used entry to be kept")
+	}
+	m.DeletePrefix("/c")
+	if n := m.Len(); n != 1 {
+		t.Errorf("expected 1 entry after deleting, got %d", n)
+	}
+}
+
+func TestMaxAge(t *testing.T) {
+	tests := []struct {
+		cc   string
+		want time.Duration
+		ok   bool
+	}{
+		{"max-age=60", time.Minute, true},
+		{"public, max-age=60, s-maxage=10", 10 * time.Second, true},
+		{"max-age=0", 0, false},
+		{"no-store", 0, false},
+		{"", 0, false},
+	}
+	for i, tt := range tests {
+		got, ok := maxAge(tt.cc)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("case %d: %q: want %v %v, got %v %v", i, tt.cc, tt.want, tt.ok, got, ok)
+		}
+	}
+}
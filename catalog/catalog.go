@@ -0,0 +1,228 @@
+/*
+Package catalog lists the operations of a document as a table, for readers who
+want the surface of an API in a spreadsheet rather than a specification.
+
+Rows returns a row for each operation with its method, path, ID, summary,
+tags, security, request and response types and documented statuses. The rows
+can be written as CSV with WriteCSV, or as an Excel workbook with WriteXLSX.
+
+Resources groups the paths into resources and reports which CRUD verbs each
+supports, as a matrix for API design reviews. Asymmetries, such as a resource
+which can be updated but not read, are listed by Gaps.
+*/
+package catalog
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/internal/httpcheck"
+	"github.com/ericchiang/swaggopher/internal/jsonpointer"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// Row describes an operation.
+type Row struct {
+	Method      string
+	Path        string
+	OperationID string
+	Summary     string
+	Tags        []string
+	// Security lists the alternative security requirements, each as the
+	// names of its schemes joined by " + " with any scopes in parentheses.
+	// It's "none" if the operation explicitly requires no security.
+	Security []string
+	// Request is the type of the body, "form" for form parameters, or empty
+	// if the operation has neither.
+	Request string
+	// Response is the type of the lowest documented successful response.
+	Response string
+	// Statuses are the documented response codes.
+	Statuses   []string
+	Deprecated bool
+}
+
+// Header holds the column names of the table.
+var Header = []string{"Method", "Path", "Operation ID", "Summary", "Tags", "Security", "Request", "Response", "Statuses", "Deprecated"}
+
+// Cells returns the values of the row's columns.
+func (r *Row) Cells() []string {
+	deprecated := ""
+	if r.Deprecated {
+		deprecated = "yes"
+	}
+	return []string{
+		r.Method,
+		r.Path,
+		r.OperationID,
+		r.Summary,
+		strings.Join(r.Tags, ", "),
+		strings.Join(r.Security, " or "),
+		r.Request,
+		r.Response,
+		strings.Join(r.Statuses, ", "),
+		deprecated,
+	}
+}
+
+var methods = []string{"GET", "PUT", "POST", "DELETE", "OPTIONS", "HEAD", "PATCH"}
+
+// Rows returns a row for each operation of a document, sorted by path and then
+// method.
+func Rows(doc *spec.Swagger) []Row {
+	paths := make([]string, 0, len(doc.Paths))
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var rows []Row
+	for _, path := range paths {
+		item := doc.Paths[path]
+		for _, method := range methods {
+			op := httpcheck.Operation(&item, method)
+			if op == nil {
+				continue
+			}
+			rows = append(rows, Row{
+				Method:      method,
+				Path:        path,
+				OperationID: op.OperationId,
+				Summary:     op.Summary,
+				Tags:        op.Tags,
+				Security:    security(doc, op),
+				Request:     request(doc, &item, op),
+				Response:    response(doc, op),
+				Statuses:    statuses(op),
+				Deprecated:  op.Deprecated,
+			})
+		}
+	}
+	return rows
+}
+
+func security(doc *spec.Swagger, op *spec.Operation) []string {
+	reqs := doc.EffectiveSecurity(op)
+	if reqs == nil {
+		return nil
+	}
+	var alternatives []string
+	for _, req := range reqs {
+		names := make([]string, 0, len(req))
+		for name := range req {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for i, name := range names {
+			if scopes := req[name]; len(scopes) > 0 {
+				names[i] += " (" + strings.Join(scopes, ", ") + ")"
+			}
+		}
+		if len(names) > 0 {
+			alternatives = append(alternatives, strings.Join(names, " + "))
+		}
+	}
+	if len(alternatives) == 0 {
+		return []string{"none"}
+	}
+	return alternatives
+}
+
+func request(doc *spec.Swagger, item *spec.PathItem, op *spec.Operation) string {
+	form := false
+	for _, p := range doc.OperationParameters(item, op) {
+		switch p.In {
+		case "body":
+			return TypeName(p.Schema)
+		case "formData":
+			form = true
+		}
+	}
+	if form {
+		return "form"
+	}
+	return ""
+}
+
+func response(doc *spec.Swagger, op *spec.Operation) string {
+	best := -1
+	for code := range op.Responses {
+		if n, err := strconv.Atoi(code); err == nil && n >= 200 && n < 300 && (best < 0 || n < best) {
+			best = n
+		}
+	}
+	r, ok := op.Responses[strconv.Itoa(best)]
+	if !ok {
+		if r, ok = op.Responses["default"]; !ok {
+			return ""
+		}
+	}
+	if r.Ref != "" {
+		r = doc.Responses[jsonpointer.Unescape(strings.TrimPrefix(r.Ref, "#/responses/"))]
+	}
+	return TypeName(r.Schema)
+}
+
+func statuses(op *spec.Operation) []string {
+	codes := make([]string, 0, len(op.Responses))
+	for code := range op.Responses {
+		codes = append(codes, code)
+	}
+	// "default" sorts after the numeric codes.
+	sort.Strings(codes)
+	return codes
+}
+
+// TypeName returns a short name for the type a schema describes: the name of
+// the definition it refers to, a Go style slice or map of another type, or a
+// primitive type with any format, such as "integer(int64)".
+func TypeName(s *spec.Schema) string {
+	switch {
+	case s == nil:
+		return ""
+	case s.Ref != "":
+		return jsonpointer.Unescape(strings.TrimPrefix(s.Ref, "#/definitions/"))
+	case s.Type == "array":
+		if s.Items == nil {
+			return "[]"
+		}
+		return "[]" + TypeName(s.Items)
+	case s.Type == "object" || (s.Type == "" && (len(s.Properties) > 0 || s.AdditionalProperties != nil)):
+		if ap := s.AdditionalProperties; len(s.Properties) == 0 && ap != nil && ap.Schema != nil {
+			return "map[string]" + TypeName(ap.Schema)
+		}
+		return "object"
+	case s.Type == "" && len(s.AllOf) > 0:
+		names := make([]string, len(s.AllOf))
+		for i := range s.AllOf {
+			names[i] = TypeName(&s.AllOf[i])
+		}
+		return strings.Join(names, " & ")
+	case s.Format != "":
+		return fmt.Sprintf("%s(%s)", s.Type, s.Format)
+	}
+	return s.Type
+}
+
+// WriteCSV writes rows as CSV with a header row.
+func WriteCSV(w io.Writer, rows []Row) error {
+	return writeCSV(w, rowTable(rows))
+}
+
+func rowTable(rows []Row) [][]string {
+	table := [][]string{Header}
+	for i := range rows {
+		table = append(table, rows[i].Cells())
+	}
+	return table
+}
+
+func writeCSV(w io.Writer, table [][]string) error {
+	cw := csv.NewWriter(w)
+	cw.WriteAll(table)
+	return cw.Error()
+}
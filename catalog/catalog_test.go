@@ -0,0 +1,186 @@
+package catalog
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+const petstore = `
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+securityDefinitions:
+  key: {type: apiKey, in: header, name: X-API-Key}
+  oauth: {type: oauth2, flow: implicit, authorizationUrl: "https://example.com/auth", scopes: {read: Read., write: Write.}}
+security:
+- key: []
+responses:
+  NotFound: {description: Not found.}
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      summary: List pets
+      tags: [pets]
+      security: []
+      responses:
+        200: {description: Pets., schema: {type: array, items: {$ref: '#/definitions/Pet'}}}
+        default: {description: Error.}
+    post:
+      operationId: createPet
+      tags: [pets, admin]
+      security:
+      - oauth: [write]
+      - key: []
+      parameters:
+      - {name: pet, in: body, schema: {$ref: '#/definitions/Pet'}}
+      responses:
+        201: {description: Created., schema: {$ref: '#/definitions/Pet'}}
+  /pets/{petId}/photo:
+    put:
+      deprecated: true
+      parameters:
+      - {name: petId, in: path, required: true, type: integer}
+      - {name: photo, in: formData, type: file}
+      responses:
+        204: {description: Uploaded.}
+        404: {$ref: '#/responses/NotFound'}
+definitions:
+  Pet:
+    type: object
+`
+
+func TestRows(t *testing.T) {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(petstore), &s); err != nil {
+		t.Fatal(err)
+	}
+	want := []Row{
+		{
+			Method:      "GET",
+			Path:        "/pets",
+			OperationID: "listPets",
+			Summary:     "List pets",
+			Tags:        []string{"pets"},
+			Security:    []string{"none"},
+			Response:    "[]Pet",
+			Statuses:    []string{"200", "default"},
+		},
+		{
+			Method:      "POST",
+			Path:        "/pets",
+			OperationID: "createPet",
+			Tags:        []string{"pets", "admin"},
+			Security:    []string{"oauth (write)", "key"},
+			Request:     "Pet",
+			Response:    "Pet",
+			Statuses:    []string{"201"},
+		},
+		{
+			Method:     "PUT",
+			Path:       "/pets/{petId}/photo",
+			Security:   []string{"key"},
+			Request:    "form",
+			Statuses:   []string{"204", "404"},
+			Deprecated: true,
+		},
+	}
+	if diff := pretty.Compare(want, Rows(&s)); diff != "" {
+		t.Errorf("rows: %s", diff)
+	}
+}
+
+func TestTypeName(t *testing.T) {
+	tests := []struct {
+		schema string
+		want   string
+	}{
+		{schema: `{$ref: '#/definitions/Pet'}`, want: "Pet"},
+		{schema: `{type: array, items: {type: integer, format: int64}}`, want: "[]integer(int64)"},
+		{schema: `{type: object, additionalProperties: {$ref: '#/definitions/Pet'}}`, want: "map[string]Pet"},
+		{schema: `{type: object, properties: {name: {type: string}}}`, want: "object"},
+		{schema: `{allOf: [{$ref: '#/definitions/Pet'}, {$ref: '#/definitions/Owned'}]}`, want: "Pet & Owned"},
+		{schema: `{type: string}`, want: "string"},
+	}
+	for _, test := range tests {
+		var s spec.Schema
+		if err := yaml.Unmarshal([]byte(test.schema), &s); err != nil {
+			t.Fatal(err)
+		}
+		if got := TypeName(&s); got != test.want {
+			t.Errorf("%s: want %q, got %q", test.schema, test.want, got)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	rows := []Row{
+		{Method: "GET", Path: "/pets", Summary: `List "all" pets`, Tags: []string{"pets", "read"}, Statuses: []string{"200"}},
+		{Method: "DELETE", Path: "/pets/{petId}", Security: []string{"a + b", "c"}, Deprecated: true},
+	}
+
+	var csv bytes.Buffer
+	if err := WriteCSV(&csv, rows); err != nil {
+		t.Fatal(err)
+	}
+	wantCSV := `Method,Path,Operation ID,Summary,Tags,Security,Request,Response,Statuses,Deprecated
+GET,/pets,,"List ""all"" pets","pets, read",,,,200,
+DELETE,/pets/{petId},,,,a + b or c,,,,yes
+`
+	if got := csv.String(); got != wantCSV {
+		t.Errorf("CSV: want %q, got %q", wantCSV, got)
+	}
+
+	var xlsx bytes.Buffer
+	if err := WriteXLSX(&xlsx, rows); err != nil {
+		t.Fatal(err)
+	}
+	z, err := zip.NewReader(bytes.NewReader(xlsx.Bytes()), int64(xlsx.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]string)
+	for _, f := range z.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name] = string(data)
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("workbook is missing %s", name)
+		}
+	}
+	sheet := files["xl/worksheets/sheet1.xml"]
+	for _, want := range []string{
+		`<c r="A1" t="inlineStr" s="1"><is><t xml:space="preserve">Method</t></is></c>`,
+		`<c r="D2" t="inlineStr"><is><t xml:space="preserve">List &#34;all&#34; pets</t></is></c>`,
+		`<c r="J3" t="inlineStr"><is><t xml:space="preserve">yes</t></is></c>`,
+	} {
+		if !strings.Contains(sheet, want) {
+			t.Errorf("expected sheet to contain %s, got %s", want, sheet)
+		}
+	}
+}
+
+func TestColumn(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"} {
+		if got := column(i); got != want {
+			t.Errorf("column(%d): want %q, got %q", i, want, got)
+		}
+	}
+}
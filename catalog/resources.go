@@ -0,0 +1,216 @@
+package catalog
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/internal/httpcheck"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// Verb is an operation on a resource.
+type Verb string
+
+// The verbs of a resource, in the order of the columns of the matrix.
+const (
+	List   Verb = "list"
+	Create Verb = "create"
+	Read   Verb = "read"
+	Update Verb = "update"
+	Delete Verb = "delete"
+)
+
+// Verbs lists every verb.
+var Verbs = []Verb{List, Create, Read, Update, Delete}
+
+// Resource is a collection path, such as "/pets", and the path of its members,
+// such as "/pets/{petId}".
+//
+// GET on the collection lists it, and POST creates a member. GET, PUT or PATCH
+// and DELETE on a member read, update and delete it. A resource without a
+// member path is a singleton, such as "/me", which is read, updated and
+// deleted through the collection path instead.
+type Resource struct {
+	// Name is the path of the collection, whether or not it's documented.
+	Name string
+	// Collection is the documented path of the collection, if any.
+	Collection string
+	// Item is the documented path of a member, if any.
+	Item string
+	// Methods holds the methods which implement each supported verb.
+	Methods map[Verb][]string
+}
+
+// ResourceHeader holds the column names of the resource matrix.
+var ResourceHeader = []string{"Resource", "List", "Create", "Read", "Update", "Delete"}
+
+// Cells returns the values of the resource's columns: its name, then the
+// methods implementing each verb.
+func (r *Resource) Cells() []string {
+	cells := []string{r.Name}
+	for _, v := range Verbs {
+		cells = append(cells, strings.Join(r.Methods[v], ", "))
+	}
+	return cells
+}
+
+// Supports reports whether the resource has an operation for a verb.
+func (r *Resource) Supports(v Verb) bool {
+	return len(r.Methods[v]) > 0
+}
+
+// Gaps describes the asymmetries of the resource's verbs: a resource which can
+// be updated or deleted but not read, or created but neither read nor listed.
+// They're often, but not always, mistakes.
+func (r *Resource) Gaps() []string {
+	var gaps []string
+	if !r.Supports(Read) {
+		if r.Supports(Update) {
+			gaps = append(gaps, fmt.Sprintf("resource %s can be updated but not read", r.Name))
+		}
+		if r.Supports(Delete) {
+			gaps = append(gaps, fmt.Sprintf("resource %s can be deleted but not read", r.Name))
+		}
+		if r.Supports(Create) && !r.Supports(List) {
+			gaps = append(gaps, fmt.Sprintf("resource %s can be created but neither read nor listed", r.Name))
+		}
+	}
+	return gaps
+}
+
+var variableSegment = regexp.MustCompile(`^\{[^{}]+\}$`)
+
+// Resources groups the paths of a document into resources, sorted by name.
+// Paths are grouped ignoring trailing slashes. A path whose last segment is
+// a single variable is the member path of the resource named by the rest of
+// it; any other path is a collection.
+func Resources(doc *spec.Swagger) []Resource {
+	paths := make([]string, 0, len(doc.Paths))
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	byName := make(map[string]*Resource)
+	resourceOf := make(map[string]*Resource, len(paths))
+	var names []string
+	resource := func(name string) *Resource {
+		r, ok := byName[name]
+		if !ok {
+			r = &Resource{Name: name, Methods: make(map[Verb][]string)}
+			byName[name] = r
+			names = append(names, name)
+		}
+		return r
+	}
+	for _, path := range paths {
+		trimmed := trimSlash(path)
+		i := strings.LastIndex(trimmed, "/")
+		if i >= 0 && variableSegment.MatchString(trimmed[i+1:]) {
+			name := trimmed[:i]
+			if name == "" {
+				name = "/"
+			}
+			r := resource(name)
+			if r.Item == "" {
+				r.Item = path
+			}
+			resourceOf[path] = r
+			continue
+		}
+		r := resource(trimmed)
+		if r.Collection == "" {
+			r.Collection = path
+		}
+		resourceOf[path] = r
+	}
+
+	// Verbs are assigned once every path is grouped, since GET on a
+	// collection path depends on whether the resource has a member path.
+	for _, path := range paths {
+		r := resourceOf[path]
+		item := doc.Paths[path]
+		for _, method := range methods {
+			if httpcheck.Operation(&item, method) == nil {
+				continue
+			}
+			if v, ok := r.verb(path, method); ok && !contains(r.Methods[v], method) {
+				r.Methods[v] = append(r.Methods[v], method)
+			}
+		}
+	}
+
+	sort.Strings(names)
+	resources := make([]Resource, 0, len(names))
+	for _, name := range names {
+		resources = append(resources, *byName[name])
+	}
+	return resources
+}
+
+// verb returns the verb a method implements on a path of the resource.
+func (r *Resource) verb(path, method string) (Verb, bool) {
+	if trimSlash(path) != r.Name {
+		switch method {
+		case "GET":
+			return Read, true
+		case "PUT", "PATCH":
+			return Update, true
+		case "DELETE":
+			return Delete, true
+		}
+		return "", false
+	}
+	switch method {
+	case "GET":
+		if r.Item == "" {
+			return Read, true
+		}
+		return List, true
+	case "POST":
+		return Create, true
+	case "PUT", "PATCH":
+		return Update, true
+	case "DELETE":
+		return Delete, true
+	}
+	return "", false
+}
+
+func trimSlash(path string) string {
+	if len(path) > 1 {
+		return strings.TrimSuffix(path, "/")
+	}
+	return path
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteResourcesCSV writes the resource matrix as CSV with a header row.
+func WriteResourcesCSV(w io.Writer, resources []Resource) error {
+	return writeCSV(w, resourceTable(resources))
+}
+
+// WriteResourcesXLSX writes the resource matrix as an Excel workbook, as
+// WriteXLSX does.
+func WriteResourcesXLSX(w io.Writer, resources []Resource) error {
+	return writeXLSX(w, resourceTable(resources))
+}
+
+func resourceTable(resources []Resource) [][]string {
+	table := [][]string{ResourceHeader}
+	for i := range resources {
+		table = append(table, resources[i].Cells())
+	}
+	return table
+}
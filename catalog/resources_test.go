@@ -0,0 +1,86 @@
+package catalog
+
+import (
+	"bytes"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+func TestResources(t *testing.T) {
+	var s spec.Swagger
+	err := yaml.Unmarshal([]byte(`
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+paths:
+  /pets:
+    get: {responses: {200: {description: OK}}}
+    post: {responses: {201: {description: Created}}}
+  /pets/{petId}:
+    get: {responses: {200: {description: OK}}}
+    put: {responses: {200: {description: OK}}}
+    patch: {responses: {200: {description: OK}}}
+    delete: {responses: {204: {description: Deleted}}}
+  /pets/{petId}/adopt:
+    post: {responses: {204: {description: Adopted}}}
+  /owners/:
+    post: {responses: {201: {description: Created}}}
+  /owners/{ownerId}:
+    patch: {responses: {200: {description: OK}}}
+  /me:
+    get: {responses: {200: {description: OK}}}
+    put: {responses: {200: {description: OK}}}
+  /events:
+    post: {responses: {202: {description: Accepted}}}
+`), &s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := Resources(&s)
+	want := []Resource{
+		{Name: "/events", Collection: "/events", Methods: map[Verb][]string{Create: {"POST"}}},
+		{Name: "/me", Collection: "/me", Methods: map[Verb][]string{Read: {"GET"}, Update: {"PUT"}}},
+		{Name: "/owners", Collection: "/owners/", Item: "/owners/{ownerId}", Methods: map[Verb][]string{Create: {"POST"}, Update: {"PATCH"}}},
+		{Name: "/pets", Collection: "/pets", Item: "/pets/{petId}", Methods: map[Verb][]string{
+			List:   {"GET"},
+			Create: {"POST"},
+			Read:   {"GET"},
+			Update: {"PUT", "PATCH"},
+			Delete: {"DELETE"},
+		}},
+		{Name: "/pets/{petId}/adopt", Collection: "/pets/{petId}/adopt", Methods: map[Verb][]string{Create: {"POST"}}},
+	}
+	if diff := pretty.Compare(got, want); diff != "" {
+		t.Errorf("want != got: %s", diff)
+	}
+
+	var gaps []string
+	for i := range got {
+		gaps = append(gaps, got[i].Gaps()...)
+	}
+	wantGaps := []string{
+		"resource /events can be created but neither read nor listed",
+		"resource /owners can be updated but not read",
+		"resource /owners can be created but neither read nor listed",
+		"resource /pets/{petId}/adopt can be created but neither read nor listed",
+	}
+	if diff := pretty.Compare(gaps, wantGaps); diff != "" {
+		t.Errorf("gaps: want != got: %s", diff)
+	}
+
+	var csv bytes.Buffer
+	if err := WriteResourcesCSV(&csv, got[2:4]); err != nil {
+		t.Fatal(err)
+	}
+	wantCSV := `Resource,List,Create,Read,Update,Delete
+/owners,,POST,,PATCH,
+/pets,GET,POST,GET,"PUT, PATCH",DELETE
+`
+	if csv.String() != wantCSV {
+		t.Errorf("CSV: want %q, got %q", wantCSV, csv.String())
+	}
+}
@@ -0,0 +1,134 @@
+/*
+Command libswaggopher builds swaggopher's parser, validator and differ as a C
+shared library, so services in other languages can call them instead of
+maintaining validators of their own:
+
+	go build -buildmode=c-shared -o libswaggopher.so ./cmd/libswaggopher
+
+The build also writes libswaggopher.h, declaring:
+
+	int   swaggopher_abi_version(void);
+	char *swaggopher_parse(char *document);
+	char *swaggopher_resolve(char *document, char *files);
+	char *swaggopher_validate(char *document);
+	char *swaggopher_validate_value(char *document, char *definition, char *value);
+	char *swaggopher_diff(char *oldDocument, char *newDocument);
+	void  swaggopher_free(char *r);
+
+Arguments are NUL terminated UTF-8 strings, and documents may be JSON or YAML.
+files is a JSON object mapping the paths of the files a document refers to,
+relative to it, to their contents. Each function but swaggopher_free returns
+a JSON object holding the parsed or resolved document, the problems or changes
+found, or an error:
+
+	{"document": {...}}
+	{"problems": [{"path": "/paths/~1pets", "message": "..."}]}
+	{"changes": [{"kind": "removed", "severity": "breaking", "path": "...", "message": "..."}]}
+	{"error": "..."}
+
+Results are allocated with malloc and must be released with swaggopher_free.
+
+swaggopher_abi_version returns the version of this interface, which changes
+only if a function's signature or the meaning of a result does. The functions
+are safe to call from multiple threads.
+*/
+package main
+
+/*
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"encoding/json"
+	"unsafe"
+
+	"github.com/ericchiang/swaggopher/internal/jsonapi"
+)
+
+// abiVersion is returned by swaggopher_abi_version.
+const abiVersion = 1
+
+//export swaggopher_abi_version
+func swaggopher_abi_version() C.int {
+	return abiVersion
+}
+
+//export swaggopher_parse
+func swaggopher_parse(document *C.char) *C.char {
+	return result(parse(bytes(document)))
+}
+
+//export swaggopher_resolve
+func swaggopher_resolve(document, files *C.char) *C.char {
+	return result(resolve(bytes(document), bytes(files)))
+}
+
+//export swaggopher_validate
+func swaggopher_validate(document *C.char) *C.char {
+	return result(validate(bytes(document)))
+}
+
+//export swaggopher_validate_value
+func swaggopher_validate_value(document, definition, value *C.char) *C.char {
+	return result(validateValue(bytes(document), bytes(definition), bytes(value)))
+}
+
+//export swaggopher_diff
+func swaggopher_diff(oldDocument, newDocument *C.char) *C.char {
+	return result(diff(bytes(oldDocument), bytes(newDocument)))
+}
+
+//export swaggopher_free
+func swaggopher_free(r *C.char) {
+	C.free(unsafe.Pointer(r))
+}
+
+// bytes copies a C string, which may be NULL.
+func bytes(s *C.char) []byte {
+	if s == nil {
+		return nil
+	}
+	return []byte(C.GoString(s))
+}
+
+// result returns an encoded result as a C string, which the caller frees.
+func result(data []byte) *C.char {
+	return C.CString(string(data))
+}
+
+// The functions below implement the exported ones, taking the contents of
+// their arguments, nil for NULL, and returning the encoded result.
+
+func parse(document []byte) []byte {
+	return jsonapi.Parse(document).Encode()
+}
+
+func resolve(document, files []byte) []byte {
+	var contents map[string]string
+	if files != nil {
+		if err := json.Unmarshal(files, &contents); err != nil {
+			return (&jsonapi.Result{Error: "files: " + err.Error()}).Encode()
+		}
+	}
+	byPath := make(map[string][]byte, len(contents))
+	for path, data := range contents {
+		byPath[path] = []byte(data)
+	}
+	return jsonapi.Resolve(document, byPath).Encode()
+}
+
+func validate(document []byte) []byte {
+	return jsonapi.Validate(document).Encode()
+}
+
+func validateValue(document, definition, value []byte) []byte {
+	return jsonapi.ValidateValue(document, string(definition), value).Encode()
+}
+
+func diff(oldDocument, newDocument []byte) []byte {
+	return jsonapi.Diff(oldDocument, newDocument).Encode()
+}
+
+// main is required by -buildmode=c-shared, but isn't called.
+func main() {}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const petstore = `
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+paths: {}
+definitions:
+  Pet: {$ref: "pet.yaml"}
+`
+
+func TestFunctions(t *testing.T) {
+	tests := []struct {
+		name string
+		got  []byte
+		want string
+	}{
+		{
+			name: "parse",
+			got:  parse([]byte(`{"swagger": "2.0", "info": {"title": "Pets", "version": "1.0"}, "paths": {}}`)),
+			want: `{"document":{"swagger":"2.0","info":{"title":"Pets","version":"1.0"},"paths":{}}}`,
+		},
+		{
+			name: "parse NULL",
+			got:  parse(nil),
+			want: `{"document":{"swagger":"","info":null,"paths":null}}`,
+		},
+		{
+			name: "resolve",
+			got:  resolve([]byte(petstore), []byte(`{"pet.yaml": "type: object"}`)),
+			want: `{"document":{"swagger":"2.0","info":{"title":"Pets","version":"1.0"},"paths":{},"definitions":{"Pet":{"type":"object"}}}}`,
+		},
+		{
+			name: "resolve with NULL files",
+			got:  resolve([]byte(petstore), nil),
+			want: `{"error":`,
+		},
+		{
+			name: "resolve with invalid files",
+			got:  resolve([]byte(petstore), []byte(`["pet.yaml"]`)),
+			want: `{"error":"files: `,
+		},
+		{
+			name: "validate",
+			got:  validate([]byte(`{"swagger": "2.0", "paths": {}}`)),
+			want: `{"problems":[{"path":"/info","message":"info is required"}]}`,
+		},
+		{
+			name: "validate value",
+			got:  validateValue([]byte(`{"definitions": {"Pet": {"type": "object"}}}`), []byte("Pet"), []byte(`[]`)),
+			want: `{"problems":[{"path":"","message":"expected an object, got an array"}]}`,
+		},
+		{
+			name: "validate value with NULL definition",
+			got:  validateValue([]byte(petstore), nil, []byte(`{}`)),
+			want: `{"error":"no definition "}`,
+		},
+		{
+			name: "diff",
+			got:  diff([]byte(petstore), []byte(petstore)),
+			want: `{}`,
+		},
+		{
+			name: "diff with invalid new document",
+			got:  diff([]byte(petstore), []byte("swagger: [")),
+			want: `{"error":"new document: `,
+		},
+	}
+	for _, tt := range tests {
+		if !strings.HasPrefix(string(tt.got), tt.want) {
+			t.Errorf("%s: want %s, got %s", tt.name, tt.want, tt.got)
+		}
+	}
+	if swaggopher_abi_version() != abiVersion {
+		t.Errorf("swaggopher_abi_version: want %d, got %d", abiVersion, swaggopher_abi_version())
+	}
+}
@@ -0,0 +1,158 @@
+/*
+Command swaggopher-perf tracks the performance of parsing, validating and
+flattening documents, to catch changes which regress it.
+
+Usage:
+
+	swaggopher-perf run [-corpus dir] [-count n] [-bench regexp] > report.json
+	swaggopher-perf compare [-time percent] [-allocs percent] [-bytes percent] base.json head.json
+
+The run command benchmarks each operation on the documents of a corpus
+directory, perf/testdata by default, and on generated documents, and writes a
+JSON report of the time and allocations each took. The compare command
+compares the report of a change with that of its base, run on the same
+machine, and prints the benchmarks which became slower or allocate more by
+more than the given percentages:
+
+	git stash && swaggopher-perf run -count 5 > base.json
+	git stash pop && swaggopher-perf run -count 5 > head.json
+	swaggopher-perf compare base.json head.json
+
+Users can point -corpus at their own documents, to check a release keeps
+them fast. compare exits with status 1 if there are regressions, and errors
+exit with status 2.
+*/
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+
+	"github.com/ericchiang/swaggopher/perf"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return 2
+	}
+	var err error
+	switch args[0] {
+	case "run":
+		err = runBenchmarks(args[1:], stdout, stderr)
+	case "compare":
+		var regressed bool
+		regressed, err = runCompare(args[1:], stdout, stderr)
+		if err == nil && regressed {
+			return 1
+		}
+	default:
+		usage(stderr)
+		return 2
+	}
+	if err != nil {
+		if err != flag.ErrHelp {
+			fmt.Fprintf(stderr, "swaggopher-perf %s: %v\n", args[0], err)
+		}
+		return 2
+	}
+	return 0
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "usage: swaggopher-perf run [-corpus dir] [-count n] [-bench regexp]\n")
+	fmt.Fprintf(w, "       swaggopher-perf compare [-time percent] [-allocs percent] [-bytes percent] base.json head.json\n")
+}
+
+func flags(name string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("swaggopher-perf "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	return fs
+}
+
+func runBenchmarks(args []string, stdout, stderr io.Writer) error {
+	fs := flags("run", stderr)
+	corpus := fs.String("corpus", "perf/testdata", "directory of the documents to benchmark")
+	count := fs.Int("count", 1, "times to run each benchmark, of which the fastest is reported")
+	bench := fs.String("bench", "", "regular expression the benchmarks to run must match, such as ^parse/")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments %q", fs.Args())
+	}
+	o := perf.Options{Count: *count}
+	if *bench != "" {
+		re, err := regexp.Compile(*bench)
+		if err != nil {
+			return err
+		}
+		o.Filter = re
+	}
+	docs, err := perf.Corpus(*corpus)
+	if err != nil {
+		return err
+	}
+	report, err := perf.Run(docs, o)
+	if err != nil {
+		return err
+	}
+	for _, r := range report.Results {
+		fmt.Fprintf(stderr, "%-40s %12d ns/op %10d B/op %8d allocs/op\n", r.Name, r.NsPerOp, r.BytesPerOp, r.AllocsPerOp)
+	}
+	enc := json.NewEncoder(stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+func runCompare(args []string, stdout, stderr io.Writer) (regressed bool, err error) {
+	fs := flags("compare", stderr)
+	t := perf.DefaultThresholds
+	timePercent := fs.Float64("time", 100*t.Time, "percent slower a benchmark may become, or -1 to ignore time")
+	allocsPercent := fs.Float64("allocs", 100*t.Allocs, "percent more allocations a benchmark may make, or -1 to ignore them")
+	bytesPercent := fs.Float64("bytes", 100*t.Bytes, "percent more bytes a benchmark may allocate, or -1 to ignore them")
+	if err := fs.Parse(args); err != nil {
+		return false, err
+	}
+	if fs.NArg() != 2 {
+		return false, fmt.Errorf("expected the reports of a base and a head")
+	}
+	base, err := readReport(fs.Arg(0))
+	if err != nil {
+		return false, err
+	}
+	head, err := readReport(fs.Arg(1))
+	if err != nil {
+		return false, err
+	}
+	t = perf.Thresholds{Time: *timePercent / 100, Allocs: *allocsPercent / 100, Bytes: *bytesPercent / 100}
+	regressions, err := perf.Compare(base, head, t)
+	if err != nil {
+		return false, err
+	}
+	for _, r := range regressions {
+		fmt.Fprintln(stdout, r)
+	}
+	return len(regressions) > 0, nil
+}
+
+func readReport(path string) (*perf.Report, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var r perf.Report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return &r, nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	dir, err := ioutil.TempDir("", "swaggopher-perf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	base := write("base.json", `{"corpus": "sha256:a", "results": [{"name": "parse/a.yaml", "nsPerOp": 1000, "allocsPerOp": 10, "bytesPerOp": 100}]}`)
+	slower := write("slower.json", `{"corpus": "sha256:a", "results": [{"name": "parse/a.yaml", "nsPerOp": 1500, "allocsPerOp": 10, "bytesPerOp": 100}]}`)
+	other := write("other.json", `{"corpus": "sha256:b", "results": []}`)
+
+	tests := []struct {
+		args       []string
+		wantCode   int
+		wantStdout string
+	}{
+		{args: []string{"compare", base, base}, wantCode: 0},
+		{args: []string{"compare", base, slower}, wantCode: 1, wantStdout: "parse/a.yaml: ns/op increased from 1000 to 1500 (+50.0%)\n"},
+		{args: []string{"compare", "-time", "60", base, slower}, wantCode: 0},
+		{args: []string{"compare", "-time", "-1", base, slower}, wantCode: 0},
+		{args: []string{"compare", base, other}, wantCode: 2},
+		{args: []string{"compare", base}, wantCode: 2},
+		{args: []string{"compare", base, filepath.Join(dir, "missing.json")}, wantCode: 2},
+		{args: []string{"run", "-bench", "("}, wantCode: 2},
+		{args: []string{"run", "-corpus", filepath.Join(dir, "missing")}, wantCode: 2},
+		{args: []string{"bench"}, wantCode: 2},
+		{args: nil, wantCode: 2},
+	}
+	for i, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		if code := run(tt.args, &stdout, &stderr); code != tt.wantCode {
+			t.Errorf("case %d: %v: want exit code %d, got %d: %s", i, tt.args, tt.wantCode, code, stderr.String())
+		}
+		if !strings.Contains(stdout.String(), tt.wantStdout) {
+			t.Errorf("case %d: %v: want stdout to contain %q, got %q", i, tt.args, tt.wantStdout, stdout.String())
+		}
+	}
+}
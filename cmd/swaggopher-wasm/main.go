@@ -0,0 +1,92 @@
+//go:build js && wasm
+
+/*
+Command swaggopher-wasm runs swaggopher's parser, resolver and validator in
+browsers and other JavaScript runtimes. Build it with:
+
+	GOOS=js GOARCH=wasm go build -o swaggopher.wasm ./cmd/swaggopher-wasm
+
+or, for a smaller module, with TinyGo:
+
+	tinygo build -o swaggopher.wasm -target wasm ./cmd/swaggopher-wasm
+
+and load it with the wasm_exec.js of the same toolchain. It defines a global
+swaggopher object, whose functions take documents as JSON or YAML strings:
+
+	swaggopher.parse(document)
+	swaggopher.resolve(document, {"common.yaml": "..."})
+	swaggopher.validate(document)
+	swaggopher.validateValue(document, "Pet", '{"name": "Rex"}')
+
+Each returns an object holding the parsed or resolved document, the problems
+validation found, or an error:
+
+	{"document": {...}}
+	{"problems": [{"path": "/paths/~1pets", "message": "..."}]}
+	{"error": "..."}
+
+Other files a document refers to are passed to resolve, keyed by their path
+relative to it, since there's no file system to read them from. Remote
+references aren't fetched.
+
+WASI runtimes can run the swaggopher command itself, built with
+GOOS=wasip1 GOARCH=wasm.
+
+Its tests run under Node.js, with the go_js_wasm_exec script of the Go
+distribution on the PATH:
+
+	PATH="$PATH:$(go env GOROOT)/lib/wasm" GOOS=js GOARCH=wasm go test ./cmd/swaggopher-wasm
+*/
+package main
+
+import (
+	"syscall/js"
+
+	"github.com/ericchiang/swaggopher/internal/jsonapi"
+)
+
+func main() {
+	register()
+
+	// The functions are called after main returns, so it must not.
+	select {}
+}
+
+// register defines the global swaggopher object.
+func register() {
+	api := js.Global().Get("Object").New()
+	api.Set("parse", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		return result(jsonapi.Parse(arg(args, 0)))
+	}))
+	api.Set("resolve", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		files := make(map[string][]byte)
+		if len(args) > 1 && args[1].Type() == js.TypeObject {
+			keys := js.Global().Get("Object").Call("keys", args[1])
+			for i := 0; i < keys.Length(); i++ {
+				name := keys.Index(i).String()
+				files[name] = []byte(args[1].Get(name).String())
+			}
+		}
+		return result(jsonapi.Resolve(arg(args, 0), files))
+	}))
+	api.Set("validate", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		return result(jsonapi.Validate(arg(args, 0)))
+	}))
+	api.Set("validateValue", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		return result(jsonapi.ValidateValue(arg(args, 0), string(arg(args, 1)), arg(args, 2)))
+	}))
+	js.Global().Set("swaggopher", api)
+}
+
+// arg returns the i'th argument as a string, or nothing if it's missing.
+func arg(args []js.Value, i int) []byte {
+	if i >= len(args) || args[i].Type() != js.TypeString {
+		return nil
+	}
+	return []byte(args[i].String())
+}
+
+// result converts a result to a JavaScript object.
+func result(r *jsonapi.Result) js.Value {
+	return js.Global().Get("JSON").Call("parse", string(r.Encode()))
+}
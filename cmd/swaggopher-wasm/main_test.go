@@ -0,0 +1,71 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"strings"
+	"syscall/js"
+	"testing"
+)
+
+const petstore = `{
+  "swagger": "2.0",
+  "info": {"title": "Pets", "version": "1.0"},
+  "paths": {},
+  "definitions": {"Pet": {"$ref": "pet.json"}}
+}`
+
+func TestFunctions(t *testing.T) {
+	register()
+	api := js.Global().Get("swaggopher")
+	files := js.Global().Get("Object").New()
+	files.Set("pet.json", `{"type": "object"}`)
+
+	tests := []struct {
+		name string
+		args []interface{}
+		want string
+	}{
+		{
+			name: "parse",
+			args: []interface{}{`{"swagger": "2.0", "info": {"title": "Pets", "version": "1.0"}, "paths": {}}`},
+			want: `{"document":{"swagger":"2.0","info":{"title":"Pets","version":"1.0"},"paths":{}}}`,
+		},
+		{
+			name: "parse",
+			args: []interface{}{`{"swagger": `},
+			want: `{"error":`,
+		},
+		{
+			name: "resolve",
+			args: []interface{}{petstore, files},
+			want: `{"document":{"swagger":"2.0","info":{"title":"Pets","version":"1.0"},"paths":{},"definitions":{"Pet":{"type":"object"}}}}`,
+		},
+		{
+			name: "resolve",
+			args: []interface{}{petstore},
+			want: `{"error":`,
+		},
+		{
+			name: "validate",
+			args: []interface{}{`{"swagger": "2.0", "paths": {}}`},
+			want: `{"problems":[{"path":"/info","message":"info is required"}]}`,
+		},
+		{
+			name: "validateValue",
+			args: []interface{}{`{"definitions": {"Pet": {"type": "object"}}}`, "Pet", `[]`},
+			want: `{"problems":[{"path":"","message":"expected an object, got an array"}]}`,
+		},
+		{
+			name: "validateValue",
+			args: []interface{}{petstore, 1, `{}`},
+			want: `{"error":"no definition "}`,
+		},
+	}
+	for _, tt := range tests {
+		r := api.Call(tt.name, tt.args...)
+		if got := js.Global().Get("JSON").Call("stringify", r).String(); !strings.HasPrefix(got, tt.want) {
+			t.Errorf("%s%v: want %s, got %s", tt.name, tt.args, tt.want, got)
+		}
+	}
+}
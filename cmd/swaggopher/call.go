@@ -0,0 +1,325 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/coerce"
+	"github.com/ericchiang/swaggopher/fixture"
+	"github.com/ericchiang/swaggopher/internal/httpcheck"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// multiFlag collects the values of a flag given more than once.
+type multiFlag []string
+
+func (m *multiFlag) String() string { return strings.Join(*m, ",") }
+
+func (m *multiFlag) Set(s string) error {
+	*m = append(*m, s)
+	return nil
+}
+
+func runCall(c *cli, args []string) error {
+	fs := c.flags("call")
+	var params, auth multiFlag
+	fs.Var(&params, "param", "a parameter as name=value, or in.name=value such as query.limit=10; repeat for each parameter or list element")
+	data := fs.String("data", "", "the JSON body, or @file to read it from a file")
+	server := fs.String("server", "", "URL to send the request to, which the basePath is appended to (default: from the document's schemes and host)")
+	fs.Var(&auth, "auth", "credentials for a security scheme as name=value: an API key, user:password for basic auth, or an OAuth2 access token")
+	force := fs.Bool("force", false, "send the request even if it doesn't satisfy the operation's parameters")
+	verbose := fs.Bool("v", false, "print the status and headers of the response to stderr")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	// Flags may also follow the document and operation, as in
+	// "call pets.yaml getPet -param petId=1".
+	positional := fs.Args()
+	if len(positional) > 2 {
+		if err := fs.Parse(positional[2:]); err != nil {
+			return err
+		}
+		positional = append(positional[:2:2], fs.Args()...)
+	}
+	if len(positional) != 2 {
+		return usageError("expected a document and an operation")
+	}
+
+	src, err := c.read(positional[0])
+	if err != nil {
+		return err
+	}
+	doc, err := parse(src)
+	if err != nil {
+		return err
+	}
+	method, path, op, ok := fixture.Find(doc, positional[1])
+	if !ok {
+		return fmt.Errorf("no operation %q", positional[1])
+	}
+
+	var body []byte
+	if *data != "" {
+		body = []byte(*data)
+		if strings.HasPrefix(*data, "@") {
+			if body, err = c.read(strings.TrimPrefix(*data, "@")); err != nil {
+				return err
+			}
+		}
+	}
+	base := *server
+	if base == "" {
+		if base, err = serverOf(doc); err != nil {
+			return err
+		}
+	}
+
+	b := &callBuilder{doc: doc, path: path, op: op, query: url.Values{}, header: http.Header{}, form: url.Values{}, vars: map[string]string{}}
+	if err := b.parameters(params, body); err != nil {
+		return usageError(err.Error())
+	}
+	if err := b.authenticate(auth); err != nil {
+		return usageError(err.Error())
+	}
+	req, err := b.request(method, strings.TrimSuffix(base, "/")+strings.TrimSuffix(doc.BasePath, "/"), body)
+	if err != nil {
+		return err
+	}
+
+	item := doc.Paths[path]
+	m := &httpcheck.Match{Template: path, Method: req.Method, Item: &item, Operation: op, Vars: b.vars}
+	if problems := httpcheck.Check(doc, m, req); len(problems) > 0 && !*force {
+		for _, p := range problems {
+			fmt.Fprintf(c.stderr, "request: %s\n", p.Message)
+		}
+		return fmt.Errorf("request doesn't satisfy %s, use -force to send it anyway", m)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if *verbose {
+		fmt.Fprintf(c.stderr, "%s %s\n", resp.Proto, resp.Status)
+		resp.Header.Write(c.stderr)
+		fmt.Fprintln(c.stderr)
+	}
+	if _, err := c.stdout.Write(respBody); err != nil {
+		return err
+	}
+	if msgs := httpcheck.Response(doc, op, resp.StatusCode, resp.Header, respBody); len(msgs) > 0 {
+		for _, msg := range msgs {
+			fmt.Fprintf(c.stderr, "response: %s\n", msg)
+		}
+		return errProblems
+	}
+	return nil
+}
+
+// serverOf returns the URL of the server a document's host and schemes
+// describe, preferring https, for commands whose -server flag wasn't given.
+func serverOf(doc *spec.Swagger) (string, error) {
+	if doc.Host == "" {
+		return "", usageError("the document has no host, so -server is required")
+	}
+	scheme := "https"
+	if len(doc.Schemes) > 0 && !contains(doc.Schemes, "https") {
+		scheme = doc.Schemes[0]
+	}
+	return scheme + "://" + doc.Host, nil
+}
+
+// callBuilder builds the request of the call command.
+type callBuilder struct {
+	doc    *spec.Swagger
+	path   string
+	op     *spec.Operation
+	query  url.Values
+	header http.Header
+	form   url.Values
+	// vars holds the values of path parameters.
+	vars map[string]string
+}
+
+// parameters sets the values given by -param flags, and checks that a body is
+// only given to operations which take one.
+func (b *callBuilder) parameters(flags []string, body []byte) error {
+	item := b.doc.Paths[b.path]
+	declared := b.doc.OperationParameters(&item, b.op)
+	values := make(map[*spec.Parameter][]string)
+	var order []*spec.Parameter
+	for _, f := range flags {
+		i := strings.Index(f, "=")
+		if i < 0 {
+			return fmt.Errorf("-param %q must be name=value", f)
+		}
+		name, value := f[:i], f[i+1:]
+		var param *spec.Parameter
+		for _, p := range declared {
+			if p.In != "body" && fixture.Matches(name, p) {
+				param = p
+				break
+			}
+		}
+		if param == nil {
+			return fmt.Errorf("the operation has no parameter %q", name)
+		}
+		if _, ok := values[param]; !ok {
+			order = append(order, param)
+		}
+		values[param] = append(values[param], value)
+	}
+
+	hasBody := false
+	for _, p := range declared {
+		hasBody = hasBody || p.In == "body"
+	}
+	if len(body) > 0 && !hasBody {
+		return fmt.Errorf("the operation has no body parameter")
+	}
+
+	for _, p := range order {
+		vals := values[p]
+		if p.CollectionFormat != "multi" {
+			sep, err := coerce.Separator(p.CollectionFormat)
+			if err != nil {
+				return err
+			}
+			vals = []string{strings.Join(vals, sep)}
+		}
+		for _, v := range vals {
+			switch p.In {
+			case "path":
+				b.vars[p.Name] = v
+			case "query":
+				b.query.Add(p.Name, v)
+			case "header":
+				b.header.Add(p.Name, v)
+			case "formData":
+				b.form.Add(p.Name, v)
+			}
+		}
+	}
+	return nil
+}
+
+// authenticate applies the credentials given by -auth flags to the first of
+// the operation's security requirements they satisfy. Without one, the request
+// is sent without credentials.
+func (b *callBuilder) authenticate(flags []string) error {
+	creds := make(map[string]string)
+	for _, f := range flags {
+		i := strings.Index(f, "=")
+		if i < 0 {
+			return fmt.Errorf("-auth %q must be name=value", f)
+		}
+		name := f[:i]
+		if _, ok := b.doc.SecurityDefinitions[name]; !ok {
+			return fmt.Errorf("no security scheme named %q", name)
+		}
+		creds[name] = f[i+1:]
+	}
+	if len(creds) == 0 {
+		return nil
+	}
+	reqs, err := b.doc.ResolveSecurity(b.op)
+	if err != nil {
+		return err
+	}
+	for _, req := range reqs {
+		satisfied := true
+		for _, applied := range req {
+			if _, ok := creds[applied.Name]; !ok {
+				satisfied = false
+			}
+		}
+		if !satisfied {
+			continue
+		}
+		for _, applied := range req {
+			scheme, cred := &applied.Scheme, creds[applied.Name]
+			if in, name, ok := scheme.APIKey(); ok {
+				if in == "query" {
+					b.query.Set(name, cred)
+				} else {
+					b.header.Set(name, cred)
+				}
+				continue
+			}
+			if scheme.IsBasic() {
+				if !strings.Contains(cred, ":") {
+					return fmt.Errorf("-auth %s must be user:password", applied.Name)
+				}
+				b.header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(cred)))
+				continue
+			}
+			if _, ok := scheme.OAuth2(); ok {
+				b.header.Set("Authorization", "Bearer "+cred)
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("the credentials don't satisfy any of the operation's security requirements")
+}
+
+func (b *callBuilder) request(method, base string, body []byte) (*http.Request, error) {
+	path := b.path
+	for name, v := range b.vars {
+		path = strings.Replace(path, "{"+name+"}", url.PathEscape(v), -1)
+	}
+	u := base + path
+	if len(b.query) > 0 {
+		u += "?" + b.query.Encode()
+	}
+
+	var r io.Reader
+	contentType := ""
+	switch {
+	case len(body) > 0:
+		r, contentType = bytes.NewReader(body), "application/json"
+	case len(b.form) > 0:
+		r, contentType = strings.NewReader(b.form.Encode()), "application/x-www-form-urlencoded"
+	}
+	req, err := http.NewRequest(strings.ToUpper(method), u, r)
+	if err != nil {
+		return nil, err
+	}
+	for name, vals := range b.header {
+		req.Header[name] = vals
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if req.Header.Get("Accept") == "" {
+		produces := b.op.Produces
+		if len(produces) == 0 {
+			produces = b.doc.Produces
+		}
+		accept := "application/json"
+		if len(produces) > 0 {
+			accept = strings.Join(produces, ", ")
+		}
+		req.Header.Set("Accept", accept)
+	}
+	return req, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,832 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/bundle"
+	"github.com/ericchiang/swaggopher/catalog"
+	"github.com/ericchiang/swaggopher/compat"
+	"github.com/ericchiang/swaggopher/convert"
+	"github.com/ericchiang/swaggopher/diff"
+	"github.com/ericchiang/swaggopher/docs/markdown"
+	"github.com/ericchiang/swaggopher/docscore"
+	"github.com/ericchiang/swaggopher/dsl"
+	"github.com/ericchiang/swaggopher/export/postman"
+	"github.com/ericchiang/swaggopher/export/proto"
+	"github.com/ericchiang/swaggopher/gen"
+	"github.com/ericchiang/swaggopher/gen/client"
+	"github.com/ericchiang/swaggopher/gen/models"
+	"github.com/ericchiang/swaggopher/gen/server"
+	"github.com/ericchiang/swaggopher/har"
+	"github.com/ericchiang/swaggopher/lint"
+	"github.com/ericchiang/swaggopher/loadtest"
+	"github.com/ericchiang/swaggopher/resolver"
+	"github.com/ericchiang/swaggopher/spec"
+	"github.com/ericchiang/swaggopher/spec3"
+	"github.com/ericchiang/swaggopher/subset"
+	"github.com/ericchiang/swaggopher/validate"
+)
+
+func runValidate(c *cli, args []string) error {
+	fs := c.flags("validate")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"-"}
+	}
+	sources := make([]validate.Source, len(paths))
+	for i, path := range paths {
+		sources[i] = validate.Source{Name: path}
+		if path == "-" {
+			data, err := c.read(path)
+			if err != nil {
+				return err
+			}
+			sources[i] = validate.Source{Name: "<stdin>", Data: data}
+		}
+	}
+
+	failed := false
+	for _, r := range validate.All(context.Background(), sources, validate.Options{}) {
+		if r.Err != nil {
+			fmt.Fprintf(c.stderr, "%s: %v\n", r.Name, r.Err)
+			failed = true
+			continue
+		}
+		for _, e := range r.Errors {
+			fmt.Fprintf(c.stdout, "%s: %s\n", r.Name, e)
+			failed = true
+		}
+	}
+	if failed {
+		return errProblems
+	}
+	return nil
+}
+
+func runConvert(c *cli, args []string) error {
+	fs := c.flags("convert")
+	to := fs.String("to", "", "version to convert to, 2.0, 3.0 or asyncapi (default: the one the document isn't)")
+	format := fs.String("format", "", "output format, json or yaml (default: the input's)")
+	tags := fs.String("tags", "", "comma separated tags of operations to convert to asyncapi channels")
+	paths := fs.String("paths", "", "comma separated globs of paths to convert to asyncapi channels")
+	ids := fs.String("operations", "", "comma separated IDs of operations to convert to asyncapi channels")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	sel := subset.Selection{Tags: list(*tags), Paths: list(*paths), OperationIDs: list(*ids)}
+	if *to != "asyncapi" && (len(sel.Tags) > 0 || len(sel.Paths) > 0 || len(sel.OperationIDs) > 0) {
+		return usageError("-tags, -paths and -operations only apply to -to asyncapi")
+	}
+	path, err := input(fs.Args())
+	if err != nil {
+		return err
+	}
+	data, err := c.read(path)
+	if err != nil {
+		return err
+	}
+	out, err := outputFormat(*format, data)
+	if err != nil {
+		return err
+	}
+	from, err := version(data)
+	if err != nil {
+		return err
+	}
+	from = majorMinor(from)
+	target := majorMinor(*to)
+	if target == "" {
+		target = "3.0"
+		if from == "3.0" {
+			target = "2.0"
+		}
+	}
+
+	switch {
+	case from == "2.0" && target == "asyncapi":
+		s, err := parse(data)
+		if err != nil {
+			return err
+		}
+		a, losses, err := convert.ConvertToAsyncAPI(s, sel)
+		if err != nil {
+			return err
+		}
+		for _, l := range losses {
+			fmt.Fprintf(c.stderr, "warning: %s\n", l)
+		}
+		return c.write(a, out)
+	case from == "2.0" && target == "3.0":
+		s, err := parse(data)
+		if err != nil {
+			return err
+		}
+		o, err := convert.Convert2To3(s)
+		if err != nil {
+			return err
+		}
+		return c.write(o, out)
+	case from == "3.0" && target == "2.0":
+		var o spec3.OpenAPI
+		if err := decode(data, &o); err != nil {
+			return err
+		}
+		s, losses, err := convert.Convert3To2(&o)
+		if err != nil {
+			return err
+		}
+		for _, l := range losses {
+			fmt.Fprintf(c.stderr, "warning: %s\n", l)
+		}
+		return c.write(s, out)
+	case from == target:
+		return fmt.Errorf("document is already version %s", from)
+	}
+	return fmt.Errorf("can't convert version %s to %s", from, target)
+}
+
+func runCompile(c *cli, args []string) error {
+	fs := c.flags("compile")
+	to := fs.String("to", "2.0", "version to compile to, 2.0 or 3.0")
+	format := fs.String("format", "", "output format, json or yaml (default: the input's)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	path, err := input(fs.Args())
+	if err != nil {
+		return err
+	}
+	data, err := c.read(path)
+	if err != nil {
+		return err
+	}
+	out, err := outputFormat(*format, data)
+	if err != nil {
+		return err
+	}
+	api, err := dsl.Parse(data)
+	if err != nil {
+		return err
+	}
+	switch majorMinor(*to) {
+	case "2.0":
+		doc, err := api.Compile()
+		if err != nil {
+			return err
+		}
+		return c.write(doc, out)
+	case "3.0":
+		doc, err := api.OpenAPI()
+		if err != nil {
+			return err
+		}
+		return c.write(doc, out)
+	}
+	return usageError(fmt.Sprintf("unknown version %q, must be 2.0 or 3.0", *to))
+}
+
+func runBundle(c *cli, args []string) error {
+	return bundleDocument(c, "bundle", args, resolver.Resolve)
+}
+
+func runFlatten(c *cli, args []string) error {
+	return bundleDocument(c, "flatten", args, resolver.Flatten)
+}
+
+// bundleDocument runs the bundle and flatten commands, which differ only in
+// how references left once the document is bundled are resolved.
+func bundleDocument(c *cli, name string, args []string, resolve func(*spec.Swagger, ...resolver.Option) error) error {
+	fs := c.flags(name)
+	format := fs.String("format", "", "output format, json or yaml (default: the input's)")
+	dryRun := fs.Bool("dry-run", false, "report the documents which would be loaded and values copied without writing the result")
+	progress := fs.Bool("progress", false, "report progress to stderr")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	path, err := input(fs.Args())
+	if err != nil {
+		return err
+	}
+	data, err := c.read(path)
+	if err != nil {
+		return err
+	}
+	out, err := outputFormat(*format, data)
+	if err != nil {
+		return err
+	}
+	var s *spec.Swagger
+	if path == "-" {
+		// Documents read from stdin have no location to bundle from, so
+		// their references are only resolved.
+		s, err = parse(data)
+	} else {
+		opts := bundle.Options{Loader: c.preloaded(path, data), DryRun: *dryRun}
+		if *dryRun {
+			opts.Logger = log.New(c.stdout, "", 0)
+		}
+		if *progress {
+			opts.Progress = func(done, total int, item string) {
+				fmt.Fprintf(c.stderr, "[%d/%d] %s\n", done, total, item)
+			}
+		}
+		s, err = opts.Bundle(path)
+	}
+	if err != nil || *dryRun {
+		return err
+	}
+	if err := resolve(s, c.resolverOptions(path)...); err != nil {
+		return err
+	}
+	return c.write(s, out)
+}
+
+func runSubset(c *cli, args []string) error {
+	fs := c.flags("subset")
+	tags := fs.String("tags", "", "comma separated tags of operations to keep")
+	paths := fs.String("paths", "", `comma separated globs of paths to keep, such as "/pets/*" or "/admin/**"`)
+	ids := fs.String("operations", "", "comma separated IDs of operations to keep")
+	format := fs.String("format", "", "output format, json or yaml (default: the input's)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	path, err := input(fs.Args())
+	if err != nil {
+		return err
+	}
+	data, err := c.read(path)
+	if err != nil {
+		return err
+	}
+	out, err := outputFormat(*format, data)
+	if err != nil {
+		return err
+	}
+	s, err := parse(data)
+	if err != nil {
+		return err
+	}
+	sel := subset.Selection{Tags: list(*tags), Paths: list(*paths), OperationIDs: list(*ids)}
+	if len(sel.Tags) == 0 && len(sel.Paths) == 0 && len(sel.OperationIDs) == 0 {
+		return usageError("one of -tags, -paths or -operations is required")
+	}
+	trimmed, err := subset.Export(s, sel)
+	if err != nil {
+		return err
+	}
+	return c.write(trimmed, out)
+}
+
+// list splits a comma separated flag value.
+func list(flagValue string) []string {
+	var values []string
+	for _, v := range strings.Split(flagValue, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func runDiff(c *cli, args []string) error {
+	fs := c.flags("diff")
+	mode := fs.String("mode", "", "compatibility to check: backward, forward or full, or drift to report every difference (default: drift if either document is a URL, otherwise backward)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return usageError("expected an old and a new document")
+	}
+	if *mode == "" {
+		*mode = "backward"
+		if isURL(fs.Arg(0)) || isURL(fs.Arg(1)) {
+			*mode = "drift"
+		}
+	}
+	var m compat.Mode
+	switch *mode {
+	case "backward":
+		m = compat.Backward
+	case "forward":
+		m = compat.Forward
+	case "full":
+		m = compat.Full
+	case "drift":
+	default:
+		return usageError(fmt.Sprintf("unknown mode %q", *mode))
+	}
+
+	var docs [2]*spec.Swagger
+	for i, path := range fs.Args() {
+		data, err := c.read(path)
+		if err != nil {
+			return err
+		}
+		if docs[i], err = parse(data); err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+	}
+	if *mode == "drift" {
+		return c.drift(fs.Arg(0), fs.Arg(1), docs[0], docs[1])
+	}
+	found := compat.Check(docs[0].Definitions, docs[1].Definitions, m)
+	for _, inc := range found {
+		fmt.Fprintln(c.stdout, inc)
+	}
+	if len(found) > 0 {
+		return errProblems
+	}
+	return nil
+}
+
+// drift reports every difference between two documents, such as one in a
+// repository and the one a server publishes. Both are normalized first by
+// resolving their references, so a document split across files compares
+// equal to the bundled document a server is likely to publish, and a schema
+// defined inline to the same schema given by reference.
+func (c *cli) drift(localPath, remotePath string, local, remote *spec.Swagger) error {
+	for _, d := range []struct {
+		path string
+		doc  *spec.Swagger
+	}{{localPath, local}, {remotePath, remote}} {
+		if err := resolver.Resolve(d.doc, c.resolverOptions(d.path)...); err != nil {
+			return fmt.Errorf("%s: %v", d.path, err)
+		}
+	}
+	changes := diff.Compare(local, remote)
+	for _, change := range changes {
+		fmt.Fprintln(c.stdout, change)
+	}
+	if len(changes) > 0 {
+		return errProblems
+	}
+	return nil
+}
+
+func runChanges(c *cli, args []string) error {
+	fs := c.flags("changes")
+	failOn := fs.String("fail-on", "breaking", "lowest severity which fails: non-breaking, deprecation or breaking")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return usageError("expected an old and a new document")
+	}
+	threshold, err := diff.ParseSeverity(*failOn)
+	if err != nil {
+		return usageError(err.Error())
+	}
+
+	var docs [2]*spec.Swagger
+	for i, path := range fs.Args() {
+		data, err := c.read(path)
+		if err != nil {
+			return err
+		}
+		if docs[i], err = parse(data); err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+	}
+	changes := diff.Compare(docs[0], docs[1])
+	for _, change := range changes {
+		fmt.Fprintln(c.stdout, change)
+	}
+	if len(diff.Filter(changes, threshold)) > 0 {
+		return errProblems
+	}
+	return nil
+}
+
+func runLoadTest(c *cli, args []string) error {
+	fs := c.flags("loadtest")
+	format := fs.String("format", "k6", "output format: k6 for a k6 script, vegeta for targets in Vegeta's JSON format, or curl for a shell script")
+	warmup := fs.Bool("warmup", false, "only include requests which are safe to warm up a deployment with: GET operations whose path parameters have defaults or enums")
+	server := fs.String("server", "", "URL to send requests to, which the basePath is appended to (default: from the document's schemes and host)")
+	var weights, headers multiFlag
+	fs.Var(&weights, "weight", "relative frequency of an operation as operation=n, where 0 leaves it out; repeat for each operation (default: 1)")
+	fs.Var(&headers, "header", "a header to send with every request, such as \"Authorization: Bearer token\"; repeat for each header")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	var write func(w io.Writer, targets []loadtest.Target) error
+	switch *format {
+	case "k6":
+		write = loadtest.WriteK6
+	case "vegeta":
+		write = loadtest.WriteVegeta
+	case "curl":
+		write = loadtest.WriteCurl
+	default:
+		return usageError(fmt.Sprintf("unknown format %q, must be k6, vegeta or curl", *format))
+	}
+	opts := loadtest.Options{Weights: make(map[string]int), Header: make(http.Header)}
+	for _, w := range weights {
+		i := strings.LastIndex(w, "=")
+		n, err := strconv.Atoi(w[i+1:])
+		if i < 0 || err != nil {
+			return usageError(fmt.Sprintf("-weight %q must be operation=n", w))
+		}
+		opts.Weights[w[:i]] = n
+	}
+	for _, h := range headers {
+		i := strings.Index(h, ":")
+		if i < 0 {
+			return usageError(fmt.Sprintf("-header %q must be \"Name: value\"", h))
+		}
+		opts.Header.Add(strings.TrimSpace(h[:i]), strings.TrimSpace(h[i+1:]))
+	}
+
+	path, err := input(fs.Args())
+	if err != nil {
+		return err
+	}
+	data, err := c.read(path)
+	if err != nil {
+		return err
+	}
+	s, err := parse(data)
+	if err != nil {
+		return err
+	}
+	base := *server
+	if base == "" {
+		if base, err = serverOf(s); err != nil {
+			return err
+		}
+	}
+	plan := loadtest.Plan
+	if *warmup {
+		plan = loadtest.Warmup
+	}
+	targets, err := plan(base, s, opts)
+	if err != nil {
+		return err
+	}
+	return write(c.stdout, targets)
+}
+
+func runExport(c *cli, args []string) error {
+	fs := c.flags("export")
+	format := fs.String("format", "csv", "output format, csv, xlsx, postman or proto")
+	resources := fs.Bool("resources", false, "list the CRUD verbs each resource supports instead of operations")
+	pkg := fs.String("package", "", "package of proto definitions (default: the title in snake case)")
+	goPackage := fs.String("go-package", "", "go_package option of proto definitions")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	var write func(w io.Writer, rows []catalog.Row) error
+	var writeResources func(w io.Writer, resources []catalog.Resource) error
+	switch *format {
+	case "csv":
+		write, writeResources = catalog.WriteCSV, catalog.WriteResourcesCSV
+	case "xlsx":
+		write, writeResources = catalog.WriteXLSX, catalog.WriteResourcesXLSX
+	case "postman", "proto":
+		if *resources {
+			return usageError(fmt.Sprintf("-resources can't be exported as %s", *format))
+		}
+	default:
+		return usageError(fmt.Sprintf("unknown format %q, must be csv, xlsx, postman or proto", *format))
+	}
+	path, err := input(fs.Args())
+	if err != nil {
+		return err
+	}
+	data, err := c.read(path)
+	if err != nil {
+		return err
+	}
+	s, err := parse(data)
+	if err != nil {
+		return err
+	}
+	if *format == "postman" {
+		collection, err := postman.Export(s)
+		if err != nil {
+			return err
+		}
+		return c.write(collection, "json")
+	}
+	if *format == "proto" {
+		out, losses, err := proto.Options{Package: *pkg, GoPackage: *goPackage}.Export(s)
+		if err != nil {
+			return err
+		}
+		for _, l := range losses {
+			fmt.Fprintf(c.stderr, "warning: %s\n", l)
+		}
+		_, err = c.stdout.Write(out)
+		return err
+	}
+	if *resources {
+		return writeResources(c.stdout, catalog.Resources(s))
+	}
+	return write(c.stdout, catalog.Rows(s))
+}
+
+func runImport(c *cli, args []string) error {
+	fs := c.flags("import")
+	from := fs.String("from", "postman", "format to import, postman or har")
+	format := fs.String("format", "", "output format, json or yaml (default: the input's)")
+	templates := fs.String("templates", "", "comma separated path templates to group a HAR file's requests under")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *from != "postman" && *from != "har" {
+		return usageError(fmt.Sprintf("unknown format %q, must be postman or har", *from))
+	}
+	path, err := input(fs.Args())
+	if err != nil {
+		return err
+	}
+	data, err := c.read(path)
+	if err != nil {
+		return err
+	}
+	out, err := outputFormat(*format, data)
+	if err != nil {
+		return err
+	}
+	var s *spec.Swagger
+	switch *from {
+	case "postman":
+		var collection postman.Collection
+		if err := json.Unmarshal(data, &collection); err != nil {
+			return err
+		}
+		s, err = postman.Import(&collection)
+	case "har":
+		var h har.HAR
+		if err := json.Unmarshal(data, &h); err != nil {
+			return err
+		}
+		s, err = har.Options{Paths: list(*templates)}.Infer(&h)
+	}
+	if err != nil {
+		return err
+	}
+	return c.write(s, out)
+}
+
+func runDocs(c *cli, args []string) error {
+	fs := c.flags("docs")
+	level := fs.Int("level", 1, "level of the title's heading, from 1 to 3")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *level < 1 || *level > 3 {
+		return usageError(fmt.Sprintf("heading level %d isn't between 1 and 3", *level))
+	}
+	path, err := input(fs.Args())
+	if err != nil {
+		return err
+	}
+	data, err := c.read(path)
+	if err != nil {
+		return err
+	}
+	s, err := parse(data)
+	if err != nil {
+		return err
+	}
+	out, err := markdown.Options{Level: *level}.Render(s)
+	if err != nil {
+		return err
+	}
+	_, err = c.stdout.Write(out)
+	return err
+}
+
+func runScore(c *cli, args []string) error {
+	fs := c.flags("score")
+	minDescription := fs.Int("min-description", 40, "length of a description which earns full marks")
+	minScore := fs.Float64("min-score", 0, "lowest percentage which passes")
+	format := fs.String("format", "text", "output format, text or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *format != "text" && *format != "json" {
+		return usageError(fmt.Sprintf("unknown format %q, must be text or json", *format))
+	}
+	path, err := input(fs.Args())
+	if err != nil {
+		return err
+	}
+	data, err := c.read(path)
+	if err != nil {
+		return err
+	}
+	s, err := parse(data)
+	if err != nil {
+		return err
+	}
+	report := docscore.Options{MinDescription: *minDescription}.Score(s)
+	if *format == "json" {
+		if err := c.write(report, "json"); err != nil {
+			return err
+		}
+	} else {
+		fmt.Fprintf(c.stdout, "grade %s (%.0f%%)\n", report.Grade, report.Score*100)
+		for _, it := range report.Worst() {
+			if it.Score < 1 {
+				fmt.Fprintln(c.stdout, it)
+			}
+		}
+	}
+	if report.Score*100 < *minScore {
+		return errProblems
+	}
+	return nil
+}
+
+func runLint(c *cli, args []string) error {
+	fs := c.flags("lint")
+	config := fs.String("config", "", "rule configuration file")
+	baseline := fs.String("baseline", "", "baseline file of known findings to ignore")
+	updateBaseline := fs.Bool("update-baseline", false, "remove resolved findings from the baseline file")
+	writeBaseline := fs.Bool("write-baseline", false, "record every current finding in the baseline file")
+	fix := fs.Bool("fix", false, "apply fixes to the document in place")
+	failOn := fs.String("fail-on", "warn", "lowest severity which fails: hint, info, warn or error")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	path, err := input(fs.Args())
+	if err != nil {
+		return err
+	}
+	threshold, err := lint.ParseSeverity(*failOn)
+	if err != nil {
+		return usageError(err.Error())
+	}
+	if (*updateBaseline || *writeBaseline) && *baseline == "" {
+		return usageError("-update-baseline and -write-baseline require -baseline")
+	}
+	if *fix && (path == "-" || isURL(path)) {
+		return usageError("-fix requires a local file")
+	}
+
+	data, err := c.read(path)
+	if err != nil {
+		return err
+	}
+	s, err := parse(data)
+	if err != nil {
+		return err
+	}
+	rules := lint.Recommended()
+	if *config != "" {
+		configData, err := ioutil.ReadFile(*config)
+		if err != nil {
+			return err
+		}
+		cfg, err := lint.ParseConfig(configData)
+		if err != nil {
+			return err
+		}
+		if err := rules.Configure(cfg); err != nil {
+			return err
+		}
+	}
+
+	errs := append(validate.ValidateDocument(s), validate.ValidateSemantics(s)...)
+	findings := append(lint.FromValidation(errs), rules.Check(s)...)
+	sups, err := lint.Suppressions(data)
+	if err != nil {
+		return err
+	}
+	findings, _ = lint.Suppress(findings, sups)
+
+	if *fix {
+		fixable := lint.Fixable(findings)
+		if len(fixable) > 0 {
+			fixed, err := lint.ApplyFixes(data, fixable)
+			if err != nil {
+				return err
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				return err
+			}
+			if err := ioutil.WriteFile(path, fixed, info.Mode().Perm()); err != nil {
+				return err
+			}
+			fmt.Fprintf(c.stderr, "fixed %d finding(s) in %s\n", len(fixable), path)
+		}
+		var remaining []lint.Finding
+		for _, f := range findings {
+			if len(f.Fix) == 0 {
+				remaining = append(remaining, f)
+			}
+		}
+		findings = remaining
+	}
+
+	if *baseline != "" {
+		b, err := lint.LoadBaseline(*baseline)
+		if err != nil {
+			return err
+		}
+		switch {
+		case *writeBaseline:
+			b = lint.NewBaseline(findings)
+		case *updateBaseline:
+			b = b.Update(findings)
+		}
+		if *writeBaseline || *updateBaseline {
+			if err := b.Save(*baseline); err != nil {
+				return err
+			}
+		}
+		findings, _ = b.Filter(findings)
+	}
+
+	failed := false
+	for _, f := range findings {
+		fmt.Fprintf(c.stdout, "%s: %s: %s\n", path, f.Severity, f)
+		if f.Severity >= threshold {
+			failed = true
+		}
+	}
+	if failed {
+		return errProblems
+	}
+	return nil
+}
+
+// generators are the kinds of code the generate command produces. Each is
+// given the package name, which is empty if the generator's default should be
+// used.
+var generators = map[string]func(doc *spec.Swagger, pkg string) ([]gen.File, error){
+	"client": func(doc *spec.Swagger, pkg string) ([]gen.File, error) {
+		return client.Generate(doc, client.Options{Package: pkg})
+	},
+	"models": func(doc *spec.Swagger, pkg string) ([]gen.File, error) {
+		return models.Generate(doc, models.Options{Package: pkg})
+	},
+	"server": func(doc *spec.Swagger, pkg string) ([]gen.File, error) {
+		return server.Generate(doc, server.Options{Package: pkg})
+	},
+}
+
+func generatorKinds() string {
+	kinds := make([]string, 0, len(generators))
+	for kind := range generators {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return strings.Join(kinds, ", ")
+}
+
+func runGenerate(c *cli, args []string) error {
+	fs := c.flags("generate")
+	pkg := fs.String("package", "", "name of the generated package (default: the kind)")
+	out := fs.String("out", ".", "directory to write the generated files to")
+	dryRun := fs.Bool("dry-run", false, "report the files which would change without writing them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return usageError("expected a kind of code to generate: " + generatorKinds())
+	}
+	generate, ok := generators[fs.Arg(0)]
+	if !ok {
+		return usageError(fmt.Sprintf("unknown kind %q, expected one of: %s", fs.Arg(0), generatorKinds()))
+	}
+	path, err := input(fs.Args()[1:])
+	if err != nil {
+		return err
+	}
+	data, err := c.read(path)
+	if err != nil {
+		return err
+	}
+	s, err := parse(data)
+	if err != nil {
+		return err
+	}
+	files, err := generate(s, *pkg)
+	if err != nil {
+		return err
+	}
+	changes, err := gen.Write(*out, files, gen.WriteOptions{DryRun: *dryRun})
+	if err != nil {
+		return err
+	}
+	for _, ch := range changes {
+		fmt.Fprintf(c.stdout, "%s %s\n", ch.Op, ch.Path)
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/internal/mapkeys"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// completionScripts are printed by the completion command. Each asks the
+// hidden __complete command for candidates, and falls back to completing
+// file names when there are none.
+var completionScripts = map[string]string{
+	"bash": bashCompletion,
+	"zsh":  "autoload -U +X bashcompinit && bashcompinit\n" + bashCompletion,
+	"fish": `complete -c swaggopher -a '(swaggopher __complete (commandline -opc)[2..-1] (commandline -ct))'
+`,
+}
+
+const bashCompletion = `_swaggopher() {
+	local IFS=$'\n'
+	COMPREPLY=($(swaggopher __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+}
+complete -o default -F _swaggopher swaggopher
+`
+
+func runCompletion(c *cli, args []string) error {
+	fs := c.flags("completion")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usageError("expected a shell: bash, zsh or fish")
+	}
+	script, ok := completionScripts[fs.Arg(0)]
+	if !ok {
+		return usageError(fmt.Sprintf("unknown shell %q, must be bash, zsh or fish", fs.Arg(0)))
+	}
+	_, err := fmt.Fprint(c.stdout, script)
+	return err
+}
+
+// complete returns the candidates for the last of its arguments, the word
+// being completed, given the words before it.
+func complete(words []string) []string {
+	if len(words) == 0 {
+		return nil
+	}
+	cur, prev := words[len(words)-1], words[:len(words)-1]
+	if len(prev) == 0 {
+		var names []string
+		for _, cmd := range commands {
+			names = append(names, cmd.name)
+		}
+		return matching(names, "", cur)
+	}
+
+	cmd, positional := prev[0], positionalArgs(prev[1:])
+	switch last := prev[len(prev)-1]; {
+	case last == "-paths" || last == "-operations":
+		doc := documentIn(positional)
+		if doc == nil {
+			return nil
+		}
+		// Both flags take comma separated lists, of which only the last
+		// element is completed.
+		done, partial := "", cur
+		if i := strings.LastIndex(cur, ","); i >= 0 {
+			done, partial = cur[:i+1], cur[i+1:]
+		}
+		if last == "-paths" {
+			return matching(mapkeys.Sorted(doc.Paths), done, partial)
+		}
+		return matching(operationIDs(doc), done, partial)
+	case last == "-format" && cmd == "export":
+		return matching([]string{"csv", "xlsx", "postman", "proto"}, "", cur)
+	case last == "-from" && cmd == "import":
+		return matching([]string{"har", "postman"}, "", cur)
+	case last == "-format" && cmd == "score":
+		return matching([]string{"json", "text"}, "", cur)
+	case last == "-format" && cmd == "loadtest":
+		return matching([]string{"curl", "k6", "vegeta"}, "", cur)
+	case last == "-format":
+		return matching([]string{"json", "yaml"}, "", cur)
+	case last == "-to" && cmd == "convert":
+		return matching([]string{"2.0", "3.0", "asyncapi"}, "", cur)
+	case last == "-to":
+		return matching([]string{"2.0", "3.0"}, "", cur)
+	case last == "-mode":
+		return matching([]string{"backward", "drift", "forward", "full"}, "", cur)
+	}
+
+	switch {
+	case cmd == "completion" && len(positional) == 0:
+		return matching([]string{"bash", "fish", "zsh"}, "", cur)
+	case cmd == "generate" && len(positional) == 0:
+		return matching(strings.Split(generatorKinds(), ", "), "", cur)
+	case cmd == "explore" && len(positional) == 1:
+		var names []string
+		for _, ec := range explorerCommands {
+			names = append(names, ec.name)
+		}
+		return matching(names, "", cur)
+	case cmd == "call" && len(positional) == 1:
+		doc := documentIn(positional)
+		if doc == nil {
+			return nil
+		}
+		return matching(operationIDs(doc), "", cur)
+	case cmd == "explore" && len(positional) == 2:
+		doc := documentIn(positional[:1])
+		if doc == nil {
+			return nil
+		}
+		switch positional[1] {
+		case "show", "curl":
+			return matching(operationIDs(doc), "", cur)
+		case "schema":
+			return matching(mapkeys.Sorted(doc.Definitions), "", cur)
+		}
+	}
+	return nil
+}
+
+// matching returns the candidates beginning with partial, each prefixed by
+// done.
+func matching(candidates []string, done, partial string) []string {
+	var found []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, partial) {
+			found = append(found, done+c)
+		}
+	}
+	return found
+}
+
+// positionalArgs drops flags, and the values of flags which take one, from a
+// command's arguments.
+func positionalArgs(args []string) []string {
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "-dry-run" || args[i] == "-fix" ||
+			args[i] == "-update-baseline" || args[i] == "-write-baseline" ||
+			args[i] == "-force" || args[i] == "-v" || args[i] == "-warmup" ||
+			args[i] == "-progress":
+		case strings.HasPrefix(args[i], "-") && !strings.Contains(args[i], "="):
+			i++
+		case strings.HasPrefix(args[i], "-"):
+		default:
+			positional = append(positional, args[i])
+		}
+	}
+	return positional
+}
+
+// documentIn returns the first of the arguments which names a readable
+// Swagger document, or nil if none does.
+func documentIn(args []string) *spec.Swagger {
+	for _, path := range args {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if doc, err := parse(data); err == nil {
+			return doc
+		}
+	}
+	return nil
+}
+
+func operationIDs(s *spec.Swagger) []string {
+	var ids []string
+	s.RangeOperations(func(path, method string, op *spec.Operation) bool {
+		if op.OperationId != "" {
+			ids = append(ids, op.OperationId)
+		}
+		return true
+	})
+	sort.Strings(ids)
+	return ids
+}
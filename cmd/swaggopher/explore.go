@@ -0,0 +1,279 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/catalog"
+	"github.com/ericchiang/swaggopher/fixture"
+	"github.com/ericchiang/swaggopher/internal/mapkeys"
+	"github.com/ericchiang/swaggopher/internal/synth"
+	"github.com/ericchiang/swaggopher/resolver"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// explorerCommands lists the commands of the explorer, for its help and for
+// shell completion.
+var explorerCommands = []struct{ name, args, summary string }{
+	{"paths", "", "list paths and their methods"},
+	{"operations", "", "list operations"},
+	{"show", "operation", "describe an operation, named by operationId or method and path"},
+	{"schemas", "", "list definitions"},
+	{"schema", "name", "print a definition with its references resolved"},
+	{"curl", "operation", "print a curl command calling an operation"},
+	{"help", "", "list commands"},
+	{"quit", "", "leave the explorer"},
+}
+
+func runExplore(c *cli, args []string) error {
+	fs := c.flags("explore")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 || fs.Arg(0) == "-" {
+		return usageError("expected a file, since commands are read from standard input")
+	}
+	path := fs.Arg(0)
+	data, err := c.read(path)
+	if err != nil {
+		return err
+	}
+	s, err := parse(data)
+	if err != nil {
+		return err
+	}
+	e := &explorer{doc: s, resolved: s, w: c.stdout}
+	// Schemas are shown resolved where possible, but an unresolvable
+	// reference shouldn't stop the rest of the document being explored.
+	var resolved spec.Swagger
+	if err := decode(data, &resolved); err == nil && resolver.Resolve(&resolved, c.resolverOptions(path)...) == nil {
+		e.resolved = &resolved
+	}
+
+	if fs.NArg() > 1 {
+		return e.run(fs.Args()[1:])
+	}
+	scanner := bufio.NewScanner(c.stdin)
+	fmt.Fprint(c.stdout, "> ")
+	for scanner.Scan() {
+		words := strings.Fields(scanner.Text())
+		if len(words) > 0 {
+			if words[0] == "quit" || words[0] == "exit" {
+				return nil
+			}
+			if err := e.run(words); err != nil {
+				fmt.Fprintln(c.stdout, err)
+			}
+		}
+		fmt.Fprint(c.stdout, "> ")
+	}
+	fmt.Fprintln(c.stdout)
+	return scanner.Err()
+}
+
+// explorer answers the commands of an interactive session.
+type explorer struct {
+	doc      *spec.Swagger
+	resolved *spec.Swagger
+	w        io.Writer
+}
+
+func (e *explorer) run(words []string) error {
+	arg := strings.Join(words[1:], " ")
+	switch words[0] {
+	case "paths":
+		for _, path := range mapkeys.Sorted(e.doc.Paths) {
+			item := e.doc.Paths[path]
+			var methods []string
+			item.RangeOperations(func(method string, op *spec.Operation) bool {
+				methods = append(methods, strings.ToUpper(method))
+				return true
+			})
+			fmt.Fprintf(e.w, "%s  %s\n", path, strings.Join(methods, " "))
+		}
+	case "operations":
+		e.doc.RangeOperations(func(path, method string, op *spec.Operation) bool {
+			fmt.Fprintf(e.w, "%-7s %s  %s  %s\n", strings.ToUpper(method), path, op.OperationId, op.Summary)
+			return true
+		})
+	case "show":
+		method, path, op, err := e.operation(arg)
+		if err != nil {
+			return err
+		}
+		e.show(method, path, op)
+	case "schemas":
+		for _, name := range mapkeys.Sorted(e.doc.Definitions) {
+			def := e.doc.Definitions[name]
+			fmt.Fprintf(e.w, "%s  %s\n", name, firstLine(def.Description))
+		}
+	case "schema":
+		def, ok := e.resolved.Definitions[arg]
+		if !ok {
+			return fmt.Errorf("no definition named %q", arg)
+		}
+		data, err := json.MarshalIndent(def, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(e.w, "%s\n", data)
+	case "curl":
+		method, path, op, err := e.operation(arg)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(e.w, e.curl(method, path, op))
+	case "help":
+		for _, cmd := range explorerCommands {
+			fmt.Fprintf(e.w, "  %-22s %s\n", strings.TrimSpace(cmd.name+" "+cmd.args), cmd.summary)
+		}
+	default:
+		return fmt.Errorf("unknown command %q, try help", words[0])
+	}
+	return nil
+}
+
+func (e *explorer) operation(key string) (method, path string, op *spec.Operation, err error) {
+	if key == "" {
+		return "", "", nil, fmt.Errorf("expected an operationId or method and path")
+	}
+	method, path, op, ok := fixture.Find(e.doc, key)
+	if !ok {
+		return "", "", nil, fmt.Errorf("no operation %q", key)
+	}
+	return method, path, op, nil
+}
+
+func (e *explorer) show(method, path string, op *spec.Operation) {
+	fmt.Fprintf(e.w, "%s %s", strings.ToUpper(method), path)
+	if op.OperationId != "" {
+		fmt.Fprintf(e.w, " (%s)", op.OperationId)
+	}
+	fmt.Fprintln(e.w)
+	if op.Deprecated {
+		fmt.Fprintln(e.w, "deprecated")
+	}
+	if text := op.Summary; text != "" || op.Description != "" {
+		if text == "" {
+			text = op.Description
+		}
+		fmt.Fprintf(e.w, "%s\n", text)
+	}
+	item := e.doc.Paths[path]
+	var params []*spec.Parameter
+	for _, p := range e.doc.OperationParameters(&item, op) {
+		// References to undefined parameters are left out.
+		if p.Ref == "" {
+			params = append(params, p)
+		}
+	}
+	if len(params) > 0 {
+		fmt.Fprintln(e.w, "parameters:")
+		for _, p := range params {
+			detail := p.In + ", " + parameterType(p)
+			if p.Required {
+				detail += ", required"
+			}
+			fmt.Fprintf(e.w, "  %s (%s)\n", p.Name, detail)
+		}
+	}
+	fmt.Fprintln(e.w, "responses:")
+	codes := make([]string, 0, len(op.Responses))
+	for code := range op.Responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		r := op.Responses[code]
+		if r.Ref != "" {
+			fmt.Fprintf(e.w, "  %s  %s\n", code, r.Ref)
+			continue
+		}
+		line := "  " + code + "  " + firstLine(r.Description)
+		if r.Schema != nil {
+			line += "  " + catalog.TypeName(r.Schema)
+		}
+		fmt.Fprintln(e.w, line)
+	}
+}
+
+// curl returns a command calling an operation. Parameters without a default
+// are left as placeholders, such as <petId>, and bodies are generated from
+// their schemas.
+func (e *explorer) curl(method, path string, op *spec.Operation) string {
+	scheme, host := "https", e.doc.Host
+	if len(e.doc.Schemes) > 0 {
+		scheme = e.doc.Schemes[0]
+	}
+	if host == "" {
+		host = "localhost"
+	}
+	value := func(p *spec.Parameter) string {
+		if p.Default != nil {
+			return fmt.Sprint(p.Default)
+		}
+		return "<" + p.Name + ">"
+	}
+
+	var query, headers []string
+	var body interface{}
+	item := e.doc.Paths[path]
+	for _, p := range e.doc.OperationParameters(&item, op) {
+		switch p.In {
+		case "path":
+			path = strings.Replace(path, "{"+p.Name+"}", value(p), -1)
+		case "query":
+			if p.Required {
+				query = append(query, p.Name+"="+value(p))
+			}
+		case "header":
+			if p.Required {
+				headers = append(headers, p.Name+": "+value(p))
+			}
+		case "body":
+			body = synth.Example(e.doc, p.Schema, true)
+		}
+	}
+
+	url := scheme + "://" + host + strings.TrimSuffix(e.doc.BasePath, "/") + path
+	if len(query) > 0 {
+		url += "?" + strings.Join(query, "&")
+	}
+	cmd := "curl -X " + strings.ToUpper(method) + " " + shellQuote(url)
+	for _, h := range headers {
+		cmd += " -H " + shellQuote(h)
+	}
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err == nil {
+			cmd += " -H 'Content-Type: application/json' -d " + shellQuote(string(data))
+		}
+	}
+	return cmd
+}
+
+func parameterType(p *spec.Parameter) string {
+	if p.Schema != nil {
+		return catalog.TypeName(p.Schema)
+	}
+	if p.Type == "array" && p.Items != nil {
+		return "[]" + p.Items.Type
+	}
+	return p.Type
+}
+
+// shellQuote quotes a string for POSIX shells.
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+func firstLine(s string) string {
+	if i := strings.Index(s, "\n"); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
@@ -0,0 +1,333 @@
+/*
+Command swaggopher validates, converts, bundles, flattens, trims, compares,
+lints and exports Swagger documents, and generates Go code from them.
+
+Usage:
+
+	swaggopher <command> [flags] [file...]
+
+Documents are read from the named files or http and https URLs, or from
+standard input if no file is given or the file is "-". JSON and YAML are both
+accepted, and output is written in the format of the input unless -format is
+given.
+
+Given a URL, such as that of the document a server publishes, diff reports how
+the deployed API has drifted from a local copy:
+
+	swaggopher diff ./api.yaml https://api.example.com/swagger.json
+
+Fetched documents are cached in the user's cache directory, and only downloaded
+again when they change. To fetch documents which require credentials, set
+SWAGGOPHER_AUTH to the value of the Authorization header to send, such as
+"Bearer token". It's only sent to the hosts of URLs named on the command line.
+
+The call command sends a request to an operation, named by its operationId or
+method and path, after checking it against the operation's parameters, then
+prints the response and checks it against the documented responses:
+
+	swaggopher call pets.yaml getPet -param petId=42 -auth api_key=secret
+
+The explore command reads commands from standard input, such as "show
+listPets" or "curl POST /pets", or runs the one given after the file. To
+complete commands, paths and operationIds in bash, run:
+
+	source <(swaggopher completion bash)
+
+Commands that find problems, such as validate, diff, changes and lint, exit
+with status 1. Invalid usage and errors reading documents exit with status 2.
+*/
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/ericchiang/swaggopher/internal/rawdoc"
+	"github.com/ericchiang/swaggopher/resolver"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(c *cli, args []string) error
+}
+
+var commands = []command{
+	{"validate", "[file...]", "check documents against the specification", runValidate},
+	{"convert", "[-to version|asyncapi] [-tags list] [-paths list] [-operations list] [-format json|yaml] [file]", "convert between Swagger 2.0 and OpenAPI 3.0, or operations to AsyncAPI channels", runConvert},
+	{"compile", "[-to version] [-format json|yaml] [file]", "compile a resource oriented description of an API into a document", runCompile},
+	{"bundle", "[-format json|yaml] [-dry-run] [-progress] [file]", "replace references with their targets, producing a single document", runBundle},
+	{"flatten", "[-format json|yaml] [-dry-run] [-progress] [file]", "bundle a document, also expanding recursive schemas so no references are left", runFlatten},
+	{"subset", "[-tags list] [-paths list] [-operations list] [-format json|yaml] [file]", "keep only the selected operations and what they refer to", runSubset},
+	{"diff", "[-mode backward|forward|full|drift] old new", "report incompatible changes to definitions, or drift from a published document", runDiff},
+	{"changes", "[-fail-on severity] old new", "report every change between documents, classified as breaking or not", runChanges},
+	{"lint", "[flags] [file]", "report style problems", runLint},
+	{"export", "[-format csv|xlsx|postman|proto] [-resources] [-package name] [-go-package path] [file]", "list operations, or the verbs of each resource, as a spreadsheet, Postman collection or Protocol Buffers service", runExport},
+	{"import", "[-from postman|har] [-templates list] [-format json|yaml] [file]", "infer a document from a Postman collection or the traffic in a HAR file", runImport},
+	{"docs", "[-level n] [file]", "render reference documentation as Markdown", runDocs},
+	{"score", "[-min-description n] [-min-score percent] [-format text|json] [file]", "grade how completely operations and definitions are documented", runScore},
+	{"loadtest", "[-format k6|vegeta|curl] [-warmup] [-server url] [-weight operation=n]... [-header header]... [file]", "generate a load test plan sending each operation's documented requests", runLoadTest},
+	{"generate", "[-package name] [-out dir] [-dry-run] kind [file]", "generate Go code of a kind: " + generatorKinds(), runGenerate},
+	{"call", "[-param name=value]... [-data json|@file] [-auth name=value]... [-server url] [-force] [-v] file operation", "send a request to an operation and check the response", runCall},
+	{"explore", "file [command]", "browse a document's paths, operations and schemas interactively", runExplore},
+	{"completion", "bash|zsh|fish", "print a script which completes commands, paths and operationIds", runCompletion},
+}
+
+func main() {
+	c := &cli{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}
+	var cacheDir string
+	if dir, err := os.UserCacheDir(); err == nil {
+		cacheDir = filepath.Join(dir, "swaggopher")
+	}
+	c.loader = c.remoteLoader(os.Getenv("SWAGGOPHER_AUTH"), cacheDir)
+	os.Exit(c.main(os.Args[1:]))
+}
+
+// cli holds the streams a command reads and writes, so it can be run in tests.
+type cli struct {
+	stdin          io.Reader
+	stdout, stderr io.Writer
+	// loader fetches documents from URLs, and the documents they refer to.
+	// If nil, resolver.DefaultLoader is used.
+	loader resolver.Loader
+	// hosts holds the hosts of the URLs named on the command line.
+	hosts map[string]bool
+}
+
+// errProblems is returned by commands which ran successfully but found
+// problems with a document.
+var errProblems = errors.New("problems found")
+
+// usageError is returned for invalid flags or arguments.
+type usageError string
+
+func (e usageError) Error() string { return string(e) }
+
+func (c *cli) main(args []string) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		c.usage()
+		if len(args) == 0 {
+			return 2
+		}
+		return 0
+	}
+	// The completion scripts call the hidden __complete command, which
+	// isn't listed with the others.
+	if args[0] == "__complete" {
+		for _, candidate := range complete(args[1:]) {
+			fmt.Fprintln(c.stdout, candidate)
+		}
+		return 0
+	}
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+		err := cmd.run(c, args[1:])
+		switch err.(type) {
+		case nil:
+			return 0
+		case usageError:
+			fmt.Fprintf(c.stderr, "swaggopher %s: %v\nusage: swaggopher %s %s\n", cmd.name, err, cmd.name, cmd.args)
+			return 2
+		}
+		if err == errProblems {
+			return 1
+		}
+		if err != flag.ErrHelp {
+			fmt.Fprintf(c.stderr, "swaggopher %s: %v\n", cmd.name, err)
+		}
+		return 2
+	}
+	fmt.Fprintf(c.stderr, "swaggopher: unknown command %q\n", args[0])
+	c.usage()
+	return 2
+}
+
+func (c *cli) usage() {
+	fmt.Fprintf(c.stderr, "usage: swaggopher <command> [flags] [file...]\n\ncommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(c.stderr, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+}
+
+// flags returns a flag set for a command which reports errors to stderr.
+func (c *cli) flags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("swaggopher "+name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	return fs
+}
+
+// read returns the contents of a file, of an http or https URL, or of stdin if
+// the path is "-".
+func (c *cli) read(path string) ([]byte, error) {
+	if path == "-" {
+		return ioutil.ReadAll(c.stdin)
+	}
+	if !isURL(path) {
+		return ioutil.ReadFile(path)
+	}
+	if u, err := url.Parse(path); err == nil {
+		if c.hosts == nil {
+			c.hosts = make(map[string]bool)
+		}
+		c.hosts[u.Host] = true
+	}
+	if c.loader != nil {
+		return c.loader(path)
+	}
+	return resolver.DefaultLoader(path)
+}
+
+// remoteLoader returns a loader which caches fetched documents in cacheDir,
+// revalidating them on later runs. If auth is set, it's sent as the
+// Authorization header to the hosts of URLs named on the command line, but not
+// to other hosts their references point to.
+func (c *cli) remoteLoader(auth, cacheDir string) resolver.Loader {
+	l := &resolver.HTTPLoader{Timeout: 30 * time.Second, CacheDir: cacheDir}
+	if auth != "" {
+		l.Auth = func(req *http.Request) error {
+			if c.hosts[req.URL.Host] {
+				req.Header.Set("Authorization", auth)
+			}
+			return nil
+		}
+	}
+	return l.Load
+}
+
+// preloaded returns a loader which answers with data for the document at path,
+// which has already been read, and loads the documents it refers to as
+// resolverOptions does.
+func (c *cli) preloaded(path string, data []byte) resolver.Loader {
+	load := c.loader
+	if load == nil {
+		load = resolver.DefaultLoader
+	}
+	if !isURL(path) {
+		path = filepath.Clean(path)
+	}
+	return func(location string) ([]byte, error) {
+		if location == path {
+			return data, nil
+		}
+		return load(location)
+	}
+}
+
+// resolverOptions returns the options to resolve the references of the
+// document at path with.
+func (c *cli) resolverOptions(path string) []resolver.Option {
+	var opts []resolver.Option
+	if path != "-" {
+		opts = append(opts, resolver.WithBase(path))
+	}
+	if c.loader != nil {
+		opts = append(opts, resolver.WithLoader(c.loader))
+	}
+	return opts
+}
+
+func isURL(path string) bool {
+	return strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://")
+}
+
+// input returns the single file argument of a command, defaulting to stdin.
+func input(args []string) (string, error) {
+	switch len(args) {
+	case 0:
+		return "-", nil
+	case 1:
+		return args[0], nil
+	}
+	return "", usageError("expected at most one file")
+}
+
+// parse decodes a Swagger 2.0 document.
+func parse(data []byte) (*spec.Swagger, error) {
+	var s spec.Swagger
+	if err := decode(data, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func decode(data []byte, v interface{}) error {
+	if rawdoc.IsJSON(data) {
+		return json.Unmarshal(data, v)
+	}
+	return yaml.Unmarshal(data, v)
+}
+
+// outputFormat returns the format to write, defaulting to that of the input.
+func outputFormat(flagValue string, input []byte) (string, error) {
+	switch flagValue {
+	case "json", "yaml":
+		return flagValue, nil
+	case "":
+		if rawdoc.IsJSON(input) {
+			return "json", nil
+		}
+		return "yaml", nil
+	}
+	return "", usageError(fmt.Sprintf("unknown format %q, must be json or yaml", flagValue))
+}
+
+// write encodes v to stdout as JSON or YAML.
+func (c *cli) write(v interface{}, format string) error {
+	var (
+		data []byte
+		err  error
+	)
+	if format == "json" {
+		data, err = json.MarshalIndent(v, "", "  ")
+		data = append(data, '\n')
+	} else {
+		data, err = yaml.Marshal(v)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = c.stdout.Write(data)
+	return err
+}
+
+// version returns the specification version a document declares, such as
+// "2.0" or "3.0.3".
+func version(data []byte) (string, error) {
+	var v struct {
+		Swagger string `json:"swagger" yaml:"swagger"`
+		OpenAPI string `json:"openapi" yaml:"openapi"`
+	}
+	if err := decode(data, &v); err != nil {
+		return "", err
+	}
+	switch {
+	case v.Swagger != "":
+		return v.Swagger, nil
+	case v.OpenAPI != "":
+		return v.OpenAPI, nil
+	}
+	return "", fmt.Errorf("document declares neither swagger nor openapi version")
+}
+
+func majorMinor(version string) string {
+	if parts := strings.SplitN(version, ".", 3); len(parts) >= 2 {
+		return parts[0] + "." + parts[1]
+	}
+	return version
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const petstore = `swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      summary: List pets.
+      description: List pets.
+      responses:
+        200:
+          description: Pets.
+          schema: {type: array, items: {$ref: '#/definitions/Pet'}}
+definitions:
+  Pet:
+    type: object
+    properties:
+      name: {type: string}
+`
+
+const collection = `{
+	"info": {"name": "Pets", "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"},
+	"item": [{"name": "Get pet", "request": {"method": "GET", "url": "https://pets.example.com/pets/:id"}}]
+}`
+
+const archive = `{"log": {"entries": [{
+	"request": {"method": "GET", "url": "https://pets.example.com/pets/1"},
+	"response": {"status": 200, "content": {"mimeType": "application/json", "text": "{\"name\": \"Rex\"}"}}
+}]}}`
+
+func TestCommands(t *testing.T) {
+	dir, err := ioutil.TempDir("", "swaggopher")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	pets := write("pets.yaml", petstore)
+	renamed := write("renamed.yaml", strings.Replace(petstore, "name: {type: string}", "name: {type: integer}", 1))
+	undocumented := write("undocumented.yaml", strings.Replace(petstore, "      description: List pets.\n", "", 1))
+
+	tests := []struct {
+		args       []string
+		stdin      string
+		wantCode   int
+		wantStdout string
+	}{
+		{args: []string{"validate", pets}, wantCode: 0},
+		{args: []string{"validate"}, stdin: `{"swagger": "2.0", "paths": {}}`, wantCode: 1, wantStdout: "<stdin>: /info: info is required\n"},
+		{args: []string{"convert", "-format", "json"}, stdin: petstore, wantCode: 0, wantStdout: `"openapi": "3.0.3"`},
+		{args: []string{"convert", "-to", "2.0", pets}, wantCode: 2},
+		{args: []string{"convert", "-to", "asyncapi", "-operations", "listPets", "-format", "json", pets}, wantCode: 0, wantStdout: `"asyncapi": "2.6.0"`},
+		{args: []string{"convert", "-operations", "listPets", pets}, wantCode: 2},
+		{args: []string{"compile", "-format", "json"}, stdin: "api: Pets\nversion: '1.0'\nresources:\n  Pet: {operations: [read]}\n", wantCode: 0, wantStdout: `"operationId": "getPet"`},
+		{args: []string{"compile", "-to", "3.0"}, stdin: "api: Pets\nversion: '1.0'\nresources:\n  Pet: {operations: [read]}\n", wantCode: 0, wantStdout: "openapi: 3.0.3\n"},
+		{args: []string{"compile", "-to", "1.2"}, stdin: "api: Pets\nversion: '1.0'\nresources: {}\n", wantCode: 2},
+		{args: []string{"bundle", pets}, wantCode: 0, wantStdout: "items:\n"},
+		{args: []string{"flatten", "-format", "json", pets}, wantCode: 0, wantStdout: `"name": {`},
+		{args: []string{"flatten", "-dry-run", pets}, wantCode: 0, wantStdout: "bundle: loaded " + pets},
+		{args: []string{"bundle", "-flatten", pets}, wantCode: 2},
+		{args: []string{"bundle", "-progress", pets}, wantCode: 0, wantStdout: "items:\n"},
+		{args: []string{"bundle", "-dry-run", pets}, wantCode: 0, wantStdout: "bundle: loaded " + pets},
+		{args: []string{"bundle"}, stdin: petstore, wantCode: 0, wantStdout: "items:\n"},
+		{args: []string{"subset", "-paths", "/pets/**", "-format", "json", pets}, wantCode: 0, wantStdout: `"Pet": {`},
+		{args: []string{"subset", "-operations", "deletePet", pets}, wantCode: 2},
+		{args: []string{"subset", pets}, wantCode: 2},
+		{args: []string{"diff", pets, renamed}, wantCode: 1, wantStdout: "/definitions/Pet/properties/name"},
+		{args: []string{"diff", pets}, wantCode: 2},
+		{args: []string{"changes", pets, renamed}, wantCode: 1, wantStdout: "/definitions/Pet/properties/name/type: type changed from string to integer (breaking)\n"},
+		{args: []string{"changes", "-fail-on", "deprecation", pets, pets}, wantCode: 0},
+		{args: []string{"changes", "-fail-on", "never", pets, renamed}, wantCode: 2},
+		{args: []string{"lint", pets}, wantCode: 0},
+		{args: []string{"lint", undocumented}, wantCode: 1, wantStdout: "warn: /paths/~1pets/get: operation has no description (operation-description)"},
+		{args: []string{"lint", "-fail-on", "error", undocumented}, wantCode: 0},
+		{args: []string{"lint", "-fix", "https://example.com/pets.yaml"}, wantCode: 2},
+		{args: []string{"lint", "-fix", undocumented}, wantCode: 0},
+		{args: []string{"lint", undocumented}, wantCode: 0},
+		{args: []string{"export", pets}, wantCode: 0, wantStdout: "GET,/pets,listPets,List pets.,,,,[]Pet,200,\n"},
+		{args: []string{"export", "-resources", pets}, wantCode: 0, wantStdout: "/pets,,,GET,,\n"},
+		{args: []string{"export", "-format", "postman", pets}, wantCode: 0, wantStdout: "\"name\": \"List pets.\","},
+		{args: []string{"export", "-format", "postman", "-resources", pets}, wantCode: 2},
+		{args: []string{"export", "-format", "proto", "-package", "pets.v1", pets}, wantCode: 0, wantStdout: "package pets.v1;"},
+		{args: []string{"export", "-format", "proto", "-resources", pets}, wantCode: 2},
+		{args: []string{"export", "-format", "pdf", pets}, wantCode: 2},
+		{args: []string{"import"}, stdin: collection, wantCode: 0, wantStdout: `"/pets/{id}": {`},
+		{args: []string{"import"}, stdin: petstore, wantCode: 2},
+		{args: []string{"import", "-format", "yaml"}, stdin: collection, wantCode: 0, wantStdout: "summary: Get pet\n"},
+		{args: []string{"import", "-from", "har"}, stdin: archive, wantCode: 0, wantStdout: `"/pets/{petId}": {`},
+		{args: []string{"import", "-from", "har", "-templates", "/pets/{name}"}, stdin: archive, wantCode: 0, wantStdout: `"/pets/{name}": {`},
+		{args: []string{"import", "-from", "har"}, stdin: collection, wantCode: 2},
+		{args: []string{"import", "-from", "curl"}, stdin: collection, wantCode: 2},
+		{args: []string{"import"}, stdin: `{"info": {"schema": "https://schema.getpostman.com/json/collection/v1.0.0/collection.json"}}`, wantCode: 2},
+		{args: []string{"docs", pets}, wantCode: 0, wantStdout: "### GET /pets\n\nList pets.\n"},
+		{args: []string{"docs", "-level", "4", pets}, wantCode: 2},
+		{args: []string{"score", pets}, wantCode: 0, wantStdout: "grade F (21%)\n  0% /definitions/Pet: no description; property name has no description; no example\n 42% /paths/~1pets/get: description is 10 of 40 characters; no example\n"},
+		{args: []string{"score", "-min-score", "50", pets}, wantCode: 1},
+		{args: []string{"score", "-format", "csv", pets}, wantCode: 2},
+		{args: []string{"loadtest", "-server", "http://localhost", "-weight", "listPets=3", pets}, wantCode: 0, wantStdout: "\"name\": \"listPets\",\n    \"weight\": 3,"},
+		{args: []string{"loadtest", "-format", "vegeta", "-server", "http://localhost", "-header", "Authorization: Bearer x", pets}, wantCode: 0, wantStdout: `{"method":"GET","url":"http://localhost/pets","header":{"Authorization":["Bearer x"]}}` + "\n"},
+		{args: []string{"loadtest", "-weight", "deletePet=1", "-server", "http://localhost", pets}, wantCode: 2},
+		{args: []string{"loadtest", "-weight", "listPets", pets}, wantCode: 2},
+		{args: []string{"loadtest", pets}, wantCode: 2},
+		{args: []string{"loadtest", "-warmup", "-format", "curl", "-server", "http://localhost", pets}, wantCode: 0, wantStdout: "curl -fsS -o /dev/null -X GET 'http://localhost/pets'\n"},
+		{args: []string{"generate", "-out", filepath.Join(dir, "pets"), "client", pets}, wantCode: 0, wantStdout: "create " + filepath.Join(dir, "pets", "client.go")},
+		{args: []string{"generate", "-out", filepath.Join(dir, "pets"), "-dry-run", "client", pets}, wantCode: 0, wantStdout: "unchanged " + filepath.Join(dir, "pets", "models.go")},
+		{args: []string{"generate", "-out", filepath.Join(dir, "server"), "server", pets}, wantCode: 0, wantStdout: "create " + filepath.Join(dir, "server", "server.go")},
+		{args: []string{"generate", "-out", filepath.Join(dir, "models"), "-package", "pets", "models", pets}, wantCode: 0, wantStdout: "create " + filepath.Join(dir, "models", "models.go")},
+		{args: []string{"generate", "frobnicate", pets}, wantCode: 2},
+		{args: []string{"frobnicate"}, wantCode: 2},
+		{args: []string{"explore", pets}, stdin: "paths\nshow listPets\nfrobnicate\nquit\n", wantCode: 0, wantStdout: "> /pets  GET\n> GET /pets (listPets)\nList pets.\nresponses:\n  200  Pets.  []Pet\n> unknown command \"frobnicate\", try help\n> "},
+		{args: []string{"explore", pets, "curl", "GET", "/pets"}, wantCode: 0, wantStdout: "curl -X GET 'https://localhost/pets'\n"},
+		{args: []string{"explore", pets, "schema", "Pet"}, wantCode: 0, wantStdout: `"name": {`},
+		{args: []string{"explore", pets, "show", "deletePet"}, wantCode: 2},
+		{args: []string{"explore"}, stdin: petstore, wantCode: 2},
+		{args: []string{"completion", "bash"}, wantCode: 0, wantStdout: "complete -o default -F _swaggopher swaggopher"},
+		{args: []string{"completion", "powershell"}, wantCode: 2},
+		{args: []string{"__complete", "ex"}, wantCode: 0, wantStdout: "export\nexplore\n"},
+		{args: []string{"__complete", "subset", "-tags", "pets", pets, "-operations", "li"}, wantCode: 0, wantStdout: "listPets\n"},
+		{args: []string{"__complete", "subset", pets, "-paths", "/a,/p"}, wantCode: 0, wantStdout: "/a,/pets\n"},
+		{args: []string{"__complete", "explore", pets, "show", ""}, wantCode: 0, wantStdout: "listPets\n"},
+		{args: []string{"__complete", "call", "-v", pets, "li"}, wantCode: 0, wantStdout: "listPets\n"},
+		{args: []string{"__complete", "generate", "mod"}, wantCode: 0, wantStdout: "models\n"},
+	}
+	for i, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		c := &cli{stdin: strings.NewReader(tt.stdin), stdout: &stdout, stderr: &stderr}
+		if code := c.main(tt.args); code != tt.wantCode {
+			t.Errorf("case %d: %s: want exit code %d, got %d: %s%s", i, tt.args, tt.wantCode, code, &stdout, &stderr)
+			continue
+		}
+		if !strings.Contains(stdout.String(), tt.wantStdout) {
+			t.Errorf("case %d: %s: expected output to contain %q, got %q", i, tt.args, tt.wantStdout, &stdout)
+		}
+	}
+}
+
+func TestCall(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		got = append(got, r.Method+" "+r.URL.RequestURI()+" "+r.Header.Get("X-Api-Key")+" "+string(body))
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/pets/7":
+			w.Write([]byte(`{"id": 7, "name": 7}`))
+		default:
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id": 1, "name": "Rex"}`))
+		}
+	}))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "swaggopher")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	doc := filepath.Join(dir, "pets.yaml")
+	err = ioutil.WriteFile(doc, []byte(`swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+basePath: /v1
+securityDefinitions:
+  key: {type: apiKey, in: header, name: X-Api-Key}
+security: [{key: []}]
+paths:
+  /pets:
+    post:
+      operationId: createPet
+      parameters:
+      - {name: pet, in: body, required: true, schema: {$ref: '#/definitions/Pet'}}
+      - {name: tags, in: query, type: array, items: {type: string}}
+      responses:
+        201: {description: Created., schema: {$ref: '#/definitions/Pet'}}
+  /pets/{petId}:
+    get:
+      operationId: getPet
+      parameters:
+      - {name: petId, in: path, required: true, type: integer}
+      responses:
+        200: {description: A pet., schema: {$ref: '#/definitions/Pet'}}
+definitions:
+  Pet:
+    type: object
+    properties:
+      id: {type: integer}
+      name: {type: string}
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args       []string
+		wantCode   int
+		wantStdout string
+		wantSent   string
+	}{
+		{
+			args:       []string{"call", "-server", srv.URL, doc, "createPet", "-data", `{"name": "Rex"}`, "-param", "tags=a", "-param", "tags=b", "-auth", "key=secret"},
+			wantStdout: `{"id": 1, "name": "Rex"}`,
+			wantSent:   `POST /v1/pets?tags=a%2Cb secret {"name": "Rex"}`,
+		},
+		// The response's name has the wrong type.
+		{args: []string{"call", "-server", srv.URL, doc, "GET /pets/{petId}", "-param", "path.petId=7"}, wantCode: 1, wantSent: "GET /v1/pets/7  "},
+		{args: []string{"call", "-server", srv.URL, doc, "getPet", "-param", "petId=seven"}, wantCode: 2},
+		{args: []string{"call", "-server", srv.URL, doc, "getPet"}, wantCode: 2},
+		{args: []string{"call", "-server", srv.URL, doc, "getPet", "-param", "limit=1"}, wantCode: 2},
+		{args: []string{"call", "-server", srv.URL, doc, "getPet", "-param", "petId=7", "-data", "{}"}, wantCode: 2},
+		{args: []string{"call", "-server", srv.URL, doc, "getPet", "-param", "petId=7", "-auth", "oauth=token"}, wantCode: 2},
+		{args: []string{"call", doc, "getPet", "-param", "petId=7"}, wantCode: 2},
+		{args: []string{"call", "-server", srv.URL, doc, "deletePet"}, wantCode: 2},
+	}
+	for i, tt := range tests {
+		got = nil
+		var stdout, stderr bytes.Buffer
+		c := &cli{stdin: strings.NewReader(""), stdout: &stdout, stderr: &stderr}
+		if code := c.main(tt.args); code != tt.wantCode {
+			t.Errorf("case %d: %s: want exit code %d, got %d: %s%s", i, tt.args, tt.wantCode, code, &stdout, &stderr)
+			continue
+		}
+		if !strings.Contains(stdout.String(), tt.wantStdout) {
+			t.Errorf("case %d: expected output to contain %q, got %q", i, tt.wantStdout, &stdout)
+		}
+		var sent string
+		if len(got) > 0 {
+			sent = got[0]
+		}
+		if sent != tt.wantSent {
+			t.Errorf("case %d: want request %q, got %q", i, tt.wantSent, sent)
+		}
+	}
+}
+
+func TestDiffRemote(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/swagger.yaml":
+		case "/private.yaml":
+			if r.Header.Get("Authorization") != "Bearer secret" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, petstore)
+	}))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "swaggopher")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	pets := write("pets.yaml", petstore)
+	renamed := write("renamed.yaml", strings.Replace(petstore, "name: {type: string}", "name: {type: integer}", 1))
+	// The same document split across files, which should compare equal to the
+	// published one once references are resolved.
+	i := strings.Index(petstore, "definitions:\n")
+	split := write("split.yaml", petstore[:i]+"definitions:\n  Pet: {$ref: 'pet.yaml'}\n")
+	write("pet.yaml", "type: object\nproperties:\n  name: {type: string}\n")
+
+	tests := []struct {
+		args []string
+		// auth is the value of SWAGGOPHER_AUTH.
+		auth       string
+		wantCode   int
+		wantStdout string
+	}{
+		{args: []string{"diff", pets, srv.URL + "/swagger.yaml"}, wantCode: 0},
+		{args: []string{"diff", split, srv.URL + "/swagger.yaml"}, wantCode: 0},
+		{args: []string{"diff", renamed, srv.URL + "/swagger.yaml"}, wantCode: 1, wantStdout: "/definitions/Pet/properties/name"},
+		{args: []string{"diff", "-mode", "drift", pets, renamed}, wantCode: 1, wantStdout: "/definitions/Pet/properties/name"},
+		{args: []string{"diff", "-mode", "backward", renamed, srv.URL + "/swagger.yaml"}, wantCode: 1, wantStdout: "/definitions/Pet/properties/name"},
+		{args: []string{"diff", pets, srv.URL + "/missing.yaml"}, wantCode: 2},
+		{args: []string{"diff", pets, srv.URL + "/private.yaml"}, auth: "Bearer secret", wantCode: 0},
+		{args: []string{"diff", pets, srv.URL + "/private.yaml"}, wantCode: 2},
+	}
+	for i, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		c := &cli{stdin: strings.NewReader(""), stdout: &stdout, stderr: &stderr}
+		c.loader = c.remoteLoader(tt.auth, filepath.Join(dir, "cache"))
+		if code := c.main(tt.args); code != tt.wantCode {
+			t.Errorf("case %d: %s: want exit code %d, got %d: %s%s", i, tt.args, tt.wantCode, code, &stdout, &stderr)
+			continue
+		}
+		if !strings.Contains(stdout.String(), tt.wantStdout) {
+			t.Errorf("case %d: expected output to contain %q, got %q", i, tt.wantStdout, &stdout)
+		}
+	}
+}
@@ -0,0 +1,372 @@
+/*
+Package coerce converts string encoded values, such as those found in query
+strings, headers and form bodies, to the types declared for them by a spec.
+*/
+package coerce
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// Error is returned when a value cannot be coerced to its declared type.
+type Error struct {
+	// Name of the parameter or header the value was provided for.
+	Name string
+	// The string encoded value.
+	Value string
+	// A description of why the value was rejected.
+	Reason string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("coerce: %s: invalid value %q: %s", e.Name, e.Value, e.Reason)
+}
+
+// Options controls how strictly values are parsed and formatted. The zero value
+// only accepts the canonical encodings defined by the spec.
+//
+// Parsing never depends on the locale of the host. Numbers must use "." as the
+// decimal separator and may not contain grouping separators, so "1,5" and
+// "1 000" are always rejected.
+type Options struct {
+	// LenientDateTime accepts date-times that are not strict RFC 3339, such as
+	// those using a space instead of "T" or those missing a timezone offset,
+	// which are interpreted as UTC.
+	LenientDateTime bool
+	// LenientBoolean accepts the boolean spellings understood by
+	// strconv.ParseBool, such as "1", "t" and "FALSE", in addition to "true" and
+	// "false".
+	LenientBoolean bool
+}
+
+// Parameter converts s to the type declared by p using the default options.
+func Parameter(p *spec.Parameter, s string) (interface{}, error) {
+	return Options{}.Parameter(p, s)
+}
+
+// Header converts s to the type declared by h using the default options. name is
+// the name of the header.
+func Header(name string, h *spec.Header, s string) (interface{}, error) {
+	return Options{}.Header(name, h, s)
+}
+
+// Value converts s to the type described by t using the default options. name
+// identifies the value in any returned error.
+func Value(name, s string, t *spec.Items) (interface{}, error) {
+	return Options{}.Value(name, s, t)
+}
+
+// Format encodes v as a string according to t using the default options.
+func Format(v interface{}, t *spec.Items) (string, error) {
+	return Options{}.Format(v, t)
+}
+
+// Parameter converts s to the type declared by p.
+func (o Options) Parameter(p *spec.Parameter, s string) (interface{}, error) {
+	return o.Value(p.Name, s, parameterItems(p))
+}
+
+// Header converts s to the type declared by h. name is the name of the header.
+func (o Options) Header(name string, h *spec.Header, s string) (interface{}, error) {
+	return o.Value(name, s, headerItems(h))
+}
+
+func parameterItems(p *spec.Parameter) *spec.Items {
+	return &spec.Items{
+		Type:             p.Type,
+		Format:           p.Format,
+		Items:            p.Items,
+		CollectionFormat: p.CollectionFormat,
+		Maximum:          p.Maximum,
+		ExclusiveMaximum: p.ExclusiveMaximum,
+		Minimum:          p.Minimum,
+		ExclusiveMinimum: p.ExclusiveMinimum,
+	}
+}
+
+func headerItems(h *spec.Header) *spec.Items {
+	return &spec.Items{
+		Type:             h.Type,
+		Format:           h.Format,
+		Items:            h.Items,
+		CollectionFormat: h.CollectionFormat,
+		Maximum:          h.Maximum,
+		ExclusiveMaximum: h.ExclusiveMaximum,
+		Minimum:          h.Minimum,
+		ExclusiveMinimum: h.ExclusiveMinimum,
+	}
+}
+
+// Value converts s to the type described by t. name identifies the value in any
+// returned error.
+//
+// Values are returned as the following Go types:
+//
+//	integer                     int64
+//	number                      float64
+//	boolean                     bool
+//	string (format "byte")      []byte
+//	string (format "date")      time.Time
+//	string (format "date-time") time.Time
+//	string                      string
+//	array                       []interface{}
+//
+// Arrays are split according to their collectionFormat. The "multi" format
+// describes repeated values rather than a single one and must be split by the
+// caller.
+func (o Options) Value(name, s string, t *spec.Items) (interface{}, error) {
+	switch t.Type {
+	case "integer":
+		bitSize := 64
+		if t.Format == "int32" {
+			bitSize = 32
+		}
+		n, err := strconv.ParseInt(s, 10, bitSize)
+		if err != nil {
+			return nil, invalid(name, s, "not a valid %s", typeName(t))
+		}
+		if err := checkRange(name, s, float64(n), t); err != nil {
+			return nil, err
+		}
+		return n, nil
+	case "number":
+		bitSize := 64
+		if t.Format == "float" {
+			bitSize = 32
+		}
+		f, err := parseFloat(s, bitSize)
+		if err != nil {
+			return nil, invalid(name, s, "not a valid %s", typeName(t))
+		}
+		if err := checkRange(name, s, f, t); err != nil {
+			return nil, err
+		}
+		return f, nil
+	case "boolean":
+		b, err := o.parseBool(s)
+		if err != nil {
+			return nil, invalid(name, s, "not a valid boolean")
+		}
+		return b, nil
+	case "string", "":
+		switch t.Format {
+		case "byte":
+			b, err := base64.StdEncoding.DecodeString(s)
+			if err != nil {
+				return nil, invalid(name, s, "not valid base64")
+			}
+			return b, nil
+		case "date":
+			d, err := time.Parse("2006-01-02", s)
+			if err != nil {
+				return nil, invalid(name, s, "not a valid full-date (expected YYYY-MM-DD)")
+			}
+			return d, nil
+		case "date-time":
+			d, err := o.parseDateTime(s)
+			if err != nil {
+				return nil, invalid(name, s, "not a valid RFC 3339 date-time")
+			}
+			return d, nil
+		}
+		return s, nil
+	case "array":
+		if t.Items == nil {
+			return nil, invalid(name, s, "array does not declare its items")
+		}
+		parts, err := split(s, t.CollectionFormat)
+		if err != nil {
+			return nil, invalid(name, s, "%v", err)
+		}
+		vals := make([]interface{}, len(parts))
+		for i, part := range parts {
+			v, err := o.Value(fmt.Sprintf("%s[%d]", name, i), part, t.Items)
+			if err != nil {
+				return nil, err
+			}
+			vals[i] = v
+		}
+		return vals, nil
+	}
+	return nil, invalid(name, s, "cannot coerce to type %q", t.Type)
+}
+
+// Format encodes v, a value of a type returned by Value, as a string according
+// to t. It is the inverse of Value.
+func (o Options) Format(v interface{}, t *spec.Items) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int32:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case []byte:
+		return base64.StdEncoding.EncodeToString(v), nil
+	case time.Time:
+		if t.Format == "date" {
+			return v.Format("2006-01-02"), nil
+		}
+		return v.Format(time.RFC3339Nano), nil
+	case []interface{}:
+		if t.Items == nil {
+			return "", fmt.Errorf("coerce: array does not declare its items")
+		}
+		sep, err := Separator(t.CollectionFormat)
+		if err != nil {
+			return "", err
+		}
+		parts := make([]string, len(v))
+		for i, elem := range v {
+			part, err := o.Format(elem, t.Items)
+			if err != nil {
+				return "", err
+			}
+			parts[i] = part
+		}
+		return strings.Join(parts, sep), nil
+	}
+	return "", fmt.Errorf("coerce: cannot format value of type %T", v)
+}
+
+// parseFloat parses a decimal number. Unlike strconv.ParseFloat it rejects
+// "NaN", "Inf" and hexadecimal floats, which are not valid JSON numbers.
+func parseFloat(s string, bitSize int) (float64, error) {
+	for _, r := range s {
+		if !strings.ContainsRune("0123456789+-.eE", r) {
+			return 0, fmt.Errorf("invalid character %q in number", r)
+		}
+	}
+	return strconv.ParseFloat(s, bitSize)
+}
+
+func (o Options) parseBool(s string) (bool, error) {
+	if o.LenientBoolean {
+		return strconv.ParseBool(s)
+	}
+	switch s {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid boolean %q", s)
+}
+
+// lenientDateTimeLayouts are accepted, in order, when LenientDateTime is set.
+var lenientDateTimeLayouts = []string{
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+}
+
+// DateTimeLayouts returns the layouts date-times are parsed with, in order:
+// RFC 3339, followed by the lenient layouts if LenientDateTime is set. Code
+// generators use them to parse as Value does.
+func (o Options) DateTimeLayouts() []string {
+	layouts := []string{time.RFC3339}
+	if o.LenientDateTime {
+		layouts = append(layouts, lenientDateTimeLayouts...)
+	}
+	return layouts
+}
+
+func (o Options) parseDateTime(s string) (time.Time, error) {
+	layouts := o.DateTimeLayouts()
+	t, err := time.Parse(layouts[0], s)
+	if err == nil {
+		return t, nil
+	}
+	for _, layout := range layouts[1:] {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return t, err
+}
+
+// split breaks an array value into its elements.
+func split(s, collectionFormat string) ([]string, error) {
+	sep, err := separator(collectionFormat)
+	if err != nil {
+		return nil, err
+	}
+	if s == "" {
+		return []string{}, nil
+	}
+	return strings.Split(s, sep), nil
+}
+
+// Separator returns the separator of the elements of an array with a
+// collectionFormat: csv, the default, ssv, tsv or pipes. The multi format,
+// which repeats the parameter instead, has no separator.
+func Separator(collectionFormat string) (string, error) {
+	sep, err := separator(collectionFormat)
+	if err != nil {
+		return "", fmt.Errorf("coerce: %v", err)
+	}
+	return sep, nil
+}
+
+// separator returns the string used to delimit array elements.
+func separator(collectionFormat string) (string, error) {
+	switch collectionFormat {
+	case "csv", "":
+		return ",", nil
+	case "ssv":
+		return " ", nil
+	case "tsv":
+		return "\t", nil
+	case "pipes":
+		return "|", nil
+	case "multi":
+		return "", fmt.Errorf("collectionFormat multi must be handled by the caller")
+	}
+	return "", fmt.Errorf("unknown collectionFormat %q", collectionFormat)
+}
+
+// checkRange enforces the maximum and minimum constraints of numeric types.
+func checkRange(name, s string, f float64, t *spec.Items) error {
+	if min := t.Minimum; min != nil {
+		if t.ExclusiveMinimum && f <= *min {
+			return invalid(name, s, "must be greater than %v", *min)
+		}
+		if f < *min {
+			return invalid(name, s, "must be greater than or equal to %v", *min)
+		}
+	}
+	if max := t.Maximum; max != nil {
+		if t.ExclusiveMaximum && f >= *max {
+			return invalid(name, s, "must be less than %v", *max)
+		}
+		if f > *max {
+			return invalid(name, s, "must be less than or equal to %v", *max)
+		}
+	}
+	return nil
+}
+
+func typeName(t *spec.Items) string {
+	if t.Format != "" {
+		return t.Type + " (" + t.Format + ")"
+	}
+	return t.Type
+}
+
+func invalid(name, s, format string, v ...interface{}) error {
+	return &Error{Name: name, Value: s, Reason: fmt.Sprintf(format, v...)}
+}
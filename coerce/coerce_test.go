@@ -0,0 +1,169 @@
+package coerce
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+func float(f float64) *float64 { return &f }
+
+func TestValue(t *testing.T) {
+	tests := []struct {
+		s       string
+		t       spec.Items
+		want    interface{}
+		wantErr bool
+	}{
+		{s: "42", t: spec.Items{Type: "integer"}, want: int64(42)},
+		{s: "4.2", t: spec.Items{Type: "integer"}, wantErr: true},
+		{s: "3000000000", t: spec.Items{Type: "integer", Format: "int32"}, wantErr: true},
+		{s: "3000000000", t: spec.Items{Type: "integer", Format: "int64"}, want: int64(3000000000)},
+		{s: "0", t: spec.Items{Type: "integer", Minimum: float(0)}, want: int64(0)},
+		{s: "0", t: spec.Items{Type: "integer", Minimum: float(0), ExclusiveMinimum: true}, wantErr: true},
+		{s: "10", t: spec.Items{Type: "number", Maximum: float(10), ExclusiveMaximum: true}, wantErr: true},
+		{s: "9.5", t: spec.Items{Type: "number", Maximum: float(10), ExclusiveMaximum: true}, want: 9.5},
+		{s: "true", t: spec.Items{Type: "boolean"}, want: true},
+		{s: "yes", t: spec.Items{Type: "boolean"}, wantErr: true},
+		{s: "aGVsbG8=", t: spec.Items{Type: "string", Format: "byte"}, want: []byte("hello")},
+		{s: "2016-02-29", t: spec.Items{Type: "string", Format: "date"}, want: time.Date(2016, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{s: "2016-02-30", t: spec.Items{Type: "string", Format: "date"}, wantErr: true},
+		{s: "2016-02-29T12:00:00Z", t: spec.Items{Type: "string", Format: "date-time"}, want: time.Date(2016, 2, 29, 12, 0, 0, 0, time.UTC)},
+		{s: "hello", t: spec.Items{Type: "string"}, want: "hello"},
+		{
+			s:    "1|2|3",
+			t:    spec.Items{Type: "array", CollectionFormat: "pipes", Items: &spec.Items{Type: "integer"}},
+			want: []interface{}{int64(1), int64(2), int64(3)},
+		},
+		{
+			s:       "1,b,3",
+			t:       spec.Items{Type: "array", Items: &spec.Items{Type: "integer"}},
+			wantErr: true,
+		},
+		{s: "{}", t: spec.Items{Type: "object"}, wantErr: true},
+	}
+
+	for i, tt := range tests {
+		got, err := Value("field", tt.s, &tt.t)
+		if err != nil {
+			if !tt.wantErr {
+				t.Errorf("case %d: unexpected error: %v", i, err)
+			}
+			continue
+		}
+		if tt.wantErr {
+			t.Errorf("case %d: expected error coercing %q", i, tt.s)
+			continue
+		}
+		if diff := pretty.Compare(got, tt.want); diff != "" {
+			t.Errorf("case %d: want != got: %s", i, diff)
+		}
+	}
+}
+
+func TestErrorAttribution(t *testing.T) {
+	p := &spec.Parameter{
+		Name:  "ids",
+		In:    "query",
+		Type:  "array",
+		Items: &spec.Items{Type: "integer"},
+	}
+	_, err := Parameter(p, "1,2,x")
+	cerr, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("expected *Error got %T (%v)", err, err)
+	}
+	if cerr.Name != "ids[2]" || cerr.Value != "x" {
+		t.Errorf("error attributed to %s=%q, wanted ids[2]=\"x\"", cerr.Name, cerr.Value)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	dateTime := &spec.Items{Type: "string", Format: "date-time"}
+	boolean := &spec.Items{Type: "boolean"}
+	number := &spec.Items{Type: "number"}
+
+	tests := []struct {
+		opts    Options
+		s       string
+		t       *spec.Items
+		want    interface{}
+		wantErr bool
+	}{
+		{s: "2016-02-29 12:00:00", t: dateTime, wantErr: true},
+		{
+			opts: Options{LenientDateTime: true},
+			s:    "2016-02-29 12:00:00",
+			t:    dateTime,
+			want: time.Date(2016, 2, 29, 12, 0, 0, 0, time.UTC),
+		},
+		{s: "1", t: boolean, wantErr: true},
+		{opts: Options{LenientBoolean: true}, s: "1", t: boolean, want: true},
+		{s: "1,5", t: number, wantErr: true},
+		{s: "1 000", t: number, wantErr: true},
+		{s: "NaN", t: number, wantErr: true},
+		{s: "0x1p-2", t: number, wantErr: true},
+		{s: "1.5e3", t: number, want: 1500.0},
+	}
+
+	for i, tt := range tests {
+		got, err := tt.opts.Value("field", tt.s, tt.t)
+		if err != nil {
+			if !tt.wantErr {
+				t.Errorf("case %d: unexpected error: %v", i, err)
+			}
+			continue
+		}
+		if tt.wantErr {
+			t.Errorf("case %d: expected error coercing %q", i, tt.s)
+			continue
+		}
+		if diff := pretty.Compare(got, tt.want); diff != "" {
+			t.Errorf("case %d: want != got: %s", i, diff)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		v    interface{}
+		t    spec.Items
+		want string
+	}{
+		{v: int64(3000000000), t: spec.Items{Type: "integer"}, want: "3000000000"},
+		{v: 1500000.25, t: spec.Items{Type: "number"}, want: "1500000.25"},
+		{v: false, t: spec.Items{Type: "boolean"}, want: "false"},
+		{v: time.Date(2016, 2, 29, 0, 0, 0, 0, time.UTC), t: spec.Items{Type: "string", Format: "date"}, want: "2016-02-29"},
+		{
+			v:    []interface{}{"a", "b"},
+			t:    spec.Items{Type: "array", CollectionFormat: "ssv", Items: &spec.Items{Type: "string"}},
+			want: "a b",
+		},
+	}
+	for i, tt := range tests {
+		got, err := Format(tt.v, &tt.t)
+		if err != nil {
+			t.Errorf("case %d: %v", i, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("case %d: want=%q, got=%q", i, tt.want, got)
+		}
+	}
+}
+
+func TestSeparator(t *testing.T) {
+	for format, want := range map[string]string{"": ",", "csv": ",", "ssv": " ", "tsv": "\t", "pipes": "|"} {
+		if got, err := Separator(format); err != nil || got != want {
+			t.Errorf("%q: want %q, got %q (%v)", format, want, got, err)
+		}
+	}
+	for _, format := range []string{"multi", "semicolons"} {
+		if _, err := Separator(format); err == nil {
+			t.Errorf("%q: expected an error", format)
+		}
+	}
+}
@@ -0,0 +1,320 @@
+/*
+Package compat checks that changes to a document's definitions are compatible
+with existing readers and writers of the data they describe.
+
+It answers the same question as a schema registry, independently of whether
+any operations changed: given a new version of the definitions, can data
+written with one version be read with the other?
+*/
+package compat
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/internal/jsonpointer"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// Mode determines which direction compatibility is checked in.
+type Mode int
+
+const (
+	// Backward requires that readers using the old definitions can read data
+	// written with the new ones. This lets producers upgrade first.
+	Backward Mode = iota
+	// Forward requires that readers using the new definitions can read data
+	// written with the old ones. This lets consumers upgrade first.
+	Forward
+	// Full requires both backward and forward compatibility.
+	Full
+)
+
+func (m Mode) String() string {
+	switch m {
+	case Backward:
+		return "backward"
+	case Forward:
+		return "forward"
+	case Full:
+		return "full"
+	}
+	return fmt.Sprintf("Mode(%d)", int(m))
+}
+
+// Incompatibility is a change which breaks the checked mode.
+type Incompatibility struct {
+	// Mode is Backward or Forward, whichever the change breaks.
+	Mode Mode `json:"mode"`
+	// Path is a JSON pointer to the changed value.
+	Path string `json:"path"`
+	// Message describes the change.
+	Message string `json:"message"`
+}
+
+func (i Incompatibility) String() string {
+	return fmt.Sprintf("%s: %s (breaks %s compatibility)", i.Path, i.Message, i.Mode)
+}
+
+// Check compares every definition present in both versions. Added and removed
+// definitions aren't incompatible by themselves, but references to a definition
+// which is missing from the reading side are.
+func Check(old, new spec.Definitions, mode Mode) []Incompatibility {
+	var found []Incompatibility
+	if mode == Backward || mode == Full {
+		found = append(found, check(new, old, Backward)...)
+	}
+	if mode == Forward || mode == Full {
+		found = append(found, check(old, new, Forward)...)
+	}
+	return found
+}
+
+// check reports what data valid under the writer's definitions could be
+// rejected by the reader's.
+func check(writer, reader spec.Definitions, mode Mode) []Incompatibility {
+	c := &checker{writer: writer, reader: reader, mode: mode, seen: make(map[[2]string]bool)}
+	// Definitions in both versions are compared once at the top level, rather
+	// than again wherever they're referenced.
+	var names []string
+	for _, name := range sortedNames(writer) {
+		if _, ok := reader[name]; ok {
+			names = append(names, name)
+			c.seen[[2]string{name, name}] = true
+		}
+	}
+	for _, name := range names {
+		w, r := writer[name], reader[name]
+		c.schema(jsonpointer.Join("/definitions", name), &w, &r)
+	}
+	return c.found
+}
+
+type checker struct {
+	writer, reader spec.Definitions
+	mode           Mode
+	// seen holds the pairs of writer and reader definitions which have already
+	// been compared, so recursive definitions terminate.
+	seen  map[[2]string]bool
+	found []Incompatibility
+}
+
+func (c *checker) report(path, format string, v ...interface{}) {
+	c.found = append(c.found, Incompatibility{Mode: c.mode, Path: path, Message: fmt.Sprintf(format, v...)})
+}
+
+func (c *checker) schema(path string, w, r *spec.Schema) {
+	if w.Ref != "" || r.Ref != "" {
+		wName, wSchema := lookup(c.writer, w)
+		rName, rSchema := lookup(c.reader, r)
+		if wSchema == nil || rSchema == nil {
+			if rSchema == nil {
+				c.report(path, "reference %q has no definition", r.Ref)
+			}
+			return
+		}
+		if wName != "" && rName != "" {
+			key := [2]string{wName, rName}
+			if c.seen[key] {
+				return
+			}
+			c.seen[key] = true
+		}
+		w, r = wSchema, rSchema
+	}
+
+	if r.Type != "" && w.Type != r.Type && !(w.Type == "integer" && r.Type == "number") {
+		if w.Type == "" {
+			c.report(path, "type %s is required but any value was allowed", r.Type)
+		} else {
+			c.report(path, "type changed between %s and %s", w.Type, r.Type)
+		}
+		return
+	}
+	if r.Format != "" && w.Format != r.Format && !widerFormat(w.Format, r.Format) {
+		c.report(jsonpointer.Join(path, "format"), "format changed between %q and %q", w.Format, r.Format)
+	}
+
+	c.enum(path, w, r)
+	c.bounds(path, w, r)
+
+	if r.Items != nil {
+		wItems := w.Items
+		if wItems == nil {
+			wItems = &spec.Schema{}
+		}
+		c.schema(jsonpointer.Join(path, "items"), wItems, r.Items)
+	}
+	c.object(path, w, r)
+
+	// allOf is compared position by position. Reordering is reported, as
+	// the schemas can't be matched otherwise.
+	for i := range r.AllOf {
+		p := jsonpointer.Join(path, "allOf", fmt.Sprint(i))
+		if i >= len(w.AllOf) {
+			c.report(p, "allOf constraint added")
+			continue
+		}
+		c.schema(p, &w.AllOf[i], &r.AllOf[i])
+	}
+}
+
+func (c *checker) object(path string, w, r *spec.Schema) {
+	wRequired := make(map[string]bool)
+	for _, name := range w.Required {
+		wRequired[name] = true
+	}
+	for _, name := range r.Required {
+		if !wRequired[name] {
+			c.report(jsonpointer.Join(path, "required"), "property %q is required but may be missing", name)
+		}
+	}
+
+	for _, name := range sortedNames(w.Properties) {
+		wProp := w.Properties[name]
+		p := jsonpointer.Join(path, "properties", name)
+		if rProp, ok := r.Properties[name]; ok {
+			c.schema(p, &wProp, &rProp)
+			continue
+		}
+		// The reader treats unknown properties as additional properties.
+		switch ap := r.AdditionalProperties; {
+		case ap == nil:
+		case ap.Schema != nil:
+			c.schema(p, &wProp, ap.Schema)
+		case !ap.Allowed:
+			c.report(p, "property %q is not allowed", name)
+		}
+	}
+
+	if ap := r.AdditionalProperties; ap != nil && (ap.Schema != nil || !ap.Allowed) {
+		wap := w.AdditionalProperties
+		writesAny := wap == nil || (wap.Allowed && wap.Schema == nil)
+		switch {
+		case writesAny && w.Type == "object" && ap.Schema == nil:
+			c.report(jsonpointer.Join(path, "additionalProperties"), "additional properties are not allowed")
+		case wap != nil && wap.Schema != nil && ap.Schema != nil:
+			c.schema(jsonpointer.Join(path, "additionalProperties"), wap.Schema, ap.Schema)
+		}
+	}
+}
+
+func (c *checker) enum(path string, w, r *spec.Schema) {
+	if len(r.Enum) == 0 {
+		return
+	}
+	p := jsonpointer.Join(path, "enum")
+	if len(w.Enum) == 0 {
+		c.report(p, "values are restricted to an enum")
+		return
+	}
+	allowed := make(map[string]bool)
+	for _, v := range r.Enum {
+		allowed[fmt.Sprintf("%#v", normalize(v))] = true
+	}
+	for _, v := range w.Enum {
+		if !allowed[fmt.Sprintf("%#v", normalize(v))] {
+			c.report(p, "enum value %v is not allowed", v)
+		}
+	}
+}
+
+func (c *checker) bounds(path string, w, r *spec.Schema) {
+	if r.Maximum != nil {
+		if w.Maximum == nil || *w.Maximum > *r.Maximum ||
+			(*w.Maximum == *r.Maximum && r.ExclusiveMaximum && !w.ExclusiveMaximum) {
+			c.report(jsonpointer.Join(path, "maximum"), "maximum is lower")
+		}
+	}
+	if r.Minimum != nil {
+		if w.Minimum == nil || *w.Minimum < *r.Minimum ||
+			(*w.Minimum == *r.Minimum && r.ExclusiveMinimum && !w.ExclusiveMinimum) {
+			c.report(jsonpointer.Join(path, "minimum"), "minimum is higher")
+		}
+	}
+	if lowerMax(w.MaxLength, r.MaxLength) {
+		c.report(jsonpointer.Join(path, "maxLength"), "maxLength is lower")
+	}
+	if w.MinLength < r.MinLength {
+		c.report(jsonpointer.Join(path, "minLength"), "minLength is higher")
+	}
+	if lowerMax(w.MaxItems, r.MaxItems) {
+		c.report(jsonpointer.Join(path, "maxItems"), "maxItems is lower")
+	}
+	if w.MinItems < r.MinItems {
+		c.report(jsonpointer.Join(path, "minItems"), "minItems is higher")
+	}
+	if r.UniqueItems && !w.UniqueItems {
+		c.report(jsonpointer.Join(path, "uniqueItems"), "items must be unique")
+	}
+	if lowerMax(w.MaxProperties, r.MaxProperties) {
+		c.report(jsonpointer.Join(path, "maxProperties"), "maxProperties is lower")
+	}
+	if w.MinProperties < r.MinProperties {
+		c.report(jsonpointer.Join(path, "minProperties"), "minProperties is higher")
+	}
+	// Patterns can't be compared, so any change is assumed to be breaking.
+	if r.Pattern != "" && w.Pattern != r.Pattern {
+		c.report(jsonpointer.Join(path, "pattern"), "pattern changed between %q and %q", w.Pattern, r.Pattern)
+	}
+	if r.MultipleOf != 0 && w.MultipleOf != r.MultipleOf {
+		c.report(jsonpointer.Join(path, "multipleOf"), "multipleOf changed between %v and %v", w.MultipleOf, r.MultipleOf)
+	}
+}
+
+// widerFormat reports if every value of the writer's format is valid in the
+// reader's, such as int32 values read as int64.
+func widerFormat(writer, reader string) bool {
+	switch reader {
+	case "int64":
+		return writer == "int32"
+	case "double":
+		return writer == "float"
+	}
+	return false
+}
+
+// lookup returns the schema a reference refers to along with the name of its
+// definition. Schemas which aren't references are returned unchanged.
+func lookup(defs spec.Definitions, s *spec.Schema) (string, *spec.Schema) {
+	if s.Ref == "" {
+		return "", s
+	}
+	name := s.Ref
+	if strings.HasPrefix(name, "#/definitions/") {
+		name = jsonpointer.Unescape(strings.TrimPrefix(name, "#/definitions/"))
+	}
+	d, ok := defs[name]
+	if !ok {
+		return name, nil
+	}
+	return name, &d
+}
+
+// normalize converts numbers so that enum values decoded from JSON and YAML
+// compare equal.
+func normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	}
+	return v
+}
+
+func sortedNames(m map[string]spec.Schema) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lowerMax reports if the reader's upper bound on a count is lower than the
+// writer's, so the writer may send values the reader rejects.
+func lowerMax(w, r *int) bool {
+	return r != nil && (w == nil || *w > *r)
+}
@@ -0,0 +1,113 @@
+package compat
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+func definitions(t *testing.T, s string) spec.Definitions {
+	var defs spec.Definitions
+	if err := yaml.Unmarshal([]byte(s), &defs); err != nil {
+		t.Fatal(err)
+	}
+	return defs
+}
+
+func TestCheck(t *testing.T) {
+	old := `
+Pet:
+  type: object
+  required: [name]
+  properties:
+    name: {type: string, maxLength: 64}
+    age: {type: integer, format: int32}
+    status: {type: string, enum: [available, sold]}
+    owner: {$ref: '#/definitions/Owner'}
+Owner:
+  type: object
+  properties:
+    pets:
+      type: array
+      items: {$ref: '#/definitions/Pet'}
+`
+	tests := []struct {
+		new  string
+		mode Mode
+		want []Incompatibility
+	}{
+		{new: old, mode: Full},
+		{
+			// Adding an optional property and widening a format is safe for
+			// old readers.
+			new: `
+Pet:
+  type: object
+  required: [name]
+  properties:
+    name: {type: string, maxLength: 64}
+    age: {type: integer, format: int64}
+    status: {type: string, enum: [available, sold]}
+    owner: {$ref: '#/definitions/Owner'}
+    tag: {type: string}
+Owner:
+  type: object
+  properties:
+    pets:
+      type: array
+      items: {$ref: '#/definitions/Pet'}
+`,
+			mode: Backward,
+			want: []Incompatibility{
+				{Mode: Backward, Path: "/definitions/Pet/properties/age/format", Message: `format changed between "int64" and "int32"`},
+			},
+		},
+		{
+			new: `
+Pet:
+  type: object
+  required: [name, age]
+  properties:
+    name: {type: string}
+    age: {type: integer, format: int32}
+    status: {type: string, enum: [available, pending, sold]}
+    owner: {$ref: '#/definitions/Owner'}
+Owner:
+  type: object
+  properties:
+    pets:
+      type: array
+      items: {$ref: '#/definitions/Pet'}
+`,
+			mode: Full,
+			want: []Incompatibility{
+				{Mode: Backward, Path: "/definitions/Pet/properties/name/maxLength", Message: "maxLength is lower"},
+				{Mode: Backward, Path: "/definitions/Pet/properties/status/enum", Message: "enum value pending is not allowed"},
+				{Mode: Forward, Path: "/definitions/Pet/required", Message: `property "age" is required but may be missing`},
+			},
+		},
+		{
+			new: `
+Pet:
+  type: object
+  required: [name]
+  properties:
+    name: {type: integer}
+`,
+			mode: Forward,
+			want: []Incompatibility{
+				{Mode: Forward, Path: "/definitions/Pet/properties/name", Message: "type changed between string and integer"},
+			},
+		},
+	}
+	for i, tt := range tests {
+		got := Check(definitions(t, old), definitions(t, tt.new), tt.mode)
+		if diff := pretty.Compare(got, tt.want); diff != "" {
+			t.Errorf("case %d: want != got: %s", i, diff)
+		}
+	}
+}
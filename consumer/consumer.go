@@ -0,0 +1,409 @@
+/*
+Package consumer implements consumer driven contracts.
+
+A consumer, such as a client application, declares the operations it calls and
+the parameters and fields it depends on in a contract file:
+
+	consumer: mobile-app
+	interactions:
+	- operationId: getPet
+	  parameters: [petId]
+	  responses:
+	    200: [id, name, owner/name]
+	- method: POST
+	  path: /pets
+	  parameters: [pet]
+	  request: [name]
+	  responses:
+	    201: [id]
+
+Fields are slash separated property names relative to a body. Arrays are
+traversed implicitly, so "tags/name" is the name of each element of "tags".
+
+Verify checks that a provider's document still satisfies a contract, and
+Breaking reports only those changes between two versions of a document which
+affect a registered consumer.
+*/
+package consumer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/ericchiang/swaggopher/internal/jsonpointer"
+	"github.com/ericchiang/swaggopher/internal/mapkeys"
+	"github.com/ericchiang/swaggopher/internal/rawdoc"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// Contract declares the parts of a provider's API a consumer relies on.
+type Contract struct {
+	// Consumer names the consumer, for reporting.
+	Consumer     string        `json:"consumer" yaml:"consumer"`
+	Interactions []Interaction `json:"interactions" yaml:"interactions"`
+}
+
+// Interaction is a single operation a consumer calls.
+type Interaction struct {
+	// The operation is identified either by its ID, or by method and path.
+	OperationID string `json:"operationId,omitempty" yaml:"operationId,omitempty"`
+	Method      string `json:"method,omitempty" yaml:"method,omitempty"`
+	Path        string `json:"path,omitempty" yaml:"path,omitempty"`
+
+	// Parameters are the names of the parameters the consumer sends,
+	// including the body parameter.
+	Parameters []string `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	// Request holds the fields of the body the consumer sends.
+	Request []string `json:"request,omitempty" yaml:"request,omitempty"`
+	// Responses maps status codes the consumer handles to the fields of the
+	// response body it reads.
+	Responses map[string][]string `json:"responses,omitempty" yaml:"responses,omitempty"`
+}
+
+func (i Interaction) String() string {
+	if i.OperationID != "" {
+		return i.OperationID
+	}
+	return strings.ToUpper(i.Method) + " " + i.Path
+}
+
+// Parse decodes a JSON or YAML contract.
+func Parse(data []byte) (*Contract, error) {
+	var c Contract
+	var err error
+	if rawdoc.IsJSON(data) {
+		err = json.Unmarshal(data, &c)
+	} else {
+		err = yaml.Unmarshal(data, &c)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("consumer: parsing contract: %v", err)
+	}
+	for i, in := range c.Interactions {
+		if in.OperationID == "" && (in.Method == "" || in.Path == "") {
+			return nil, fmt.Errorf("consumer: interaction %d must have an operationId, or a method and path", i)
+		}
+	}
+	return &c, nil
+}
+
+// Load reads a contract file.
+func Load(path string) (*Contract, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Problem is a way in which a document fails to satisfy a contract.
+type Problem struct {
+	Consumer    string `json:"consumer"`
+	Interaction string `json:"interaction"`
+	Message     string `json:"message"`
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: %s: %s", p.Consumer, p.Interaction, p.Message)
+}
+
+// Verify checks that every interaction in the contract is supported by the
+// document: the operation exists, every parameter and field the consumer uses
+// is declared, and the consumer sends everything the operation requires.
+func Verify(c *Contract, s *spec.Swagger) []Problem {
+	v := &verifier{contract: c, doc: s}
+	for _, in := range c.Interactions {
+		v.interaction(in)
+	}
+	return v.problems
+}
+
+// Breaking compares two versions of a document and reports the changes which
+// break at least one of the contracts. Problems a contract already had with
+// the old version aren't reported.
+func Breaking(contracts []*Contract, old, new *spec.Swagger) []Problem {
+	var problems []Problem
+	for _, c := range contracts {
+		known := make(map[Problem]bool)
+		for _, p := range Verify(c, old) {
+			known[p] = true
+		}
+		for _, p := range Verify(c, new) {
+			if !known[p] {
+				problems = append(problems, p)
+			}
+		}
+		problems = append(problems, typeChanges(c, old, new)...)
+	}
+	return problems
+}
+
+type verifier struct {
+	contract *Contract
+	doc      *spec.Swagger
+	problems []Problem
+}
+
+func (v *verifier) report(in Interaction, format string, args ...interface{}) {
+	v.problems = append(v.problems, Problem{
+		Consumer:    v.contract.Consumer,
+		Interaction: in.String(),
+		Message:     fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *verifier) interaction(in Interaction) {
+	op, params := find(v.doc, in)
+	if op == nil {
+		v.report(in, "operation does not exist")
+		return
+	}
+
+	sent := make(map[string]bool)
+	for _, name := range in.Parameters {
+		sent[name] = true
+		if _, ok := params[name]; !ok {
+			v.report(in, "parameter %q is not declared", name)
+		}
+	}
+	var body *spec.Schema
+	for _, name := range mapkeys.Sorted(params) {
+		p := params[name]
+		if p.Required && !sent[name] {
+			v.report(in, "required parameter %q is not sent", name)
+		}
+		if p.In == "body" {
+			body = p.Schema
+		}
+	}
+
+	if len(in.Request) > 0 {
+		if body == nil {
+			v.report(in, "operation does not accept a request body")
+		} else {
+			for _, f := range in.Request {
+				if lookup(v.doc, body, f) == nil {
+					v.report(in, "request field %q is not declared", f)
+				}
+			}
+			v.requiredFields(in, body)
+		}
+	}
+
+	for _, code := range mapkeys.Sorted(in.Responses) {
+		resp, ok := response(v.doc, op, code)
+		if !ok {
+			v.report(in, "response %s is not documented", code)
+			continue
+		}
+		for _, f := range in.Responses[code] {
+			if resp.Schema == nil || lookup(v.doc, resp.Schema, f) == nil {
+				v.report(in, "response %s field %q is not declared", code, f)
+			}
+		}
+	}
+}
+
+// requiredFields reports required properties of the request body, and of
+// objects the consumer sends within it, which the consumer doesn't send.
+func (v *verifier) requiredFields(in Interaction, body *spec.Schema) {
+	sent := make(map[string]bool)
+	parents := map[string]bool{"": true}
+	for _, f := range in.Request {
+		sent[f] = true
+		parts := strings.Split(f, "/")
+		for i := 1; i < len(parts); i++ {
+			parents[strings.Join(parts[:i], "/")] = true
+		}
+	}
+	for _, parent := range mapkeys.Sorted(parents) {
+		s := body
+		if parent != "" {
+			s = lookup(v.doc, body, parent)
+		}
+		for _, name := range required(v.doc, s) {
+			f := name
+			if parent != "" {
+				f = parent + "/" + name
+			}
+			if !sent[f] && !parents[f] {
+				v.report(in, "required request field %q is not sent", f)
+			}
+		}
+	}
+}
+
+// typeChanges reports fields used by the contract whose type differs between
+// the two documents. Fields missing from either document are reported by
+// Verify instead.
+func typeChanges(c *Contract, old, new *spec.Swagger) []Problem {
+	var problems []Problem
+	compare := func(in Interaction, kind, f string, o, n *spec.Schema) {
+		o, n = lookup(old, o, f), lookup(new, n, f)
+		if o == nil || n == nil || o.Type == n.Type {
+			return
+		}
+		// Integers are valid numbers, so reading one as the other is fine.
+		if kind == "request" && o.Type == "integer" && n.Type == "number" ||
+			kind != "request" && o.Type == "number" && n.Type == "integer" {
+			return
+		}
+		problems = append(problems, Problem{
+			Consumer:    c.Consumer,
+			Interaction: in.String(),
+			Message:     fmt.Sprintf("%s field %q changed type from %q to %q", kind, f, o.Type, n.Type),
+		})
+	}
+	for _, in := range c.Interactions {
+		oldOp, oldParams := find(old, in)
+		newOp, newParams := find(new, in)
+		if oldOp == nil || newOp == nil {
+			continue
+		}
+		if o, n := bodySchema(oldParams), bodySchema(newParams); o != nil && n != nil {
+			for _, f := range in.Request {
+				compare(in, "request", f, o, n)
+			}
+		}
+		for _, code := range mapkeys.Sorted(in.Responses) {
+			o, ok := response(old, oldOp, code)
+			if !ok || o.Schema == nil {
+				continue
+			}
+			n, ok := response(new, newOp, code)
+			if !ok || n.Schema == nil {
+				continue
+			}
+			for _, f := range in.Responses[code] {
+				compare(in, "response "+code, f, o.Schema, n.Schema)
+			}
+		}
+	}
+	return problems
+}
+
+// find returns the operation an interaction refers to and its parameters,
+// including those of its path, keyed by name.
+func find(s *spec.Swagger, in Interaction) (*spec.Operation, map[string]*spec.Parameter) {
+	var (
+		found  *spec.Operation
+		params map[string]*spec.Parameter
+	)
+	s.RangeOperations(func(path, method string, op *spec.Operation) bool {
+		if in.OperationID != "" {
+			if op.OperationId != in.OperationID {
+				return true
+			}
+		} else if path != in.Path || method != strings.ToLower(in.Method) {
+			return true
+		}
+
+		item := s.Paths[path]
+		found, params = op, make(map[string]*spec.Parameter)
+		for _, p := range s.OperationParameters(&item, op) {
+			if p, ok := s.ResolveParameter(p); ok {
+				if _, dup := params[p.Name]; !dup {
+					params[p.Name] = p
+				}
+			}
+		}
+		return false
+	})
+	return found, params
+}
+
+func bodySchema(params map[string]*spec.Parameter) *spec.Schema {
+	for _, p := range params {
+		if p.In == "body" {
+			return p.Schema
+		}
+	}
+	return nil
+}
+
+// response returns the documented response for a status code, falling back to
+// the default response.
+func response(s *spec.Swagger, op *spec.Operation, code string) (spec.Response, bool) {
+	r, ok := op.Responses[code]
+	if !ok {
+		r, ok = op.Responses["default"]
+	}
+	if ok && r.Ref != "" {
+		r, ok = s.Responses[jsonpointer.Unescape(strings.TrimPrefix(r.Ref, "#/responses/"))]
+	}
+	return r, ok
+}
+
+// maxRefs bounds how many references are followed in a row, so cyclic
+// references terminate.
+const maxRefs = 32
+
+// resolve follows local references to definitions.
+func resolve(s *spec.Swagger, schema *spec.Schema) *spec.Schema {
+	for i := 0; schema != nil && schema.Ref != ""; i++ {
+		if i == maxRefs {
+			return nil
+		}
+		d, ok := s.Definitions[jsonpointer.Unescape(strings.TrimPrefix(schema.Ref, "#/definitions/"))]
+		if !ok {
+			return nil
+		}
+		schema = &d
+	}
+	return schema
+}
+
+// lookup returns the schema of a slash separated field within a body, or nil
+// if the field isn't declared.
+func lookup(s *spec.Swagger, schema *spec.Schema, field string) *spec.Schema {
+	for _, name := range strings.Split(field, "/") {
+		schema = property(s, schema, name)
+		if schema == nil {
+			return nil
+		}
+	}
+	return schema
+}
+
+func property(s *spec.Swagger, schema *spec.Schema, name string) *spec.Schema {
+	schema = resolve(s, schema)
+	for schema != nil && schema.Type == "array" {
+		schema = resolve(s, schema.Items)
+	}
+	if schema == nil {
+		return nil
+	}
+	if p, ok := schema.Properties[name]; ok {
+		return &p
+	}
+	for i := range schema.AllOf {
+		if p := property(s, &schema.AllOf[i], name); p != nil {
+			return p
+		}
+	}
+	if ap := schema.AdditionalProperties; ap != nil && ap.Schema != nil {
+		return ap.Schema
+	}
+	return nil
+}
+
+// required returns the required properties of an object schema, including
+// those of its allOf schemas.
+func required(s *spec.Swagger, schema *spec.Schema) []string {
+	schema = resolve(s, schema)
+	for schema != nil && schema.Type == "array" {
+		schema = resolve(s, schema.Items)
+	}
+	if schema == nil {
+		return nil
+	}
+	names := append([]string(nil), schema.Required...)
+	for i := range schema.AllOf {
+		names = append(names, required(s, &schema.AllOf[i])...)
+	}
+	return names
+}
@@ -0,0 +1,184 @@
+package consumer
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+const provider = `
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+paths:
+  /pets:
+    post:
+      operationId: createPet
+      parameters:
+      - name: pet
+        in: body
+        required: true
+        schema: {$ref: '#/definitions/NewPet'}
+      responses:
+        201:
+          description: Created.
+          schema: {$ref: '#/definitions/Pet'}
+  /pets/{petId}:
+    parameters:
+    - {$ref: '#/parameters/petId'}
+    get:
+      operationId: getPet
+      parameters:
+      - {name: verbose, in: query, type: boolean}
+      responses:
+        200:
+          description: A pet.
+          schema: {$ref: '#/definitions/Pet'}
+        default:
+          description: An error.
+parameters:
+  petId: {name: petId, in: path, required: true, type: string}
+definitions:
+  NewPet:
+    type: object
+    required: [name]
+    properties:
+      name: {type: string}
+      tag: {type: string}
+  Pet:
+    allOf:
+    - {$ref: '#/definitions/NewPet'}
+    - type: object
+      properties:
+        id: {type: integer}
+        owner:
+          type: object
+          properties:
+            name: {type: string}
+        tags:
+          type: array
+          items:
+            type: object
+            properties:
+              name: {type: string}
+`
+
+const mobile = `
+consumer: mobile-app
+interactions:
+- operationId: getPet
+  parameters: [petId]
+  responses:
+    200: [id, name, owner/name, tags/name]
+    404: []
+- method: POST
+  path: /pets
+  parameters: [pet]
+  request: [name]
+  responses:
+    201: [id]
+`
+
+func parse(t *testing.T, doc string) *spec.Swagger {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(doc), &s); err != nil {
+		t.Fatal(err)
+	}
+	return &s
+}
+
+func TestVerify(t *testing.T) {
+	s := parse(t, provider)
+	tests := []struct {
+		contract string
+		want     []Problem
+	}{
+		{contract: mobile},
+		{
+			contract: `
+consumer: web
+interactions:
+- operationId: listPets
+- method: get
+  path: /pets/{petId}
+  parameters: [petId, limit]
+  request: [name]
+  responses:
+    200: [id, owner/email]
+- operationId: createPet
+  request: [tag, owner/name]
+`,
+			want: []Problem{
+				{"web", "listPets", "operation does not exist"},
+				{"web", "GET /pets/{petId}", `parameter "limit" is not declared`},
+				{"web", "GET /pets/{petId}", "operation does not accept a request body"},
+				{"web", "GET /pets/{petId}", `response 200 field "owner/email" is not declared`},
+				{"web", "createPet", `required parameter "pet" is not sent`},
+				{"web", "createPet", `request field "owner/name" is not declared`},
+				{"web", "createPet", `required request field "name" is not sent`},
+			},
+		},
+	}
+	for i, tt := range tests {
+		c, err := Parse([]byte(tt.contract))
+		if err != nil {
+			t.Errorf("case %d: %v", i, err)
+			continue
+		}
+		if diff := pretty.Compare(Verify(c, s), tt.want); diff != "" {
+			t.Errorf("case %d: want != got: %s", i, diff)
+		}
+	}
+}
+
+func TestBreaking(t *testing.T) {
+	old := parse(t, provider)
+	new := parse(t, provider)
+
+	// Changes the mobile app doesn't depend on.
+	delete(new.Definitions["NewPet"].Properties, "tag")
+	get := new.Paths["/pets/{petId}"].Get
+	get.Parameters = nil
+
+	// Changes it does.
+	pet := new.Definitions["Pet"]
+	pet.AllOf[1].Properties["id"] = spec.Schema{Type: "string"}
+	delete(pet.AllOf[1].Properties["owner"].Properties, "name")
+	get.Parameters = append(get.Parameters, spec.Parameter{Name: "region", In: "header", Required: true, Type: "string"})
+
+	mobileApp, err := Parse([]byte(mobile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A consumer which only creates pets, but had a problem all along.
+	other, err := Parse([]byte(`{"consumer": "importer", "interactions": [{"operationId": "createPet", "parameters": ["pet", "dryRun"]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := Breaking([]*Contract{mobileApp, other}, old, new)
+	want := []Problem{
+		{"mobile-app", "getPet", `required parameter "region" is not sent`},
+		{"mobile-app", "getPet", `response 200 field "owner/name" is not declared`},
+		{"mobile-app", "getPet", `response 200 field "id" changed type from "integer" to "string"`},
+		{"mobile-app", "POST /pets", `response 201 field "id" changed type from "integer" to "string"`},
+	}
+	if diff := pretty.Compare(got, want); diff != "" {
+		t.Errorf("want != got: %s", diff)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		`{"consumer": "x", "interactions": [{"method": "GET"}]}`,
+		"consumer: [",
+	}
+	for i, tt := range tests {
+		if _, err := Parse([]byte(tt)); err == nil {
+			t.Errorf("case %d: expected error", i)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package contract
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/internal/synth"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// testCase describes a request to send. Parameters are keyed by location and
+// name, such as "query/limit".
+type testCase struct {
+	name     string
+	negative bool
+	// omit is the key of a parameter to leave out.
+	omit string
+	// invalid is the key of a parameter to send an invalid value for.
+	invalid string
+	// values holds the values of parameters set by a fixture, by key.
+	values map[string]string
+	// body, if set, is sent instead of a generated body.
+	body interface{}
+}
+
+// invalidValue is sent for parameters which must be numbers or booleans.
+const invalidValue = "not-a-valid-value"
+
+func key(p *spec.Parameter) string {
+	return p.In + "/" + p.Name
+}
+
+// cases generates the positive case and, for each required or typed
+// parameter, a negative case.
+func (r *runner) cases(op Operation) []testCase {
+	cases := []testCase{{name: "valid request"}}
+	for _, p := range r.parameters(op) {
+		if p.Required && p.In != "path" {
+			cases = append(cases, testCase{
+				name:     fmt.Sprintf("missing required %s parameter %s", p.In, p.Name),
+				negative: true,
+				omit:     key(p),
+			})
+		}
+		switch p.Type {
+		case "integer", "number", "boolean":
+			cases = append(cases, testCase{
+				name:     fmt.Sprintf("invalid %s parameter %s", p.In, p.Name),
+				negative: true,
+				invalid:  key(p),
+			})
+		}
+	}
+	return cases
+}
+
+// parameters returns the operation's parameters merged with those of its path,
+// as spec.Swagger's OperationParameters does.
+func (r *runner) parameters(op Operation) []*spec.Parameter {
+	item := op.item
+	if item == nil {
+		item = new(spec.PathItem)
+	}
+	return r.doc.OperationParameters(item, op.Operation)
+}
+
+func (r *runner) request(ctx context.Context, op Operation, tc testCase) (*http.Request, error) {
+	path := op.Path
+	query := url.Values{}
+	header := http.Header{}
+	form := url.Values{}
+	var (
+		body     interface{}
+		hasBody  bool
+		hasFile  bool
+		consumes = op.Consumes
+	)
+	if len(consumes) == 0 {
+		consumes = r.doc.Consumes
+	}
+
+	for _, p := range r.parameters(op) {
+		if p.Ref != "" {
+			// References to undefined parameters are skipped.
+			continue
+		}
+		k := key(p)
+		if k == tc.omit {
+			continue
+		}
+		if p.In == "body" {
+			switch {
+			case tc.body != nil:
+				body, hasBody = tc.body, true
+			case p.Schema != nil:
+				body, hasBody = synth.Example(r.doc, p.Schema, true), true
+			}
+			continue
+		}
+		fixed, isFixed := tc.values[k]
+		if !p.Required && p.Default == nil && k != tc.invalid && !isFixed {
+			continue
+		}
+
+		var values []string
+		switch {
+		case k == tc.invalid:
+			values = []string{invalidValue}
+		case isFixed:
+			values = []string{fixed}
+		case p.Type == "file":
+			hasFile = true
+			values = []string{"test"}
+		default:
+			v, err := r.value(op, p)
+			if err != nil {
+				return nil, fmt.Errorf("parameter %s: %v", p.Name, err)
+			}
+			values = v
+		}
+
+		switch p.In {
+		case "path":
+			path = strings.Replace(path, "{"+p.Name+"}", url.PathEscape(values[0]), -1)
+		case "query":
+			query[p.Name] = values
+		case "header":
+			header[http.CanonicalHeaderKey(p.Name)] = values
+		case "formData":
+			form[p.Name] = values
+		}
+	}
+
+	u := *r.base
+	u.Path += path
+	u.RawQuery = query.Encode()
+
+	var (
+		reqBody     []byte
+		contentType string
+	)
+	switch {
+	case hasBody:
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reqBody, contentType = data, "application/json"
+		for _, mt := range consumes {
+			if strings.Contains(mt, "json") {
+				contentType = mt
+				break
+			}
+		}
+	case hasFile || contains(consumes, "multipart/form-data"):
+		var buf bytes.Buffer
+		w := multipart.NewWriter(&buf)
+		for name, values := range form {
+			for _, v := range values {
+				if err := w.WriteField(name, v); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		reqBody, contentType = buf.Bytes(), w.FormDataContentType()
+	case len(form) > 0:
+		reqBody, contentType = []byte(form.Encode()), "application/x-www-form-urlencoded"
+	}
+
+	req, err := http.NewRequest(strings.ToUpper(op.Method), u.String(), bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return req, nil
+}
+
+// value returns the string encoded values to send for a parameter. Only
+// parameters with the "multi" collectionFormat have more than one.
+func (r *runner) value(op Operation, p *spec.Parameter) ([]string, error) {
+	if r.opts.Value != nil {
+		if v, ok := r.opts.Value(op, p); ok {
+			return []string{v}, nil
+		}
+	}
+	return synth.Parameter(p)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/assert"
+	"github.com/ericchiang/swaggopher/fixture"
+	"github.com/ericchiang/swaggopher/internal/conform"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// checkResponse compares the response to a positive case with the operation's
+// documented responses.
+func (r *runner) checkResponse(op Operation, resp *http.Response) []string {
+	code := strconv.Itoa(resp.StatusCode)
+	documented, ok := op.Responses[code]
+	if !ok {
+		documented, ok = op.Responses["default"]
+	}
+	if !ok {
+		return []string{fmt.Sprintf("status %d is not documented", resp.StatusCode)}
+	}
+	if documented.Ref != "" {
+		documented = r.doc.Responses[strings.TrimPrefix(documented.Ref, "#/responses/")]
+	}
+
+	var failures []string
+	if resp.StatusCode >= 400 && hasSuccess(op.Responses) {
+		failures = append(failures, fmt.Sprintf("expected a successful status, got %d", resp.StatusCode))
+	}
+	assertions := r.lookupAssertions(op)
+	if resp.StatusCode >= 400 {
+		assertions = nil
+	}
+	if (documented.Schema == nil && len(assertions) == 0) || resp.StatusCode == http.StatusNoContent {
+		return failures
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if !strings.HasSuffix(mediaType, "json") {
+		produces := op.Produces
+		if len(produces) == 0 {
+			produces = r.doc.Produces
+		}
+		// Only JSON bodies can be checked against their schema.
+		if len(produces) == 0 || hasJSON(produces) {
+			failures = append(failures, fmt.Sprintf("expected a JSON response, got content type %q", mediaType))
+		}
+		return failures
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return append(failures, fmt.Sprintf("reading body: %v", err))
+	}
+	var body interface{}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return append(failures, fmt.Sprintf("invalid JSON body: %v", err))
+	}
+	if documented.Schema != nil {
+		for _, msg := range conform.Value(r.doc, documented.Schema, body, "") {
+			failures = append(failures, "body"+msg)
+		}
+	}
+	for _, msg := range assertions.Check(r.doc, body) {
+		failures = append(failures, "assertion "+msg)
+	}
+	return failures
+}
+
+// compileAssertions compiles the assertions of Options.Assertions, checking
+// that the operations they're keyed by exist.
+func compileAssertions(s *spec.Swagger, exprs map[string][]string) (map[string]assert.Assertions, error) {
+	keys := make([]string, 0, len(exprs))
+	for k := range exprs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	compiled := make(map[string]assert.Assertions, len(exprs))
+	for _, k := range keys {
+		list := exprs[k]
+		if _, _, _, ok := fixture.Find(s, k); !ok {
+			return nil, fmt.Errorf("contract: assertions for %s: no such operation", k)
+		}
+		a, err := assert.CompileAll(list)
+		if err != nil {
+			return nil, fmt.Errorf("contract: assertions for %s: %v", k, err)
+		}
+		compiled[k] = a
+	}
+	return compiled, nil
+}
+
+// lookupAssertions returns the assertions of an operation, keyed by its
+// operationId or its method and path.
+func (r *runner) lookupAssertions(op Operation) assert.Assertions {
+	if op.OperationId != "" {
+		if a, ok := r.assertions[op.OperationId]; ok {
+			return a
+		}
+	}
+	return r.assertions[strings.ToUpper(op.Method)+" "+op.Path]
+}
+
+func hasSuccess(responses spec.Responses) bool {
+	for code := range responses {
+		if strings.HasPrefix(code, "2") {
+			return true
+		}
+	}
+	return false
+}
+
+func hasJSON(mediaTypes []string) bool {
+	for _, mt := range mediaTypes {
+		if strings.Contains(mt, "json") {
+			return true
+		}
+	}
+	return false
+}
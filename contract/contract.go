@@ -0,0 +1,279 @@
+/*
+Package contract tests a running implementation of an API against its spec.
+
+Run generates test cases for every operation and sends them to a server. Each
+operation gets a positive case, a request built from valid values which must
+succeed with a documented response, and negative cases, such as requests with
+a required parameter missing or a value of the wrong type, which must be
+rejected with a 4xx status.
+
+Operations are tested in the order given by Plan, so that those creating
+resources or returning credentials run before the operations which need them.
+Dependencies Plan can't infer are declared with the "x-depends-on" extension:
+
+	/pets/{petId}/vaccinations:
+	  get:
+	    operationId: listVaccinations
+	    x-depends-on: [createPet, vaccinatePet]
+
+Besides conforming to their documented schemas, the bodies of successful
+responses can be checked with assertions from package assert, given by
+Options.Assertions:
+
+	opts.Assertions = map[string][]string{
+		"getPet": {"$.id type integer", "$.name matches ^[A-Z]"},
+	}
+*/
+package contract
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/assert"
+	"github.com/ericchiang/swaggopher/fixture"
+	"github.com/ericchiang/swaggopher/internal/logutil"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// Operation identifies the operation a test case is generated for.
+type Operation struct {
+	Method string
+	Path   string
+	*spec.Operation
+
+	item *spec.PathItem
+}
+
+func (o Operation) String() string {
+	return strings.ToUpper(o.Method) + " " + o.Path
+}
+
+// Options configures Run. The zero value is valid.
+type Options struct {
+	// Client sends requests. It defaults to http.DefaultClient.
+	Client *http.Client
+
+	// Auth is called with every request before it's sent, to add credentials.
+	Auth func(req *http.Request) error
+
+	// Setup is called before an operation's cases are run, for example to
+	// create resources its path parameters refer to. If it returns an error
+	// the operation's cases are skipped and the operation fails.
+	Setup func(ctx context.Context, op Operation) error
+	// Teardown is called after an operation's cases are run, even if they
+	// fail.
+	Teardown func(ctx context.Context, op Operation) error
+
+	// Value returns the string encoded value to use for a parameter, such as
+	// the ID of a resource created by Setup. If it returns false, or is nil, a
+	// value matching the parameter's type is generated.
+	Value func(op Operation, p *spec.Parameter) (string, bool)
+
+	// Fixtures, if set, describe requests to send before an operation is
+	// tested, after Setup, and values for its parameters, which take
+	// precedence over Value. See package fixture.
+	Fixtures fixture.Fixtures
+
+	// Assertions maps the operationId, or method and path such as
+	// "GET /pets/{id}", of operations to assertions their successful responses'
+	// JSON bodies must pass, such as "$.id type integer". See package assert.
+	Assertions map[string][]string
+
+	// SkipNegative only runs positive cases.
+	SkipNegative bool
+
+	// Progress, if set, is called after each operation is tested.
+	Progress spec.ProgressFunc
+	// Logger, if set, receives a line for each request sent.
+	Logger spec.Logger
+}
+
+// Report holds the results of every tested operation, in the order they were
+// tested.
+type Report struct {
+	Operations []OperationReport `json:"operations"`
+}
+
+// OK reports if every operation passed.
+func (r *Report) OK() bool {
+	for _, op := range r.Operations {
+		if !op.OK() {
+			return false
+		}
+	}
+	return true
+}
+
+// OperationReport holds the results of the cases run for one operation.
+type OperationReport struct {
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+	OperationID string `json:"operationId,omitempty"`
+	// Err is set if the operation couldn't be tested, such as when Setup or
+	// a request of its fixture fails.
+	Err   string `json:"error,omitempty"`
+	Cases []Case `json:"cases"`
+}
+
+// OK reports if the operation was tested and every case passed.
+func (r OperationReport) OK() bool {
+	if r.Err != "" {
+		return false
+	}
+	for _, c := range r.Cases {
+		if !c.OK() {
+			return false
+		}
+	}
+	return true
+}
+
+// Case is the result of a single request.
+type Case struct {
+	// Name describes the case, such as "missing required query parameter limit".
+	Name string `json:"name"`
+	// Negative is set for cases that are expected to be rejected.
+	Negative bool `json:"negative,omitempty"`
+	// Status is the status code of the response, or zero if none was received.
+	Status int `json:"status,omitempty"`
+	// Failures describes how the response differed from the spec. A case
+	// with no failures passed.
+	Failures []string `json:"failures,omitempty"`
+}
+
+// OK reports if the case passed.
+func (c Case) OK() bool {
+	return len(c.Failures) == 0
+}
+
+// Run tests every operation in s against the server at baseURL. The
+// document's basePath is appended to baseURL. An error is only returned if
+// baseURL is invalid, the operations can't be ordered, or the fixtures or
+// assertions are invalid; failing cases are recorded in the report.
+func Run(ctx context.Context, baseURL string, s *spec.Swagger, opts Options) (*Report, error) {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("contract: invalid base URL %q: %v", baseURL, err)
+	}
+	if base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("contract: base URL %q must be absolute", baseURL)
+	}
+	base.Path = strings.TrimSuffix(base.Path, "/") + strings.TrimSuffix(s.BasePath, "/")
+
+	r := &runner{doc: s, base: base, opts: opts, client: opts.Client}
+	if r.client == nil {
+		r.client = http.DefaultClient
+	}
+
+	if opts.Fixtures != nil {
+		if err := opts.Fixtures.Check(s); err != nil {
+			return nil, err
+		}
+	}
+	if r.assertions, err = compileAssertions(s, opts.Assertions); err != nil {
+		return nil, err
+	}
+	ops, err := Plan(s)
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{}
+	for i, op := range ops {
+		report.Operations = append(report.Operations, r.operation(ctx, op))
+		if opts.Progress != nil {
+			opts.Progress(i+1, len(ops), op.String())
+		}
+	}
+	return report, nil
+}
+
+type runner struct {
+	doc    *spec.Swagger
+	base   *url.URL
+	opts   Options
+	client *http.Client
+	// assertions holds the compiled assertions of Options.Assertions, keyed
+	// as they are.
+	assertions map[string]assert.Assertions
+}
+
+func (r *runner) operation(ctx context.Context, op Operation) OperationReport {
+	report := OperationReport{Method: strings.ToUpper(op.Method), Path: op.Path, OperationID: op.OperationId}
+	if r.opts.Setup != nil {
+		if err := r.opts.Setup(ctx, op); err != nil {
+			report.Err = fmt.Sprintf("setup: %v", err)
+			return report
+		}
+	}
+	if r.opts.Teardown != nil {
+		defer func() {
+			if err := r.opts.Teardown(ctx, op); err != nil && report.Err == "" {
+				report.Err = fmt.Sprintf("teardown: %v", err)
+			}
+		}()
+	}
+
+	var values map[string]string
+	if fx, ok := r.opts.Fixtures.Lookup(op.Method, op.Path, op.Operation); ok {
+		v, err := r.fixture(ctx, op, fx)
+		if err != nil {
+			report.Err = fmt.Sprintf("fixture: %v", err)
+			return report
+		}
+		values = v
+	}
+
+	for _, tc := range r.cases(op) {
+		if tc.negative && r.opts.SkipNegative {
+			continue
+		}
+		tc.values = values
+		report.Cases = append(report.Cases, r.run(ctx, op, tc))
+	}
+	return report
+}
+
+func (r *runner) run(ctx context.Context, op Operation, tc testCase) Case {
+	c := Case{Name: tc.name, Negative: tc.negative}
+	req, err := r.request(ctx, op, tc)
+	if err != nil {
+		c.Failures = append(c.Failures, fmt.Sprintf("building request: %v", err))
+		return c
+	}
+	if r.opts.Auth != nil {
+		if err := r.opts.Auth(req); err != nil {
+			c.Failures = append(c.Failures, fmt.Sprintf("auth: %v", err))
+			return c
+		}
+	}
+	logutil.Printf(r.opts.Logger, "contract: %s %s (%s)", req.Method, req.URL, tc.name)
+	resp, err := r.client.Do(req)
+	if err != nil {
+		c.Failures = append(c.Failures, err.Error())
+		return c
+	}
+	defer resp.Body.Close()
+	c.Status = resp.StatusCode
+
+	if tc.negative {
+		if resp.StatusCode < 400 || resp.StatusCode >= 500 {
+			c.Failures = append(c.Failures, fmt.Sprintf("expected a 4xx status, got %d", resp.StatusCode))
+		}
+		return c
+	}
+	c.Failures = append(c.Failures, r.checkResponse(op, resp)...)
+	return c
+}
+
+// operations returns a document's operations ordered by path, then method.
+func operations(s *spec.Swagger) []Operation {
+	var ops []Operation
+	for _, o := range s.SortedOperations() {
+		ops = append(ops, Operation{Method: o.Method, Path: o.Path, Operation: o.Operation, item: o.Item})
+	}
+	return ops
+}
@@ -0,0 +1,390 @@
+package contract
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/ericchiang/swaggopher/fixture"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+const petstore = `
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+basePath: /v1
+produces: [application/json]
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      parameters:
+      - {name: limit, in: query, type: integer, minimum: 1}
+      responses:
+        200:
+          description: Pets.
+          schema:
+            type: array
+            items: {$ref: '#/definitions/Pet'}
+    post:
+      operationId: createPet
+      parameters:
+      - name: pet
+        in: body
+        required: true
+        schema: {$ref: '#/definitions/Pet'}
+      responses:
+        201:
+          description: Created.
+          schema: {$ref: '#/definitions/Pet'}
+        400:
+          description: Invalid pet.
+  /pets/{petId}:
+    get:
+      operationId: getPet
+      parameters:
+      - {name: petId, in: path, required: true, type: integer}
+      - {name: X-Request-Id, in: header, required: true, type: string}
+      responses:
+        200:
+          description: A pet.
+          schema: {$ref: '#/definitions/Pet'}
+        400:
+          description: Bad request.
+definitions:
+  Pet:
+    type: object
+    required: [id, name]
+    properties:
+      id: {type: integer, readOnly: true}
+      name: {type: string}
+`
+
+// server implements the petstore, except that getPet returns the wrong type
+// for "name" and ignores a missing X-Request-Id header.
+func server() *httptest.Server {
+	mux := http.NewServeMux()
+	reply := func(w http.ResponseWriter, status int, v interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(v)
+	}
+	mux.HandleFunc("/v1/pets", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			if l := r.URL.Query().Get("limit"); l != "" {
+				if _, err := strconv.Atoi(l); err != nil {
+					reply(w, 400, map[string]string{"error": "invalid limit"})
+					return
+				}
+			}
+			reply(w, 200, []interface{}{map[string]interface{}{"id": 1, "name": "Rex"}})
+		case "POST":
+			var pet map[string]interface{}
+			if err := json.NewDecoder(r.Body).Decode(&pet); err != nil {
+				reply(w, 400, map[string]string{"error": err.Error()})
+				return
+			}
+			pet["id"] = 2
+			reply(w, 201, pet)
+		}
+	})
+	mux.HandleFunc("/v1/pets/", func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.URL.Path[len("/v1/pets/"):])
+		if err != nil {
+			reply(w, 400, map[string]string{"error": "invalid id"})
+			return
+		}
+		reply(w, 200, map[string]interface{}{"id": id, "name": 7})
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestRun(t *testing.T) {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(petstore), &s); err != nil {
+		t.Fatal(err)
+	}
+	srv := server()
+	defer srv.Close()
+
+	var setup []string
+	opts := Options{
+		Setup: func(ctx context.Context, op Operation) error {
+			setup = append(setup, op.OperationId)
+			return nil
+		},
+		Value: func(op Operation, p *spec.Parameter) (string, bool) {
+			if p.Name == "petId" {
+				return "42", true
+			}
+			return "", false
+		},
+	}
+	report, err := Run(context.Background(), srv.URL, &s, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.OK() {
+		t.Errorf("expected report to fail")
+	}
+
+	type result struct {
+		Op, Case string
+		Status   int
+		Failures []string
+	}
+	var got []result
+	for _, op := range report.Operations {
+		for _, c := range op.Cases {
+			got = append(got, result{fmt.Sprintf("%s %s", op.Method, op.Path), c.Name, c.Status, c.Failures})
+		}
+	}
+	want := []result{
+		{"GET /pets", "valid request", 200, nil},
+		{"GET /pets", "invalid query parameter limit", 400, nil},
+		{"POST /pets", "valid request", 201, nil},
+		{"POST /pets", "missing required body parameter pet", 400, nil},
+		{"GET /pets/{petId}", "valid request", 200, []string{`body/name: expected a string, got an integer`}},
+		{"GET /pets/{petId}", "invalid path parameter petId", 400, nil},
+		{"GET /pets/{petId}", "missing required header parameter X-Request-Id", 200, []string{"expected a 4xx status, got 200"}},
+	}
+	if diff := pretty.Compare(got, want); diff != "" {
+		t.Errorf("want != got: %s", diff)
+	}
+	if diff := pretty.Compare(setup, []string{"listPets", "createPet", "getPet"}); diff != "" {
+		t.Errorf("setup: want != got: %s", diff)
+	}
+}
+
+func TestRunSetupFailure(t *testing.T) {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(petstore), &s); err != nil {
+		t.Fatal(err)
+	}
+	srv := server()
+	defer srv.Close()
+
+	opts := Options{
+		Setup: func(ctx context.Context, op Operation) error {
+			if op.OperationId == "createPet" {
+				return fmt.Errorf("database unavailable")
+			}
+			return nil
+		},
+		SkipNegative: true,
+	}
+	report, err := Run(context.Background(), srv.URL, &s, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, op := range report.Operations {
+		if op.OperationID == "createPet" {
+			if op.Err != "setup: database unavailable" || len(op.Cases) != 0 {
+				t.Errorf("expected createPet to fail setup, got %+v", op)
+			}
+		} else if len(op.Cases) != 1 {
+			t.Errorf("%s: expected only the positive case, got %d cases", op.OperationID, len(op.Cases))
+		}
+	}
+}
+
+func TestPlan(t *testing.T) {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(`
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+security:
+- key: []
+paths:
+  /auth/token:
+    post:
+      operationId: login
+      security: []
+      responses:
+        200: {description: A token.}
+  /pets:
+    get:
+      operationId: listPets
+      security: []
+      responses:
+        200: {description: Pets.}
+    post:
+      operationId: createPet
+      responses:
+        201: {description: Created.}
+  /owners:
+    get:
+      operationId: listOwners
+      x-depends-on: [createPet]
+      responses:
+        200: {description: Owners.}
+  /pets/{petId}:
+    delete:
+      operationId: deletePet
+      responses:
+        204: {description: Deleted.}
+    get:
+      operationId: getPet
+      responses:
+        200: {description: A pet.}
+`), &s); err != nil {
+		t.Fatal(err)
+	}
+	deps, err := Dependencies(&s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, d := range deps {
+		got = append(got, d.String())
+	}
+	want := []string{
+		"GET /owners requires POST /pets (declared by x-depends-on)",
+		"GET /owners requires POST /auth/token (provides credentials)",
+		"POST /pets requires POST /auth/token (provides credentials)",
+		"DELETE /pets/{petId} requires POST /pets (creates the resources of /pets)",
+		"DELETE /pets/{petId} requires GET /pets/{petId} (deletes the resource)",
+		"DELETE /pets/{petId} requires POST /auth/token (provides credentials)",
+		"GET /pets/{petId} requires POST /pets (creates the resources of /pets)",
+		"GET /pets/{petId} requires POST /auth/token (provides credentials)",
+	}
+	if diff := pretty.Compare(want, got); diff != "" {
+		t.Errorf("dependencies: want != got: %s", diff)
+	}
+
+	plan, err := Plan(&s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = nil
+	for _, op := range plan {
+		got = append(got, op.OperationId)
+	}
+	want = []string{"login", "listPets", "createPet", "listOwners", "getPet", "deletePet"}
+	if diff := pretty.Compare(want, got); diff != "" {
+		t.Errorf("plan: want != got: %s", diff)
+	}
+
+	// Dependencies which form a cycle can't be planned.
+	s.Paths["/pets"].Post.Extensions = map[string]interface{}{DependsOnExtension: []interface{}{"listOwners"}}
+	if _, err := Plan(&s); err == nil {
+		t.Errorf("expected an error for cyclic dependencies")
+	}
+}
+
+func TestRunFixtures(t *testing.T) {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(petstore), &s); err != nil {
+		t.Fatal(err)
+	}
+	srv := server()
+	defer srv.Close()
+
+	var requests []string
+	logged := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+r.Header.Get("X-Request-Id"))
+		r.URL.Scheme, r.URL.Host = "http", srv.Listener.Addr().String()
+		resp, err := http.DefaultTransport.RoundTrip(&http.Request{Method: r.Method, URL: r.URL, Header: r.Header, Body: r.Body})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		w.WriteHeader(resp.StatusCode)
+		io.Copy(w, resp.Body)
+	}))
+	defer logged.Close()
+
+	fixtures, err := fixture.Parse([]byte(`
+getPet:
+  create:
+  - operation: createPet
+    as: pet
+    body: {name: Rex}
+  values:
+    path.petId: $pet.body#/id
+    X-Request-Id: $pet.status
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := Options{Fixtures: fixtures, SkipNegative: true}
+	report, err := Run(context.Background(), logged.URL, &s, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"GET /v1/pets ",
+		"POST /v1/pets ",
+		"POST /v1/pets ",
+		"GET /v1/pets/2 201",
+	}
+	if diff := pretty.Compare(want, requests); diff != "" {
+		t.Errorf("requests: want != got: %s", diff)
+	}
+	for _, op := range report.Operations {
+		if op.Err != "" {
+			t.Errorf("%s %s: %s", op.Method, op.Path, op.Err)
+		}
+	}
+
+	opts.Fixtures = fixture.Fixtures{"getPet": {Create: []fixture.Step{{Operation: "deletePet"}}}}
+	if _, err := Run(context.Background(), srv.URL, &s, opts); err == nil {
+		t.Errorf("expected an error for a fixture calling an unknown operation")
+	}
+}
+
+func TestRunAssertions(t *testing.T) {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(petstore), &s); err != nil {
+		t.Fatal(err)
+	}
+	srv := server()
+	defer srv.Close()
+
+	opts := Options{
+		SkipNegative: true,
+		Assertions: map[string][]string{
+			"listPets":          {"$[*].name matches ^[A-Z]", "$[*].id range 1.."},
+			"POST /pets":        {"$.id range 3.."},
+			"GET /pets/{petId}": {"$.id type integer"},
+		},
+	}
+	report, err := Run(context.Background(), srv.URL, &s, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string][]string)
+	for _, op := range report.Operations {
+		for _, c := range op.Cases {
+			got[op.Method+" "+op.Path] = append(got[op.Method+" "+op.Path], c.Failures...)
+		}
+	}
+	want := map[string][]string{
+		"GET /pets":         nil,
+		"POST /pets":        {"assertion $.id: 2 is outside the range 3.."},
+		"GET /pets/{petId}": {"body/name: expected a string, got an integer"},
+	}
+	if diff := pretty.Compare(want, got); diff != "" {
+		t.Errorf("want != got: %s", diff)
+	}
+
+	opts.Assertions = map[string][]string{"listPets": {"$.id kind integer"}}
+	if _, err := Run(context.Background(), srv.URL, &s, opts); err == nil {
+		t.Errorf("expected an error for an invalid assertion")
+	}
+	opts.Assertions = map[string][]string{"deletePet": {"$.id type integer"}}
+	if _, err := Run(context.Background(), srv.URL, &s, opts); err == nil {
+		t.Errorf("expected an error for assertions of an unknown operation")
+	}
+}
@@ -0,0 +1,94 @@
+package contract
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/ericchiang/swaggopher/fixture"
+	"github.com/ericchiang/swaggopher/internal/logutil"
+)
+
+// fixture sends the requests of an operation's fixture, returning the values of
+// its parameters by key.
+func (r *runner) fixture(ctx context.Context, op Operation, fx fixture.Fixture) (map[string]string, error) {
+	responses := make(map[string]*fixture.Response)
+	for i, step := range fx.Create {
+		method, path, stepOp, ok := fixture.Find(r.doc, step.Operation)
+		if !ok {
+			return nil, fmt.Errorf("create %d: no such operation %q", i, step.Operation)
+		}
+		target := Operation{Method: method, Path: path, Operation: stepOp}
+		item := r.doc.Paths[path]
+		target.item = &item
+
+		values, err := r.fixtureValues(target, step.Values, responses)
+		if err != nil {
+			return nil, fmt.Errorf("create %d (%s): %v", i, target, err)
+		}
+		resp, err := r.send(ctx, target, testCase{name: "fixture", values: values, body: step.Body})
+		if err != nil {
+			return nil, fmt.Errorf("create %d (%s): %v", i, target, err)
+		}
+		if resp.Status < 200 || resp.Status > 299 {
+			return nil, fmt.Errorf("create %d (%s): unexpected status %d", i, target, resp.Status)
+		}
+		responses[step.Name(r.doc)] = resp
+	}
+	return r.fixtureValues(op, fx.Values, responses)
+}
+
+// fixtureValues resolves the values of a fixture, keyed by the parameters they
+// set.
+func (r *runner) fixtureValues(op Operation, values map[string]string, responses map[string]*fixture.Response) (map[string]string, error) {
+	resolved := make(map[string]string)
+	for name, v := range values {
+		found := false
+		for _, p := range r.parameters(op) {
+			if !fixture.Matches(name, p) {
+				continue
+			}
+			s, err := fixture.Value(v, responses)
+			if err != nil {
+				return nil, fmt.Errorf("value %s: %v", name, err)
+			}
+			resolved[key(p)] = s
+			found = true
+			break
+		}
+		if !found {
+			return nil, fmt.Errorf("value %s: %s has no such parameter", name, op)
+		}
+	}
+	return resolved, nil
+}
+
+// send makes a request, returning the response with its body decoded if it's
+// JSON.
+func (r *runner) send(ctx context.Context, op Operation, tc testCase) (*fixture.Response, error) {
+	req, err := r.request(ctx, op, tc)
+	if err != nil {
+		return nil, err
+	}
+	if r.opts.Auth != nil {
+		if err := r.opts.Auth(req); err != nil {
+			return nil, fmt.Errorf("auth: %v", err)
+		}
+	}
+	logutil.Printf(r.opts.Logger, "contract: %s %s (%s)", req.Method, req.URL, tc.name)
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	out := &fixture.Response{Status: resp.StatusCode, Header: resp.Header}
+	if json.Unmarshal(data, &out.Body) != nil {
+		out.Body = nil
+	}
+	return out, nil
+}
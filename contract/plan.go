@@ -0,0 +1,196 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// DependsOnExtension is the vendor extension of an operation listing the
+// operationIds of operations which must be tested before it.
+const DependsOnExtension = "x-depends-on"
+
+// Dependency records that an operation must be tested after another.
+type Dependency struct {
+	Operation Operation
+	Requires  Operation
+	// Reason describes why the dependency exists.
+	Reason string
+}
+
+func (d Dependency) String() string {
+	return fmt.Sprintf("%s requires %s (%s)", d.Operation, d.Requires, d.Reason)
+}
+
+// loginSegments are the last path segments of operations which are assumed to
+// return credentials, if they're exempt from the document's security.
+var loginSegments = []string{"auth", "login", "oauth", "session", "sessions", "signin", "token", "tokens"}
+
+// Dependencies returns the dependencies between the operations of a document,
+// ordered by operation then requirement. They're declared by the
+// "x-depends-on" extension, or inferred:
+//
+//   - an operation with path parameters requires the POST operation of each
+//     collection its path is within, such as "POST /pets" for "/pets/{petId}",
+//     so the resources it refers to are created first
+//   - a DELETE operation requires the other operations of its path, so a
+//     resource isn't removed while it's still needed
+//   - an operation which needs credentials requires login operations: those
+//     exempt from security with a last path segment such as "login" or "token"
+//
+// An error is returned if a declared dependency doesn't exist.
+func Dependencies(s *spec.Swagger) ([]Dependency, error) {
+	ops := operations(s)
+	byID := make(map[string]Operation)
+	byRoute := make(map[string]Operation)
+	var logins []Operation
+	for _, op := range ops {
+		if op.OperationId != "" {
+			byID[op.OperationId] = op
+		}
+		byRoute[op.Method+" "+op.Path] = op
+		if isLogin(s, op) {
+			logins = append(logins, op)
+		}
+	}
+
+	var deps []Dependency
+	seen := make(map[string]bool)
+	add := func(op, req Operation, reason string) {
+		k := op.String() + "\x00" + req.String()
+		if op.String() == req.String() || seen[k] {
+			return
+		}
+		seen[k] = true
+		deps = append(deps, Dependency{Operation: op, Requires: req, Reason: reason})
+	}
+	for _, op := range ops {
+		declared, err := dependsOn(op)
+		if err != nil {
+			return nil, fmt.Errorf("contract: %s: %v", op, err)
+		}
+		for _, id := range declared {
+			req, ok := byID[id]
+			if !ok {
+				return nil, fmt.Errorf("contract: %s: %s: no operation has operationId %q", op, DependsOnExtension, id)
+			}
+			add(op, req, "declared by "+DependsOnExtension)
+		}
+
+		segments := strings.Split(op.Path, "/")
+		for i, seg := range segments {
+			if !strings.Contains(seg, "{") {
+				continue
+			}
+			collection := strings.Join(segments[:i], "/")
+			if create, ok := byRoute["post "+collection]; ok {
+				add(op, create, "creates the resources of "+collection)
+			}
+		}
+
+		if op.Method == "delete" {
+			for _, other := range ops {
+				if other.Path == op.Path && other.Method != "delete" {
+					add(op, other, "deletes the resource")
+				}
+			}
+		}
+
+		if requiresAuth(s, op) {
+			for _, login := range logins {
+				add(op, login, "provides credentials")
+			}
+		}
+	}
+	return deps, nil
+}
+
+// Plan returns the order operations are tested in: their order by path and
+// method, except that every operation comes after those it depends on. An
+// error is returned if the dependencies can't be satisfied, such as when they
+// form a cycle.
+func Plan(s *spec.Swagger) ([]Operation, error) {
+	deps, err := Dependencies(s)
+	if err != nil {
+		return nil, err
+	}
+	ops := operations(s)
+	index := make(map[string]int)
+	for i, op := range ops {
+		index[op.String()] = i
+	}
+	requires := make([][]int, len(ops))
+	for _, d := range deps {
+		i := index[d.Operation.String()]
+		requires[i] = append(requires[i], index[d.Requires.String()])
+	}
+
+	// Repeatedly take the first operation whose requirements have all been
+	// taken, which keeps the original order where dependencies allow.
+	done := make([]bool, len(ops))
+	var plan []Operation
+	for len(plan) < len(ops) {
+		next := -1
+		for i := range ops {
+			if done[i] {
+				continue
+			}
+			ready := true
+			for _, r := range requires[i] {
+				if !done[r] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			var cycle []string
+			for i, op := range ops {
+				if !done[i] {
+					cycle = append(cycle, op.String())
+				}
+			}
+			return nil, fmt.Errorf("contract: dependencies of %s form a cycle", strings.Join(cycle, ", "))
+		}
+		done[next] = true
+		plan = append(plan, ops[next])
+	}
+	return plan, nil
+}
+
+func dependsOn(op Operation) ([]string, error) {
+	ext, ok := op.Extensions[DependsOnExtension]
+	if !ok {
+		return nil, nil
+	}
+	data, err := json.Marshal(ext)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	if err := json.Unmarshal(data, &ids); err != nil {
+		return nil, fmt.Errorf("%s: expected a list of operationIds", DependsOnExtension)
+	}
+	return ids, nil
+}
+
+// requiresAuth reports if an operation has security requirements, either its
+// own or the document's.
+func requiresAuth(s *spec.Swagger, op Operation) bool {
+	return len(s.EffectiveSecurity(op.Operation)) > 0
+}
+
+// isLogin reports if an operation is assumed to return credentials.
+func isLogin(s *spec.Swagger, op Operation) bool {
+	if requiresAuth(s, op) {
+		return false
+	}
+	segments := strings.Split(strings.TrimSuffix(op.Path, "/"), "/")
+	return contains(loginSegments, strings.ToLower(segments[len(segments)-1]))
+}
@@ -0,0 +1,311 @@
+package convert
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/asyncapi"
+	"github.com/ericchiang/swaggopher/internal/jsonpointer"
+	"github.com/ericchiang/swaggopher/internal/mapkeys"
+	"github.com/ericchiang/swaggopher/spec"
+	"github.com/ericchiang/swaggopher/spec3"
+	"github.com/ericchiang/swaggopher/subset"
+)
+
+// AsyncAPIVersion is the AsyncAPI version of converted documents.
+const AsyncAPIVersion = "2.6.0"
+
+// ConvertToAsyncAPI converts the selected operations of a Swagger 2.0 document,
+// such as the webhooks or callbacks an API delivers, to the channels of an
+// AsyncAPI 2 document, so event-driven documentation can share its source with
+// an HTTP API's. If the selection is empty, every operation is converted.
+//
+// Each path becomes a channel whose subscribe operation, describing the
+// messages the application sends, is converted from the path's operation. The
+// message's payload is the operation's body or form parameters, its headers the
+// header parameters and its name the operation's ID. Path parameters become
+// channel parameters, and definitions become component schemas.
+//
+// Query parameters, and any operation beyond the first of a path, have no
+// equivalent and are reported as losses. Responses, which only acknowledge a
+// delivery, are dropped.
+func ConvertToAsyncAPI(s *spec.Swagger, sel subset.Selection) (*asyncapi.AsyncAPI, []Loss, error) {
+	if len(sel.OperationIDs) > 0 || len(sel.Tags) > 0 || len(sel.Paths) > 0 || sel.Contract != nil {
+		selected, err := subset.Export(s, sel)
+		if err != nil {
+			return nil, nil, err
+		}
+		s = selected
+	}
+	c := &converterToAsyncAPI{doc: s, schemas: &converter2To3{doc: s}}
+	a, err := c.convert()
+	if err != nil {
+		return nil, nil, err
+	}
+	return a, c.losses, nil
+}
+
+type converterToAsyncAPI struct {
+	doc *spec.Swagger
+	// schemas converts schemas, which AsyncAPI shares with OpenAPI 3.0.
+	schemas *converter2To3
+	losses  []Loss
+}
+
+func (c *converterToAsyncAPI) lose(path, format string, v ...interface{}) {
+	c.losses = append(c.losses, Loss{Path: path, Message: fmt.Sprintf(format, v...)})
+}
+
+func (c *converterToAsyncAPI) convert() (*asyncapi.AsyncAPI, error) {
+	s := c.doc
+	out := &asyncapi.AsyncAPI{
+		AsyncAPI:     AsyncAPIVersion,
+		Servers:      c.servers(),
+		Channels:     asyncapi.Channels{},
+		Tags:         tags(s.Tags),
+		ExternalDocs: externalDocs(s.ExternalDocs),
+	}
+	if len(s.Consumes) > 0 {
+		out.DefaultContentType = s.Consumes[0]
+	}
+	if s.Info != nil {
+		out.Info = &spec3.Info{
+			Title:          s.Info.Title,
+			Description:    s.Info.Description,
+			TermsOfService: s.Info.TermsOfService,
+			Version:        s.Info.Version,
+		}
+		if ct := s.Info.Contact; ct != nil {
+			out.Info.Contact = &spec3.Contact{Name: ct.Name, Url: ct.Url, Email: ct.Email}
+		}
+		if l := s.Info.License; l != nil {
+			out.Info.License = &spec3.License{Name: l.Name, Url: l.Url}
+		}
+	}
+
+	for _, path := range mapkeys.Sorted(s.Paths) {
+		channel, ok, err := c.channel(path, s.Paths[path])
+		if err != nil {
+			return nil, fmt.Errorf("convert: paths %s: %v", path, err)
+		}
+		if ok {
+			out.Channels[path] = channel
+		}
+	}
+
+	components := &asyncapi.Components{}
+	for name, schema := range s.Definitions {
+		if components.Schemas == nil {
+			components.Schemas = make(map[string]spec3.Schema)
+		}
+		components.Schemas[name] = *c.schemas.schema(&schema)
+	}
+	for name, scheme := range s.SecurityDefinitions {
+		converted, err := asyncAPISecurityScheme(&scheme)
+		if err != nil {
+			return nil, fmt.Errorf("convert: securityDefinitions %s: %v", name, err)
+		}
+		if components.SecuritySchemes == nil {
+			components.SecuritySchemes = make(map[string]asyncapi.SecurityScheme)
+		}
+		components.SecuritySchemes[name] = converted
+	}
+	if components.Schemas != nil || components.SecuritySchemes != nil {
+		out.Components = components
+	}
+	return out, nil
+}
+
+// servers derives a server for each scheme from host and basePath, named after
+// the scheme. Without a host there's nothing to connect to, so there are no
+// servers.
+func (c *converterToAsyncAPI) servers() map[string]asyncapi.Server {
+	s := c.doc
+	if s.Host == "" {
+		return nil
+	}
+	schemes := s.Schemes
+	if len(schemes) == 0 {
+		schemes = []string{"https"}
+	}
+	var security []spec3.SecurityRequirement
+	for _, req := range s.Security {
+		security = append(security, spec3.SecurityRequirement(req))
+	}
+	servers := make(map[string]asyncapi.Server, len(schemes))
+	for _, scheme := range schemes {
+		servers[scheme] = asyncapi.Server{Url: s.Host + s.BasePath, Protocol: scheme, Security: security}
+	}
+	return servers
+}
+
+// channel converts the first operation of a path to a channel, preferring those
+// with bodies since webhooks are usually delivered with a POST. It returns
+// false if the path has no operations.
+func (c *converterToAsyncAPI) channel(path string, item spec.PathItem) (asyncapi.ChannelItem, bool, error) {
+	var out asyncapi.ChannelItem
+	ops := []struct {
+		method string
+		op     *spec.Operation
+	}{
+		{"post", item.Post}, {"put", item.Put}, {"patch", item.Patch}, {"get", item.Get},
+		{"delete", item.Delete}, {"options", item.Options}, {"head", item.Head},
+	}
+	for _, o := range ops {
+		if o.op == nil {
+			continue
+		}
+		opPath := jsonpointer.Join("/paths", path, o.method)
+		if out.Subscribe != nil {
+			c.lose(opPath, "a channel has one subscribe operation, so only the path's first operation is converted")
+			continue
+		}
+		op, params, err := c.operation(jsonpointer.Join("/paths", path), o.method, o.op, item.Parameters)
+		if err != nil {
+			return out, false, fmt.Errorf("%s: %v", o.method, err)
+		}
+		out.Subscribe, out.Parameters = op, params
+	}
+	return out, out.Subscribe != nil, nil
+}
+
+func (c *converterToAsyncAPI) operation(itemPath, method string, op *spec.Operation, pathParams []spec.Parameter) (*asyncapi.Operation, map[string]asyncapi.Parameter, error) {
+	path := jsonpointer.Join(itemPath, method)
+	out := &asyncapi.Operation{
+		OperationId:  op.OperationId,
+		Summary:      op.Summary,
+		Description:  op.Description,
+		ExternalDocs: externalDocs(op.ExternalDocs),
+	}
+	for _, name := range op.Tags {
+		out.Tags = append(out.Tags, spec3.Tag{Name: name})
+	}
+	for _, req := range op.Security {
+		out.Security = append(out.Security, spec3.SecurityRequirement(req))
+	}
+	msg := &asyncapi.Message{Name: op.OperationId, Summary: op.Summary, Description: op.Description}
+	out.Message = msg
+
+	// Operation parameters override path parameters with the same name and
+	// location.
+	type param struct {
+		*spec.Parameter
+		path string
+	}
+	var params []param
+	overridden := make(map[string]bool)
+	for i := range op.Parameters {
+		p, err := c.parameter(&op.Parameters[i])
+		if err != nil {
+			return nil, nil, err
+		}
+		overridden[p.In+"/"+p.Name] = true
+		params = append(params, param{p, jsonpointer.Join(path, "parameters", strconv.Itoa(i))})
+	}
+	for i := range pathParams {
+		p, err := c.parameter(&pathParams[i])
+		if err != nil {
+			return nil, nil, err
+		}
+		if !overridden[p.In+"/"+p.Name] {
+			params = append(params, param{p, jsonpointer.Join(itemPath, "parameters", strconv.Itoa(i))})
+		}
+	}
+
+	var channelParams map[string]asyncapi.Parameter
+	var bodyParams []*spec.Parameter
+	headers := &spec3.Schema{Type: "object", Properties: make(map[string]spec3.Schema)}
+	for _, p := range params {
+		switch p.In {
+		case "path":
+			if channelParams == nil {
+				channelParams = make(map[string]asyncapi.Parameter)
+			}
+			channelParams[p.Name] = asyncapi.Parameter{Description: p.Description, Schema: simpleSchemaOf(p.Parameter)}
+		case "header":
+			schema := simpleSchemaOf(p.Parameter)
+			schema.Description = p.Description
+			headers.Properties[p.Name] = *schema
+			if p.Required {
+				headers.Required = append(headers.Required, p.Name)
+			}
+		case "body", "formData":
+			bodyParams = append(bodyParams, p.Parameter)
+		default:
+			c.lose(p.path, "%s parameter %s has no AsyncAPI equivalent", p.In, p.Name)
+		}
+	}
+	if len(headers.Properties) > 0 {
+		msg.Headers = headers
+	}
+	if len(bodyParams) > 0 {
+		consumes := op.Consumes
+		if len(consumes) == 0 {
+			consumes = c.doc.Consumes
+		}
+		body := c.schemas.requestBody(bodyParams, consumes)
+		contentType := ""
+		for _, mediaType := range consumes {
+			if _, ok := body.Content[mediaType]; ok {
+				contentType = mediaType
+				break
+			}
+		}
+		if contentType == "" {
+			contentType = mapkeys.Sorted(body.Content)[0]
+		}
+		if len(body.Content) > 1 {
+			c.lose(path, "a message has one content type, so only %s is converted", contentType)
+		}
+		msg.Payload = body.Content[contentType].Schema
+		if len(c.doc.Consumes) == 0 || contentType != c.doc.Consumes[0] {
+			msg.ContentType = contentType
+		}
+		if msg.Description == "" {
+			msg.Description = body.Description
+		}
+		if msg.Payload != nil && msg.Payload.Example != nil {
+			msg.Examples = []asyncapi.MessageExample{{Payload: msg.Payload.Example}}
+		}
+	}
+	return out, channelParams, nil
+}
+
+// parameter returns the parameter a reference refers to, since AsyncAPI
+// parameters can't be shared with headers or payloads.
+func (c *converterToAsyncAPI) parameter(p *spec.Parameter) (*spec.Parameter, error) {
+	if p.Ref == "" {
+		return p, nil
+	}
+	target, ok := c.doc.Parameters[strings.TrimPrefix(p.Ref, "#/parameters/")]
+	if !strings.HasPrefix(p.Ref, "#/parameters/") || !ok {
+		return nil, fmt.Errorf("unresolved parameter reference %q", p.Ref)
+	}
+	return &target, nil
+}
+
+func simpleSchemaOf(p *spec.Parameter) *spec3.Schema {
+	return simpleSchema(p.Type, p.Format, p.Items, p.Default, p.Maximum, p.ExclusiveMaximum, p.Minimum, p.ExclusiveMinimum, p.MaxLength, p.MinLength, p.Pattern, p.MaxItems, p.MinItems, p.UniqueItems, p.Enum, p.MultipleOf)
+}
+
+// asyncAPISecurityScheme converts a security scheme. AsyncAPI names HTTP API
+// keys "httpApiKey", since its "apiKey" is a key sent as a user or password.
+func asyncAPISecurityScheme(s *spec.SecurityScheme) (asyncapi.SecurityScheme, error) {
+	converted, err := securityScheme(s)
+	if err != nil {
+		return asyncapi.SecurityScheme{}, err
+	}
+	out := asyncapi.SecurityScheme{
+		Type:        converted.Type,
+		Description: converted.Description,
+		Name:        converted.Name,
+		In:          converted.In,
+		Scheme:      converted.Scheme,
+		Flows:       converted.Flows,
+	}
+	if out.Type == "apiKey" {
+		out.Type = "httpApiKey"
+	}
+	return out, nil
+}
@@ -0,0 +1,548 @@
+/*
+Package convert translates documents between versions of the specification.
+*/
+package convert
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/spec"
+	"github.com/ericchiang/swaggopher/spec3"
+)
+
+// Version is the OpenAPI version of converted documents.
+const Version = "3.0.3"
+
+// defaultMediaType is used for bodies when neither the operation nor the
+// document declares what it consumes or produces.
+const defaultMediaType = "application/json"
+
+// Convert2To3 converts a Swagger 2.0 document to OpenAPI 3.0.
+//
+// host, basePath and schemes become servers; body and formData parameters
+// become request bodies; consumes and produces become the content maps of
+// request bodies and responses; and definitions, parameters, responses and
+// securityDefinitions move to components. References are rewritten to point at
+// their new locations.
+func Convert2To3(s *spec.Swagger) (*spec3.OpenAPI, error) {
+	c := &converter2To3{doc: s}
+	return c.convert()
+}
+
+type converter2To3 struct {
+	doc *spec.Swagger
+}
+
+func (c *converter2To3) convert() (*spec3.OpenAPI, error) {
+	s := c.doc
+	out := &spec3.OpenAPI{
+		OpenAPI:      Version,
+		Servers:      servers(s),
+		Paths:        spec3.Paths{},
+		Tags:         tags(s.Tags),
+		ExternalDocs: externalDocs(s.ExternalDocs),
+	}
+	if s.Info != nil {
+		out.Info = &spec3.Info{
+			Title:          s.Info.Title,
+			Description:    s.Info.Description,
+			TermsOfService: s.Info.TermsOfService,
+			Version:        s.Info.Version,
+		}
+		if ct := s.Info.Contact; ct != nil {
+			out.Info.Contact = &spec3.Contact{Name: ct.Name, Url: ct.Url, Email: ct.Email}
+		}
+		if l := s.Info.License; l != nil {
+			out.Info.License = &spec3.License{Name: l.Name, Url: l.Url}
+		}
+	}
+	for _, req := range s.Security {
+		out.Security = append(out.Security, spec3.SecurityRequirement(req))
+	}
+
+	for path, item := range s.Paths {
+		converted, err := c.pathItem(item)
+		if err != nil {
+			return nil, fmt.Errorf("convert: paths %s: %v", path, err)
+		}
+		out.Paths[path] = converted
+	}
+
+	components := &spec3.Components{}
+	for name, schema := range s.Definitions {
+		if components.Schemas == nil {
+			components.Schemas = make(map[string]spec3.Schema)
+		}
+		components.Schemas[name] = *c.schema(&schema)
+	}
+	for name, p := range s.Parameters {
+		p := p
+		if p.In == "body" || p.In == "formData" {
+			if components.RequestBodies == nil {
+				components.RequestBodies = make(map[string]spec3.RequestBody)
+			}
+			components.RequestBodies[name] = *c.requestBody([]*spec.Parameter{&p}, s.Consumes)
+			continue
+		}
+		converted, err := c.parameter(&p)
+		if err != nil {
+			return nil, fmt.Errorf("convert: parameters %s: %v", name, err)
+		}
+		if components.Parameters == nil {
+			components.Parameters = make(map[string]spec3.Parameter)
+		}
+		components.Parameters[name] = converted
+	}
+	for name, resp := range s.Responses {
+		if components.Responses == nil {
+			components.Responses = make(map[string]spec3.Response)
+		}
+		components.Responses[name] = c.response(&resp, s.Produces)
+	}
+	for name, scheme := range s.SecurityDefinitions {
+		converted, err := securityScheme(&scheme)
+		if err != nil {
+			return nil, fmt.Errorf("convert: securityDefinitions %s: %v", name, err)
+		}
+		if components.SecuritySchemes == nil {
+			components.SecuritySchemes = make(map[string]spec3.SecurityScheme)
+		}
+		components.SecuritySchemes[name] = converted
+	}
+	if components.Schemas != nil || components.Parameters != nil || components.RequestBodies != nil ||
+		components.Responses != nil || components.SecuritySchemes != nil {
+		out.Components = components
+	}
+	return out, nil
+}
+
+// servers derives the server list from host, basePath and schemes. Without a
+// host the server URL is relative to wherever the document is served from.
+func servers(s *spec.Swagger) []spec3.Server {
+	basePath := s.BasePath
+	if s.Host == "" {
+		if basePath == "" || basePath == "/" {
+			return nil
+		}
+		return []spec3.Server{{Url: basePath}}
+	}
+	if len(s.Schemes) == 0 {
+		// A scheme-relative URL uses the scheme the document was fetched with,
+		// which matches the 2.0 default.
+		return []spec3.Server{{Url: "//" + s.Host + basePath}}
+	}
+	servers := make([]spec3.Server, len(s.Schemes))
+	for i, scheme := range s.Schemes {
+		servers[i] = spec3.Server{Url: scheme + "://" + s.Host + basePath}
+	}
+	return servers
+}
+
+func (c *converter2To3) pathItem(item spec.PathItem) (spec3.PathItem, error) {
+	out := spec3.PathItem{Ref: item.Ref}
+	for i := range item.Parameters {
+		p := c.bodyRef(&item.Parameters[i])
+		if p.In == "body" || p.In == "formData" {
+			// Request bodies are per operation in 3.0, so these are merged into
+			// each operation below.
+			continue
+		}
+		converted, err := c.parameter(p)
+		if err != nil {
+			return out, err
+		}
+		out.Parameters = append(out.Parameters, converted)
+	}
+
+	ops := []struct {
+		method string
+		in     *spec.Operation
+		out    **spec3.Operation
+	}{
+		{"get", item.Get, &out.Get},
+		{"put", item.Put, &out.Put},
+		{"post", item.Post, &out.Post},
+		{"delete", item.Delete, &out.Delete},
+		{"options", item.Options, &out.Options},
+		{"head", item.Head, &out.Head},
+		{"patch", item.Patch, &out.Patch},
+	}
+	for _, op := range ops {
+		if op.in == nil {
+			continue
+		}
+		converted, err := c.operation(op.in, item.Parameters)
+		if err != nil {
+			return out, fmt.Errorf("%s: %v", op.method, err)
+		}
+		*op.out = converted
+	}
+	return out, nil
+}
+
+func (c *converter2To3) operation(op *spec.Operation, pathParams []spec.Parameter) (*spec3.Operation, error) {
+	out := &spec3.Operation{
+		Tags:         op.Tags,
+		Summary:      op.Summary,
+		Description:  op.Description,
+		ExternalDocs: externalDocs(op.ExternalDocs),
+		OperationId:  op.OperationId,
+		Deprecated:   op.Deprecated,
+		Responses:    spec3.Responses{},
+	}
+	for _, req := range op.Security {
+		out.Security = append(out.Security, spec3.SecurityRequirement(req))
+	}
+	if len(op.Schemes) > 0 {
+		doc := *c.doc
+		doc.Schemes = op.Schemes
+		out.Servers = servers(&doc)
+	}
+
+	// Operation parameters override path parameters with the same name and
+	// location.
+	var bodyParams []*spec.Parameter
+	overridden := make(map[string]bool)
+	for i := range op.Parameters {
+		p := c.bodyRef(&op.Parameters[i])
+		overridden[p.In+"/"+p.Name] = true
+		if p.In == "body" || p.In == "formData" {
+			bodyParams = append(bodyParams, p)
+			continue
+		}
+		converted, err := c.parameter(p)
+		if err != nil {
+			return nil, err
+		}
+		out.Parameters = append(out.Parameters, converted)
+	}
+	for i := range pathParams {
+		p := c.bodyRef(&pathParams[i])
+		if (p.In == "body" || p.In == "formData") && !overridden[p.In+"/"+p.Name] {
+			bodyParams = append(bodyParams, p)
+		}
+	}
+	if len(bodyParams) > 0 {
+		consumes := op.Consumes
+		if len(consumes) == 0 {
+			consumes = c.doc.Consumes
+		}
+		out.RequestBody = c.requestBody(bodyParams, consumes)
+	}
+
+	produces := op.Produces
+	if len(produces) == 0 {
+		produces = c.doc.Produces
+	}
+	for code, resp := range op.Responses {
+		out.Responses[code] = c.response(&resp, produces)
+	}
+	return out, nil
+}
+
+// bodyRef returns the parameter a reference refers to if it's in the body or
+// form. These can't remain references in 3.0 since they're merged into request
+// bodies. Other parameters are returned unchanged.
+func (c *converter2To3) bodyRef(p *spec.Parameter) *spec.Parameter {
+	if !strings.HasPrefix(p.Ref, "#/parameters/") {
+		return p
+	}
+	target, ok := c.doc.Parameters[strings.TrimPrefix(p.Ref, "#/parameters/")]
+	if !ok || (target.In != "body" && target.In != "formData") {
+		return p
+	}
+	return &target
+}
+
+// parameter converts a parameter that isn't in the body or form.
+func (c *converter2To3) parameter(p *spec.Parameter) (spec3.Parameter, error) {
+	if p.Ref != "" {
+		return spec3.Parameter{Ref: ref2To3(p.Ref)}, nil
+	}
+	out := spec3.Parameter{
+		Name:            p.Name,
+		In:              p.In,
+		Description:     p.Description,
+		Required:        p.Required,
+		AllowEmptyValue: p.AllowEmptyValue,
+		Schema:          simpleSchema(p.Type, p.Format, p.Items, p.Default, p.Maximum, p.ExclusiveMaximum, p.Minimum, p.ExclusiveMinimum, p.MaxLength, p.MinLength, p.Pattern, p.MaxItems, p.MinItems, p.UniqueItems, p.Enum, p.MultipleOf),
+	}
+	switch p.In {
+	case "query", "header", "path":
+	default:
+		return out, fmt.Errorf("parameter %s: unsupported location %q", p.Name, p.In)
+	}
+	if p.Type == "array" {
+		style, explode, err := collectionStyle(p.In, p.CollectionFormat)
+		if err != nil {
+			return out, fmt.Errorf("parameter %s: %v", p.Name, err)
+		}
+		out.Style = style
+		out.Explode = &explode
+	}
+	return out, nil
+}
+
+// collectionStyle maps a 2.0 collectionFormat to a 3.0 style and explode.
+func collectionStyle(in, collectionFormat string) (style string, explode bool, err error) {
+	simple := "form"
+	if in == "path" || in == "header" {
+		simple = "simple"
+	}
+	switch collectionFormat {
+	case "", "csv":
+		return simple, false, nil
+	case "multi":
+		return "form", true, nil
+	case "ssv":
+		return "spaceDelimited", false, nil
+	case "pipes":
+		return "pipeDelimited", false, nil
+	}
+	return "", false, fmt.Errorf("collectionFormat %q has no OpenAPI 3.0 equivalent", collectionFormat)
+}
+
+// requestBody merges body and formData parameters into a single request body.
+func (c *converter2To3) requestBody(params []*spec.Parameter, consumes []string) *spec3.RequestBody {
+	out := &spec3.RequestBody{Content: make(map[string]spec3.MediaType)}
+
+	for _, p := range params {
+		if p.In == "body" {
+			if len(consumes) == 0 {
+				consumes = []string{defaultMediaType}
+			}
+			out.Description = p.Description
+			out.Required = p.Required
+			for _, mediaType := range consumes {
+				out.Content[mediaType] = spec3.MediaType{Schema: c.schema(p.Schema)}
+			}
+			return out
+		}
+	}
+
+	// Form parameters become the properties of an object schema.
+	form := &spec3.Schema{Type: "object", Properties: make(map[string]spec3.Schema)}
+	hasFile := false
+	for _, p := range params {
+		if p.Type == "file" {
+			hasFile = true
+		}
+		prop := simpleSchema(p.Type, p.Format, p.Items, p.Default, p.Maximum, p.ExclusiveMaximum, p.Minimum, p.ExclusiveMinimum, p.MaxLength, p.MinLength, p.Pattern, p.MaxItems, p.MinItems, p.UniqueItems, p.Enum, p.MultipleOf)
+		prop.Description = p.Description
+		form.Properties[p.Name] = *prop
+		if p.Required {
+			form.Required = append(form.Required, p.Name)
+			out.Required = true
+		}
+	}
+
+	var mediaTypes []string
+	for _, mediaType := range consumes {
+		if mediaType == "application/x-www-form-urlencoded" || mediaType == "multipart/form-data" {
+			mediaTypes = append(mediaTypes, mediaType)
+		}
+	}
+	if len(mediaTypes) == 0 {
+		mediaTypes = []string{"application/x-www-form-urlencoded"}
+		if hasFile {
+			mediaTypes = []string{"multipart/form-data"}
+		}
+	}
+	for _, mediaType := range mediaTypes {
+		out.Content[mediaType] = spec3.MediaType{Schema: form}
+	}
+	return out
+}
+
+func (c *converter2To3) response(r *spec.Response, produces []string) spec3.Response {
+	if r.Ref != "" {
+		return spec3.Response{Ref: ref2To3(r.Ref)}
+	}
+	out := spec3.Response{Description: r.Description}
+	for name, h := range r.Headers {
+		if out.Headers == nil {
+			out.Headers = make(map[string]spec3.Header)
+		}
+		out.Headers[name] = spec3.Header{
+			Description: h.Description,
+			Schema:      simpleSchema(h.Type, h.Format, h.Items, h.Default, h.Maximum, h.ExclusiveMaximum, h.Minimum, h.ExclusiveMinimum, h.MaxLength, h.MinLength, h.Pattern, h.MaxItems, h.MinItems, h.UniqueItems, h.Enum, h.MultipleOf),
+		}
+	}
+	if r.Schema == nil && len(r.Examples) == 0 {
+		return out
+	}
+	if len(produces) == 0 {
+		produces = []string{defaultMediaType}
+	}
+	out.Content = make(map[string]spec3.MediaType)
+	for _, mediaType := range produces {
+		mt := spec3.MediaType{}
+		if r.Schema != nil {
+			mt.Schema = c.schema(r.Schema)
+		}
+		if example, ok := r.Examples[mediaType]; ok {
+			mt.Example = example
+		}
+		out.Content[mediaType] = mt
+	}
+	// Examples for media types that aren't produced still get an entry.
+	for mediaType, example := range r.Examples {
+		if _, ok := out.Content[mediaType]; !ok {
+			out.Content[mediaType] = spec3.MediaType{Schema: c.schema(r.Schema), Example: example}
+		}
+	}
+	return out
+}
+
+func (c *converter2To3) schema(s *spec.Schema) *spec3.Schema {
+	if s == nil {
+		return nil
+	}
+	out := &spec3.Schema{
+		Ref:              ref2To3(s.Ref),
+		Title:            s.Title,
+		MultipleOf:       s.MultipleOf,
+		Maximum:          s.Maximum,
+		ExclusiveMaximum: s.ExclusiveMaximum,
+		Minimum:          s.Minimum,
+		ExclusiveMinimum: s.ExclusiveMinimum,
+		MaxLength:        s.MaxLength,
+		MinLength:        s.MinLength,
+		Pattern:          s.Pattern,
+		MaxItems:         s.MaxItems,
+		MinItems:         s.MinItems,
+		UniqueItems:      s.UniqueItems,
+		MaxProperties:    s.MaxProperties,
+		MinProperties:    s.MinProperties,
+		Required:         s.Required,
+		Enum:             s.Enum,
+		Type:             s.Type,
+		Items:            c.schema(s.Items),
+		Description:      s.Description,
+		Format:           s.Format,
+		Default:          s.Default,
+		ReadOnly:         s.ReadOnly,
+		ExternalDocs:     externalDocs(s.ExternalDocs),
+		Example:          s.Example,
+	}
+	if s.Type == "file" {
+		out.Type, out.Format = "string", "binary"
+	}
+	for i := range s.AllOf {
+		out.AllOf = append(out.AllOf, *c.schema(&s.AllOf[i]))
+	}
+	for name, prop := range s.Properties {
+		if out.Properties == nil {
+			out.Properties = make(map[string]spec3.Schema)
+		}
+		out.Properties[name] = *c.schema(&prop)
+	}
+	if ap := s.AdditionalProperties; ap != nil {
+		out.AdditionalProperties = &spec3.AdditionalProperties{Allowed: ap.Allowed, Schema: c.schema(ap.Schema)}
+	}
+	if s.Discriminator != "" {
+		out.Discriminator = &spec3.Discriminator{PropertyName: s.Discriminator}
+	}
+	if x := s.Xml; x != nil {
+		out.Xml = &spec3.XML{Name: x.Name, Namespace: x.Namespace, Prefix: x.Prefix, Attribute: x.Attribute, Wrapped: x.Wrapped}
+	}
+	return out
+}
+
+// simpleSchema builds a schema from the fields shared by non-body parameters,
+// headers and items.
+func simpleSchema(typ, format string, items *spec.Items, def interface{}, max *float64, exclusiveMax bool, min *float64, exclusiveMin bool, maxLength *int, minLength int, pattern string, maxItems *int, minItems int, uniqueItems bool, enum []interface{}, multipleOf float64) *spec3.Schema {
+	s := &spec3.Schema{
+		Type:             typ,
+		Format:           format,
+		Default:          def,
+		Maximum:          max,
+		ExclusiveMaximum: exclusiveMax,
+		Minimum:          min,
+		ExclusiveMinimum: exclusiveMin,
+		MaxLength:        maxLength,
+		MinLength:        minLength,
+		Pattern:          pattern,
+		MaxItems:         maxItems,
+		MinItems:         minItems,
+		UniqueItems:      uniqueItems,
+		Enum:             enum,
+		MultipleOf:       multipleOf,
+	}
+	if typ == "file" {
+		s.Type, s.Format = "string", "binary"
+	}
+	if i := items; i != nil {
+		s.Items = simpleSchema(i.Type, i.Format, i.Items, i.Default, i.Maximum, i.ExclusiveMaximum, i.Minimum, i.ExclusiveMinimum, i.MaxLength, i.MinLength, i.Pattern, i.MaxItems, i.MinItems, i.UniqueItems, i.Enum, i.MultipleOf)
+	}
+	return s
+}
+
+// refPrefixes2To3 maps the locations of reusable objects in 2.0 to 3.0.
+var refPrefixes2To3 = []struct{ from, to string }{
+	{"#/definitions/", "#/components/schemas/"},
+	{"#/parameters/", "#/components/parameters/"},
+	{"#/responses/", "#/components/responses/"},
+}
+
+func ref2To3(ref string) string {
+	for _, p := range refPrefixes2To3 {
+		if strings.HasPrefix(ref, p.from) {
+			return p.to + strings.TrimPrefix(ref, p.from)
+		}
+	}
+	return ref
+}
+
+func securityScheme(s *spec.SecurityScheme) (spec3.SecurityScheme, error) {
+	out := spec3.SecurityScheme{Description: s.Description}
+	switch s.Type {
+	case "basic":
+		out.Type, out.Scheme = "http", "basic"
+	case "apiKey":
+		out.Type, out.Name, out.In = "apiKey", s.Name, s.In
+	case "oauth2":
+		out.Type = "oauth2"
+		flow := &spec3.OAuthFlow{Scopes: map[string]string(s.Scopes)}
+		if flow.Scopes == nil {
+			flow.Scopes = map[string]string{}
+		}
+		out.Flows = &spec3.OAuthFlows{}
+		switch s.Flow {
+		case "implicit":
+			flow.AuthorizationUrl = s.AuthorizationUrl
+			out.Flows.Implicit = flow
+		case "password":
+			flow.TokenUrl = s.TokenUrl
+			out.Flows.Password = flow
+		case "application":
+			flow.TokenUrl = s.TokenUrl
+			out.Flows.ClientCredentials = flow
+		case "accessCode":
+			flow.AuthorizationUrl = s.AuthorizationUrl
+			flow.TokenUrl = s.TokenUrl
+			out.Flows.AuthorizationCode = flow
+		default:
+			return out, fmt.Errorf("unknown oauth2 flow %q", s.Flow)
+		}
+	default:
+		return out, fmt.Errorf("unknown security scheme type %q", s.Type)
+	}
+	return out, nil
+}
+
+func tags(tags []spec.Tag) []spec3.Tag {
+	var out []spec3.Tag
+	for _, t := range tags {
+		out = append(out, spec3.Tag{Name: t.Name, Description: t.Description, ExternalDocs: externalDocs(t.ExternalDocs)})
+	}
+	return out
+}
+
+func externalDocs(d *spec.ExternalDocumentation) *spec3.ExternalDocumentation {
+	if d == nil {
+		return nil
+	}
+	return &spec3.ExternalDocumentation{Description: d.Description, Url: d.Url}
+}
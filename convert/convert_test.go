@@ -0,0 +1,723 @@
+package convert
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/ericchiang/swaggopher/asyncapi"
+	"github.com/ericchiang/swaggopher/spec"
+	"github.com/ericchiang/swaggopher/spec12"
+	"github.com/ericchiang/swaggopher/spec3"
+	"github.com/ericchiang/swaggopher/subset"
+)
+
+const petstore = `
+swagger: "2.0"
+info:
+  title: Petstore
+  version: 1.0.0
+host: petstore.example.com
+basePath: /v1
+schemes: [http, https]
+consumes: [application/json]
+produces: [application/json]
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      parameters:
+      - name: tags
+        in: query
+        type: array
+        collectionFormat: multi
+        items:
+          type: string
+      responses:
+        200:
+          description: A list of pets.
+          headers:
+            x-next:
+              type: string
+          schema:
+            type: array
+            items:
+              $ref: '#/definitions/Pet'
+    post:
+      operationId: createPet
+      parameters:
+      - name: pet
+        in: body
+        required: true
+        schema:
+          $ref: '#/definitions/Pet'
+      responses:
+        201:
+          description: Created.
+      security:
+      - oauth: [write]
+  /pets/{id}/photo:
+    parameters:
+    - name: id
+      in: path
+      required: true
+      type: integer
+    put:
+      operationId: uploadPhoto
+      consumes: [multipart/form-data]
+      parameters:
+      - name: photo
+        in: formData
+        required: true
+        type: file
+      - name: caption
+        in: formData
+        type: string
+      responses:
+        204:
+          description: Uploaded.
+definitions:
+  Pet:
+    type: object
+    discriminator: kind
+    required: [kind]
+    properties:
+      kind:
+        type: string
+securityDefinitions:
+  basic:
+    type: basic
+  oauth:
+    type: oauth2
+    flow: accessCode
+    authorizationUrl: https://example.com/auth
+    tokenUrl: https://example.com/token
+    scopes:
+      write: Modify pets.
+security:
+- basic: []
+`
+
+func TestConvert2To3(t *testing.T) {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(petstore), &s); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Convert2To3(&s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	explode := true
+	pet := &spec3.Schema{Ref: "#/components/schemas/Pet"}
+	want := &spec3.OpenAPI{
+		OpenAPI: Version,
+		Info:    &spec3.Info{Title: "Petstore", Version: "1.0.0"},
+		Servers: []spec3.Server{
+			{Url: "http://petstore.example.com/v1"},
+			{Url: "https://petstore.example.com/v1"},
+		},
+		Paths: spec3.Paths{
+			"/pets": {
+				Get: &spec3.Operation{
+					OperationId: "listPets",
+					Parameters: []spec3.Parameter{{
+						Name:    "tags",
+						In:      "query",
+						Style:   "form",
+						Explode: &explode,
+						Schema:  &spec3.Schema{Type: "array", Items: &spec3.Schema{Type: "string"}},
+					}},
+					Responses: spec3.Responses{
+						"200": {
+							Description: "A list of pets.",
+							Headers: map[string]spec3.Header{
+								"x-next": {Schema: &spec3.Schema{Type: "string"}},
+							},
+							Content: map[string]spec3.MediaType{
+								"application/json": {Schema: &spec3.Schema{Type: "array", Items: pet}},
+							},
+						},
+					},
+				},
+				Post: &spec3.Operation{
+					OperationId: "createPet",
+					RequestBody: &spec3.RequestBody{
+						Required: true,
+						Content: map[string]spec3.MediaType{
+							"application/json": {Schema: pet},
+						},
+					},
+					Responses: spec3.Responses{"201": {Description: "Created."}},
+					Security:  []spec3.SecurityRequirement{{"oauth": {"write"}}},
+				},
+			},
+			"/pets/{id}/photo": {
+				Parameters: []spec3.Parameter{{
+					Name:     "id",
+					In:       "path",
+					Required: true,
+					Schema:   &spec3.Schema{Type: "integer"},
+				}},
+				Put: &spec3.Operation{
+					OperationId: "uploadPhoto",
+					RequestBody: &spec3.RequestBody{
+						Required: true,
+						Content: map[string]spec3.MediaType{
+							"multipart/form-data": {Schema: &spec3.Schema{
+								Type:     "object",
+								Required: []string{"photo"},
+								Properties: map[string]spec3.Schema{
+									"photo":   {Type: "string", Format: "binary"},
+									"caption": {Type: "string"},
+								},
+							}},
+						},
+					},
+					Responses: spec3.Responses{"204": {Description: "Uploaded."}},
+				},
+			},
+		},
+		Components: &spec3.Components{
+			Schemas: map[string]spec3.Schema{
+				"Pet": {
+					Type:          "object",
+					Discriminator: &spec3.Discriminator{PropertyName: "kind"},
+					Required:      []string{"kind"},
+					Properties:    map[string]spec3.Schema{"kind": {Type: "string"}},
+				},
+			},
+			SecuritySchemes: map[string]spec3.SecurityScheme{
+				"basic": {Type: "http", Scheme: "basic"},
+				"oauth": {
+					Type: "oauth2",
+					Flows: &spec3.OAuthFlows{
+						AuthorizationCode: &spec3.OAuthFlow{
+							AuthorizationUrl: "https://example.com/auth",
+							TokenUrl:         "https://example.com/token",
+							Scopes:           map[string]string{"write": "Modify pets."},
+						},
+					},
+				},
+			},
+		},
+		Security: []spec3.SecurityRequirement{{"basic": {}}},
+	}
+	if diff := pretty.Compare(got, want); diff != "" {
+		t.Errorf("want != got: %s", diff)
+	}
+}
+
+func TestServers(t *testing.T) {
+	tests := []struct {
+		s    spec.Swagger
+		want []spec3.Server
+	}{
+		{s: spec.Swagger{}},
+		{s: spec.Swagger{BasePath: "/v1"}, want: []spec3.Server{{Url: "/v1"}}},
+		{s: spec.Swagger{Host: "example.com"}, want: []spec3.Server{{Url: "//example.com"}}},
+		{
+			s:    spec.Swagger{Host: "example.com", BasePath: "/api", Schemes: []string{"https"}},
+			want: []spec3.Server{{Url: "https://example.com/api"}},
+		},
+	}
+	for i, tt := range tests {
+		if diff := pretty.Compare(servers(&tt.s), tt.want); diff != "" {
+			t.Errorf("case %d: want != got: %s", i, diff)
+		}
+	}
+}
+
+func TestConvert2To3Errors(t *testing.T) {
+	tests := []spec.Swagger{
+		{SecurityDefinitions: map[string]spec.SecurityScheme{"k": {Type: "kerberos"}}},
+		{SecurityDefinitions: map[string]spec.SecurityScheme{"o": {Type: "oauth2", Flow: "device"}}},
+		{Paths: spec.Paths{"/": {Get: &spec.Operation{Parameters: []spec.Parameter{{
+			Name:             "ids",
+			In:               "query",
+			Type:             "array",
+			CollectionFormat: "tsv",
+			Items:            &spec.Items{Type: "string"},
+		}}}}}},
+	}
+	for i, tt := range tests {
+		if _, err := Convert2To3(&tt); err == nil {
+			t.Errorf("case %d: expected error", i)
+		}
+	}
+}
+
+const lossy = `
+openapi: 3.0.3
+info: {title: Pets, version: "1.0"}
+servers:
+- url: https://pets.example.com/v1
+- url: http://pets.example.com/v1
+- url: https://staging.example.com/v1
+paths:
+  /pets:
+    post:
+      operationId: createPet
+      requestBody:
+        $ref: '#/components/requestBodies/Pet'
+      responses:
+        "201":
+          description: Created.
+          content:
+            application/json:
+              schema:
+                oneOf:
+                - $ref: '#/components/schemas/Pet'
+      callbacks:
+        created:
+          '{$request.body#/callback}':
+            post:
+              responses:
+                "200": {description: OK}
+components:
+  schemas:
+    Pet:
+      type: object
+      properties:
+        name: {type: string}
+  requestBodies:
+    Pet:
+      required: true
+      content:
+        application/json:
+          schema:
+            $ref: '#/components/schemas/Pet'
+`
+
+func TestConvert3To2(t *testing.T) {
+	var o spec3.OpenAPI
+	if err := yaml.Unmarshal([]byte(lossy), &o); err != nil {
+		t.Fatal(err)
+	}
+	got, losses, err := Convert3To2(&o)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &spec.Swagger{
+		Swagger:  "2.0",
+		Info:     &spec.Info{Title: "Pets", Version: "1.0"},
+		Host:     "pets.example.com",
+		BasePath: "/v1",
+		Schemes:  []string{"https", "http"},
+		Paths: spec.Paths{
+			"/pets": {
+				Post: &spec.Operation{
+					OperationId: "createPet",
+					Consumes:    []string{"application/json"},
+					Produces:    []string{"application/json"},
+					Parameters: []spec.Parameter{{
+						Name:     "body",
+						In:       "body",
+						Required: true,
+						Schema:   &spec.Schema{Ref: "#/definitions/Pet"},
+					}},
+					Responses: spec.Responses{
+						"201": {Description: "Created.", Schema: &spec.Schema{}},
+					},
+				},
+			},
+		},
+		Definitions: spec.Definitions{
+			"Pet": {Type: "object", Properties: map[string]spec.Schema{"name": {Type: "string"}}},
+		},
+	}
+	if diff := pretty.Compare(got, want); diff != "" {
+		t.Errorf("want != got: %s", diff)
+	}
+
+	wantLosses := []Loss{
+		{Path: "/paths/~1pets/post/callbacks", Message: "callbacks are not supported"},
+		{Path: "/paths/~1pets/post/responses/201/content/application~1json/schema/oneOf", Message: "oneOf is not supported"},
+		{Path: "/servers/2", Message: "only the first server is kept"},
+	}
+	if diff := pretty.Compare(losses, wantLosses); diff != "" {
+		t.Errorf("losses: want != got: %s", diff)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(petstore), &s); err != nil {
+		t.Fatal(err)
+	}
+	o, err := Convert2To3(&s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	back, losses, err := Convert3To2(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(losses) != 0 {
+		t.Errorf("expected no losses converting a 2.0 document back, got %v", losses)
+	}
+	if diff := pretty.Compare(back.Definitions, s.Definitions); diff != "" {
+		t.Errorf("definitions: want != got: %s", diff)
+	}
+	if diff := pretty.Compare(back.SecurityDefinitions, s.SecurityDefinitions); diff != "" {
+		t.Errorf("securityDefinitions: want != got: %s", diff)
+	}
+	// Form parameters come from object properties, so they're ordered by name.
+	upload := back.Paths["/pets/{id}/photo"].Put
+	params := s.Paths["/pets/{id}/photo"].Put.Parameters
+	if diff := pretty.Compare(upload.Parameters, []spec.Parameter{params[1], params[0]}); diff != "" {
+		t.Errorf("form parameters: want != got: %s", diff)
+	}
+}
+
+func TestConvert2To3References(t *testing.T) {
+	s := &spec.Swagger{
+		Paths: spec.Paths{
+			"/pets": {Post: &spec.Operation{
+				Parameters: []spec.Parameter{
+					{Ref: "#/parameters/trace"},
+					{Ref: "#/parameters/pet"},
+				},
+				Responses: spec.Responses{"default": {Ref: "#/responses/Error"}},
+			}},
+		},
+		Parameters: spec.ParametersDefinitions{
+			"trace": {Name: "X-Trace", In: "header", Type: "string"},
+			"pet":   {Name: "pet", In: "body", Schema: &spec.Schema{Ref: "#/definitions/Pet"}},
+		},
+	}
+	o, err := Convert2To3(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &spec3.Operation{
+		Parameters: []spec3.Parameter{{Ref: "#/components/parameters/trace"}},
+		RequestBody: &spec3.RequestBody{Content: map[string]spec3.MediaType{
+			"application/json": {Schema: &spec3.Schema{Ref: "#/components/schemas/Pet"}},
+		}},
+		Responses: spec3.Responses{"default": {Ref: "#/components/responses/Error"}},
+	}
+	if diff := pretty.Compare(o.Paths["/pets"].Post, want); diff != "" {
+		t.Errorf("want != got: %s", diff)
+	}
+}
+
+const listing12 = `
+swaggerVersion: "1.2"
+apiVersion: "1.0"
+info: {title: Pets, description: A pet store., contact: pets@example.com}
+apis:
+- {path: '/pet.{format}', description: Operations about pets}
+- {path: '/store.{format}'}
+authorizations:
+  key: {type: apiKey, passAs: header, keyname: X-Key}
+  oauth:
+    type: oauth2
+    scopes: [{scope: write}]
+    grantTypes:
+      implicit: {loginEndpoint: {url: 'https://example.com/login'}}
+`
+
+const pets12 = `
+swaggerVersion: "1.2"
+basePath: https://pets.example.com/api
+resourcePath: /pet
+produces: [application/json]
+authorizations: {key: []}
+apis:
+- path: /pet/{petId}
+  operations:
+  - method: GET
+    nickname: getPet
+    type: Pet
+    authorizations: {}
+    parameters:
+    - {name: petId, paramType: path, required: true, type: integer, format: int64, minimum: "1"}
+    responseMessages:
+    - {code: 404, message: Pet not found}
+  - method: DELETE
+    nickname: deletePet
+    type: void
+    deprecated: "true"
+    authorizations: {oauth: [{scope: write}]}
+    parameters:
+    - {name: petId, paramType: path, required: true, type: integer, format: int64}
+    - {name: reason, paramType: header, type: Reason}
+- path: /pet/findByStatus
+  operations:
+  - method: GET
+    nickname: findPetsByStatus
+    type: array
+    items: {$ref: Pet}
+    parameters:
+    - {name: status, paramType: query, type: string, allowMultiple: true, defaultValue: available, enum: [available, sold]}
+models:
+  Animal:
+    id: Animal
+    required: [kind]
+    subTypes: [Pet]
+    discriminator: kind
+    properties:
+      kind: {type: string}
+  Pet:
+    id: Pet
+    properties:
+      id: {type: integer, format: int64, defaultValue: "0"}
+      tags: {type: array, items: {type: string}, uniqueItems: true}
+`
+
+const store12 = `
+swaggerVersion: "1.2"
+basePath: https://pets.example.com/api/v2
+resourcePath: /store
+apis:
+- path: /order
+  operations:
+  - method: POST
+    nickname: placeOrder
+    consumes: [application/json]
+    parameters:
+    - {name: body, paramType: body, required: true, type: Order}
+    responseMessages:
+    - {code: 201, message: Created.}
+models:
+  Order: {id: Order, properties: {pet: {$ref: Pet}}}
+`
+
+func TestConvert12To2(t *testing.T) {
+	var listing spec12.ResourceListing
+	if err := yaml.Unmarshal([]byte(listing12), &listing); err != nil {
+		t.Fatal(err)
+	}
+	var decls []*spec12.APIDeclaration
+	for _, data := range []string{pets12, store12} {
+		var decl spec12.APIDeclaration
+		if err := yaml.Unmarshal([]byte(data), &decl); err != nil {
+			t.Fatal(err)
+		}
+		decls = append(decls, &decl)
+	}
+	got, losses, err := Convert12To2(&listing, decls)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var want spec.Swagger
+	if err := yaml.Unmarshal([]byte(`
+swagger: "2.0"
+info:
+  title: Pets
+  description: A pet store.
+  version: "1.0"
+  contact: {email: pets@example.com}
+host: pets.example.com
+basePath: /api
+schemes: [https]
+tags:
+- {name: pet, description: Operations about pets}
+- {name: store}
+securityDefinitions:
+  key: {type: apiKey, name: X-Key, in: header}
+  oauth: {type: oauth2, flow: implicit, authorizationUrl: 'https://example.com/login', scopes: {write: ""}}
+paths:
+  /pet/{petId}:
+    get:
+      operationId: getPet
+      tags: [pet]
+      produces: [application/json]
+      security: []
+      parameters:
+      - {name: petId, in: path, required: true, type: integer, format: int64, minimum: 1}
+      responses:
+        200: {description: OK, schema: {$ref: '#/definitions/Pet'}}
+        404: {description: Pet not found}
+    delete:
+      operationId: deletePet
+      tags: [pet]
+      deprecated: true
+      produces: [application/json]
+      security:
+      - oauth: [write]
+      parameters:
+      - {name: petId, in: path, required: true, type: integer, format: int64}
+      - {name: reason, in: header, type: string}
+      responses:
+        200: {description: OK}
+  /pet/findByStatus:
+    get:
+      operationId: findPetsByStatus
+      tags: [pet]
+      produces: [application/json]
+      security:
+      - key: []
+      parameters:
+      - name: status
+        in: query
+        type: array
+        items: {type: string, enum: [available, sold]}
+        collectionFormat: csv
+        default: available
+      responses:
+        200: {description: OK, schema: {type: array, items: {$ref: '#/definitions/Pet'}}}
+  /v2/order:
+    post:
+      operationId: placeOrder
+      tags: [store]
+      consumes: [application/json]
+      parameters:
+      - {name: body, in: body, required: true, schema: {$ref: '#/definitions/Order'}}
+      responses:
+        201: {description: Created.}
+definitions:
+  Animal:
+    type: object
+    required: [kind]
+    discriminator: kind
+    properties:
+      kind: {type: string}
+  Pet:
+    allOf:
+    - $ref: '#/definitions/Animal'
+    - type: object
+      properties:
+        id: {type: integer, format: int64, default: 0}
+        tags: {type: array, items: {type: string}, uniqueItems: true}
+  Order:
+    type: object
+    properties:
+      pet: {$ref: '#/definitions/Pet'}
+`), &want); err != nil {
+		t.Fatal(err)
+	}
+	if diff := pretty.Compare(got, &want); diff != "" {
+		t.Errorf("want != got: %s", diff)
+	}
+
+	wantLosses := []Loss{
+		{Path: "/pet#/apis/0/operations/1/parameters/1/type", Message: "header parameters can't be models, assuming string"},
+	}
+	if diff := pretty.Compare(losses, wantLosses); diff != "" {
+		t.Errorf("losses: want != got: %s", diff)
+	}
+}
+
+const webhooks = `
+swagger: "2.0"
+info: {title: Orders, version: "1.0"}
+host: hooks.example.com
+basePath: /v1
+schemes: [https]
+consumes: [application/json]
+securityDefinitions:
+  signature: {type: apiKey, in: header, name: X-Signature}
+security:
+- signature: []
+paths:
+  /orders/{orderId}/shipped:
+    parameters:
+    - {name: orderId, in: path, type: string, required: true, description: The order.}
+    post:
+      operationId: orderShipped
+      summary: An order was shipped.
+      tags: [webhooks]
+      parameters:
+      - {name: X-Delivery, in: header, type: string, required: true}
+      - {name: attempt, in: query, type: integer}
+      - {name: body, in: body, required: true, schema: {$ref: '#/definitions/Shipment'}}
+      responses:
+        204: {description: Received.}
+    put:
+      operationId: replaceShipment
+      responses:
+        204: {description: Replaced.}
+  /orders:
+    get:
+      operationId: listOrders
+      responses:
+        200: {description: The orders.}
+definitions:
+  Shipment:
+    type: object
+    properties:
+      carrier: {type: string}
+`
+
+func TestConvertToAsyncAPI(t *testing.T) {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(webhooks), &s); err != nil {
+		t.Fatal(err)
+	}
+	got, losses, err := ConvertToAsyncAPI(&s, subset.Selection{Tags: []string{"webhooks"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var want asyncapi.AsyncAPI
+	if err := yaml.Unmarshal([]byte(`
+asyncapi: 2.6.0
+info: {title: Orders, version: "1.0"}
+servers:
+  https:
+    url: hooks.example.com/v1
+    protocol: https
+    security:
+    - signature: []
+defaultContentType: application/json
+channels:
+  /orders/{orderId}/shipped:
+    parameters:
+      orderId:
+        description: The order.
+        schema: {type: string}
+    subscribe:
+      operationId: orderShipped
+      summary: An order was shipped.
+      tags:
+      - name: webhooks
+      message:
+        name: orderShipped
+        summary: An order was shipped.
+        headers:
+          type: object
+          required: [X-Delivery]
+          properties:
+            X-Delivery: {type: string}
+        payload: {$ref: '#/components/schemas/Shipment'}
+components:
+  schemas:
+    Shipment:
+      type: object
+      properties:
+        carrier: {type: string}
+  securitySchemes:
+    signature: {type: httpApiKey, in: header, name: X-Signature}
+`), &want); err != nil {
+		t.Fatal(err)
+	}
+	if diff := pretty.Compare(got, &want); diff != "" {
+		t.Errorf("want != got: %s", diff)
+	}
+
+	wantLosses := []Loss{
+		{Path: "/paths/~1orders~1{orderId}~1shipped/post/parameters/1", Message: "query parameter attempt has no AsyncAPI equivalent"},
+	}
+	if diff := pretty.Compare(losses, wantLosses); diff != "" {
+		t.Errorf("losses: want != got: %s", diff)
+	}
+
+	// Without a selection, every operation is converted, though a channel
+	// only has room for one of a path's.
+	got, losses, err = ConvertToAsyncAPI(&s, subset.Selection{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := got.Channels["/orders"]; !ok {
+		t.Errorf("expected a channel for /orders")
+	}
+	if len(losses) != 2 || losses[1].Path != "/paths/~1orders~1{orderId}~1shipped/put" {
+		t.Errorf("expected the second operation of a path to be lost, got %v", losses)
+	}
+}
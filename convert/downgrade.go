@@ -0,0 +1,819 @@
+package convert
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/internal/jsonpointer"
+	"github.com/ericchiang/swaggopher/internal/mapkeys"
+	"github.com/ericchiang/swaggopher/spec"
+	"github.com/ericchiang/swaggopher/spec3"
+)
+
+// Loss records part of a document which couldn't be represented after
+// conversion and was dropped or approximated.
+type Loss struct {
+	// Path is a JSON pointer to the value in the source document.
+	Path string `json:"path"`
+	// Message describes what was lost.
+	Message string `json:"message"`
+}
+
+func (l Loss) String() string {
+	return l.Path + ": " + l.Message
+}
+
+// Convert3To2 converts an OpenAPI 3.0 document to Swagger 2.0 on a best-effort
+// basis. Features without a 2.0 equivalent, such as oneOf, callbacks, links and
+// multiple servers, are dropped and reported as losses.
+//
+// References to reusable parameters, request bodies and responses are inlined.
+// Schema references are rewritten to point at definitions.
+func Convert3To2(o *spec3.OpenAPI) (*spec.Swagger, []Loss, error) {
+	c := &converter3To2{doc: o}
+	s, err := c.convert()
+	if err != nil {
+		return nil, nil, err
+	}
+	return s, c.losses, nil
+}
+
+type converter3To2 struct {
+	doc    *spec3.OpenAPI
+	losses []Loss
+}
+
+func (c *converter3To2) lose(path, format string, v ...interface{}) {
+	c.losses = append(c.losses, Loss{Path: path, Message: fmt.Sprintf(format, v...)})
+}
+
+func (c *converter3To2) convert() (*spec.Swagger, error) {
+	o := c.doc
+	s := &spec.Swagger{
+		Swagger:      "2.0",
+		Paths:        spec.Paths{},
+		Tags:         tags3To2(o.Tags),
+		ExternalDocs: externalDocs3To2(o.ExternalDocs),
+	}
+	if o.Info != nil {
+		s.Info = &spec.Info{
+			Title:          o.Info.Title,
+			Description:    o.Info.Description,
+			TermsOfService: o.Info.TermsOfService,
+			Version:        o.Info.Version,
+		}
+		if ct := o.Info.Contact; ct != nil {
+			s.Info.Contact = &spec.Contact{Name: ct.Name, Url: ct.Url, Email: ct.Email}
+		}
+		if l := o.Info.License; l != nil {
+			s.Info.License = &spec.License{Name: l.Name, Url: l.Url}
+		}
+	}
+	if err := c.servers(s); err != nil {
+		return nil, err
+	}
+	for _, req := range o.Security {
+		s.Security = append(s.Security, spec.SecurityRequirement(req))
+	}
+
+	for _, path := range mapkeys.Sorted(o.Paths) {
+		item, err := c.pathItem(jsonpointer.Join("/paths", path), o.Paths[path])
+		if err != nil {
+			return nil, fmt.Errorf("convert: paths %s: %v", path, err)
+		}
+		s.Paths[path] = item
+	}
+
+	if comp := o.Components; comp != nil {
+		for name, schema := range comp.Schemas {
+			if s.Definitions == nil {
+				s.Definitions = spec.Definitions{}
+			}
+			s.Definitions[name] = *c.schema(jsonpointer.Join("/components/schemas", name), &schema)
+		}
+		for name, p := range comp.Parameters {
+			path := jsonpointer.Join("/components/parameters", name)
+			converted, ok, err := c.parameter(path, &p)
+			if err != nil {
+				return nil, fmt.Errorf("convert: components parameters %s: %v", name, err)
+			}
+			if !ok {
+				continue
+			}
+			if s.Parameters == nil {
+				s.Parameters = spec.ParametersDefinitions{}
+			}
+			s.Parameters[name] = converted
+		}
+		for name, r := range comp.Responses {
+			path := jsonpointer.Join("/components/responses", name)
+			converted, _, err := c.response(path, &r)
+			if err != nil {
+				return nil, fmt.Errorf("convert: components responses %s: %v", name, err)
+			}
+			if s.Responses == nil {
+				s.Responses = spec.ResponsesDefinitions{}
+			}
+			s.Responses[name] = converted
+		}
+		for _, name := range mapkeys.Sorted(comp.SecuritySchemes) {
+			scheme := comp.SecuritySchemes[name]
+			path := jsonpointer.Join("/components/securitySchemes", name)
+			converted, ok := c.securityScheme(path, &scheme)
+			if !ok {
+				continue
+			}
+			if s.SecurityDefinitions == nil {
+				s.SecurityDefinitions = spec.SecurityDefinitions{}
+			}
+			s.SecurityDefinitions[name] = converted
+		}
+		if len(comp.Links) > 0 {
+			c.lose("/components/links", "links are not supported")
+		}
+		if len(comp.Callbacks) > 0 {
+			c.lose("/components/callbacks", "callbacks are not supported")
+		}
+		// Request bodies, headers and examples are inlined where they're
+		// referenced, so dropping the components loses nothing.
+	}
+	sort.SliceStable(c.losses, func(i, j int) bool { return c.losses[i].Path < c.losses[j].Path })
+	return s, nil
+}
+
+// servers sets host, basePath and schemes from the first server. Servers that
+// differ only by scheme are merged.
+func (c *converter3To2) servers(s *spec.Swagger) error {
+	for i, server := range c.doc.Servers {
+		path := jsonpointer.Join("/servers", fmt.Sprint(i))
+		raw := server.Url
+		for name, v := range server.Variables {
+			raw = strings.Replace(raw, "{"+name+"}", v.Default, -1)
+		}
+		if len(server.Variables) > 0 {
+			c.lose(jsonpointer.Join(path, "variables"), "server variables replaced by their defaults")
+		}
+		u, err := url.Parse(raw)
+		if err != nil {
+			return fmt.Errorf("convert: servers %d: invalid url %q: %v", i, server.Url, err)
+		}
+		basePath := strings.TrimSuffix(u.Path, "/")
+		if i == 0 {
+			s.Host, s.BasePath = u.Host, basePath
+			if u.Scheme != "" {
+				s.Schemes = []string{u.Scheme}
+			}
+			continue
+		}
+		if u.Host == s.Host && basePath == s.BasePath && u.Scheme != "" && len(s.Schemes) > 0 {
+			s.Schemes = append(s.Schemes, u.Scheme)
+			continue
+		}
+		c.lose(path, "only the first server is kept")
+	}
+	return nil
+}
+
+func (c *converter3To2) pathItem(path string, item spec3.PathItem) (spec.PathItem, error) {
+	out := spec.PathItem{Ref: ref3To2(item.Ref)}
+	if item.Summary != "" || item.Description != "" {
+		c.lose(path, "path item summary and description are not supported")
+	}
+	if len(item.Servers) > 0 {
+		c.lose(jsonpointer.Join(path, "servers"), "path item servers are not supported")
+	}
+	for i := range item.Parameters {
+		p, ok, err := c.parameter(jsonpointer.Join(path, "parameters", fmt.Sprint(i)), &item.Parameters[i])
+		if err != nil {
+			return out, err
+		}
+		if ok {
+			out.Parameters = append(out.Parameters, p)
+		}
+	}
+	if item.Trace != nil {
+		c.lose(jsonpointer.Join(path, "trace"), "trace operations are not supported")
+	}
+
+	ops := []struct {
+		method string
+		in     *spec3.Operation
+		out    **spec.Operation
+	}{
+		{"get", item.Get, &out.Get},
+		{"put", item.Put, &out.Put},
+		{"post", item.Post, &out.Post},
+		{"delete", item.Delete, &out.Delete},
+		{"options", item.Options, &out.Options},
+		{"head", item.Head, &out.Head},
+		{"patch", item.Patch, &out.Patch},
+	}
+	for _, op := range ops {
+		if op.in == nil {
+			continue
+		}
+		converted, err := c.operation(jsonpointer.Join(path, op.method), op.in)
+		if err != nil {
+			return out, fmt.Errorf("%s: %v", op.method, err)
+		}
+		*op.out = converted
+	}
+	return out, nil
+}
+
+func (c *converter3To2) operation(path string, op *spec3.Operation) (*spec.Operation, error) {
+	out := &spec.Operation{
+		Tags:         op.Tags,
+		Summary:      op.Summary,
+		Description:  op.Description,
+		ExternalDocs: externalDocs3To2(op.ExternalDocs),
+		OperationId:  op.OperationId,
+		Deprecated:   op.Deprecated,
+		Responses:    spec.Responses{},
+	}
+	for _, req := range op.Security {
+		out.Security = append(out.Security, spec.SecurityRequirement(req))
+	}
+	if len(op.Servers) > 0 {
+		c.lose(jsonpointer.Join(path, "servers"), "operation servers are not supported")
+	}
+	if len(op.Callbacks) > 0 {
+		c.lose(jsonpointer.Join(path, "callbacks"), "callbacks are not supported")
+	}
+
+	for i := range op.Parameters {
+		p, ok, err := c.parameter(jsonpointer.Join(path, "parameters", fmt.Sprint(i)), &op.Parameters[i])
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			out.Parameters = append(out.Parameters, p)
+		}
+	}
+	if op.RequestBody != nil {
+		params, consumes, err := c.requestBody(jsonpointer.Join(path, "requestBody"), op.RequestBody)
+		if err != nil {
+			return nil, err
+		}
+		out.Parameters = append(out.Parameters, params...)
+		out.Consumes = consumes
+	}
+
+	var produces []string
+	for _, code := range mapkeys.Sorted(op.Responses) {
+		r := op.Responses[code]
+		converted, mediaTypes, err := c.response(jsonpointer.Join(path, "responses", code), &r)
+		if err != nil {
+			return nil, err
+		}
+		out.Responses[code] = converted
+		produces = appendUnique(produces, mediaTypes...)
+	}
+	out.Produces = produces
+	return out, nil
+}
+
+// parameter converts a parameter, reporting false if it has no 2.0
+// equivalent.
+func (c *converter3To2) parameter(path string, p *spec3.Parameter) (spec.Parameter, bool, error) {
+	if p.Ref != "" {
+		resolved, err := c.resolveParameter(p.Ref)
+		if err != nil {
+			return spec.Parameter{}, false, err
+		}
+		p = resolved
+	}
+	if p.In == "cookie" {
+		c.lose(path, "cookie parameters are not supported")
+		return spec.Parameter{}, false, nil
+	}
+	out := spec.Parameter{
+		Name:            p.Name,
+		In:              p.In,
+		Description:     p.Description,
+		Required:        p.Required,
+		AllowEmptyValue: p.AllowEmptyValue,
+	}
+	schema := p.Schema
+	if schema == nil {
+		for _, mediaType := range mapkeys.Sorted(p.Content) {
+			schema = p.Content[mediaType].Schema
+			c.lose(jsonpointer.Join(path, "content"), "parameter content is replaced by its %s schema", mediaType)
+			break
+		}
+	}
+	if schema != nil {
+		items := c.items(jsonpointer.Join(path, "schema"), schema)
+		out.Type, out.Format, out.Items = items.Type, items.Format, items.Items
+		out.Default, out.Enum, out.MultipleOf = items.Default, items.Enum, items.MultipleOf
+		out.Maximum, out.ExclusiveMaximum = items.Maximum, items.ExclusiveMaximum
+		out.Minimum, out.ExclusiveMinimum = items.Minimum, items.ExclusiveMinimum
+		out.MaxLength, out.MinLength, out.Pattern = items.MaxLength, items.MinLength, items.Pattern
+		out.MaxItems, out.MinItems, out.UniqueItems = items.MaxItems, items.MinItems, items.UniqueItems
+	}
+	if out.Type == "array" {
+		format, ok := collectionFormat(p.In, p.Style, p.Explode)
+		if !ok {
+			c.lose(path, "style %q has no collectionFormat equivalent", p.Style)
+		}
+		out.CollectionFormat = format
+	}
+	return out, true, nil
+}
+
+// collectionFormat maps a 3.0 style and explode to a 2.0 collectionFormat.
+func collectionFormat(in, style string, explode *bool) (string, bool) {
+	if style == "" {
+		style = "simple"
+		if in == "query" {
+			style = "form"
+		}
+	}
+	// Form style explodes by default.
+	exploded := style == "form"
+	if explode != nil {
+		exploded = *explode
+	}
+	switch style {
+	case "form":
+		if exploded {
+			return "multi", true
+		}
+		return "csv", true
+	case "simple":
+		return "csv", true
+	case "spaceDelimited":
+		return "ssv", true
+	case "pipeDelimited":
+		return "pipes", true
+	}
+	return "csv", false
+}
+
+// requestBody converts a request body to a body parameter, or to formData
+// parameters for form media types.
+func (c *converter3To2) requestBody(path string, rb *spec3.RequestBody) ([]spec.Parameter, []string, error) {
+	if rb.Ref != "" {
+		resolved, err := c.resolveRequestBody(rb.Ref)
+		if err != nil {
+			return nil, nil, err
+		}
+		rb = resolved
+	}
+	consumes := mapkeys.Sorted(rb.Content)
+	if len(consumes) == 0 {
+		return nil, nil, nil
+	}
+
+	var form []string
+	for _, mediaType := range consumes {
+		if mediaType == "application/x-www-form-urlencoded" || mediaType == "multipart/form-data" {
+			form = append(form, mediaType)
+		}
+	}
+	if len(form) > 0 {
+		if len(form) != len(consumes) {
+			c.lose(jsonpointer.Join(path, "content"), "form and non-form request bodies can't be mixed, only form media types are kept")
+		}
+		mediaType := form[0]
+		schema, err := c.resolveSchema(rb.Content[mediaType].Schema)
+		if err != nil {
+			return nil, nil, err
+		}
+		schemaPath := jsonpointer.Join(path, "content", mediaType, "schema")
+		if schema == nil || len(schema.Properties) == 0 {
+			c.lose(schemaPath, "form bodies must be objects with properties")
+			return nil, form, nil
+		}
+		required := make(map[string]bool)
+		for _, name := range schema.Required {
+			required[name] = true
+		}
+		var params []spec.Parameter
+		for _, name := range mapkeys.Sorted(schema.Properties) {
+			prop := schema.Properties[name]
+			propPath := jsonpointer.Join(schemaPath, "properties", name)
+			p := spec.Parameter{Name: name, In: "formData", Description: prop.Description, Required: required[name]}
+			if prop.Type == "string" && prop.Format == "binary" {
+				p.Type = "file"
+			} else {
+				items := c.items(propPath, &prop)
+				p.Type, p.Format, p.Items, p.Default, p.Enum = items.Type, items.Format, items.Items, items.Default, items.Enum
+				if p.Type == "array" {
+					p.CollectionFormat = "multi"
+				}
+			}
+			params = append(params, p)
+		}
+		return params, form, nil
+	}
+
+	// 2.0 has a single body schema for every media type, so prefer JSON.
+	mediaType := consumes[0]
+	for _, mt := range consumes {
+		if mt == defaultMediaType {
+			mediaType = mt
+		}
+	}
+	for _, mt := range consumes {
+		if mt != mediaType && !sameSchema(rb.Content[mt].Schema, rb.Content[mediaType].Schema) {
+			c.lose(jsonpointer.Join(path, "content", mt, "schema"), "request body uses the %s schema", mediaType)
+		}
+	}
+	body := spec.Parameter{
+		Name:        "body",
+		In:          "body",
+		Description: rb.Description,
+		Required:    rb.Required,
+		Schema:      c.schema(jsonpointer.Join(path, "content", mediaType, "schema"), rb.Content[mediaType].Schema),
+	}
+	if body.Schema == nil {
+		body.Schema = &spec.Schema{}
+	}
+	return []spec.Parameter{body}, consumes, nil
+}
+
+// response converts a response and returns the media types it produces.
+func (c *converter3To2) response(path string, r *spec3.Response) (spec.Response, []string, error) {
+	if r.Ref != "" {
+		resolved, err := c.resolveResponse(r.Ref)
+		if err != nil {
+			return spec.Response{}, nil, err
+		}
+		r = resolved
+	}
+	out := spec.Response{Description: r.Description}
+	for _, name := range mapkeys.Sorted(r.Headers) {
+		h := r.Headers[name]
+		if h.Ref != "" {
+			resolved, err := c.resolveHeader(h.Ref)
+			if err != nil {
+				return out, nil, err
+			}
+			h = *resolved
+		}
+		header := spec.Header{Description: h.Description}
+		if h.Schema != nil {
+			items := c.items(jsonpointer.Join(path, "headers", name, "schema"), h.Schema)
+			header.Type, header.Format, header.Items = items.Type, items.Format, items.Items
+			header.Default, header.Enum, header.Pattern = items.Default, items.Enum, items.Pattern
+			header.Maximum, header.Minimum = items.Maximum, items.Minimum
+		}
+		if out.Headers == nil {
+			out.Headers = spec.Headers{}
+		}
+		out.Headers[name] = header
+	}
+	if len(r.Links) > 0 {
+		c.lose(jsonpointer.Join(path, "links"), "links are not supported")
+	}
+
+	produces := mapkeys.Sorted(r.Content)
+	if len(produces) == 0 {
+		return out, nil, nil
+	}
+	mediaType := produces[0]
+	for _, mt := range produces {
+		if mt == defaultMediaType {
+			mediaType = mt
+		}
+	}
+	out.Schema = c.schema(jsonpointer.Join(path, "content", mediaType, "schema"), r.Content[mediaType].Schema)
+	for _, mt := range produces {
+		content := r.Content[mt]
+		if mt != mediaType && !sameSchema(content.Schema, r.Content[mediaType].Schema) {
+			c.lose(jsonpointer.Join(path, "content", mt, "schema"), "response uses the %s schema", mediaType)
+		}
+		example := content.Example
+		if example == nil {
+			for _, name := range mapkeys.Sorted(content.Examples) {
+				example = content.Examples[name].Value
+				break
+			}
+		}
+		if example != nil {
+			if out.Examples == nil {
+				out.Examples = spec.Example{}
+			}
+			out.Examples[mt] = example
+		}
+	}
+	return out, produces, nil
+}
+
+func (c *converter3To2) schema(path string, s *spec3.Schema) *spec.Schema {
+	if s == nil {
+		return nil
+	}
+	out := &spec.Schema{
+		Ref:              ref3To2(s.Ref),
+		Title:            s.Title,
+		MultipleOf:       s.MultipleOf,
+		Maximum:          s.Maximum,
+		ExclusiveMaximum: s.ExclusiveMaximum,
+		Minimum:          s.Minimum,
+		ExclusiveMinimum: s.ExclusiveMinimum,
+		MaxLength:        s.MaxLength,
+		MinLength:        s.MinLength,
+		Pattern:          s.Pattern,
+		MaxItems:         s.MaxItems,
+		MinItems:         s.MinItems,
+		UniqueItems:      s.UniqueItems,
+		MaxProperties:    s.MaxProperties,
+		MinProperties:    s.MinProperties,
+		Required:         s.Required,
+		Enum:             s.Enum,
+		Type:             s.Type,
+		Items:            c.schema(jsonpointer.Join(path, "items"), s.Items),
+		Description:      s.Description,
+		Format:           s.Format,
+		Default:          s.Default,
+		ReadOnly:         s.ReadOnly,
+		ExternalDocs:     externalDocs3To2(s.ExternalDocs),
+		Example:          s.Example,
+	}
+	for i := range s.AllOf {
+		out.AllOf = append(out.AllOf, *c.schema(jsonpointer.Join(path, "allOf", fmt.Sprint(i)), &s.AllOf[i]))
+	}
+	for name, prop := range s.Properties {
+		if out.Properties == nil {
+			out.Properties = make(map[string]spec.Schema)
+		}
+		out.Properties[name] = *c.schema(jsonpointer.Join(path, "properties", name), &prop)
+	}
+	if ap := s.AdditionalProperties; ap != nil {
+		out.AdditionalProperties = &spec.AdditionalProperties{
+			Allowed: ap.Allowed,
+			Schema:  c.schema(jsonpointer.Join(path, "additionalProperties"), ap.Schema),
+		}
+	}
+	if d := s.Discriminator; d != nil {
+		out.Discriminator = d.PropertyName
+		if len(d.Mapping) > 0 {
+			c.lose(jsonpointer.Join(path, "discriminator", "mapping"), "discriminator mappings are not supported")
+		}
+	}
+	if x := s.Xml; x != nil {
+		out.Xml = &spec.XML{Name: x.Name, Namespace: x.Namespace, Prefix: x.Prefix, Attribute: x.Attribute, Wrapped: x.Wrapped}
+	}
+
+	unsupported := []struct {
+		keyword string
+		set     bool
+	}{
+		{"oneOf", len(s.OneOf) > 0},
+		{"anyOf", len(s.AnyOf) > 0},
+		{"not", s.Not != nil},
+		{"nullable", s.Nullable},
+		{"writeOnly", s.WriteOnly},
+		{"deprecated", s.Deprecated},
+	}
+	for _, u := range unsupported {
+		if u.set {
+			c.lose(jsonpointer.Join(path, u.keyword), "%s is not supported", u.keyword)
+		}
+	}
+	if s.Type == "string" && s.Format == "binary" {
+		// "file" is only valid for formData parameters and responses, and a
+		// binary string is the closest match elsewhere.
+		out.Format = "binary"
+	}
+	return out
+}
+
+// items converts a schema used by a non-body parameter or header.
+func (c *converter3To2) items(path string, s *spec3.Schema) *spec.Items {
+	resolved, err := c.resolveSchema(s)
+	if err != nil || resolved == nil {
+		c.lose(path, "unresolvable schema %s", s.Ref)
+		return &spec.Items{Type: "string"}
+	}
+	s = resolved
+	switch s.Type {
+	case "object", "":
+		c.lose(path, "parameter and header schemas must be primitive types or arrays")
+		return &spec.Items{Type: "string"}
+	}
+	out := &spec.Items{
+		Type:             s.Type,
+		Format:           s.Format,
+		Default:          s.Default,
+		Maximum:          s.Maximum,
+		ExclusiveMaximum: s.ExclusiveMaximum,
+		Minimum:          s.Minimum,
+		ExclusiveMinimum: s.ExclusiveMinimum,
+		MaxLength:        s.MaxLength,
+		MinLength:        s.MinLength,
+		Pattern:          s.Pattern,
+		MaxItems:         s.MaxItems,
+		MinItems:         s.MinItems,
+		UniqueItems:      s.UniqueItems,
+		Enum:             s.Enum,
+		MultipleOf:       s.MultipleOf,
+	}
+	if s.Type == "array" && s.Items != nil {
+		out.Items = c.items(jsonpointer.Join(path, "items"), s.Items)
+		out.Items.CollectionFormat = "csv"
+	}
+	return out
+}
+
+func (c *converter3To2) components() *spec3.Components {
+	if c.doc.Components == nil {
+		return &spec3.Components{}
+	}
+	return c.doc.Components
+}
+
+// component returns the name of a local reference to the given kind of
+// component.
+func component(ref, kind string) (string, error) {
+	prefix := "#/components/" + kind + "/"
+	if !strings.HasPrefix(ref, prefix) {
+		return "", fmt.Errorf("unsupported reference %q", ref)
+	}
+	return jsonpointer.Unescape(strings.TrimPrefix(ref, prefix)), nil
+}
+
+func (c *converter3To2) resolveParameter(ref string) (*spec3.Parameter, error) {
+	name, err := component(ref, "parameters")
+	if err != nil {
+		return nil, err
+	}
+	p, ok := c.components().Parameters[name]
+	if !ok {
+		return nil, fmt.Errorf("unresolved reference %q", ref)
+	}
+	return &p, nil
+}
+
+func (c *converter3To2) resolveRequestBody(ref string) (*spec3.RequestBody, error) {
+	name, err := component(ref, "requestBodies")
+	if err != nil {
+		return nil, err
+	}
+	rb, ok := c.components().RequestBodies[name]
+	if !ok {
+		return nil, fmt.Errorf("unresolved reference %q", ref)
+	}
+	return &rb, nil
+}
+
+func (c *converter3To2) resolveResponse(ref string) (*spec3.Response, error) {
+	name, err := component(ref, "responses")
+	if err != nil {
+		return nil, err
+	}
+	r, ok := c.components().Responses[name]
+	if !ok {
+		return nil, fmt.Errorf("unresolved reference %q", ref)
+	}
+	return &r, nil
+}
+
+func (c *converter3To2) resolveHeader(ref string) (*spec3.Header, error) {
+	name, err := component(ref, "headers")
+	if err != nil {
+		return nil, err
+	}
+	h, ok := c.components().Headers[name]
+	if !ok {
+		return nil, fmt.Errorf("unresolved reference %q", ref)
+	}
+	return &h, nil
+}
+
+// resolveSchema follows local schema references.
+func (c *converter3To2) resolveSchema(s *spec3.Schema) (*spec3.Schema, error) {
+	seen := make(map[string]bool)
+	for s != nil && s.Ref != "" {
+		if seen[s.Ref] {
+			return nil, fmt.Errorf("circular reference %q", s.Ref)
+		}
+		seen[s.Ref] = true
+		name, err := component(s.Ref, "schemas")
+		if err != nil {
+			return nil, err
+		}
+		resolved, ok := c.components().Schemas[name]
+		if !ok {
+			return nil, fmt.Errorf("unresolved reference %q", s.Ref)
+		}
+		s = &resolved
+	}
+	return s, nil
+}
+
+func (c *converter3To2) securityScheme(path string, s *spec3.SecurityScheme) (spec.SecurityScheme, bool) {
+	out := spec.SecurityScheme{Description: s.Description}
+	switch s.Type {
+	case "http":
+		if strings.EqualFold(s.Scheme, "basic") {
+			out.Type = "basic"
+			return out, true
+		}
+		if strings.EqualFold(s.Scheme, "bearer") {
+			c.lose(path, "bearer authentication is approximated by an Authorization header API key")
+			out.Type, out.Name, out.In = "apiKey", "Authorization", "header"
+			return out, true
+		}
+		c.lose(path, "http %s authentication is not supported", s.Scheme)
+		return out, false
+	case "apiKey":
+		if s.In == "cookie" {
+			c.lose(path, "cookie API keys are not supported")
+			return out, false
+		}
+		out.Type, out.Name, out.In = "apiKey", s.Name, s.In
+		return out, true
+	case "oauth2":
+		out.Type = "oauth2"
+		if s.Flows == nil {
+			c.lose(path, "oauth2 scheme has no flows")
+			return out, false
+		}
+		// 2.0 allows one flow per scheme, so the first one defined is kept.
+		flows := []struct {
+			name, flow2 string
+			f           *spec3.OAuthFlow
+		}{
+			{"implicit", "implicit", s.Flows.Implicit},
+			{"password", "password", s.Flows.Password},
+			{"clientCredentials", "application", s.Flows.ClientCredentials},
+			{"authorizationCode", "accessCode", s.Flows.AuthorizationCode},
+		}
+		kept := false
+		for _, f := range flows {
+			if f.f == nil {
+				continue
+			}
+			if kept {
+				c.lose(jsonpointer.Join(path, "flows", f.name), "only one oauth2 flow is supported")
+				continue
+			}
+			kept = true
+			out.Flow = f.flow2
+			out.AuthorizationUrl = f.f.AuthorizationUrl
+			out.TokenUrl = f.f.TokenUrl
+			out.Scopes = spec.Scopes(f.f.Scopes)
+			if f.name == "implicit" {
+				out.TokenUrl = ""
+			}
+			if f.name == "password" || f.name == "clientCredentials" {
+				out.AuthorizationUrl = ""
+			}
+		}
+		return out, kept
+	}
+	c.lose(path, "%s security schemes are not supported", s.Type)
+	return out, false
+}
+
+func ref3To2(ref string) string {
+	for _, p := range refPrefixes2To3 {
+		if strings.HasPrefix(ref, p.to) {
+			return p.from + strings.TrimPrefix(ref, p.to)
+		}
+	}
+	return ref
+}
+
+func sameSchema(a, b *spec3.Schema) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Ref != "" && a.Ref == b.Ref
+}
+
+func tags3To2(tags []spec3.Tag) []spec.Tag {
+	var out []spec.Tag
+	for _, t := range tags {
+		out = append(out, spec.Tag{Name: t.Name, Description: t.Description, ExternalDocs: externalDocs3To2(t.ExternalDocs)})
+	}
+	return out
+}
+
+func externalDocs3To2(d *spec3.ExternalDocumentation) *spec.ExternalDocumentation {
+	if d == nil {
+		return nil
+	}
+	return &spec.ExternalDocumentation{Description: d.Description, Url: d.Url}
+}
+
+func appendUnique(list []string, values ...string) []string {
+	for _, v := range values {
+		found := false
+		for _, existing := range list {
+			if existing == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, v)
+		}
+	}
+	return list
+}
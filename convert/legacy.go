@@ -0,0 +1,430 @@
+package convert
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/internal/jsonpointer"
+	"github.com/ericchiang/swaggopher/internal/mapkeys"
+	"github.com/ericchiang/swaggopher/spec"
+	"github.com/ericchiang/swaggopher/spec12"
+)
+
+// Convert12To2 converts a Swagger 1.2 resource listing and the API declarations
+// of its resources to a single Swagger 2.0 document.
+//
+// Each resource becomes a tag, and its models become definitions. The first
+// declaration's basePath sets host, basePath and schemes; paths of the others
+// are made relative to it. Since 1.2 describes each operation's success
+// response by its return type, it becomes the operation's first 2xx response,
+// or a 200 response if none is listed.
+//
+// The paths of losses are a declaration's resource path followed by a JSON
+// pointer into it, such as "/pet#/apis/0/operations/1".
+func Convert12To2(listing *spec12.ResourceListing, decls []*spec12.APIDeclaration) (*spec.Swagger, []Loss, error) {
+	c := &converter12To2{listing: listing, decls: decls}
+	s, err := c.convert()
+	if err != nil {
+		return nil, nil, err
+	}
+	return s, c.losses, nil
+}
+
+type converter12To2 struct {
+	listing *spec12.ResourceListing
+	decls   []*spec12.APIDeclaration
+	losses  []Loss
+}
+
+func (c *converter12To2) lose(path, format string, v ...interface{}) {
+	c.losses = append(c.losses, Loss{Path: path, Message: fmt.Sprintf(format, v...)})
+}
+
+func (c *converter12To2) convert() (*spec.Swagger, error) {
+	l := c.listing
+	s := &spec.Swagger{
+		Swagger: "2.0",
+		Info:    &spec.Info{Version: l.ApiVersion},
+		Paths:   spec.Paths{},
+	}
+	if info := l.Info; info != nil {
+		s.Info.Title = info.Title
+		s.Info.Description = info.Description
+		s.Info.TermsOfService = info.TermsOfServiceUrl
+		if info.Contact != "" {
+			s.Info.Contact = &spec.Contact{Email: info.Contact}
+		}
+		if info.License != "" || info.LicenseUrl != "" {
+			s.Info.License = &spec.License{Name: info.License, Url: info.LicenseUrl}
+		}
+	}
+	for _, r := range l.Apis {
+		if name := tagName(r.Path); name != "" {
+			s.Tags = append(s.Tags, spec.Tag{Name: name, Description: r.Description})
+		}
+	}
+	for _, name := range mapkeys.Sorted(l.Authorizations) {
+		a := l.Authorizations[name]
+		scheme, err := authorization(&a)
+		if err != nil {
+			return nil, fmt.Errorf("convert: authorizations %s: %v", name, err)
+		}
+		if s.SecurityDefinitions == nil {
+			s.SecurityDefinitions = spec.SecurityDefinitions{}
+		}
+		s.SecurityDefinitions[name] = scheme
+	}
+
+	subTypes := make(map[string]string)
+	for i, decl := range c.decls {
+		if err := c.declaration(s, i, decl, subTypes); err != nil {
+			return nil, fmt.Errorf("convert: resource %s: %v", decl.ResourcePath, err)
+		}
+	}
+	// Sub-types become compositions of their parent and their own properties.
+	for _, child := range mapkeys.Sorted(subTypes) {
+		parent := subTypes[child]
+		if def, ok := s.Definitions[child]; ok {
+			s.Definitions[child] = spec.Schema{
+				AllOf: []spec.Schema{{Ref: "#/definitions/" + jsonpointer.Escape(parent)}, def},
+			}
+		}
+	}
+	sort.SliceStable(c.losses, func(i, j int) bool { return c.losses[i].Path < c.losses[j].Path })
+	return s, nil
+}
+
+// tagName names the tag of a resource after its path, without the leading
+// slash or any file extension, such as "pet" for "/pet.{format}".
+func tagName(path string) string {
+	name := strings.TrimPrefix(path, "/")
+	if i := strings.Index(name, "."); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+func authorization(a *spec12.Authorization) (spec.SecurityScheme, error) {
+	switch a.Type {
+	case "basicAuth":
+		return spec.SecurityScheme{Type: "basic"}, nil
+	case "apiKey":
+		return spec.SecurityScheme{Type: "apiKey", Name: a.Keyname, In: a.PassAs}, nil
+	case "oauth2":
+		scheme := spec.SecurityScheme{Type: "oauth2", Scopes: spec.Scopes{}}
+		for _, scope := range a.Scopes {
+			scheme.Scopes[scope.Scope] = scope.Description
+		}
+		g := a.GrantTypes
+		switch {
+		case g != nil && g.Implicit != nil:
+			scheme.Flow = "implicit"
+			scheme.AuthorizationUrl = g.Implicit.LoginEndpoint.Url
+		case g != nil && g.AuthorizationCode != nil:
+			scheme.Flow = "accessCode"
+			scheme.AuthorizationUrl = g.AuthorizationCode.TokenRequestEndpoint.Url
+			scheme.TokenUrl = g.AuthorizationCode.TokenEndpoint.Url
+		default:
+			return spec.SecurityScheme{}, fmt.Errorf("no grant types")
+		}
+		return scheme, nil
+	}
+	return spec.SecurityScheme{}, fmt.Errorf("unknown type %q", a.Type)
+}
+
+func (c *converter12To2) declaration(s *spec.Swagger, i int, decl *spec12.APIDeclaration, subTypes map[string]string) error {
+	resource := decl.ResourcePath
+	u, err := url.Parse(decl.BasePath)
+	if err != nil {
+		return fmt.Errorf("invalid basePath %q: %v", decl.BasePath, err)
+	}
+	basePath := strings.TrimSuffix(u.Path, "/")
+	if i == 0 {
+		s.Host, s.BasePath = u.Host, basePath
+		if u.Scheme != "" {
+			s.Schemes = []string{u.Scheme}
+		}
+	}
+	if u.Host != s.Host {
+		c.lose(resource+"#/basePath", "host %q differs from %q of the first resource", u.Host, s.Host)
+	}
+	prefix := basePath
+	if strings.HasPrefix(basePath+"/", s.BasePath+"/") {
+		prefix = strings.TrimPrefix(basePath, s.BasePath)
+	} else {
+		c.lose(resource+"#/basePath", "basePath %q isn't within %q of the first resource", basePath, s.BasePath)
+	}
+	if s.Info.Version == "" {
+		s.Info.Version = decl.ApiVersion
+	}
+
+	for _, id := range mapkeys.Sorted(decl.Models) {
+		m := decl.Models[id]
+		def := c.model(resource+jsonpointer.Join("#/models", id), &m)
+		if existing, ok := s.Definitions[id]; ok {
+			if !reflect.DeepEqual(existing, def) {
+				c.lose(resource+jsonpointer.Join("#/models", id), "model %s differs from one declared by another resource, which is kept", id)
+			}
+			continue
+		}
+		if s.Definitions == nil {
+			s.Definitions = spec.Definitions{}
+		}
+		s.Definitions[id] = def
+		for _, child := range m.SubTypes {
+			subTypes[child] = id
+		}
+	}
+
+	var tags []string
+	if name := tagName(resource); name != "" {
+		tags = []string{name}
+	}
+	for j, api := range decl.Apis {
+		path := prefix + api.Path
+		item := s.Paths[path]
+		for k, op := range api.Operations {
+			ptr := resource + "#" + jsonpointer.Join("/apis", strconv.Itoa(j), "operations", strconv.Itoa(k))
+			converted, err := c.operation(ptr, decl, &op)
+			if err != nil {
+				return fmt.Errorf("%s %s: %v", op.Method, api.Path, err)
+			}
+			converted.Tags = tags
+			method := strings.ToLower(op.Method)
+			if item.Operation(method) != nil {
+				return fmt.Errorf("%s %s: operation declared twice", op.Method, path)
+			}
+			if !item.SetOperation(method, converted) {
+				return fmt.Errorf("%s %s: unknown method", op.Method, api.Path)
+			}
+		}
+		s.Paths[path] = item
+	}
+	return nil
+}
+
+func (c *converter12To2) operation(path string, decl *spec12.APIDeclaration, op *spec12.Operation) (*spec.Operation, error) {
+	out := &spec.Operation{
+		OperationId: op.Nickname,
+		Summary:     op.Summary,
+		Description: op.Notes,
+		Deprecated:  op.Deprecated == "true",
+		Consumes:    op.Consumes,
+		Produces:    op.Produces,
+		Responses:   spec.Responses{},
+	}
+	if out.Consumes == nil {
+		out.Consumes = decl.Consumes
+	}
+	if out.Produces == nil {
+		out.Produces = decl.Produces
+	}
+	// An empty map means the operation requires no authorization, unlike a
+	// missing one.
+	auths := op.Authorizations
+	if auths == nil {
+		auths = decl.Authorizations
+	}
+	if auths != nil {
+		out.Security = []spec.SecurityRequirement{}
+	}
+	for _, name := range mapkeys.Sorted(auths) {
+		scopes := []string{}
+		for _, scope := range auths[name] {
+			scopes = append(scopes, scope.Scope)
+		}
+		out.Security = append(out.Security, spec.SecurityRequirement{name: scopes})
+	}
+
+	for i, p := range op.Parameters {
+		converted, err := c.parameter(jsonpointer.Join(path, "parameters", strconv.Itoa(i)), &p)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %s: %v", p.Name, err)
+		}
+		out.Parameters = append(out.Parameters, converted)
+	}
+
+	result := c.schema(jsonpointer.Join(path, "type"), &op.DataType)
+	success := ""
+	for _, m := range op.ResponseMessages {
+		code := strconv.Itoa(m.Code)
+		resp := spec.Response{Description: m.Message}
+		if resp.Description == "" {
+			resp.Description = http.StatusText(m.Code)
+		}
+		if m.ResponseModel != "" {
+			resp.Schema = &spec.Schema{Ref: "#/definitions/" + jsonpointer.Escape(m.ResponseModel)}
+		}
+		if m.Code >= 200 && m.Code < 300 && success == "" {
+			success = code
+			if resp.Schema == nil {
+				resp.Schema = result
+			}
+		}
+		out.Responses[code] = resp
+	}
+	if success == "" {
+		out.Responses["200"] = spec.Response{Description: http.StatusText(http.StatusOK), Schema: result}
+	}
+	return out, nil
+}
+
+func (c *converter12To2) parameter(path string, p *spec12.Parameter) (spec.Parameter, error) {
+	out := spec.Parameter{
+		Name:        p.Name,
+		Description: p.Description,
+		Required:    p.Required,
+	}
+	switch p.ParamType {
+	case "path", "query", "header":
+		out.In = p.ParamType
+	case "form":
+		out.In = "formData"
+	case "body":
+		out.In = "body"
+		out.Schema = c.schema(path, &p.DataType)
+		return out, nil
+	default:
+		return spec.Parameter{}, fmt.Errorf("unknown paramType %q", p.ParamType)
+	}
+
+	s := c.schema(path, &p.DataType)
+	switch {
+	case s == nil:
+		c.lose(jsonpointer.Join(path, "type"), "parameter has no type, assuming string")
+		s = &spec.Schema{Type: "string"}
+	case s.Ref != "":
+		c.lose(jsonpointer.Join(path, "type"), "%s parameters can't be models, assuming string", p.ParamType)
+		s = &spec.Schema{Type: "string"}
+	case s.Type == "file" && out.In != "formData":
+		c.lose(jsonpointer.Join(path, "type"), "only form parameters can be files, assuming string")
+		s = &spec.Schema{Type: "string"}
+	}
+	if s.Type == "array" {
+		if s.Items == nil || s.Items.Ref != "" {
+			c.lose(jsonpointer.Join(path, "items"), "array items must be primitives, assuming strings")
+			s.Items = &spec.Schema{Type: "string"}
+		}
+		out.Items = &spec.Items{Type: s.Items.Type, Format: s.Items.Format}
+	}
+	out.Type = s.Type
+	out.Format = s.Format
+	out.Default = s.Default
+	out.Enum = s.Enum
+	out.Minimum = s.Minimum
+	out.Maximum = s.Maximum
+	out.UniqueItems = s.UniqueItems
+
+	if p.AllowMultiple && out.In != "formData" && out.Type != "array" {
+		// A list of values separated by commas.
+		out.Items = &spec.Items{Type: out.Type, Format: out.Format, Enum: out.Enum}
+		out.Type, out.Format, out.Enum = "array", "", nil
+		out.CollectionFormat = "csv"
+	}
+	return out, nil
+}
+
+func (c *converter12To2) model(path string, m *spec12.Model) spec.Schema {
+	out := spec.Schema{
+		Type:          "object",
+		Description:   m.Description,
+		Required:      m.Required,
+		Discriminator: m.Discriminator,
+	}
+	for _, name := range mapkeys.Sorted(m.Properties) {
+		prop := m.Properties[name]
+		s := c.schema(jsonpointer.Join(path, "properties", name), &prop.DataType)
+		if s == nil {
+			c.lose(jsonpointer.Join(path, "properties", name), "property has no type")
+			s = &spec.Schema{}
+		}
+		s.Description = prop.Description
+		if out.Properties == nil {
+			out.Properties = map[string]spec.Schema{}
+		}
+		out.Properties[name] = *s
+	}
+	return out
+}
+
+// primitives are the primitive types of 1.2. Any other type names a model.
+var primitives = map[string]bool{
+	"integer": true,
+	"number":  true,
+	"string":  true,
+	"boolean": true,
+}
+
+// schema converts a data type, returning nil for "void" or no type.
+func (c *converter12To2) schema(path string, t *spec12.DataType) *spec.Schema {
+	if t.Ref != "" {
+		return &spec.Schema{Ref: "#/definitions/" + jsonpointer.Escape(t.Ref)}
+	}
+	switch {
+	case t.Type == "" || t.Type == "void":
+		return nil
+	case t.Type == "File":
+		return &spec.Schema{Type: "file"}
+	case t.Type == "array":
+		s := &spec.Schema{Type: "array", UniqueItems: t.UniqueItems}
+		if it := t.Items; it != nil {
+			s.Items = c.schema(jsonpointer.Join(path, "items"), &spec12.DataType{Type: it.Type, Ref: it.Ref, Format: it.Format})
+		}
+		return s
+	case !primitives[t.Type]:
+		return &spec.Schema{Ref: "#/definitions/" + jsonpointer.Escape(t.Type)}
+	}
+
+	s := &spec.Schema{
+		Type:    t.Type,
+		Format:  t.Format,
+		Default: primitive(t.Type, t.DefaultValue),
+	}
+	for _, v := range t.Enum {
+		s.Enum = append(s.Enum, primitive(t.Type, v))
+	}
+	for _, bound := range []struct {
+		name  string
+		value string
+		dst   **float64
+	}{
+		{"minimum", t.Minimum, &s.Minimum},
+		{"maximum", t.Maximum, &s.Maximum},
+	} {
+		if bound.value == "" {
+			continue
+		}
+		f, err := strconv.ParseFloat(bound.value, 64)
+		if err != nil {
+			c.lose(jsonpointer.Join(path, bound.name), "invalid %s %q", bound.name, bound.value)
+			continue
+		}
+		*bound.dst = &f
+	}
+	return s
+}
+
+// primitive converts a value given as a string, such as a default value or one
+// of an enum, to the type encoding/json decodes values of the type as.
+func primitive(typ string, v interface{}) interface{} {
+	str, ok := v.(string)
+	if !ok {
+		return v
+	}
+	switch typ {
+	case "integer", "number":
+		if f, err := strconv.ParseFloat(str, 64); err == nil {
+			return f
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(str); err == nil {
+			return b
+		}
+	}
+	return v
+}
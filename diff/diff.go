@@ -0,0 +1,448 @@
+/*
+Package diff compares two versions of a document and reports what changed
+between them.
+
+Every added, removed or changed path, operation, parameter, response, schema
+property and constraint is reported, classified by its effect on clients
+written against the old version:
+
+	changes := diff.Compare(old, new)
+	if len(diff.Filter(changes, diff.Breaking)) > 0 {
+		// Fail the build.
+	}
+
+Whether a schema change breaks clients depends on which way the data flows.
+Tightening a constraint, such as lowering a maximum, breaks clients sending
+data which used to be valid, while loosening one breaks clients reading
+responses they don't expect. Schemas of parameters and request bodies are
+classified as requests, and those of responses as responses. Definitions are
+compared once, and classified by every operation of either version which
+refers to them. Definitions no operation refers to are classified as both.
+*/
+package diff
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/internal/jsonpointer"
+	"github.com/ericchiang/swaggopher/internal/mapkeys"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// Kind is what happened to a part of the document.
+type Kind int
+
+const (
+	Added Kind = iota
+	Removed
+	Changed
+)
+
+var kindNames = []string{"added", "removed", "changed"}
+
+func (k Kind) String() string {
+	if k < 0 || int(k) >= len(kindNames) {
+		return fmt.Sprintf("Kind(%d)", int(k))
+	}
+	return kindNames[k]
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (k Kind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// Severity is the effect of a change on existing clients. Severities are
+// ordered, so a build can fail on changes at or above a threshold.
+type Severity int
+
+const (
+	// NonBreaking changes don't affect existing clients.
+	NonBreaking Severity = iota
+	// Deprecation changes warn that a part of the API will be removed.
+	Deprecation
+	// Breaking changes may cause existing clients to fail.
+	Breaking
+)
+
+var severityNames = []string{"non-breaking", "deprecation", "breaking"}
+
+func (s Severity) String() string {
+	if s < 0 || int(s) >= len(severityNames) {
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+	return severityNames[s]
+}
+
+// ParseSeverity parses the name of a severity: "non-breaking", "deprecation"
+// or "breaking".
+func ParseSeverity(name string) (Severity, error) {
+	for i, n := range severityNames {
+		if n == name {
+			return Severity(i), nil
+		}
+	}
+	return NonBreaking, fmt.Errorf("diff: unknown severity %q, must be one of %s", name, strings.Join(severityNames, ", "))
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Change is a single difference between two documents.
+type Change struct {
+	Kind     Kind     `json:"kind"`
+	Severity Severity `json:"severity"`
+	// Path is a JSON pointer to the changed value, in the new document unless
+	// it was removed.
+	Path string `json:"path"`
+	// Message describes the change.
+	Message string `json:"message"`
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s (%s)", c.Path, c.Message, c.Severity)
+}
+
+// Filter returns the changes of at least a severity.
+func Filter(changes []Change, min Severity) []Change {
+	var filtered []Change
+	for _, c := range changes {
+		if c.Severity >= min {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered
+}
+
+// Compare reports the differences between two versions of a document, in
+// document order.
+func Compare(old, new *spec.Swagger) []Change {
+	d := &differ{old: old, new: new}
+	if old.BasePath != new.BasePath {
+		d.report(Changed, Breaking, "/basePath", "base path changed from %q to %q", old.BasePath, new.BasePath)
+	}
+	d.paths()
+	d.definitions()
+	return d.changes
+}
+
+type differ struct {
+	old, new *spec.Swagger
+	changes  []Change
+}
+
+func (d *differ) report(kind Kind, sev Severity, path, format string, v ...interface{}) {
+	d.changes = append(d.changes, Change{Kind: kind, Severity: sev, Path: path, Message: fmt.Sprintf(format, v...)})
+}
+
+var pathParam = regexp.MustCompile(`\{[^}]*\}`)
+
+// templates maps path templates, with their parameters' names removed, to the
+// templates of a document. Renaming a path parameter doesn't change the
+// requests a path matches.
+func templates(s *spec.Swagger) map[string]string {
+	m := make(map[string]string)
+	for path := range s.Paths {
+		m[pathParam.ReplaceAllString(path, "{}")] = path
+	}
+	return m
+}
+
+func (d *differ) paths() {
+	oldPaths, newPaths := templates(d.old), templates(d.new)
+	var keys []string
+	for key := range oldPaths {
+		keys = append(keys, key)
+	}
+	for key := range newPaths {
+		if _, ok := oldPaths[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		oldPath, inOld := oldPaths[key]
+		newPath, inNew := newPaths[key]
+		switch {
+		case !inNew:
+			d.report(Removed, Breaking, jsonpointer.Join("/paths", oldPath), "path removed")
+		case !inOld:
+			d.report(Added, NonBreaking, jsonpointer.Join("/paths", newPath), "path added")
+		default:
+			oldItem, newItem := d.old.Paths[oldPath], d.new.Paths[newPath]
+			renames := renamedParams(oldPath, newPath)
+			for _, method := range spec.Methods {
+				d.operation(oldPath, newPath, method, &oldItem, &newItem, renames)
+			}
+		}
+	}
+}
+
+// renamedParams maps the names of path parameters in the old template to their
+// names in the new one.
+func renamedParams(oldPath, newPath string) map[string]string {
+	oldNames := pathParam.FindAllString(oldPath, -1)
+	newNames := pathParam.FindAllString(newPath, -1)
+	renames := make(map[string]string)
+	for i, name := range oldNames {
+		if i < len(newNames) {
+			renames[strings.Trim(name, "{}")] = strings.Trim(newNames[i], "{}")
+		}
+	}
+	return renames
+}
+
+func (d *differ) operation(oldPath, newPath, method string, oldItem, newItem *spec.PathItem, renames map[string]string) {
+	oldOp, newOp := oldItem.Operation(method), newItem.Operation(method)
+	oldPtr := jsonpointer.Join("/paths", oldPath, method)
+	path := jsonpointer.Join("/paths", newPath, method)
+	switch {
+	case oldOp == nil && newOp == nil:
+		return
+	case newOp == nil:
+		d.report(Removed, Breaking, oldPtr, "operation %s %s removed", strings.ToUpper(method), oldPath)
+		return
+	case oldOp == nil:
+		d.report(Added, NonBreaking, path, "operation %s %s added", strings.ToUpper(method), newPath)
+		return
+	}
+
+	switch {
+	case !oldOp.Deprecated && newOp.Deprecated:
+		d.report(Changed, Deprecation, jsonpointer.Join(path, "deprecated"), "operation deprecated")
+	case oldOp.Deprecated && !newOp.Deprecated:
+		d.report(Changed, NonBreaking, jsonpointer.Join(path, "deprecated"), "operation no longer deprecated")
+	}
+	switch {
+	case oldOp.OperationId == newOp.OperationId:
+	case oldOp.OperationId == "":
+		d.report(Added, NonBreaking, jsonpointer.Join(path, "operationId"), "operation ID %q added", newOp.OperationId)
+	default:
+		// IDs aren't sent over the wire, but name the methods of generated
+		// clients.
+		d.report(Changed, Breaking, jsonpointer.Join(path, "operationId"), "operation ID changed from %q to %q", oldOp.OperationId, newOp.OperationId)
+	}
+
+	oldParams := params(d.old, oldItem.Parameters, oldOp.Parameters, jsonpointer.Join("/paths", oldPath), oldPtr)
+	newParams := params(d.new, newItem.Parameters, newOp.Parameters, jsonpointer.Join("/paths", newPath), path)
+	d.parameters(oldParams, newParams, renames)
+	if hasBody(oldParams) {
+		d.mediaTypes(jsonpointer.Join(path, "consumes"), "request", orDefault(oldOp.Consumes, d.old.Consumes), orDefault(newOp.Consumes, d.new.Consumes))
+	}
+	d.mediaTypes(jsonpointer.Join(path, "produces"), "response", orDefault(oldOp.Produces, d.old.Produces), orDefault(newOp.Produces, d.new.Produces))
+	d.responses(oldPtr, path, oldOp, newOp)
+}
+
+func orDefault(types, def []string) []string {
+	if types == nil {
+		return def
+	}
+	return types
+}
+
+// mediaTypes reports media types which are no longer accepted or produced.
+func (d *differ) mediaTypes(path, kind string, old, new []string) {
+	newTypes := make(map[string]bool)
+	for _, t := range new {
+		newTypes[t] = true
+	}
+	oldTypes := make(map[string]bool)
+	for _, t := range old {
+		oldTypes[t] = true
+		if !newTypes[t] {
+			d.report(Removed, Breaking, path, "%s media type %q removed", kind, t)
+		}
+	}
+	for _, t := range new {
+		if !oldTypes[t] {
+			d.report(Added, NonBreaking, path, "%s media type %q added", kind, t)
+		}
+	}
+}
+
+// param is a parameter of an operation and a pointer to where it's declared.
+type param struct {
+	*spec.Parameter
+	path string
+}
+
+// params returns the parameters of an operation, including those of its path,
+// keyed by location and name.
+func params(s *spec.Swagger, itemParams, opParams []spec.Parameter, itemPath, opPath string) map[string]param {
+	m := make(map[string]param)
+	add := func(list []spec.Parameter, path string) {
+		for i := range list {
+			p := &list[i]
+			if name := strings.TrimPrefix(p.Ref, "#/parameters/"); name != p.Ref {
+				if def, ok := s.Parameters[jsonpointer.Unescape(name)]; ok {
+					p = &def
+				}
+			}
+			m[p.In+" "+p.Name] = param{p, jsonpointer.Join(path, "parameters", fmt.Sprint(i))}
+		}
+	}
+	add(itemParams, itemPath)
+	add(opParams, opPath)
+	return m
+}
+
+// hasBody reports whether an operation accepts a request body, whose media
+// type it consumes.
+func hasBody(params map[string]param) bool {
+	for _, p := range params {
+		if p.In == "body" || p.In == "formData" {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *differ) parameters(old, new map[string]param, renames map[string]string) {
+	// Match path parameters by position, so renaming them isn't a change.
+	matched := make(map[string]param)
+	for key, p := range old {
+		if p.In == "path" {
+			if name, ok := renames[p.Name]; ok {
+				key = "path " + name
+			}
+		}
+		matched[key] = p
+	}
+
+	for _, key := range mapkeys.Sorted(matched) {
+		o := matched[key]
+		n, ok := new[key]
+		if !ok {
+			d.report(Removed, Breaking, o.path, "%s parameter %q removed", o.In, o.Name)
+			continue
+		}
+		switch {
+		case !o.Required && n.Required:
+			d.report(Changed, Breaking, jsonpointer.Join(n.path, "required"), "%s parameter %q became required", n.In, n.Name)
+		case o.Required && !n.Required:
+			d.report(Changed, NonBreaking, jsonpointer.Join(n.path, "required"), "%s parameter %q became optional", n.In, n.Name)
+		}
+		if o.In == "body" {
+			d.schema(n.path+"/schema", request, o.Schema, n.Schema)
+			continue
+		}
+		if o.CollectionFormat != n.CollectionFormat {
+			d.report(Changed, Breaking, jsonpointer.Join(n.path, "collectionFormat"), "collection format changed from %q to %q", o.CollectionFormat, n.CollectionFormat)
+		}
+		d.schema(n.path, request, o.ValueSchema(), n.ValueSchema())
+	}
+	for _, key := range mapkeys.Sorted(new) {
+		if _, ok := matched[key]; ok {
+			continue
+		}
+		n := new[key]
+		if n.Required {
+			d.report(Added, Breaking, n.path, "required %s parameter %q added", n.In, n.Name)
+		} else {
+			d.report(Added, NonBreaking, n.path, "%s parameter %q added", n.In, n.Name)
+		}
+	}
+}
+
+func (d *differ) responses(oldOpPath, opPath string, oldOp, newOp *spec.Operation) {
+	var codes []string
+	for code := range oldOp.Responses {
+		codes = append(codes, code)
+	}
+	for code := range newOp.Responses {
+		if _, ok := oldOp.Responses[code]; !ok {
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		o, inOld := lookupResponse(d.old, oldOp, code)
+		n, inNew := lookupResponse(d.new, newOp, code)
+		path := jsonpointer.Join(opPath, "responses", code)
+		switch {
+		case !inNew:
+			// Clients must already handle errors they don't expect.
+			sev := NonBreaking
+			if strings.HasPrefix(code, "2") || code == "default" {
+				sev = Breaking
+			}
+			d.report(Removed, sev, jsonpointer.Join(oldOpPath, "responses", code), "response %s removed", code)
+			continue
+		case !inOld:
+			d.report(Added, NonBreaking, path, "response %s added", code)
+			continue
+		}
+
+		switch {
+		case o.Schema != nil && n.Schema == nil:
+			d.report(Removed, Breaking, jsonpointer.Join(path, "schema"), "response %s body removed", code)
+		case o.Schema == nil && n.Schema != nil:
+			d.report(Added, NonBreaking, jsonpointer.Join(path, "schema"), "response %s body added", code)
+		case o.Schema != nil:
+			d.schema(jsonpointer.Join(path, "schema"), response, o.Schema, n.Schema)
+		}
+
+		for _, name := range mapkeys.Sorted(o.Headers) {
+			oh := o.Headers[name]
+			nh, ok := n.Headers[name]
+			if !ok {
+				d.report(Removed, Breaking, jsonpointer.Join(oldOpPath, "responses", code, "headers", name), "response %s header %q removed", code, name)
+				continue
+			}
+			d.schema(jsonpointer.Join(path, "headers", name), response, oh.ValueSchema(), nh.ValueSchema())
+		}
+		for _, name := range mapkeys.Sorted(n.Headers) {
+			if _, ok := o.Headers[name]; !ok {
+				d.report(Added, NonBreaking, jsonpointer.Join(path, "headers", name), "response %s header %q added", code, name)
+			}
+		}
+	}
+}
+
+// lookupResponse returns an operation's response for a status code, following
+// references to the document's responses.
+func lookupResponse(s *spec.Swagger, op *spec.Operation, code string) (spec.Response, bool) {
+	resp, ok := op.Responses[code]
+	if !ok {
+		return resp, false
+	}
+	if name := strings.TrimPrefix(resp.Ref, "#/responses/"); name != resp.Ref {
+		if def, ok := s.Responses[jsonpointer.Unescape(name)]; ok {
+			return def, true
+		}
+	}
+	return resp, true
+}
+
+func (d *differ) definitions() {
+	use := uses(d.old)
+	for name, dir := range uses(d.new) {
+		use[name] |= dir
+	}
+	for _, name := range mapkeys.Sorted(d.old.Definitions) {
+		path := jsonpointer.Join("/definitions", name)
+		n, ok := d.new.Definitions[name]
+		if !ok {
+			// Definitions only matter to clients through the operations
+			// which refer to them, whose changes are reported separately.
+			d.report(Removed, NonBreaking, path, "definition %q removed", name)
+			continue
+		}
+		dir := use[name]
+		if dir == 0 {
+			dir = request | response
+		}
+		o := d.old.Definitions[name]
+		d.schema(path, dir, &o, &n)
+	}
+	for _, name := range mapkeys.Sorted(d.new.Definitions) {
+		if _, ok := d.old.Definitions[name]; !ok {
+			d.report(Added, NonBreaking, jsonpointer.Join("/definitions", name), "definition %q added", name)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package diff
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+const petstore = `
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+basePath: /v1
+consumes: [application/json]
+produces: [application/json]
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      parameters:
+      - {name: limit, in: query, type: integer, maximum: 100}
+      - {name: status, in: query, type: string, enum: [available, sold]}
+      responses:
+        200:
+          description: Pets.
+          schema: {type: array, items: {$ref: '#/definitions/Pet'}}
+          headers:
+            X-Total: {type: integer}
+    post:
+      operationId: createPet
+      parameters:
+      - {name: pet, in: body, required: true, schema: {$ref: '#/definitions/NewPet'}}
+      responses:
+        201: {description: Created.}
+        400: {description: Invalid pet.}
+  /pets/{id}:
+    parameters:
+    - {name: id, in: path, required: true, type: integer}
+    get:
+      operationId: getPet
+      responses:
+        200: {description: A pet., schema: {$ref: '#/definitions/Pet'}}
+    delete:
+      operationId: deletePet
+      responses:
+        204: {description: Deleted.}
+definitions:
+  Pet:
+    type: object
+    required: [id, name]
+    properties:
+      id: {type: integer, format: int64}
+      name: {type: string}
+      status: {type: string, enum: [available, sold]}
+  NewPet:
+    type: object
+    required: [name]
+    properties:
+      name: {type: string, maxLength: 50}
+      tag: {type: string}
+`
+
+func parse(t *testing.T, data string) *spec.Swagger {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(data), &s); err != nil {
+		t.Fatal(err)
+	}
+	return &s
+}
+
+func TestCompare(t *testing.T) {
+	old := parse(t, petstore)
+	if got := Compare(old, parse(t, petstore)); len(got) != 0 {
+		t.Errorf("expected no changes between identical documents, got %v", got)
+	}
+
+	new := parse(t, `
+swagger: "2.0"
+info: {title: Pets, version: "2.0"}
+basePath: /v1
+consumes: [application/json]
+produces: [application/json, application/xml]
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      parameters:
+      - {name: limit, in: query, type: integer, maximum: 50}
+      - {name: status, in: query, type: string, enum: [available, sold, pending]}
+      - {name: owner, in: query, required: true, type: string}
+      responses:
+        200:
+          description: Pets.
+          schema: {type: array, items: {$ref: '#/definitions/Pet'}}
+    post:
+      operationId: createPet
+      deprecated: true
+      parameters:
+      - {name: pet, in: body, required: true, schema: {$ref: '#/definitions/NewPet'}}
+      responses:
+        201: {description: Created.}
+  /pets/{petId}:
+    parameters:
+    - {name: petId, in: path, required: true, type: integer}
+    get:
+      operationId: getPet
+      responses:
+        200: {description: A pet., schema: {$ref: '#/definitions/Pet'}}
+  /owners:
+    get:
+      operationId: listOwners
+      responses:
+        200: {description: Owners.}
+definitions:
+  Pet:
+    type: object
+    required: [id]
+    properties:
+      id: {type: integer, format: int64}
+      name: {type: string}
+      status: {type: string, enum: [available, sold, pending]}
+      age: {type: integer}
+  NewPet:
+    type: object
+    required: [name]
+    properties:
+      name: {type: string, maxLength: 100}
+`)
+	want := []Change{
+		{Kind: Added, Severity: NonBreaking, Path: "/paths/~1owners", Message: "path added"},
+		{Kind: Changed, Severity: Breaking, Path: "/paths/~1pets/get/parameters/0/maximum", Message: "maximum changed from 100 to 50"},
+		{Kind: Changed, Severity: NonBreaking, Path: "/paths/~1pets/get/parameters/1/enum", Message: "enum value pending added"},
+		{Kind: Added, Severity: Breaking, Path: "/paths/~1pets/get/parameters/2", Message: `required query parameter "owner" added`},
+		{Kind: Added, Severity: NonBreaking, Path: "/paths/~1pets/get/produces", Message: `response media type "application/xml" added`},
+		{Kind: Removed, Severity: Breaking, Path: "/paths/~1pets/get/responses/200/headers/X-Total", Message: `response 200 header "X-Total" removed`},
+		{Kind: Changed, Severity: Deprecation, Path: "/paths/~1pets/post/deprecated", Message: "operation deprecated"},
+		{Kind: Added, Severity: NonBreaking, Path: "/paths/~1pets/post/produces", Message: `response media type "application/xml" added`},
+		{Kind: Removed, Severity: NonBreaking, Path: "/paths/~1pets/post/responses/400", Message: "response 400 removed"},
+		{Kind: Added, Severity: NonBreaking, Path: "/paths/~1pets~1{petId}/get/produces", Message: `response media type "application/xml" added`},
+		{Kind: Removed, Severity: Breaking, Path: "/paths/~1pets~1{id}/delete", Message: "operation DELETE /pets/{id} removed"},
+		// NewPet is only sent, so loosening it is safe.
+		{Kind: Changed, Severity: NonBreaking, Path: "/definitions/NewPet/properties/name/maxLength", Message: "maxLength changed from 50 to 100"},
+		{Kind: Removed, Severity: Breaking, Path: "/definitions/NewPet/properties/tag", Message: `property "tag" removed`},
+		// Pet is only read, so loosening it isn't.
+		{Kind: Changed, Severity: Breaking, Path: "/definitions/Pet/properties/status/enum", Message: "enum value pending added"},
+		{Kind: Added, Severity: NonBreaking, Path: "/definitions/Pet/properties/age", Message: `property "age" added`},
+		{Kind: Changed, Severity: Breaking, Path: "/definitions/Pet/required", Message: `property "name" became optional`},
+	}
+	got := Compare(old, new)
+	if diff := pretty.Compare(want, got); diff != "" {
+		t.Errorf("changes: %s", diff)
+	}
+
+	var breaking []Change
+	for _, c := range want {
+		if c.Severity == Breaking {
+			breaking = append(breaking, c)
+		}
+	}
+	if diff := pretty.Compare(breaking, Filter(got, Breaking)); diff != "" {
+		t.Errorf("breaking changes: %s", diff)
+	}
+}
+
+func TestCompareSchemas(t *testing.T) {
+	tests := []struct {
+		old, new string
+		dir      direction
+		want     []Change
+	}{
+		{
+			old:  `{type: integer, format: int32}`,
+			new:  `{type: integer, format: int64}`,
+			dir:  request,
+			want: []Change{{Kind: Changed, Severity: NonBreaking, Path: "/format", Message: `format changed from "int32" to "int64"`}},
+		},
+		{
+			old:  `{type: integer, format: int32}`,
+			new:  `{type: integer, format: int64}`,
+			dir:  response,
+			want: []Change{{Kind: Changed, Severity: Breaking, Path: "/format", Message: `format changed from "int32" to "int64"`}},
+		},
+		{
+			old:  `{type: string}`,
+			new:  `{type: integer, minimum: 1}`,
+			dir:  request | response,
+			want: []Change{{Kind: Changed, Severity: Breaking, Path: "/type", Message: "type changed from string to integer"}},
+		},
+		{
+			old: `{type: object, properties: {a: {type: string}}}`,
+			new: `{type: object, required: [a], properties: {a: {type: string}}, additionalProperties: false}`,
+			dir: response,
+			want: []Change{
+				{Kind: Changed, Severity: NonBreaking, Path: "/required", Message: `property "a" became required`},
+				{Kind: Changed, Severity: NonBreaking, Path: "/additionalProperties", Message: "additional properties no longer allowed"},
+			},
+		},
+		{
+			old:  `{type: number, minimum: 0}`,
+			new:  `{type: number, minimum: 0, exclusiveMinimum: true}`,
+			dir:  request,
+			want: []Change{{Kind: Changed, Severity: Breaking, Path: "/minimum", Message: "minimum became exclusive"}},
+		},
+		{
+			old:  `{type: string, pattern: '^[a-z]+$'}`,
+			new:  `{type: string}`,
+			dir:  request,
+			want: []Change{{Kind: Changed, Severity: NonBreaking, Path: "/pattern", Message: `pattern changed from "^[a-z]+$" to ""`}},
+		},
+		{
+			old:  `{$ref: '#/definitions/Pet'}`,
+			new:  `{$ref: '#/definitions/Animal'}`,
+			dir:  request,
+			want: []Change{{Kind: Changed, Severity: Breaking, Path: "/$ref", Message: `reference changed from "#/definitions/Pet" to "#/definitions/Animal"`}},
+		},
+	}
+	for i, test := range tests {
+		var o, n spec.Schema
+		if err := yaml.Unmarshal([]byte(test.old), &o); err != nil {
+			t.Fatal(err)
+		}
+		if err := yaml.Unmarshal([]byte(test.new), &n); err != nil {
+			t.Fatal(err)
+		}
+		d := &differ{}
+		d.schema("", test.dir, &o, &n)
+		if diff := pretty.Compare(test.want, d.changes); diff != "" {
+			t.Errorf("case %d: %s", i, diff)
+		}
+	}
+}
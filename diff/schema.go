@@ -0,0 +1,334 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/internal/jsonpointer"
+	"github.com/ericchiang/swaggopher/internal/mapkeys"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// direction is the way data described by a schema flows.
+type direction int
+
+const (
+	// request schemas describe data clients send.
+	request direction = 1 << iota
+	// response schemas describe data clients read.
+	response
+)
+
+// severity classifies a schema change. Tightening breaks requests, as values
+// clients send may be rejected, and loosening breaks responses, as clients may
+// read values they don't expect.
+func severity(dir direction, tightens, loosens bool) Severity {
+	if (dir&request != 0 && tightens) || (dir&response != 0 && loosens) {
+		return Breaking
+	}
+	return NonBreaking
+}
+
+func (d *differ) constraint(path string, dir direction, tightens, loosens bool, format string, v ...interface{}) {
+	d.report(Changed, severity(dir, tightens, loosens), path, format, v...)
+}
+
+// schema compares two schemas describing the same value. References to
+// definitions aren't followed, as definitions are compared separately.
+func (d *differ) schema(path string, dir direction, o, n *spec.Schema) {
+	if o.Ref != "" || n.Ref != "" {
+		if o.Ref != n.Ref {
+			d.report(Changed, Breaking, jsonpointer.Join(path, "$ref"), "reference changed from %q to %q", o.Ref, n.Ref)
+		}
+		return
+	}
+
+	if o.Type != n.Type {
+		switch {
+		case o.Type == "":
+			d.constraint(jsonpointer.Join(path, "type"), dir, true, false, "type %s required", n.Type)
+		case n.Type == "":
+			d.constraint(jsonpointer.Join(path, "type"), dir, false, true, "type %s no longer required", o.Type)
+		case o.Type == "integer" && n.Type == "number":
+			d.constraint(jsonpointer.Join(path, "type"), dir, false, true, "type changed from integer to number")
+		default:
+			d.report(Changed, Breaking, jsonpointer.Join(path, "type"), "type changed from %s to %s", o.Type, n.Type)
+			return
+		}
+	}
+	if o.Format != n.Format {
+		wider, narrower := widerFormat(o.Format, n.Format), widerFormat(n.Format, o.Format)
+		if !wider && !narrower {
+			wider, narrower = true, true
+		}
+		d.constraint(jsonpointer.Join(path, "format"), dir, narrower, wider, "format changed from %q to %q", o.Format, n.Format)
+	}
+
+	d.enum(path, dir, o.Enum, n.Enum)
+	d.bounds(path, dir, o, n)
+
+	switch {
+	case o.Items != nil && n.Items != nil:
+		d.schema(jsonpointer.Join(path, "items"), dir, o.Items, n.Items)
+	case o.Items != nil:
+		d.constraint(jsonpointer.Join(path, "items"), dir, false, true, "items schema removed")
+	case n.Items != nil:
+		d.constraint(jsonpointer.Join(path, "items"), dir, true, false, "items schema added")
+	}
+
+	d.properties(path, dir, o, n)
+
+	for i := range n.AllOf {
+		p := jsonpointer.Join(path, "allOf", fmt.Sprint(i))
+		if i >= len(o.AllOf) {
+			d.constraint(p, dir, true, false, "allOf schema added")
+			continue
+		}
+		d.schema(p, dir, &o.AllOf[i], &n.AllOf[i])
+	}
+	for i := len(n.AllOf); i < len(o.AllOf); i++ {
+		d.constraint(jsonpointer.Join(path, "allOf", fmt.Sprint(i)), dir, false, true, "allOf schema removed")
+	}
+}
+
+func (d *differ) properties(path string, dir direction, o, n *spec.Schema) {
+	for _, name := range mapkeys.Sorted(o.Properties) {
+		p := jsonpointer.Join(path, "properties", name)
+		np, ok := n.Properties[name]
+		if !ok {
+			// Clients may send or read any property they know about.
+			d.report(Removed, Breaking, p, "property %q removed", name)
+			continue
+		}
+		op := o.Properties[name]
+		d.schema(p, dir, &op, &np)
+	}
+	for _, name := range mapkeys.Sorted(n.Properties) {
+		if _, ok := o.Properties[name]; !ok {
+			d.report(Added, NonBreaking, jsonpointer.Join(path, "properties", name), "property %q added", name)
+		}
+	}
+
+	oldRequired, newRequired := set(o.Required), set(n.Required)
+	for _, name := range n.Required {
+		if !oldRequired[name] {
+			d.constraint(jsonpointer.Join(path, "required"), dir, true, false, "property %q became required", name)
+		}
+	}
+	for _, name := range o.Required {
+		if !newRequired[name] {
+			d.constraint(jsonpointer.Join(path, "required"), dir, false, true, "property %q became optional", name)
+		}
+	}
+
+	oap, nap := o.AdditionalProperties, n.AdditionalProperties
+	switch p := jsonpointer.Join(path, "additionalProperties"); {
+	case allowsAny(oap) && !allowsAny(nap):
+		if nap.Schema != nil {
+			d.constraint(p, dir, true, false, "additional properties restricted to a schema")
+		} else {
+			d.constraint(p, dir, true, false, "additional properties no longer allowed")
+		}
+	case !allowsAny(oap) && allowsAny(nap):
+		d.constraint(p, dir, false, true, "any additional properties allowed")
+	case oap != nil && nap != nil && oap.Schema != nil && nap.Schema != nil:
+		d.schema(p, dir, oap.Schema, nap.Schema)
+	case oap != nil && nap != nil && (oap.Schema == nil) != (nap.Schema == nil):
+		// One forbids additional properties and the other allows some.
+		d.constraint(p, dir, nap.Schema == nil, oap.Schema == nil, "additional properties changed")
+	}
+}
+
+// allowsAny reports whether additionalProperties permits any value.
+func allowsAny(ap *spec.AdditionalProperties) bool {
+	return ap == nil || (ap.Allowed && ap.Schema == nil)
+}
+
+func (d *differ) enum(path string, dir direction, o, n []interface{}) {
+	if len(o) == 0 && len(n) == 0 {
+		return
+	}
+	p := jsonpointer.Join(path, "enum")
+	switch {
+	case len(o) == 0:
+		d.constraint(p, dir, true, false, "values restricted to an enum")
+		return
+	case len(n) == 0:
+		d.constraint(p, dir, false, true, "values no longer restricted to an enum")
+		return
+	}
+	oldValues, newValues := make(map[string]bool), make(map[string]bool)
+	for _, v := range o {
+		oldValues[enumKey(v)] = true
+	}
+	for _, v := range n {
+		newValues[enumKey(v)] = true
+	}
+	for _, v := range o {
+		if !newValues[enumKey(v)] {
+			d.constraint(p, dir, true, false, "enum value %v removed", v)
+		}
+	}
+	for _, v := range n {
+		if !oldValues[enumKey(v)] {
+			d.constraint(p, dir, false, true, "enum value %v added", v)
+		}
+	}
+}
+
+// enumKey converts numbers so that enum values decoded from JSON and YAML
+// compare equal.
+func enumKey(v interface{}) string {
+	switch n := v.(type) {
+	case int:
+		v = float64(n)
+	case int64:
+		v = float64(n)
+	}
+	return fmt.Sprintf("%#v", v)
+}
+
+func (d *differ) bounds(path string, dir direction, o, n *spec.Schema) {
+	d.bound(path, dir, "maximum", o.Maximum, o.ExclusiveMaximum, n.Maximum, n.ExclusiveMaximum, 1)
+	d.bound(path, dir, "minimum", o.Minimum, o.ExclusiveMinimum, n.Minimum, n.ExclusiveMinimum, -1)
+	d.maxLimit(path, dir, "maxLength", o.MaxLength, n.MaxLength)
+	d.minLimit(path, dir, "minLength", o.MinLength, n.MinLength)
+	d.maxLimit(path, dir, "maxItems", o.MaxItems, n.MaxItems)
+	d.minLimit(path, dir, "minItems", o.MinItems, n.MinItems)
+	d.maxLimit(path, dir, "maxProperties", o.MaxProperties, n.MaxProperties)
+	d.minLimit(path, dir, "minProperties", o.MinProperties, n.MinProperties)
+
+	if o.UniqueItems != n.UniqueItems {
+		d.constraint(jsonpointer.Join(path, "uniqueItems"), dir, n.UniqueItems, o.UniqueItems, "uniqueItems changed to %t", n.UniqueItems)
+	}
+	// Patterns and multiples can't be compared, so any change other than
+	// adding or removing one both tightens and loosens.
+	if o.Pattern != n.Pattern {
+		d.constraint(jsonpointer.Join(path, "pattern"), dir, n.Pattern != "", o.Pattern != "", "pattern changed from %q to %q", o.Pattern, n.Pattern)
+	}
+	if o.MultipleOf != n.MultipleOf {
+		d.constraint(jsonpointer.Join(path, "multipleOf"), dir, n.MultipleOf != 0, o.MultipleOf != 0, "multipleOf changed from %v to %v", o.MultipleOf, n.MultipleOf)
+	}
+}
+
+// bound compares an optional, possibly exclusive, bound. Sign is 1 for
+// maximums and -1 for minimums, so that a larger signed value is looser.
+func (d *differ) bound(path string, dir direction, name string, o *float64, oExcl bool, n *float64, nExcl bool, sign float64) {
+	path = jsonpointer.Join(path, name)
+	switch {
+	case o == nil && n == nil:
+	case o == nil:
+		d.constraint(path, dir, true, false, "%s %v added", name, *n)
+	case n == nil:
+		d.constraint(path, dir, false, true, "%s %v removed", name, *o)
+	case *o != *n:
+		looser := *n*sign > *o*sign
+		d.constraint(path, dir, !looser, looser, "%s changed from %v to %v", name, *o, *n)
+	case oExcl != nExcl:
+		d.constraint(path, dir, nExcl, oExcl, "%s became %s", name, exclusive(nExcl))
+	}
+}
+
+func exclusive(excl bool) string {
+	if excl {
+		return "exclusive"
+	}
+	return "inclusive"
+}
+
+// minLimit compares a lower bound on a count, whose zero value means no bound.
+func (d *differ) minLimit(path string, dir direction, name string, o, n int) {
+	if o == n {
+		return
+	}
+	looser := n < o
+	d.constraint(jsonpointer.Join(path, name), dir, !looser, looser, "%s changed from %d to %d", name, o, n)
+}
+
+// maxLimit compares an optional upper bound on a count. Zero is a bound, which
+// only allows empty values.
+func (d *differ) maxLimit(path string, dir direction, name string, o, n *int) {
+	path = jsonpointer.Join(path, name)
+	switch {
+	case o == nil && n == nil:
+	case o == nil:
+		d.constraint(path, dir, true, false, "%s %d added", name, *n)
+	case n == nil:
+		d.constraint(path, dir, false, true, "%s %d removed", name, *o)
+	case *o != *n:
+		looser := *n > *o
+		d.constraint(path, dir, !looser, looser, "%s changed from %d to %d", name, *o, *n)
+	}
+}
+
+// widerFormat reports if every value of the old format is valid in the new
+// one, such as int32 values read as int64.
+func widerFormat(o, n string) bool {
+	switch n {
+	case "":
+		return true
+	case "int64":
+		return o == "int32"
+	case "double":
+		return o == "float"
+	}
+	return false
+}
+
+func set(names []string) map[string]bool {
+	m := make(map[string]bool)
+	for _, name := range names {
+		m[name] = true
+	}
+	return m
+}
+
+// uses returns the directions in which each definition referred to by the
+// document's operations is used, including through other definitions.
+func uses(s *spec.Swagger) map[string]direction {
+	use := make(map[string]direction)
+	var visit func(schema *spec.Schema, dir direction)
+	visit = func(schema *spec.Schema, dir direction) {
+		if schema == nil {
+			return
+		}
+		if name := strings.TrimPrefix(schema.Ref, "#/definitions/"); name != schema.Ref {
+			name = jsonpointer.Unescape(name)
+			def, ok := s.Definitions[name]
+			if !ok || use[name]&dir == dir {
+				return
+			}
+			use[name] |= dir
+			visit(&def, dir)
+			return
+		}
+		visit(schema.Items, dir)
+		for i := range schema.AllOf {
+			visit(&schema.AllOf[i], dir)
+		}
+		for _, name := range mapkeys.Sorted(schema.Properties) {
+			p := schema.Properties[name]
+			visit(&p, dir)
+		}
+		if ap := schema.AdditionalProperties; ap != nil {
+			visit(ap.Schema, dir)
+		}
+	}
+	for path, item := range s.Paths {
+		item := item
+		for _, method := range spec.Methods {
+			op := item.Operation(method)
+			if op == nil {
+				continue
+			}
+			for _, p := range params(s, item.Parameters, op.Parameters, path, path) {
+				visit(p.Schema, request)
+			}
+			for code := range op.Responses {
+				resp, _ := lookupResponse(s, op, code)
+				visit(resp.Schema, response)
+			}
+		}
+	}
+	return use
+}
@@ -0,0 +1,542 @@
+/*
+Package markdown renders documents as Markdown, for reference documentation
+committed alongside an API's code or published to a wiki.
+
+Render writes an overview from the document's info, then a section for each
+tag describing its operations, and a section describing the definitions:
+
+	# Pet Store
+
+	- **Version:** 1.0
+	- **Base URL:** `https://pets.example.com/v1`
+
+	## pets
+
+	### GET /pets/{petId}
+
+	Get a pet.
+
+	#### Parameters
+
+	| Name | In | Type | Required | Description |
+	| --- | --- | --- | --- | --- |
+	| petId | path | integer (int64) | yes | The pet. |
+
+Tags are ordered as the document declares them. Operations with several tags
+are described in the section of each, and operations without tags in a final
+section of their own. Schemas which refer to definitions link to them, using
+the anchors GitHub gives headings, and the properties of inline objects are
+listed with the names of the properties holding them, such as "owner.name".
+*/
+package markdown
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/ericchiang/swaggopher/internal/jsonpointer"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// Options configures Render. The zero value is valid.
+type Options struct {
+	// Level is the level of the heading of the document's title, from 1 to
+	// 3, so the Markdown can be included in a larger page. It defaults to 1.
+	Level int
+}
+
+// Render renders a document as Markdown, using the default options. See
+// Options.Render.
+func Render(doc *spec.Swagger) ([]byte, error) {
+	return Options{}.Render(doc)
+}
+
+// Render renders a document as Markdown.
+func (o Options) Render(doc *spec.Swagger) ([]byte, error) {
+	if o.Level == 0 {
+		o.Level = 1
+	}
+	if o.Level < 1 || o.Level > 3 {
+		return nil, fmt.Errorf("markdown: heading level %d isn't between 1 and 3", o.Level)
+	}
+	r := &renderer{doc: doc, level: o.Level, slugs: make(map[string]int)}
+	r.plan()
+	return r.render(), nil
+}
+
+// section is the operations of a tag.
+type section struct {
+	name, description string
+	operations        []operation
+}
+
+type operation struct {
+	path, method string
+	op           *spec.Operation
+}
+
+type renderer struct {
+	doc   *spec.Swagger
+	level int
+	// sections and definitions hold what's rendered, in order.
+	sections    []section
+	definitions []string
+	// slugs counts the headings with each anchor, and anchors holds the
+	// anchors of the definitions' headings.
+	slugs   map[string]int
+	anchors map[string]string
+	b       bytes.Buffer
+}
+
+// untagged is the name of the section of operations without tags.
+const untagged = "Other operations"
+
+// plan orders the sections and definitions, and finds the anchors of the
+// definitions' headings, which depend on every heading before them.
+func (r *renderer) plan() {
+	index := make(map[string]int)
+	add := func(name, description string) {
+		if _, ok := index[name]; !ok {
+			index[name] = len(r.sections)
+			r.sections = append(r.sections, section{name: name, description: description})
+		}
+	}
+	for _, t := range r.doc.Tags {
+		add(t.Name, t.Description)
+	}
+	var used []string
+	var other []operation
+	r.doc.RangeOperations(func(path, method string, op *spec.Operation) bool {
+		if len(op.Tags) == 0 {
+			other = append(other, operation{path, method, op})
+		}
+		for _, tag := range op.Tags {
+			if _, ok := index[tag]; !ok {
+				used = append(used, tag)
+			}
+		}
+		return true
+	})
+	sort.Strings(used)
+	for _, tag := range used {
+		add(tag, "")
+	}
+	r.doc.RangeOperations(func(path, method string, op *spec.Operation) bool {
+		for _, tag := range op.Tags {
+			s := &r.sections[index[tag]]
+			s.operations = append(s.operations, operation{path, method, op})
+		}
+		return true
+	})
+	if len(other) > 0 {
+		r.sections = append(r.sections, section{name: untagged, operations: other})
+	}
+
+	for name := range r.doc.Definitions {
+		r.definitions = append(r.definitions, name)
+	}
+	sort.Strings(r.definitions)
+
+	r.slug(r.title())
+	for _, s := range r.sections {
+		r.slug(s.name)
+		for _, o := range s.operations {
+			r.slug(o.heading())
+			if len(r.parameters(o)) > 0 {
+				r.slug("Parameters")
+			}
+			if len(o.op.Responses) > 0 {
+				r.slug("Responses")
+			}
+		}
+	}
+	r.slug("Definitions")
+	r.anchors = make(map[string]string, len(r.definitions))
+	for _, name := range r.definitions {
+		r.anchors[name] = r.slug(name)
+	}
+}
+
+// slug returns the anchor GitHub gives a heading: its letters, digits,
+// hyphens and underscores in lower case, with spaces replaced by hyphens and,
+// for headings repeating an earlier one, a number appended.
+func (r *renderer) slug(heading string) string {
+	var b strings.Builder
+	for _, c := range strings.ToLower(heading) {
+		switch {
+		case unicode.IsLetter(c) || unicode.IsDigit(c) || c == '-' || c == '_':
+			b.WriteRune(c)
+		case c == ' ':
+			b.WriteByte('-')
+		}
+	}
+	slug := b.String()
+	n := r.slugs[slug]
+	r.slugs[slug]++
+	if n > 0 {
+		slug += "-" + strconv.Itoa(n)
+	}
+	return slug
+}
+
+func (r *renderer) title() string {
+	if r.doc.Info != nil && r.doc.Info.Title != "" {
+		return r.doc.Info.Title
+	}
+	return "API"
+}
+
+func (o operation) heading() string {
+	return strings.ToUpper(o.method) + " " + o.path
+}
+
+func (r *renderer) heading(level int, text string) {
+	fmt.Fprintf(&r.b, "%s %s\n\n", strings.Repeat("#", r.level+level), text)
+}
+
+func (r *renderer) paragraph(text string) {
+	if text = strings.TrimSpace(text); text != "" {
+		fmt.Fprintf(&r.b, "%s\n\n", text)
+	}
+}
+
+func (r *renderer) render() []byte {
+	r.heading(0, r.title())
+	r.overview()
+	for _, s := range r.sections {
+		r.heading(1, s.name)
+		r.paragraph(s.description)
+		for _, o := range s.operations {
+			r.operation(o)
+		}
+	}
+	if len(r.definitions) > 0 {
+		r.heading(1, "Definitions")
+		for _, name := range r.definitions {
+			def := r.doc.Definitions[name]
+			r.heading(2, name)
+			r.definition(&def)
+		}
+	}
+	return bytes.TrimSuffix(r.b.Bytes(), []byte("\n"))
+}
+
+func (r *renderer) overview() {
+	doc := r.doc
+	var facts []string
+	if info := doc.Info; info != nil {
+		r.paragraph(info.Description)
+		if info.Version != "" {
+			facts = append(facts, "**Version:** "+info.Version)
+		}
+	}
+	if doc.Host != "" || doc.BasePath != "" {
+		schemes := doc.Schemes
+		if doc.Host == "" {
+			schemes = []string{""}
+		} else if len(schemes) == 0 {
+			schemes = []string{"https"}
+		}
+		var urls []string
+		for _, scheme := range schemes {
+			url := doc.Host + doc.BasePath
+			if scheme != "" {
+				url = scheme + "://" + url
+			}
+			urls = append(urls, code(url))
+		}
+		facts = append(facts, "**Base URL:** "+strings.Join(urls, ", "))
+	}
+	if info := doc.Info; info != nil {
+		if ct := info.Contact; ct != nil {
+			contact := link(ct.Name, ct.Url)
+			if ct.Email != "" {
+				if contact != "" {
+					contact += " "
+				}
+				contact += "<" + ct.Email + ">"
+			}
+			if contact != "" {
+				facts = append(facts, "**Contact:** "+contact)
+			}
+		}
+		if l := info.License; l != nil && l.Name != "" {
+			facts = append(facts, "**License:** "+link(l.Name, l.Url))
+		}
+		if info.TermsOfService != "" {
+			facts = append(facts, "**Terms of service:** "+info.TermsOfService)
+		}
+	}
+	if doc.ExternalDocs != nil && doc.ExternalDocs.Url != "" {
+		facts = append(facts, "**Documentation:** "+link(doc.ExternalDocs.Description, doc.ExternalDocs.Url))
+	}
+	for _, fact := range facts {
+		fmt.Fprintf(&r.b, "- %s\n", fact)
+	}
+	if len(facts) > 0 {
+		r.b.WriteString("\n")
+	}
+}
+
+// link returns a Markdown link, or just the text or URL if the other is
+// empty.
+func link(text, url string) string {
+	switch {
+	case url == "":
+		return text
+	case text == "":
+		return "<" + url + ">"
+	}
+	return "[" + text + "](" + url + ")"
+}
+
+func code(s string) string {
+	return "`" + s + "`"
+}
+
+func (r *renderer) operation(o operation) {
+	op := o.op
+	r.heading(2, o.heading())
+	if op.Deprecated {
+		r.paragraph("**Deprecated.**")
+	}
+	r.paragraph(op.Summary)
+	if op.Description != op.Summary {
+		r.paragraph(op.Description)
+	}
+	if op.OperationId != "" {
+		r.paragraph("Operation ID: " + code(op.OperationId))
+	}
+
+	if params := r.parameters(o); len(params) > 0 {
+		r.heading(3, "Parameters")
+		rows := make([][]string, 0, len(params))
+		for _, p := range params {
+			required := ""
+			if p.Required || p.In == "path" {
+				required = "yes"
+			}
+			rows = append(rows, []string{p.Name, p.In, r.typeOf(p.ValueSchema()), required, describe(p.Description, p.Enum, p.Default, false)})
+		}
+		r.table([]string{"Name", "In", "Type", "Required", "Description"}, rows)
+	}
+
+	if len(op.Responses) > 0 {
+		r.heading(3, "Responses")
+		codes := make([]string, 0, len(op.Responses))
+		for code := range op.Responses {
+			codes = append(codes, code)
+		}
+		// Status codes are ordered numerically, with the default last.
+		sort.Slice(codes, func(i, j int) bool {
+			if (codes[i] == "default") != (codes[j] == "default") {
+				return codes[j] == "default"
+			}
+			return codes[i] < codes[j]
+		})
+		rows := make([][]string, 0, len(codes))
+		for _, c := range codes {
+			resp := r.response(op.Responses[c])
+			rows = append(rows, []string{c, resp.Description, r.typeOf(resp.Schema)})
+		}
+		r.table([]string{"Code", "Description", "Schema"}, rows)
+	}
+}
+
+// parameters returns the parameters of an operation and of its path, with
+// references resolved, in the order they're declared. Operation parameters
+// override path parameters with the same name and location.
+func (r *renderer) parameters(o operation) []spec.Parameter {
+	var out []spec.Parameter
+	index := make(map[string]int)
+	for _, list := range [][]spec.Parameter{r.doc.Paths[o.path].Parameters, o.op.Parameters} {
+		for _, p := range list {
+			p = r.parameter(p)
+			key := p.In + "/" + p.Name
+			if i, ok := index[key]; ok {
+				out[i] = p
+				continue
+			}
+			index[key] = len(out)
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// parameter returns the parameter a reference refers to. Unresolved
+// references are rendered as parameters named after them.
+func (r *renderer) parameter(p spec.Parameter) spec.Parameter {
+	if q, ok := r.doc.ResolveParameter(&p); ok {
+		return *q
+	}
+	return spec.Parameter{Name: p.Ref}
+}
+
+func (r *renderer) response(resp spec.Response) spec.Response {
+	if resp.Ref == "" {
+		return resp
+	}
+	if target, ok := r.doc.Responses[jsonpointer.Unescape(strings.TrimPrefix(resp.Ref, "#/responses/"))]; ok && strings.HasPrefix(resp.Ref, "#/responses/") {
+		return target
+	}
+	return spec.Response{Description: code(resp.Ref)}
+}
+
+// typeOf describes the type of a schema, linking to the definitions it refers
+// to.
+func (r *renderer) typeOf(s *spec.Schema) string {
+	switch {
+	case s == nil:
+		return ""
+	case s.Ref != "":
+		name := jsonpointer.Unescape(strings.TrimPrefix(s.Ref, "#/definitions/"))
+		if anchor, ok := r.anchors[name]; ok && strings.HasPrefix(s.Ref, "#/definitions/") {
+			return "[" + name + "](#" + anchor + ")"
+		}
+		return code(s.Ref)
+	case len(s.AllOf) > 0:
+		parts := make([]string, 0, len(s.AllOf))
+		for i := range s.AllOf {
+			parts = append(parts, r.typeOf(&s.AllOf[i]))
+		}
+		return strings.Join(parts, " and ")
+	case s.Type == "array":
+		if s.Items == nil {
+			return "array"
+		}
+		return "array of " + r.typeOf(s.Items)
+	case len(s.Properties) == 0 && s.AdditionalProperties != nil && s.AdditionalProperties.Schema != nil:
+		return "map of " + r.typeOf(s.AdditionalProperties.Schema)
+	case s.Type == "" && len(s.Properties) > 0:
+		return "object"
+	case s.Type == "":
+		return "any"
+	case s.Format != "":
+		return s.Type + " (" + s.Format + ")"
+	}
+	return s.Type
+}
+
+// describe returns the description of a parameter or property, followed by
+// the values it's limited to, its default and whether it's read-only.
+func describe(description string, enum []interface{}, def interface{}, readOnly bool) string {
+	parts := []string{strings.TrimSpace(description)}
+	if len(enum) > 0 {
+		values := make([]string, 0, len(enum))
+		for _, v := range enum {
+			values = append(values, code(literal(v)))
+		}
+		parts = append(parts, "One of "+strings.Join(values, ", ")+".")
+	}
+	if def != nil {
+		parts = append(parts, "Default: "+code(literal(def))+".")
+	}
+	if readOnly {
+		parts = append(parts, "Read-only.")
+	}
+	return strings.TrimSpace(strings.Join(parts, " "))
+}
+
+// literal formats a value from a document as JSON, or strings as they are.
+func literal(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+func (r *renderer) definition(s *spec.Schema) {
+	r.paragraph(s.Description)
+	var includes []string
+	for i := range s.AllOf {
+		if s.AllOf[i].Ref != "" {
+			includes = append(includes, r.typeOf(&s.AllOf[i]))
+		}
+	}
+	if len(includes) > 0 {
+		r.paragraph("Includes the properties of " + strings.Join(includes, ", ") + ".")
+	}
+	var rows [][]string
+	r.properties(&rows, "", s)
+	if len(rows) == 0 {
+		if len(includes) == 0 {
+			r.paragraph("Type: " + r.typeOf(s) + ". " + describe("", s.Enum, s.Default, false))
+		}
+		return
+	}
+	r.table([]string{"Property", "Type", "Required", "Description"}, rows)
+}
+
+// properties adds a row for each property of a schema, and of the inline
+// objects it holds, to rows. The properties of the inline members of allOf
+// are the schema's own.
+func (r *renderer) properties(rows *[][]string, prefix string, s *spec.Schema) {
+	required := make(map[string]bool)
+	props := make(map[string]spec.Schema)
+	for _, part := range append([]spec.Schema{*s}, s.AllOf...) {
+		if part.Ref != "" {
+			continue
+		}
+		for _, name := range part.Required {
+			required[name] = true
+		}
+		for name, prop := range part.Properties {
+			props[name] = prop
+		}
+	}
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		prop := props[name]
+		req := ""
+		if required[name] {
+			req = "yes"
+		}
+		*rows = append(*rows, []string{prefix + name, r.typeOf(&prop), req, describe(prop.Description, prop.Enum, prop.Default, prop.ReadOnly)})
+		// Inline objects, and arrays of them, have no heading to link to.
+		switch {
+		case prop.Ref == "" && len(prop.Properties) > 0:
+			r.properties(rows, prefix+name+".", &prop)
+		case prop.Type == "array" && prop.Items != nil && prop.Items.Ref == "" && len(prop.Items.Properties) > 0:
+			r.properties(rows, prefix+name+"[].", prop.Items)
+		}
+	}
+}
+
+func (r *renderer) table(header []string, rows [][]string) {
+	r.row(header)
+	sep := make([]string, len(header))
+	for i := range sep {
+		sep[i] = "---"
+	}
+	r.row(sep)
+	for _, row := range rows {
+		r.row(row)
+	}
+	r.b.WriteString("\n")
+}
+
+// cellEscaper keeps text within a table cell: pipes end cells and newlines end
+// rows.
+var cellEscaper = strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>")
+
+func (r *renderer) row(cells []string) {
+	r.b.WriteString("|")
+	for _, cell := range cells {
+		r.b.WriteString(" " + cellEscaper.Replace(strings.TrimSpace(cell)) + " |")
+	}
+	r.b.WriteString("\n")
+}
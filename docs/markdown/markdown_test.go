@@ -0,0 +1,246 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+const petstore = `
+swagger: "2.0"
+info:
+  title: Pet Store
+  description: Pets for sale.
+  version: "1.0"
+  contact: {name: Pet Team, email: pets@example.com}
+  license: {name: MIT, url: 'https://opensource.org/licenses/MIT'}
+host: pets.example.com
+basePath: /v1
+tags:
+  - {name: pets, description: Everything about pets.}
+  - {name: Pet}
+paths:
+  /pets:
+    get:
+      tags: [pets]
+      summary: List pets.
+      operationId: listPets
+      parameters:
+        - {name: limit, in: query, type: integer, format: int32, default: 20, description: Pets per page.}
+        - {name: status, in: query, type: array, items: {type: string}, enum: [available, sold]}
+      responses:
+        200:
+          description: The pets.
+          schema:
+            type: array
+            items: {$ref: '#/definitions/Pet'}
+        default: {$ref: '#/responses/Error'}
+    post:
+      tags: [pets, admin]
+      operationId: createPet
+      deprecated: true
+      parameters:
+        - {name: pet, in: body, required: true, schema: {$ref: '#/definitions/Pet'}}
+      responses:
+        201: {description: Created., schema: {$ref: '#/definitions/Pet'}}
+  /pets/{petId}:
+    parameters:
+      - {$ref: '#/parameters/petId'}
+    get:
+      summary: Get a pet.
+      description: |-
+        Get a pet
+        by its ID.
+      responses:
+        200: {description: The pet., schema: {$ref: '#/definitions/Pet'}}
+parameters:
+  petId: {name: petId, in: path, type: integer, format: int64, description: The pet.}
+responses:
+  Error: {description: An error., schema: {type: object, additionalProperties: {type: string}}}
+definitions:
+  Pet:
+    description: A pet.
+    allOf:
+      - $ref: '#/definitions/Named'
+      - type: object
+        required: [id]
+        properties:
+          id: {type: integer, format: int64, readOnly: true}
+          status: {type: string, enum: [available, sold], description: 'Either|or.'}
+          owner:
+            type: object
+            properties:
+              name: {type: string}
+          toys:
+            type: array
+            items:
+              type: object
+              properties:
+                kind: {type: string}
+  Named:
+    type: object
+    required: [name]
+    properties:
+      name: {type: string, description: The name.}
+  Kind:
+    type: string
+    description: A kind of pet.
+    enum: [cat, dog]
+`
+
+const want = "# Pet Store\n" +
+	"\n" +
+	"Pets for sale.\n" +
+	"\n" +
+	"- **Version:** 1.0\n" +
+	"- **Base URL:** `https://pets.example.com/v1`\n" +
+	"- **Contact:** Pet Team <pets@example.com>\n" +
+	"- **License:** [MIT](https://opensource.org/licenses/MIT)\n" +
+	"\n" +
+	"## pets\n" +
+	"\n" +
+	"Everything about pets.\n" +
+	"\n" +
+	"### GET /pets\n" +
+	"\n" +
+	"List pets.\n" +
+	"\n" +
+	"Operation ID: `listPets`\n" +
+	"\n" +
+	"#### Parameters\n" +
+	"\n" +
+	"| Name | In | Type | Required | Description |\n" +
+	"| --- | --- | --- | --- | --- |\n" +
+	"| limit | query | integer (int32) |  | Pets per page. Default: `20`. |\n" +
+	"| status | query | array of string |  | One of `available`, `sold`. |\n" +
+	"\n" +
+	"#### Responses\n" +
+	"\n" +
+	"| Code | Description | Schema |\n" +
+	"| --- | --- | --- |\n" +
+	"| 200 | The pets. | array of [Pet](#pet-1) |\n" +
+	"| default | An error. | map of string |\n" +
+	"\n" +
+	"### POST /pets\n" +
+	"\n" +
+	"**Deprecated.**\n" +
+	"\n" +
+	"Operation ID: `createPet`\n" +
+	"\n" +
+	"#### Parameters\n" +
+	"\n" +
+	"| Name | In | Type | Required | Description |\n" +
+	"| --- | --- | --- | --- | --- |\n" +
+	"| pet | body | [Pet](#pet-1) | yes |  |\n" +
+	"\n" +
+	"#### Responses\n" +
+	"\n" +
+	"| Code | Description | Schema |\n" +
+	"| --- | --- | --- |\n" +
+	"| 201 | Created. | [Pet](#pet-1) |\n" +
+	"\n" +
+	"## Pet\n" +
+	"\n" +
+	"## admin\n" +
+	"\n" +
+	"### POST /pets\n" +
+	"\n" +
+	"**Deprecated.**\n" +
+	"\n" +
+	"Operation ID: `createPet`\n" +
+	"\n" +
+	"#### Parameters\n" +
+	"\n" +
+	"| Name | In | Type | Required | Description |\n" +
+	"| --- | --- | --- | --- | --- |\n" +
+	"| pet | body | [Pet](#pet-1) | yes |  |\n" +
+	"\n" +
+	"#### Responses\n" +
+	"\n" +
+	"| Code | Description | Schema |\n" +
+	"| --- | --- | --- |\n" +
+	"| 201 | Created. | [Pet](#pet-1) |\n" +
+	"\n" +
+	"## Other operations\n" +
+	"\n" +
+	"### GET /pets/{petId}\n" +
+	"\n" +
+	"Get a pet.\n" +
+	"\n" +
+	"Get a pet\n" +
+	"by its ID.\n" +
+	"\n" +
+	"#### Parameters\n" +
+	"\n" +
+	"| Name | In | Type | Required | Description |\n" +
+	"| --- | --- | --- | --- | --- |\n" +
+	"| petId | path | integer (int64) | yes | The pet. |\n" +
+	"\n" +
+	"#### Responses\n" +
+	"\n" +
+	"| Code | Description | Schema |\n" +
+	"| --- | --- | --- |\n" +
+	"| 200 | The pet. | [Pet](#pet-1) |\n" +
+	"\n" +
+	"## Definitions\n" +
+	"\n" +
+	"### Kind\n" +
+	"\n" +
+	"A kind of pet.\n" +
+	"\n" +
+	"Type: string. One of `cat`, `dog`.\n" +
+	"\n" +
+	"### Named\n" +
+	"\n" +
+	"| Property | Type | Required | Description |\n" +
+	"| --- | --- | --- | --- |\n" +
+	"| name | string | yes | The name. |\n" +
+	"\n" +
+	"### Pet\n" +
+	"\n" +
+	"A pet.\n" +
+	"\n" +
+	"Includes the properties of [Named](#named).\n" +
+	"\n" +
+	"| Property | Type | Required | Description |\n" +
+	"| --- | --- | --- | --- |\n" +
+	"| id | integer (int64) | yes | Read-only. |\n" +
+	"| owner | object |  |  |\n" +
+	"| owner.name | string |  |  |\n" +
+	"| status | string |  | Either\\|or. One of `available`, `sold`. |\n" +
+	"| toys | array of object |  |  |\n" +
+	"| toys[].kind | string |  |  |\n"
+
+func TestRender(t *testing.T) {
+	var doc spec.Swagger
+	if err := spec.UnmarshalYAML([]byte(petstore), &doc); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Render(&doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("wanted:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestRenderOptions(t *testing.T) {
+	var doc spec.Swagger
+	if err := spec.UnmarshalYAML([]byte(petstore), &doc); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Options{Level: 3}.Render(&doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"### Pet Store\n", "\n##### GET /pets\n", "\n###### Parameters\n"} {
+		if !strings.Contains(string(got), s) {
+			t.Errorf("expected output to contain %q", s)
+		}
+	}
+	if _, err := (Options{Level: 4}).Render(&doc); err == nil {
+		t.Errorf("expected an error for a heading level deeper than 3")
+	}
+}
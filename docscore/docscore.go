@@ -0,0 +1,288 @@
+/*
+Package docscore grades how completely a document is documented, for docs
+teams deciding what to write next.
+
+Each operation and definition is scored by a few checks, each between 0 and 1:
+
+	summary      the operation has a summary
+	description  the description is at least Options.MinDescription long
+	parameters   the fraction of the operation's parameters with descriptions
+	properties   the fraction of the schema's properties with descriptions
+	example      a successful response, or the schema, has an example
+
+Checks which don't apply, such as parameters for an operation without any, are
+left out. An item's score is the mean of its checks, and the document's the
+mean of its items', graded from A to F.
+*/
+package docscore
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/internal/jsonpointer"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// Options configures Score. The zero value is valid.
+type Options struct {
+	// MinDescription is the length, in characters, of a description which
+	// earns full marks. Shorter descriptions earn a share of them. The
+	// default is 40.
+	MinDescription int
+}
+
+// Report is the score of a document.
+type Report struct {
+	// Score is the mean score of the items, from 0 to 1.
+	Score float64 `json:"score"`
+	// Grade is the letter grade of the score.
+	Grade string `json:"grade"`
+	// Items holds the operations, then the definitions, in the order of
+	// their pointers.
+	Items []Item `json:"items"`
+}
+
+// Item is the score of an operation or definition.
+type Item struct {
+	// Pointer is the JSON pointer of the item, such as "/paths/~1pets/get"
+	// or "/definitions/Pet".
+	Pointer string `json:"pointer"`
+	// Score is the mean score of the checks, from 0 to 1.
+	Score  float64 `json:"score"`
+	Checks []Check `json:"checks"`
+}
+
+// Check is the result of one check of an item.
+type Check struct {
+	Name string `json:"name"`
+	// Score is between 0 and 1.
+	Score float64 `json:"score"`
+	// Message explains what's missing if the score is less than 1.
+	Message string `json:"message,omitempty"`
+}
+
+// String describes an item and what it's missing, such as
+// "40% /paths/~1pets/get: no summary; no example".
+func (it Item) String() string {
+	var missing []string
+	for _, c := range it.Checks {
+		if c.Message != "" {
+			missing = append(missing, c.Message)
+		}
+	}
+	s := fmt.Sprintf("%3.0f%% %s", it.Score*100, it.Pointer)
+	if len(missing) > 0 {
+		s += ": " + strings.Join(missing, "; ")
+	}
+	return s
+}
+
+// Grade returns the letter grade of a score between 0 and 1: A for 0.9 and
+// above, B for 0.8, C for 0.7, D for 0.6 and F below that.
+func Grade(score float64) string {
+	switch {
+	case score >= 0.9:
+		return "A"
+	case score >= 0.8:
+		return "B"
+	case score >= 0.7:
+		return "C"
+	case score >= 0.6:
+		return "D"
+	}
+	return "F"
+}
+
+// Worst returns the items sorted from the lowest score to the highest.
+func (r *Report) Worst() []Item {
+	items := append([]Item(nil), r.Items...)
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Score < items[j].Score })
+	return items
+}
+
+// Score scores a document with the default options.
+func Score(doc *spec.Swagger) *Report {
+	return Options{}.Score(doc)
+}
+
+// Score scores the operations and definitions of a document. A document
+// without either scores 1.
+func (o Options) Score(doc *spec.Swagger) *Report {
+	if o.MinDescription <= 0 {
+		o.MinDescription = 40
+	}
+	r := &Report{}
+	doc.RangeOperations(func(path, method string, op *spec.Operation) bool {
+		item := doc.Paths[path]
+		r.add(jsonpointer.Join("/paths", path, method), o.operation(doc, &item, op))
+		return true
+	})
+	names := make([]string, 0, len(doc.Definitions))
+	for name := range doc.Definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		s := doc.Definitions[name]
+		r.add(jsonpointer.Join("/definitions", name), o.schema(doc, &s))
+	}
+
+	r.Score = 1
+	if len(r.Items) > 0 {
+		r.Score = 0
+		for _, it := range r.Items {
+			r.Score += it.Score
+		}
+		r.Score /= float64(len(r.Items))
+	}
+	r.Grade = Grade(r.Score)
+	return r
+}
+
+func (r *Report) add(pointer string, checks []Check) {
+	it := Item{Pointer: pointer, Checks: checks}
+	for _, c := range checks {
+		it.Score += c.Score
+	}
+	it.Score /= float64(len(checks))
+	r.Items = append(r.Items, it)
+}
+
+func (o Options) operation(doc *spec.Swagger, item *spec.PathItem, op *spec.Operation) []Check {
+	checks := []Check{{Name: "summary", Score: 1}}
+	if op.Summary == "" {
+		checks[0] = Check{Name: "summary", Message: "no summary"}
+	}
+	checks = append(checks, o.description(op.Description))
+
+	params := doc.OperationParameters(item, op)
+	if len(params) > 0 {
+		var undescribed []string
+		for _, p := range params {
+			if p.Description == "" {
+				undescribed = append(undescribed, p.Name)
+			}
+		}
+		checks = append(checks, fraction("parameters", "parameter", len(params), undescribed))
+	}
+
+	// Only successful responses with a body are expected to have examples.
+	withBody, withExample := false, false
+	for code, resp := range op.Responses {
+		if !strings.HasPrefix(code, "2") {
+			continue
+		}
+		if resp.Ref != "" {
+			resp = doc.Responses[jsonpointer.Unescape(strings.TrimPrefix(resp.Ref, "#/responses/"))]
+		}
+		if resp.Schema == nil {
+			continue
+		}
+		withBody = true
+		if len(resp.Examples) > 0 || hasExample(doc, resp.Schema) {
+			withExample = true
+		}
+	}
+	if withBody {
+		checks = append(checks, example(withExample))
+	}
+	return checks
+}
+
+func (o Options) schema(doc *spec.Swagger, s *spec.Schema) []Check {
+	checks := []Check{o.description(s.Description)}
+	if len(s.Properties) > 0 {
+		names := make([]string, 0, len(s.Properties))
+		for name := range s.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var undescribed []string
+		for _, name := range names {
+			if s.Properties[name].Description == "" {
+				undescribed = append(undescribed, name)
+			}
+		}
+		checks = append(checks, fraction("properties", "property", len(names), undescribed))
+	}
+	return append(checks, example(hasExample(doc, s)))
+}
+
+func (o Options) description(d string) Check {
+	n := len([]rune(strings.TrimSpace(d)))
+	switch {
+	case n == 0:
+		return Check{Name: "description", Message: "no description"}
+	case n < o.MinDescription:
+		return Check{
+			Name:    "description",
+			Score:   float64(n) / float64(o.MinDescription),
+			Message: fmt.Sprintf("description is %d of %d characters", n, o.MinDescription),
+		}
+	}
+	return Check{Name: "description", Score: 1}
+}
+
+// fraction scores the share of a list of parameters or properties which have
+// descriptions.
+func fraction(name, noun string, total int, undescribed []string) Check {
+	c := Check{Name: name, Score: float64(total-len(undescribed)) / float64(total)}
+	switch len(undescribed) {
+	case 0:
+	case 1:
+		c.Message = fmt.Sprintf("%s %s has no description", noun, undescribed[0])
+	default:
+		c.Message = fmt.Sprintf("%s %s have no description", name, strings.Join(undescribed, ", "))
+	}
+	return c
+}
+
+func example(ok bool) Check {
+	if ok {
+		return Check{Name: "example", Score: 1}
+	}
+	return Check{Name: "example", Message: "no example"}
+}
+
+// hasExample reports if a schema, the definition it refers to, or the items of
+// an array have an example, or every property of an object does.
+func hasExample(doc *spec.Swagger, s *spec.Schema) bool {
+	return (&exampleFinder{doc: doc, seen: make(map[string]bool)}).find(s)
+}
+
+type exampleFinder struct {
+	doc *spec.Swagger
+	// seen holds the references being followed, so recursive definitions
+	// end.
+	seen map[string]bool
+}
+
+func (f *exampleFinder) find(s *spec.Schema) bool {
+	if s.Example != nil {
+		return true
+	}
+	if s.Ref != "" {
+		target, ok := f.doc.Definitions[jsonpointer.Unescape(strings.TrimPrefix(s.Ref, "#/definitions/"))]
+		if !ok || f.seen[s.Ref] {
+			return false
+		}
+		f.seen[s.Ref] = true
+		defer delete(f.seen, s.Ref)
+		return f.find(&target)
+	}
+	if s.Items != nil {
+		return f.find(s.Items)
+	}
+	if len(s.Properties) == 0 {
+		return false
+	}
+	for name := range s.Properties {
+		p := s.Properties[name]
+		if !f.find(&p) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,110 @@
+package docscore
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+func TestScore(t *testing.T) {
+	var s spec.Swagger
+	err := yaml.Unmarshal([]byte(`
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+parameters:
+  limit: {name: limit, in: query, type: integer, description: The most pets to return.}
+paths:
+  /pets:
+    parameters:
+    - $ref: '#/parameters/limit'
+    get:
+      summary: List pets.
+      description: Lists every pet, sorted by name.
+      parameters:
+      - {name: tag, in: query, type: string}
+      responses:
+        200:
+          description: Pets.
+          schema: {type: array, items: {$ref: '#/definitions/Pet'}}
+  /pets/{id}:
+    delete:
+      responses:
+        204: {description: Deleted.}
+definitions:
+  Pet:
+    description: A pet, with a name and the pets it lives with.
+    properties:
+      name: {type: string, description: The name of the pet., example: Rex}
+      friends: {type: array, items: {$ref: '#/definitions/Pet'}}
+  Error:
+    description: An error.
+    example: {message: Not found.}
+`), &s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := Options{MinDescription: 20}.Score(&s)
+	want := &Report{
+		Score: 0.4625,
+		Grade: "F",
+		Items: []Item{
+			{
+				Pointer: "/paths/~1pets/get",
+				Score:   0.625,
+				Checks: []Check{
+					{Name: "summary", Score: 1},
+					{Name: "description", Score: 1},
+					{Name: "parameters", Score: 0.5, Message: "parameter tag has no description"},
+					// Pet's friends are Pets, so only the name has an example.
+					{Name: "example", Message: "no example"},
+				},
+			},
+			{
+				Pointer: "/paths/~1pets~1{id}/delete",
+				Score:   0,
+				Checks: []Check{
+					{Name: "summary", Message: "no summary"},
+					{Name: "description", Message: "no description"},
+				},
+			},
+			{
+				Pointer: "/definitions/Error",
+				Score:   0.725,
+				Checks: []Check{
+					{Name: "description", Score: 0.45, Message: "description is 9 of 20 characters"},
+					{Name: "example", Score: 1},
+				},
+			},
+			{
+				Pointer: "/definitions/Pet",
+				Score:   0.5,
+				Checks: []Check{
+					{Name: "description", Score: 1},
+					{Name: "properties", Score: 0.5, Message: "property friends has no description"},
+					{Name: "example", Message: "no example"},
+				},
+			},
+		},
+	}
+	if diff := pretty.Compare(r, want); diff != "" {
+		t.Errorf("want != got: %s", diff)
+	}
+
+	var worst []string
+	for _, it := range r.Worst() {
+		worst = append(worst, it.String())
+	}
+	wantWorst := []string{
+		"  0% /paths/~1pets~1{id}/delete: no summary; no description",
+		" 50% /definitions/Pet: property friends has no description; no example",
+		" 62% /paths/~1pets/get: parameter tag has no description; no example",
+		" 72% /definitions/Error: description is 9 of 20 characters",
+	}
+	if diff := pretty.Compare(worst, wantWorst); diff != "" {
+		t.Errorf("worst: want != got: %s", diff)
+	}
+}
@@ -0,0 +1,473 @@
+/*
+Package dsl compiles a terse, resource oriented description of an API into a
+full document, so contracts can be written without the boilerplate of paths,
+parameters and responses:
+
+	api: Petstore
+	version: "1.0"
+	basePath: /v1
+	auth:
+	  key: {type: apiKey, in: header, name: X-API-Key}
+	security: {key: []}
+	resources:
+	  Owner:
+	    fields:
+	      name: string!
+	  Pet:
+	    id: integer
+	    parent: Owner
+	    operations: [list, create, read, delete]
+	    fields:
+	      name: string!
+	      born: date
+	      tags: "[string]"
+	    relations:
+	      owner: Owner
+
+Each resource becomes a definition with its fields, an "id" property and its
+relations, which refer to the definitions of other resources. Fields and the
+id are typed by expressions: a primitive type ("string", "integer", "number"
+or "boolean"), a format implying one ("int64", "date", "date-time", "email",
+...), another resource's name, or "[type]" for an array, suffixed with "!" if
+the property is required. The id and relations are read only, so they're only
+sent in responses.
+
+A resource's collection path is its plural name in kebab case, such as
+"/pets", nested below its parent's member path if it has one, such as
+"/owners/{ownerId}/pets". Its operations, all five by default, are:
+
+	list    GET /pets                 listPets    200 with an array of Pets
+	create  POST /pets                createPet   201 with the created Pet
+	read    GET /pets/{petId}         getPet      200 with the Pet, or 404
+	update  PUT /pets/{petId}         updatePet   200 with the Pet, or 404
+	delete  DELETE /pets/{petId}      deletePet   204, or 404
+
+Compile builds the document with package builder, so it's valid, and OpenAPI
+converts it to OpenAPI 3.0.
+*/
+package dsl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/ericchiang/swaggopher/builder"
+	"github.com/ericchiang/swaggopher/convert"
+	"github.com/ericchiang/swaggopher/internal/mapkeys"
+	"github.com/ericchiang/swaggopher/internal/rawdoc"
+	"github.com/ericchiang/swaggopher/spec"
+	"github.com/ericchiang/swaggopher/spec3"
+)
+
+// API describes an API as resources.
+type API struct {
+	API         string `json:"api" yaml:"api"`
+	Version     string `json:"version" yaml:"version"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Host        string `json:"host,omitempty" yaml:"host,omitempty"`
+	BasePath    string `json:"basePath,omitempty" yaml:"basePath,omitempty"`
+	// Auth declares security schemes, as securityDefinitions does.
+	Auth map[string]spec.SecurityScheme `json:"auth,omitempty" yaml:"auth,omitempty"`
+	// Security is the security requirement of every operation, mapping
+	// scheme names to scopes. Resources may override it.
+	Security  spec.SecurityRequirement `json:"security,omitempty" yaml:"security,omitempty"`
+	Resources map[string]Resource      `json:"resources" yaml:"resources"`
+}
+
+// Resource describes a resource, named by its key in API.Resources.
+type Resource struct {
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// Plural is the plural of the resource's name. By default it's formed
+	// by adding "s", "es" or "ies".
+	Plural string `json:"plural,omitempty" yaml:"plural,omitempty"`
+	// ID is the type of the resource's id. The default is "string".
+	ID string `json:"id,omitempty" yaml:"id,omitempty"`
+	// Parent names the resource the resource is nested below.
+	Parent string `json:"parent,omitempty" yaml:"parent,omitempty"`
+	// Operations lists the operations of the resource: list, create, read,
+	// update and delete. The default is all of them.
+	Operations []string `json:"operations,omitempty" yaml:"operations,omitempty"`
+	// Fields maps property names to type expressions.
+	Fields map[string]string `json:"fields,omitempty" yaml:"fields,omitempty"`
+	// Relations maps property names to other resources, or "[Resource]" for
+	// a list of them.
+	Relations map[string]string `json:"relations,omitempty" yaml:"relations,omitempty"`
+	// Security, if set, overrides API.Security for the resource's
+	// operations. An empty requirement makes them public.
+	Security *spec.SecurityRequirement `json:"security,omitempty" yaml:"security,omitempty"`
+}
+
+var allOperations = []string{"list", "create", "read", "update", "delete"}
+
+// Parse decodes a JSON or YAML description of an API.
+func Parse(data []byte) (*API, error) {
+	var a API
+	var err error
+	if rawdoc.IsJSON(data) {
+		err = json.Unmarshal(data, &a)
+	} else {
+		err = yaml.Unmarshal(data, &a)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("dsl: parsing: %v", err)
+	}
+	return &a, nil
+}
+
+// Load reads a description of an API from a file.
+func Load(path string) (*API, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Compile builds a Swagger 2.0 document from the API.
+func (a *API) Compile() (*spec.Swagger, error) {
+	names := make([]string, 0, len(a.Resources))
+	for name := range a.Resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	c := &compiler{api: a}
+	b := builder.New(a.API, a.Version).Description(a.Description)
+	if a.Host != "" {
+		b.Host(a.Host)
+	}
+	if a.BasePath != "" {
+		b.BasePath(a.BasePath)
+	}
+	schemes := make([]string, 0, len(a.Auth))
+	for name := range a.Auth {
+		schemes = append(schemes, name)
+	}
+	sort.Strings(schemes)
+	for _, name := range schemes {
+		b.SecurityDefinition(name, a.Auth[name])
+	}
+	for _, name := range mapkeys.Sorted(a.Security) {
+		b.Security(name, a.Security[name]...)
+	}
+
+	for _, name := range names {
+		def, err := c.definition(name)
+		if err != nil {
+			return nil, err
+		}
+		b.Definition(name, def)
+		r := a.Resources[name]
+		b.Tag(kebab(r.plural(name)), r.Description)
+		if err := c.paths(b, name); err != nil {
+			return nil, err
+		}
+	}
+	doc, err := b.Build()
+	if err != nil {
+		return nil, fmt.Errorf("dsl: %v", err)
+	}
+	return doc, nil
+}
+
+// OpenAPI builds an OpenAPI 3.0 document from the API.
+func (a *API) OpenAPI() (*spec3.OpenAPI, error) {
+	doc, err := a.Compile()
+	if err != nil {
+		return nil, err
+	}
+	return convert.Convert2To3(doc)
+}
+
+type compiler struct {
+	api *API
+}
+
+func (c *compiler) resource(name string) (*Resource, error) {
+	r, ok := c.api.Resources[name]
+	if !ok {
+		return nil, fmt.Errorf("dsl: unknown resource %q", name)
+	}
+	return &r, nil
+}
+
+func (c *compiler) definition(name string) (*spec.Schema, error) {
+	r, err := c.resource(name)
+	if err != nil {
+		return nil, err
+	}
+	id, _, err := c.typeOf(r.id())
+	if err != nil {
+		return nil, fmt.Errorf("dsl: resource %s: id: %v", name, err)
+	}
+	id.ReadOnly = true
+	props := []builder.Property{builder.Required("id", id)}
+	for _, field := range mapkeys.Sorted(r.Fields) {
+		s, required, err := c.typeOf(r.Fields[field])
+		if err != nil {
+			return nil, fmt.Errorf("dsl: resource %s: field %s: %v", name, field, err)
+		}
+		props = append(props, builder.Property{Name: field, Schema: s, Required: required})
+	}
+	for _, rel := range mapkeys.Sorted(r.Relations) {
+		target := r.Relations[rel]
+		many := strings.HasPrefix(target, "[") && strings.HasSuffix(target, "]")
+		if many {
+			target = target[1 : len(target)-1]
+		}
+		if _, ok := c.api.Resources[target]; !ok {
+			return nil, fmt.Errorf("dsl: resource %s: relation %s: unknown resource %q", name, rel, target)
+		}
+		var s *spec.Schema
+		if many {
+			s = builder.ArrayOf(builder.Ref(target))
+		} else {
+			// A reference can't have siblings, so it's wrapped to mark
+			// it read only.
+			s = &spec.Schema{AllOf: []spec.Schema{*builder.Ref(target)}}
+		}
+		s.ReadOnly = true
+		props = append(props, builder.Optional(rel, s))
+	}
+	s := builder.Object(props...)
+	s.Description = r.Description
+	return s, nil
+}
+
+// primitives maps the primitive types and formats of type expressions to
+// schemas.
+var primitives = map[string]spec.Schema{
+	"string":    {Type: "string"},
+	"integer":   {Type: "integer"},
+	"number":    {Type: "number"},
+	"boolean":   {Type: "boolean"},
+	"int32":     {Type: "integer", Format: "int32"},
+	"int64":     {Type: "integer", Format: "int64"},
+	"float":     {Type: "number", Format: "float"},
+	"double":    {Type: "number", Format: "double"},
+	"byte":      {Type: "string", Format: "byte"},
+	"binary":    {Type: "string", Format: "binary"},
+	"date":      {Type: "string", Format: "date"},
+	"date-time": {Type: "string", Format: "date-time"},
+	"password":  {Type: "string", Format: "password"},
+	"email":     {Type: "string", Format: "email"},
+	"uuid":      {Type: "string", Format: "uuid"},
+}
+
+// typeOf returns the schema of a type expression, and whether it's required.
+func (c *compiler) typeOf(expr string) (*spec.Schema, bool, error) {
+	expr = strings.TrimSpace(expr)
+	required := strings.HasSuffix(expr, "!")
+	expr = strings.TrimSuffix(expr, "!")
+	if strings.HasPrefix(expr, "[") && strings.HasSuffix(expr, "]") {
+		items, _, err := c.typeOf(expr[1 : len(expr)-1])
+		if err != nil {
+			return nil, false, err
+		}
+		return builder.ArrayOf(items), required, nil
+	}
+	if s, ok := primitives[expr]; ok {
+		return &s, required, nil
+	}
+	if _, ok := c.api.Resources[expr]; ok {
+		return builder.Ref(expr), required, nil
+	}
+	return nil, false, fmt.Errorf("unknown type %q", expr)
+}
+
+// paths declares the collection and member paths of a resource.
+func (c *compiler) paths(b *builder.Builder, name string) error {
+	r, err := c.resource(name)
+	if err != nil {
+		return err
+	}
+	collection, params, err := c.collectionPath(name, nil)
+	if err != nil {
+		return err
+	}
+	ops := r.Operations
+	if ops == nil {
+		ops = allOperations
+	}
+	for _, op := range ops {
+		if !contains(allOperations, op) {
+			return fmt.Errorf("dsl: resource %s: unknown operation %q, must be one of %s", name, op, strings.Join(allOperations, ", "))
+		}
+	}
+	plural := r.plural(name)
+	tag := kebab(plural)
+	describe := func(op *builder.Operation, id, summary string) {
+		op.ID(id).Summary(summary).Tags(tag)
+		for _, p := range params {
+			op.PathParam(p.name, p.typ)
+		}
+		if r.Security != nil {
+			if len(*r.Security) == 0 {
+				op.NoSecurity()
+			}
+			for _, scheme := range mapkeys.Sorted(*r.Security) {
+				op.Security(scheme, (*r.Security)[scheme]...)
+			}
+		}
+	}
+	ref := builder.Ref(name)
+	article := indefiniteArticle(name)
+
+	if contains(ops, "list") || contains(ops, "create") {
+		b.Path(collection, func(p *builder.Path) {
+			if contains(ops, "list") {
+				p.Get(func(op *builder.Operation) {
+					describe(op, "list"+plural, fmt.Sprintf("List %s.", lowerFirst(plural)))
+					op.Response(200, fmt.Sprintf("The %s.", lowerFirst(plural)), builder.ArrayOf(ref))
+				})
+			}
+			if contains(ops, "create") {
+				p.Post(func(op *builder.Operation) {
+					describe(op, "create"+name, fmt.Sprintf("Create %s %s.", article, lowerFirst(name)))
+					op.Body(lowerFirst(name), ref).
+						Response(201, fmt.Sprintf("The created %s.", lowerFirst(name)), ref)
+				})
+			}
+		})
+	}
+	if !contains(ops, "read") && !contains(ops, "update") && !contains(ops, "delete") {
+		return nil
+	}
+	idParam := r.idParam(name)
+	idType, _, err := c.typeOf(r.id())
+	if err != nil {
+		return fmt.Errorf("dsl: resource %s: id: %v", name, err)
+	}
+	params = append(params, pathParam{idParam, idType.Type})
+	b.Path(collection+"/{"+idParam+"}", func(p *builder.Path) {
+		if contains(ops, "read") {
+			p.Get(func(op *builder.Operation) {
+				describe(op, "get"+name, fmt.Sprintf("Get %s %s.", article, lowerFirst(name)))
+				op.Response(200, fmt.Sprintf("The %s.", lowerFirst(name)), ref).Response(404, "", nil)
+			})
+		}
+		if contains(ops, "update") {
+			p.Put(func(op *builder.Operation) {
+				describe(op, "update"+name, fmt.Sprintf("Update %s %s.", article, lowerFirst(name)))
+				op.Body(lowerFirst(name), ref).
+					Response(200, fmt.Sprintf("The updated %s.", lowerFirst(name)), ref).
+					Response(404, "", nil)
+			})
+		}
+		if contains(ops, "delete") {
+			p.Delete(func(op *builder.Operation) {
+				describe(op, "delete"+name, fmt.Sprintf("Delete %s %s.", article, lowerFirst(name)))
+				op.Response(204, "", nil).Response(404, "", nil)
+			})
+		}
+	})
+	return nil
+}
+
+type pathParam struct {
+	name, typ string
+}
+
+// collectionPath returns the collection path of a resource, and the path
+// parameters of its ancestors. seen holds the resources below it, to report
+// cycles of parents.
+func (c *compiler) collectionPath(name string, seen []string) (string, []pathParam, error) {
+	if contains(seen, name) {
+		return "", nil, fmt.Errorf("dsl: resources have a cycle of parents: %s", strings.Join(append(seen, name), ", "))
+	}
+	r, err := c.resource(name)
+	if err != nil {
+		return "", nil, err
+	}
+	path := "/" + kebab(r.plural(name))
+	if r.Parent == "" {
+		return path, nil, nil
+	}
+	parentPath, params, err := c.collectionPath(r.Parent, append(seen, name))
+	if err != nil {
+		return "", nil, err
+	}
+	parent, err := c.resource(r.Parent)
+	if err != nil {
+		return "", nil, err
+	}
+	idType, _, err := c.typeOf(parent.id())
+	if err != nil {
+		return "", nil, fmt.Errorf("dsl: resource %s: id: %v", r.Parent, err)
+	}
+	idParam := parent.idParam(r.Parent)
+	params = append(params, pathParam{idParam, idType.Type})
+	return parentPath + "/{" + idParam + "}" + path, params, nil
+}
+
+func (r *Resource) id() string {
+	if r.ID == "" {
+		return "string"
+	}
+	return r.ID
+}
+
+// idParam returns the name of the path parameter holding the resource's id,
+// such as "petId".
+func (r *Resource) idParam(name string) string {
+	return lowerFirst(name) + "Id"
+}
+
+func (r *Resource) plural(name string) string {
+	if r.Plural != "" {
+		return r.Plural
+	}
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, "y") && len(lower) > 1 && !strings.ContainsRune("aeiou", rune(lower[len(lower)-2])):
+		return name[:len(name)-1] + "ies"
+	case strings.HasSuffix(lower, "s"), strings.HasSuffix(lower, "x"), strings.HasSuffix(lower, "z"),
+		strings.HasSuffix(lower, "ch"), strings.HasSuffix(lower, "sh"):
+		return name + "es"
+	}
+	return name + "s"
+}
+
+// kebab converts a CamelCase name to kebab case, such as "pet-owners".
+func kebab(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('-')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+func indefiniteArticle(name string) string {
+	if name != "" && strings.ContainsRune("AEIOUaeiou", rune(name[0])) {
+		return "an"
+	}
+	return "a"
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package dsl
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+const petstore = `
+api: Petstore
+version: "1.0"
+basePath: /v1
+auth:
+  key: {type: apiKey, in: header, name: X-API-Key}
+security: {key: []}
+resources:
+  Owner:
+    description: A person with pets.
+    operations: [read]
+    fields:
+      name: string!
+  Pet:
+    id: integer
+    parent: Owner
+    operations: [list, create, read, delete]
+    fields:
+      name: string!
+      born: date
+      tags: "[string]"
+    relations:
+      owner: Owner
+  Category:
+    plural: Categories
+    security: {}
+    operations: [list]
+`
+
+func TestCompile(t *testing.T) {
+	a, err := Parse([]byte(petstore))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := a.Compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ops []string
+	doc.RangeOperations(func(path, method string, op *spec.Operation) bool {
+		ops = append(ops, method+" "+path+" "+op.OperationId)
+		return true
+	})
+	wantOps := []string{
+		"get /categories listCategories",
+		"get /owners/{ownerId} getOwner",
+		"get /owners/{ownerId}/pets listPets",
+		"post /owners/{ownerId}/pets createPet",
+		"get /owners/{ownerId}/pets/{petId} getPet",
+		"delete /owners/{ownerId}/pets/{petId} deletePet",
+	}
+	if diff := pretty.Compare(ops, wantOps); diff != "" {
+		t.Errorf("operations: want != got: %s", diff)
+	}
+
+	getPet := doc.Paths["/owners/{ownerId}/pets/{petId}"].Get
+	var params []string
+	for _, p := range getPet.Parameters {
+		params = append(params, p.Name+":"+p.Type)
+	}
+	sort.Strings(params)
+	if diff := pretty.Compare(params, []string{"ownerId:string", "petId:integer"}); diff != "" {
+		t.Errorf("getPet parameters: want != got: %s", diff)
+	}
+	if got := doc.Paths["/categories"].Get.Security; got == nil || len(got) != 0 {
+		t.Errorf("listCategories: want no security, got %v", got)
+	}
+
+	pet := doc.Definitions["Pet"]
+	if diff := pretty.Compare(pet.Required, []string{"id", "name"}); diff != "" {
+		t.Errorf("Pet required: want != got: %s", diff)
+	}
+	for name, want := range map[string]string{
+		"id":    `{"type":"integer","readOnly":true}`,
+		"born":  `{"format":"date","type":"string"}`,
+		"tags":  `{"type":"array","items":{"type":"string"}}`,
+		"owner": `{"allOf":[{"$ref":"#/definitions/Owner"}],"readOnly":true}`,
+	} {
+		data, err := pet.Properties[name].MarshalJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != want {
+			t.Errorf("Pet.%s: want %s, got %s", name, want, data)
+		}
+	}
+
+	if _, err := a.OpenAPI(); err != nil {
+		t.Errorf("converting to OpenAPI 3.0: %v", err)
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		api  string
+		want string
+	}{
+		{
+			"api: A\nversion: '1'\nresources:\n  Pet: {fields: {age: years}}\n",
+			`dsl: resource Pet: field age: unknown type "years"`,
+		},
+		{
+			"api: A\nversion: '1'\nresources:\n  Pet: {relations: {owner: Owner}}\n",
+			`dsl: resource Pet: relation owner: unknown resource "Owner"`,
+		},
+		{
+			"api: A\nversion: '1'\nresources:\n  Pet: {operations: [search]}\n",
+			`dsl: resource Pet: unknown operation "search", must be one of list, create, read, update, delete`,
+		},
+		{
+			"api: A\nversion: '1'\nresources:\n  Egg: {parent: Hen}\n  Hen: {parent: Egg}\n",
+			"dsl: resources have a cycle of parents: Egg, Hen, Egg",
+		},
+	}
+	for _, tt := range tests {
+		a, err := Parse([]byte(tt.api))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := a.Compile(); err == nil || err.Error() != tt.want {
+			t.Errorf("want error %q, got %v", tt.want, err)
+		}
+	}
+}
@@ -0,0 +1,474 @@
+/*
+Package examplegen generates random values which are valid against a schema,
+for mock servers, documentation examples and tests.
+
+Unlike the fixed examples the mock server falls back to, generated values
+vary: numbers are drawn from between a schema's bounds, strings are sized
+between its length limits or built to match its pattern, and arrays and
+objects are filled with values generated from their items and properties.
+Strings with a format such as date-time, email or uuid are generated in that
+format.
+
+Generation is driven by a seeded source of randomness, so a generator created
+with the same seed produces the same values for the same schemas:
+
+	g := examplegen.New(examplegen.Options{Doc: doc, Seed: 42})
+	v := g.Generate(doc.Definitions["Pet"])
+
+Each call continues the generator's sequence of random numbers, so the value
+generated for a schema depends on the calls before it. With Stable set, each
+call starts from a state derived from the seed and the schema instead, so a
+schema generates the same value however many other values were generated
+first. This keeps snapshot tests stable as schemas are added.
+
+With Realistic set, strings which have no format or pattern are drawn from
+corpora of names, addresses, companies and phone numbers when the name of
+their property suggests one, such as "email", "firstName" or "city".
+*/
+package examplegen
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"reflect"
+	"regexp/syntax"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ericchiang/swaggopher/internal/synth"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// Values nested deeper than synth.MaxDepth are kept minimal, so recursive
+// definitions terminate: objects only have their required properties and
+// arrays the fewest items they allow. A definition which requires itself
+// without end can't be satisfied, so it's cut off at maxDepth.
+const maxDepth = 2 * synth.MaxDepth
+
+// Options configures a Generator.
+type Options struct {
+	// Doc, if set, is the document references to definitions are resolved
+	// against. References which can't be resolved generate nil.
+	Doc *spec.Swagger
+	// Seed seeds the generator's source of randomness.
+	Seed int64
+	// Rand, if set, is the generator's source of randomness in place of one
+	// seeded with Seed.
+	Rand rand.Source
+	// Stable reseeds the generator for each call to Generate from Seed and
+	// the schema, so the value generated for a schema doesn't depend on the
+	// calls before it. It's ignored if Rand is set.
+	Stable bool
+	// Realistic draws strings from corpora of names, addresses and the like
+	// when their property's name suggests one.
+	Realistic bool
+}
+
+// Generator generates values from schemas. It isn't safe for concurrent use.
+type Generator struct {
+	doc       *spec.Swagger
+	rand      *rand.Rand
+	seed      int64
+	stable    bool
+	realistic bool
+}
+
+// New returns a generator.
+func New(opts Options) *Generator {
+	doc := opts.Doc
+	if doc == nil {
+		doc = &spec.Swagger{}
+	}
+	g := &Generator{doc: doc, seed: opts.Seed, realistic: opts.Realistic}
+	if opts.Rand != nil {
+		g.rand = rand.New(opts.Rand)
+	} else {
+		g.rand = rand.New(rand.NewSource(opts.Seed))
+		g.stable = opts.Stable
+	}
+	return g
+}
+
+// Generate returns a value valid against s, using a generator with the zero
+// Options. Each call returns the same value for the same schema.
+func Generate(s *spec.Schema) interface{} {
+	return New(Options{}).Generate(s)
+}
+
+// Generate returns a value valid against s. Values are those decoded by
+// encoding/json: numbers are float64s, arrays []interface{} and objects
+// map[string]interface{}.
+func (g *Generator) Generate(s *spec.Schema) interface{} {
+	if g.stable {
+		g.rand.Seed(g.seed ^ fingerprint(s))
+	}
+	return g.value(s, "", 0)
+}
+
+// fingerprint hashes the JSON encoding of a schema.
+func fingerprint(s *spec.Schema) int64 {
+	h := fnv.New64a()
+	if err := json.NewEncoder(h).Encode(s); err != nil {
+		return 0
+	}
+	return int64(h.Sum64())
+}
+
+// value generates a value for a schema. Name is the name of the property the
+// value is for, if any.
+func (g *Generator) value(s *spec.Schema, name string, depth int) interface{} {
+	s = synth.Resolve(g.doc, s)
+	if s == nil {
+		return nil
+	}
+	if len(s.Enum) > 0 {
+		return s.Enum[g.rand.Intn(len(s.Enum))]
+	}
+	typ := s.Type
+	if typ == "" && (len(s.Properties) > 0 || len(s.AllOf) > 0 || s.AdditionalProperties != nil) {
+		typ = "object"
+	}
+	switch typ {
+	case "object":
+		return g.object(s, depth)
+	case "array":
+		return g.array(s, name, depth)
+	case "integer":
+		return g.integer(s)
+	case "number":
+		return g.number(s)
+	case "boolean":
+		return g.rand.Intn(2) == 0
+	case "string":
+		return g.str(s, name)
+	}
+	return nil
+}
+
+func (g *Generator) object(s *spec.Schema, depth int) map[string]interface{} {
+	obj := make(map[string]interface{})
+	if depth >= maxDepth {
+		return obj
+	}
+	minimal := depth >= synth.MaxDepth
+	for i := range s.AllOf {
+		if m, ok := g.value(&s.AllOf[i], "", depth+1).(map[string]interface{}); ok {
+			for k, v := range m {
+				obj[k] = v
+			}
+		}
+	}
+	// Properties are generated in order so the output only depends on the seed.
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	required := make(map[string]bool)
+	for _, name := range s.Required {
+		required[name] = true
+	}
+	for _, name := range names {
+		if minimal && !required[name] {
+			continue
+		}
+		prop := s.Properties[name]
+		obj[name] = g.value(&prop, name, depth+1)
+	}
+	if ap := s.AdditionalProperties; ap != nil && ap.Schema != nil && len(s.Properties) == 0 {
+		n := between(g.rand, s.MinProperties, s.MaxProperties, 1, 3)
+		if minimal {
+			n = s.MinProperties
+		}
+		for i := 0; i < n; i++ {
+			obj[fmt.Sprintf("%s%d", g.word(), i+1)] = g.value(ap.Schema, "", depth+1)
+		}
+	}
+	return obj
+}
+
+// array generates an array. Its items are named after the array, so
+// realistic values are generated for lists such as "emails".
+func (g *Generator) array(s *spec.Schema, name string, depth int) []interface{} {
+	if depth >= maxDepth || s.Items == nil {
+		return []interface{}{}
+	}
+	n := between(g.rand, s.MinItems, s.MaxItems, 1, 3)
+	if depth >= synth.MaxDepth {
+		n = s.MinItems
+	}
+	elems := make([]interface{}, 0, n)
+	for tries := 0; len(elems) < n && tries < 10*n; tries++ {
+		v := g.value(s.Items, name, depth+1)
+		if s.UniqueItems && containsValue(elems, v) {
+			continue
+		}
+		elems = append(elems, v)
+	}
+	return elems
+}
+
+func (g *Generator) integer(s *spec.Schema) float64 {
+	lo, hi := bounds(s.Minimum, s.ExclusiveMinimum, s.Maximum, s.ExclusiveMaximum, 100)
+	lo, hi = math.Ceil(lo), math.Floor(hi)
+	if s.Minimum != nil && s.ExclusiveMinimum && lo == *s.Minimum {
+		lo++
+	}
+	if s.Maximum != nil && s.ExclusiveMaximum && hi == *s.Maximum {
+		hi--
+	}
+	step := 1.0
+	if s.MultipleOf > 0 {
+		step = s.MultipleOf
+	}
+	return g.step(lo, hi, step)
+}
+
+func (g *Generator) number(s *spec.Schema) float64 {
+	lo, hi := bounds(s.Minimum, s.ExclusiveMinimum, s.Maximum, s.ExclusiveMaximum, 100)
+	if s.MultipleOf > 0 {
+		return g.step(lo, hi, s.MultipleOf)
+	}
+	v := lo + g.rand.Float64()*(hi-lo)
+	// Prefer values with two decimal places, as long as they're in bounds.
+	if r := math.Round(v*100) / 100; r > lo && r < hi {
+		return r
+	}
+	if v == lo && s.ExclusiveMinimum {
+		return (lo + hi) / 2
+	}
+	return v
+}
+
+// step returns a random multiple of step between lo and hi, or lo if there's
+// none.
+func (g *Generator) step(lo, hi, step float64) float64 {
+	first, last := math.Ceil(lo/step), math.Floor(hi/step)
+	if last < first {
+		return lo
+	}
+	n := int64(last - first + 1)
+	if n <= 0 || n > 1<<53 {
+		n = 1 << 53
+	}
+	return (first + float64(g.rand.Int63n(n))) * step
+}
+
+// bounds returns the range to draw numbers from, centering a range of width
+// size on zero, or extending it from a single bound.
+func bounds(min *float64, exclusiveMin bool, max *float64, exclusiveMax bool, size float64) (float64, float64) {
+	switch {
+	case min != nil && max != nil:
+		return *min, *max
+	case min != nil:
+		return *min, *min + size
+	case max != nil:
+		return *max - size, *max
+	}
+	return 0, size
+}
+
+// str returns a string valid against s, honoring its format, pattern and
+// length limits.
+func (g *Generator) str(s *spec.Schema, name string) string {
+	switch s.Format {
+	case "date":
+		return g.time().Format("2006-01-02")
+	case "date-time":
+		return g.time().Format(time.RFC3339)
+	case "email":
+		if g.realistic {
+			return g.email()
+		}
+		return g.word() + "." + g.word() + "@example.com"
+	case "uuid":
+		return g.uuid()
+	case "uri", "url":
+		return "https://example.com/" + g.word() + "/" + g.word()
+	case "hostname":
+		return g.word() + ".example.com"
+	case "ipv4":
+		return fmt.Sprintf("192.0.2.%d", g.rand.Intn(254)+1)
+	case "ipv6":
+		return fmt.Sprintf("2001:db8::%x", g.rand.Intn(0xffff)+1)
+	case "byte":
+		b := make([]byte, between(g.rand, 0, nil, 4, 12))
+		g.rand.Read(b)
+		return base64.StdEncoding.EncodeToString(b)
+	}
+	if s.Pattern != "" {
+		if v, ok := g.pattern(s.Pattern, s.MinLength, s.MaxLength); ok {
+			return v
+		}
+	}
+	if g.realistic {
+		if v, ok := g.realisticString(name); ok && fits(v, s.MinLength, s.MaxLength) {
+			return v
+		}
+	}
+	n := between(g.rand, s.MinLength, s.MaxLength, 4, 12)
+	var b bytes.Buffer
+	for b.Len() < n {
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(g.word())
+	}
+	v := b.String()[:n]
+	if strings.HasSuffix(v, " ") {
+		v = v[:n-1] + "s"
+	}
+	return v
+}
+
+// time returns a time within a few years of 2016, to the second.
+func (g *Generator) time() time.Time {
+	base := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	return base.Add(time.Duration(g.rand.Int63n(5*365*24*60*60)) * time.Second)
+}
+
+func (g *Generator) uuid() string {
+	b := make([]byte, 16)
+	g.rand.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+var syllables = []string{"ka", "lo", "mi", "ne", "po", "ra", "si", "tu", "va", "ze", "bo", "di", "fe", "gu", "ha", "jo"}
+
+// word returns a pronounceable lower case word of two or three syllables.
+func (g *Generator) word() string {
+	n := 2 + g.rand.Intn(2)
+	var w string
+	for i := 0; i < n; i++ {
+		w += syllables[g.rand.Intn(len(syllables))]
+	}
+	return w
+}
+
+// pattern returns a string matching a regular expression, with a length
+// between min and max if they're set.
+func (g *Generator) pattern(expr string, min int, max *int) (string, bool) {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return "", false
+	}
+	re = re.Simplify()
+	for tries := 0; tries < 20; tries++ {
+		var b bytes.Buffer
+		g.regexp(&b, re)
+		if v := b.String(); fits(v, min, max) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+func (g *Generator) regexp(b *bytes.Buffer, re *syntax.Regexp) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		b.WriteString(string(re.Rune))
+	case syntax.OpCharClass:
+		b.WriteRune(g.class(re.Rune))
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		b.WriteRune(rune('a' + g.rand.Intn(26)))
+	case syntax.OpCapture:
+		g.regexp(b, re.Sub[0])
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			g.regexp(b, sub)
+		}
+	case syntax.OpAlternate:
+		g.regexp(b, re.Sub[g.rand.Intn(len(re.Sub))])
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		min, max := re.Min, re.Max
+		switch re.Op {
+		case syntax.OpStar:
+			min, max = 0, 3
+		case syntax.OpPlus:
+			min, max = 1, 3
+		case syntax.OpQuest:
+			min, max = 0, 1
+		}
+		if max < 0 {
+			max = min + 3
+		}
+		for n := min + g.rand.Intn(max-min+1); n > 0; n-- {
+			g.regexp(b, re.Sub[0])
+		}
+	}
+}
+
+// class returns a rune from a character class, given as pairs of inclusive
+// ranges, preferring printable ASCII.
+func (g *Generator) class(ranges []rune) rune {
+	var printable []rune
+	for i := 0; i+1 < len(ranges); i += 2 {
+		lo, hi := ranges[i], ranges[i+1]
+		if lo < ' '+1 {
+			lo = ' ' + 1
+		}
+		if hi > '~' {
+			hi = '~'
+		}
+		for r := lo; r <= hi; r++ {
+			if unicode.IsPrint(r) {
+				printable = append(printable, r)
+			}
+		}
+	}
+	if len(printable) > 0 {
+		return printable[g.rand.Intn(len(printable))]
+	}
+	if len(ranges) < 2 {
+		return 'x'
+	}
+	i := 2 * g.rand.Intn(len(ranges)/2)
+	return ranges[i] + rune(g.rand.Int63n(int64(ranges[i+1]-ranges[i]+1)))
+}
+
+// fits reports whether a string's length is between min and max, if they're
+// set.
+func fits(v string, min int, max *int) bool {
+	n := len([]rune(v))
+	return n >= min && (max == nil || n <= *max)
+}
+
+// between returns a random number between min and max, using defMin and
+// defMax for limits which aren't set.
+func between(r *rand.Rand, min int, max *int, defMin, defMax int) int {
+	lo, hi := defMin, defMax
+	if min > 0 {
+		lo = min
+		if hi < lo {
+			hi = lo + defMax - defMin
+		}
+	}
+	if max != nil {
+		hi = *max
+		if lo > hi {
+			lo = hi
+		}
+	}
+	if hi < lo {
+		hi = lo
+	}
+	return lo + r.Intn(hi-lo+1)
+}
+
+func containsValue(list []interface{}, v interface{}) bool {
+	for _, e := range list {
+		if reflect.DeepEqual(e, v) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,261 @@
+package examplegen
+
+import (
+	"encoding/base64"
+	"math"
+	"math/rand"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/ericchiang/swaggopher/internal/conform"
+	"github.com/ericchiang/swaggopher/internal/synth"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+const petstore = `
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+paths: {}
+definitions:
+  Pet:
+    type: object
+    required: [id, name]
+    properties:
+      id: {type: string, format: uuid}
+      name: {type: string, minLength: 3, maxLength: 8}
+      age: {type: integer, minimum: 0, exclusiveMinimum: true, maximum: 3}
+      weight: {type: number, minimum: 0.5, maximum: 0.75}
+      price: {type: number, multipleOf: 0.25, minimum: 10, maximum: 11}
+      status: {type: string, enum: [available, pending, sold]}
+      born: {type: string, format: date}
+      updated: {type: string, format: date-time}
+      contact: {type: string, format: email}
+      photo: {type: string, format: byte}
+      code: {type: string, pattern: '^[A-Z]{3}-\d{2,4}$'}
+      tags: {type: array, minItems: 2, maxItems: 4, uniqueItems: true, items: {type: string, enum: [a, b, c, d]}}
+      labels: {type: object, additionalProperties: {type: boolean}}
+      owner: {$ref: '#/definitions/Owner'}
+  Owner:
+    type: object
+    properties:
+      name: {type: string}
+      pets: {type: array, items: {$ref: '#/definitions/Pet'}}
+`
+
+func TestGenerate(t *testing.T) {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(petstore), &s); err != nil {
+		t.Fatal(err)
+	}
+	pet := s.Definitions["Pet"]
+	code := regexp.MustCompile(`^[A-Z]{3}-\d{2,4}$`)
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	email := regexp.MustCompile(`^[a-z.]+@example\.com$`)
+
+	for seed := int64(0); seed < 50; seed++ {
+		v := New(Options{Doc: &s, Seed: seed}).Generate(&pet)
+		if msgs := conform.Value(&s, &pet, v, ""); len(msgs) > 0 {
+			t.Errorf("seed %d: generated value doesn't conform: %v", seed, msgs)
+			continue
+		}
+		p := v.(map[string]interface{})
+		if id := p["id"].(string); !uuid.MatchString(id) {
+			t.Errorf("seed %d: id %q is not a version 4 UUID", seed, id)
+		}
+		if name := p["name"].(string); len(name) < 3 || len(name) > 8 {
+			t.Errorf("seed %d: name %q has the wrong length", seed, name)
+		}
+		if age := p["age"].(float64); age <= 0 || age > 3 || age != math.Trunc(age) {
+			t.Errorf("seed %d: age %v out of bounds", seed, age)
+		}
+		if w := p["weight"].(float64); w < 0.5 || w > 0.75 {
+			t.Errorf("seed %d: weight %v out of bounds", seed, w)
+		}
+		if price := p["price"].(float64); price < 10 || price > 11 || math.Mod(price, 0.25) != 0 {
+			t.Errorf("seed %d: price %v isn't a multiple of 0.25 between 10 and 11", seed, price)
+		}
+		if _, err := time.Parse("2006-01-02", p["born"].(string)); err != nil {
+			t.Errorf("seed %d: born: %v", seed, err)
+		}
+		if _, err := time.Parse(time.RFC3339, p["updated"].(string)); err != nil {
+			t.Errorf("seed %d: updated: %v", seed, err)
+		}
+		if c := p["contact"].(string); !email.MatchString(c) {
+			t.Errorf("seed %d: contact %q is not an email address", seed, c)
+		}
+		if _, err := base64.StdEncoding.DecodeString(p["photo"].(string)); err != nil {
+			t.Errorf("seed %d: photo: %v", seed, err)
+		}
+		if c := p["code"].(string); !code.MatchString(c) {
+			t.Errorf("seed %d: code %q doesn't match its pattern", seed, c)
+		}
+		tags := p["tags"].([]interface{})
+		if len(tags) < 2 || len(tags) > 4 {
+			t.Errorf("seed %d: want 2 to 4 tags, got %v", seed, tags)
+		}
+		seen := make(map[interface{}]bool)
+		for _, tag := range tags {
+			if seen[tag] {
+				t.Errorf("seed %d: duplicate tag %v", seed, tag)
+			}
+			seen[tag] = true
+		}
+	}
+}
+
+func TestGenerateDeep(t *testing.T) {
+	var s spec.Swagger
+	doc := `
+definitions:
+  Node:
+    type: object
+    required: [name, tags]
+    properties:
+      name: {type: string}
+      tags: {type: array, minItems: 2, items: {type: string}}
+      next: {$ref: '#/definitions/Node'}
+`
+	if err := yaml.Unmarshal([]byte(doc), &s); err != nil {
+		t.Fatal(err)
+	}
+	node := s.Definitions["Node"]
+	for seed := int64(0); seed < 10; seed++ {
+		v := New(Options{Doc: &s, Seed: seed}).Generate(&node)
+		if msgs := conform.Value(&s, &node, v, ""); len(msgs) > 0 {
+			t.Errorf("seed %d: generated value doesn't conform: %v", seed, msgs)
+		}
+		// Past synth.MaxDepth, the optional next node is left out and only
+		// the fewest tags are generated.
+		n := v.(map[string]interface{})
+		for depth := 0; depth < synth.MaxDepth; depth++ {
+			n = n["next"].(map[string]interface{})
+		}
+		if _, ok := n["next"]; ok || len(n["tags"].([]interface{})) != 2 {
+			t.Errorf("seed %d: want a minimal node at depth %d, got %v", seed, synth.MaxDepth, n)
+		}
+	}
+}
+
+func TestGenerateSeeds(t *testing.T) {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(petstore), &s); err != nil {
+		t.Fatal(err)
+	}
+	pet := s.Definitions["Pet"]
+	a := New(Options{Doc: &s, Seed: 7}).Generate(&pet)
+	b := New(Options{Doc: &s, Seed: 7}).Generate(&pet)
+	if diff := pretty.Compare(a, b); diff != "" {
+		t.Errorf("same seed generated different values: %s", diff)
+	}
+	if diff := pretty.Compare(a, New(Options{Doc: &s, Rand: rand.NewSource(7)}).Generate(&pet)); diff != "" {
+		t.Errorf("source seeded with 7 generated a different value: %s", diff)
+	}
+	c := New(Options{Doc: &s, Seed: 8}).Generate(&pet)
+	if diff := pretty.Compare(a, c); diff == "" {
+		t.Errorf("different seeds generated the same value: %v", a)
+	}
+
+	str := &spec.Schema{Type: "string"}
+	if Generate(str) != Generate(str) {
+		t.Errorf("expected Generate to return the same value for the same schema")
+	}
+	// References can't be resolved without a document.
+	if v := Generate(&spec.Schema{Ref: "#/definitions/Pet"}); v != nil {
+		t.Errorf("expected nil for an unresolved reference, got %v", v)
+	}
+}
+
+func TestGenerateStable(t *testing.T) {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(petstore), &s); err != nil {
+		t.Fatal(err)
+	}
+	pet, owner := s.Definitions["Pet"], s.Definitions["Owner"]
+
+	g := New(Options{Doc: &s, Seed: 7, Stable: true})
+	a := g.Generate(&pet)
+	g.Generate(&owner)
+	if diff := pretty.Compare(a, g.Generate(&pet)); diff != "" {
+		t.Errorf("value changed after generating another schema: %s", diff)
+	}
+	if diff := pretty.Compare(a, New(Options{Doc: &s, Seed: 7, Stable: true}).Generate(&pet)); diff != "" {
+		t.Errorf("new generator with the same seed generated a different value: %s", diff)
+	}
+	if diff := pretty.Compare(a, New(Options{Doc: &s, Seed: 8, Stable: true}).Generate(&pet)); diff == "" {
+		t.Errorf("different seeds generated the same value: %v", a)
+	}
+
+	// Without Stable, each call continues the sequence.
+	g = New(Options{Doc: &s, Seed: 7})
+	if diff := pretty.Compare(g.Generate(&pet), g.Generate(&pet)); diff == "" {
+		t.Errorf("expected successive values to differ")
+	}
+}
+
+func TestGenerateRealistic(t *testing.T) {
+	var person spec.Schema
+	if err := yaml.Unmarshal([]byte(`
+type: object
+properties:
+  first_name: {type: string}
+  name: {type: string}
+  nickname: {type: string, maxLength: 3}
+  email: {type: string}
+  work: {type: string, format: email}
+  city: {type: string}
+  phone: {type: string}
+  company: {type: string, pattern: '^[A-Z]{4}$'}
+  emails: {type: array, items: {type: string}}
+`), &person); err != nil {
+		t.Fatal(err)
+	}
+	in := func(corpus []string, v interface{}) bool {
+		for _, c := range corpus {
+			if c == v {
+				return true
+			}
+		}
+		return false
+	}
+	email := regexp.MustCompile(`^[a-z]+\.[a-z]+@example\.com$`)
+	phone := regexp.MustCompile(`^\+1-\d{3}-555-01\d{2}$`)
+
+	for seed := int64(0); seed < 20; seed++ {
+		p := New(Options{Seed: seed, Realistic: true}).Generate(&person).(map[string]interface{})
+		if !in(firstNames, p["first_name"]) {
+			t.Errorf("seed %d: first_name %q is not a first name", seed, p["first_name"])
+		}
+		if name := strings.SplitN(p["name"].(string), " ", 2); len(name) != 2 || !in(firstNames, name[0]) || !in(lastNames, name[1]) {
+			t.Errorf("seed %d: name %q is not a full name", seed, p["name"])
+		}
+		if n := p["nickname"].(string); len(n) > 3 {
+			t.Errorf("seed %d: nickname %q is longer than its limit", seed, n)
+		}
+		for _, key := range []string{"email", "work"} {
+			if e := p[key].(string); !email.MatchString(e) {
+				t.Errorf("seed %d: %s %q is not a realistic email address", seed, key, e)
+			}
+		}
+		for _, e := range p["emails"].([]interface{}) {
+			if !email.MatchString(e.(string)) {
+				t.Errorf("seed %d: emails item %q is not a realistic email address", seed, e)
+			}
+		}
+		if !in(cities, p["city"]) {
+			t.Errorf("seed %d: city %q is not a city", seed, p["city"])
+		}
+		if ph := p["phone"].(string); !phone.MatchString(ph) {
+			t.Errorf("seed %d: phone %q is not a fictional phone number", seed, ph)
+		}
+		// Patterns take precedence.
+		if c := p["company"].(string); !regexp.MustCompile(`^[A-Z]{4}$`).MatchString(c) {
+			t.Errorf("seed %d: company %q doesn't match its pattern", seed, c)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package examplegen
+
+import (
+	"fmt"
+	"strings"
+)
+
+var (
+	firstNames = []string{"Alice", "Bruno", "Chen", "Dana", "Elif", "Farid", "Grace", "Hiro", "Ines", "Jamal", "Kofi", "Lena", "Mateo", "Nadia", "Oskar", "Priya"}
+	lastNames  = []string{"Abbott", "Baker", "Costa", "Diaz", "Evans", "Fischer", "Garcia", "Haddad", "Ivanova", "Jensen", "Kim", "Lopez", "Moreau", "Nguyen", "Okafor", "Patel"}
+	streets    = []string{"Main Street", "Oak Avenue", "Maple Drive", "Cedar Lane", "Elm Street", "Park Road", "Hill Street", "River Road", "Lake View", "Church Street"}
+	cities     = []string{"Springfield", "Riverton", "Lakeside", "Fairview", "Greenville", "Kingston", "Milford", "Oakridge", "Salem", "Westport"}
+	countries  = []string{"Australia", "Brazil", "Canada", "France", "Germany", "India", "Japan", "Kenya", "Mexico", "Norway"}
+	companies  = []string{"Acme Corporation", "Globex", "Initech", "Umbrella Foods", "Stark Logistics", "Wayne Analytics", "Hooli", "Vandelay Imports"}
+)
+
+// realisticString returns a value drawn from the corpus a property's name
+// suggests, if any. Names are compared without case, underscores or hyphens,
+// so "first_name" and "firstName" are alike.
+func (g *Generator) realisticString(name string) (string, bool) {
+	key := strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(name))
+	switch {
+	case key == "":
+		return "", false
+	case strings.Contains(key, "email"):
+		return g.email(), true
+	case key == "firstname" || key == "givenname":
+		return g.pick(firstNames), true
+	case key == "lastname" || key == "surname" || key == "familyname":
+		return g.pick(lastNames), true
+	case key == "username" || key == "login" || key == "handle":
+		return strings.ToLower(g.pick(firstNames)) + fmt.Sprint(g.rand.Intn(100)), true
+	case strings.Contains(key, "company") || strings.Contains(key, "organization"):
+		return g.pick(companies), true
+	case key == "name" || strings.HasSuffix(key, "fullname") || key == "displayname" || key == "ownername":
+		return g.pick(firstNames) + " " + g.pick(lastNames), true
+	case strings.Contains(key, "street") || key == "address" || key == "addressline1":
+		return fmt.Sprintf("%d %s", 1+g.rand.Intn(999), g.pick(streets)), true
+	case key == "city" || key == "town":
+		return g.pick(cities), true
+	case key == "country":
+		return g.pick(countries), true
+	case key == "zip" || key == "zipcode" || key == "postcode" || key == "postalcode":
+		return fmt.Sprintf("%05d", g.rand.Intn(100000)), true
+	case strings.Contains(key, "phone") || key == "mobile" || key == "telephone":
+		// 555-0100 through 555-0199 are reserved for fictional use.
+		return fmt.Sprintf("+1-%03d-555-01%02d", 200+g.rand.Intn(800), g.rand.Intn(100)), true
+	}
+	return "", false
+}
+
+// email returns an address at example.com built from a realistic name.
+func (g *Generator) email() string {
+	return strings.ToLower(g.pick(firstNames) + "." + g.pick(lastNames) + "@example.com")
+}
+
+func (g *Generator) pick(corpus []string) string {
+	return corpus[g.rand.Intn(len(corpus))]
+}
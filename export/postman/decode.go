@@ -0,0 +1,137 @@
+package postman
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Collections written by Postman and other tools use several forms for some
+// values, which are decoded into the single form this package writes.
+
+// Description is a description, which Postman writes as a string or as an
+// object holding its content and media type.
+type Description string
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Description) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Content string `json:"content"`
+	}
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "{") {
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		*d = Description(v.Content)
+		return nil
+	}
+	var s *string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s != nil {
+		*d = Description(*s)
+	}
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler. A request may be written as just
+// its URL, which is fetched with GET.
+func (r *Request) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err == nil {
+		*r = Request{Method: "GET", URL: parseURL(raw)}
+		return nil
+	}
+	type request Request
+	return json.Unmarshal(data, (*request)(r))
+}
+
+// UnmarshalJSON implements json.Unmarshaler. A URL may be written as just its
+// raw form.
+func (u *URL) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err == nil {
+		*u = parseURL(raw)
+		return nil
+	}
+	type url URL
+	return json.Unmarshal(data, (*url)(u))
+}
+
+// parseURL splits the raw form of a URL into its parts, as Postman does.
+// Variables, such as "{{baseUrl}}", are left in place.
+func parseURL(raw string) URL {
+	u := URL{Raw: raw}
+	rest := raw
+	if i := strings.Index(rest, "#"); i >= 0 {
+		rest = rest[:i]
+	}
+	if i := strings.Index(rest, "?"); i >= 0 {
+		for _, pair := range strings.Split(rest[i+1:], "&") {
+			if pair == "" {
+				continue
+			}
+			kv := strings.SplitN(pair, "=", 2)
+			q := Query{Key: kv[0]}
+			if len(kv) == 2 {
+				q.Value = kv[1]
+			}
+			u.Query = append(u.Query, q)
+		}
+		rest = rest[:i]
+	}
+	if i := strings.Index(rest, "://"); i >= 0 {
+		u.Protocol, rest = rest[:i], rest[i+len("://"):]
+	}
+	host := rest
+	if i := strings.Index(rest, "/"); i >= 0 {
+		host, rest = rest[:i], rest[i+1:]
+		u.Path = strings.Split(rest, "/")
+	}
+	if host != "" {
+		u.Host = strings.Split(host, ".")
+	}
+	return u
+}
+
+// UnmarshalJSON implements json.Unmarshaler. The value may be of any type.
+func (v *Variable) UnmarshalJSON(data []byte) error {
+	type variable Variable
+	var decoded struct {
+		variable
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*v = Variable(decoded.variable)
+	v.Value = text(decoded.Value)
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler. The value may be of any type.
+func (p *AuthParam) UnmarshalJSON(data []byte) error {
+	type authParam AuthParam
+	var decoded struct {
+		authParam
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*p = AuthParam(decoded.authParam)
+	p.Value = text(decoded.Value)
+	return nil
+}
+
+// text returns a JSON string's value, or the encoding of any other value.
+func text(data json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		return s
+	}
+	if string(data) == "null" {
+		return ""
+	}
+	return string(data)
+}
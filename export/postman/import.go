@@ -0,0 +1,416 @@
+package postman
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/genspec"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// Import builds a document from the requests of a collection, on a
+// best-effort basis, to bootstrap the documentation of an API which has none.
+//
+// Each request becomes an operation, summarized by its name and tagged with
+// its folder. Paths come from the requests' URLs, with collection variables
+// expanded and path variables, such as ":id" or an undefined "{{id}}",
+// becoming path parameters. Query parameters and headers become parameters
+// too, with types inferred from their values. Schemas of bodies are inferred
+// from the requests' JSON bodies and those of their saved responses, merged
+// across requests for the same operation. The host and schemes are those of
+// the first request, and auth becomes security definitions.
+func Import(c *Collection) (*spec.Swagger, error) {
+	if strings.Contains(c.Info.Schema, "/v1.") {
+		return nil, fmt.Errorf("postman: only v2.0 and v2.1 collections are supported, got %s", c.Info.Schema)
+	}
+	im := &importer{
+		auth: c.Auth,
+		doc: &spec.Swagger{
+			Swagger: "2.0",
+			Info:    &spec.Info{Title: c.Info.Name, Description: string(c.Info.Description), Version: c.Info.Version},
+			Paths:   spec.Paths{},
+		},
+		vars: make(map[string]string, len(c.Variable)),
+	}
+	if im.doc.Info.Title == "" {
+		im.doc.Info.Title = "Untitled"
+	}
+	if im.doc.Info.Version == "" {
+		im.doc.Info.Version = "1.0.0"
+	}
+	for _, v := range c.Variable {
+		im.vars[v.Key] = v.Value
+	}
+	if req, ok := im.security(c.Auth); ok {
+		im.doc.Security = req
+	}
+	im.items(c.Item, "")
+	// Every operation needs a response, even if no example was saved.
+	im.doc.RangeOperations(func(path, method string, op *spec.Operation) bool {
+		if len(op.Responses) == 0 {
+			op.Responses["default"] = spec.Response{Description: "Not documented."}
+		}
+		return true
+	})
+	return im.doc, nil
+}
+
+type importer struct {
+	doc *spec.Swagger
+	// auth is the collection's auth, which requests inherit.
+	auth *Auth
+	// vars holds the values of the collection's variables.
+	vars map[string]string
+	// hasHost is set once the host of the first request is recorded.
+	hasHost bool
+}
+
+func (im *importer) items(items []Item, folder string) {
+	for _, it := range items {
+		if it.Request == nil {
+			if len(it.Item) > 0 && !im.hasTag(it.Name) {
+				im.doc.Tags = append(im.doc.Tags, spec.Tag{Name: it.Name, Description: string(it.Description)})
+			}
+			im.items(it.Item, it.Name)
+			continue
+		}
+		im.request(&it, folder)
+	}
+}
+
+func (im *importer) hasTag(name string) bool {
+	for _, t := range im.doc.Tags {
+		if t.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+var variable = regexp.MustCompile(`\{\{([^{}]+)\}\}`)
+
+// expand replaces the collection variables in s with their values.
+func (im *importer) expand(s string) string {
+	return variable.ReplaceAllStringFunc(s, func(v string) string {
+		if val, ok := im.vars[v[2:len(v)-2]]; ok {
+			return val
+		}
+		return v
+	})
+}
+
+func (im *importer) request(it *Item, folder string) {
+	req := it.Request
+	u := req.URL
+	if len(u.Host) == 0 && len(u.Path) == 0 && u.Raw != "" {
+		u = parseURL(u.Raw)
+	}
+
+	protocol := im.expand(u.Protocol)
+	host := im.expand(strings.Join(u.Host, "."))
+	var segments []string
+	if strings.Contains(host, "/") {
+		// A variable, such as {{baseUrl}}, held the scheme or a path too.
+		base := parseURL(host)
+		if base.Protocol != "" {
+			protocol = base.Protocol
+		}
+		host, segments = strings.Join(base.Host, "."), base.Path
+	}
+	for _, seg := range u.Path {
+		segments = append(segments, strings.Split(im.expand(seg), "/")...)
+	}
+	if !im.hasHost && host != "" && !variable.MatchString(host) {
+		im.hasHost = true
+		im.doc.Host = host
+		if protocol == "http" || protocol == "https" {
+			im.doc.Schemes = []string{protocol}
+		}
+	}
+
+	op := &spec.Operation{Summary: it.Name, Description: string(req.Description)}
+	if folder != "" {
+		op.Tags = []string{folder}
+	}
+	pathVars := make(map[string]Variable, len(u.Variable))
+	for _, v := range u.Variable {
+		pathVars[v.Key] = v
+	}
+	var path []string
+	for _, seg := range segments {
+		name := ""
+		switch {
+		case seg == "":
+			continue
+		case strings.HasPrefix(seg, ":"):
+			name = seg[1:]
+		case variable.MatchString(seg) && variable.FindString(seg) == seg:
+			name = seg[2 : len(seg)-2]
+		}
+		if name == "" {
+			path = append(path, seg)
+			continue
+		}
+		path = append(path, "{"+name+"}")
+		v := pathVars[name]
+		p := spec.Parameter{Name: name, In: "path", Required: true, Description: string(v.Description), Type: valueType(im.expand(v.Value))}
+		op.Parameters = append(op.Parameters, p)
+	}
+	for _, q := range u.Query {
+		p := spec.Parameter{Name: q.Key, In: "query", Required: !q.Disabled, Description: string(q.Description), Type: valueType(im.expand(q.Value))}
+		op.Parameters = append(op.Parameters, p)
+	}
+
+	// The header holding an API key is described by its security scheme,
+	// rather than as a parameter.
+	apiKeyHeader := ""
+	if auth := req.Auth; auth != nil || im.auth != nil {
+		if auth == nil {
+			auth = im.auth
+		}
+		if auth.Type == "apikey" && authParam(auth.APIKey, "in") != "query" {
+			apiKeyHeader = authParam(auth.APIKey, "key")
+		}
+	}
+	contentType := ""
+	for _, h := range req.Header {
+		switch {
+		case strings.EqualFold(h.Key, "Content-Type"):
+			contentType = h.Value
+		case strings.EqualFold(h.Key, "Accept"), strings.EqualFold(h.Key, "Authorization"), strings.EqualFold(h.Key, apiKeyHeader):
+		default:
+			p := spec.Parameter{Name: h.Key, In: "header", Required: !h.Disabled, Description: string(h.Description), Type: "string"}
+			op.Parameters = append(op.Parameters, p)
+		}
+	}
+	im.body(op, req.Body, contentType)
+
+	op.Responses = spec.Responses{}
+	for _, resp := range it.Response {
+		code := "default"
+		if resp.Code != 0 {
+			code = strconv.Itoa(resp.Code)
+		}
+		r := spec.Response{Description: resp.Status}
+		if r.Description == "" {
+			r.Description = http.StatusText(resp.Code)
+		}
+		if r.Description == "" {
+			r.Description = resp.Name
+		}
+		var v interface{}
+		if err := json.Unmarshal([]byte(resp.Body), &v); err == nil {
+			r.Schema = genspec.SchemaFromValue(v)
+			r.Examples = spec.Example{"application/json": v}
+		}
+		if prev, ok := op.Responses[code]; ok {
+			r = mergeResponses(prev, r)
+		}
+		op.Responses[code] = r
+	}
+	if req.Auth != nil {
+		// Requests inherit the collection's auth unless they set their own.
+		if reqs, ok := im.security(req.Auth); ok && !reflect.DeepEqual(reqs, im.doc.Security) {
+			op.Security = reqs
+		}
+	}
+
+	method := strings.ToLower(req.Method)
+	if method == "" {
+		method = "get"
+	}
+	im.add("/"+strings.Join(path, "/"), method, op)
+}
+
+// body adds the parameters of a request body to an operation.
+func (im *importer) body(op *spec.Operation, b *Body, contentType string) {
+	if b == nil {
+		return
+	}
+	switch b.Mode {
+	case "raw":
+		if b.Raw == "" {
+			return
+		}
+		p := spec.Parameter{Name: "body", In: "body", Required: true}
+		var v interface{}
+		if err := json.Unmarshal([]byte(b.Raw), &v); err == nil {
+			p.Schema = genspec.SchemaFromValue(v)
+			p.Schema.Example = v
+			if contentType == "" {
+				contentType = "application/json"
+			}
+		} else {
+			p.Schema = &spec.Schema{Type: "string"}
+			if contentType == "" {
+				contentType = "text/plain"
+			}
+		}
+		op.Parameters = append(op.Parameters, p)
+	case "urlencoded", "formdata":
+		params := b.URLEncoded
+		contentType = "application/x-www-form-urlencoded"
+		if b.Mode == "formdata" {
+			params, contentType = b.FormData, "multipart/form-data"
+		}
+		for _, f := range params {
+			p := spec.Parameter{Name: f.Key, In: "formData", Required: !f.Disabled, Description: string(f.Description), Type: "string"}
+			if f.Type == "file" {
+				p.Type = "file"
+			}
+			op.Parameters = append(op.Parameters, p)
+		}
+	}
+	if contentType != "" {
+		op.Consumes = []string{contentType}
+	}
+}
+
+// add adds an operation to the document, merging it with any operation
+// already imported for the same method and path.
+func (im *importer) add(path, method string, op *spec.Operation) {
+	item := im.doc.Paths[path]
+	if prev := item.Operation(method); prev != nil {
+		op = mergeOperations(prev, op)
+	}
+	if item.SetOperation(method, op) {
+		im.doc.Paths[path] = item
+	}
+}
+
+// mergeOperations merges an operation into one imported from an earlier
+// request, keeping the earlier one's summary and description.
+func mergeOperations(prev, op *spec.Operation) *spec.Operation {
+	for _, p := range op.Parameters {
+		found := false
+		for i := range prev.Parameters {
+			q := &prev.Parameters[i]
+			if q.In != p.In || q.Name != p.Name {
+				continue
+			}
+			found = true
+			// A parameter is only required if every request sends it.
+			q.Required = q.Required && p.Required
+			if q.Schema != nil && p.Schema != nil {
+				example := q.Schema.Example
+				q.Schema = genspec.MergeSchemas(q.Schema, p.Schema)
+				q.Schema.Example = example
+			}
+		}
+		if !found {
+			p.Required = p.Required && p.In == "path"
+			prev.Parameters = append(prev.Parameters, p)
+		}
+	}
+	for code, r := range op.Responses {
+		if p, ok := prev.Responses[code]; ok {
+			r = mergeResponses(p, r)
+		}
+		prev.Responses[code] = r
+	}
+	return prev
+}
+
+func mergeResponses(prev, r spec.Response) spec.Response {
+	prev.Schema = genspec.MergeSchemas(prev.Schema, r.Schema)
+	if prev.Examples == nil {
+		prev.Examples = r.Examples
+	}
+	return prev
+}
+
+// valueType infers the type of a parameter from an example value.
+func valueType(v string) string {
+	if _, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return "integer"
+	}
+	if _, err := strconv.ParseFloat(v, 64); err == nil {
+		return "number"
+	}
+	if v == "true" || v == "false" {
+		return "boolean"
+	}
+	return "string"
+}
+
+func authParam(params []AuthParam, key string) string {
+	for _, p := range params {
+		if p.Key == key {
+			return p.Value
+		}
+	}
+	return ""
+}
+
+// security returns the security requirements of an auth, adding its scheme to
+// the document's securityDefinitions. ok is false if the auth is of a type
+// which can't be described.
+func (im *importer) security(a *Auth) (reqs []spec.SecurityRequirement, ok bool) {
+	if a == nil {
+		return nil, false
+	}
+	var name string
+	var scheme spec.SecurityScheme
+	var scopes []string
+	switch a.Type {
+	case "noauth":
+		return []spec.SecurityRequirement{}, true
+	case "basic":
+		name, scheme = "basic", spec.SecurityScheme{Type: "basic"}
+	case "apikey":
+		in := authParam(a.APIKey, "in")
+		if in == "" {
+			in = "header"
+		}
+		name, scheme = "apiKey", spec.SecurityScheme{Type: "apiKey", In: in, Name: authParam(a.APIKey, "key")}
+	case "bearer":
+		// Swagger 2.0 has no bearer scheme, so the token is described as
+		// an API key sent in the Authorization header.
+		name = "bearer"
+		scheme = spec.SecurityScheme{Type: "apiKey", In: "header", Name: "Authorization", Description: `A bearer token, sent as "Bearer <token>".`}
+	case "oauth2":
+		flows := map[string]string{
+			"implicit":             "implicit",
+			"password_credentials": "password",
+			"client_credentials":   "application",
+			"authorization_code":   "accessCode",
+		}
+		flow, ok := flows[authParam(a.OAuth2, "grant_type")]
+		if !ok {
+			flow = "accessCode"
+		}
+		name = "oauth2"
+		scheme = spec.SecurityScheme{Type: "oauth2", Flow: flow, Scopes: spec.Scopes{}}
+		f, _ := scheme.OAuth2()
+		if f.UsesAuthorization() {
+			scheme.AuthorizationUrl = im.expand(authParam(a.OAuth2, "authUrl"))
+		}
+		if f.UsesToken() {
+			scheme.TokenUrl = im.expand(authParam(a.OAuth2, "accessTokenUrl"))
+		}
+		scopes = strings.Fields(authParam(a.OAuth2, "scope"))
+	default:
+		return nil, false
+	}
+	if prev, ok := im.doc.SecurityDefinitions[name]; ok && prev.Type == "oauth2" {
+		scheme.Scopes = prev.Scopes
+	}
+	for _, s := range scopes {
+		if _, ok := scheme.Scopes[s]; !ok {
+			scheme.Scopes[s] = ""
+		}
+	}
+	if im.doc.SecurityDefinitions == nil {
+		im.doc.SecurityDefinitions = spec.SecurityDefinitions{}
+	}
+	im.doc.SecurityDefinitions[name] = scheme
+	if scopes == nil {
+		scopes = []string{}
+	}
+	return []spec.SecurityRequirement{{name: scopes}}, true
+}
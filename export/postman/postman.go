@@ -0,0 +1,459 @@
+/*
+Package postman converts documents to and from Postman collections, for teams
+who try out and share requests in Postman.
+
+Export returns a Postman v2.1 collection with a folder for each tag and a
+request for each operation, filled in with the document's examples, defaults
+and enums, or values generated from their schemas where there are none:
+
+	c, err := postman.Export(doc)
+	if err != nil {
+		// Handle error.
+	}
+	err = json.NewEncoder(w).Encode(c)
+
+Requests are sent to the collection's scheme, host and basePath variables,
+which default to the document's, so the collection can be pointed at another
+server by editing them. Security schemes become the collection's and
+requests' auth, with their credentials held in variables too.
+
+Import goes the other way, inferring a document from a v2.0 or v2.1 collection
+decoded from JSON, for APIs whose only documentation is their collection. The
+result is a starting point to be edited rather than a faithful description.
+*/
+package postman
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/internal/synth"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// SchemaURL is the schema of Postman v2.1 collections.
+const SchemaURL = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+// Collection is a Postman v2.1 collection.
+type Collection struct {
+	Info     Info       `json:"info"`
+	Item     []Item     `json:"item"`
+	Auth     *Auth      `json:"auth,omitempty"`
+	Variable []Variable `json:"variable,omitempty"`
+}
+
+// Info describes a collection.
+type Info struct {
+	Name        string      `json:"name"`
+	Description Description `json:"description,omitempty"`
+	Version     string      `json:"version,omitempty"`
+	// Schema is SchemaURL.
+	Schema string `json:"schema"`
+}
+
+// Item is a folder, which holds items, or a request.
+type Item struct {
+	Name        string      `json:"name"`
+	Description Description `json:"description,omitempty"`
+	Item        []Item      `json:"item,omitempty"`
+	Request     *Request    `json:"request,omitempty"`
+	// Response holds example responses saved with a request.
+	Response []Response `json:"response,omitempty"`
+}
+
+// Request is a request of a collection.
+type Request struct {
+	Method      string      `json:"method"`
+	Description Description `json:"description,omitempty"`
+	Header      []Header    `json:"header"`
+	URL         URL         `json:"url"`
+	Body        *Body       `json:"body,omitempty"`
+	// Auth overrides the collection's auth. Its type is "noauth" for
+	// requests which require no security.
+	Auth *Auth `json:"auth,omitempty"`
+}
+
+// Response is an example response of a request.
+type Response struct {
+	Name   string   `json:"name"`
+	Code   int      `json:"code,omitempty"`
+	Status string   `json:"status,omitempty"`
+	Header []Header `json:"header,omitempty"`
+	Body   string   `json:"body,omitempty"`
+}
+
+// Header is a header of a request or response.
+type Header struct {
+	Key         string      `json:"key"`
+	Value       string      `json:"value"`
+	Description Description `json:"description,omitempty"`
+	// Disabled headers are optional, and aren't sent unless they're
+	// enabled.
+	Disabled bool `json:"disabled,omitempty"`
+}
+
+// URL is the URL of a request. Path parameters are written as ":name" in the
+// path, with their values in Variable.
+type URL struct {
+	Raw      string     `json:"raw"`
+	Protocol string     `json:"protocol,omitempty"`
+	Host     []string   `json:"host,omitempty"`
+	Path     []string   `json:"path,omitempty"`
+	Query    []Query    `json:"query,omitempty"`
+	Variable []Variable `json:"variable,omitempty"`
+}
+
+// Query is a query parameter of a URL.
+type Query struct {
+	Key         string      `json:"key"`
+	Value       string      `json:"value"`
+	Description Description `json:"description,omitempty"`
+	Disabled    bool        `json:"disabled,omitempty"`
+}
+
+// Variable is a variable of a collection, or a path parameter of a URL.
+type Variable struct {
+	Key         string      `json:"key"`
+	Value       string      `json:"value"`
+	Type        string      `json:"type,omitempty"`
+	Description Description `json:"description,omitempty"`
+}
+
+// Body is the body of a request. Mode is "raw", "urlencoded" or "formdata".
+type Body struct {
+	Mode       string       `json:"mode"`
+	Raw        string       `json:"raw,omitempty"`
+	URLEncoded []Param      `json:"urlencoded,omitempty"`
+	FormData   []Param      `json:"formdata,omitempty"`
+	Options    *BodyOptions `json:"options,omitempty"`
+}
+
+// BodyOptions holds the language of a raw body, such as "json".
+type BodyOptions struct {
+	Raw struct {
+		Language string `json:"language"`
+	} `json:"raw"`
+}
+
+// Param is a field of a form body. Type is "text" or "file".
+type Param struct {
+	Key         string      `json:"key"`
+	Value       string      `json:"value,omitempty"`
+	Type        string      `json:"type"`
+	Description Description `json:"description,omitempty"`
+	Disabled    bool        `json:"disabled,omitempty"`
+}
+
+// Auth configures how requests are authenticated. Type is "noauth", "basic",
+// "apikey", "bearer" or "oauth2", and the parameters of the type are held in
+// the field named after it.
+type Auth struct {
+	Type   string      `json:"type"`
+	Basic  []AuthParam `json:"basic,omitempty"`
+	APIKey []AuthParam `json:"apikey,omitempty"`
+	Bearer []AuthParam `json:"bearer,omitempty"`
+	OAuth2 []AuthParam `json:"oauth2,omitempty"`
+}
+
+// AuthParam is a parameter of an auth type.
+type AuthParam struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Type  string `json:"type"`
+}
+
+// Export converts a document to a collection. Operations are placed in the
+// folder of their first tag, folders ordered as the document's tags and then
+// by name, and operations without tags follow the folders.
+func Export(doc *spec.Swagger) (*Collection, error) {
+	c := &Collection{Info: Info{Schema: SchemaURL}, Item: []Item{}}
+	if doc.Info != nil {
+		c.Info.Name = doc.Info.Title
+		c.Info.Description = Description(doc.Info.Description)
+		c.Info.Version = doc.Info.Version
+	}
+
+	scheme := "https"
+	if len(doc.Schemes) > 0 && !contains(doc.Schemes, "https") {
+		scheme = doc.Schemes[0]
+	}
+	host := doc.Host
+	if host == "" {
+		host = "localhost"
+	}
+	c.Variable = []Variable{
+		{Key: "scheme", Value: scheme, Type: "string"},
+		{Key: "host", Value: host, Type: "string"},
+	}
+	basePath := strings.Trim(doc.BasePath, "/")
+	if basePath != "" {
+		c.Variable = append(c.Variable, Variable{Key: "basePath", Value: basePath, Type: "string"})
+	}
+
+	e := &exporter{doc: doc, basePath: basePath, credentials: make(map[string]bool)}
+	var err error
+	if c.Auth, err = e.auth(doc.Security); err != nil {
+		return nil, err
+	}
+
+	folders := make(map[string]*Item)
+	var order []string
+	for _, t := range doc.Tags {
+		if _, ok := folders[t.Name]; !ok {
+			folders[t.Name] = &Item{Name: t.Name, Description: Description(t.Description)}
+			order = append(order, t.Name)
+		}
+	}
+	var untagged []Item
+	var undeclared []string
+	doc.RangeOperations(func(path, method string, op *spec.Operation) bool {
+		var req Item
+		if req, err = e.request(path, method, op); err != nil {
+			return false
+		}
+		if len(op.Tags) == 0 {
+			untagged = append(untagged, req)
+			return true
+		}
+		f, ok := folders[op.Tags[0]]
+		if !ok {
+			f = &Item{Name: op.Tags[0]}
+			folders[op.Tags[0]] = f
+			undeclared = append(undeclared, op.Tags[0])
+		}
+		f.Item = append(f.Item, req)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(undeclared)
+	for _, name := range append(order, undeclared...) {
+		// Declared tags without operations don't get empty folders.
+		if f := folders[name]; len(f.Item) > 0 {
+			c.Item = append(c.Item, *f)
+		}
+	}
+	c.Item = append(c.Item, untagged...)
+
+	credentials := make([]string, 0, len(e.credentials))
+	for name := range e.credentials {
+		credentials = append(credentials, name)
+	}
+	sort.Strings(credentials)
+	for _, name := range credentials {
+		c.Variable = append(c.Variable, Variable{Key: name, Value: "", Type: "string"})
+	}
+	return c, nil
+}
+
+type exporter struct {
+	doc      *spec.Swagger
+	basePath string
+	// credentials holds the variables auth refers to.
+	credentials map[string]bool
+}
+
+func (e *exporter) request(path, method string, op *spec.Operation) (Item, error) {
+	name := strings.ToUpper(method) + " " + path
+	it := Item{Name: name}
+	switch {
+	case op.Summary != "":
+		it.Name = op.Summary
+	case op.OperationId != "":
+		it.Name = op.OperationId
+	}
+	if op.OperationId != "" {
+		name = op.OperationId
+	}
+
+	req := &Request{Method: strings.ToUpper(method), Description: Description(op.Description), Header: []Header{}}
+	req.URL.Protocol = "{{scheme}}"
+	req.URL.Host = []string{"{{host}}"}
+	if e.basePath != "" {
+		req.URL.Path = append(req.URL.Path, "{{basePath}}")
+	}
+	for _, seg := range strings.Split(strings.Trim(path, "/"), "/") {
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			seg = ":" + seg[1:len(seg)-1]
+		}
+		if seg != "" {
+			req.URL.Path = append(req.URL.Path, seg)
+		}
+	}
+
+	item := e.doc.Paths[path]
+	consumes := op.Consumes
+	if len(consumes) == 0 {
+		consumes = e.doc.Consumes
+	}
+	var form []Param
+	hasFile := false
+	for _, p := range e.doc.OperationParameters(&item, op) {
+		if p.Ref != "" {
+			// References to undefined parameters are skipped.
+			continue
+		}
+		if p.In == "body" {
+			if p.Schema == nil {
+				continue
+			}
+			data, err := json.MarshalIndent(synth.Example(e.doc, p.Schema, true), "", "  ")
+			if err != nil {
+				return Item{}, fmt.Errorf("postman: %s: parameter %s: %v", name, p.Name, err)
+			}
+			req.Body = &Body{Mode: "raw", Raw: string(data), Options: &BodyOptions{}}
+			req.Body.Options.Raw.Language = "json"
+			contentType := "application/json"
+			for _, mt := range consumes {
+				if strings.Contains(mt, "json") {
+					contentType = mt
+					break
+				}
+			}
+			req.Header = append(req.Header, Header{Key: "Content-Type", Value: contentType})
+			continue
+		}
+		values := []string{""}
+		if p.Type != "file" {
+			var err error
+			if values, err = synth.Parameter(p); err != nil {
+				return Item{}, fmt.Errorf("postman: %s: parameter %s: %v", name, p.Name, err)
+			}
+		}
+		// Optional parameters are listed but disabled, so they're easy to
+		// turn on.
+		disabled := !p.Required
+		switch p.In {
+		case "path":
+			req.URL.Variable = append(req.URL.Variable, Variable{Key: p.Name, Value: values[0], Description: Description(p.Description)})
+		case "query":
+			for _, v := range values {
+				req.URL.Query = append(req.URL.Query, Query{Key: p.Name, Value: v, Description: Description(p.Description), Disabled: disabled})
+			}
+		case "header":
+			req.Header = append(req.Header, Header{Key: p.Name, Value: values[0], Description: Description(p.Description), Disabled: disabled})
+		case "formData":
+			typ := "text"
+			if p.Type == "file" {
+				typ, hasFile = "file", true
+			}
+			for _, v := range values {
+				form = append(form, Param{Key: p.Name, Value: v, Type: typ, Description: Description(p.Description), Disabled: disabled})
+			}
+		}
+	}
+	switch {
+	case len(form) > 0 && (hasFile || contains(consumes, "multipart/form-data")):
+		req.Body = &Body{Mode: "formdata", FormData: form}
+	case len(form) > 0:
+		req.Body = &Body{Mode: "urlencoded", URLEncoded: form}
+		req.Header = append(req.Header, Header{Key: "Content-Type", Value: "application/x-www-form-urlencoded"})
+	}
+	req.URL.Raw = rawURL(&req.URL)
+
+	if op.Security != nil {
+		auth, err := e.auth(op.Security)
+		if err != nil {
+			return Item{}, fmt.Errorf("postman: %s: %v", name, err)
+		}
+		if auth == nil {
+			auth = &Auth{Type: "noauth"}
+		}
+		req.Auth = auth
+	}
+	it.Request = req
+	return it, nil
+}
+
+// rawURL returns the URL as it's written in Postman's address bar.
+func rawURL(u *URL) string {
+	raw := u.Protocol + "://" + strings.Join(u.Host, ".")
+	if len(u.Path) > 0 {
+		raw += "/" + strings.Join(u.Path, "/")
+	}
+	var query []string
+	for _, q := range u.Query {
+		if !q.Disabled {
+			query = append(query, q.Key+"="+q.Value)
+		}
+	}
+	if len(query) > 0 {
+		raw += "?" + strings.Join(query, "&")
+	}
+	return raw
+}
+
+// auth returns the auth of the first scheme of the first of a list of
+// security requirements, or nil if there are none. Postman only supports one
+// scheme per request.
+func (e *exporter) auth(reqs []spec.SecurityRequirement) (*Auth, error) {
+	if len(reqs) == 0 || len(reqs[0]) == 0 {
+		return nil, nil
+	}
+	names := make([]string, 0, len(reqs[0]))
+	for name := range reqs[0] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	name := names[0]
+	scheme, ok := e.doc.SecurityDefinitions[name]
+	if !ok {
+		return nil, fmt.Errorf("postman: security scheme %q is not defined", name)
+	}
+	variable := func(key string) string {
+		e.credentials[key] = true
+		return "{{" + key + "}}"
+	}
+	param := func(key, value string) AuthParam {
+		return AuthParam{Key: key, Value: value, Type: "string"}
+	}
+	if in, key, ok := scheme.APIKey(); ok {
+		return &Auth{Type: "apikey", APIKey: []AuthParam{
+			param("key", key),
+			param("value", variable(name)),
+			param("in", in),
+		}}, nil
+	}
+	if scheme.IsBasic() {
+		return &Auth{Type: "basic", Basic: []AuthParam{
+			param("username", variable("username")),
+			param("password", variable("password")),
+		}}, nil
+	}
+	if flow, ok := scheme.OAuth2(); ok {
+		grants := map[string]string{
+			"implicit":    "implicit",
+			"password":    "password_credentials",
+			"application": "client_credentials",
+			"accessCode":  "authorization_code",
+		}
+		params := []AuthParam{
+			param("grant_type", grants[flow.Flow]),
+			param("accessToken", variable("accessToken")),
+			param("addTokenTo", "header"),
+		}
+		if flow.UsesAuthorization() {
+			params = append(params, param("authUrl", flow.AuthorizationURL))
+		}
+		if flow.UsesToken() {
+			params = append(params, param("accessTokenUrl", flow.TokenURL))
+		}
+		if scopes := reqs[0][name]; len(scopes) > 0 {
+			params = append(params, param("scope", strings.Join(scopes, " ")))
+		}
+		return &Auth{Type: "oauth2", OAuth2: params}, nil
+	}
+	return nil, fmt.Errorf("postman: security scheme %q has unsupported type %q", name, scheme.Type)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
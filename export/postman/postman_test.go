@@ -0,0 +1,374 @@
+package postman
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/ericchiang/swaggopher/spec"
+	"github.com/ericchiang/swaggopher/spectest"
+)
+
+const petstore = `
+swagger: "2.0"
+info: {title: Pets, version: "1.0", description: A pet store.}
+host: pets.example.com
+basePath: /v1
+schemes: [http, https]
+consumes: [application/json]
+securityDefinitions:
+  key: {type: apiKey, in: header, name: X-API-Key}
+  login: {type: basic}
+security:
+  - key: []
+tags:
+  - name: pets
+    description: Everyone's pets.
+  - name: stores
+paths:
+  /pets:
+    get:
+      tags: [pets]
+      summary: List pets.
+      operationId: listPets
+      parameters:
+        - {name: limit, in: query, type: integer, default: 10}
+        - {name: tag, in: query, type: string}
+      responses:
+        200: {description: The pets.}
+    post:
+      tags: [pets]
+      operationId: createPet
+      security:
+        - login: []
+      parameters:
+        - name: pet
+          in: body
+          schema: {$ref: '#/definitions/Pet'}
+      responses:
+        201: {description: Created.}
+  /pets/{id}:
+    parameters:
+      - {name: id, in: path, type: integer, required: true, description: The pet.}
+    delete:
+      tags: [admin]
+      security: []
+      responses:
+        204: {description: Deleted.}
+  /health:
+    get:
+      responses:
+        200: {description: OK.}
+definitions:
+  Pet:
+    type: object
+    required: [name]
+    properties:
+      name: {type: string, example: Rex}
+`
+
+const want = `{
+	"info": {
+		"name": "Pets",
+		"description": "A pet store.",
+		"version": "1.0",
+		"schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+	},
+	"auth": {
+		"type": "apikey",
+		"apikey": [
+			{"key": "key", "value": "X-API-Key", "type": "string"},
+			{"key": "value", "value": "{{key}}", "type": "string"},
+			{"key": "in", "value": "header", "type": "string"}
+		]
+	},
+	"variable": [
+		{"key": "scheme", "value": "https", "type": "string"},
+		{"key": "host", "value": "pets.example.com", "type": "string"},
+		{"key": "basePath", "value": "v1", "type": "string"},
+		{"key": "key", "value": "", "type": "string"},
+		{"key": "password", "value": "", "type": "string"},
+		{"key": "username", "value": "", "type": "string"}
+	],
+	"item": [
+		{
+			"name": "pets",
+			"description": "Everyone's pets.",
+			"item": [
+				{
+					"name": "List pets.",
+					"request": {
+						"method": "GET",
+						"header": [],
+						"url": {
+							"raw": "{{scheme}}://{{host}}/{{basePath}}/pets",
+							"protocol": "{{scheme}}",
+							"host": ["{{host}}"],
+							"path": ["{{basePath}}", "pets"],
+							"query": [
+								{"key": "limit", "value": "10", "disabled": true},
+								{"key": "tag", "value": "test", "disabled": true}
+							]
+						}
+					}
+				},
+				{
+					"name": "createPet",
+					"request": {
+						"method": "POST",
+						"header": [{"key": "Content-Type", "value": "application/json"}],
+						"url": {
+							"raw": "{{scheme}}://{{host}}/{{basePath}}/pets",
+							"protocol": "{{scheme}}",
+							"host": ["{{host}}"],
+							"path": ["{{basePath}}", "pets"]
+						},
+						"body": {
+							"mode": "raw",
+							"raw": "{\n  \"name\": \"Rex\"\n}",
+							"options": {"raw": {"language": "json"}}
+						},
+						"auth": {
+							"type": "basic",
+							"basic": [
+								{"key": "username", "value": "{{username}}", "type": "string"},
+								{"key": "password", "value": "{{password}}", "type": "string"}
+							]
+						}
+					}
+				}
+			]
+		},
+		{
+			"name": "admin",
+			"item": [
+				{
+					"name": "DELETE /pets/{id}",
+					"request": {
+						"method": "DELETE",
+						"header": [],
+						"url": {
+							"raw": "{{scheme}}://{{host}}/{{basePath}}/pets/:id",
+							"protocol": "{{scheme}}",
+							"host": ["{{host}}"],
+							"path": ["{{basePath}}", "pets", ":id"],
+							"variable": [{"key": "id", "value": "1", "description": "The pet."}]
+						},
+						"auth": {"type": "noauth"}
+					}
+				}
+			]
+		},
+		{
+			"name": "GET /health",
+			"request": {
+				"method": "GET",
+				"header": [],
+				"url": {
+					"raw": "{{scheme}}://{{host}}/{{basePath}}/health",
+					"protocol": "{{scheme}}",
+					"host": ["{{host}}"],
+					"path": ["{{basePath}}", "health"]
+				}
+			}
+		}
+	]
+}`
+
+func TestExport(t *testing.T) {
+	var doc spec.Swagger
+	if err := yaml.Unmarshal([]byte(petstore), &doc); err != nil {
+		t.Fatal(err)
+	}
+	c, err := Export(&doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got, w interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatal(err)
+	}
+	if diff := pretty.Compare(got, w); diff != "" {
+		t.Errorf("want != got: %s", diff)
+	}
+
+	doc.Security = []spec.SecurityRequirement{{"missing": {}}}
+	if _, err := Export(&doc); err == nil {
+		t.Errorf("expected an error for an undefined security scheme")
+	}
+}
+
+const collection = `{
+	"info": {
+		"name": "Pets",
+		"description": {"content": "A pet store.", "type": "text/markdown"},
+		"schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+	},
+	"variable": [
+		{"key": "baseUrl", "value": "https://pets.example.com/v1"},
+		{"key": "limit", "value": 10}
+	],
+	"auth": {
+		"type": "bearer",
+		"bearer": [{"key": "token", "value": "{{token}}", "type": "string"}]
+	},
+	"item": [
+		{
+			"name": "pets",
+			"description": "Everyone's pets.",
+			"item": [
+				{
+					"name": "List pets",
+					"request": {
+						"method": "GET",
+						"header": [{"key": "X-Request-ID", "value": "abc", "disabled": true}],
+						"url": "{{baseUrl}}/pets?limit={{limit}}"
+					},
+					"response": [
+						{"name": "Two pets", "code": 200, "body": "[{\"name\": \"Rex\", \"age\": 3}, {\"name\": \"Tom\", \"age\": null}]"}
+					]
+				},
+				{
+					"name": "Get pet",
+					"request": {
+						"method": "GET",
+						"url": {
+							"raw": "{{baseUrl}}/pets/:id",
+							"host": ["{{baseUrl}}"],
+							"path": ["pets", ":id"],
+							"variable": [{"key": "id", "value": "42", "description": "The pet."}]
+						}
+					}
+				},
+				{
+					"name": "Create pet",
+					"request": {
+						"method": "POST",
+						"header": [{"key": "Content-Type", "value": "application/json"}],
+						"url": "{{baseUrl}}/pets",
+						"body": {"mode": "raw", "raw": "{\"name\": \"Rex\", \"born\": \"2020-01-02\"}"}
+					}
+				},
+				{
+					"name": "Create pet with a tag",
+					"request": {
+						"method": "POST",
+						"url": "{{baseUrl}}/pets",
+						"body": {"mode": "raw", "raw": "{\"name\": \"Rex\", \"tag\": \"dog\"}"}
+					}
+				}
+			]
+		},
+		{
+			"name": "Health",
+			"request": {
+				"method": "GET",
+				"url": "{{baseUrl}}/health",
+				"auth": {"type": "noauth"}
+			}
+		}
+	]
+}`
+
+const imported = `
+swagger: "2.0"
+info: {title: Pets, description: A pet store., version: 1.0.0}
+host: pets.example.com
+schemes: [https]
+securityDefinitions:
+  bearer:
+    type: apiKey
+    in: header
+    name: Authorization
+    description: 'A bearer token, sent as "Bearer <token>".'
+security:
+  - bearer: []
+tags:
+  - {name: pets, description: Everyone's pets.}
+paths:
+  /v1/pets:
+    get:
+      tags: [pets]
+      summary: List pets
+      parameters:
+        - {name: limit, in: query, required: true, type: integer}
+        - {name: X-Request-ID, in: header, type: string}
+      responses:
+        200:
+          description: OK
+          schema:
+            type: array
+            items:
+              type: object
+              required: [age, name]
+              properties:
+                name: {type: string}
+                age: {type: integer, x-nullable: true}
+          examples:
+            application/json: [{name: Rex, age: 3}, {name: Tom, age: null}]
+    post:
+      tags: [pets]
+      summary: Create pet
+      consumes: [application/json]
+      parameters:
+        - name: body
+          in: body
+          required: true
+          schema:
+            type: object
+            required: [name]
+            properties:
+              name: {type: string}
+              born: {type: string, format: date}
+              tag: {type: string}
+            example: {name: Rex, born: "2020-01-02"}
+      responses:
+        default: {description: Not documented.}
+  /v1/pets/{id}:
+    get:
+      tags: [pets]
+      summary: Get pet
+      parameters:
+        - {name: id, in: path, required: true, description: The pet., type: integer}
+      responses:
+        default: {description: Not documented.}
+  /v1/health:
+    get:
+      summary: Health
+      security: []
+      responses:
+        default: {description: Not documented.}
+`
+
+func TestImport(t *testing.T) {
+	var c Collection
+	if err := json.Unmarshal([]byte(collection), &c); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Import(&c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want spec.Swagger
+	if err := spec.UnmarshalYAML([]byte(imported), &want); err != nil {
+		t.Fatal(err)
+	}
+	if diff := spectest.Diff(&want, got); diff != "" {
+		t.Errorf("imported document (-want +got):\n%s", diff)
+	}
+
+	c.Info.Schema = "https://schema.getpostman.com/json/collection/v1.0.0/collection.json"
+	if _, err := Import(&c); err == nil {
+		t.Errorf("expected an error for a v1 collection")
+	}
+}
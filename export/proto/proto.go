@@ -0,0 +1,816 @@
+/*
+Package proto converts documents to Protocol Buffers definitions, for APIs
+moving to gRPC while a gateway, such as grpc-gateway, keeps serving their JSON
+API.
+
+Export writes a proto3 file with a message for each definition and a service
+with an rpc for each operation. Each rpc is annotated with the method and path
+it's served at, so the gateway routes requests as the document describes:
+
+	service PetsService {
+	  // Get a pet.
+	  rpc GetPet(GetPetRequest) returns (Pet) {
+	    option (google.api.http) = {
+	      get: "/v1/pets/{pet_id}"
+	    };
+	  }
+	}
+
+An rpc's request message has a field for each path, query, form and body
+parameter, and it returns the message of its success response's schema.
+Responses which aren't objects are wrapped in a message whose field is the
+response_body, so the JSON the gateway serves is unchanged.
+
+Fields are numbered in the order of their names. Since adding a property can
+then renumber others, which breaks wire compatibility, a property's number can
+be fixed with the "x-proto-number" extension:
+
+	properties:
+	  name: {type: string, x-proto-number: 2}
+
+Schemas Protocol Buffers can't express, such as arrays of arrays or header
+parameters, which gRPC sends as metadata, are approximated or dropped and
+reported as losses.
+*/
+package proto
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/ericchiang/swaggopher/internal/jsonpointer"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// NumberExtension is the vendor extension fixing the field number of a
+// property.
+const NumberExtension = "x-proto-number"
+
+// Loss records part of a document which couldn't be represented in the
+// Protocol Buffers definitions and was dropped or approximated.
+type Loss struct {
+	// Path is a JSON pointer to the value in the document.
+	Path string `json:"path"`
+	// Message describes what was lost.
+	Message string `json:"message"`
+}
+
+func (l Loss) String() string {
+	return l.Path + ": " + l.Message
+}
+
+// Options configures Export. The zero value is valid.
+type Options struct {
+	// Package is the package of the definitions, such as "pets.v1". It
+	// defaults to the document's title in snake case.
+	Package string
+	// Service is the name of the service. It defaults to the document's
+	// title in camel case followed by "Service", such as "PetsService".
+	Service string
+	// GoPackage, if set, is the go_package option, such as
+	// "example.com/pets/v1;petspb".
+	GoPackage string
+}
+
+// Export converts a document to a proto3 file, using the default options. See
+// Options.Export.
+func Export(doc *spec.Swagger) ([]byte, []Loss, error) {
+	return Options{}.Export(doc)
+}
+
+// Export converts a document to a proto3 file, returning what couldn't be
+// converted as losses.
+func (o Options) Export(doc *spec.Swagger) ([]byte, []Loss, error) {
+	title := ""
+	if doc.Info != nil {
+		title = doc.Info.Title
+	}
+	if o.Package == "" {
+		o.Package = snake(title)
+	}
+	if o.Package == "" {
+		return nil, nil, fmt.Errorf("proto: a package is required, since the document has no title")
+	}
+	if o.Service == "" {
+		o.Service = camel(title) + "Service"
+	}
+
+	e := &exporter{doc: doc, names: make(map[string]bool), imports: make(map[string]bool)}
+	defNames := make([]string, 0, len(doc.Definitions))
+	for name := range doc.Definitions {
+		defNames = append(defNames, name)
+		e.names[camel(name)] = true
+	}
+	sort.Strings(defNames)
+	for _, name := range defNames {
+		def := doc.Definitions[name]
+		path := jsonpointer.Join("/definitions", name)
+		switch {
+		case isEnum(&def):
+			e.enums = append(e.enums, e.enum(camel(name), &def))
+		case isObject(&def):
+			m := &message{name: camel(name), comment: description(&def)}
+			e.fields(path, m, &def)
+			e.messages = append(e.messages, m)
+		}
+	}
+	if err := e.operations(); err != nil {
+		return nil, nil, err
+	}
+	return e.write(o, title), e.losses, nil
+}
+
+type exporter struct {
+	doc      *spec.Swagger
+	messages []*message
+	enums    []*enum
+	rpcs     []rpc
+	// names holds the names of the top-level messages and enums.
+	names   map[string]bool
+	imports map[string]bool
+	losses  []Loss
+}
+
+func (e *exporter) lose(path, format string, v ...interface{}) {
+	e.losses = append(e.losses, Loss{Path: path, Message: fmt.Sprintf(format, v...)})
+}
+
+type message struct {
+	name    string
+	comment string
+	fields  []field
+	nested  []*message
+	enums   []*enum
+}
+
+type field struct {
+	name     string
+	typ      string
+	number   int
+	repeated bool
+	optional bool
+	jsonName string
+	comment  string
+}
+
+type enum struct {
+	name    string
+	comment string
+	values  []string
+}
+
+type rpc struct {
+	name     string
+	comment  string
+	request  string
+	response string
+	method   string
+	path     string
+	// body and responseBody are the fields of the request and response
+	// holding the HTTP bodies, or "*" for the whole request.
+	body         string
+	responseBody string
+}
+
+// fieldType is the type of a field.
+type fieldType struct {
+	name     string
+	repeated bool
+	// scalar is set for types whose presence is only tracked if the field
+	// is optional.
+	scalar bool
+}
+
+func isEnum(s *spec.Schema) bool {
+	return len(s.Enum) > 0 && (s.Type == "string" || s.Type == "integer")
+}
+
+func isObject(s *spec.Schema) bool {
+	return s.Type == "object" && (len(s.Properties) > 0 || len(s.AllOf) > 0) ||
+		s.Type == "" && (len(s.Properties) > 0 || len(s.AllOf) > 0)
+}
+
+func description(s *spec.Schema) string {
+	if s.Description != "" {
+		return s.Description
+	}
+	return s.Title
+}
+
+// definition returns the definition a reference refers to.
+func (e *exporter) definition(ref string) (string, *spec.Schema, bool) {
+	if !strings.HasPrefix(ref, "#/definitions/") {
+		return "", nil, false
+	}
+	name := jsonpointer.Unescape(strings.TrimPrefix(ref, "#/definitions/"))
+	def, ok := e.doc.Definitions[name]
+	return name, &def, ok
+}
+
+// typeOf returns the type of a field holding values of a schema. Enums and
+// objects declared inline are nested in m, named after the field.
+func (e *exporter) typeOf(path string, m *message, fieldName string, s *spec.Schema) fieldType {
+	return e.typeOfDepth(path, m, fieldName, s, 0)
+}
+
+func (e *exporter) typeOfDepth(path string, m *message, fieldName string, s *spec.Schema, depth int) fieldType {
+	if s.Ref != "" {
+		name, def, ok := e.definition(s.Ref)
+		if !ok || depth > 8 {
+			e.lose(path, "reference %q is not to a definition", s.Ref)
+			return e.wellKnown("Value", false)
+		}
+		if isEnum(def) {
+			return fieldType{name: camel(name), scalar: true}
+		}
+		if isObject(def) {
+			return fieldType{name: camel(name)}
+		}
+		// Definitions of other types are aliases, written as their
+		// types.
+		return e.typeOfDepth(jsonpointer.Join("/definitions", name), m, fieldName, def, depth+1)
+	}
+	if isEnum(s) {
+		en := e.enum(m.name+camel(fieldName), s)
+		en.name = camel(fieldName)
+		m.enums = append(m.enums, en)
+		return fieldType{name: en.name, scalar: true}
+	}
+	switch s.Type {
+	case "array":
+		if s.Items == nil {
+			return fieldType{name: e.wellKnown("Value", false).name, repeated: true}
+		}
+		t := e.typeOfDepth(jsonpointer.Join(path, "items"), m, fieldName, s.Items, depth)
+		if t.repeated || strings.HasPrefix(t.name, "map<") {
+			e.lose(path, "arrays of arrays and maps are written as google.protobuf.ListValue")
+			return fieldType{name: e.wellKnown("ListValue", false).name, repeated: true}
+		}
+		t.repeated = true
+		return t
+	case "object", "":
+		if len(s.Properties) > 0 || len(s.AllOf) > 0 {
+			nested := &message{name: camel(fieldName), comment: description(s)}
+			e.fields(path, nested, s)
+			m.nested = append(m.nested, nested)
+			return fieldType{name: nested.name}
+		}
+		if s.Type == "" {
+			return e.wellKnown("Value", false)
+		}
+		if ap := s.AdditionalProperties; ap != nil && ap.Schema != nil {
+			t := e.typeOfDepth(jsonpointer.Join(path, "additionalProperties"), m, fieldName+"_value", ap.Schema, depth)
+			if t.repeated || strings.HasPrefix(t.name, "map<") {
+				e.lose(path, "maps of arrays and maps are written as google.protobuf.Struct")
+				return e.wellKnown("Struct", false)
+			}
+			return fieldType{name: "map<string, " + t.name + ">"}
+		}
+		return e.wellKnown("Struct", false)
+	case "string":
+		switch s.Format {
+		case "byte", "binary":
+			return fieldType{name: "bytes", scalar: true}
+		case "date-time":
+			return e.wellKnown("Timestamp", false)
+		}
+		return fieldType{name: "string", scalar: true}
+	case "integer":
+		if s.Format == "int32" {
+			return fieldType{name: "int32", scalar: true}
+		}
+		return fieldType{name: "int64", scalar: true}
+	case "number":
+		if s.Format == "float" {
+			return fieldType{name: "float", scalar: true}
+		}
+		return fieldType{name: "double", scalar: true}
+	case "boolean":
+		return fieldType{name: "bool", scalar: true}
+	case "file":
+		return fieldType{name: "bytes", scalar: true}
+	}
+	e.lose(path, "unknown type %q is written as google.protobuf.Value", s.Type)
+	return e.wellKnown("Value", false)
+}
+
+// wellKnownFiles are the files declaring the well-known types used.
+var wellKnownFiles = map[string]string{
+	"Empty":     "google/protobuf/empty.proto",
+	"ListValue": "google/protobuf/struct.proto",
+	"Struct":    "google/protobuf/struct.proto",
+	"Timestamp": "google/protobuf/timestamp.proto",
+	"Value":     "google/protobuf/struct.proto",
+}
+
+func (e *exporter) wellKnown(name string, repeated bool) fieldType {
+	e.imports[wellKnownFiles[name]] = true
+	return fieldType{name: "google.protobuf." + name, repeated: repeated}
+}
+
+// properties returns the properties of an object and those it's composed of
+// with allOf, and the names of those which are required.
+func (e *exporter) properties(path string, s *spec.Schema, props map[string]spec.Schema, required map[string]bool, paths map[string]string, depth int) {
+	for _, name := range s.Required {
+		required[name] = true
+	}
+	for name, p := range s.Properties {
+		if _, ok := props[name]; !ok {
+			props[name] = p
+			paths[name] = jsonpointer.Join(path, "properties", name)
+		}
+	}
+	for i := range s.AllOf {
+		part := &s.AllOf[i]
+		partPath := jsonpointer.Join(path, "allOf", strconv.Itoa(i))
+		if part.Ref != "" {
+			name, def, ok := e.definition(part.Ref)
+			if !ok || depth > 8 {
+				e.lose(partPath, "reference %q is not to a definition", part.Ref)
+				continue
+			}
+			part, partPath = def, jsonpointer.Join("/definitions", name)
+		}
+		e.properties(partPath, part, props, required, paths, depth+1)
+	}
+}
+
+// fields adds the fields of an object's properties to a message.
+func (e *exporter) fields(path string, m *message, s *spec.Schema) {
+	props := make(map[string]spec.Schema)
+	required := make(map[string]bool)
+	paths := make(map[string]string)
+	e.properties(path, s, props, required, paths, 0)
+	if ap := s.AdditionalProperties; ap != nil && (ap.Allowed || ap.Schema != nil) && len(props) > 0 {
+		e.lose(path, "additional properties beside declared ones are not supported")
+	}
+
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Fixed numbers are assigned first, and the rest fill the gaps.
+	numbers := make(map[string]int)
+	used := make(map[int]bool)
+	for _, name := range names {
+		p := props[name]
+		if n, ok := number(p.Extensions[NumberExtension]); ok {
+			if n < 1 || used[n] {
+				e.lose(paths[name], "%s %d is not a free field number", NumberExtension, n)
+				continue
+			}
+			numbers[name], used[n] = n, true
+		}
+	}
+	next := 1
+	fieldNames := make(map[string]bool)
+	for _, name := range names {
+		p := props[name]
+		fieldName := snake(name)
+		if fieldNames[fieldName] {
+			e.lose(paths[name], "property %s has the same field name as another, %s", name, fieldName)
+			continue
+		}
+		fieldNames[fieldName] = true
+		n, ok := numbers[name]
+		if !ok {
+			for used[next] {
+				next++
+			}
+			n, used[next] = next, true
+		}
+		t := e.typeOf(paths[name], m, fieldName, &p)
+		f := field{
+			name:     fieldName,
+			typ:      t.name,
+			number:   n,
+			repeated: t.repeated,
+			optional: t.scalar && !t.repeated && !required[name],
+			comment:  description(&p),
+		}
+		if lowerCamel(fieldName) != name {
+			f.jsonName = name
+		}
+		m.fields = append(m.fields, f)
+	}
+	sort.Slice(m.fields, func(i, j int) bool { return m.fields[i].number < m.fields[j].number })
+}
+
+func number(v interface{}) (int, bool) {
+	switch v := v.(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case float64:
+		if v == float64(int(v)) {
+			return int(v), true
+		}
+	}
+	return 0, false
+}
+
+// enum converts the values of an enum to a proto enum's, prefixed with its
+// name in upper snake case, after an unspecified zero value.
+func (e *exporter) enum(name string, s *spec.Schema) *enum {
+	prefix := strings.ToUpper(snake(name)) + "_"
+	en := &enum{name: name, comment: description(s), values: []string{prefix + "UNSPECIFIED"}}
+	seen := map[string]bool{en.values[0]: true}
+	for _, v := range s.Enum {
+		value := prefix + strings.ToUpper(snake(fmt.Sprint(v)))
+		if seen[value] {
+			continue
+		}
+		seen[value] = true
+		en.values = append(en.values, value)
+	}
+	return en
+}
+
+// unique returns a top-level name which isn't taken, based on name.
+func (e *exporter) unique(name string) string {
+	candidate := name
+	for i := 2; e.names[candidate]; i++ {
+		candidate = name + strconv.Itoa(i)
+	}
+	e.names[candidate] = true
+	return candidate
+}
+
+func (e *exporter) operations() error {
+	paths := make([]string, 0, len(e.doc.Paths))
+	for path := range e.doc.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	rpcNames := make(map[string]bool)
+	for _, path := range paths {
+		item := e.doc.Paths[path]
+		for _, method := range spec.Methods {
+			op := item.Operation(method)
+			if op == nil {
+				continue
+			}
+			opPath := jsonpointer.Join("/paths", path, method)
+			name := camel(op.OperationId)
+			if name == "" {
+				name = camel(method + " " + path)
+			}
+			if rpcNames[name] {
+				return fmt.Errorf("proto: %s: rpc %s is declared more than once", opPath, name)
+			}
+			rpcNames[name] = true
+			r := rpc{name: name, comment: op.Summary, method: method}
+			if r.comment == "" {
+				r.comment = op.Description
+			}
+			e.request(opPath, &r, path, item.Parameters, op)
+			e.response(opPath, &r, op)
+			e.rpcs = append(e.rpcs, r)
+		}
+	}
+	return nil
+}
+
+// parameter returns the parameter a reference refers to.
+func (e *exporter) parameter(p spec.Parameter) (spec.Parameter, bool) {
+	q, ok := e.doc.ResolveParameter(&p)
+	return *q, ok
+}
+
+// locations orders the fields of a request message.
+var locations = map[string]int{"path": 0, "query": 1, "formData": 2, "body": 3}
+
+func (e *exporter) request(opPath string, r *rpc, path string, shared []spec.Parameter, op *spec.Operation) {
+	type param struct {
+		spec.Parameter
+		path string
+	}
+	var params []param
+	index := make(map[string]int)
+	for i, list := range [][]spec.Parameter{shared, op.Parameters} {
+		base := jsonpointer.Join("/paths", path, "parameters")
+		if i == 1 {
+			base = jsonpointer.Join(opPath, "parameters")
+		}
+		for j, p := range list {
+			at := jsonpointer.Join(base, strconv.Itoa(j))
+			p, ok := e.parameter(p)
+			if !ok {
+				e.lose(at, "reference %q is not to a parameter", p.Ref)
+				continue
+			}
+			key := p.In + " " + p.Name
+			if k, ok := index[key]; ok {
+				params[k] = param{p, at}
+				continue
+			}
+			index[key] = len(params)
+			params = append(params, param{p, at})
+		}
+	}
+	sort.SliceStable(params, func(i, j int) bool { return locations[params[i].In] < locations[params[j].In] })
+
+	m := &message{name: e.unique(r.name + "Request")}
+	r.path = e.doc.BasePath + path
+	for _, p := range params {
+		name := snake(p.Name)
+		var t fieldType
+		switch p.In {
+		case "header":
+			e.lose(p.path, "header parameter %s is sent as gRPC metadata, not a field", p.Name)
+			continue
+		case "body":
+			if p.Schema == nil {
+				continue
+			}
+			t = e.typeOf(jsonpointer.Join(p.path, "schema"), m, name, p.Schema)
+			r.body = name
+		default:
+			s := p.ValueSchema()
+			s.Description = p.Description
+			t = e.typeOf(p.path, m, name, s)
+			if p.In == "formData" {
+				r.body = "*"
+			}
+		}
+		if p.In == "path" {
+			r.path = strings.Replace(r.path, "{"+p.Name+"}", "{"+name+"}", -1)
+		}
+		f := field{
+			name:     name,
+			typ:      t.name,
+			number:   len(m.fields) + 1,
+			repeated: t.repeated,
+			optional: t.scalar && !t.repeated && !p.Required && p.In != "body",
+			comment:  p.Description,
+		}
+		if lowerCamel(name) != p.Name {
+			f.jsonName = p.Name
+		}
+		m.fields = append(m.fields, f)
+	}
+	if len(m.fields) == 0 {
+		delete(e.names, m.name)
+		r.request = e.wellKnown("Empty", false).name
+		return
+	}
+	e.messages = append(e.messages, m)
+	r.request = m.name
+}
+
+// successCode returns the code of an operation's success response: the lowest
+// 2xx, or the default.
+func successCode(op *spec.Operation) string {
+	codes := make([]string, 0, len(op.Responses))
+	for code := range op.Responses {
+		if strings.HasPrefix(code, "2") {
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+	if len(codes) > 0 {
+		return codes[0]
+	}
+	if _, ok := op.Responses["default"]; ok {
+		return "default"
+	}
+	return ""
+}
+
+func (e *exporter) response(opPath string, r *rpc, op *spec.Operation) {
+	code := successCode(op)
+	resp, ok := op.Responses[code]
+	if ok && resp.Ref != "" {
+		resp, ok = e.doc.Responses[jsonpointer.Unescape(strings.TrimPrefix(resp.Ref, "#/responses/"))]
+	}
+	if !ok || resp.Schema == nil {
+		r.response = e.wellKnown("Empty", false).name
+		return
+	}
+	path := jsonpointer.Join(opPath, "responses", code, "schema")
+	s := resp.Schema
+	if s.Ref != "" {
+		if name, def, ok := e.definition(s.Ref); ok && isObject(def) {
+			r.response = camel(name)
+			return
+		}
+	}
+	m := &message{name: e.unique(r.name + "Response")}
+	if s.Ref == "" && isObject(s) {
+		e.fields(path, m, s)
+	} else {
+		// Other responses are wrapped, with the gateway serving the
+		// field as the body.
+		name := "value"
+		if s.Type == "array" {
+			name = "items"
+		}
+		t := e.typeOf(path, m, name, s)
+		m.fields = []field{{name: name, typ: t.name, number: 1, repeated: t.repeated}}
+		r.responseBody = name
+	}
+	e.messages = append(e.messages, m)
+	r.response = m.name
+}
+
+func (e *exporter) write(o Options, title string) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by swaggopher. DO NOT EDIT.\n")
+	if title != "" {
+		fmt.Fprintf(&b, "// Source: %s", title)
+		if e.doc.Info.Version != "" {
+			fmt.Fprintf(&b, " %s", e.doc.Info.Version)
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "\nsyntax = \"proto3\";\n\npackage %s;\n", o.Package)
+
+	if len(e.rpcs) > 0 {
+		e.imports["google/api/annotations.proto"] = true
+	}
+	if len(e.imports) > 0 {
+		b.WriteString("\n")
+		imports := make([]string, 0, len(e.imports))
+		for imp := range e.imports {
+			imports = append(imports, imp)
+		}
+		sort.Strings(imports)
+		for _, imp := range imports {
+			fmt.Fprintf(&b, "import %q;\n", imp)
+		}
+	}
+	if o.GoPackage != "" {
+		fmt.Fprintf(&b, "\noption go_package = %q;\n", o.GoPackage)
+	}
+
+	if len(e.rpcs) > 0 {
+		b.WriteString("\n")
+		comment(&b, "", title)
+		fmt.Fprintf(&b, "service %s {\n", o.Service)
+		for i, r := range e.rpcs {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			writeRPC(&b, r)
+		}
+		b.WriteString("}\n")
+	}
+	for _, en := range e.enums {
+		b.WriteString("\n")
+		writeEnum(&b, "", en)
+	}
+	for _, m := range e.messages {
+		b.WriteString("\n")
+		writeMessage(&b, "", m)
+	}
+	return b.Bytes()
+}
+
+func writeRPC(b *bytes.Buffer, r rpc) {
+	comment(b, "  ", r.comment)
+	fmt.Fprintf(b, "  rpc %s(%s) returns (%s) {\n", r.name, r.request, r.response)
+	b.WriteString("    option (google.api.http) = {\n")
+	switch r.method {
+	case "get", "put", "post", "delete", "patch":
+		fmt.Fprintf(b, "      %s: %q\n", r.method, r.path)
+	default:
+		fmt.Fprintf(b, "      custom: {kind: %q, path: %q}\n", strings.ToUpper(r.method), r.path)
+	}
+	if r.body != "" && r.method != "get" {
+		fmt.Fprintf(b, "      body: %q\n", r.body)
+	}
+	if r.responseBody != "" {
+		fmt.Fprintf(b, "      response_body: %q\n", r.responseBody)
+	}
+	b.WriteString("    };\n  }\n")
+}
+
+func writeEnum(b *bytes.Buffer, indent string, en *enum) {
+	comment(b, indent, en.comment)
+	fmt.Fprintf(b, "%senum %s {\n", indent, en.name)
+	for i, v := range en.values {
+		fmt.Fprintf(b, "%s  %s = %d;\n", indent, v, i)
+	}
+	fmt.Fprintf(b, "%s}\n", indent)
+}
+
+func writeMessage(b *bytes.Buffer, indent string, m *message) {
+	comment(b, indent, m.comment)
+	fmt.Fprintf(b, "%smessage %s {\n", indent, m.name)
+	for _, en := range m.enums {
+		writeEnum(b, indent+"  ", en)
+	}
+	for _, n := range m.nested {
+		writeMessage(b, indent+"  ", n)
+	}
+	for _, f := range m.fields {
+		comment(b, indent+"  ", f.comment)
+		b.WriteString(indent + "  ")
+		switch {
+		case f.repeated:
+			b.WriteString("repeated ")
+		case f.optional:
+			b.WriteString("optional ")
+		}
+		fmt.Fprintf(b, "%s %s = %d", f.typ, f.name, f.number)
+		if f.jsonName != "" {
+			fmt.Fprintf(b, " [json_name = %q]", f.jsonName)
+		}
+		b.WriteString(";\n")
+	}
+	fmt.Fprintf(b, "%s}\n", indent)
+}
+
+func comment(b *bytes.Buffer, indent, text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+	for _, line := range strings.Split(text, "\n") {
+		fmt.Fprintf(b, "%s// %s\n", indent, strings.TrimRight(line, " "))
+	}
+}
+
+// words splits a name from a document, such as "pet_id", "petId" or
+// "get /pets/{petId}", into its words.
+func words(s string) []string {
+	var (
+		out  []string
+		word []rune
+	)
+	runes := []rune(s)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			if len(word) > 0 {
+				out = append(out, string(word))
+				word = nil
+			}
+		case unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])):
+			out = append(out, string(word))
+			word = []rune{r}
+		default:
+			word = append(word, r)
+		}
+	}
+	if len(word) > 0 {
+		out = append(out, string(word))
+	}
+	return out
+}
+
+// camel converts a name to upper camel case, as messages are named, such as
+// "PetOwner".
+func camel(s string) string {
+	var b strings.Builder
+	for _, w := range words(s) {
+		r := []rune(strings.ToLower(w))
+		b.WriteRune(unicode.ToUpper(r[0]))
+		b.WriteString(string(r[1:]))
+	}
+	name := b.String()
+	if name != "" && unicode.IsDigit([]rune(name)[0]) {
+		name = "X" + name
+	}
+	return name
+}
+
+// snake converts a name to lower snake case, as fields are named, such as
+// "pet_id".
+func snake(s string) string {
+	ws := words(s)
+	for i, w := range ws {
+		ws[i] = strings.ToLower(w)
+	}
+	name := strings.Join(ws, "_")
+	if name != "" && unicode.IsDigit([]rune(name)[0]) {
+		name = "x" + name
+	}
+	return name
+}
+
+// lowerCamel returns the JSON name protoc gives a field by default, such as
+// "petId" for "pet_id".
+func lowerCamel(field string) string {
+	var b strings.Builder
+	upper := false
+	for _, r := range field {
+		if r == '_' {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,262 @@
+package proto
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+const petstore = `
+swagger: "2.0"
+info: {title: Pet Store, version: "1.0"}
+basePath: /v1
+paths:
+  /pets:
+    get:
+      summary: List pets.
+      operationId: listPets
+      parameters:
+        - {name: pageSize, in: query, type: integer, format: int32}
+        - {name: tags, in: query, type: array, items: {type: string}}
+        - {name: X-Request-ID, in: header, type: string}
+      responses:
+        200:
+          description: The pets.
+          schema:
+            type: array
+            items: {$ref: '#/definitions/Pet'}
+    post:
+      operationId: createPet
+      parameters:
+        - name: pet
+          in: body
+          required: true
+          schema: {$ref: '#/definitions/Pet'}
+      responses:
+        201: {description: Created., schema: {$ref: '#/definitions/Pet'}}
+  /pets/{petId}:
+    parameters:
+      - {name: petId, in: path, type: integer, format: int64, required: true, description: The pet.}
+    get:
+      operationId: getPet
+      responses:
+        200: {description: The pet., schema: {$ref: '#/definitions/Pet'}}
+    delete:
+      responses:
+        204: {description: Deleted.}
+    head:
+      operationId: petExists
+      responses:
+        200: {description: It exists.}
+  /health:
+    get:
+      operationId: health
+      responses:
+        200: {description: OK., schema: {type: string}}
+definitions:
+  Pet:
+    description: A pet.
+    allOf:
+      - $ref: '#/definitions/Named'
+      - type: object
+        required: [id]
+        properties:
+          id: {type: integer, format: int64}
+          status: {type: string, enum: [available, sold], x-proto-number: 10}
+          born: {type: string, format: date-time}
+          photo_urls: {type: array, items: {type: string}}
+          labels: {type: object, additionalProperties: {type: string}}
+          owner:
+            type: object
+            properties:
+              name: {type: string}
+          grid: {type: array, items: {type: array, items: {type: integer}}}
+  Named:
+    type: object
+    required: [name]
+    properties:
+      name: {type: string, description: The name.}
+  Kind:
+    type: string
+    enum: [cat, dog]
+  Tags:
+    type: array
+    items: {type: string}
+`
+
+const want = `// Code generated by swaggopher. DO NOT EDIT.
+// Source: Pet Store 1.0
+
+syntax = "proto3";
+
+package pet_store;
+
+import "google/api/annotations.proto";
+import "google/protobuf/empty.proto";
+import "google/protobuf/struct.proto";
+import "google/protobuf/timestamp.proto";
+
+option go_package = "example.com/pets;petspb";
+
+// Pet Store
+service PetStoreService {
+  rpc Health(google.protobuf.Empty) returns (HealthResponse) {
+    option (google.api.http) = {
+      get: "/v1/health"
+      response_body: "value"
+    };
+  }
+
+  // List pets.
+  rpc ListPets(ListPetsRequest) returns (ListPetsResponse) {
+    option (google.api.http) = {
+      get: "/v1/pets"
+      response_body: "items"
+    };
+  }
+
+  rpc CreatePet(CreatePetRequest) returns (Pet) {
+    option (google.api.http) = {
+      post: "/v1/pets"
+      body: "pet"
+    };
+  }
+
+  rpc GetPet(GetPetRequest) returns (Pet) {
+    option (google.api.http) = {
+      get: "/v1/pets/{pet_id}"
+    };
+  }
+
+  rpc DeletePetsPetId(DeletePetsPetIdRequest) returns (google.protobuf.Empty) {
+    option (google.api.http) = {
+      delete: "/v1/pets/{pet_id}"
+    };
+  }
+
+  rpc PetExists(PetExistsRequest) returns (google.protobuf.Empty) {
+    option (google.api.http) = {
+      custom: {kind: "HEAD", path: "/v1/pets/{pet_id}"}
+    };
+  }
+}
+
+enum Kind {
+  KIND_UNSPECIFIED = 0;
+  KIND_CAT = 1;
+  KIND_DOG = 2;
+}
+
+message Named {
+  // The name.
+  string name = 1;
+}
+
+// A pet.
+message Pet {
+  enum Status {
+    PET_STATUS_UNSPECIFIED = 0;
+    PET_STATUS_AVAILABLE = 1;
+    PET_STATUS_SOLD = 2;
+  }
+  message Owner {
+    optional string name = 1;
+  }
+  google.protobuf.Timestamp born = 1;
+  repeated google.protobuf.ListValue grid = 2;
+  int64 id = 3;
+  map<string, string> labels = 4;
+  // The name.
+  string name = 5;
+  Owner owner = 6;
+  repeated string photo_urls = 7 [json_name = "photo_urls"];
+  optional Status status = 10;
+}
+
+message HealthResponse {
+  string value = 1;
+}
+
+message ListPetsRequest {
+  optional int32 page_size = 1;
+  repeated string tags = 2;
+}
+
+message ListPetsResponse {
+  repeated Pet items = 1;
+}
+
+message CreatePetRequest {
+  Pet pet = 1;
+}
+
+message GetPetRequest {
+  // The pet.
+  int64 pet_id = 1;
+}
+
+message DeletePetsPetIdRequest {
+  // The pet.
+  int64 pet_id = 1;
+}
+
+message PetExistsRequest {
+  // The pet.
+  int64 pet_id = 1;
+}
+`
+
+func TestExport(t *testing.T) {
+	var doc spec.Swagger
+	if err := spec.UnmarshalYAML([]byte(petstore), &doc); err != nil {
+		t.Fatal(err)
+	}
+	got, losses, err := Options{GoPackage: "example.com/pets;petspb"}.Export(&doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("wanted:\n%s\ngot:\n%s", want, got)
+	}
+	var gotLosses []string
+	for _, l := range losses {
+		gotLosses = append(gotLosses, l.String())
+	}
+	wantLosses := []string{
+		"/definitions/Pet/allOf/1/properties/grid: arrays of arrays and maps are written as google.protobuf.ListValue",
+		"/paths/~1pets/get/parameters/2: header parameter X-Request-ID is sent as gRPC metadata, not a field",
+	}
+	if !reflect.DeepEqual(gotLosses, wantLosses) {
+		t.Errorf("wanted losses %q, got %q", wantLosses, gotLosses)
+	}
+}
+
+func TestExportOptions(t *testing.T) {
+	var doc spec.Swagger
+	if err := spec.UnmarshalYAML([]byte(petstore), &doc); err != nil {
+		t.Fatal(err)
+	}
+	got, _, err := Options{Package: "pets.v1", Service: "Pets"}.Export(&doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"package pets.v1;", "service Pets {"} {
+		if !strings.Contains(string(got), s) {
+			t.Errorf("expected output to contain %q", s)
+		}
+	}
+	if strings.Contains(string(got), "go_package") {
+		t.Errorf("expected no go_package option")
+	}
+
+	doc.Info.Title = ""
+	if _, _, err := Export(&doc); err == nil {
+		t.Errorf("expected an error without a package")
+	}
+	doc.Paths["/pets"].Get.OperationId = "getPet"
+	if _, _, err := (Options{Package: "pets"}).Export(&doc); err == nil {
+		t.Errorf("expected an error for rpcs of the same name")
+	}
+}
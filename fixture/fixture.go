@@ -0,0 +1,282 @@
+/*
+Package fixture describes the data operations need before they can be tested,
+such as the resources their path parameters refer to.
+
+Fixtures are written in JSON or YAML, keyed by the operationId of the
+operation they apply to, or its method and path. Each lists requests to send
+first, and values for the operation's parameters, which may be taken from the
+responses to those requests:
+
+	getPet:
+	  create:
+	  - operation: createPet
+	    as: pet
+	    body: {name: Rex}
+	  values:
+	    petId: $pet.body#/id
+	GET /owners/{ownerId}/pets:
+	  create:
+	  - operation: createOwner
+	    as: owner
+	  - operation: POST /owners/{ownerId}/pets
+	    values:
+	      ownerId: $owner.body#/id
+	  values:
+	    path.ownerId: $owner.body#/id
+
+Parameters are named as in the operation, optionally prefixed by their
+location. Values starting with "$" refer to the response of an earlier
+request, by its "as" name or operationId: "$pet.body#/id" is the "id" field of
+its JSON body, "$pet.header.Location" a header and "$pet.status" its status.
+Start a literal value with "$$" to send a single "$".
+
+Package contract runs the requests of an operation's fixture before testing
+it.
+*/
+package fixture
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/internal/jsonpointer"
+	"github.com/ericchiang/swaggopher/internal/mapkeys"
+	"github.com/ericchiang/swaggopher/internal/rawdoc"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// Fixtures maps the operationId, or method and path such as "GET /pets/{id}",
+// of operations to their fixtures.
+type Fixtures map[string]Fixture
+
+// Fixture describes the data an operation needs.
+type Fixture struct {
+	// Create lists requests to send, in order, before the operation is
+	// tested.
+	Create []Step `json:"create,omitempty" yaml:"create,omitempty"`
+	// Values maps the operation's parameters to their values.
+	Values map[string]string `json:"values,omitempty" yaml:"values,omitempty"`
+}
+
+// Step is a request sent to set up an operation's data.
+type Step struct {
+	// Operation is the operationId, or method and path, of the operation
+	// called.
+	Operation string `json:"operation" yaml:"operation"`
+	// As names the response, for values of later requests to refer to. It
+	// defaults to the operationId.
+	As string `json:"as,omitempty" yaml:"as,omitempty"`
+	// Values maps the operation's parameters to their values. Parameters
+	// without one are given valid values.
+	Values map[string]string `json:"values,omitempty" yaml:"values,omitempty"`
+	// Body is the request's JSON body. If nil, one is generated from the
+	// body parameter's schema.
+	Body interface{} `json:"body,omitempty" yaml:"body,omitempty"`
+}
+
+// Name returns the name later values refer to the step's response by.
+func (s Step) Name(doc *spec.Swagger) string {
+	if s.As != "" {
+		return s.As
+	}
+	if _, _, op, ok := Find(doc, s.Operation); ok && op.OperationId != "" {
+		return op.OperationId
+	}
+	return s.Operation
+}
+
+// Parse decodes fixtures from a JSON or YAML document.
+func Parse(data []byte) (Fixtures, error) {
+	doc, err := rawdoc.Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("fixture: %v", err)
+	}
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("fixture: %v", err)
+	}
+	var f Fixtures
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("fixture: %v", err)
+	}
+	return f, nil
+}
+
+// Lookup returns the fixture of an operation, keyed by its operationId or its
+// method and path.
+func (f Fixtures) Lookup(method, path string, op *spec.Operation) (Fixture, bool) {
+	if op.OperationId != "" {
+		if fx, ok := f[op.OperationId]; ok {
+			return fx, true
+		}
+	}
+	fx, ok := f[strings.ToUpper(method)+" "+path]
+	return fx, ok
+}
+
+// Find returns the operation an operationId, or method and path, refers to.
+func Find(doc *spec.Swagger, key string) (method, path string, op *spec.Operation, ok bool) {
+	if i := strings.Index(key, " "); i >= 0 {
+		method, path = strings.ToLower(key[:i]), key[i+1:]
+		item, ok := doc.Paths[path]
+		if !ok {
+			return "", "", nil, false
+		}
+		if op := item.Operation(method); op != nil {
+			return method, path, op, true
+		}
+		return "", "", nil, false
+	}
+	op, path, method, ok = doc.OperationByID(key)
+	return method, path, op, ok
+}
+
+// Matches reports if a key of a fixture's values names a parameter: either
+// its name, or its location and name such as "path.id".
+func Matches(key string, p *spec.Parameter) bool {
+	return key == p.Name || key == p.In+"."+p.Name
+}
+
+// Check returns an error if fixtures refer to operations the document doesn't
+// have, or values refer to responses which haven't been received.
+func (f Fixtures) Check(doc *spec.Swagger) error {
+	keys := make([]string, 0, len(f))
+	for k := range f {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if _, _, _, ok := Find(doc, k); !ok {
+			return fmt.Errorf("fixture: %s: no such operation", k)
+		}
+		fx := f[k]
+		names := make(map[string]bool)
+		for i, step := range fx.Create {
+			if _, _, _, ok := Find(doc, step.Operation); !ok {
+				return fmt.Errorf("fixture: %s: create %d: no such operation %q", k, i, step.Operation)
+			}
+			if err := checkValues(step.Values, names); err != nil {
+				return fmt.Errorf("fixture: %s: create %d: %v", k, i, err)
+			}
+			names[step.Name(doc)] = true
+		}
+		if err := checkValues(fx.Values, names); err != nil {
+			return fmt.Errorf("fixture: %s: %v", k, err)
+		}
+	}
+	return nil
+}
+
+func checkValues(values map[string]string, names map[string]bool) error {
+	for _, k := range mapkeys.Sorted(values) {
+		ref, err := ParseRef(values[k])
+		if err != nil {
+			return fmt.Errorf("value %s: %v", k, err)
+		}
+		if ref != nil && !names[ref.Step] {
+			return fmt.Errorf("value %s: no earlier response is named %q", k, ref.Step)
+		}
+	}
+	return nil
+}
+
+// Ref refers to part of the response to an earlier request.
+type Ref struct {
+	// Step is the name of the request.
+	Step string
+	// In is "body", "header" or "status".
+	In string
+	// Name is the name of a header.
+	Name string
+	// Pointer is a JSON pointer into the body, or empty for the whole body.
+	Pointer string
+}
+
+// ParseRef parses a value referring to a response, such as "$pet.body#/id".
+// It returns nil for literal values.
+func ParseRef(s string) (*Ref, error) {
+	if !strings.HasPrefix(s, "$") || strings.HasPrefix(s, "$$") {
+		return nil, nil
+	}
+	i := strings.Index(s, ".")
+	if i < 2 {
+		return nil, fmt.Errorf("invalid reference %q", s)
+	}
+	ref := &Ref{Step: s[1:i]}
+	rest := s[i+1:]
+	switch {
+	case rest == "status":
+		ref.In = "status"
+	case rest == "body" || strings.HasPrefix(rest, "body#/"):
+		ref.In, ref.Pointer = "body", strings.TrimPrefix(strings.TrimPrefix(rest, "body"), "#")
+	case strings.HasPrefix(rest, "header.") && len(rest) > len("header."):
+		ref.In, ref.Name = "header", strings.TrimPrefix(rest, "header.")
+	default:
+		return nil, fmt.Errorf("invalid reference %q: expected body, header or status", s)
+	}
+	return ref, nil
+}
+
+// Response is a response received while setting up a fixture. Body holds the
+// decoded JSON body, or nil if it isn't JSON.
+type Response struct {
+	Status int
+	Header http.Header
+	Body   interface{}
+}
+
+// Value returns the string to send for a fixture's value, resolving references
+// to the responses received so far.
+func Value(s string, responses map[string]*Response) (string, error) {
+	ref, err := ParseRef(s)
+	if err != nil {
+		return "", err
+	}
+	if ref == nil {
+		return strings.TrimPrefix(s, "$"), nil
+	}
+	resp, ok := responses[ref.Step]
+	if !ok {
+		return "", fmt.Errorf("no response named %q", ref.Step)
+	}
+	switch ref.In {
+	case "status":
+		return strconv.Itoa(resp.Status), nil
+	case "header":
+		if v := resp.Header.Get(ref.Name); v != "" {
+			return v, nil
+		}
+		return "", fmt.Errorf("%s: response has no %s header", s, ref.Name)
+	}
+	v := resp.Body
+	for _, tok := range jsonpointer.Split(ref.Pointer) {
+		switch val := v.(type) {
+		case map[string]interface{}:
+			elem, ok := val[tok]
+			if !ok {
+				return "", fmt.Errorf("%s: not found in response", s)
+			}
+			v = elem
+		case []interface{}:
+			i, err := strconv.Atoi(tok)
+			if err != nil || i < 0 || i >= len(val) {
+				return "", fmt.Errorf("%s: not found in response", s)
+			}
+			v = val[i]
+		default:
+			return "", fmt.Errorf("%s: not found in response", s)
+		}
+	}
+	if str, ok := v.(string); ok {
+		return str, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,161 @@
+package fixture
+
+import (
+	"net/http"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+const doc = `
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+paths:
+  /owners:
+    post:
+      operationId: createOwner
+      responses:
+        201: {description: Created.}
+  /owners/{ownerId}/pets:
+    post:
+      parameters:
+      - {name: ownerId, in: path, required: true, type: integer}
+      responses:
+        201: {description: Created.}
+    get:
+      operationId: listOwnerPets
+      parameters:
+      - {name: ownerId, in: path, required: true, type: integer}
+      responses:
+        200: {description: Pets.}
+`
+
+func TestParse(t *testing.T) {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(doc), &s); err != nil {
+		t.Fatal(err)
+	}
+	f, err := Parse([]byte(`
+GET /owners/{ownerId}/pets:
+  create:
+  - operation: createOwner
+  - operation: POST /owners/{ownerId}/pets
+    as: pet
+    values:
+      ownerId: $createOwner.body#/id
+    body: {name: Rex}
+  values:
+    path.ownerId: $createOwner.body#/id
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Fixtures{
+		"GET /owners/{ownerId}/pets": {
+			Create: []Step{
+				{Operation: "createOwner"},
+				{
+					Operation: "POST /owners/{ownerId}/pets",
+					As:        "pet",
+					Values:    map[string]string{"ownerId": "$createOwner.body#/id"},
+					Body:      map[string]interface{}{"name": "Rex"},
+				},
+			},
+			Values: map[string]string{"path.ownerId": "$createOwner.body#/id"},
+		},
+	}
+	if diff := pretty.Compare(want, f); diff != "" {
+		t.Errorf("want != got: %s", diff)
+	}
+	if err := f.Check(&s); err != nil {
+		t.Error(err)
+	}
+	item := s.Paths["/owners/{ownerId}/pets"]
+	if _, ok := f.Lookup("get", "/owners/{ownerId}/pets", item.Get); !ok {
+		t.Errorf("fixture not found by method and path")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(doc), &s); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		f    Fixtures
+		want string
+	}{
+		{
+			f:    Fixtures{"getOwner": {}},
+			want: "fixture: getOwner: no such operation",
+		},
+		{
+			f:    Fixtures{"listOwnerPets": {Create: []Step{{Operation: "DELETE /owners"}}}},
+			want: `fixture: listOwnerPets: create 0: no such operation "DELETE /owners"`,
+		},
+		{
+			f: Fixtures{"listOwnerPets": {
+				Create: []Step{{Operation: "createOwner", As: "owner"}},
+				Values: map[string]string{"ownerId": "$createOwner.body#/id"},
+			}},
+			want: `fixture: listOwnerPets: value ownerId: no earlier response is named "createOwner"`,
+		},
+		{
+			f: Fixtures{"listOwnerPets": {
+				Values: map[string]string{"ownerId": "$owner.id"},
+			}},
+			want: `fixture: listOwnerPets: value ownerId: invalid reference "$owner.id": expected body, header or status`,
+		},
+	}
+	for i, tt := range tests {
+		err := tt.f.Check(&s)
+		if err == nil {
+			t.Errorf("case %d: expected error %q", i, tt.want)
+		} else if err.Error() != tt.want {
+			t.Errorf("case %d: want error %q, got %q", i, tt.want, err)
+		}
+	}
+}
+
+func TestValue(t *testing.T) {
+	responses := map[string]*Response{
+		"pet": {
+			Status: 201,
+			Header: http.Header{"Location": {"/pets/7"}},
+			Body: map[string]interface{}{
+				"id":   float64(7),
+				"tags": []interface{}{"dog"},
+			},
+		},
+	}
+	tests := []struct {
+		value, want string
+	}{
+		{"rex", "rex"},
+		{"$$rex", "$rex"},
+		{"$pet.status", "201"},
+		{"$pet.header.Location", "/pets/7"},
+		{"$pet.body#/id", "7"},
+		{"$pet.body#/tags/0", "dog"},
+		{"$pet.body#/tags", `["dog"]`},
+	}
+	for _, tt := range tests {
+		got, err := Value(tt.value, responses)
+		if err != nil {
+			t.Errorf("%s: %v", tt.value, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: want %q, got %q", tt.value, tt.want, got)
+		}
+	}
+	for _, value := range []string{"$owner.status", "$pet.body#/name", "$pet.header.ETag"} {
+		if _, err := Value(value, responses); err == nil {
+			t.Errorf("%s: expected error", value)
+		}
+	}
+}
@@ -0,0 +1,503 @@
+/*
+Package form describes the inputs of a document's operations for user
+interfaces, so admin forms can be built from the contract rather than by hand.
+
+Forms returns a Form for each operation, listing its parameters as fields in
+the order they're declared. Each field has a label, help text, the widget
+suited to its type, the options of an enum and the constraints its value must
+satisfy. Body parameters with an object schema become a group of fields, one
+per property. The result is intended to be encoded as JSON:
+
+	{
+	  "operation": "POST /pets",
+	  "operationId": "createPet",
+	  "title": "Add a pet",
+	  "fields": [
+	    {
+	      "name": "pet",
+	      "in": "body",
+	      "label": "Pet",
+	      "widget": "group",
+	      "type": "object",
+	      "required": true,
+	      "fields": [
+	        {"name": "name", "label": "The pet's name", "widget": "text", "type": "string", "required": true, "constraints": {"maxLength": 64}},
+	        {"name": "status", "label": "Status", "widget": "select", "type": "string", "options": ["available", "sold"]}
+	      ]
+	    }
+	  ]
+	}
+
+Labels are taken from a schema's title, then from the first sentence of a
+description, then from the name of the field. Properties are ordered by their
+"x-order" extension if they have one, then required properties in the order
+they're listed, then the rest by name. Read only properties are left out.
+*/
+package form
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/ericchiang/swaggopher/internal/httpcheck"
+	"github.com/ericchiang/swaggopher/internal/synth"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// OrderExtension is the vendor extension of a property schema giving its
+// position among its siblings.
+const OrderExtension = "x-order"
+
+// Form describes the inputs of an operation.
+type Form struct {
+	// Operation is the method and path, such as "POST /pets".
+	Operation   string `json:"operation"`
+	OperationID string `json:"operationId,omitempty"`
+	// Title is the operation's summary, or its ID if it has none.
+	Title       string  `json:"title"`
+	Description string  `json:"description,omitempty"`
+	Fields      []Field `json:"fields"`
+}
+
+// Widget is the kind of input suited to a field.
+type Widget string
+
+// Widgets used for fields.
+const (
+	Text     Widget = "text"
+	Number   Widget = "number"
+	Checkbox Widget = "checkbox"
+	Select   Widget = "select"
+	// Multiselect is used for lists of enum values.
+	Multiselect Widget = "multiselect"
+	Date        Widget = "date"
+	DateTime    Widget = "datetime"
+	Password    Widget = "password"
+	File        Widget = "file"
+	// List is used for other arrays. The field's Item describes its elements.
+	List Widget = "list"
+	// Group is used for objects. The field's Fields describes its properties.
+	Group Widget = "group"
+)
+
+// Field describes a parameter, or a property of a body parameter.
+type Field struct {
+	Name string `json:"name"`
+	// In is where a parameter is sent, such as "query". It's empty for
+	// properties.
+	In    string `json:"in,omitempty"`
+	Label string `json:"label"`
+	// Help is the field's description, if it says more than Label.
+	Help     string      `json:"help,omitempty"`
+	Widget   Widget      `json:"widget"`
+	Type     string      `json:"type,omitempty"`
+	Format   string      `json:"format,omitempty"`
+	Required bool        `json:"required,omitempty"`
+	Default  interface{} `json:"default,omitempty"`
+	// Options are the values of an enum.
+	Options     []interface{} `json:"options,omitempty"`
+	Constraints *Constraints  `json:"constraints,omitempty"`
+	// Item describes the elements of a list.
+	Item *Field `json:"item,omitempty"`
+	// Fields describes the properties of a group.
+	Fields []Field `json:"fields,omitempty"`
+}
+
+// Constraints are the limits a field's value must satisfy.
+type Constraints struct {
+	Minimum          *float64 `json:"minimum,omitempty"`
+	ExclusiveMinimum bool     `json:"exclusiveMinimum,omitempty"`
+	Maximum          *float64 `json:"maximum,omitempty"`
+	ExclusiveMaximum bool     `json:"exclusiveMaximum,omitempty"`
+	MultipleOf       float64  `json:"multipleOf,omitempty"`
+	MinLength        int      `json:"minLength,omitempty"`
+	MaxLength        *int     `json:"maxLength,omitempty"`
+	Pattern          string   `json:"pattern,omitempty"`
+	MinItems         int      `json:"minItems,omitempty"`
+	MaxItems         *int     `json:"maxItems,omitempty"`
+	UniqueItems      bool     `json:"uniqueItems,omitempty"`
+}
+
+var methods = []string{"GET", "PUT", "POST", "DELETE", "OPTIONS", "HEAD", "PATCH"}
+
+// Forms returns a form for each operation of a document, sorted by path and
+// then method.
+func Forms(doc *spec.Swagger) []Form {
+	paths := make([]string, 0, len(doc.Paths))
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var forms []Form
+	for _, path := range paths {
+		item := doc.Paths[path]
+		for _, method := range methods {
+			if op := httpcheck.Operation(&item, method); op != nil {
+				forms = append(forms, operationForm(doc, method+" "+path, &item, op))
+			}
+		}
+	}
+	return forms
+}
+
+func operationForm(doc *spec.Swagger, name string, item *spec.PathItem, op *spec.Operation) Form {
+	f := Form{
+		Operation:   name,
+		OperationID: op.OperationId,
+		Title:       op.Summary,
+		Description: op.Description,
+		Fields:      []Field{},
+	}
+	if f.Title == "" {
+		f.Title = op.OperationId
+	}
+	if f.Title == "" {
+		f.Title = name
+	}
+
+	// Path parameters come first, unless the operation overrides them.
+	var params []*spec.Parameter
+	index := make(map[string]int)
+	for _, list := range [][]spec.Parameter{item.Parameters, op.Parameters} {
+		for i := range list {
+			p, ok := doc.ResolveParameter(&list[i])
+			if !ok {
+				continue
+			}
+			key := p.In + "/" + p.Name
+			if j, ok := index[key]; ok {
+				params[j] = p
+				continue
+			}
+			index[key] = len(params)
+			params = append(params, p)
+		}
+	}
+	for _, p := range params {
+		f.Fields = append(f.Fields, parameterField(doc, p))
+	}
+	return f
+}
+
+func parameterField(doc *spec.Swagger, p *spec.Parameter) Field {
+	var f Field
+	if p.In == "body" {
+		f = schemaField(doc, p.Name, p.Schema, make(map[string]bool), 0)
+	} else {
+		f = itemsField(p.Name, &spec.Items{
+			Type:             p.Type,
+			Format:           p.Format,
+			Items:            p.Items,
+			Default:          p.Default,
+			Maximum:          p.Maximum,
+			ExclusiveMaximum: p.ExclusiveMaximum,
+			Minimum:          p.Minimum,
+			ExclusiveMinimum: p.ExclusiveMinimum,
+			MaxLength:        p.MaxLength,
+			MinLength:        p.MinLength,
+			Pattern:          p.Pattern,
+			MaxItems:         p.MaxItems,
+			MinItems:         p.MinItems,
+			UniqueItems:      p.UniqueItems,
+			Enum:             p.Enum,
+			MultipleOf:       p.MultipleOf,
+		})
+		if p.Type == "file" {
+			f.Widget = File
+		}
+	}
+	f.In = p.In
+	f.Required = p.Required
+	f.Label, f.Help = label(p.Name, "", p.Description)
+	return f
+}
+
+func itemsField(name string, t *spec.Items) Field {
+	f := Field{
+		Name:    name,
+		Type:    t.Type,
+		Format:  t.Format,
+		Default: t.Default,
+		Options: t.Enum,
+		Constraints: constraints(Constraints{
+			Minimum:          t.Minimum,
+			ExclusiveMinimum: t.ExclusiveMinimum,
+			Maximum:          t.Maximum,
+			ExclusiveMaximum: t.ExclusiveMaximum,
+			MultipleOf:       t.MultipleOf,
+			MinLength:        t.MinLength,
+			MaxLength:        t.MaxLength,
+			Pattern:          t.Pattern,
+			MinItems:         t.MinItems,
+			MaxItems:         t.MaxItems,
+			UniqueItems:      t.UniqueItems,
+		}),
+	}
+	f.Label, _ = label(name, "", "")
+	if t.Type == "array" && t.Items != nil {
+		item := itemsField(name, t.Items)
+		f.Item = &item
+	}
+	f.Widget = widget(&f)
+	return f
+}
+
+func schemaField(doc *spec.Swagger, name string, s *spec.Schema, seen map[string]bool, depth int) Field {
+	f := Field{Name: name}
+	if s == nil {
+		f.Label, _ = label(name, "", "")
+		f.Widget = Text
+		return f
+	}
+	title, description := s.Title, s.Description
+	if s.Ref != "" {
+		if seen[s.Ref] {
+			// A recursive schema is left as an empty group.
+			f.Label, f.Help = label(name, title, description)
+			f.Widget, f.Type = Group, "object"
+			return f
+		}
+		seen = with(seen, s.Ref)
+	}
+	if r := synth.Resolve(doc, s); r != nil {
+		s = r
+	}
+	if title == "" {
+		title = s.Title
+	}
+	if description == "" {
+		description = s.Description
+	}
+	f.Label, f.Help = label(name, title, description)
+	f.Type = s.Type
+	f.Format = s.Format
+	f.Default = s.Default
+	f.Options = s.Enum
+	f.Constraints = constraints(Constraints{
+		Minimum:          s.Minimum,
+		ExclusiveMinimum: s.ExclusiveMinimum,
+		Maximum:          s.Maximum,
+		ExclusiveMaximum: s.ExclusiveMaximum,
+		MultipleOf:       s.MultipleOf,
+		MinLength:        s.MinLength,
+		MaxLength:        s.MaxLength,
+		Pattern:          s.Pattern,
+		MinItems:         s.MinItems,
+		MaxItems:         s.MaxItems,
+		UniqueItems:      s.UniqueItems,
+	})
+
+	props, required := properties(doc, s, make(map[string]bool))
+	if f.Type == "" && len(props) > 0 {
+		f.Type = "object"
+	}
+	switch {
+	case depth >= synth.MaxDepth:
+	case f.Type == "array" && s.Items != nil:
+		item := schemaField(doc, name, s.Items, seen, depth+1)
+		f.Item = &item
+	case f.Type == "object":
+		for _, p := range order(props, required) {
+			prop := props[p]
+			if prop.ReadOnly {
+				continue
+			}
+			field := schemaField(doc, p, &prop, seen, depth+1)
+			field.Required = contains(required, p)
+			f.Fields = append(f.Fields, field)
+		}
+	}
+	f.Widget = widget(&f)
+	return f
+}
+
+// properties returns the properties of an object schema, including those of
+// the schemas it's composed of, and the names of those which are required.
+func properties(doc *spec.Swagger, s *spec.Schema, seen map[string]bool) (map[string]spec.Schema, []string) {
+	props := make(map[string]spec.Schema)
+	var required []string
+	for i := range s.AllOf {
+		sub := &s.AllOf[i]
+		if sub.Ref != "" {
+			if seen[sub.Ref] {
+				continue
+			}
+			seen[sub.Ref] = true
+		}
+		if sub = synth.Resolve(doc, sub); sub == nil {
+			continue
+		}
+		p, r := properties(doc, sub, seen)
+		for name, prop := range p {
+			props[name] = prop
+		}
+		required = append(required, r...)
+	}
+	for name, prop := range s.Properties {
+		props[name] = prop
+	}
+	return props, append(required, s.Required...)
+}
+
+// order returns the names of properties in the order they should be shown.
+func order(props map[string]spec.Schema, required []string) []string {
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	rank := func(name string) (float64, bool) {
+		n, ok := props[name].Extensions[OrderExtension].(float64)
+		return n, ok
+	}
+	position := func(name string) int {
+		for i, r := range required {
+			if r == name {
+				return i
+			}
+		}
+		return len(required)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		ri, oki := rank(names[i])
+		rj, okj := rank(names[j])
+		if oki != okj {
+			return oki
+		}
+		if oki && ri != rj {
+			return ri < rj
+		}
+		if pi, pj := position(names[i]), position(names[j]); pi != pj {
+			return pi < pj
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+func widget(f *Field) Widget {
+	switch {
+	case f.Type == "array" && f.Item != nil && len(f.Item.Options) > 0:
+		return Multiselect
+	case len(f.Options) > 0:
+		return Select
+	}
+	switch f.Type {
+	case "boolean":
+		return Checkbox
+	case "integer", "number":
+		return Number
+	case "array":
+		return List
+	case "object":
+		return Group
+	case "file":
+		return File
+	}
+	switch f.Format {
+	case "date":
+		return Date
+	case "date-time":
+		return DateTime
+	case "password":
+		return Password
+	case "binary":
+		return File
+	}
+	return Text
+}
+
+// constraints returns c, or nil if it's empty.
+func constraints(c Constraints) *Constraints {
+	if c == (Constraints{}) {
+		return nil
+	}
+	return &c
+}
+
+// label returns the label and help text of a field.
+func label(name, title, description string) (string, string) {
+	description = strings.TrimSpace(description)
+	if title != "" {
+		return title, description
+	}
+	if description == "" {
+		return humanize(name), ""
+	}
+	sentence := description
+	if i := strings.Index(sentence, "\n"); i >= 0 {
+		sentence = sentence[:i]
+	}
+	if i := strings.Index(sentence, ". "); i >= 0 {
+		sentence = sentence[:i]
+	}
+	sentence = strings.TrimSuffix(strings.TrimSpace(sentence), ".")
+	if sentence == strings.TrimSuffix(description, ".") {
+		return sentence, ""
+	}
+	return sentence, description
+}
+
+var initialisms = map[string]string{
+	"api":  "API",
+	"http": "HTTP",
+	"id":   "ID",
+	"ids":  "IDs",
+	"ip":   "IP",
+	"uri":  "URI",
+	"url":  "URL",
+	"uuid": "UUID",
+}
+
+// humanize turns an identifier such as "petId" or "created_at" into words,
+// such as "Pet ID" and "Created at".
+func humanize(name string) string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, strings.ToLower(string(word)))
+			word = nil
+		}
+	}
+	runes := []rune(name)
+	for i, r := range runes {
+		switch {
+		case r == '_' || r == '-' || r == ' ' || r == '.':
+			flush()
+			continue
+		case unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))):
+			flush()
+		}
+		word = append(word, r)
+	}
+	flush()
+	for i, w := range words {
+		switch {
+		case initialisms[w] != "":
+			words[i] = initialisms[w]
+		case i == 0:
+			words[i] = strings.ToUpper(w[:1]) + w[1:]
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+func with(seen map[string]bool, ref string) map[string]bool {
+	next := make(map[string]bool, len(seen)+1)
+	for k := range seen {
+		next[k] = true
+	}
+	next[ref] = true
+	return next
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package form
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+func TestForms(t *testing.T) {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(`
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+parameters:
+  limit: {name: limit, in: query, type: integer, minimum: 1, maximum: 100, default: 20, description: How many pets to return.}
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      parameters:
+      - $ref: '#/parameters/limit'
+      - {name: tags, in: query, type: array, items: {type: string, enum: [cat, dog]}}
+      responses:
+        200: {description: Pets.}
+    post:
+      summary: Add a pet
+      parameters:
+      - {name: pet, in: body, required: true, schema: {$ref: '#/definitions/Pet'}}
+      responses:
+        201: {description: Created.}
+  /pets/{petId}:
+    parameters:
+    - {name: petId, in: path, required: true, type: integer}
+    - {name: X-Trace, in: header, type: string}
+    put:
+      parameters:
+      - {name: petId, in: path, required: true, type: string, format: uuid, description: "The pet's ID. Assigned when it's created."}
+      - {name: photo, in: formData, type: file}
+      responses:
+        200: {description: Updated.}
+definitions:
+  Named:
+    type: object
+    required: [name]
+    properties:
+      name: {type: string, maxLength: 64, description: The pet's name.}
+  Pet:
+    allOf:
+    - $ref: '#/definitions/Named'
+    - type: object
+      properties:
+        id: {type: integer, readOnly: true}
+        born: {type: string, format: date, x-order: 1}
+        vaccinated: {type: boolean}
+        status: {type: string, enum: [available, sold]}
+        parent: {$ref: '#/definitions/Pet'}
+`), &s); err != nil {
+		t.Fatal(err)
+	}
+	var want []Form
+	if err := json.Unmarshal([]byte(`[
+  {
+    "operation": "GET /pets",
+    "operationId": "listPets",
+    "title": "listPets",
+    "fields": [
+      {"name": "limit", "in": "query", "label": "How many pets to return", "widget": "number", "type": "integer", "default": 20, "constraints": {"minimum": 1, "maximum": 100}},
+      {"name": "tags", "in": "query", "label": "Tags", "widget": "multiselect", "type": "array",
+       "item": {"name": "tags", "label": "Tags", "widget": "select", "type": "string", "options": ["cat", "dog"]}}
+    ]
+  },
+  {
+    "operation": "POST /pets",
+    "title": "Add a pet",
+    "fields": [
+      {
+        "name": "pet", "in": "body", "label": "Pet", "widget": "group", "type": "object", "required": true,
+        "fields": [
+          {"name": "born", "label": "Born", "widget": "date", "type": "string", "format": "date"},
+          {"name": "name", "label": "The pet's name", "widget": "text", "type": "string", "required": true, "constraints": {"maxLength": 64}},
+          {"name": "parent", "label": "Parent", "widget": "group", "type": "object"},
+          {"name": "status", "label": "Status", "widget": "select", "type": "string", "options": ["available", "sold"]},
+          {"name": "vaccinated", "label": "Vaccinated", "widget": "checkbox", "type": "boolean"}
+        ]
+      }
+    ]
+  },
+  {
+    "operation": "PUT /pets/{petId}",
+    "title": "PUT /pets/{petId}",
+    "fields": [
+      {"name": "petId", "in": "path", "label": "The pet's ID", "help": "The pet's ID. Assigned when it's created.", "widget": "text", "type": "string", "format": "uuid", "required": true},
+      {"name": "X-Trace", "in": "header", "label": "X trace", "widget": "text", "type": "string"},
+      {"name": "photo", "in": "formData", "label": "Photo", "widget": "file", "type": "file"}
+    ]
+  }
+]`), &want); err != nil {
+		t.Fatal(err)
+	}
+	if diff := pretty.Compare(want, Forms(&s)); diff != "" {
+		t.Errorf("forms: %s", diff)
+	}
+}
+
+func TestHumanize(t *testing.T) {
+	for name, want := range map[string]string{
+		"petId":       "Pet ID",
+		"created_at":  "Created at",
+		"HTTPServer":  "HTTP server",
+		"owner-ids":   "Owner IDs",
+		"X-Trace":     "X trace",
+		"photoURL":    "Photo URL",
+		"name":        "Name",
+		"PetCategory": "Pet category",
+	} {
+		if got := humanize(name); got != want {
+			t.Errorf("humanize(%q): want %q, got %q", name, want, got)
+		}
+	}
+}
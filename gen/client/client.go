@@ -0,0 +1,634 @@
+/*
+Package client generates a typed Go client for the operations of a document.
+
+The generated package has a Client with a method per operation. Each method
+takes a struct holding the operation's parameters, where required parameters
+are values and optional ones are pointers or slices, and returns a struct with a
+field per documented response which is decoded according to the status code:
+
+	c := petstore.NewClient("https://pets.example.com/v1", nil)
+	resp, err := c.GetPet(ctx, &petstore.GetPetParams{PetID: 7})
+	if err != nil {
+		// The request failed, or the status isn't documented.
+	}
+	if resp.StatusCode == http.StatusOK {
+		fmt.Println(resp.OK.Name)
+	}
+
+Responses which declare links with the "x-links" extension, described by the
+links package, get a method per link following it from a response to the
+operation it calls:
+
+	resp, err := c.CreatePet(ctx, &petstore.CreatePetParams{Pet: pet})
+	...
+	// Calls GetPet with the ID of the created pet.
+	pet, err := c.CreatePetSelf(ctx, resp)
+
+Definitions become Go types in models.go.
+*/
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/coerce"
+	"github.com/ericchiang/swaggopher/gen"
+	"github.com/ericchiang/swaggopher/gen/internal/golang"
+	"github.com/ericchiang/swaggopher/internal/jsonpointer"
+	"github.com/ericchiang/swaggopher/internal/mapkeys"
+	"github.com/ericchiang/swaggopher/links"
+	"github.com/ericchiang/swaggopher/spec"
+	"github.com/ericchiang/swaggopher/spec3"
+)
+
+// Options configures Generate.
+type Options struct {
+	// Package is the name of the generated package. It defaults to "client".
+	Package string
+	// Coerce controls how strictly links parse the values they take from
+	// response headers.
+	Coerce coerce.Options
+}
+
+// Generate returns the files of a client package for a document: client.go,
+// holding the client and its operations, and models.go, holding the
+// definitions. An error is returned if two operations or links would have the
+// same method name, or if a link can't be followed.
+func Generate(doc *spec.Swagger, opts Options) ([]gen.File, error) {
+	pkg := opts.Package
+	if pkg == "" {
+		pkg = "client"
+	}
+	g := &generator{doc: doc, pkg: pkg, types: golang.NewTypes(doc)}
+	ops, err := g.types.Operations()
+	if err != nil {
+		return nil, fmt.Errorf("client: %v", err)
+	}
+
+	var body bytes.Buffer
+	g.client(&body)
+	names := make(map[string]string)
+	for _, op := range ops {
+		g.operation(&body, op)
+		names[op.Name] = op.Method + " " + op.Path
+	}
+	for _, op := range ops {
+		if err := g.links(&body, op, ops, names); err != nil {
+			return nil, err
+		}
+	}
+	if g.hasLinks {
+		body.WriteString(linkValue)
+		body.WriteString(golang.ParseValue(opts.Coerce))
+	}
+	title := "the API"
+	if doc.Info != nil && doc.Info.Title != "" {
+		title = "the " + doc.Info.Title + " API"
+	}
+	header := fmt.Sprintf("// Package %s is a client for %s.\n", pkg, title)
+	client, err := g.file("client.go", header, &body)
+	if err != nil {
+		return nil, err
+	}
+
+	var defs bytes.Buffer
+	g.types.Definitions(&defs)
+	models, err := g.file("models.go", "", &defs)
+	if err != nil {
+		return nil, err
+	}
+	return []gen.File{client, models}, nil
+}
+
+type generator struct {
+	doc   *spec.Swagger
+	pkg   string
+	types *golang.Types
+	// hasLinks is set once a link method is written.
+	hasLinks bool
+}
+
+// candidates are the packages generated files may import.
+var candidates = []string{
+	"bytes", "context", "encoding/base64", "encoding/json", "fmt", "io",
+	"io/ioutil", "mime/multipart", "net/http", "net/url", "reflect", "strconv",
+	"strings", "time",
+}
+
+// file assembles a generated file, importing the packages its body uses.
+func (g *generator) file(name, header string, body *bytes.Buffer) (gen.File, error) {
+	src, err := golang.File(name, header, g.pkg, body.Bytes(), candidates)
+	if err != nil {
+		return gen.File{}, fmt.Errorf("client: %v", err)
+	}
+	return gen.File{Name: name, Data: src}, nil
+}
+
+// client writes the Client type and the helpers its methods share.
+func (g *generator) client(b *bytes.Buffer) {
+	base := ""
+	if g.doc.Host != "" {
+		scheme := "http"
+		for _, s := range g.doc.Schemes {
+			if s == "https" {
+				scheme = s
+			}
+		}
+		if scheme == "http" && len(g.doc.Schemes) > 0 {
+			scheme = g.doc.Schemes[0]
+		}
+		base = scheme + "://" + g.doc.Host
+	}
+	base += strings.TrimSuffix(g.doc.BasePath, "/")
+
+	fmt.Fprintf(b, `// DefaultBaseURL is the URL the document says the API is served from.
+const DefaultBaseURL = %q
+
+// Client calls the operations of the API.
+type Client struct {
+	// BaseURL is the URL which operation paths are relative to. If empty,
+	// DefaultBaseURL is used.
+	BaseURL string
+	// HTTPClient makes requests. If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+}
+
+// NewClient returns a client for the API served at baseURL, making requests
+// with httpClient.
+func NewClient(baseURL string, httpClient *http.Client) *Client {
+	return &Client{BaseURL: baseURL, HTTPClient: httpClient}
+}
+
+// StatusError is returned when the server responds with a status which the
+// operation doesn't document.
+type StatusError struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status %%d: %%s", e.StatusCode, bytes.TrimSpace(e.Body))
+}
+
+func newStatusError(resp *http.Response) error {
+	body, _ := ioutil.ReadAll(resp.Body)
+	return &StatusError{StatusCode: resp.StatusCode, Header: resp.Header, Body: body}
+}
+
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, header http.Header, body io.Reader) (*http.Response, error) {
+	base := c.BaseURL
+	if base == "" {
+		base = DefaultBaseURL
+	}
+	if !strings.Contains(base, "://") {
+		return nil, fmt.Errorf("base URL %%q must be absolute", base)
+	}
+	u := strings.TrimSuffix(base, "/") + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return client.Do(req.WithContext(ctx))
+}
+
+// formatParam formats the value of a parameter as a string.
+func formatParam(v interface{}) string {
+	switch v := v.(type) {
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case []byte:
+		return base64.StdEncoding.EncodeToString(v)
+	}
+	return fmt.Sprint(v)
+}
+
+func decodeJSON(resp *http.Response, v interface{}) error {
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil && err != io.EOF {
+		return fmt.Errorf("decoding %%d response: %%v", resp.StatusCode, err)
+	}
+	return nil
+}
+
+`, base)
+}
+
+// operation writes the parameter and response types of an operation, and the
+// client method which calls it.
+func (g *generator) operation(b *bytes.Buffer, op golang.Operation) {
+	summary := op.Summary
+	if summary == "" {
+		summary = op.Description
+	}
+
+	if len(op.Params) > 0 {
+		fmt.Fprintf(b, "// %sParams holds the parameters of %s.\ntype %sParams struct {\n", op.Name, op.Name, op.Name)
+		for _, p := range op.Params {
+			if p.Description != "" {
+				golang.Comment(b, p.Description)
+			}
+			fmt.Fprintf(b, "%s %s\n", p.Field, p.GoType)
+		}
+		b.WriteString("}\n\n")
+	}
+
+	fmt.Fprintf(b, "// %sResponse holds the response to %s. The field of the documented\n// response with the returned status is set.\ntype %sResponse struct {\n", op.Name, op.Name, op.Name)
+	b.WriteString("StatusCode int\nHeader http.Header\n")
+	for _, r := range op.Responses {
+		if r.GoType == "" {
+			continue
+		}
+		if r.Code == "default" {
+			fmt.Fprintf(b, "// %s is set for statuses without their own field.\n", r.Field)
+		} else {
+			fmt.Fprintf(b, "// %s is set for status %s.\n", r.Field, r.Code)
+		}
+		fmt.Fprintf(b, "%s %s\n", r.Field, r.GoType)
+	}
+	b.WriteString("}\n\n")
+
+	if summary != "" {
+		golang.Comment(b, op.Name+" calls "+op.Method+" "+op.Path+": "+summary)
+	} else {
+		fmt.Fprintf(b, "// %s calls %s %s.\n", op.Name, op.Method, op.Path)
+	}
+	if op.Deprecated {
+		b.WriteString("//\n// Deprecated: the operation is deprecated by the API.\n")
+	}
+	if len(op.Params) > 0 {
+		fmt.Fprintf(b, "func (c *Client) %s(ctx context.Context, params *%sParams) (*%sResponse, error) {\n", op.Name, op.Name, op.Name)
+		fmt.Fprintf(b, "if params == nil {\nparams = &%sParams{}\n}\n", op.Name)
+	} else {
+		fmt.Fprintf(b, "func (c *Client) %s(ctx context.Context) (*%sResponse, error) {\n", op.Name, op.Name)
+	}
+
+	// Build the path from the template.
+	byName := make(map[string]golang.Param)
+	for _, p := range op.Params {
+		if p.In == "path" {
+			byName[p.Name] = p
+		}
+	}
+	var parts []string
+	last := 0
+	for _, m := range golang.PathVariable.FindAllStringSubmatchIndex(op.Path, -1) {
+		if lit := op.Path[last:m[0]]; lit != "" {
+			parts = append(parts, strconv.Quote(lit))
+		}
+		if p, ok := byName[op.Path[m[2]:m[3]]]; ok {
+			parts = append(parts, fmt.Sprintf("url.PathEscape(%s)", g.format(p)))
+		} else {
+			parts = append(parts, strconv.Quote(op.Path[m[0]:m[1]]))
+		}
+		last = m[1]
+	}
+	if lit := op.Path[last:]; lit != "" || len(parts) == 0 {
+		parts = append(parts, strconv.Quote(lit))
+	}
+	fmt.Fprintf(b, "path := %s\n", strings.Join(parts, " + "))
+
+	b.WriteString("query := url.Values{}\nheader := http.Header{}\nvar body io.Reader\n")
+	for _, p := range op.Params {
+		switch p.In {
+		case "query":
+			g.setValue(b, p, "query.Set", "query.Add")
+		case "header":
+			g.setValue(b, p, "header.Set", "header.Add")
+		}
+	}
+	g.body(b, op)
+
+	fmt.Fprintf(b, "resp, err := c.do(ctx, %q, path, query, header, body)\nif err != nil {\nreturn nil, err\n}\ndefer resp.Body.Close()\n", op.Method)
+	fmt.Fprintf(b, "out := &%sResponse{StatusCode: resp.StatusCode, Header: resp.Header}\nswitch resp.StatusCode {\n", op.Name)
+	hasDefault := false
+	for _, r := range op.Responses {
+		if r.Code == "default" {
+			hasDefault = true
+			b.WriteString("default:\n")
+		} else {
+			fmt.Fprintf(b, "case %s:\n", r.Code)
+		}
+		if r.GoType != "" {
+			fmt.Fprintf(b, "if err := decodeJSON(resp, &out.%s); err != nil {\nreturn nil, err\n}\n", r.Field)
+		}
+	}
+	if !hasDefault {
+		b.WriteString("default:\nreturn nil, newStatusError(resp)\n")
+	}
+	b.WriteString("}\nreturn out, nil\n}\n\n")
+}
+
+// format returns an expression formatting a non-array parameter as a string.
+func (g *generator) format(p golang.Param) string {
+	v := "params." + p.Field
+	if strings.HasPrefix(p.GoType, "*") {
+		v = "*" + v
+	}
+	return "formatParam(" + v + ")"
+}
+
+// setValue writes the code adding a query or header parameter, if it's set.
+func (g *generator) setValue(b *bytes.Buffer, p golang.Param, set, add string) {
+	v := "params." + p.Field
+	if p.Type == "array" {
+		fmt.Fprintf(b, "if len(%s) > 0 {\n", v)
+		if p.CollectionFormat == "multi" {
+			fmt.Fprintf(b, "for _, v := range %s {\n%s(%q, formatParam(v))\n}\n}\n", v, add, p.Name)
+			return
+		}
+		fmt.Fprintf(b, "values := make([]string, len(%s))\nfor i, v := range %s {\nvalues[i] = formatParam(v)\n}\n", v, v)
+		fmt.Fprintf(b, "%s(%q, strings.Join(values, %q))\n}\n", set, p.Name, golang.CollectionSeparator(p.CollectionFormat))
+		return
+	}
+	if strings.HasPrefix(p.GoType, "*") {
+		fmt.Fprintf(b, "if %s != nil {\n%s(%q, %s)\n}\n", v, set, p.Name, g.format(p))
+		return
+	}
+	fmt.Fprintf(b, "%s(%q, %s)\n", set, p.Name, g.format(p))
+}
+
+// body writes the code encoding an operation's body or form parameters.
+func (g *generator) body(b *bytes.Buffer, op golang.Operation) {
+	var form []golang.Param
+	for _, p := range op.Params {
+		switch p.In {
+		case "body":
+			v := "params." + p.Field
+			fmt.Fprintf(b, "if %s != nil {\ndata, err := json.Marshal(%s)\nif err != nil {\nreturn nil, err\n}\n", v, v)
+			b.WriteString("body = bytes.NewReader(data)\nheader.Set(\"Content-Type\", \"application/json\")\n}\n")
+		case "formData":
+			form = append(form, p)
+		}
+	}
+	if len(form) == 0 {
+		return
+	}
+	if op.HasFile() {
+		b.WriteString("var buf bytes.Buffer\nmw := multipart.NewWriter(&buf)\n")
+		for _, p := range form {
+			v := "params." + p.Field
+			if p.Type == "file" {
+				fmt.Fprintf(b, "if %s != nil {\nfw, err := mw.CreateFormFile(%q, %q)\nif err != nil {\nreturn nil, err\n}\n", v, p.Name, p.Name)
+				fmt.Fprintf(b, "if _, err := io.Copy(fw, %s); err != nil {\nreturn nil, err\n}\n}\n", v)
+				continue
+			}
+			g.setValue(b, p, "mw.WriteField", "mw.WriteField")
+		}
+		b.WriteString("if err := mw.Close(); err != nil {\nreturn nil, err\n}\n")
+		b.WriteString("body = &buf\nheader.Set(\"Content-Type\", mw.FormDataContentType())\n")
+		return
+	}
+	b.WriteString("form := url.Values{}\n")
+	for _, p := range form {
+		g.setValue(b, p, "form.Set", "form.Add")
+	}
+	b.WriteString("body = strings.NewReader(form.Encode())\nheader.Set(\"Content-Type\", \"application/x-www-form-urlencoded\")\n")
+}
+
+// links writes a method for each link declared by an operation's responses,
+// which calls the linked operation with the values the link takes from a
+// response and, optionally, the request which produced it.
+func (g *generator) links(b *bytes.Buffer, op golang.Operation, ops []golang.Operation, names map[string]string) error {
+	responses := make(map[string]golang.Response)
+	for _, r := range op.Responses {
+		responses[r.Code] = r
+	}
+	for _, code := range mapkeys.Sorted(op.Operation.Responses) {
+		r := op.Operation.Responses[code]
+		if r.Ref != "" {
+			r = g.doc.Responses[jsonpointer.Unescape(strings.TrimPrefix(r.Ref, "#/responses/"))]
+		}
+		list, err := links.Parse(&r)
+		if err != nil {
+			return fmt.Errorf("client: response %s of %s: %v", code, op.Name, err)
+		}
+		resp, ok := responses[code]
+		if !ok {
+			continue
+		}
+		for _, name := range mapkeys.Sorted(list) {
+			l := list[name]
+			method := op.Name + golang.Name(name)
+			id := fmt.Sprintf("link %s of %s's %s response", name, op.Name, code)
+			if other, ok := names[method]; ok {
+				return fmt.Errorf("client: %s and %s would both be called %s", other, id, method)
+			}
+			names[method] = id
+			if err := g.link(b, op, ops, resp, name, method, &l); err != nil {
+				return fmt.Errorf("client: %s: %v", id, err)
+			}
+			g.hasLinks = true
+		}
+	}
+	return nil
+}
+
+// link writes the method following a single link.
+func (g *generator) link(b *bytes.Buffer, op golang.Operation, ops []golang.Operation, resp golang.Response, name, method string, l *spec3.Link) error {
+	code := resp.Code
+	path, m, _, err := links.Target(g.doc, l)
+	if err != nil {
+		return err
+	}
+	var target golang.Operation
+	for _, o := range ops {
+		if o.Path == path && o.Method == strings.ToUpper(m) {
+			target = o
+		}
+	}
+
+	// Each value is an expression in Go and a pointer into its JSON form.
+	usesRequest := false
+	value := func(v interface{}) (string, string, error) {
+		if !links.IsExpression(v) {
+			data, err := json.Marshal(v)
+			if err != nil {
+				return "", "", err
+			}
+			return fmt.Sprintf("json.RawMessage(%q)", data), "", nil
+		}
+		e, err := links.ParseExpression(v.(string))
+		if err != nil {
+			return "", "", err
+		}
+		switch {
+		case e.Source == "statusCode":
+			return "from.StatusCode", "", nil
+		case e.Source == "method":
+			return strconv.Quote(op.Method), "", nil
+		case e.Source == "response" && e.In == "header":
+			return fmt.Sprintf("from.Header.Get(%q)", e.Name), "", nil
+		case e.Source == "response" && e.In == "body":
+			if resp.GoType == "" {
+				return "", "", fmt.Errorf("%s: the response has no body", e)
+			}
+			return "from." + resp.Field, e.Pointer, nil
+		case e.Source == "request":
+			for _, p := range op.Params {
+				if p.In == e.In && (e.In == "body" || p.Name == e.Name) {
+					usesRequest = true
+					return "req." + p.Field, e.Pointer, nil
+				}
+			}
+			return "", "", fmt.Errorf("%s: %s has no such parameter", e, op.Name)
+		}
+		return "", "", fmt.Errorf("%s isn't supported", e)
+	}
+
+	var body bytes.Buffer
+	for _, key := range mapkeys.Sorted(l.Parameters) {
+		p, ok := param(target, key)
+		if !ok {
+			return fmt.Errorf("parameter %q isn't a parameter of %s", key, target.Name)
+		}
+		v, pointer, err := value(l.Parameters[key])
+		if err != nil {
+			return fmt.Errorf("parameter %q: %v", key, err)
+		}
+		fmt.Fprintf(&body, "if err := linkValue(%s, %q, &params.%s); err != nil {\n", v, pointer, p.Field)
+		fmt.Fprintf(&body, "return nil, fmt.Errorf(\"link %%s: parameter %%s: %%v\", %q, %q, err)\n}\n", name, key)
+	}
+	if l.RequestBody != nil {
+		p, ok := param(target, "body")
+		if !ok {
+			return fmt.Errorf("%s has no body parameter", target.Name)
+		}
+		v, pointer, err := value(l.RequestBody)
+		if err != nil {
+			return fmt.Errorf("requestBody: %v", err)
+		}
+		fmt.Fprintf(&body, "if err := linkValue(%s, %q, &params.%s); err != nil {\n", v, pointer, p.Field)
+		fmt.Fprintf(&body, "return nil, fmt.Errorf(\"link %%s: request body: %%v\", %q, err)\n}\n", name)
+	}
+
+	fmt.Fprintf(b, "// %s follows the %s link of a %s response to %s, calling %s.\n", method, name, code, op.Name, target.Name)
+	if l.Description != "" {
+		b.WriteString("//\n")
+		golang.Comment(b, l.Description)
+	}
+	if usesRequest {
+		b.WriteString("// The link takes values from the parameters of the request, req.\n")
+		fmt.Fprintf(b, "func (c *Client) %s(ctx context.Context, from *%sResponse, req *%sParams) (*%sResponse, error) {\n", method, op.Name, op.Name, target.Name)
+		fmt.Fprintf(b, "if req == nil {\nreq = &%sParams{}\n}\n", op.Name)
+	} else {
+		fmt.Fprintf(b, "func (c *Client) %s(ctx context.Context, from *%sResponse) (*%sResponse, error) {\n", method, op.Name, target.Name)
+	}
+	if code != "default" {
+		fmt.Fprintf(b, "if from.StatusCode != %s {\n", code)
+		fmt.Fprintf(b, "return nil, fmt.Errorf(\"link %%s applies to status %%d, not %%d\", %q, %s, from.StatusCode)\n}\n", name, code)
+	}
+	if len(target.Params) == 0 {
+		fmt.Fprintf(b, "return c.%s(ctx)\n}\n\n", target.Name)
+		return nil
+	}
+	fmt.Fprintf(b, "params := &%sParams{}\n", target.Name)
+	b.Write(body.Bytes())
+	fmt.Fprintf(b, "return c.%s(ctx, params)\n}\n\n", target.Name)
+	return nil
+}
+
+// param returns the parameter of an operation a link's parameter sets, named
+// as in links.Parameter, or its body parameter for "body".
+func param(op golang.Operation, key string) (golang.Param, bool) {
+	in, name := "", key
+	if key == "body" {
+		in = "body"
+	} else if i := strings.Index(key, "."); i >= 0 {
+		switch key[:i] {
+		case "path", "query", "header", "formData":
+			in, name = key[:i], key[i+1:]
+		}
+	}
+	for _, p := range op.Params {
+		if in == "body" && p.In == "body" {
+			return p, true
+		}
+		if p.In != "body" && p.Name == name && (in == "" || p.In == in) {
+			return p, true
+		}
+	}
+	return golang.Param{}, false
+}
+
+// linkValue is written when the document declares links.
+const linkValue = `// linkValue sets dst, a pointer to a parameter, to the value a JSON pointer
+// refers to within v.
+func linkValue(v interface{}, pointer string, dst interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if pointer != "" {
+		var doc interface{}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return err
+		}
+		for _, tok := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+			tok = strings.NewReplacer("~1", "/", "~0", "~").Replace(tok)
+			switch d := doc.(type) {
+			case map[string]interface{}:
+				val, ok := d[tok]
+				if !ok {
+					return fmt.Errorf("%s not found in response", pointer)
+				}
+				doc = val
+			case []interface{}:
+				i, err := strconv.Atoi(tok)
+				if err != nil || i < 0 || i >= len(d) {
+					return fmt.Errorf("%s not found in response", pointer)
+				}
+				doc = d[i]
+			default:
+				return fmt.Errorf("%s not found in response", pointer)
+			}
+		}
+		if data, err = json.Marshal(doc); err != nil {
+			return err
+		}
+	}
+	if err := json.Unmarshal(data, dst); err != nil {
+		// Headers are strings which may hold other types, and string
+		// parameters may be set from other types.
+		var s string
+		if json.Unmarshal(data, &s) == nil {
+			if json.Unmarshal([]byte(s), dst) == nil {
+				return nil
+			}
+			// Optional parameters are pointers, set to a newly parsed value.
+			if v := reflect.ValueOf(dst).Elem(); v.Kind() == reflect.Ptr {
+				elem := reflect.New(v.Type().Elem())
+				if parseValue(s, elem.Interface()) == nil {
+					v.Set(elem)
+					return nil
+				}
+			} else if parseValue(s, dst) == nil {
+				return nil
+			}
+		}
+		if p, ok := dst.(*string); ok {
+			var val interface{}
+			if json.Unmarshal(data, &val) == nil {
+				*p = fmt.Sprint(val)
+				return nil
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+`
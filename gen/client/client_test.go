@@ -0,0 +1,316 @@
+package client
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+const petstore = `
+swagger: "2.0"
+info:
+  title: Petstore
+  version: "1.0"
+host: pets.example.com
+basePath: /v1
+schemes: [https]
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      summary: List the pets in the store.
+      parameters:
+        - name: limit
+          in: query
+          type: integer
+          format: int32
+        - name: tags
+          in: query
+          type: array
+          items:
+            type: string
+          collectionFormat: multi
+        - name: X-Request-Id
+          in: header
+          type: string
+          required: true
+      responses:
+        "200":
+          description: The pets.
+          schema:
+            type: array
+            items:
+              $ref: "#/definitions/Pet"
+        default:
+          $ref: "#/responses/Error"
+    post:
+      operationId: createPet
+      parameters:
+        - name: pet
+          in: body
+          required: true
+          schema:
+            $ref: "#/definitions/Pet"
+      responses:
+        "201":
+          description: Created.
+          schema:
+            $ref: "#/definitions/Pet"
+          x-links:
+            self:
+              operationId: getPet
+              parameters:
+                petId: $response.body#/id
+        "409":
+          description: Conflict.
+  /pets/{petId}:
+    parameters:
+      - name: petId
+        in: path
+        required: true
+        type: integer
+    get:
+      operationId: getPet
+      responses:
+        "200":
+          description: The pet.
+          schema:
+            $ref: "#/definitions/Pet"
+          x-links:
+            photo:
+              operationRef: "#/paths/~1pets~1{petId}~1photo/put"
+              description: Uploads a photo of the pet.
+              parameters:
+                petId: $request.path.petId
+                caption: Portrait.
+        "404":
+          $ref: "#/responses/Error"
+  /pets/{petId}/photo:
+    put:
+      consumes: [multipart/form-data]
+      parameters:
+        - name: petId
+          in: path
+          required: true
+          type: string
+        - name: caption
+          in: formData
+          type: string
+        - name: photo
+          in: formData
+          type: file
+          required: true
+      responses:
+        "204":
+          description: Uploaded.
+  /health:
+    get:
+      responses:
+        "200":
+          description: Healthy.
+responses:
+  Error:
+    description: An error.
+    schema:
+      $ref: "#/definitions/Error"
+definitions:
+  Pet:
+    description: A pet in the store.
+    type: object
+    required: [id, name]
+    properties:
+      id:
+        type: integer
+        format: int64
+      name:
+        type: string
+      born:
+        type: string
+        format: date-time
+      owner:
+        $ref: "#/definitions/Owner"
+  Owner:
+    type: object
+    properties:
+      name:
+        type: string
+  Error:
+    type: object
+    properties:
+      message:
+        type: string
+`
+
+func parse(t *testing.T, doc string) *spec.Swagger {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(doc), &s); err != nil {
+		t.Fatal(err)
+	}
+	return &s
+}
+
+func TestGenerate(t *testing.T) {
+	files, err := Generate(parse(t, petstore), Options{Package: "petstore"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The generated package must compile.
+	fset := token.NewFileSet()
+	var parsed []*ast.File
+	for _, f := range files {
+		if !strings.HasPrefix(string(f.Data), "// Code generated by swaggopher. DO NOT EDIT.") {
+			t.Errorf("%s: missing generated code header", f.Name)
+		}
+		af, err := parser.ParseFile(fset, f.Name, f.Data, 0)
+		if err != nil {
+			t.Fatalf("%s: %v\n%s", f.Name, err, f.Data)
+		}
+		parsed = append(parsed, af)
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	pkg, err := conf.Check("petstore", fset, parsed, nil)
+	if err != nil {
+		for _, f := range files {
+			t.Logf("%s:\n%s", f.Name, f.Data)
+		}
+		t.Fatalf("type checking generated code: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"DefaultBaseURL", `untyped string`},
+		{"NewClient", "func(baseURL string, httpClient *net/http.Client) *petstore.Client"},
+		{"ListPetsParams", "struct{Limit *int32; Tags []string; XRequestID string}"},
+		{"ListPetsResponse", "struct{StatusCode int; Header net/http.Header; OK []petstore.Pet; Default *petstore.Error}"},
+		{"CreatePetParams", "struct{Pet *petstore.Pet}"},
+		{"CreatePetResponse", "struct{StatusCode int; Header net/http.Header; Created *petstore.Pet}"},
+		{"GetPetParams", "struct{PetID int64}"},
+		{"PutPetsPetIDPhotoParams", "struct{PetID string; Caption *string; Photo io.Reader}"},
+		{"GetHealthResponse", "struct{StatusCode int; Header net/http.Header}"},
+		{"Pet", "struct{Born time.Time \"json:\\\"born,omitempty\\\"\"; ID int64 \"json:\\\"id\\\"\"; Name string \"json:\\\"name\\\"\"; Owner *petstore.Owner \"json:\\\"owner,omitempty\\\"\"}"},
+	}
+	for i, tt := range tests {
+		obj := pkg.Scope().Lookup(tt.name)
+		if obj == nil {
+			t.Errorf("case %d: %s not declared", i, tt.name)
+			continue
+		}
+		if got := obj.Type().Underlying().String(); got != tt.want {
+			t.Errorf("case %d: %s: want %s, got %s", i, tt.name, tt.want, got)
+		}
+	}
+
+	methods := []struct {
+		name string
+		want string
+	}{
+		{"ListPets", "func(ctx context.Context, params *petstore.ListPetsParams) (*petstore.ListPetsResponse, error)"},
+		{"GetHealth", "func(ctx context.Context) (*petstore.GetHealthResponse, error)"},
+		{"CreatePetSelf", "func(ctx context.Context, from *petstore.CreatePetResponse) (*petstore.GetPetResponse, error)"},
+		{"GetPetPhoto", "func(ctx context.Context, from *petstore.GetPetResponse, req *petstore.GetPetParams) (*petstore.PutPetsPetIDPhotoResponse, error)"},
+	}
+	client := types.NewPointer(pkg.Scope().Lookup("Client").Type())
+	for i, tt := range methods {
+		obj, _, _ := types.LookupFieldOrMethod(client, true, pkg, tt.name)
+		if obj == nil {
+			t.Errorf("case %d: method %s not declared", i, tt.name)
+			continue
+		}
+		if got := obj.Type().String(); got != tt.want {
+			t.Errorf("case %d: %s: want %s, got %s", i, tt.name, tt.want, got)
+		}
+	}
+
+	clientSrc := string(files[0].Data)
+	for _, want := range []string{
+		`const DefaultBaseURL = "https://pets.example.com/v1"`,
+		`path := "/pets/" + url.PathEscape(formatParam(params.PetID))`,
+		`query.Add("tags", formatParam(v))`,
+		`header.Set("X-Request-Id", formatParam(params.XRequestID))`,
+		`mw.CreateFormFile("photo", "photo")`,
+		`return nil, newStatusError(resp)`,
+		`if err := linkValue(from.Created, "/id", &params.PetID); err != nil {`,
+		`if err := linkValue(req.PetID, "", &params.PetID); err != nil {`,
+		`if err := linkValue(json.RawMessage("\"Portrait.\""), "", &params.Caption); err != nil {`,
+	} {
+		if !strings.Contains(clientSrc, want) {
+			t.Errorf("client.go doesn't contain %q", want)
+		}
+	}
+}
+
+func TestGenerateDuplicateNames(t *testing.T) {
+	doc := parse(t, `
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+paths:
+  /pets:
+    get:
+      operationId: getPets
+      responses: {"200": {description: OK.}}
+  /pets/:
+    get:
+      operationId: get_pets
+      responses: {"200": {description: OK.}}
+`)
+	if _, err := Generate(doc, Options{}); err == nil {
+		t.Errorf("expected an error for operations with the same name")
+	}
+}
+
+func TestGenerateLinkErrors(t *testing.T) {
+	tests := []struct {
+		links string
+		want  string
+	}{
+		{
+			links: `{pets: {operationId: listPets, parameters: {limit: $url}}}`,
+			want:  `client: link pets of ListPets's 200 response: parameter "limit": $url isn't supported`,
+		},
+		{
+			links: `{pets: {operationId: listPets, parameters: {limit: $request.header.X-Trace}}}`,
+			want:  `client: link pets of ListPets's 200 response: parameter "limit": $request.header.X-Trace: ListPets has no such parameter`,
+		},
+		{
+			links: `{pets: {operationId: listPets, parameters: {limit: $response.body#/size}}}`,
+			want:  `client: link pets of ListPets's 200 response: parameter "limit": $response.body#/size: the response has no body`,
+		},
+		{
+			links: `{pets: {operationId: listPets}, Pets: {operationId: listPets}}`,
+			want:  `client: link Pets of ListPets's 200 response and link pets of ListPets's 200 response would both be called ListPetsPets`,
+		},
+	}
+	for i, tt := range tests {
+		doc := parse(t, `
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      parameters:
+      - {name: limit, in: query, type: integer}
+      responses:
+        "200":
+          description: OK.
+          x-links: `+tt.links+`
+`)
+		_, err := Generate(doc, Options{})
+		if err == nil {
+			t.Errorf("case %d: expected error %q", i, tt.want)
+		} else if err.Error() != tt.want {
+			t.Errorf("case %d: want error %q, got %q", i, tt.want, err)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+/*
+Package gen implements functionality shared by the code generators.
+*/
+package gen
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/ericchiang/swaggopher/internal/logutil"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// File is a generated file.
+type File struct {
+	// Path of the file, relative to the output directory.
+	Name string
+	Data []byte
+}
+
+// Op describes what writing a File did, or would do, to the file system.
+type Op int
+
+const (
+	// Create indicates the file did not exist.
+	Create Op = iota
+	// Update indicates the file existed with different contents.
+	Update
+	// Unchanged indicates the file already had the generated contents.
+	Unchanged
+)
+
+func (op Op) String() string {
+	switch op {
+	case Create:
+		return "create"
+	case Update:
+		return "update"
+	case Unchanged:
+		return "unchanged"
+	}
+	return "unknown"
+}
+
+// Change records the effect of writing a single File.
+type Change struct {
+	// Path of the file, including the output directory.
+	Path string
+	Op   Op
+}
+
+// WriteOptions controls how generated files are written.
+type WriteOptions struct {
+	// DryRun reports the changes that would be made without writing anything.
+	DryRun bool
+	// Progress, if non-nil, is called after each file is processed.
+	Progress spec.ProgressFunc
+	// Logger, if non-nil, receives a line for each change.
+	Logger spec.Logger
+}
+
+// Write writes files to dir, creating any directories required, and reports
+// what changed. Files that already have the generated contents are not
+// rewritten.
+func Write(dir string, files []File, opts WriteOptions) ([]Change, error) {
+	defer logutil.Phase(opts.Logger, "generate")()
+	changes := make([]Change, len(files))
+	for i, f := range files {
+		path := filepath.Join(dir, f.Name)
+		op, err := diskOp(path, f.Data)
+		if err != nil {
+			return nil, err
+		}
+		changes[i] = Change{Path: path, Op: op}
+
+		if op != Unchanged && !opts.DryRun {
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return nil, err
+			}
+			if err := ioutil.WriteFile(path, f.Data, 0644); err != nil {
+				return nil, err
+			}
+		}
+
+		if opts.DryRun {
+			logutil.Printf(opts.Logger, "would %s %s", op, path)
+		} else {
+			logutil.Printf(opts.Logger, "%s %s", op, path)
+		}
+		if opts.Progress != nil {
+			opts.Progress(i+1, len(files), path)
+		}
+	}
+	return changes, nil
+}
+
+func diskOp(path string, data []byte) (Op, error) {
+	existing, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Create, nil
+		}
+		return 0, err
+	}
+	if bytes.Equal(existing, data) {
+		return Unchanged, nil
+	}
+	return Update, nil
+}
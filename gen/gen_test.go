@@ -0,0 +1,79 @@
+package gen
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "swaggopher-gen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "same.go"), []byte("same"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "old.go"), []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	files := []File{
+		{Name: "same.go", Data: []byte("same")},
+		{Name: "old.go", Data: []byte("new")},
+		{Name: "sub/new.go", Data: []byte("new")},
+	}
+	want := []Change{
+		{Path: filepath.Join(dir, "same.go"), Op: Unchanged},
+		{Path: filepath.Join(dir, "old.go"), Op: Update},
+		{Path: filepath.Join(dir, "sub/new.go"), Op: Create},
+	}
+
+	var progress []int
+	var logs bytes.Buffer
+	opts := WriteOptions{
+		DryRun:   true,
+		Progress: func(done, total int, item string) { progress = append(progress, done) },
+		Logger:   log.New(&logs, "", 0),
+	}
+	got, err := Write(dir, files, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := pretty.Compare(got, want); diff != "" {
+		t.Errorf("dry run: want != got: %s", diff)
+	}
+	if diff := pretty.Compare(progress, []int{1, 2, 3}); diff != "" {
+		t.Errorf("progress: want != got: %s", diff)
+	}
+	lines := strings.Split(strings.TrimSuffix(logs.String(), "\n"), "\n")
+	if len(lines) != 5 || lines[0] != "generate: started" ||
+		lines[2] != "would update "+filepath.Join(dir, "old.go") ||
+		!strings.HasPrefix(lines[4], "generate: finished in ") {
+		t.Errorf("unexpected log output %q", logs.String())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "sub/new.go")); !os.IsNotExist(err) {
+		t.Errorf("dry run created a file")
+	}
+
+	if _, err := Write(dir, files, WriteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	got, err = Write(dir, files, WriteOptions{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range got {
+		if c.Op != Unchanged {
+			t.Errorf("after write expected %s to be unchanged, got %s", c.Path, c.Op)
+		}
+	}
+}
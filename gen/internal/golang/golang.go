@@ -0,0 +1,422 @@
+// Package golang maps Swagger schemas to Go types for the code generators.
+package golang
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/ericchiang/swaggopher/internal/formats"
+	"github.com/ericchiang/swaggopher/internal/jsonpointer"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// initialisms are words written in upper case in Go identifiers.
+var initialisms = map[string]bool{
+	"API": true, "HTML": true, "HTTP": true, "HTTPS": true, "ID": true,
+	"IP": true, "JSON": true, "SQL": true, "TLS": true, "TTL": true,
+	"UI": true, "URI": true, "URL": true, "UUID": true, "XML": true,
+}
+
+// Name converts a name from a document, such as "pet_id", "petId" or
+// "get /pets/{petId}", to an exported Go identifier such as "PetID".
+func Name(s string) string {
+	var (
+		words []string
+		word  []rune
+	)
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = nil
+		}
+	}
+	runes := []rune(s)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && i > 0 && unicode.IsLower(runes[i-1]):
+			flush()
+			word = append(word, r)
+		default:
+			word = append(word, r)
+		}
+	}
+	flush()
+
+	var b bytes.Buffer
+	for _, w := range words {
+		if upper := strings.ToUpper(w); initialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		r := []rune(w)
+		b.WriteRune(unicode.ToUpper(r[0]))
+		b.WriteString(string(r[1:]))
+	}
+	name := b.String()
+	if name == "" || unicode.IsDigit([]rune(name)[0]) {
+		name = "X" + name
+	}
+	return name
+}
+
+// Types converts the schemas of a document to Go type expressions.
+type Types struct {
+	// Enums, if set, declares a named type for each enum and a constant for
+	// each of its values. Definitions are named after themselves, and
+	// properties after their definition and name, such as PetStatus.
+	Enums bool
+	// Formats maps formats to the Go types used for them, overriding the
+	// defaults. Types from other packages are written as the import path, a
+	// dot and the name, such as "github.com/google/uuid.UUID".
+	Formats map[string]string
+	// Registered, if set, uses the GoType of formats registered with
+	// validate.RegisterFormat for those not in Formats.
+	Registered bool
+
+	doc *spec.Swagger
+	// enums holds the declarations of the enum types of properties.
+	enums bytes.Buffer
+}
+
+// NewTypes returns a converter for the schemas of a document.
+func NewTypes(doc *spec.Swagger) *Types {
+	return &Types{doc: doc}
+}
+
+// RefName returns the Go type name of a reference to a definition, or an empty
+// string if it doesn't refer to one.
+func RefName(ref string) string {
+	if !strings.HasPrefix(ref, "#/definitions/") {
+		return ""
+	}
+	return Name(jsonpointer.Unescape(strings.TrimPrefix(ref, "#/definitions/")))
+}
+
+// Expr returns the Go type of a schema. References to definitions are
+// converted to the names of the types Definitions declares.
+func (t *Types) Expr(s *spec.Schema) string {
+	return t.expr(s, "")
+}
+
+// expr returns the Go type of a schema. If name is set and Enums is, an enum is
+// declared as a type with that name.
+func (t *Types) expr(s *spec.Schema, name string) string {
+	if s == nil {
+		return "interface{}"
+	}
+	if s.Ref != "" {
+		if name := RefName(s.Ref); name != "" {
+			return name
+		}
+		return "interface{}"
+	}
+	switch s.Type {
+	case "string", "integer", "number", "boolean":
+		if name != "" && t.isEnum(s) {
+			t.enum(&t.enums, name, s)
+			return name
+		}
+		return t.Primitive(s.Type, s.Format)
+	case "array":
+		return "[]" + t.expr(s.Items, name)
+	case "object", "":
+		if len(s.Properties) > 0 || len(s.AllOf) > 0 {
+			var b bytes.Buffer
+			b.WriteString("struct {\n")
+			t.fields(&b, s, name)
+			b.WriteString("}")
+			return b.String()
+		}
+		if ap := s.AdditionalProperties; ap != nil && ap.Schema != nil {
+			return "map[string]" + t.expr(ap.Schema, name)
+		}
+		if s.Type == "object" {
+			return "map[string]interface{}"
+		}
+	}
+	return "interface{}"
+}
+
+// Primitive returns the Go type of a parameter, header or schema with a
+// primitive type and format.
+func (t *Types) Primitive(typ, format string) string {
+	if qualified, ok := t.Formats[format]; ok {
+		return path.Base(qualified)
+	}
+	if t.Registered {
+		if f, ok := formats.Lookup(format); ok && f.Type == typ && f.GoType != "" {
+			return path.Base(f.GoType)
+		}
+	}
+	switch typ {
+	case "string":
+		switch format {
+		case "date-time":
+			return "time.Time"
+		case "byte":
+			return "[]byte"
+		}
+		return "string"
+	case "integer":
+		if format == "int32" {
+			return "int32"
+		}
+		return "int64"
+	case "number":
+		if format == "float" {
+			return "float32"
+		}
+		return "float64"
+	case "boolean":
+		return "bool"
+	case "file":
+		return "io.Reader"
+	}
+	return "interface{}"
+}
+
+// RegisteredTypes returns the GoType of each format registered with
+// validate.RegisterFormat which has one.
+func RegisteredTypes() map[string]string {
+	m := make(map[string]string)
+	for _, name := range formats.Names() {
+		if f, _ := formats.Lookup(name); f.GoType != "" {
+			m[name] = f.GoType
+		}
+	}
+	return m
+}
+
+// IsStruct reports if a schema is converted to a struct, either declared by a
+// definition or inline.
+func (t *Types) IsStruct(s *spec.Schema) bool {
+	if s == nil {
+		return false
+	}
+	if s.Ref != "" {
+		name := RefName(s.Ref)
+		if name == "" {
+			return false
+		}
+		def, ok := t.doc.Definitions[jsonpointer.Unescape(strings.TrimPrefix(s.Ref, "#/definitions/"))]
+		return ok && t.IsStruct(&def)
+	}
+	return (s.Type == "object" || s.Type == "") && (len(s.Properties) > 0 || len(s.AllOf) > 0)
+}
+
+// fields writes the fields of an object schema, whose Go name, if it has one,
+// is parent. Schemas composed with allOf embed the definitions they refer to,
+// and inline the fields of the rest.
+func (t *Types) fields(b *bytes.Buffer, s *spec.Schema, parent string) {
+	for i := range s.AllOf {
+		part := &s.AllOf[i]
+		if name := RefName(part.Ref); name != "" {
+			fmt.Fprintf(b, "%s\n", name)
+			continue
+		}
+		t.fields(b, part, parent)
+	}
+	required := make(map[string]bool)
+	for _, name := range s.Required {
+		required[name] = true
+	}
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		prop := s.Properties[name]
+		if prop.Description != "" {
+			Comment(b, prop.Description)
+		}
+		enumName := ""
+		if parent != "" {
+			enumName = t.unique(parent + Name(name))
+		}
+		typ := t.expr(&prop, enumName)
+		tag := name
+		if !required[name] {
+			tag += ",omitempty"
+			// Structs are never empty, so only a pointer can be omitted.
+			if t.IsStruct(&prop) {
+				typ = "*" + typ
+			}
+		}
+		fmt.Fprintf(b, "%s %s `json:%s`\n", Name(name), typ, strconv.Quote(tag))
+	}
+}
+
+// Definitions writes a type declaration for each of the document's
+// definitions, in name order.
+func (t *Types) Definitions(b *bytes.Buffer) {
+	names := make([]string, 0, len(t.doc.Definitions))
+	for name := range t.doc.Definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		def := t.doc.Definitions[name]
+		goName := Name(name)
+		if def.Ref == "" && t.isEnum(&def) {
+			t.enum(b, goName, &def)
+			continue
+		}
+		desc := def.Description
+		if desc == "" {
+			desc = def.Title
+		}
+		if desc != "" {
+			Comment(b, goName+": "+desc)
+		}
+		if def.Ref != "" {
+			// A definition which is another's alias.
+			fmt.Fprintf(b, "type %s = %s\n\n", goName, t.Expr(&def))
+			continue
+		}
+		fmt.Fprintf(b, "type %s %s\n\n", goName, t.expr(&def, goName))
+	}
+	b.Write(t.enums.Bytes())
+	t.enums.Reset()
+}
+
+// isEnum reports if a schema is declared as an enum type.
+func (t *Types) isEnum(s *spec.Schema) bool {
+	if !t.Enums || len(s.Enum) == 0 {
+		return false
+	}
+	switch t.Primitive(s.Type, s.Format) {
+	case "string", "int32", "int64", "float32", "float64":
+		return true
+	}
+	return false
+}
+
+// unique returns a name for a property's enum type which doesn't collide with
+// a definition.
+func (t *Types) unique(name string) string {
+	for def := range t.doc.Definitions {
+		if Name(def) == name {
+			return name + "Value"
+		}
+	}
+	return name
+}
+
+// enum writes the declaration of an enum type and its values.
+func (t *Types) enum(b *bytes.Buffer, name string, s *spec.Schema) {
+	typ := t.Primitive(s.Type, s.Format)
+	desc := s.Description
+	if desc == "" {
+		desc = s.Title
+	}
+	if desc != "" {
+		Comment(b, name+": "+desc)
+	}
+	fmt.Fprintf(b, "type %s %s\n\n", name, typ)
+	fmt.Fprintf(b, "// Values of %s.\nconst (\n", name)
+	seen := make(map[string]bool)
+	for _, v := range s.Enum {
+		var lit string
+		if typ == "string" {
+			lit = strconv.Quote(fmt.Sprint(v))
+		} else {
+			lit = strconv.FormatFloat(toFloat(v), 'g', -1, 64)
+		}
+		constName := Name(name + " " + fmt.Sprint(v))
+		if strings.HasPrefix(lit, "-") {
+			constName = Name(name + " minus " + fmt.Sprint(v))
+		}
+		if seen[constName] {
+			continue
+		}
+		seen[constName] = true
+		fmt.Fprintf(b, "%s %s = %s\n", constName, name, lit)
+	}
+	b.WriteString(")\n\n")
+}
+
+func toFloat(v interface{}) float64 {
+	switch v := v.(type) {
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float64:
+		return v
+	}
+	f, _ := strconv.ParseFloat(fmt.Sprint(v), 64)
+	return f
+}
+
+// Comment writes text as a Go comment.
+func Comment(b *bytes.Buffer, text string) {
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		fmt.Fprintf(b, "// %s\n", strings.TrimSpace(line))
+	}
+}
+
+// File assembles a generated Go file from a header comment, which must end in a
+// newline if set, a package name and the declarations of the body. Whichever of
+// the candidate imports the body refers to are imported, and the result is
+// formatted.
+func File(name, header, pkg string, body []byte, candidates []string) ([]byte, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, name, append([]byte("package "+pkg+"\n"), body...), 0)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %v\n%s", name, err, body)
+	}
+	used := make(map[string]bool)
+	ast.Inspect(f, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok && id.Obj == nil {
+				used[id.Name] = true
+			}
+		}
+		return true
+	})
+	var imports []string
+	for _, imp := range candidates {
+		if used[path.Base(imp)] {
+			imports = append(imports, imp)
+			// Each package is imported once, even if it's a candidate twice.
+			delete(used, path.Base(imp))
+		}
+	}
+	sort.Strings(imports)
+
+	var b bytes.Buffer
+	b.WriteString("// Code generated by swaggopher. DO NOT EDIT.\n\n")
+	b.WriteString(header)
+	fmt.Fprintf(&b, "package %s\n\n", pkg)
+	if len(imports) > 0 {
+		b.WriteString("import (\n")
+		for _, imp := range imports {
+			fmt.Fprintf(&b, "%q\n", imp)
+		}
+		b.WriteString(")\n\n")
+	}
+	b.Write(body)
+	return Format(name, b.Bytes())
+}
+
+// Format formats Go source, including the source in any error so generator
+// bugs can be diagnosed.
+func Format(name string, src []byte) ([]byte, error) {
+	out, err := format.Source(src)
+	if err != nil {
+		return nil, fmt.Errorf("formatting %s: %v\n%s", name, err, src)
+	}
+	return out, nil
+}
@@ -0,0 +1,260 @@
+package golang
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/coerce"
+	"github.com/ericchiang/swaggopher/internal/jsonpointer"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// PathVariable matches the variables of a path template, such as "{petId}".
+var PathVariable = regexp.MustCompile(`\{([^{}/]+)\}`)
+
+// CollectionSeparator returns the separator of an array parameter's collection
+// format, other than "multi", as coerce.Separator does. Unknown formats, which
+// document validation reports, are treated as csv.
+func CollectionSeparator(collectionFormat string) string {
+	sep, err := coerce.Separator(collectionFormat)
+	if err != nil {
+		return ","
+	}
+	return sep
+}
+
+// ParseValue returns the source of parseValue, which generated code calls to
+// parse the string value of a parameter or header into dst, a pointer to a
+// value of a type Primitive returns. It accepts the encodings coerce.Value
+// does with the same options.
+func ParseValue(opts coerce.Options) string {
+	parseBool := `switch s {
+		case "true":
+			*dst = true
+		case "false":
+			*dst = false
+		default:
+			err = fmt.Errorf("invalid boolean")
+		}`
+	if opts.LenientBoolean {
+		parseBool = "*dst, err = strconv.ParseBool(s)"
+	}
+	layouts := make([]string, len(opts.DateTimeLayouts()))
+	for i, layout := range opts.DateTimeLayouts() {
+		layouts[i] = strconv.Quote(layout)
+	}
+	return fmt.Sprintf(`// parseValue parses the string value of a parameter or header into dst.
+func parseValue(s string, dst interface{}) error {
+	var err error
+	switch dst := dst.(type) {
+	case *string:
+		*dst = s
+	case *int32:
+		var n int64
+		n, err = strconv.ParseInt(s, 10, 32)
+		*dst = int32(n)
+	case *int64:
+		*dst, err = strconv.ParseInt(s, 10, 64)
+	case *float32:
+		var f float64
+		f, err = strconv.ParseFloat(s, 32)
+		*dst = float32(f)
+	case *float64:
+		*dst, err = strconv.ParseFloat(s, 64)
+	case *bool:
+		%s
+	case *time.Time:
+		for _, layout := range []string{%s} {
+			if *dst, err = time.Parse(layout, s); err == nil {
+				break
+			}
+		}
+	case *[]byte:
+		*dst, err = base64.StdEncoding.DecodeString(s)
+	case *interface{}:
+		*dst = s
+	default:
+		return fmt.Errorf("unsupported type %%T", dst)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid value %%q", s)
+	}
+	return nil
+}
+
+`, parseBool, strings.Join(layouts, ", "))
+}
+
+// Operation is an operation of a document and the Go names of its parts.
+type Operation struct {
+	*spec.Operation
+	// Name is the Go name of the operation, derived from its operationId or,
+	// if it has none, its method and path.
+	Name      string
+	Method    string
+	Path      string
+	Params    []Param
+	Responses []Response
+}
+
+// HasFile reports if the operation has a file parameter, requiring a multipart
+// request body.
+func (op *Operation) HasFile() bool {
+	for _, p := range op.Params {
+		if p.In == "formData" && p.Type == "file" {
+			return true
+		}
+	}
+	return false
+}
+
+// Param is a resolved parameter and the field holding it.
+type Param struct {
+	*spec.Parameter
+	Field string
+	// GoType is the type of the field. Required parameters have a value
+	// type and optional ones are pointers, slices or interfaces.
+	GoType string
+}
+
+// Response is a documented response and the field holding its body.
+type Response struct {
+	// Code is the status code, or "default".
+	Code  string
+	Field string
+	// GoType is the type of the body, or empty if it has none.
+	GoType string
+}
+
+// Operations returns the document's operations, ordered by name. An error is
+// returned if two operations would have the same name.
+func (t *Types) Operations() ([]Operation, error) {
+	var (
+		ops []Operation
+		err error
+	)
+	names := make(map[string]string)
+	t.doc.RangeOperations(func(path, method string, op *spec.Operation) bool {
+		method = strings.ToUpper(method)
+		name := Name(op.OperationId)
+		if op.OperationId == "" {
+			name = Name(strings.ToLower(method) + " " + path)
+		}
+		id := method + " " + path
+		if other, ok := names[name]; ok {
+			err = fmt.Errorf("%s and %s would both be called %s", other, id, name)
+			return false
+		}
+		names[name] = id
+		item := t.doc.Paths[path]
+		ops = append(ops, Operation{
+			Operation: op,
+			Name:      name,
+			Method:    method,
+			Path:      path,
+			Params:    t.params(&item, op),
+			Responses: t.responses(op),
+		})
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(ops, func(i, j int) bool { return ops[i].Name < ops[j].Name })
+	return ops, nil
+}
+
+// params returns the parameters of an operation, including those of its path
+// item which it doesn't override, in the order they're declared.
+func (t *Types) params(item *spec.PathItem, op *spec.Operation) []Param {
+	var all []Param
+	fields := make(map[string]bool)
+	for _, p := range t.doc.OperationParameters(item, op) {
+		if p.Ref != "" {
+			// Unresolved references are reported by validation.
+			continue
+		}
+		field := Name(p.Name)
+		if fields[field] {
+			field = Name(p.In + " " + p.Name)
+		}
+		fields[field] = true
+
+		var typ string
+		switch {
+		case p.In == "body":
+			typ = t.Expr(p.Schema)
+			if t.IsStruct(p.Schema) {
+				typ = "*" + typ
+			}
+		case p.Type == "array":
+			typ = "[]" + t.itemsType(p.Items)
+		default:
+			typ = t.Primitive(p.Type, p.Format)
+			if !p.Required && p.Type != "file" {
+				typ = "*" + typ
+			}
+		}
+		all = append(all, Param{Parameter: p, Field: field, GoType: typ})
+	}
+	return all
+}
+
+func (t *Types) itemsType(items *spec.Items) string {
+	if items == nil {
+		return "string"
+	}
+	if items.Type == "array" {
+		return "[]" + t.itemsType(items.Items)
+	}
+	return t.Primitive(items.Type, items.Format)
+}
+
+// responses returns the documented responses of an operation ordered by
+// status, with the default response last.
+func (t *Types) responses(op *spec.Operation) []Response {
+	codes := make([]string, 0, len(op.Responses))
+	for code := range op.Responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	var responses []Response
+	var def *Response
+	for _, code := range codes {
+		r := op.Responses[code]
+		if r.Ref != "" {
+			r = t.doc.Responses[jsonpointer.Unescape(strings.TrimPrefix(r.Ref, "#/responses/"))]
+		}
+		resp := Response{Code: code, Field: "Default"}
+		if code != "default" {
+			n, err := strconv.Atoi(code)
+			if err != nil {
+				continue
+			}
+			resp.Field = Name(http.StatusText(n))
+			if http.StatusText(n) == "" {
+				resp.Field = "Status" + code
+			}
+		}
+		if r.Schema != nil && r.Schema.Type != "file" {
+			resp.GoType = t.Expr(r.Schema)
+			if t.IsStruct(r.Schema) {
+				resp.GoType = "*" + resp.GoType
+			}
+		}
+		if code == "default" {
+			def = &resp
+			continue
+		}
+		responses = append(responses, resp)
+	}
+	if def != nil {
+		responses = append(responses, *def)
+	}
+	return responses
+}
@@ -0,0 +1,90 @@
+/*
+Package models generates Go types for the definitions of a document, without a
+client or server.
+
+Each definition becomes a named type. Objects become structs whose optional
+fields are omitted when empty, and definitions composed with allOf embed the
+definitions they refer to:
+
+	// Dog: A pet which barks.
+	type Dog struct {
+		Pet
+		Bark bool `json:"bark,omitempty"`
+	}
+
+Enums become named types with a constant for each value, such as PetStatus and
+PetStatusAvailable. Formats choose the types of strings: date-time is
+time.Time, byte is []byte and, by default, uuid is github.com/google/uuid.UUID.
+Formats registered with validate.RegisterFormat use their GoType.
+*/
+package models
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/gen"
+	"github.com/ericchiang/swaggopher/gen/internal/golang"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// DefaultFormats are the format types used if Options.Formats is nil.
+var DefaultFormats = map[string]string{
+	"uuid": "github.com/google/uuid.UUID",
+}
+
+// Options configures Generate.
+type Options struct {
+	// Package is the name of the generated package. It defaults to "models".
+	Package string
+	// Formats maps formats to the Go types used for them, as the import path,
+	// a dot and the name, such as "github.com/google/uuid.UUID". If nil,
+	// DefaultFormats is used.
+	Formats map[string]string
+}
+
+// Generate returns models.go, which declares a type for each of a document's
+// definitions.
+func Generate(doc *spec.Swagger, opts Options) ([]gen.File, error) {
+	pkg := opts.Package
+	if pkg == "" {
+		pkg = "models"
+	}
+	formats := opts.Formats
+	if formats == nil {
+		formats = DefaultFormats
+	}
+	goTypes := golang.RegisteredTypes()
+	for format, typ := range formats {
+		goTypes[format] = typ
+	}
+	candidates := []string{"time"}
+	for format, typ := range goTypes {
+		i := strings.LastIndex(typ, ".")
+		if i < 0 {
+			continue
+		}
+		if i == len(typ)-1 || strings.LastIndex(typ, "/") > i {
+			return nil, fmt.Errorf("models: format %s: type %q must be an import path, a dot and a name", format, typ)
+		}
+		candidates = append(candidates, typ[:i])
+	}
+
+	types := golang.NewTypes(doc)
+	types.Enums = true
+	types.Formats = formats
+	types.Registered = true
+	var body bytes.Buffer
+	types.Definitions(&body)
+	title := "the API"
+	if doc.Info != nil && doc.Info.Title != "" {
+		title = "the " + doc.Info.Title + " API"
+	}
+	header := fmt.Sprintf("// Package %s holds the types of %s.\n", pkg, title)
+	src, err := golang.File("models.go", header, pkg, body.Bytes(), candidates)
+	if err != nil {
+		return nil, fmt.Errorf("models: %v", err)
+	}
+	return []gen.File{{Name: "models.go", Data: src}}, nil
+}
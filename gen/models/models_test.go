@@ -0,0 +1,161 @@
+package models
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/ericchiang/swaggopher/spec"
+	"github.com/ericchiang/swaggopher/validate"
+)
+
+const petstore = `
+swagger: "2.0"
+info: {title: Petstore, version: "1.0"}
+paths: {}
+definitions:
+  Pet:
+    description: A pet in the store.
+    type: object
+    required: [id, name]
+    properties:
+      id: {type: string, format: uuid}
+      name: {type: string}
+      born: {type: string, format: date-time}
+      status:
+        type: string
+        enum: [available, sold]
+      tags:
+        type: array
+        items: {type: string}
+  Dog:
+    description: A pet which barks.
+    allOf:
+      - $ref: "#/definitions/Pet"
+      - type: object
+        properties:
+          bark: {type: boolean}
+  Level:
+    type: integer
+    enum: [1, 2, -1]
+  Owner:
+    $ref: "#/definitions/Person"
+  Person:
+    type: object
+    properties:
+      name: {type: string}
+`
+
+func parse(t *testing.T, doc string) *spec.Swagger {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(doc), &s); err != nil {
+		t.Fatal(err)
+	}
+	return &s
+}
+
+func TestGenerate(t *testing.T) {
+	files, err := Generate(parse(t, petstore), Options{Package: "petstore"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].Name != "models.go" {
+		t.Fatalf("expected models.go, got %v", files)
+	}
+	src := string(files[0].Data)
+	for _, want := range []string{
+		`"github.com/google/uuid"`,
+		"ID     uuid.UUID `json:\"id\"`",
+		"// Dog: A pet which barks.\ntype Dog struct {\n\tPet\n",
+		"type PetStatus string",
+		`PetStatusAvailable PetStatus = "available"`,
+		"LevelMinus1 Level = -1",
+		"type Owner = Person",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("models.go doesn't contain %q:\n%s", want, src)
+		}
+	}
+}
+
+func TestGenerateCompiles(t *testing.T) {
+	files, err := Generate(parse(t, petstore), Options{Package: "petstore", Formats: map[string]string{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, files[0].Name, files[0].Data, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	pkg, err := conf.Check("petstore", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatalf("type checking generated code: %v\n%s", err, files[0].Data)
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"PetStatusSold", "petstore.PetStatus"},
+		{"Level2", "petstore.Level"},
+		{"Pet", `struct{Born time.Time "json:\"born,omitempty\""; ID string "json:\"id\""; Name string "json:\"name\""; Status petstore.PetStatus "json:\"status,omitempty\""; Tags []string "json:\"tags,omitempty\""}`},
+	}
+	for i, tt := range tests {
+		obj := pkg.Scope().Lookup(tt.name)
+		if obj == nil {
+			t.Errorf("case %d: %s not declared", i, tt.name)
+			continue
+		}
+		got := obj.Type().String()
+		if _, ok := obj.(*types.TypeName); ok {
+			got = obj.Type().Underlying().String()
+		}
+		if got != tt.want {
+			t.Errorf("case %d: %s: want %s, got %s", i, tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestGenerateInvalidFormat(t *testing.T) {
+	_, err := Generate(parse(t, petstore), Options{Formats: map[string]string{"uuid": "github.com/google/uuid."}})
+	if err == nil {
+		t.Errorf("expected an error for an invalid format type")
+	}
+}
+
+func TestGenerateRegisteredFormat(t *testing.T) {
+	validate.RegisterFormat("x-money", validate.Format{
+		Type:   "string",
+		GoType: "github.com/shopspring/decimal.Decimal",
+	})
+	doc := parse(t, `
+definitions:
+  Price:
+    type: object
+    properties:
+      amount: {type: string, format: x-money}
+      at: {type: string, format: date-time}
+`)
+	files, err := Generate(doc, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := string(files[0].Data)
+	for _, want := range []string{
+		`"github.com/shopspring/decimal"`,
+		"Amount decimal.Decimal `json:\"amount,omitempty\"`",
+		`"time"`,
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("models.go doesn't contain %q:\n%s", want, src)
+		}
+	}
+}
@@ -0,0 +1,525 @@
+/*
+Package server generates net/http server scaffolding for the operations of a
+document.
+
+The generated package declares an interface per tag, with a method for each
+operation tagged with it, and a router which serves them:
+
+	type pets struct{}
+
+	func (pets) GetPet(ctx context.Context, params *petstore.GetPetParams) (*petstore.GetPetResponse, error) {
+		return &petstore.GetPetResponse{OK: &petstore.Pet{ID: params.PetID}}, nil
+	}
+
+	http.ListenAndServe(":8080", petstore.NewRouter(petstore.Handlers{Pets: pets{}}))
+
+Operations without tags belong to the DefaultHandler interface, and operations
+with several tags to the interface of the first. The router decodes and checks
+the parameters of each request, responding with a 400 if they're invalid, and
+encodes the body of the response the handler returns according to its status.
+Operations whose handler is nil respond with a 501.
+
+Definitions become Go types in models.go.
+*/
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"net/textproto"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/coerce"
+	"github.com/ericchiang/swaggopher/gen"
+	"github.com/ericchiang/swaggopher/gen/internal/golang"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// Options configures Generate.
+type Options struct {
+	// Package is the name of the generated package. It defaults to "server".
+	Package string
+	// Coerce controls how strictly the generated router parses parameter
+	// values.
+	Coerce coerce.Options
+}
+
+// Generate returns the files of a server package for a document: server.go,
+// holding the handler interfaces and router, and models.go, holding the
+// definitions. An error is returned if two operations would have the same
+// method name.
+func Generate(doc *spec.Swagger, opts Options) ([]gen.File, error) {
+	pkg := opts.Package
+	if pkg == "" {
+		pkg = "server"
+	}
+	g := &generator{doc: doc, pkg: pkg, coerce: opts.Coerce, types: golang.NewTypes(doc)}
+	ops, err := g.types.Operations()
+	if err != nil {
+		return nil, fmt.Errorf("server: %v", err)
+	}
+
+	var body bytes.Buffer
+	g.handlers(&body, ops)
+	g.router(&body, ops)
+	for _, op := range ops {
+		g.declare(&body, op)
+		g.serve(&body, op)
+	}
+	title := "the API"
+	if doc.Info != nil && doc.Info.Title != "" {
+		title = "the " + doc.Info.Title + " API"
+	}
+	header := fmt.Sprintf("// Package %s serves %s.\n", pkg, title)
+	server, err := g.file("server.go", header, &body)
+	if err != nil {
+		return nil, err
+	}
+
+	var defs bytes.Buffer
+	g.types.Definitions(&defs)
+	models, err := g.file("models.go", "", &defs)
+	if err != nil {
+		return nil, err
+	}
+	return []gen.File{server, models}, nil
+}
+
+type generator struct {
+	doc    *spec.Swagger
+	pkg    string
+	coerce coerce.Options
+	types  *golang.Types
+}
+
+// candidates are the packages generated files may import.
+var candidates = []string{
+	"bytes", "context", "encoding/base64", "encoding/json", "fmt",
+	"io", "io/ioutil", "net/http", "net/url", "strconv", "strings", "time",
+}
+
+// file assembles a generated file, importing the packages its body uses.
+func (g *generator) file(name, header string, body *bytes.Buffer) (gen.File, error) {
+	src, err := golang.File(name, header, g.pkg, body.Bytes(), candidates)
+	if err != nil {
+		return gen.File{}, fmt.Errorf("server: %v", err)
+	}
+	return gen.File{Name: name, Data: src}, nil
+}
+
+// group is the operations served by a handler interface.
+type group struct {
+	tag   string
+	field string
+	ops   []golang.Operation
+}
+
+// groups returns the handler interfaces of the operations, ordered by tag with
+// the default interface last.
+func groups(ops []golang.Operation) []group {
+	byTag := make(map[string]*group)
+	var tags []string
+	for _, op := range ops {
+		tag := ""
+		if len(op.Tags) > 0 {
+			tag = op.Tags[0]
+		}
+		grp, ok := byTag[tag]
+		if !ok {
+			field := "Default"
+			if tag != "" {
+				field = golang.Name(tag)
+				tags = append(tags, tag)
+			}
+			grp = &group{tag: tag, field: field}
+			byTag[tag] = grp
+		}
+		grp.ops = append(grp.ops, op)
+	}
+	sort.Strings(tags)
+	if _, ok := byTag[""]; ok {
+		tags = append(tags, "")
+	}
+	list := make([]group, len(tags))
+	for i, tag := range tags {
+		list[i] = *byTag[tag]
+	}
+	return list
+}
+
+// handlerOf returns the field of Handlers which serves an operation.
+func handlerOf(op golang.Operation) string {
+	if len(op.Tags) > 0 {
+		return golang.Name(op.Tags[0])
+	}
+	return "Default"
+}
+
+// signature returns the method signature of an operation's handler.
+func signature(op golang.Operation) string {
+	if len(op.Params) == 0 {
+		return fmt.Sprintf("%s(ctx context.Context) (*%sResponse, error)", op.Name, op.Name)
+	}
+	return fmt.Sprintf("%s(ctx context.Context, params *%sParams) (*%sResponse, error)", op.Name, op.Name, op.Name)
+}
+
+// handlers writes the handler interfaces and the Handlers struct.
+func (g *generator) handlers(b *bytes.Buffer, ops []golang.Operation) {
+	descriptions := make(map[string]string)
+	for _, tag := range g.doc.Tags {
+		descriptions[tag.Name] = tag.Description
+	}
+	grps := groups(ops)
+	for _, grp := range grps {
+		if grp.tag == "" {
+			fmt.Fprintf(b, "// %sHandler handles the operations without tags.\n", grp.field)
+		} else {
+			fmt.Fprintf(b, "// %sHandler handles the operations tagged %q.\n", grp.field, grp.tag)
+			if desc := descriptions[grp.tag]; desc != "" {
+				b.WriteString("//\n")
+				golang.Comment(b, desc)
+			}
+		}
+		fmt.Fprintf(b, "type %sHandler interface {\n", grp.field)
+		for _, op := range grp.ops {
+			summary := op.Summary
+			if summary == "" {
+				summary = op.Description
+			}
+			if summary != "" {
+				golang.Comment(b, op.Name+" handles "+op.Method+" "+op.Path+": "+summary)
+			} else {
+				fmt.Fprintf(b, "// %s handles %s %s.\n", op.Name, op.Method, op.Path)
+			}
+			b.WriteString(signature(op) + "\n")
+		}
+		b.WriteString("}\n\n")
+	}
+
+	b.WriteString("// Handlers holds the implementations of the API's operations. Operations\n// whose handler is nil respond with 501 Not Implemented.\ntype Handlers struct {\n")
+	for _, grp := range grps {
+		fmt.Fprintf(b, "%s %sHandler\n", grp.field, grp.field)
+	}
+	b.WriteString("}\n\n")
+}
+
+// routeOrder reports if path template a should be matched before b: at the
+// first segment where they differ, literal text is preferred to a variable.
+func routeOrder(a, b string) bool {
+	as, bs := strings.Split(a, "/"), strings.Split(b, "/")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] == bs[i] {
+			continue
+		}
+		av, bv := strings.Contains(as[i], "{"), strings.Contains(bs[i], "{")
+		if av != bv {
+			return bv
+		}
+		return as[i] < bs[i]
+	}
+	return len(as) < len(bs)
+}
+
+// router writes NewRouter and the helpers the generated code shares.
+func (g *generator) router(b *bytes.Buffer, ops []golang.Operation) {
+	routes := append([]golang.Operation(nil), ops...)
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routeOrder(routes[i].Path, routes[j].Path)
+		}
+		return routes[i].Method < routes[j].Method
+	})
+
+	fmt.Fprintf(b, `// BasePath is the prefix the document says operation paths are served under.
+// The router strips it from request paths.
+const BasePath = %q
+
+// NewRouter returns a handler routing requests to the implementations of the
+// operations. Requests which don't match a path receive a 404, and those which
+// match a path but not its methods a 405.
+func NewRouter(h Handlers) http.Handler {
+	return &router{routes: []route{
+`, strings.TrimSuffix(g.doc.BasePath, "/"))
+	for _, op := range routes {
+		segments := strings.Split(strings.Trim(op.Path, "/"), "/")
+		quoted := make([]string, len(segments))
+		for i, s := range segments {
+			quoted[i] = strconv.Quote(s)
+		}
+		fmt.Fprintf(b, "{%q, []string{%s}, func(w http.ResponseWriter, r *http.Request, vars map[string]string) {\nserve%s(h.%s, w, r, vars)\n}},\n",
+			op.Method, strings.Join(quoted, ", "), op.Name, handlerOf(op))
+	}
+	b.WriteString(`}}
+}
+
+type route struct {
+	method   string
+	segments []string
+	serve    func(w http.ResponseWriter, r *http.Request, vars map[string]string)
+}
+
+type router struct {
+	routes []route
+}
+
+func (rt *router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.EscapedPath()
+	if !strings.HasPrefix(path, BasePath) {
+		writeError(w, http.StatusNotFound, "no operation for path "+r.URL.Path)
+		return
+	}
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(path, BasePath), "/"), "/")
+	var allowed []string
+	for _, route := range rt.routes {
+		vars, ok := matchPath(route.segments, segments)
+		if !ok {
+			continue
+		}
+		if route.method != r.Method {
+			allowed = append(allowed, route.method)
+			continue
+		}
+		route.serve(w, r, vars)
+		return
+	}
+	if len(allowed) > 0 {
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		writeError(w, http.StatusMethodNotAllowed, "method "+r.Method+" not allowed")
+		return
+	}
+	writeError(w, http.StatusNotFound, "no operation for path "+r.URL.Path)
+}
+
+// matchPath matches the segments of a request's path against those of a path
+// template, returning the values of its variables.
+func matchPath(pattern, segments []string) (map[string]string, bool) {
+	if len(pattern) != len(segments) {
+		return nil, false
+	}
+	vars := make(map[string]string)
+	for i, p := range pattern {
+		s := segments[i]
+		open, close := strings.Index(p, "{"), strings.LastIndex(p, "}")
+		if open < 0 || close < open {
+			if p != s {
+				return nil, false
+			}
+			continue
+		}
+		prefix, suffix := p[:open], p[close+1:]
+		if len(s) <= len(prefix)+len(suffix) || !strings.HasPrefix(s, prefix) || !strings.HasSuffix(s, suffix) {
+			return nil, false
+		}
+		v, err := url.PathUnescape(s[len(prefix) : len(s)-len(suffix)])
+		if err != nil {
+			return nil, false
+		}
+		vars[p[open+1:close]] = v
+	}
+	return vars, true
+}
+
+// checkEnum checks that the value of a parameter is one of those allowed.
+func checkEnum(v interface{}, allowed ...string) error {
+	s := fmt.Sprint(v)
+	for _, a := range allowed {
+		if s == a {
+			return nil
+		}
+	}
+	return fmt.Errorf("%q is not one of %s", s, strings.Join(allowed, ", "))
+}
+
+func writeError(w http.ResponseWriter, code int, message string) {
+	writeJSON(w, code, map[string]string{"message": message})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		code = http.StatusInternalServerError
+		data, _ = json.Marshal(map[string]string{"message": "encoding response: " + err.Error()})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(append(data, '\n'))
+}
+
+`)
+	b.WriteString(golang.ParseValue(g.coerce))
+}
+
+// declare writes the parameter and response types of an operation.
+func (g *generator) declare(b *bytes.Buffer, op golang.Operation) {
+	if len(op.Params) > 0 {
+		fmt.Fprintf(b, "// %sParams holds the parameters of %s.\ntype %sParams struct {\n", op.Name, op.Name, op.Name)
+		for _, p := range op.Params {
+			if p.Description != "" {
+				golang.Comment(b, p.Description)
+			}
+			fmt.Fprintf(b, "%s %s\n", p.Field, p.GoType)
+		}
+		b.WriteString("}\n\n")
+	}
+
+	status := defaultStatus(op)
+	fmt.Fprintf(b, "// %sResponse is the response to %s. The body is taken from the field of\n// the documented response with its status, which defaults to %d.\ntype %sResponse struct {\n", op.Name, op.Name, status, op.Name)
+	b.WriteString("StatusCode int\nHeader http.Header\n")
+	for _, r := range op.Responses {
+		if r.GoType == "" {
+			continue
+		}
+		if r.Code == "default" {
+			fmt.Fprintf(b, "// %s is the body for statuses without their own field.\n", r.Field)
+		} else {
+			fmt.Fprintf(b, "// %s is the body for status %s.\n", r.Field, r.Code)
+		}
+		fmt.Fprintf(b, "%s %s\n", r.Field, r.GoType)
+	}
+	b.WriteString("}\n\n")
+}
+
+// defaultStatus returns the status of a response which leaves StatusCode
+// unset: the operation's first documented status, or 200.
+func defaultStatus(op golang.Operation) int {
+	for _, r := range op.Responses {
+		if n, err := strconv.Atoi(r.Code); err == nil {
+			return n
+		}
+	}
+	return 200
+}
+
+// serve writes the function decoding a request for an operation, calling its
+// handler and encoding the response.
+func (g *generator) serve(b *bytes.Buffer, op golang.Operation) {
+	handler := handlerOf(op) + "Handler"
+	fmt.Fprintf(b, "func serve%s(h %s, w http.ResponseWriter, r *http.Request, vars map[string]string) {\n", op.Name, handler)
+	fmt.Fprintf(b, "if h == nil {\nwriteError(w, http.StatusNotImplemented, %q)\nreturn\n}\n", op.Name+" is not implemented")
+
+	if len(op.Params) > 0 {
+		fmt.Fprintf(b, "params := &%sParams{}\n", op.Name)
+	}
+	hasQuery, hasForm := false, false
+	for _, p := range op.Params {
+		switch p.In {
+		case "query":
+			hasQuery = true
+		case "formData":
+			hasForm = true
+		}
+	}
+	if hasQuery {
+		b.WriteString("query := r.URL.Query()\n")
+	}
+	if hasForm {
+		b.WriteString("if err := r.ParseMultipartForm(32 << 20); err != nil && err != http.ErrNotMultipart {\nwriteError(w, http.StatusBadRequest, \"parsing form: \"+err.Error())\nreturn\n}\n")
+		b.WriteString("form := r.PostForm\n")
+	}
+	for _, p := range op.Params {
+		switch p.In {
+		case "path":
+			fmt.Fprintf(b, "{\nvalues := []string{vars[%q]}\n", p.Name)
+			g.decodeValues(b, p)
+			b.WriteString("}\n")
+		case "query":
+			g.decodeParam(b, p, fmt.Sprintf("query[%q]", p.Name))
+		case "header":
+			g.decodeParam(b, p, fmt.Sprintf("r.Header[%q]", textproto.CanonicalMIMEHeaderKey(p.Name)))
+		case "formData":
+			if p.Type == "file" {
+				fmt.Fprintf(b, "if f, _, err := r.FormFile(%q); err == nil {\ndefer f.Close()\nparams.%s = f\n}", p.Name, p.Field)
+				if p.Required {
+					fmt.Fprintf(b, " else {\nwriteError(w, http.StatusBadRequest, %q)\nreturn\n}", p.Name+" is required")
+				}
+				b.WriteString("\n")
+				continue
+			}
+			g.decodeParam(b, p, fmt.Sprintf("form[%q]", p.Name))
+		case "body":
+			b.WriteString("if data, err := ioutil.ReadAll(r.Body); err != nil {\nwriteError(w, http.StatusBadRequest, \"reading body: \"+err.Error())\nreturn\n}")
+			b.WriteString(" else if len(bytes.TrimSpace(data)) > 0 {\n")
+			fmt.Fprintf(b, "if err := json.Unmarshal(data, &params.%s); err != nil {\nwriteError(w, http.StatusBadRequest, %q+err.Error())\nreturn\n}\n}", p.Field, p.Name+": ")
+			if p.Required {
+				fmt.Fprintf(b, " else {\nwriteError(w, http.StatusBadRequest, %q)\nreturn\n}", p.Name+" is required")
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	if len(op.Params) > 0 {
+		fmt.Fprintf(b, "resp, err := h.%s(r.Context(), params)\n", op.Name)
+	} else {
+		fmt.Fprintf(b, "resp, err := h.%s(r.Context())\n", op.Name)
+	}
+	b.WriteString("if err != nil {\nwriteError(w, http.StatusInternalServerError, err.Error())\nreturn\n}\n")
+	fmt.Fprintf(b, "if resp == nil {\nresp = &%sResponse{}\n}\n", op.Name)
+	fmt.Fprintf(b, "code := resp.StatusCode\nif code == 0 {\ncode = %d\n}\n", defaultStatus(op))
+	b.WriteString("for k, v := range resp.Header {\nw.Header()[k] = v\n}\nswitch code {\n")
+	hasDefault := false
+	for _, r := range op.Responses {
+		if r.Code == "default" {
+			hasDefault = true
+			b.WriteString("default:\n")
+		} else {
+			fmt.Fprintf(b, "case %s:\n", r.Code)
+		}
+		switch {
+		case r.GoType == "":
+			b.WriteString("w.WriteHeader(code)\n")
+		case strings.HasPrefix(r.GoType, "*"):
+			fmt.Fprintf(b, "if resp.%s == nil {\nw.WriteHeader(code)\nbreak\n}\nwriteJSON(w, code, resp.%s)\n", r.Field, r.Field)
+		default:
+			fmt.Fprintf(b, "writeJSON(w, code, resp.%s)\n", r.Field)
+		}
+	}
+	if !hasDefault {
+		b.WriteString("default:\nw.WriteHeader(code)\n")
+	}
+	b.WriteString("}\n}\n\n")
+}
+
+// decodeParam writes the code decoding a parameter from the values of a query,
+// header or form field, checking it's set if required.
+func (g *generator) decodeParam(b *bytes.Buffer, p golang.Param, values string) {
+	fmt.Fprintf(b, "if values := %s; len(values) > 0 {\n", values)
+	g.decodeValues(b, p)
+	b.WriteString("}")
+	if p.Required {
+		fmt.Fprintf(b, " else {\nwriteError(w, http.StatusBadRequest, %q)\nreturn\n}", p.Name+" is required")
+	}
+	b.WriteString("\n")
+}
+
+// decodeValues writes the code parsing a parameter from the values in scope,
+// which are known to be non-empty.
+func (g *generator) decodeValues(b *bytes.Buffer, p golang.Param) {
+	dst := "&params." + p.Field
+	invalid := fmt.Sprintf("writeError(w, http.StatusBadRequest, %q+err.Error())\nreturn\n", p.Name+": ")
+	if p.Type == "array" {
+		if p.CollectionFormat != "multi" {
+			fmt.Fprintf(b, "values = strings.Split(values[0], %q)\n", golang.CollectionSeparator(p.CollectionFormat))
+		}
+		fmt.Fprintf(b, "params.%s = make(%s, len(values))\nfor i, v := range values {\n", p.Field, p.GoType)
+		fmt.Fprintf(b, "if err := parseValue(v, &params.%s[i]); err != nil {\n%s}\n}\n", p.Field, invalid)
+		return
+	}
+	if strings.HasPrefix(p.GoType, "*") {
+		fmt.Fprintf(b, "params.%s = new(%s)\n", p.Field, strings.TrimPrefix(p.GoType, "*"))
+		dst = "params." + p.Field
+	}
+	fmt.Fprintf(b, "if err := parseValue(values[0], %s); err != nil {\n%s}\n", dst, invalid)
+	if len(p.Enum) > 0 {
+		allowed := make([]string, len(p.Enum))
+		for i, v := range p.Enum {
+			allowed[i] = strconv.Quote(fmt.Sprint(v))
+		}
+		v := "params." + p.Field
+		if strings.HasPrefix(p.GoType, "*") {
+			v = "*" + v
+		}
+		fmt.Fprintf(b, "if err := checkEnum(%s, %s); err != nil {\n%s}\n", v, strings.Join(allowed, ", "), invalid)
+	}
+}
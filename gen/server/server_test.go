@@ -0,0 +1,286 @@
+package server
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/ericchiang/swaggopher/gen"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+const petstore = `
+swagger: "2.0"
+info:
+  title: Petstore
+  version: "1.0"
+basePath: /v1
+tags:
+  - name: pets
+    description: Everything about pets.
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      tags: [pets]
+      parameters:
+        - name: limit
+          in: query
+          type: integer
+          format: int32
+        - name: kind
+          in: query
+          type: string
+          enum: [cat, dog]
+        - name: tags
+          in: query
+          type: array
+          items:
+            type: string
+        - name: X-Request-Id
+          in: header
+          type: string
+          required: true
+      responses:
+        "200":
+          description: The pets.
+          schema:
+            type: array
+            items:
+              $ref: "#/definitions/Pet"
+    post:
+      operationId: createPet
+      tags: [pets]
+      parameters:
+        - name: pet
+          in: body
+          required: true
+          schema:
+            $ref: "#/definitions/Pet"
+      responses:
+        "201":
+          description: Created.
+          schema:
+            $ref: "#/definitions/Pet"
+  /pets/mine:
+    get:
+      operationId: listMyPets
+      tags: [pets]
+      responses:
+        "200":
+          description: The pets.
+  /pets/{petId}:
+    parameters:
+      - name: petId
+        in: path
+        required: true
+        type: integer
+    get:
+      operationId: getPet
+      tags: [pets]
+      responses:
+        "200":
+          description: The pet.
+          schema:
+            $ref: "#/definitions/Pet"
+        default:
+          description: An error.
+          schema:
+            $ref: "#/definitions/Error"
+  /health:
+    get:
+      responses:
+        "204":
+          description: Healthy.
+definitions:
+  Pet:
+    type: object
+    required: [name]
+    properties:
+      id:
+        type: integer
+      name:
+        type: string
+  Error:
+    type: object
+    properties:
+      message:
+        type: string
+`
+
+func parse(t *testing.T, doc string) *spec.Swagger {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(doc), &s); err != nil {
+		t.Fatal(err)
+	}
+	return &s
+}
+
+func TestGenerate(t *testing.T) {
+	files, err := Generate(parse(t, petstore), Options{Package: "petstore"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	var parsed []*ast.File
+	for _, f := range files {
+		af, err := parser.ParseFile(fset, f.Name, f.Data, 0)
+		if err != nil {
+			t.Fatalf("%s: %v\n%s", f.Name, err, f.Data)
+		}
+		parsed = append(parsed, af)
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	pkg, err := conf.Check("petstore", fset, parsed, nil)
+	if err != nil {
+		for _, f := range files {
+			t.Logf("%s:\n%s", f.Name, f.Data)
+		}
+		t.Fatalf("type checking generated code: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"BasePath", "untyped string"},
+		{"Handlers", "struct{Pets petstore.PetsHandler; Default petstore.DefaultHandler}"},
+		{"PetsHandler", "interface{CreatePet(ctx context.Context, params *petstore.CreatePetParams) (*petstore.CreatePetResponse, error); GetPet(ctx context.Context, params *petstore.GetPetParams) (*petstore.GetPetResponse, error); ListMyPets(ctx context.Context) (*petstore.ListMyPetsResponse, error); ListPets(ctx context.Context, params *petstore.ListPetsParams) (*petstore.ListPetsResponse, error)}"},
+		{"DefaultHandler", "interface{GetHealth(ctx context.Context) (*petstore.GetHealthResponse, error)}"},
+		{"NewRouter", "func(h petstore.Handlers) net/http.Handler"},
+		{"ListPetsParams", "struct{Limit *int32; Kind *string; Tags []string; XRequestID string}"},
+		{"GetPetResponse", "struct{StatusCode int; Header net/http.Header; OK *petstore.Pet; Default *petstore.Error}"},
+	}
+	for i, tt := range tests {
+		obj := pkg.Scope().Lookup(tt.name)
+		if obj == nil {
+			t.Errorf("case %d: %s not declared", i, tt.name)
+			continue
+		}
+		if got := obj.Type().Underlying().String(); got != tt.want {
+			t.Errorf("case %d: %s: want %s, got %s", i, tt.name, tt.want, got)
+		}
+	}
+}
+
+// serverTest exercises a generated server by running it with go test.
+const serverTest = `package petstore
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type pets struct{}
+
+func (pets) CreatePet(ctx context.Context, params *CreatePetParams) (*CreatePetResponse, error) {
+	params.Pet.ID = 7
+	return &CreatePetResponse{Created: params.Pet}, nil
+}
+
+func (pets) GetPet(ctx context.Context, params *GetPetParams) (*GetPetResponse, error) {
+	if params.PetID != 7 {
+		return &GetPetResponse{StatusCode: 404, Default: &Error{Message: "no such pet"}}, nil
+	}
+	return &GetPetResponse{OK: &Pet{ID: 7, Name: "rex"}}, nil
+}
+
+func (pets) ListMyPets(ctx context.Context) (*ListMyPetsResponse, error) {
+	return nil, nil
+}
+
+func (pets) ListPets(ctx context.Context, params *ListPetsParams) (*ListPetsResponse, error) {
+	limit := "none"
+	if params.Limit != nil {
+		limit = fmt.Sprint(*params.Limit)
+	}
+	return &ListPetsResponse{OK: []Pet{{Name: limit + " " + strings.Join(params.Tags, "+") + " " + params.XRequestID}}}, nil
+}
+
+func TestServer(t *testing.T) {
+	s := httptest.NewServer(NewRouter(Handlers{Pets: pets{}}))
+	defer s.Close()
+
+	tests := []struct {
+		method, path, header, body string
+		wantCode                   int
+		wantBody                   string
+	}{
+		{"GET", "/v1/pets?limit=2&tags=a,b", "x", "", 200, ` + "`" + `[{"name":"2 a+b x"}]` + "`" + `},
+		{"GET", "/v1/pets?limit=two", "x", "", 400, ` + "`" + `{"message":"limit: invalid value \"two\""}` + "`" + `},
+		{"GET", "/v1/pets?kind=fish", "x", "", 400, ` + "`" + `{"message":"kind: \"fish\" is not one of cat, dog"}` + "`" + `},
+		{"GET", "/v1/pets", "", "", 400, ` + "`" + `{"message":"X-Request-Id is required"}` + "`" + `},
+		{"POST", "/v1/pets", "", ` + "`" + `{"name":"rex"}` + "`" + `, 201, ` + "`" + `{"id":7,"name":"rex"}` + "`" + `},
+		{"POST", "/v1/pets", "", "", 400, ` + "`" + `{"message":"pet is required"}` + "`" + `},
+		{"GET", "/v1/pets/7", "", "", 200, ` + "`" + `{"id":7,"name":"rex"}` + "`" + `},
+		{"GET", "/v1/pets/8", "", "", 404, ` + "`" + `{"message":"no such pet"}` + "`" + `},
+		{"GET", "/v1/pets/rex", "", "", 400, ` + "`" + `{"message":"petId: invalid value \"rex\""}` + "`" + `},
+		{"GET", "/v1/pets/mine", "", "", 200, ""},
+		{"DELETE", "/v1/pets/7", "", "", 405, ` + "`" + `{"message":"method DELETE not allowed"}` + "`" + `},
+		{"GET", "/v1/toys", "", "", 404, ` + "`" + `{"message":"no operation for path /v1/toys"}` + "`" + `},
+		{"GET", "/v1/health", "", "", 501, ` + "`" + `{"message":"GetHealth is not implemented"}` + "`" + `},
+	}
+	for i, tt := range tests {
+		req, err := http.NewRequest(tt.method, s.URL+tt.path, strings.NewReader(tt.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tt.header != "" {
+			req.Header.Set("X-Request-Id", tt.header)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != tt.wantCode || strings.TrimSpace(string(body)) != tt.wantBody {
+			t.Errorf("case %d: %s %s: want %d %s, got %d %s", i, tt.method, tt.path, tt.wantCode, tt.wantBody, resp.StatusCode, body)
+		}
+	}
+}
+`
+
+func TestGeneratedServer(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping build of generated code in short mode")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not found")
+	}
+	dir, err := ioutil.TempDir("", "swaggopher-server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files, err := Generate(parse(t, petstore), Options{Package: "petstore"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	files = append(files, gen.File{Name: "server_test.go", Data: []byte(serverTest)})
+	files = append(files, gen.File{Name: "go.mod", Data: []byte("module petstore\n")})
+	if _, err := gen.Write(dir, files, gen.WriteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(goTool, "test", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GO111MODULE=on")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("testing generated server: %v\n%s", err, out)
+	}
+}
@@ -0,0 +1,328 @@
+/*
+Package genspec builds documents from Go code, so they can be kept in sync with
+the types a program actually encodes and the handlers it serves.
+
+SchemaFromType and Definitions use reflection. FromSource reads swaggo style
+annotations from source code instead, without running it.
+
+Fields are named and omitted the way encoding/json names and omits them. A
+field is required unless it's a pointer or tagged omitempty, and the fields of
+embedded structs are promoted into the embedding struct. A field's description
+comes from its "description" tag:
+
+	type Pet struct {
+		Name string  `json:"name" description:"The name given to the pet."`
+		Tag  *string `json:"tag"`
+	}
+*/
+package genspec
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ericchiang/swaggopher/internal/jsonpointer"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// SchemaFromType returns the schema of the JSON encoding of values of type t.
+// Struct types are inlined. A struct which contains itself refers to its own
+// definition, named after the type, as Definitions would name it.
+func SchemaFromType(t reflect.Type) *spec.Schema {
+	r := &reflector{inProgress: make(map[reflect.Type]bool)}
+	s := r.schema(t)
+	return &s
+}
+
+// Definitions returns a definition for each of the given types, and for every
+// named struct type they refer to. Definitions are named after their types and
+// refer to each other with references, rather than being inlined.
+func Definitions(types ...reflect.Type) spec.Definitions {
+	r := &reflector{
+		inProgress: make(map[reflect.Type]bool),
+		defs:       make(spec.Definitions),
+	}
+	for _, t := range types {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if name := definitionName(t); name != "" {
+			r.define(name, t)
+		}
+	}
+	return r.defs
+}
+
+type reflector struct {
+	// inProgress holds the struct types being inlined, so recursion can be
+	// detected.
+	inProgress map[reflect.Type]bool
+	// defs collects definitions. If nil, struct types are inlined.
+	defs spec.Definitions
+}
+
+// definitionName returns the name of the definition for t, or "" if t isn't
+// a named struct.
+func definitionName(t reflect.Type) string {
+	if t.Kind() != reflect.Struct || t.Name() == "" || t == timeType {
+		return ""
+	}
+	return t.Name()
+}
+
+func ref(name string) spec.Schema {
+	return spec.Schema{Ref: "#/definitions/" + jsonpointer.Escape(name)}
+}
+
+func (r *reflector) define(name string, t reflect.Type) {
+	if _, ok := r.defs[name]; ok {
+		return
+	}
+	// Reserve the name before reflecting the fields, so recursive types
+	// refer to the definition instead of defining it again.
+	r.defs[name] = spec.Schema{}
+	r.defs[name] = r.object(t)
+}
+
+func (r *reflector) schema(t reflect.Type) spec.Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return spec.Schema{Type: "string", Format: "date-time"}
+	case t == rawMessageType:
+		return spec.Schema{}
+	case t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType):
+		// Nothing is known about custom encodings.
+		return spec.Schema{}
+	case t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType):
+		return spec.Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return spec.Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return spec.Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64:
+		return spec.Schema{Type: "integer", Format: "int64"}
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		min := 0.0
+		return spec.Schema{Type: "integer", Format: "int32", Minimum: &min}
+	case reflect.Uint, reflect.Uint64, reflect.Uintptr:
+		min := 0.0
+		return spec.Schema{Type: "integer", Format: "int64", Minimum: &min}
+	case reflect.Float32:
+		return spec.Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return spec.Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return spec.Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			// encoding/json encodes []byte as base64.
+			return spec.Schema{Type: "string", Format: "byte"}
+		}
+		items := r.schema(t.Elem())
+		s := spec.Schema{Type: "array", Items: &items}
+		if t.Kind() == reflect.Array {
+			n := t.Len()
+			s.MinItems, s.MaxItems = n, &n
+		}
+		return s
+	case reflect.Map:
+		values := r.schema(t.Elem())
+		return spec.Schema{
+			Type:                 "object",
+			AdditionalProperties: &spec.AdditionalProperties{Allowed: true, Schema: &values},
+		}
+	case reflect.Struct:
+		name := definitionName(t)
+		if r.defs != nil && name != "" {
+			r.define(name, t)
+			return ref(name)
+		}
+		if r.inProgress[t] {
+			return ref(name)
+		}
+		r.inProgress[t] = true
+		defer delete(r.inProgress, t)
+		return r.object(t)
+	}
+	// Interfaces can hold any value. Channels, functions and complex numbers
+	// can't be encoded, so they're treated the same way.
+	return spec.Schema{}
+}
+
+// field is a struct field as encoding/json sees it.
+type field struct {
+	name        string
+	typ         reflect.Type
+	description string
+	required    bool
+	tagged      bool
+	depth       int
+}
+
+func (r *reflector) object(t reflect.Type) spec.Schema {
+	s := spec.Schema{Type: "object"}
+	fields := fields(t)
+	if len(fields) == 0 {
+		return s
+	}
+	s.Properties = make(map[string]spec.Schema, len(fields))
+	for _, f := range fields {
+		prop := r.schema(f.typ)
+		if f.description != "" {
+			if prop.Ref != "" {
+				// Siblings of a reference are ignored, so wrap it.
+				prop = spec.Schema{AllOf: []spec.Schema{prop}}
+			}
+			prop.Description = f.description
+		}
+		s.Properties[f.name] = prop
+		if f.required {
+			s.Required = append(s.Required, f.name)
+		}
+	}
+	sort.Strings(s.Required)
+	return s
+}
+
+// fields returns the fields encoding/json would encode for t, including those
+// promoted from embedded structs.
+func fields(t reflect.Type) []field {
+	var all []field
+	collect(t, 0, true, make(map[reflect.Type]bool), &all)
+
+	// Resolve name conflicts the way encoding/json does: the shallowest field
+	// wins, then a tagged field, and otherwise none of them are encoded.
+	byName := make(map[string][]field)
+	var names []string
+	for _, f := range all {
+		if _, ok := byName[f.name]; !ok {
+			names = append(names, f.name)
+		}
+		byName[f.name] = append(byName[f.name], f)
+	}
+	var fields []field
+	for _, name := range names {
+		if f, ok := dominant(byName[name]); ok {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+func dominant(fields []field) (field, bool) {
+	depth := fields[0].depth
+	for _, f := range fields {
+		if f.depth < depth {
+			depth = f.depth
+		}
+	}
+	var candidates, tagged []field
+	for _, f := range fields {
+		if f.depth != depth {
+			continue
+		}
+		candidates = append(candidates, f)
+		if f.tagged {
+			tagged = append(tagged, f)
+		}
+	}
+	if len(candidates) == 1 {
+		return candidates[0], true
+	}
+	if len(tagged) == 1 {
+		return tagged[0], true
+	}
+	return field{}, false
+}
+
+// collect appends the fields of t to all. required is false if t is reached
+// through an embedded pointer, which may be nil.
+func collect(t reflect.Type, depth int, required bool, visited map[reflect.Type]bool, all *[]field) {
+	if visited[t] {
+		return
+	}
+	visited[t] = true
+	defer delete(visited, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts := tag, ""
+		if j := strings.Index(tag, ","); j >= 0 {
+			name, opts = tag[:j], tag[j+1:]
+		}
+
+		ft := sf.Type
+		if sf.Anonymous {
+			et := ft
+			if et.Kind() == reflect.Ptr {
+				et = et.Elem()
+			}
+			if name == "" && et.Kind() == reflect.Struct {
+				collect(et, depth+1, required && ft.Kind() != reflect.Ptr, visited, all)
+				continue
+			}
+			if sf.PkgPath != "" && et.Kind() != reflect.Struct {
+				continue
+			}
+		} else if sf.PkgPath != "" {
+			continue
+		}
+
+		f := field{
+			name:        name,
+			typ:         ft,
+			description: sf.Tag.Get("description"),
+			required:    required && ft.Kind() != reflect.Ptr && !hasOption(opts, "omitempty"),
+			tagged:      name != "",
+			depth:       depth,
+		}
+		if f.name == "" {
+			f.name = sf.Name
+		}
+		if hasOption(opts, "string") {
+			switch k := indirect(ft).Kind(); {
+			case k == reflect.Bool, k >= reflect.Int && k <= reflect.Float64, k == reflect.String:
+				f.typ = reflect.TypeOf("")
+			}
+		}
+		*all = append(*all, f)
+	}
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+func hasOption(opts, opt string) bool {
+	for _, o := range strings.Split(opts, ",") {
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,344 @@
+package genspec
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+type Base struct {
+	ID      int64     `json:"id" description:"Unique identifier."`
+	Created time.Time `json:"created"`
+}
+
+type Audit struct {
+	Editor string `json:"editor"`
+}
+
+type Owner struct {
+	Name string `json:"name"`
+	Pets []Pet  `json:"pets,omitempty"`
+}
+
+type Pet struct {
+	Base
+	*Audit
+	Name    string            `json:"name"`
+	Tag     *string           `json:"tag"`
+	Age     uint8             `json:"age,omitempty"`
+	Weight  float64           `json:",string"`
+	Photo   []byte            `json:"photo,omitempty"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Owner   *Owner            `json:"owner" description:"Who the pet belongs to."`
+	Extra   json.RawMessage   `json:"extra,omitempty"`
+	Ignored string            `json:"-"`
+	private string
+}
+
+func TestSchemaFromType(t *testing.T) {
+	zero := 0.0
+	owner := spec.Schema{
+		Type:     "object",
+		Required: []string{"name"},
+		Properties: map[string]spec.Schema{
+			"name": {Type: "string"},
+			"pets": {Type: "array", Items: &spec.Schema{Ref: "#/definitions/Pet"}},
+		},
+	}
+	owner.Description = "Who the pet belongs to."
+	want := &spec.Schema{
+		Type:     "object",
+		Required: []string{"Weight", "created", "id", "name"},
+		Properties: map[string]spec.Schema{
+			"id":      {Type: "integer", Format: "int64", Description: "Unique identifier."},
+			"created": {Type: "string", Format: "date-time"},
+			"editor":  {Type: "string"},
+			"name":    {Type: "string"},
+			"tag":     {Type: "string"},
+			"age":     {Type: "integer", Format: "int32", Minimum: &zero},
+			"Weight":  {Type: "string"},
+			"photo":   {Type: "string", Format: "byte"},
+			"labels": {
+				Type:                 "object",
+				AdditionalProperties: &spec.AdditionalProperties{Allowed: true, Schema: &spec.Schema{Type: "string"}},
+			},
+			"owner": owner,
+			"extra": {},
+		},
+	}
+	got := SchemaFromType(reflect.TypeOf(Pet{}))
+	if diff := pretty.Compare(want, got); diff != "" {
+		t.Errorf("schema of Pet: %s", diff)
+	}
+
+	two := 2
+	for _, test := range []struct {
+		v    interface{}
+		want *spec.Schema
+	}{
+		{v: true, want: &spec.Schema{Type: "boolean"}},
+		{v: int32(0), want: &spec.Schema{Type: "integer", Format: "int32"}},
+		{v: new(string), want: &spec.Schema{Type: "string"}},
+		{v: [2]float32{}, want: &spec.Schema{Type: "array", Items: &spec.Schema{Type: "number", Format: "float"}, MinItems: 2, MaxItems: &two}},
+		{v: []interface{}{}, want: &spec.Schema{Type: "array", Items: &spec.Schema{}}},
+		{v: struct{}{}, want: &spec.Schema{Type: "object"}},
+	} {
+		got := SchemaFromType(reflect.TypeOf(test.v))
+		if diff := pretty.Compare(test.want, got); diff != "" {
+			t.Errorf("schema of %T: %s", test.v, diff)
+		}
+	}
+}
+
+func TestSchemaFromValue(t *testing.T) {
+	nullable := map[string]interface{}{"x-nullable": true}
+	for _, test := range []struct {
+		value string
+		want  *spec.Schema
+	}{
+		{value: `true`, want: &spec.Schema{Type: "boolean"}},
+		{value: `3`, want: &spec.Schema{Type: "integer"}},
+		{value: `3.5`, want: &spec.Schema{Type: "number"}},
+		{value: `"2020-01-02T03:04:05Z"`, want: &spec.Schema{Type: "string", Format: "date-time"}},
+		{value: `null`, want: &spec.Schema{Extensions: nullable}},
+		{value: `[]`, want: &spec.Schema{Type: "array", Items: &spec.Schema{}}},
+		{value: `[1, 2.5, null]`, want: &spec.Schema{Type: "array", Items: &spec.Schema{Type: "number", Extensions: nullable}}},
+		{value: `[1, "a"]`, want: &spec.Schema{Type: "array", Items: &spec.Schema{}}},
+		{value: `["2020-01-02", "a"]`, want: &spec.Schema{Type: "array", Items: &spec.Schema{Type: "string"}}},
+		{
+			value: `[{"name": "Rex", "age": 3}, {"name": "Tom", "tag": null}]`,
+			want: &spec.Schema{Type: "array", Items: &spec.Schema{
+				Type:     "object",
+				Required: []string{"name"},
+				Properties: map[string]spec.Schema{
+					"name": {Type: "string"},
+					"age":  {Type: "integer"},
+					"tag":  {Extensions: nullable},
+				},
+			}},
+		},
+	} {
+		var v interface{}
+		if err := json.Unmarshal([]byte(test.value), &v); err != nil {
+			t.Fatal(err)
+		}
+		got := SchemaFromValue(v)
+		if diff := pretty.Compare(test.want, got); diff != "" {
+			t.Errorf("schema of %s: %s", test.value, diff)
+		}
+	}
+}
+
+type left struct {
+	Name string
+}
+
+type right struct {
+	Name string
+}
+
+type tagged struct {
+	Name string `json:"Name"`
+}
+
+func TestEmbeddedConflicts(t *testing.T) {
+	tests := []struct {
+		v    interface{}
+		want []string
+	}{
+		{
+			// Fields at the same depth cancel out.
+			v: struct {
+				left
+				right
+			}{},
+			want: nil,
+		},
+		{
+			// Tagged fields win over untagged fields at the same depth.
+			v: struct {
+				left
+				tagged
+			}{},
+			want: []string{"Name"},
+		},
+		{
+			// Shallower fields win.
+			v: struct {
+				left
+				Name int
+			}{},
+			want: []string{"Name"},
+		},
+	}
+	for i, test := range tests {
+		s := SchemaFromType(reflect.TypeOf(test.v))
+		var got []string
+		for name := range s.Properties {
+			got = append(got, name)
+		}
+		if diff := pretty.Compare(test.want, got); diff != "" {
+			t.Errorf("case %d: %s", i, diff)
+		}
+	}
+}
+
+func TestDefinitions(t *testing.T) {
+	defs := Definitions(reflect.TypeOf(&Owner{}))
+	var names []string
+	for name := range defs {
+		names = append(names, name)
+	}
+	if len(defs) != 2 {
+		t.Fatalf("expected definitions of Owner and Pet, got %v", names)
+	}
+	if got := defs["Owner"].Properties["pets"].Items.Ref; got != "#/definitions/Pet" {
+		t.Errorf("expected Owner.pets to refer to Pet, got %q", got)
+	}
+	owner := defs["Pet"].Properties["owner"]
+	if len(owner.AllOf) != 1 || owner.AllOf[0].Ref != "#/definitions/Owner" || owner.Description == "" {
+		t.Errorf("expected a described reference to Owner, got %+v", owner)
+	}
+}
+
+func TestFromSource(t *testing.T) {
+	got, err := FromSource("testdata/petstore", "testdata/petstore/model")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want spec.Swagger
+	if err := yaml.Unmarshal([]byte(`
+swagger: "2.0"
+info: {title: Petstore, version: "1.0", description: A sample API.}
+basePath: /v1
+produces: [application/json]
+securityDefinitions:
+  ApiKey: {type: apiKey, in: header, name: X-API-Key}
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      summary: List pets
+      tags: [pets]
+      parameters:
+      - {name: limit, in: query, type: integer, format: int64, description: How many to return}
+      - {name: tags, in: query, type: array, items: {type: string}, description: Tags to filter by}
+      responses:
+        200:
+          description: The pets
+          schema: {type: array, items: {$ref: '#/definitions/model.Pet'}}
+    post:
+      operationId: createPet
+      summary: Add a pet
+      tags: [pets]
+      consumes: [application/json]
+      parameters:
+      - {name: pet, in: body, required: true, description: The pet to add, schema: {$ref: '#/definitions/model.Pet'}}
+      responses:
+        201: {description: Success, schema: {$ref: '#/definitions/model.Pet'}}
+        400: {description: Invalid pet, schema: {$ref: '#/definitions/main.Error'}}
+      security:
+      - ApiKey: []
+definitions:
+  main.Error:
+    description: Error describes a failed request.
+    type: object
+    required: [message]
+    properties:
+      message: {type: string}
+  model.Owner:
+    type: object
+    required: [name]
+    properties:
+      name: {type: string}
+  model.Pet:
+    description: Pet is a pet in the store.
+    type: object
+    required: [created, id, name]
+    properties:
+      id: {type: integer, format: int64}
+      created: {type: string, format: date-time}
+      name: {type: string, description: The name given to the pet.}
+      tag: {type: string}
+      owner:
+        description: Who the pet belongs to.
+        allOf: [{$ref: '#/definitions/model.Owner'}]
+      labels: {type: array, items: {type: string}}
+`), &want); err != nil {
+		t.Fatal(err)
+	}
+	if diff := pretty.Compare(&want, got); diff != "" {
+		t.Errorf("document: %s", diff)
+	}
+}
+
+func TestFromSourceErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{
+			src: `package main
+
+// @Param id path int maybe
+// @Router /pets/{id} [get]
+func getPet() {}
+`,
+			want: `genspec: main.go:3: @Param id: invalid required value "maybe"`,
+		},
+		{
+			src: `package main
+
+// @Success 200 {object} Pet
+// @Router /pets [get]
+func listPets() {}
+`,
+			want: "genspec: main.go:3: @Success 200: unknown type Pet",
+		},
+		{
+			src: `package main
+
+// @Router /pets
+func listPets() {}
+`,
+			want: `genspec: main.go:3: @Router: expected a path and [method], got "/pets"`,
+		},
+		{
+			src: `package main
+
+// @Router /pets [get]
+func listPets() {}
+
+// @Router /pets [get]
+func allPets() {}
+`,
+			want: "genspec: main.go:6: @Router: GET /pets is already documented by listPets",
+		},
+	}
+	for i, test := range tests {
+		dir, err := ioutil.TempDir("", "genspec")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		if err := ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte(test.src), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err = FromSource(dir)
+		if err == nil {
+			t.Errorf("case %d: expected error %q", i, test.want)
+		} else if err.Error() != test.want {
+			t.Errorf("case %d: want error %q, got %q", i, test.want, err)
+		}
+	}
+}
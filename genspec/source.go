@@ -0,0 +1,681 @@
+package genspec
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// FromSource builds a document from the annotations in the comments of the Go
+// packages in dirs, in the style of swaggo. General information is read from
+// any comment which has a @title annotation, usually the package's or main's:
+//
+//	// @title Petstore
+//	// @version 1.0
+//	// @BasePath /v1
+//	// @securityDefinitions.apikey ApiKey
+//	// @in header
+//	// @name X-API-Key
+//
+// Each function with a @Router annotation documents an operation:
+//
+//	// @Summary Find a pet by ID
+//	// @Tags pets
+//	// @Produce json
+//	// @Param petId path int true "ID of the pet"
+//	// @Success 200 {object} model.Pet "The pet"
+//	// @Failure 404 {object} model.Error
+//	// @Security ApiKey
+//	// @Router /pets/{petId} [get]
+//
+// Types named by annotations must be declared in one of the packages, and
+// become definitions named after the package and type, such as "model.Pet",
+// built following the rules of SchemaFromType. Field comments are used as
+// descriptions when fields have no "description" tag.
+func FromSource(dirs ...string) (*spec.Swagger, error) {
+	p := &sourceParser{
+		fset:  token.NewFileSet(),
+		types: make(map[string]*ast.TypeSpec),
+		pkgOf: make(map[*ast.TypeSpec]string),
+		doc: &spec.Swagger{
+			Swagger: "2.0",
+			Info:    &spec.Info{},
+			Paths:   make(spec.Paths),
+		},
+	}
+	var files []*ast.File
+	for _, dir := range dirs {
+		pkgs, err := parser.ParseDir(p.fset, dir, func(fi os.FileInfo) bool {
+			return !strings.HasSuffix(fi.Name(), "_test.go")
+		}, parser.ParseComments)
+		if err != nil {
+			return nil, fmt.Errorf("genspec: %v", err)
+		}
+		var names []string
+		for name := range pkgs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			var filenames []string
+			for filename := range pkgs[name].Files {
+				filenames = append(filenames, filename)
+			}
+			sort.Strings(filenames)
+			for _, filename := range filenames {
+				f := pkgs[name].Files[filename]
+				p.addTypes(name, f)
+				files = append(files, f)
+			}
+		}
+	}
+
+	for _, f := range files {
+		groups := append([]*ast.CommentGroup{f.Doc}, f.Comments...)
+		for _, g := range groups {
+			if g != nil && hasAnnotation(g, "@title") {
+				if err := p.general(g); err != nil {
+					return nil, err
+				}
+				break
+			}
+		}
+	}
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Doc == nil || !hasAnnotation(fn.Doc, "@Router") {
+				continue
+			}
+			if err := p.operation(f.Name.Name, fn); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(p.doc.Definitions) == 0 {
+		p.doc.Definitions = nil
+	}
+	return p.doc, nil
+}
+
+type sourceParser struct {
+	fset *token.FileSet
+	doc  *spec.Swagger
+	// types holds the declared types, keyed by package and name such as
+	// "model.Pet".
+	types map[string]*ast.TypeSpec
+	pkgOf map[*ast.TypeSpec]string
+}
+
+func (p *sourceParser) addTypes(pkg string, f *ast.File) {
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, s := range gen.Specs {
+			ts := s.(*ast.TypeSpec)
+			if ts.Doc == nil && len(gen.Specs) == 1 {
+				ts.Doc = gen.Doc
+			}
+			p.types[pkg+"."+ts.Name.Name] = ts
+			p.pkgOf[ts] = pkg
+		}
+	}
+}
+
+// annotation is a line of a comment beginning with "@".
+type annotation struct {
+	pos  token.Pos
+	name string
+	args string
+}
+
+func annotations(g *ast.CommentGroup) []annotation {
+	var list []annotation
+	for _, c := range g.List {
+		text := strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(c.Text, "//"), "/*"))
+		for _, line := range strings.Split(text, "\n") {
+			line = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(line), "*/"))
+			if !strings.HasPrefix(line, "@") {
+				continue
+			}
+			name, args := line, ""
+			if i := strings.IndexFunc(line, unicode.IsSpace); i >= 0 {
+				name, args = line[:i], strings.TrimSpace(line[i:])
+			}
+			list = append(list, annotation{pos: c.Pos(), name: name, args: args})
+		}
+	}
+	return list
+}
+
+func hasAnnotation(g *ast.CommentGroup, name string) bool {
+	for _, a := range annotations(g) {
+		if strings.EqualFold(a.name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *sourceParser) errorf(pos token.Pos, format string, v ...interface{}) error {
+	position := p.fset.Position(pos)
+	return fmt.Errorf("genspec: %s:%d: %s", filepath.Base(position.Filename), position.Line, fmt.Sprintf(format, v...))
+}
+
+// general reads the annotations describing the document as a whole.
+func (p *sourceParser) general(g *ast.CommentGroup) error {
+	doc := p.doc
+	// scheme names the security definition @in and @name apply to.
+	var scheme string
+	for _, a := range annotations(g) {
+		switch strings.ToLower(a.name) {
+		case "@title":
+			doc.Info.Title = a.args
+		case "@version":
+			doc.Info.Version = a.args
+		case "@description":
+			doc.Info.Description = joinLines(doc.Info.Description, a.args)
+		case "@termsofservice":
+			doc.Info.TermsOfService = a.args
+		case "@contact.name", "@contact.url", "@contact.email":
+			if doc.Info.Contact == nil {
+				doc.Info.Contact = &spec.Contact{}
+			}
+			switch strings.ToLower(a.name) {
+			case "@contact.name":
+				doc.Info.Contact.Name = a.args
+			case "@contact.url":
+				doc.Info.Contact.Url = a.args
+			default:
+				doc.Info.Contact.Email = a.args
+			}
+		case "@license.name", "@license.url":
+			if doc.Info.License == nil {
+				doc.Info.License = &spec.License{}
+			}
+			if strings.ToLower(a.name) == "@license.name" {
+				doc.Info.License.Name = a.args
+			} else {
+				doc.Info.License.Url = a.args
+			}
+		case "@host":
+			doc.Host = a.args
+		case "@basepath":
+			doc.BasePath = a.args
+		case "@schemes":
+			doc.Schemes = strings.Fields(a.args)
+		case "@accept":
+			doc.Consumes = mimeTypes(a.args)
+		case "@produce":
+			doc.Produces = mimeTypes(a.args)
+		case "@securitydefinitions.apikey", "@securitydefinitions.basic":
+			if a.args == "" {
+				return p.errorf(a.pos, "%s: expected a name", a.name)
+			}
+			if doc.SecurityDefinitions == nil {
+				doc.SecurityDefinitions = make(spec.SecurityDefinitions)
+			}
+			typ := "basic"
+			if strings.HasSuffix(strings.ToLower(a.name), "apikey") {
+				typ = "apiKey"
+			}
+			doc.SecurityDefinitions[a.args] = spec.SecurityScheme{Type: typ}
+			scheme = a.args
+		case "@in", "@name":
+			s, ok := doc.SecurityDefinitions[scheme]
+			if !ok || s.Type != "apiKey" {
+				return p.errorf(a.pos, "%s must follow @securityDefinitions.apikey", a.name)
+			}
+			if strings.ToLower(a.name) == "@in" {
+				s.In = a.args
+			} else {
+				s.Name = a.args
+			}
+			doc.SecurityDefinitions[scheme] = s
+		}
+	}
+	return nil
+}
+
+// operation adds the operation a function's annotations document.
+func (p *sourceParser) operation(pkg string, fn *ast.FuncDecl) error {
+	op := &spec.Operation{Responses: make(spec.Responses)}
+	var path, method string
+	for _, a := range annotations(fn.Doc) {
+		var err error
+		switch strings.ToLower(a.name) {
+		case "@summary":
+			op.Summary = a.args
+		case "@description":
+			op.Description = joinLines(op.Description, a.args)
+		case "@id":
+			op.OperationId = a.args
+		case "@tags":
+			for _, tag := range strings.Split(a.args, ",") {
+				op.Tags = append(op.Tags, strings.TrimSpace(tag))
+			}
+		case "@accept":
+			op.Consumes = mimeTypes(a.args)
+		case "@produce":
+			op.Produces = mimeTypes(a.args)
+		case "@deprecated":
+			op.Deprecated = true
+		case "@security":
+			req := make(spec.SecurityRequirement)
+			for _, name := range strings.Split(a.args, "||") {
+				req[strings.TrimSpace(name)] = []string{}
+			}
+			op.Security = append(op.Security, req)
+		case "@param":
+			err = p.param(pkg, op, a)
+		case "@success", "@failure", "@response":
+			err = p.response(pkg, op, a)
+		case "@router":
+			fields := strings.Fields(a.args)
+			if len(fields) != 2 || !strings.HasPrefix(fields[1], "[") || !strings.HasSuffix(fields[1], "]") {
+				return p.errorf(a.pos, "@Router: expected a path and [method], got %q", a.args)
+			}
+			path, method = fields[0], strings.ToLower(strings.Trim(fields[1], "[]"))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if op.OperationId == "" {
+		op.OperationId = fn.Name.Name
+	}
+	if len(op.Responses) == 0 {
+		op.Responses["default"] = spec.Response{Description: "Unexpected response."}
+	}
+
+	item := p.doc.Paths[path]
+	if prev := item.Operation(method); prev != nil {
+		return p.errorf(fn.Doc.Pos(), "@Router: %s %s is already documented by %s", strings.ToUpper(method), path, prev.OperationId)
+	}
+	if !item.SetOperation(method, op) {
+		return p.errorf(fn.Doc.Pos(), "@Router: unknown method %q", method)
+	}
+	p.doc.Paths[path] = item
+	return nil
+}
+
+// param reads "@Param name in type required comment".
+func (p *sourceParser) param(pkg string, op *spec.Operation, a annotation) error {
+	fields := splitQuoted(a.args)
+	if len(fields) < 4 {
+		return p.errorf(a.pos, "@Param: expected a name, location, type and whether it's required, got %q", a.args)
+	}
+	required, err := strconv.ParseBool(fields[3])
+	if err != nil {
+		return p.errorf(a.pos, "@Param %s: invalid required value %q", fields[0], fields[3])
+	}
+	param := spec.Parameter{Name: fields[0], In: fields[1], Required: required}
+	if len(fields) > 4 {
+		param.Description = fields[4]
+	}
+	switch param.In {
+	case "body":
+		if param.Schema, err = p.schemaRef(pkg, "", fields[2]); err != nil {
+			return p.errorf(a.pos, "@Param %s: %v", param.Name, err)
+		}
+	case "path", "query", "header", "formData":
+		typ := fields[2]
+		if strings.HasPrefix(typ, "[]") {
+			param.Type = "array"
+			param.Items = &spec.Items{}
+			param.Items.Type, param.Items.Format = simpleType(strings.TrimPrefix(typ, "[]"))
+			if param.Items.Type == "" {
+				return p.errorf(a.pos, "@Param %s: unsupported type %q", param.Name, typ)
+			}
+		} else if param.Type, param.Format = simpleType(typ); param.Type == "" {
+			return p.errorf(a.pos, "@Param %s: unsupported type %q", param.Name, typ)
+		}
+	default:
+		return p.errorf(a.pos, "@Param %s: unknown location %q", param.Name, param.In)
+	}
+	op.Parameters = append(op.Parameters, param)
+	return nil
+}
+
+// response reads "@Success code {kind} type comment", where the kind, type and
+// comment are optional.
+func (p *sourceParser) response(pkg string, op *spec.Operation, a annotation) error {
+	fields := splitQuoted(a.args)
+	if len(fields) == 0 {
+		return p.errorf(a.pos, "%s: expected a status code", a.name)
+	}
+	code := fields[0]
+	if _, err := strconv.Atoi(code); err != nil && code != "default" {
+		return p.errorf(a.pos, "%s: invalid status code %q", a.name, code)
+	}
+	fields = fields[1:]
+	resp := spec.Response{}
+	if len(fields) > 0 && strings.HasPrefix(fields[0], "{") {
+		kind := strings.Trim(fields[0], "{}")
+		typ := kind
+		if len(fields) > 1 {
+			typ = fields[1]
+			fields = fields[1:]
+		}
+		fields = fields[1:]
+		s, err := p.schemaRef(pkg, kind, typ)
+		if err != nil {
+			return p.errorf(a.pos, "%s %s: %v", a.name, code, err)
+		}
+		resp.Schema = s
+	}
+	if len(fields) > 0 {
+		resp.Description = fields[0]
+	} else {
+		resp.Description = strings.TrimPrefix(a.name, "@")
+	}
+	op.Responses[code] = resp
+	return nil
+}
+
+// schemaRef returns the schema of a type named by an annotation. kind is the
+// swaggo kind, such as "object" or "array", if one was given.
+func (p *sourceParser) schemaRef(pkg, kind, typ string) (*spec.Schema, error) {
+	if kind == "array" || strings.HasPrefix(typ, "[]") {
+		items, err := p.schemaRef(pkg, "", strings.TrimPrefix(typ, "[]"))
+		if err != nil {
+			return nil, err
+		}
+		return &spec.Schema{Type: "array", Items: items}, nil
+	}
+	if t, format := simpleType(typ); t != "" {
+		return &spec.Schema{Type: t, Format: format}, nil
+	}
+	if typ == "object" {
+		return &spec.Schema{Type: "object"}, nil
+	}
+	name := typ
+	if !strings.Contains(name, ".") {
+		name = pkg + "." + name
+	}
+	if _, ok := p.types[name]; !ok {
+		return nil, fmt.Errorf("unknown type %s", typ)
+	}
+	s := p.define(name)
+	return &s, nil
+}
+
+// simpleType returns the type and format of a Go or swaggo primitive type, or
+// "" if name isn't one.
+func simpleType(name string) (string, string) {
+	switch name {
+	case "string":
+		return "string", ""
+	case "int", "int64", "uint", "uint64":
+		return "integer", "int64"
+	case "integer", "int8", "int16", "int32", "uint8", "uint16", "uint32":
+		if name == "integer" {
+			return "integer", ""
+		}
+		return "integer", "int32"
+	case "number":
+		return "number", ""
+	case "float32":
+		return "number", "float"
+	case "float64":
+		return "number", "double"
+	case "bool", "boolean":
+		return "boolean", ""
+	case "file":
+		return "file", ""
+	case "time.Time":
+		return "string", "date-time"
+	}
+	return "", ""
+}
+
+// define adds the definition of a declared type, if it hasn't been already,
+// and returns a reference to it.
+func (p *sourceParser) define(name string) spec.Schema {
+	if p.doc.Definitions == nil {
+		p.doc.Definitions = make(spec.Definitions)
+	}
+	if _, ok := p.doc.Definitions[name]; !ok {
+		ts := p.types[name]
+		p.doc.Definitions[name] = spec.Schema{}
+		s := p.exprSchema(p.pkgOf[ts], ts.Type)
+		if ts.Doc != nil && s.Description == "" {
+			s.Description = strings.TrimSpace(ts.Doc.Text())
+		}
+		p.doc.Definitions[name] = s
+	}
+	return ref(name)
+}
+
+// exprSchema returns the schema of a type expression in a package.
+func (p *sourceParser) exprSchema(pkg string, expr ast.Expr) spec.Schema {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		if t, format := simpleType(e.Name); t != "" {
+			return spec.Schema{Type: t, Format: format}
+		}
+		if e.Name == "byte" || e.Name == "rune" {
+			return spec.Schema{Type: "integer", Format: "int32"}
+		}
+		if _, ok := p.types[pkg+"."+e.Name]; ok {
+			return p.define(pkg + "." + e.Name)
+		}
+	case *ast.SelectorExpr:
+		if x, ok := e.X.(*ast.Ident); ok {
+			name := x.Name + "." + e.Sel.Name
+			if t, format := simpleType(name); t != "" {
+				return spec.Schema{Type: t, Format: format}
+			}
+			if name == "json.RawMessage" {
+				return spec.Schema{}
+			}
+			if _, ok := p.types[name]; ok {
+				return p.define(name)
+			}
+		}
+	case *ast.StarExpr:
+		return p.exprSchema(pkg, e.X)
+	case *ast.ArrayType:
+		if ident, ok := e.Elt.(*ast.Ident); ok && ident.Name == "byte" && e.Len == nil {
+			return spec.Schema{Type: "string", Format: "byte"}
+		}
+		items := p.exprSchema(pkg, e.Elt)
+		return spec.Schema{Type: "array", Items: &items}
+	case *ast.MapType:
+		values := p.exprSchema(pkg, e.Value)
+		return spec.Schema{
+			Type:                 "object",
+			AdditionalProperties: &spec.AdditionalProperties{Allowed: true, Schema: &values},
+		}
+	case *ast.StructType:
+		return p.structSchema(pkg, e)
+	}
+	// Interfaces, and types declared outside the parsed packages, may hold
+	// any value.
+	return spec.Schema{}
+}
+
+// structSchema returns the schema of a struct, following the same rules as
+// SchemaFromType. Embedded structs declared in the parsed packages have their
+// fields promoted.
+func (p *sourceParser) structSchema(pkg string, st *ast.StructType) spec.Schema {
+	s := spec.Schema{Type: "object"}
+	for _, f := range st.Fields.List {
+		var tag reflect.StructTag
+		if f.Tag != nil {
+			if unquoted, err := strconv.Unquote(f.Tag.Value); err == nil {
+				tag = reflect.StructTag(unquoted)
+			}
+		}
+		jsonTag := tag.Get("json")
+		if jsonTag == "-" {
+			continue
+		}
+		name, opts := jsonTag, ""
+		if i := strings.Index(jsonTag, ","); i >= 0 {
+			name, opts = jsonTag[:i], jsonTag[i+1:]
+		}
+		_, pointer := f.Type.(*ast.StarExpr)
+
+		if len(f.Names) == 0 && name == "" {
+			if embedded := p.embedded(pkg, f.Type); embedded != nil {
+				promoted := p.structSchema(p.pkgOf[embedded], embedded.Type.(*ast.StructType))
+				for prop, ps := range promoted.Properties {
+					if _, ok := s.Properties[prop]; ok {
+						continue
+					}
+					if s.Properties == nil {
+						s.Properties = make(map[string]spec.Schema)
+					}
+					s.Properties[prop] = ps
+				}
+				if !pointer {
+					s.Required = append(s.Required, promoted.Required...)
+				}
+				continue
+			}
+		}
+
+		names := f.Names
+		if len(names) == 0 {
+			names = []*ast.Ident{embeddedName(f.Type)}
+		}
+		for _, ident := range names {
+			if ident == nil || !ident.IsExported() {
+				continue
+			}
+			prop := p.exprSchema(pkg, f.Type)
+			if hasOption(opts, "string") {
+				prop = spec.Schema{Type: "string"}
+			}
+			desc := tag.Get("description")
+			if desc == "" && f.Doc != nil {
+				desc = strings.TrimSpace(f.Doc.Text())
+			}
+			if desc == "" && f.Comment != nil {
+				desc = strings.TrimSpace(f.Comment.Text())
+			}
+			if desc != "" {
+				if prop.Ref != "" {
+					prop = spec.Schema{AllOf: []spec.Schema{prop}}
+				}
+				prop.Description = desc
+			}
+			propName := name
+			if propName == "" {
+				propName = ident.Name
+			}
+			if s.Properties == nil {
+				s.Properties = make(map[string]spec.Schema)
+			}
+			s.Properties[propName] = prop
+			if !pointer && !hasOption(opts, "omitempty") {
+				s.Required = append(s.Required, propName)
+			}
+		}
+	}
+	sort.Strings(s.Required)
+	return s
+}
+
+// embedded returns the declaration of an embedded struct type, or nil if it
+// isn't a struct declared in the parsed packages.
+func (p *sourceParser) embedded(pkg string, expr ast.Expr) *ast.TypeSpec {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	var name string
+	switch e := expr.(type) {
+	case *ast.Ident:
+		name = pkg + "." + e.Name
+	case *ast.SelectorExpr:
+		if x, ok := e.X.(*ast.Ident); ok {
+			name = x.Name + "." + e.Sel.Name
+		}
+	}
+	ts, ok := p.types[name]
+	if !ok {
+		return nil
+	}
+	if _, ok := ts.Type.(*ast.StructType); !ok {
+		return nil
+	}
+	return ts
+}
+
+func embeddedName(expr ast.Expr) *ast.Ident {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return embeddedName(e.X)
+	case *ast.Ident:
+		return e
+	case *ast.SelectorExpr:
+		return e.Sel
+	}
+	return nil
+}
+
+// splitQuoted splits s on white space, keeping double quoted strings together
+// and unquoting them.
+func splitQuoted(s string) []string {
+	var fields []string
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		if s[0] == '"' {
+			if end := strings.Index(s[1:], `"`); end >= 0 {
+				fields = append(fields, s[1:end+1])
+				s = s[end+2:]
+				continue
+			}
+		}
+		i := strings.IndexFunc(s, unicode.IsSpace)
+		if i < 0 {
+			i = len(s)
+		}
+		fields = append(fields, s[:i])
+		s = s[i:]
+	}
+	return fields
+}
+
+// mimeTypes expands swaggo's short names, such as "json", to MIME types.
+func mimeTypes(s string) []string {
+	short := map[string]string{
+		"json":                  "application/json",
+		"xml":                   "application/xml",
+		"plain":                 "text/plain",
+		"html":                  "text/html",
+		"mpfd":                  "multipart/form-data",
+		"x-www-form-urlencoded": "application/x-www-form-urlencoded",
+		"octet-stream":          "application/octet-stream",
+	}
+	var types []string
+	for _, t := range strings.Split(s, ",") {
+		t = strings.TrimSpace(t)
+		if full, ok := short[t]; ok {
+			t = full
+		}
+		if t != "" {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+func joinLines(existing, line string) string {
+	if existing == "" {
+		return line
+	}
+	return existing + "\n" + line
+}
@@ -0,0 +1,43 @@
+// @title Petstore
+// @version 1.0
+// @description A sample API.
+// @BasePath /v1
+// @produce json
+// @securityDefinitions.apikey ApiKey
+// @in header
+// @name X-API-Key
+package main
+
+import (
+	"net/http"
+
+	"example.com/petstore/model"
+)
+
+var _ model.Pet
+
+// listPets lists the pets.
+//
+// @Summary List pets
+// @Tags pets
+// @Param limit query int false "How many to return"
+// @Param tags query []string false "Tags to filter by"
+// @Success 200 {array} model.Pet "The pets"
+// @Router /pets [get]
+func listPets(w http.ResponseWriter, r *http.Request) {}
+
+// @Summary Add a pet
+// @ID createPet
+// @Tags pets
+// @Accept json
+// @Param pet body model.Pet true "The pet to add"
+// @Success 201 {object} model.Pet
+// @Failure 400 {object} Error "Invalid pet"
+// @Security ApiKey
+// @Router /pets [post]
+func createPet(w http.ResponseWriter, r *http.Request) {}
+
+// Error describes a failed request.
+type Error struct {
+	Message string `json:"message"`
+}
@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// Base is embedded by every model.
+type Base struct {
+	ID      int64     `json:"id"`
+	Created time.Time `json:"created"`
+}
+
+// Pet is a pet in the store.
+type Pet struct {
+	Base
+	// The name given to the pet.
+	Name   string   `json:"name"`
+	Tag    *string  `json:"tag"`
+	Owner  *Owner   `json:"owner,omitempty" description:"Who the pet belongs to."`
+	Labels []string `json:"labels,omitempty"`
+	secret string
+}
+
+type Owner struct {
+	Name string `json:"name"`
+}
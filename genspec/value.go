@@ -0,0 +1,144 @@
+package genspec
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// SchemaFromValue infers a schema from a value decoded by encoding/json, such
+// as an example request or response body. Objects require the properties
+// they have, whole numbers are integers, and strings holding RFC 3339 times
+// or dates have the date-time or date format. The elements of an array are
+// merged into the schema of its items, as by MergeSchemas. Null allows any
+// value, and is marked x-nullable.
+func SchemaFromValue(v interface{}) *spec.Schema {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		s := &spec.Schema{Type: "object", Properties: make(map[string]spec.Schema, len(v))}
+		for name, val := range v {
+			s.Properties[name] = *SchemaFromValue(val)
+			s.Required = append(s.Required, name)
+		}
+		sort.Strings(s.Required)
+		return s
+	case []interface{}:
+		s := &spec.Schema{Type: "array"}
+		for _, elem := range v {
+			s.Items = MergeSchemas(s.Items, SchemaFromValue(elem))
+		}
+		if s.Items == nil {
+			s.Items = &spec.Schema{}
+		}
+		return s
+	case string:
+		s := &spec.Schema{Type: "string"}
+		if _, err := time.Parse(time.RFC3339, v); err == nil {
+			s.Format = "date-time"
+		} else if _, err := time.Parse("2006-01-02", v); err == nil {
+			s.Format = "date"
+		}
+		return s
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return &spec.Schema{Type: "integer"}
+		}
+		return &spec.Schema{Type: "number"}
+	case bool:
+		return &spec.Schema{Type: "boolean"}
+	case nil:
+		return &spec.Schema{Extensions: map[string]interface{}{"x-nullable": true}}
+	}
+	return &spec.Schema{}
+}
+
+// MergeSchemas returns a schema allowing the values of either of two inferred
+// schemas, for building one schema from many samples. Either may be nil.
+//
+// Objects allow the properties of both, and only require those both require.
+// Integers and numbers merge into numbers. A null merges into the other schema,
+// marking it x-nullable. Schemas of other differing types merge into one which
+// allows any value.
+func MergeSchemas(a, b *spec.Schema) *spec.Schema {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	case isNull(a):
+		return nullable(b)
+	case isNull(b):
+		return nullable(a)
+	}
+	s := &spec.Schema{Type: a.Type}
+	if isNullable(a) || isNullable(b) {
+		s.Extensions = map[string]interface{}{"x-nullable": true}
+	}
+	switch {
+	case a.Type == b.Type:
+	case a.Type == "integer" && b.Type == "number", a.Type == "number" && b.Type == "integer":
+		s.Type = "number"
+		return s
+	default:
+		s.Type = ""
+		return s
+	}
+	switch s.Type {
+	case "object":
+		s.Properties = make(map[string]spec.Schema, len(a.Properties)+len(b.Properties))
+		for name, p := range a.Properties {
+			if q, ok := b.Properties[name]; ok {
+				s.Properties[name] = *MergeSchemas(&p, &q)
+			} else {
+				s.Properties[name] = p
+			}
+		}
+		for name, q := range b.Properties {
+			if _, ok := a.Properties[name]; !ok {
+				s.Properties[name] = q
+			}
+		}
+		for _, name := range a.Required {
+			if contains(b.Required, name) {
+				s.Required = append(s.Required, name)
+			}
+		}
+	case "array":
+		s.Items = MergeSchemas(a.Items, b.Items)
+	case "string":
+		if a.Format == b.Format {
+			s.Format = a.Format
+		}
+	}
+	return s
+}
+
+func isNull(s *spec.Schema) bool {
+	return s.Type == "" && isNullable(s) && len(s.Extensions) == 1
+}
+
+func isNullable(s *spec.Schema) bool {
+	nullable, _ := s.Extensions["x-nullable"].(bool)
+	return nullable
+}
+
+// nullable returns a copy of s marked x-nullable.
+func nullable(s *spec.Schema) *spec.Schema {
+	c := *s
+	c.Extensions = map[string]interface{}{"x-nullable": true}
+	for k, v := range s.Extensions {
+		c.Extensions[k] = v
+	}
+	return &c
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+/*
+Package har infers documents from HTTP Archives (HAR files), such as those
+saved by a browser's developer tools or a recording proxy, to bootstrap the
+documentation of an API from its real traffic.
+
+Infer groups the archive's requests into operations by method and path, and
+describes what they were seen to send and receive:
+
+	var h har.HAR
+	if err := json.Unmarshal(data, &h); err != nil {
+		// Handle error.
+	}
+	doc, err := har.Infer(&h)
+
+Path segments which look like identifiers, such as numbers and UUIDs, become
+path parameters, so that "/pets/1" and "/pets/2" are the same operation.
+Options.Paths lists templates for paths the heuristic gets wrong. Query
+parameters and headers become parameters, required if every request sent
+them, and the schemas of JSON bodies are inferred from each sample and merged
+across them.
+*/
+package har
+
+// HAR is an HTTP Archive, as described by
+// http://www.softwareishard.com/blog/har-12-spec/. Only the fields inference
+// uses are decoded.
+type HAR struct {
+	Log Log `json:"log"`
+}
+
+// Log holds an archive's entries.
+type Log struct {
+	Version string  `json:"version"`
+	Creator Creator `json:"creator"`
+	Entries []Entry `json:"entries"`
+}
+
+// Creator is the application which recorded an archive.
+type Creator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// Entry is a request and the response it received.
+type Entry struct {
+	StartedDateTime string   `json:"startedDateTime"`
+	Request         Request  `json:"request"`
+	Response        Response `json:"response"`
+}
+
+// Request is a recorded request.
+type Request struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	HTTPVersion string      `json:"httpVersion"`
+	Headers     []NameValue `json:"headers"`
+	QueryString []NameValue `json:"queryString"`
+	PostData    *PostData   `json:"postData,omitempty"`
+}
+
+// Response is a recorded response.
+type Response struct {
+	Status      int         `json:"status"`
+	StatusText  string      `json:"statusText"`
+	HTTPVersion string      `json:"httpVersion"`
+	Headers     []NameValue `json:"headers"`
+	Content     Content     `json:"content"`
+}
+
+// NameValue is a header or query parameter.
+type NameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// PostData is the body of a request. Form bodies may be recorded as Params
+// rather than Text.
+type PostData struct {
+	MimeType string  `json:"mimeType"`
+	Text     string  `json:"text,omitempty"`
+	Params   []Param `json:"params,omitempty"`
+}
+
+// Param is a field of a form body.
+type Param struct {
+	Name        string `json:"name"`
+	Value       string `json:"value,omitempty"`
+	FileName    string `json:"fileName,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+}
+
+// Content is the body of a response. Text is base64 encoded if Encoding is
+// "base64".
+type Content struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
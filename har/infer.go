@@ -0,0 +1,539 @@
+package har
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/genspec"
+	"github.com/ericchiang/swaggopher/internal/mapkeys"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// Options configures inference.
+type Options struct {
+	// Host restricts inference to the requests sent to a host, such as
+	// "api.example.com". If empty, it's the host most requests were sent to,
+	// so that requests for a page's scripts and images are ignored.
+	Host string
+	// BasePath is the document's basePath. Only requests to paths under it
+	// are inferred from.
+	BasePath string
+	// Paths are path templates, such as "/pets/{name}", which the paths
+	// matching them are grouped under. Other paths have their parameters
+	// guessed.
+	Paths []string
+	// Examples sets the first body seen for each request and response as its
+	// example. Recorded traffic may hold personal data, so bodies are left
+	// out by default.
+	Examples bool
+}
+
+// Infer infers a document from an archive using the default options. See
+// Options.Infer.
+func Infer(h *HAR) (*spec.Swagger, error) {
+	return Options{}.Infer(h)
+}
+
+// Infer infers a document from the requests an archive recorded, and the
+// responses they received.
+//
+// Requests are grouped into operations by method and path template. Each
+// operation has the query, header and form parameters its requests sent,
+// which are only required if every request sent them, with types inferred
+// from their values. Headers which clients send to any API, such as
+// User-Agent, Cookie and Authorization, are ignored. The schemas of JSON
+// request and response bodies are inferred from each sample and merged, so a
+// property is only required if every sample had it. Requests which didn't
+// receive a response, such as those a browser blocked, are ignored.
+func (o Options) Infer(h *HAR) (*spec.Swagger, error) {
+	if len(h.Log.Entries) == 0 {
+		return nil, errors.New("har: archive has no entries")
+	}
+	templates := make([][]string, len(o.Paths))
+	for i, p := range o.Paths {
+		templates[i] = segments(p)
+	}
+
+	urls := make([]*url.URL, len(h.Log.Entries))
+	hosts := make(map[string]int)
+	for i, e := range h.Log.Entries {
+		u, err := url.Parse(e.Request.URL)
+		if err != nil {
+			return nil, fmt.Errorf("har: entry %d: %v", i, err)
+		}
+		urls[i] = u
+		hosts[u.Host]++
+	}
+	host := o.Host
+	if host == "" {
+		for h, n := range hosts {
+			if n > hosts[host] || (n == hosts[host] && h < host) {
+				host = h
+			}
+		}
+	}
+	basePath := strings.TrimSuffix(o.BasePath, "/")
+
+	in := &inferrer{examples: o.Examples, ops: make(map[opKey]*operation)}
+	schemes := make(map[string]bool)
+	for i, e := range h.Log.Entries {
+		u := urls[i]
+		if u.Host != host || e.Response.Status == 0 {
+			continue
+		}
+		path := u.EscapedPath()
+		if basePath != "" {
+			if path != basePath && !strings.HasPrefix(path, basePath+"/") {
+				continue
+			}
+			path = strings.TrimPrefix(path, basePath)
+		}
+		schemes[u.Scheme] = true
+		tmpl, params := template(segments(path), templates)
+		in.entry(strings.ToLower(e.Request.Method), tmpl, params, &e)
+	}
+	if len(in.ops) == 0 {
+		return nil, fmt.Errorf("har: archive has no responses from %s%s", host, basePath)
+	}
+
+	doc := &spec.Swagger{
+		Swagger:  "2.0",
+		Info:     &spec.Info{Title: host, Version: "1.0.0"},
+		Host:     host,
+		BasePath: o.BasePath,
+		Paths:    make(spec.Paths),
+	}
+	for scheme := range schemes {
+		if scheme == "http" || scheme == "https" {
+			doc.Schemes = append(doc.Schemes, scheme)
+		}
+	}
+	sort.Strings(doc.Schemes)
+	for key, op := range in.ops {
+		item := doc.Paths[key.path]
+		if item.SetOperation(key.method, op.build()) {
+			doc.Paths[key.path] = item
+		}
+	}
+	return doc, nil
+}
+
+type opKey struct {
+	method, path string
+}
+
+type inferrer struct {
+	examples bool
+	ops      map[opKey]*operation
+}
+
+// operation accumulates what the requests for an operation were seen to send
+// and receive.
+type operation struct {
+	examples bool
+	// samples counts the requests.
+	samples  int
+	params   map[paramKey]*param
+	body     *sample
+	consumes map[string]bool
+	produces map[string]bool
+	// responses holds the responses seen, keyed by status.
+	responses map[int]*sample
+}
+
+type paramKey struct {
+	in, name string
+}
+
+type param struct {
+	// order sorts path parameters by their position in the path.
+	order int
+	// count is the number of requests which sent the parameter.
+	count  int
+	schema *spec.Schema
+	// multi is set if a request sent the parameter more than once.
+	multi bool
+	file  bool
+}
+
+// sample accumulates the bodies of requests or responses.
+type sample struct {
+	count   int
+	schema  *spec.Schema
+	text    bool
+	example interface{}
+	// status is the first status text seen for a response.
+	status string
+}
+
+func (in *inferrer) entry(method, path string, pathParams []NameValue, e *Entry) {
+	key := opKey{method, path}
+	op, ok := in.ops[key]
+	if !ok {
+		op = &operation{
+			examples:  in.examples,
+			params:    make(map[paramKey]*param),
+			consumes:  make(map[string]bool),
+			produces:  make(map[string]bool),
+			responses: make(map[int]*sample),
+		}
+		in.ops[key] = op
+	}
+	op.samples++
+
+	for i, p := range pathParams {
+		op.param("path", []NameValue{p}).order = i
+	}
+	op.sent("query", e.Request.QueryString)
+	var headers []NameValue
+	for _, h := range e.Request.Headers {
+		if !ignoredHeader(h.Name) {
+			headers = append(headers, NameValue{Name: http.CanonicalHeaderKey(h.Name), Value: h.Value})
+		}
+	}
+	op.sent("header", headers)
+
+	if pd := e.Request.PostData; pd != nil && (pd.Text != "" || len(pd.Params) > 0) {
+		t := mediaType(pd.MimeType)
+		if t != "" {
+			op.consumes[t] = true
+		}
+		switch t {
+		case "application/x-www-form-urlencoded", "multipart/form-data":
+			fields := make([]NameValue, 0, len(pd.Params))
+			files := make(map[string]bool)
+			for _, p := range pd.Params {
+				fields = append(fields, NameValue{Name: p.Name, Value: p.Value})
+				if p.FileName != "" {
+					files[p.Name] = true
+				}
+			}
+			if len(pd.Params) == 0 {
+				values, _ := url.ParseQuery(pd.Text)
+				for _, name := range mapkeys.Sorted(values) {
+					for _, v := range values[name] {
+						fields = append(fields, NameValue{Name: name, Value: v})
+					}
+				}
+			}
+			op.sent("formData", fields)
+			for name := range files {
+				op.params[paramKey{"formData", name}].file = true
+			}
+		default:
+			if op.body == nil {
+				op.body = &sample{}
+			}
+			op.body.add(pd.Text, isJSON(t), op.examples)
+		}
+	}
+
+	resp := e.Response
+	r, ok := op.responses[resp.Status]
+	if !ok {
+		r = &sample{status: resp.StatusText}
+		op.responses[resp.Status] = r
+	}
+	text := resp.Content.Text
+	if resp.Content.Encoding == "base64" {
+		data, err := base64.StdEncoding.DecodeString(text)
+		if err != nil {
+			text = ""
+		} else {
+			text = string(data)
+		}
+	}
+	if text == "" {
+		return
+	}
+	t := mediaType(resp.Content.MimeType)
+	if t != "" {
+		op.produces[t] = true
+	}
+	r.add(text, isJSON(t), op.examples)
+}
+
+// params2 records the parameters a request sent in a location, noting those
+// it sent more than once.
+func (op *operation) sent(in string, values []NameValue) {
+	byName := make(map[string][]NameValue)
+	var names []string
+	for _, v := range values {
+		if _, ok := byName[v.Name]; !ok {
+			names = append(names, v.Name)
+		}
+		byName[v.Name] = append(byName[v.Name], v)
+	}
+	for _, name := range names {
+		p := op.param(in, byName[name])
+		if len(byName[name]) > 1 {
+			p.multi = true
+		}
+	}
+}
+
+// param records a parameter sent by a request, with one or more values.
+func (op *operation) param(in string, values []NameValue) *param {
+	key := paramKey{in, values[0].Name}
+	p, ok := op.params[key]
+	if !ok {
+		p = &param{}
+		op.params[key] = p
+	}
+	p.count++
+	for _, v := range values {
+		p.schema = genspec.MergeSchemas(p.schema, &spec.Schema{Type: valueType(v.Value)})
+	}
+	return p
+}
+
+func (s *sample) add(text string, asJSON bool, examples bool) {
+	s.count++
+	if !asJSON {
+		s.text = true
+		return
+	}
+	v, ok := decodeJSON(text)
+	if !ok {
+		s.text = true
+		return
+	}
+	s.schema = genspec.MergeSchemas(s.schema, genspec.SchemaFromValue(v))
+	if examples && s.example == nil {
+		s.example = v
+	}
+}
+
+// locations orders parameters by where they're sent.
+var locations = map[string]int{"path": 0, "query": 1, "header": 2, "formData": 3}
+
+func (op *operation) build() *spec.Operation {
+	out := &spec.Operation{
+		Consumes:  sortedSet(op.consumes),
+		Produces:  sortedSet(op.produces),
+		Responses: make(spec.Responses, len(op.responses)),
+	}
+
+	keys := make([]paramKey, 0, len(op.params))
+	for key := range op.params {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.in != b.in {
+			return locations[a.in] < locations[b.in]
+		}
+		if a.in == "path" {
+			return op.params[a].order < op.params[b].order
+		}
+		return a.name < b.name
+	})
+	for _, key := range keys {
+		p := op.params[key]
+		typ := p.schema.Type
+		if typ == "" {
+			// Values of different types can only be described as strings.
+			typ = "string"
+		}
+		param := spec.Parameter{
+			Name:     key.name,
+			In:       key.in,
+			Required: key.in == "path" || p.count == op.samples,
+			Type:     typ,
+		}
+		switch {
+		case p.file:
+			param.Type = "file"
+		case p.multi && (key.in == "query" || key.in == "formData"):
+			param.Type = "array"
+			param.Items = &spec.Items{Type: typ}
+			param.CollectionFormat = "multi"
+		}
+		out.Parameters = append(out.Parameters, param)
+	}
+	if b := op.body; b != nil {
+		out.Parameters = append(out.Parameters, spec.Parameter{
+			Name:     "body",
+			In:       "body",
+			Required: b.count == op.samples,
+			Schema:   b.build(),
+		})
+		if b.example != nil {
+			out.Parameters[len(out.Parameters)-1].Schema.Example = b.example
+		}
+	}
+
+	for code, r := range op.responses {
+		resp := spec.Response{Description: http.StatusText(code), Schema: r.build()}
+		if resp.Description == "" {
+			resp.Description = r.status
+		}
+		if resp.Description == "" {
+			resp.Description = "Status " + strconv.Itoa(code) + "."
+		}
+		if r.example != nil {
+			resp.Examples = spec.Example{"application/json": r.example}
+		}
+		out.Responses[strconv.Itoa(code)] = resp
+	}
+	return out
+}
+
+// build returns the schema of the bodies sampled, or nil if there were none.
+func (s *sample) build() *spec.Schema {
+	switch {
+	case s.count == 0:
+		return nil
+	case s.text && s.schema == nil:
+		return &spec.Schema{Type: "string"}
+	case s.text:
+		// Some bodies weren't JSON, so nothing is known about them.
+		return &spec.Schema{}
+	}
+	return s.schema
+}
+
+// identifier matches path segments which are likely to be identifiers: numbers,
+// UUIDs and long hexadecimal strings.
+var identifier = regexp.MustCompile(`^([0-9]+|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}|[0-9a-fA-F]{16,})$`)
+
+// template returns the path template a path's segments belong to, and the
+// values of its parameters. The first of templates the path matches is used.
+// Otherwise, segments which look like identifiers become parameters named
+// after the segment before them, so that "/pets/1" becomes "/pets/{petId}".
+func template(path []string, templates [][]string) (string, []NameValue) {
+	for _, t := range templates {
+		if params, ok := match(t, path); ok {
+			return "/" + strings.Join(t, "/"), params
+		}
+	}
+	out := make([]string, len(path))
+	var params []NameValue
+	used := make(map[string]bool)
+	for i, seg := range path {
+		if !identifier.MatchString(seg) {
+			out[i] = seg
+			continue
+		}
+		name := "id"
+		if i > 0 && !strings.HasPrefix(out[i-1], "{") {
+			name = singular(out[i-1]) + "Id"
+		}
+		for n := 2; used[name]; n++ {
+			name = strings.TrimRight(name, "0123456789") + strconv.Itoa(n)
+		}
+		used[name] = true
+		out[i] = "{" + name + "}"
+		params = append(params, NameValue{Name: name, Value: seg})
+	}
+	return "/" + strings.Join(out, "/"), params
+}
+
+// match reports whether a path matches a template's segments, and the values
+// of its parameters if it does.
+func match(t, path []string) ([]NameValue, bool) {
+	if len(t) != len(path) {
+		return nil, false
+	}
+	var params []NameValue
+	for i, seg := range t {
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			params = append(params, NameValue{Name: seg[1 : len(seg)-1], Value: path[i]})
+		} else if seg != path[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+func segments(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+// singular guesses the singular of an English noun, such as a collection's name.
+func singular(s string) string {
+	switch {
+	case strings.HasSuffix(s, "ies"):
+		return strings.TrimSuffix(s, "ies") + "y"
+	case strings.HasSuffix(s, "ss"):
+		return s
+	}
+	return strings.TrimSuffix(s, "s")
+}
+
+// ignoredHeaders are request headers which aren't parameters of an API, since
+// clients send them to any server, or which are described by other fields.
+var ignoredHeaders = map[string]bool{
+	"accept": true, "accept-encoding": true, "accept-language": true,
+	"authorization": true, "cache-control": true, "connection": true,
+	"content-length": true, "content-type": true, "cookie": true, "dnt": true,
+	"host": true, "if-modified-since": true, "if-none-match": true,
+	"origin": true, "pragma": true, "priority": true, "referer": true,
+	"te": true, "upgrade-insecure-requests": true, "user-agent": true,
+}
+
+func ignoredHeader(name string) bool {
+	name = strings.ToLower(name)
+	// HTTP/2 pseudo-headers, such as ":authority", and those browsers add
+	// for fetch metadata, such as "sec-fetch-mode".
+	return ignoredHeaders[name] || strings.HasPrefix(name, ":") || strings.HasPrefix(name, "sec-")
+}
+
+func mediaType(contentType string) string {
+	t, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return t
+}
+
+func isJSON(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+func decodeJSON(text string) (interface{}, bool) {
+	var v interface{}
+	if err := json.Unmarshal([]byte(text), &v); err != nil {
+		return nil, false
+	}
+	return v, true
+}
+
+// valueType infers the type of a parameter from a value.
+func valueType(v string) string {
+	if _, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return "integer"
+	}
+	if _, err := strconv.ParseFloat(v, 64); err == nil {
+		return "number"
+	}
+	if v == "true" || v == "false" {
+		return "boolean"
+	}
+	return "string"
+}
+
+func sortedSet(m map[string]bool) []string {
+	if len(m) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,232 @@
+package har
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/ericchiang/swaggopher/spec"
+	"github.com/ericchiang/swaggopher/spectest"
+)
+
+const archive = `{
+	"log": {
+		"version": "1.2",
+		"creator": {"name": "test", "version": "1.0"},
+		"entries": [
+			{
+				"request": {
+					"method": "GET",
+					"url": "https://api.example.com/v1/pets?limit=10",
+					"headers": [{"name": "User-Agent", "value": "test"}, {"name": "x-request-id", "value": "abc"}],
+					"queryString": [{"name": "limit", "value": "10"}]
+				},
+				"response": {
+					"status": 200,
+					"content": {"mimeType": "application/json", "text": "[{\"id\": 1, \"name\": \"Rex\"}]"}
+				}
+			},
+			{
+				"request": {
+					"method": "GET",
+					"url": "https://api.example.com/v1/pets?limit=20&tag=a&tag=b",
+					"queryString": [{"name": "limit", "value": "20"}, {"name": "tag", "value": "a"}, {"name": "tag", "value": "b"}]
+				},
+				"response": {
+					"status": 200,
+					"content": {"mimeType": "application/json", "text": "[{\"id\": 2, \"name\": \"Tom\", \"tag\": \"cat\"}]"}
+				}
+			},
+			{
+				"request": {"method": "GET", "url": "https://api.example.com/v1/pets/1"},
+				"response": {
+					"status": 200,
+					"content": {"mimeType": "application/json", "text": "{\"id\": 1, \"name\": \"Rex\", \"weight\": 3}"}
+				}
+			},
+			{
+				"request": {"method": "GET", "url": "https://api.example.com/v1/pets/2"},
+				"response": {
+					"status": 200,
+					"content": {"mimeType": "application/json", "text": "{\"id\": 2, \"name\": \"Tom\", \"weight\": 3.5}"}
+				}
+			},
+			{
+				"request": {"method": "GET", "url": "https://api.example.com/v1/pets/3"},
+				"response": {
+					"status": 404,
+					"content": {"mimeType": "application/json", "text": "eyJtZXNzYWdlIjogIm5vdCBmb3VuZCJ9", "encoding": "base64"}
+				}
+			},
+			{
+				"request": {
+					"method": "POST",
+					"url": "https://api.example.com/v1/pets",
+					"headers": [{"name": "Content-Type", "value": "application/json; charset=utf-8"}],
+					"postData": {"mimeType": "application/json; charset=utf-8", "text": "{\"name\": \"Rex\"}"}
+				},
+				"response": {
+					"status": 201,
+					"content": {"mimeType": "application/json", "text": "{\"id\": 1, \"name\": \"Rex\"}"}
+				}
+			},
+			{
+				"request": {"method": "GET", "url": "https://api.example.com/v1/owners/1b4e28ba-2fa1-11d2-883f-0016d3cca427/pets/5"},
+				"response": {
+					"status": 200,
+					"content": {"mimeType": "application/json", "text": "[]"}
+				}
+			},
+			{
+				"request": {"method": "DELETE", "url": "https://api.example.com/v1/pets/1"},
+				"response": {"status": 0, "content": {}}
+			},
+			{
+				"request": {"method": "GET", "url": "https://cdn.example.com/app.js"},
+				"response": {"status": 200, "content": {"mimeType": "text/javascript", "text": "alert(1)"}}
+			}
+		]
+	}
+}`
+
+const inferred = `
+swagger: "2.0"
+info: {title: api.example.com, version: 1.0.0}
+host: api.example.com
+basePath: /v1
+schemes: [https]
+paths:
+  /pets:
+    get:
+      produces: [application/json]
+      parameters:
+        - {name: limit, in: query, required: true, type: integer}
+        - {name: tag, in: query, type: array, items: {type: string}, collectionFormat: multi}
+        - {name: X-Request-Id, in: header, type: string}
+      responses:
+        200:
+          description: OK
+          schema:
+            type: array
+            items:
+              type: object
+              required: [id, name]
+              properties:
+                id: {type: integer}
+                name: {type: string}
+                tag: {type: string}
+    post:
+      consumes: [application/json]
+      produces: [application/json]
+      parameters:
+        - name: body
+          in: body
+          required: true
+          schema:
+            type: object
+            required: [name]
+            properties:
+              name: {type: string}
+      responses:
+        201:
+          description: Created
+          schema:
+            type: object
+            required: [id, name]
+            properties:
+              id: {type: integer}
+              name: {type: string}
+  /pets/{petId}:
+    get:
+      produces: [application/json]
+      parameters:
+        - {name: petId, in: path, required: true, type: integer}
+      responses:
+        200:
+          description: OK
+          schema:
+            type: object
+            required: [id, name, weight]
+            properties:
+              id: {type: integer}
+              name: {type: string}
+              weight: {type: number}
+        404:
+          description: Not Found
+          schema:
+            type: object
+            required: [message]
+            properties:
+              message: {type: string}
+  /owners/{ownerId}/pets/{petId}:
+    get:
+      produces: [application/json]
+      parameters:
+        - {name: ownerId, in: path, required: true, type: string}
+        - {name: petId, in: path, required: true, type: integer}
+      responses:
+        200:
+          description: OK
+          schema: {type: array, items: {}}
+`
+
+func TestInfer(t *testing.T) {
+	var h HAR
+	if err := json.Unmarshal([]byte(archive), &h); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Options{BasePath: "/v1"}.Infer(&h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want spec.Swagger
+	if err := yaml.Unmarshal([]byte(inferred), &want); err != nil {
+		t.Fatal(err)
+	}
+	if diff := spectest.Diff(&want, got); diff != "" {
+		t.Errorf("inferred document (-want +got):\n%s", diff)
+	}
+}
+
+func TestInferOptions(t *testing.T) {
+	var h HAR
+	if err := json.Unmarshal([]byte(archive), &h); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Options{Paths: []string{"/v1/pets/{name}"}, Examples: true}.Infer(&h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := got.Paths["/v1/pets/{name}"].Get
+	if get == nil {
+		t.Fatalf("expected paths to be grouped under /v1/pets/{name}, got %v", got.Paths)
+	}
+	if p := get.Parameters[0]; p.Name != "name" || p.In != "path" {
+		t.Errorf("expected a name path parameter, got %s in %s", p.Name, p.In)
+	}
+	wantExample := map[string]interface{}{"id": 1.0, "name": "Rex", "weight": 3.0}
+	if diff := pretty.Compare(get.Responses["200"].Examples["application/json"], wantExample); diff != "" {
+		t.Errorf("example of the response: %s", diff)
+	}
+	post := got.Paths["/v1/pets"].Post
+	if diff := pretty.Compare(post.Parameters[0].Schema.Example, map[string]interface{}{"name": "Rex"}); diff != "" {
+		t.Errorf("example of the body: %s", diff)
+	}
+
+	for _, test := range []struct {
+		name string
+		o    Options
+		h    HAR
+	}{
+		{name: "empty archive"},
+		{name: "unknown host", o: Options{Host: "pets.example.com"}, h: h},
+		{name: "outside basePath", o: Options{BasePath: "/v2"}, h: h},
+	} {
+		if _, err := test.o.Infer(&test.h); err == nil {
+			t.Errorf("%s: expected an error", test.name)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package conform
+
+import (
+	"github.com/ericchiang/swaggopher/internal/synth"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// Compiled is a schema prepared for checking many values: its references, and
+// those of its subschemas, are resolved once rather than each time a value is
+// checked. It's safe for concurrent use.
+type Compiled struct {
+	// schema is the resolved schema, or nil if it couldn't be resolved, in
+	// which case any value conforms.
+	schema     *spec.Schema
+	allOf      []*Compiled
+	properties map[string]*Compiled
+	additional *Compiled
+	items      *Compiled
+}
+
+// Compile prepares a schema of a document for checking values. The document
+// must not be modified while the compiled schema is in use.
+func Compile(doc *spec.Swagger, s *spec.Schema) *Compiled {
+	c := &compiler{doc: doc, refs: make(map[string]*Compiled)}
+	return c.compile(s)
+}
+
+type compiler struct {
+	doc *spec.Swagger
+	// refs holds the compiled definitions, by reference, so recursive
+	// schemas compile to cycles.
+	refs map[string]*Compiled
+}
+
+func (c *compiler) compile(s *spec.Schema) *Compiled {
+	if s == nil {
+		return nil
+	}
+	if s.Ref == "" {
+		out := new(Compiled)
+		c.fill(out, s)
+		return out
+	}
+	if out, ok := c.refs[s.Ref]; ok {
+		return out
+	}
+	out := new(Compiled)
+	c.refs[s.Ref] = out
+	if resolved := synth.Resolve(c.doc, s); resolved != nil {
+		c.fill(out, resolved)
+	}
+	return out
+}
+
+// fill compiles the subschemas of a resolved schema.
+func (c *compiler) fill(out *Compiled, s *spec.Schema) {
+	out.schema = s
+	for i := range s.AllOf {
+		out.allOf = append(out.allOf, c.compile(&s.AllOf[i]))
+	}
+	for name, prop := range s.Properties {
+		if out.properties == nil {
+			out.properties = make(map[string]*Compiled, len(s.Properties))
+		}
+		prop := prop
+		out.properties[name] = c.compile(&prop)
+	}
+	if ap := s.AdditionalProperties; ap != nil {
+		out.additional = c.compile(ap.Schema)
+	}
+	out.items = c.compile(s.Items)
+}
+
+// Value checks a decoded JSON value against the schema, as the package's Value
+// function does.
+func (c *Compiled) Value(v interface{}, path string) []string {
+	var msgs []string
+	for _, m := range c.Check(v, path) {
+		msgs = append(msgs, m.String())
+	}
+	return msgs
+}
+
+// Check checks a decoded JSON value against the schema, as the package's Check
+// function does.
+func (c *Compiled) Check(v interface{}, path string) []Mismatch {
+	return c.check(v, path, 0)
+}
+
+func (c *Compiled) check(v interface{}, path string, allOf int) []Mismatch {
+	if c == nil || c.schema == nil || allOf > maxAllOf {
+		return nil
+	}
+	return checkResolved(c.schema, v, path, func(sub subschema, v interface{}, path string) []Mismatch {
+		var child *Compiled
+		switch sub.keyword {
+		case "allOf":
+			child = c.allOf[sub.index]
+		case "properties":
+			child = c.properties[sub.name]
+		case "additionalProperties":
+			child = c.additional
+		case "items":
+			child = c.items
+		}
+		return child.check(v, path, sub.allOf(allOf))
+	})
+}
@@ -0,0 +1,307 @@
+// Package conform checks decoded JSON values against schemas.
+package conform
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/ericchiang/swaggopher/internal/formats"
+	"github.com/ericchiang/swaggopher/internal/jsonpointer"
+	"github.com/ericchiang/swaggopher/internal/synth"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// Mismatch is a way a value doesn't conform to a schema.
+type Mismatch struct {
+	// Path is the JSON pointer of the offending value.
+	Path    string
+	Message string
+}
+
+func (m Mismatch) String() string {
+	return m.Path + ": " + m.Message
+}
+
+// Value checks a decoded JSON value against a schema, returning a message for
+// each mismatch prefixed by the JSON pointer of the offending value relative to
+// path. See Check.
+func Value(doc *spec.Swagger, s *spec.Schema, v interface{}, path string) []string {
+	var msgs []string
+	for _, m := range Check(doc, s, v, path) {
+		msgs = append(msgs, m.String())
+	}
+	return msgs
+}
+
+// Check checks a decoded JSON value against a schema, returning each mismatch
+// with the JSON pointer of the offending value relative to path. The type,
+// format, format independent constraints, enum, required, properties,
+// additionalProperties, items and allOf keywords are checked. Constraints apply
+// to values of the type they constrain, whatever the schema's type.
+func Check(doc *spec.Swagger, s *spec.Schema, v interface{}, path string) []Mismatch {
+	return check(doc, s, v, path, 0)
+}
+
+// maxAllOf bounds how many allOf schemas nested in each other are checked
+// against the same value, so a schema which includes itself through allOf is
+// still checked in bounded time. Subschemas of a value's properties and items
+// aren't bounded: the value itself is, so checking them always ends.
+const maxAllOf = 2 * synth.MaxDepth
+
+func check(doc *spec.Swagger, s *spec.Schema, v interface{}, path string, allOf int) []Mismatch {
+	s = synth.Resolve(doc, s)
+	if s == nil || allOf > maxAllOf {
+		return nil
+	}
+	return checkResolved(s, v, path, func(sub subschema, v interface{}, path string) []Mismatch {
+		return check(doc, sub.schema, v, path, sub.allOf(allOf))
+	})
+}
+
+// subschema is a subschema of a schema being checked.
+type subschema struct {
+	schema *spec.Schema
+	// keyword is "allOf", "properties", "additionalProperties" or "items".
+	keyword string
+	// index is the position of an allOf schema.
+	index int
+	// name is the name of a property.
+	name string
+}
+
+// allOf returns the number of nested allOf schemas the subschema is checked
+// within, given the number its schema is. It's reset when the subschema
+// applies to a property or an item rather than to the same value.
+func (sub subschema) allOf(n int) int {
+	if sub.keyword == "allOf" {
+		return n + 1
+	}
+	return 0
+}
+
+// checkResolved checks a value against a schema whose reference has been
+// resolved, calling child to check values against its subschemas.
+func checkResolved(s *spec.Schema, v interface{}, path string, child func(sub subschema, v interface{}, path string) []Mismatch) []Mismatch {
+	var msgs []Mismatch
+	fail := func(format string, args ...interface{}) {
+		msgs = append(msgs, Mismatch{path, fmt.Sprintf(format, args...)})
+	}
+	constraints(s, v, fail)
+
+	for i := range s.AllOf {
+		msgs = append(msgs, child(subschema{schema: &s.AllOf[i], keyword: "allOf", index: i}, v, path)...)
+	}
+	if len(s.Enum) > 0 {
+		found := false
+		for _, e := range s.Enum {
+			if equal(e, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("value %v is not one of the allowed values", v)
+		}
+	}
+
+	switch s.Type {
+	case "object":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			fail("expected an object, got %s", Type(v))
+			return msgs
+		}
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				fail("missing required property %q", name)
+			}
+		}
+		names := make([]string, 0, len(obj))
+		for name := range obj {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			p := jsonpointer.Join(path, name)
+			if prop, ok := s.Properties[name]; ok {
+				msgs = append(msgs, child(subschema{schema: &prop, keyword: "properties", name: name}, obj[name], p)...)
+				continue
+			}
+			if ap := s.AdditionalProperties; ap != nil {
+				if ap.Schema != nil {
+					msgs = append(msgs, child(subschema{schema: ap.Schema, keyword: "additionalProperties"}, obj[name], p)...)
+				} else if !ap.Allowed {
+					fail("property %q is not allowed", name)
+				}
+			}
+		}
+	case "array":
+		arr, ok := v.([]interface{})
+		if !ok {
+			fail("expected an array, got %s", Type(v))
+			return msgs
+		}
+		if s.Items != nil {
+			for i, elem := range arr {
+				msgs = append(msgs, child(subschema{schema: s.Items, keyword: "items"}, elem, jsonpointer.Join(path, strconv.Itoa(i)))...)
+			}
+		}
+	case "integer":
+		f, ok := v.(float64)
+		if !ok || f != math.Trunc(f) {
+			fail("expected an integer, got %s", Type(v))
+			return msgs
+		}
+		checkFormat(s, v, fail)
+	case "number":
+		if _, ok := v.(float64); !ok {
+			fail("expected a number, got %s", Type(v))
+			return msgs
+		}
+		checkFormat(s, v, fail)
+	case "string":
+		if _, ok := v.(string); !ok {
+			fail("expected a string, got %s", Type(v))
+			return msgs
+		}
+		checkFormat(s, v, fail)
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			fail("expected a boolean, got %s", Type(v))
+		}
+	}
+	return msgs
+}
+
+// checkFormat checks a value of the schema's type against the format
+// registered with the name of the schema's format.
+func checkFormat(s *spec.Schema, v interface{}, fail func(format string, args ...interface{})) {
+	if s.Format == "" {
+		return
+	}
+	if err := formats.Check(s.Format, s.Type, v); err != nil {
+		fail("%v is not a valid %s: %v", v, s.Format, err)
+	}
+}
+
+// constraints checks the keywords which constrain numbers, strings, arrays and
+// objects.
+func constraints(s *spec.Schema, v interface{}, fail func(format string, args ...interface{})) {
+	switch v := v.(type) {
+	case float64:
+		if s.Maximum != nil && (v > *s.Maximum || (s.ExclusiveMaximum && v == *s.Maximum)) {
+			if s.ExclusiveMaximum {
+				fail("%v must be less than %v", v, *s.Maximum)
+			} else {
+				fail("%v must be at most %v", v, *s.Maximum)
+			}
+		}
+		if s.Minimum != nil && (v < *s.Minimum || (s.ExclusiveMinimum && v == *s.Minimum)) {
+			if s.ExclusiveMinimum {
+				fail("%v must be greater than %v", v, *s.Minimum)
+			} else {
+				fail("%v must be at least %v", v, *s.Minimum)
+			}
+		}
+		if s.MultipleOf > 0 {
+			if q := v / s.MultipleOf; math.Abs(q-math.Round(q)) > 1e-9 {
+				fail("%v is not a multiple of %v", v, s.MultipleOf)
+			}
+		}
+	case string:
+		n := utf8.RuneCountInString(v)
+		if s.MaxLength != nil && n > *s.MaxLength {
+			fail("length %d is more than the maximum of %d", n, *s.MaxLength)
+		}
+		if n < s.MinLength {
+			fail("length %d is less than the minimum of %d", n, s.MinLength)
+		}
+		if s.Pattern != "" {
+			// Invalid patterns are left for document validation to report.
+			if re, err := compile(s.Pattern); err == nil && !re.MatchString(v) {
+				fail("%q does not match pattern %q", v, s.Pattern)
+			}
+		}
+	case []interface{}:
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			fail("%d items is more than the maximum of %d", len(v), *s.MaxItems)
+		}
+		if len(v) < s.MinItems {
+			fail("%d items is less than the minimum of %d", len(v), s.MinItems)
+		}
+		if s.UniqueItems {
+			for i := range v {
+				for j := 0; j < i; j++ {
+					if equal(v[i], v[j]) {
+						fail("items %d and %d are equal, but items must be unique", j, i)
+					}
+				}
+			}
+		}
+	case map[string]interface{}:
+		if s.MaxProperties != nil && len(v) > *s.MaxProperties {
+			fail("%d properties is more than the maximum of %d", len(v), *s.MaxProperties)
+		}
+		if len(v) < s.MinProperties {
+			fail("%d properties is less than the minimum of %d", len(v), s.MinProperties)
+		}
+	}
+}
+
+var patterns sync.Map
+
+// compile compiles a pattern, caching the result since the same schemas are
+// checked many times.
+func compile(pattern string) (*regexp.Regexp, error) {
+	if re, ok := patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	patterns.Store(pattern, re)
+	return re, nil
+}
+
+// Type describes the JSON type of a decoded value, such as "an object".
+func Type(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "an object"
+	case []interface{}:
+		return "an array"
+	case float64:
+		if v == math.Trunc(v) {
+			return "an integer"
+		}
+		return "a number"
+	case string:
+		return "a string"
+	case bool:
+		return "a boolean"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// equal compares an enum value from the spec, which may have been decoded from
+// YAML, with a value decoded from JSON.
+func equal(a, b interface{}) bool {
+	aj, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	bj, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return string(aj) == string(bj)
+}
@@ -0,0 +1,133 @@
+// Package formats is the registry of the formats of strings and numbers, used
+// to check values and to choose the Go types generated for them.
+package formats
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"net/mail"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Format checks and types the values of a format.
+type Format struct {
+	// Type is the JSON type the format applies to: "string", "integer" or
+	// "number". Values of other types aren't checked.
+	Type string
+	// Check returns an error if a decoded JSON value of Type isn't valid in the
+	// format. A nil Check accepts every value.
+	Check func(v interface{}) error
+	// GoType is the Go type generated for the format, as the import path, a dot
+	// and the name, such as "github.com/google/uuid.UUID", or a predeclared
+	// type such as "int32". If empty, the type's default is used.
+	GoType string
+}
+
+var (
+	mu       sync.RWMutex
+	registry = map[string]Format{
+		"int32":     {Type: "integer", Check: checkInt32, GoType: "int32"},
+		"int64":     {Type: "integer", GoType: "int64"},
+		"float":     {Type: "number", Check: checkFloat, GoType: "float32"},
+		"double":    {Type: "number", GoType: "float64"},
+		"byte":      {Type: "string", Check: checkByte, GoType: "[]byte"},
+		"binary":    {Type: "string"},
+		"date":      {Type: "string", Check: checkDate},
+		"date-time": {Type: "string", Check: checkDateTime, GoType: "time.Time"},
+		"password":  {Type: "string"},
+		"email":     {Type: "string", Check: checkEmail},
+		"uuid":      {Type: "string", Check: checkUUID},
+	}
+)
+
+// Register adds a format, replacing any already registered with the name.
+func Register(name string, f Format) {
+	mu.Lock()
+	defer mu.Unlock()
+	registry[name] = f
+}
+
+// Lookup returns the format registered with a name.
+func Lookup(name string) (Format, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	f, ok := registry[name]
+	return f, ok
+}
+
+// Names returns the names of the registered formats in order.
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Check checks a value against the format registered with a name. Values of
+// other types than the format's, and formats which aren't registered, are
+// accepted.
+func Check(name, typ string, v interface{}) error {
+	f, ok := Lookup(name)
+	if !ok || f.Check == nil || f.Type != typ {
+		return nil
+	}
+	return f.Check(v)
+}
+
+func checkInt32(v interface{}) error {
+	if f, ok := v.(float64); ok && (f < math.MinInt32 || f > math.MaxInt32) {
+		return fmt.Errorf("out of range")
+	}
+	return nil
+}
+
+func checkFloat(v interface{}) error {
+	if f, ok := v.(float64); ok && math.Abs(f) > math.MaxFloat32 {
+		return fmt.Errorf("out of range")
+	}
+	return nil
+}
+
+func checkByte(v interface{}) error {
+	_, err := base64.StdEncoding.DecodeString(v.(string))
+	return err
+}
+
+func checkDate(v interface{}) error {
+	_, err := time.Parse("2006-01-02", v.(string))
+	return err
+}
+
+func checkDateTime(v interface{}) error {
+	_, err := time.Parse(time.RFC3339, v.(string))
+	return err
+}
+
+func checkEmail(v interface{}) error {
+	s := v.(string)
+	addr, err := mail.ParseAddress(s)
+	if err != nil {
+		return err
+	}
+	if addr.Address != s {
+		return fmt.Errorf("not a bare address")
+	}
+	return nil
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+func checkUUID(v interface{}) error {
+	if !uuidPattern.MatchString(v.(string)) {
+		return fmt.Errorf("does not match %s", uuidPattern)
+	}
+	return nil
+}
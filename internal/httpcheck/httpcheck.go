@@ -0,0 +1,368 @@
+// Package httpcheck matches HTTP requests to the operations of a document and
+// checks requests and responses against them.
+package httpcheck
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ericchiang/swaggopher/coerce"
+	"github.com/ericchiang/swaggopher/internal/conform"
+	"github.com/ericchiang/swaggopher/internal/jsonpointer"
+	"github.com/ericchiang/swaggopher/params"
+	"github.com/ericchiang/swaggopher/router"
+	"github.com/ericchiang/swaggopher/spec"
+	"github.com/ericchiang/swaggopher/variant"
+)
+
+// Match is the operation a request was routed to.
+type Match = router.Match
+
+// Error is returned by Route when no operation handles a request.
+type Error = router.Error
+
+// Route finds the operation which handles a request with a router compiled
+// from a document. See package router. If the operation has a variant whose
+// condition the request satisfies, chosen by variants, the variant is returned
+// in its place.
+func Route(rt *router.Router, variants *variant.Selector, r *http.Request) (*Match, error) {
+	m, err := rt.Route(r)
+	if err != nil {
+		return nil, err
+	}
+	if v := variants.Select(m.Operation, r); v != nil {
+		m.Operation = v.Operation
+	}
+	return m, nil
+}
+
+// Operation returns the operation of a path item for an HTTP method, or nil if
+// there isn't one.
+func Operation(item *spec.PathItem, method string) *spec.Operation {
+	return router.Operation(item, method)
+}
+
+// Problem is a way a request doesn't satisfy its operation's parameters.
+type Problem struct {
+	// In and Name identify the parameter, such as "query" and "limit". Both
+	// are empty if the request couldn't be read.
+	In   string `json:"in,omitempty"`
+	Name string `json:"name,omitempty"`
+	// Message describes the problem.
+	Message string `json:"message"`
+}
+
+// Request returns a message describing why a request doesn't satisfy the
+// parameters of the operation it was routed to, or an empty string if it does.
+// Only the problems of the first parameter Check rejects are described.
+//
+// The request's body is read, then replaced so it can still be forwarded.
+func Request(doc *spec.Swagger, m *Match, r *http.Request) string {
+	return Message(Check(doc, m, r))
+}
+
+// Message describes the problems of the first parameter in a list, or returns
+// an empty string if the list is empty.
+func Message(problems []Problem) string {
+	if len(problems) == 0 {
+		return ""
+	}
+	var msgs []string
+	for _, p := range problems {
+		if p.In == problems[0].In && p.Name == problems[0].Name {
+			msgs = append(msgs, p.Message)
+		}
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// DefaultMaxBodyBytes is the size of the largest request body checked unless
+// Options.MaxBodyBytes says otherwise.
+const DefaultMaxBodyBytes = 10 << 20
+
+// Options configures how requests are checked. The zero value only accepts
+// the canonical encodings of parameter values.
+type Options struct {
+	// Coerce controls how strictly the values of non-body parameters are
+	// parsed.
+	Coerce coerce.Options
+	// MaxBodyBytes is the size of the largest request body read. Larger
+	// bodies are reported as a problem. If zero, DefaultMaxBodyBytes is used.
+	MaxBodyBytes int64
+}
+
+func (o Options) maxBodyBytes() int64 {
+	if o.MaxBodyBytes > 0 {
+		return o.MaxBodyBytes
+	}
+	return DefaultMaxBodyBytes
+}
+
+// Check returns every way a request doesn't satisfy the parameters of the
+// operation it was routed to, using the default options. See Options.Check.
+func Check(doc *spec.Swagger, m *Match, r *http.Request) []Problem {
+	return Options{}.Check(doc, m, r)
+}
+
+// Check returns every way a request doesn't satisfy the parameters of the
+// operation it was routed to, in the order the parameters are declared.
+//
+// The request's body is read, up to MaxBodyBytes, then replaced so it can
+// still be forwarded.
+func (o Options) Check(doc *spec.Swagger, m *Match, r *http.Request) []Problem {
+	return o.check(doc, m, r, func(p *spec.Parameter, v interface{}) []string {
+		return conform.Value(doc, p.ValueSchema(), v, "")
+	})
+}
+
+// Parameters holds the compiled schemas of an operation's parameters, for
+// checking many requests without resolving their schemas each time. It's safe
+// for concurrent use.
+type Parameters struct {
+	opts Options
+	doc  *spec.Swagger
+	// schemas holds the compiled schemas by location and name, such as
+	// "query/limit".
+	schemas map[string]*conform.Compiled
+}
+
+// CompileParameters compiles the schemas of the parameters of an operation and
+// of the path item holding it, using the default options. See
+// Options.CompileParameters.
+func CompileParameters(doc *spec.Swagger, item *spec.PathItem, op *spec.Operation) *Parameters {
+	return Options{}.CompileParameters(doc, item, op)
+}
+
+// CompileParameters compiles the schemas of the parameters of an operation and
+// of the path item holding it. The document must not be modified while they're
+// in use.
+func (o Options) CompileParameters(doc *spec.Swagger, item *spec.PathItem, op *spec.Operation) *Parameters {
+	c := &Parameters{opts: o, doc: doc, schemas: make(map[string]*conform.Compiled)}
+	for _, p := range parameters(doc, item, op) {
+		c.schemas[p.In+"/"+p.Name] = conform.Compile(doc, p.ValueSchema())
+	}
+	return c
+}
+
+// Check returns every way a request doesn't satisfy the parameters, as the
+// package's Check function does. The request must have been routed to the
+// operation the parameters were compiled from.
+func (c *Parameters) Check(m *Match, r *http.Request) []Problem {
+	return c.opts.check(c.doc, m, r, func(p *spec.Parameter, v interface{}) []string {
+		if s, ok := c.schemas[p.In+"/"+p.Name]; ok {
+			return s.Value(v, "")
+		}
+		return conform.Value(c.doc, p.ValueSchema(), v, "")
+	})
+}
+
+// parameters returns the parameters of an operation and its path item which
+// can be checked. Unresolved references are skipped; they're reported by the
+// document's validation instead.
+func parameters(doc *spec.Swagger, item *spec.PathItem, op *spec.Operation) []*spec.Parameter {
+	var resolved []*spec.Parameter
+	for _, p := range doc.OperationParameters(item, op) {
+		if p.Ref == "" {
+			resolved = append(resolved, p)
+		}
+	}
+	return resolved
+}
+
+// check checks a request, calling conforms to check the decoded value of a
+// parameter against its schema.
+func (o Options) check(doc *spec.Swagger, m *Match, r *http.Request, conforms func(p *spec.Parameter, v interface{}) []string) []Problem {
+	var data []byte
+	if r.Body != nil {
+		max := o.maxBodyBytes()
+		var err error
+		if data, err = ioutil.ReadAll(io.LimitReader(r.Body, max+1)); err != nil {
+			return []Problem{{Message: err.Error()}}
+		}
+		if int64(len(data)) > max {
+			// Leave the rest of the body unread, but put back what was read.
+			r.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(data), r.Body), r.Body}
+			return []Problem{{In: "body", Message: fmt.Sprintf("request body is larger than %d bytes", max)}}
+		}
+		r.Body.Close()
+		r.Body = ioutil.NopCloser(bytes.NewReader(data))
+	}
+
+	// Parse forms from a copy so the original body is left for the handler.
+	form := new(http.Request)
+	*form = *r
+	form.Body = ioutil.NopCloser(bytes.NewReader(data))
+	form.Form, form.PostForm = nil, nil
+	if err := form.ParseForm(); err != nil {
+		return []Problem{{Message: err.Error()}}
+	}
+
+	var problems []Problem
+	for _, p := range parameters(doc, m.Item, m.Operation) {
+		if p.In == "body" {
+			for _, msg := range body(p, data, conforms) {
+				problems = append(problems, Problem{In: p.In, Name: p.Name, Message: msg})
+			}
+			continue
+		}
+		values, ok := params.Lookup(form, m.Vars, p)
+		if !ok {
+			if p.Required {
+				problems = append(problems, Problem{
+					In:      p.In,
+					Name:    p.Name,
+					Message: fmt.Sprintf("missing required %s parameter %s", p.In, p.Name),
+				})
+			}
+			continue
+		}
+		if p.Type == "file" {
+			continue
+		}
+		v, err := params.Options{Options: o.Coerce}.Parse(p, values)
+		if err != nil {
+			problems = append(problems, Problem{In: p.In, Name: p.Name, Message: err.Error()})
+			continue
+		}
+		if v == nil {
+			continue
+		}
+		items := &spec.Items{Type: p.Type, Format: p.Format, Items: p.Items, CollectionFormat: p.CollectionFormat}
+		for _, msg := range conforms(p, jsonValue(v, items)) {
+			problems = append(problems, Problem{In: p.In, Name: p.Name, Message: p.Name + msg})
+		}
+	}
+	return problems
+}
+
+// jsonValue converts a value params.Parse returns to the value decoding it
+// from JSON would, so it can be checked against the parameter's schema:
+// integers become float64s, and dates and bytes their text.
+func jsonValue(v interface{}, t *spec.Items) interface{} {
+	switch v := v.(type) {
+	case int64:
+		return float64(v)
+	case []interface{}:
+		elems := make([]interface{}, len(v))
+		for i, elem := range v {
+			elems[i] = elem
+			if t.Items != nil {
+				elems[i] = jsonValue(elem, t.Items)
+			}
+		}
+		return elems
+	case time.Time, []byte:
+		if s, err := coerce.Format(v, t); err == nil {
+			return s
+		}
+	}
+	return v
+}
+
+func body(p *spec.Parameter, data []byte, conforms func(p *spec.Parameter, v interface{}) []string) []string {
+	if len(data) == 0 {
+		if p.Required {
+			return []string{fmt.Sprintf("missing required body parameter %s", p.Name)}
+		}
+		return nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return []string{fmt.Sprintf("invalid JSON body: %v", err)}
+	}
+	var msgs []string
+	for _, msg := range conforms(p, v) {
+		msgs = append(msgs, "body"+msg)
+	}
+	return msgs
+}
+
+// Response returns the ways a response doesn't match those documented by an
+// operation: an undocumented status, or a JSON body which doesn't conform to the
+// response's schema. Bodies which aren't JSON aren't checked.
+func Response(doc *spec.Swagger, op *spec.Operation, code int, header http.Header, data []byte) []string {
+	return response(doc, op, code, header, data, func(status string, s *spec.Schema, v interface{}) []string {
+		return conform.Value(doc, s, v, "")
+	})
+}
+
+// Responses holds the compiled schemas of an operation's responses, for checking
+// many responses without resolving their schemas each time. It's safe for
+// concurrent use.
+type Responses struct {
+	doc *spec.Swagger
+	op  *spec.Operation
+	// schemas holds the compiled schemas by status code, or "default".
+	schemas map[string]*conform.Compiled
+}
+
+// CompileResponses compiles the schemas of an operation's responses. The
+// document must not be modified while they're in use.
+func CompileResponses(doc *spec.Swagger, op *spec.Operation) *Responses {
+	r := &Responses{doc: doc, op: op, schemas: make(map[string]*conform.Compiled, len(op.Responses))}
+	for status := range op.Responses {
+		if documented, ok := documentedResponse(doc, op, status); ok && documented.Schema != nil {
+			r.schemas[status] = conform.Compile(doc, documented.Schema)
+		}
+	}
+	return r
+}
+
+// Check returns the ways a response doesn't match those documented by the
+// operation, as Response does.
+func (r *Responses) Check(code int, header http.Header, data []byte) []string {
+	return response(r.doc, r.op, code, header, data, func(status string, s *spec.Schema, v interface{}) []string {
+		return r.schemas[status].Value(v, "")
+	})
+}
+
+// documentedResponse returns an operation's response for a status code or
+// "default", resolving its reference.
+func documentedResponse(doc *spec.Swagger, op *spec.Operation, status string) (spec.Response, bool) {
+	documented, ok := op.Responses[status]
+	if ok && documented.Ref != "" {
+		documented = doc.Responses[jsonpointer.Unescape(strings.TrimPrefix(documented.Ref, "#/responses/"))]
+	}
+	return documented, ok
+}
+
+// response checks a response, calling check with the status of the documented
+// response and its schema to check a JSON body.
+func response(doc *spec.Swagger, op *spec.Operation, code int, header http.Header, data []byte, check func(status string, s *spec.Schema, v interface{}) []string) []string {
+	status := strconv.Itoa(code)
+	documented, ok := documentedResponse(doc, op, status)
+	if !ok {
+		status = "default"
+		documented, ok = documentedResponse(doc, op, status)
+	}
+	if !ok {
+		return []string{fmt.Sprintf("status %d is not documented", code)}
+	}
+	if documented.Schema == nil || code == http.StatusNoContent {
+		return nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	if !strings.HasSuffix(mediaType, "json") {
+		return nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return []string{fmt.Sprintf("invalid JSON body: %v", err)}
+	}
+	var problems []string
+	for _, msg := range check(status, documented.Schema, v) {
+		problems = append(problems, "body"+msg)
+	}
+	return problems
+}
@@ -0,0 +1,150 @@
+// Package jsonapi exposes parsing, validation and comparison as functions which
+// take documents and return a Result encoded as JSON, for the wrappers which
+// call them from other languages, the WebAssembly build and the C shared
+// library.
+package jsonapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/diff"
+	"github.com/ericchiang/swaggopher/internal/jsonpointer"
+	"github.com/ericchiang/swaggopher/resolver"
+	"github.com/ericchiang/swaggopher/spec"
+	"github.com/ericchiang/swaggopher/validate"
+)
+
+// Result is the outcome of a call. A call which failed sets only Error, and a
+// validation which found no problems, or a comparison which found no changes,
+// sets nothing.
+type Result struct {
+	// Document is the document, encoded as JSON.
+	Document json.RawMessage `json:"document,omitempty"`
+	Problems []Problem       `json:"problems,omitempty"`
+	// Changes are the differences found by Diff.
+	Changes []diff.Change `json:"changes,omitempty"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// Problem is a problem found by validation.
+type Problem struct {
+	// Path is a JSON pointer to the offending value.
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// Encode encodes a result as JSON.
+func (r *Result) Encode() []byte {
+	data, err := json.Marshal(r)
+	if err != nil {
+		data, _ = json.Marshal(&Result{Error: err.Error()})
+	}
+	return data
+}
+
+func failed(err error) *Result {
+	return &Result{Error: err.Error()}
+}
+
+func parse(data []byte) (*spec.Swagger, error) {
+	var doc spec.Swagger
+	if err := spec.UnmarshalYAML(data, &doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// Parse parses a JSON or YAML document, and returns it as JSON.
+func Parse(data []byte) *Result {
+	doc, err := parse(data)
+	if err != nil {
+		return failed(err)
+	}
+	return document(doc)
+}
+
+func document(doc *spec.Swagger) *Result {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return failed(err)
+	}
+	return &Result{Document: data}
+}
+
+// Resolve parses a document and replaces its references with their targets.
+// Other documents it refers to are looked up in files, keyed by their
+// location relative to the document, as there's no file system or network to
+// load them from.
+func Resolve(data []byte, files map[string][]byte) *Result {
+	doc, err := parse(data)
+	if err != nil {
+		return failed(err)
+	}
+	load := func(location string) ([]byte, error) {
+		if data, ok := files[strings.TrimPrefix(location, "./")]; ok {
+			return data, nil
+		}
+		return nil, fmt.Errorf("%s is not one of the files given", location)
+	}
+	if err := resolver.Resolve(doc, resolver.WithBase("."), resolver.WithLoader(load)); err != nil {
+		return failed(err)
+	}
+	return document(doc)
+}
+
+// Validate parses a document and checks it against the specification.
+func Validate(data []byte) *Result {
+	doc, err := parse(data)
+	if err != nil {
+		return failed(err)
+	}
+	r := &Result{}
+	for _, e := range append(validate.ValidateDocument(doc), validate.ValidateSemantics(doc)...) {
+		r.Problems = append(r.Problems, Problem{Path: e.Path, Message: e.Message})
+	}
+	return r
+}
+
+// ValidateValue parses a document and checks a JSON value against one of its
+// definitions, named as in the document or by a reference such as
+// "#/definitions/Pet".
+func ValidateValue(data []byte, definition string, value []byte) *Result {
+	doc, err := parse(data)
+	if err != nil {
+		return failed(err)
+	}
+	name := jsonpointer.Unescape(strings.TrimPrefix(definition, "#/definitions/"))
+	s, ok := doc.Definitions[name]
+	if !ok {
+		return failed(fmt.Errorf("no definition %s", name))
+	}
+	var v interface{}
+	if err := json.Unmarshal(value, &v); err != nil {
+		return failed(err)
+	}
+	r := &Result{}
+	for _, err := range (validate.ValueOptions{Doc: doc}).Value(&s, v) {
+		p := Problem{Message: err.Error()}
+		if e, ok := err.(validate.ValidationError); ok {
+			p = Problem{Path: e.Path, Message: e.Message}
+		}
+		r.Problems = append(r.Problems, p)
+	}
+	return r
+}
+
+// Diff parses two versions of a document and reports the differences between
+// them.
+func Diff(old, new []byte) *Result {
+	var docs [2]*spec.Swagger
+	for i, data := range [][]byte{old, new} {
+		doc, err := parse(data)
+		if err != nil {
+			return failed(fmt.Errorf("%s document: %v", [...]string{"old", "new"}[i], err))
+		}
+		docs[i] = doc
+	}
+	return &Result{Changes: diff.Compare(docs[0], docs[1])}
+}
@@ -0,0 +1,143 @@
+package jsonapi
+
+import (
+	"strings"
+	"testing"
+)
+
+const petstore = `
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+paths: {}
+definitions:
+  Pet:
+    type: object
+    required: [name]
+    properties:
+      name: {type: string}
+`
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		doc  string
+		want string
+	}{
+		{
+			doc:  petstore,
+			want: `{"document":{"swagger":"2.0","info":{"title":"Pets","version":"1.0"},"paths":{},"definitions":{"Pet":{"required":["name"],"type":"object","properties":{"name":{"type":"string"}}}}}}`,
+		},
+		{
+			doc:  `{"swagger": "2.0", "info": {"title": "Pets", "version": "1.0"}, "paths": {}}`,
+			want: `{"document":{"swagger":"2.0","info":{"title":"Pets","version":"1.0"},"paths":{}}}`,
+		},
+		{
+			doc:  "swagger: [",
+			want: `{"error":`,
+		},
+	}
+	for _, tt := range tests {
+		if got := string(Parse([]byte(tt.doc)).Encode()); !strings.HasPrefix(got, tt.want) {
+			t.Errorf("Parse(%q): want %s, got %s", tt.doc, tt.want, got)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	const doc = `
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+paths: {}
+definitions:
+  Pet: {$ref: "%s"}
+`
+	files := map[string][]byte{
+		"pet.yaml": []byte("type: object\nproperties:\n  name: {type: string}\n"),
+	}
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{"pet.yaml", `"Pet":{"type":"object","properties":{"name":{"type":"string"}}}`},
+		{"./pet.yaml", `"Pet":{"type":"object","properties":{"name":{"type":"string"}}}`},
+		{"missing.yaml", `{"error":`},
+	}
+	for _, tt := range tests {
+		got := string(Resolve([]byte(strings.Replace(doc, "%s", tt.ref, 1)), files).Encode())
+		if !strings.Contains(got, tt.want) {
+			t.Errorf("Resolve(%s): want %s, got %s", tt.ref, tt.want, got)
+		}
+	}
+	if got := string(Resolve([]byte("swagger: ["), files).Encode()); !strings.HasPrefix(got, `{"error":`) {
+		t.Errorf("Resolve of an invalid document: got %s", got)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		doc  string
+		want string
+	}{
+		{`{"swagger": "2.0", "info": {"title": "Pets", "version": "1.0"}, "paths": {}}`, `{}`},
+		{
+			doc:  `{"swagger": "2.0", "paths": {}}`,
+			want: `{"problems":[{"path":"/info","message":"info is required"}]}`,
+		},
+		{"swagger: [", `{"error":`},
+	}
+	for _, tt := range tests {
+		if got := string(Validate([]byte(tt.doc)).Encode()); !strings.HasPrefix(got, tt.want) {
+			t.Errorf("Validate(%q): want %s, got %s", tt.doc, tt.want, got)
+		}
+	}
+}
+
+func TestValidateValue(t *testing.T) {
+	tests := []struct {
+		doc        string
+		definition string
+		value      string
+		want       string
+	}{
+		{petstore, "Pet", `{"name": "Rex"}`, `{}`},
+		{petstore, "#/definitions/Pet", `{"name": "Rex"}`, `{}`},
+		{petstore, "Pet", `{}`, `{"problems":[{"path":"","message":"missing required property \"name\""}]}`},
+		{petstore, "Owner", `{}`, `{"error":"no definition Owner"}`},
+		{petstore, "Pet", `{`, `{"error":`},
+		{"swagger: [", "Pet", `{}`, `{"error":`},
+	}
+	for _, tt := range tests {
+		got := string(ValidateValue([]byte(tt.doc), tt.definition, []byte(tt.value)).Encode())
+		if !strings.HasPrefix(got, tt.want) {
+			t.Errorf("ValidateValue(%s, %s): want %s, got %s", tt.definition, tt.value, tt.want, got)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	const pets = `
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+paths:
+  /pets:
+    get: {responses: {200: {description: OK}}}
+`
+	const none = `
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+paths: {}
+`
+	tests := []struct {
+		old, new string
+		want     string
+	}{
+		{pets, pets, `{}`},
+		{pets, none, `{"changes":[{"kind":"removed","severity":"breaking","path":"/paths/~1pets"`},
+		{"swagger: [", pets, `{"error":"old document: `},
+		{pets, "swagger: [", `{"error":"new document: `},
+	}
+	for _, tt := range tests {
+		if got := string(Diff([]byte(tt.old), []byte(tt.new)).Encode()); !strings.HasPrefix(got, tt.want) {
+			t.Errorf("Diff: want %s, got %s", tt.want, got)
+		}
+	}
+}
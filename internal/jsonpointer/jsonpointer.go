@@ -0,0 +1,47 @@
+// Package jsonpointer implements helpers for building and parsing RFC 6901 JSON
+// pointers.
+package jsonpointer
+
+import "strings"
+
+var (
+	escaper   = strings.NewReplacer("~", "~0", "/", "~1")
+	unescaper = strings.NewReplacer("~1", "/", "~0", "~")
+)
+
+// Escape escapes a single reference token, such as a path key like "/pets".
+func Escape(token string) string {
+	return escaper.Replace(token)
+}
+
+// Unescape reverses Escape.
+func Unescape(token string) string {
+	return unescaper.Replace(token)
+}
+
+// Join appends escaped tokens to a pointer.
+func Join(pointer string, tokens ...string) string {
+	for _, t := range tokens {
+		pointer += "/" + Escape(t)
+	}
+	return pointer
+}
+
+// Split returns the unescaped reference tokens of a pointer. A leading "#", as
+// used by JSON references, is ignored.
+func Split(pointer string) []string {
+	pointer = strings.TrimPrefix(pointer, "#")
+	if pointer == "" {
+		return nil
+	}
+	tokens := strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+	for i, t := range tokens {
+		tokens[i] = Unescape(t)
+	}
+	return tokens
+}
+
+// HasPrefix reports if pointer refers to prefix or a value nested within it.
+func HasPrefix(pointer, prefix string) bool {
+	return prefix == "" || pointer == prefix || strings.HasPrefix(pointer, prefix+"/")
+}
@@ -0,0 +1,35 @@
+package jsonpointer
+
+import (
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestRoundTrip(t *testing.T) {
+	tokens := []string{"paths", "/pets/{id}", "a~b"}
+	p := Join("", tokens...)
+	if want := "/paths/~1pets~1{id}/a~0b"; p != want {
+		t.Errorf("expected pointer %q got %q", want, p)
+	}
+	if diff := pretty.Compare(Split("#"+p), tokens); diff != "" {
+		t.Errorf("want != got: %s", diff)
+	}
+}
+
+func TestHasPrefix(t *testing.T) {
+	tests := []struct {
+		pointer, prefix string
+		want            bool
+	}{
+		{"/paths/~1pets/get", "", true},
+		{"/paths/~1pets/get", "/paths/~1pets", true},
+		{"/paths/~1pets", "/paths/~1pets", true},
+		{"/paths/~1petsore", "/paths/~1pets", false},
+	}
+	for i, tt := range tests {
+		if got := HasPrefix(tt.pointer, tt.prefix); got != tt.want {
+			t.Errorf("case %d: HasPrefix(%q, %q) = %t", i, tt.pointer, tt.prefix, got)
+		}
+	}
+}
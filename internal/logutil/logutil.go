@@ -0,0 +1,35 @@
+// Package logutil implements helpers for components that accept an optional
+// spec.Logger.
+package logutil
+
+import (
+	"time"
+)
+
+// Logger has the same method set as spec.Logger. It's declared here so that
+// package spec can use these helpers too.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// Printf logs to l if it's non-nil.
+func Printf(l Logger, format string, v ...interface{}) {
+	if l != nil {
+		l.Printf(format, v...)
+	}
+}
+
+// Phase logs the start of a named phase and returns a function that logs how
+// long the phase took. It's intended to be used with defer:
+//
+//	defer logutil.Phase(logger, "resolve")()
+func Phase(l Logger, name string) func() {
+	if l == nil {
+		return func() {}
+	}
+	start := time.Now()
+	l.Printf("%s: started", name)
+	return func() {
+		l.Printf("%s: finished in %s", name, time.Since(start))
+	}
+}
@@ -0,0 +1,19 @@
+// Package mapkeys implements a helper for visiting maps in a stable order.
+package mapkeys
+
+import (
+	"reflect"
+	"sort"
+)
+
+// Sorted returns the keys of a map with string keys, such as spec.Paths or
+// url.Values, in order. It panics if m isn't such a map.
+func Sorted(m interface{}) []string {
+	v := reflect.ValueOf(m)
+	keys := make([]string, 0, v.Len())
+	for _, k := range v.MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,28 @@
+package mapkeys
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+type names map[string]int
+
+func TestSorted(t *testing.T) {
+	tests := []struct {
+		m    interface{}
+		want []string
+	}{
+		{map[string]string{"b": "", "a": "", "c": ""}, []string{"a", "b", "c"}},
+		{names{"/pets/{id}": 1, "/pets": 2}, []string{"/pets", "/pets/{id}"}},
+		{url.Values{"limit": nil, "after": nil}, []string{"after", "limit"}},
+		{map[string]bool{}, []string{}},
+		{names(nil), []string{}},
+	}
+	for _, tt := range tests {
+		if diff := pretty.Compare(Sorted(tt.m), tt.want); diff != "" {
+			t.Errorf("Sorted(%v): want != got: %s", tt.m, diff)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+//go:build noyaml
+
+package rawdoc
+
+// MapSlice is an ordered mapping. Without a YAML engine, it's never decoded.
+type MapSlice []MapItem
+
+// MapItem is a key and value of a MapSlice.
+type MapItem struct {
+	Key, Value interface{}
+}
+
+// MarshalYAML returns ErrNoYAML.
+func MarshalYAML(v interface{}) ([]byte, error) {
+	return nil, ErrNoYAML
+}
+
+// UnmarshalYAML returns ErrNoYAML.
+func UnmarshalYAML(data []byte, v interface{}) error {
+	return ErrNoYAML
+}
@@ -0,0 +1,78 @@
+// Package rawdoc decodes JSON and YAML documents into generic values.
+//
+// It's the only package the core of swaggopher, the spec package, reaches YAML
+// through. Building with the noyaml tag leaves the YAML engine out, and YAML
+// documents are rejected with ErrNoYAML, so programs which only read JSON
+// depend on nothing but the standard library.
+package rawdoc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrNoYAML is returned when decoding or encoding YAML in a build without YAML
+// support.
+var ErrNoYAML = errors.New("YAML support is not built in (built with the noyaml tag)")
+
+// IsJSON reports if data looks like a JSON document rather than YAML.
+func IsJSON(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("{"))
+}
+
+// Decode decodes a JSON or YAML document into the generic values used by
+// encoding/json: map[string]interface{}, []interface{}, string, float64, bool
+// and nil. YAML integers are converted to float64 to match.
+func Decode(data []byte) (interface{}, error) {
+	var v interface{}
+	if IsJSON(data) {
+		if err := json.Unmarshal(data, &v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+	if err := UnmarshalYAML(data, &v); err != nil {
+		return nil, err
+	}
+	return Normalize(v)
+}
+
+// Normalize converts a value decoded by gopkg.in/yaml.v2 into the generic values
+// used by encoding/json.
+func Normalize(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			k, ok := key.(string)
+			if !ok {
+				k = fmt.Sprint(key)
+			}
+			n, err := Normalize(val)
+			if err != nil {
+				return nil, err
+			}
+			m[k] = n
+		}
+		return m, nil
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, val := range v {
+			n, err := Normalize(val)
+			if err != nil {
+				return nil, err
+			}
+			s[i] = n
+		}
+		return s, nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	}
+	return v, nil
+}
@@ -0,0 +1,21 @@
+//go:build !noyaml
+
+package rawdoc
+
+import "gopkg.in/yaml.v2"
+
+// MapSlice and MapItem are the ordered mappings of the YAML engine.
+type (
+	MapSlice = yaml.MapSlice
+	MapItem  = yaml.MapItem
+)
+
+// MarshalYAML encodes v as YAML.
+func MarshalYAML(v interface{}) ([]byte, error) {
+	return yaml.Marshal(v)
+}
+
+// UnmarshalYAML decodes a YAML document into v.
+func UnmarshalYAML(data []byte, v interface{}) error {
+	return yaml.Unmarshal(data, v)
+}
@@ -0,0 +1,154 @@
+// Package refs implements helpers for following the JSON References of
+// documents split across files and URLs.
+package refs
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/internal/jsonpointer"
+	"github.com/ericchiang/swaggopher/internal/logutil"
+	"github.com/ericchiang/swaggopher/internal/rawdoc"
+)
+
+// SchemaRef returns a schema's reference in its full form.
+func SchemaRef(ref string) string {
+	// Early versions of the specification's examples refer to definitions
+	// by name alone, such as "$ref: Pet".
+	if ref != "" && !strings.ContainsAny(ref, "#/.") {
+		return "#/definitions/" + ref
+	}
+	return ref
+}
+
+// IsURL reports if a location is an http or https URL rather than a file path.
+func IsURL(loc string) bool {
+	return strings.HasPrefix(loc, "http://") || strings.HasPrefix(loc, "https://")
+}
+
+// Locate splits a reference into the location of the document it refers to,
+// relative to the location of the document it appears in, and a normalized
+// JSON pointer within that document.
+func Locate(base, ref string) (loc, fragment string, err error) {
+	loc, fragment = ref, ""
+	if i := strings.Index(ref, "#"); i >= 0 {
+		loc, fragment = ref[:i], ref[i+1:]
+	}
+	if fragment, err = url.PathUnescape(fragment); err != nil {
+		return "", "", fmt.Errorf("invalid reference %q: %v", ref, err)
+	}
+	fragment = jsonpointer.Join("", jsonpointer.Split(fragment)...)
+
+	switch {
+	case loc == "":
+		return base, fragment, nil
+	case IsURL(loc):
+		return loc, fragment, nil
+	case IsURL(base):
+		b, err := url.Parse(base)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid base %q: %v", base, err)
+		}
+		u, err := url.Parse(loc)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid reference %q: %v", ref, err)
+		}
+		return b.ResolveReference(u).String(), fragment, nil
+	case filepath.IsAbs(loc):
+		return filepath.Clean(loc), fragment, nil
+	}
+	return filepath.Join(filepath.Dir(base), filepath.FromSlash(loc)), fragment, nil
+}
+
+// Lookup evaluates a JSON pointer against a decoded document.
+func Lookup(doc interface{}, pointer string) (interface{}, bool) {
+	v := doc
+	for _, token := range jsonpointer.Split(pointer) {
+		switch val := v.(type) {
+		case map[string]interface{}:
+			child, ok := val[token]
+			if !ok {
+				return nil, false
+			}
+			v = child
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(val) {
+				return nil, false
+			}
+			v = val[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// Convert decodes a generic value, such as one returned by Lookup, into a spec
+// type.
+func Convert(val, v interface{}) error {
+	data, err := json.Marshal(val)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// Documents loads and decodes documents, caching them by location.
+type Documents struct {
+	// Load fetches the document at a location.
+	Load func(location string) ([]byte, error)
+	// Logger, if set, receives a line for each document loaded, beginning
+	// with Prefix.
+	Logger logutil.Logger
+	Prefix string
+
+	docs map[string]interface{}
+}
+
+// Set caches a decoded document, so it's used in place of the one at loc.
+func (d *Documents) Set(loc string, doc interface{}) {
+	if d.docs == nil {
+		d.docs = make(map[string]interface{})
+	}
+	d.docs[loc] = doc
+}
+
+// Fetch returns the decoded document at a location.
+func (d *Documents) Fetch(loc string) (interface{}, error) {
+	if doc, ok := d.docs[loc]; ok {
+		return doc, nil
+	}
+	data, err := d.Load(loc)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := rawdoc.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	logutil.Printf(d.Logger, "%s: loaded %s (%d bytes)", d.Prefix, loc, len(data))
+	d.Set(loc, doc)
+	return doc, nil
+}
+
+// Get decodes the value at a location and fragment, which ref refers to, into
+// v.
+func (d *Documents) Get(loc, fragment, ref string, v interface{}) error {
+	doc, err := d.Fetch(loc)
+	if err != nil {
+		return fmt.Errorf("%s: %v", ref, err)
+	}
+	val, ok := Lookup(doc, fragment)
+	if !ok {
+		return fmt.Errorf("%s: not found", ref)
+	}
+	if err := Convert(val, v); err != nil {
+		return fmt.Errorf("%s: %v", ref, err)
+	}
+	return nil
+}
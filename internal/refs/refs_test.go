@@ -0,0 +1,92 @@
+package refs
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSchemaRef(t *testing.T) {
+	tests := []struct {
+		ref, want string
+	}{
+		{"Pet", "#/definitions/Pet"},
+		{"#/definitions/Pet", "#/definitions/Pet"},
+		{"pet.yaml", "pet.yaml"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := SchemaRef(tt.ref); got != tt.want {
+			t.Errorf("SchemaRef(%q): want %q, got %q", tt.ref, tt.want, got)
+		}
+	}
+}
+
+func TestLocate(t *testing.T) {
+	tests := []struct {
+		base, ref     string
+		loc, fragment string
+	}{
+		{"api.yaml", "#/definitions/Pet", "api.yaml", "/definitions/Pet"},
+		{"specs/api.yaml", "common.yaml#/definitions/Error", filepath.Join("specs", "common.yaml"), "/definitions/Error"},
+		{"specs/api.yaml", "pet.yaml", filepath.Join("specs", "pet.yaml"), ""},
+		{"https://example.com/specs/api.yaml", "common.yaml#/definitions/Error", "https://example.com/specs/common.yaml", "/definitions/Error"},
+		{"api.yaml", "https://example.com/pet.yaml#/Pet", "https://example.com/pet.yaml", "/Pet"},
+		{"api.yaml", "#/paths/~1pets%7Bid%7D", "api.yaml", "/paths/~1pets{id}"},
+	}
+	for _, tt := range tests {
+		loc, fragment, err := Locate(tt.base, tt.ref)
+		if err != nil {
+			t.Errorf("Locate(%q, %q): %v", tt.base, tt.ref, err)
+			continue
+		}
+		if loc != tt.loc || fragment != tt.fragment {
+			t.Errorf("Locate(%q, %q): want %q %q, got %q %q", tt.base, tt.ref, tt.loc, tt.fragment, loc, fragment)
+		}
+	}
+	if _, _, err := Locate("api.yaml", "#/definitions/%zz"); err == nil {
+		t.Errorf("expected an error for an invalid escape")
+	}
+}
+
+func TestLookup(t *testing.T) {
+	doc := map[string]interface{}{
+		"tags": []interface{}{"a", map[string]interface{}{"name": "b"}},
+	}
+	tests := []struct {
+		pointer string
+		want    interface{}
+		ok      bool
+	}{
+		{"/tags/0", "a", true},
+		{"/tags/1/name", "b", true},
+		{"/tags/2", nil, false},
+		{"/tags/name", nil, false},
+		{"/missing", nil, false},
+	}
+	for _, tt := range tests {
+		got, ok := Lookup(doc, tt.pointer)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Lookup(%q): want %v %v, got %v %v", tt.pointer, tt.want, tt.ok, got, ok)
+		}
+	}
+}
+
+func TestDocuments(t *testing.T) {
+	loads := 0
+	d := &Documents{Load: func(string) ([]byte, error) {
+		loads++
+		return []byte(`{"definitions": {"Pet": {"type": "object"}}}`), nil
+	}}
+	var v struct{ Type string }
+	for i := 0; i < 2; i++ {
+		if err := d.Get("pet.json", "/definitions/Pet", "pet.json#/definitions/Pet", &v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if v.Type != "object" || loads != 1 {
+		t.Errorf("want an object loaded once, got %q loaded %d times", v.Type, loads)
+	}
+	if err := d.Get("pet.json", "/definitions/Owner", "pet.json#/definitions/Owner", &v); err == nil || err.Error() != "pet.json#/definitions/Owner: not found" {
+		t.Errorf("missing value: got %v", err)
+	}
+}
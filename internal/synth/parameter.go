@@ -0,0 +1,119 @@
+package synth
+
+import (
+	"math"
+	"time"
+
+	"github.com/ericchiang/swaggopher/coerce"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// Parameter returns the string encoded values to send for a non-body parameter:
+// its default, its first enum value, or a value generated from its type. Only
+// parameters with the "multi" collectionFormat have more than one.
+func Parameter(p *spec.Parameter) ([]string, error) {
+	items := &spec.Items{
+		Type:             p.Type,
+		Format:           p.Format,
+		Items:            p.Items,
+		CollectionFormat: p.CollectionFormat,
+		Default:          p.Default,
+		Maximum:          p.Maximum,
+		ExclusiveMaximum: p.ExclusiveMaximum,
+		Minimum:          p.Minimum,
+		ExclusiveMinimum: p.ExclusiveMinimum,
+		MinLength:        p.MinLength,
+		MinItems:         p.MinItems,
+		Enum:             p.Enum,
+	}
+	v := primitive(items)
+	if p.CollectionFormat == "multi" {
+		elems, _ := v.([]interface{})
+		values := make([]string, len(elems))
+		for i, elem := range elems {
+			s, err := coerce.Format(elem, p.Items)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = s
+		}
+		return values, nil
+	}
+	s, err := coerce.Format(v, items)
+	if err != nil {
+		return nil, err
+	}
+	return []string{s}, nil
+}
+
+// primitive generates a valid value for a non-body parameter, of a type
+// accepted by coerce.Format.
+func primitive(t *spec.Items) interface{} {
+	if t.Default != nil {
+		return encodable(t.Default)
+	}
+	if len(t.Enum) > 0 {
+		return encodable(t.Enum[0])
+	}
+	switch t.Type {
+	case "integer":
+		return int64(math.Ceil(Number(t.Minimum, t.ExclusiveMinimum, t.Maximum, t.ExclusiveMaximum, 1)))
+	case "number":
+		return Number(t.Minimum, t.ExclusiveMinimum, t.Maximum, t.ExclusiveMaximum, 1)
+	case "boolean":
+		return true
+	case "array":
+		n := t.MinItems
+		if n == 0 {
+			n = 1
+		}
+		elems := make([]interface{}, n)
+		item := &spec.Items{Type: "string"}
+		if t.Items != nil {
+			item = t.Items
+		}
+		for i := range elems {
+			elems[i] = primitive(item)
+		}
+		return elems
+	}
+	return str(t.Format, t.MinLength)
+}
+
+// encodable converts numbers decoded from JSON or YAML to the types
+// coerce.Format expects.
+func encodable(v interface{}) interface{} {
+	switch v := v.(type) {
+	case int:
+		return int64(v)
+	case float64:
+		if v == math.Trunc(v) {
+			return int64(v)
+		}
+	case []interface{}:
+		elems := make([]interface{}, len(v))
+		for i, elem := range v {
+			elems[i] = encodable(elem)
+		}
+		return elems
+	}
+	return v
+}
+
+func str(format string, minLength int) interface{} {
+	switch format {
+	case "date", "date-time":
+		return time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	case "byte":
+		return []byte("test")
+	case "uuid":
+		return "00000000-0000-4000-8000-000000000000"
+	case "email":
+		return "test@example.com"
+	}
+	s := "test"
+	for len(s) < minLength {
+		s += "x"
+	}
+	return s
+}
@@ -0,0 +1,149 @@
+// Package synth generates JSON values which are valid against a schema.
+package synth
+
+import (
+	"math"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/internal/jsonpointer"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// MaxDepth bounds how deeply nested generated values are, so recursive
+// definitions terminate.
+const MaxDepth = 5
+
+// Example generates a value valid against s, preferring the schema's example,
+// default or first enum value. Read only properties are left out of values
+// for request bodies unless they're required.
+func Example(doc *spec.Swagger, s *spec.Schema, request bool) interface{} {
+	g := &generator{doc: doc, request: request}
+	return g.example(s, 0)
+}
+
+// Resolve follows local references to definitions, returning nil if a
+// reference can't be resolved.
+func Resolve(doc *spec.Swagger, s *spec.Schema) *spec.Schema {
+	for i := 0; s != nil && s.Ref != ""; i++ {
+		if i == MaxDepth {
+			return nil
+		}
+		d, ok := doc.Definitions[jsonpointer.Unescape(strings.TrimPrefix(s.Ref, "#/definitions/"))]
+		if !ok {
+			return nil
+		}
+		s = &d
+	}
+	return s
+}
+
+// Number returns a value within the given bounds, preferring def.
+func Number(min *float64, exclusiveMin bool, max *float64, exclusiveMax bool, def float64) float64 {
+	v := def
+	if min != nil && (v < *min || (exclusiveMin && v == *min)) {
+		v = *min
+		if exclusiveMin {
+			v++
+		}
+	}
+	if max != nil && (v > *max || (exclusiveMax && v == *max)) {
+		v = *max
+		if exclusiveMax {
+			v--
+		}
+	}
+	return v
+}
+
+type generator struct {
+	doc     *spec.Swagger
+	request bool
+}
+
+func (g *generator) example(s *spec.Schema, depth int) interface{} {
+	s = Resolve(g.doc, s)
+	if s == nil {
+		return nil
+	}
+	if s.Example != nil {
+		return s.Example
+	}
+	if s.Default != nil {
+		return s.Default
+	}
+	if len(s.Enum) > 0 {
+		return s.Enum[0]
+	}
+
+	typ := s.Type
+	if typ == "" && (len(s.Properties) > 0 || len(s.AllOf) > 0) {
+		typ = "object"
+	}
+	switch typ {
+	case "object":
+		obj := make(map[string]interface{})
+		if depth >= MaxDepth {
+			return obj
+		}
+		for _, sub := range s.AllOf {
+			if m, ok := g.example(&sub, depth+1).(map[string]interface{}); ok {
+				for k, v := range m {
+					obj[k] = v
+				}
+			}
+		}
+		required := make(map[string]bool)
+		for _, name := range s.Required {
+			required[name] = true
+		}
+		for name, prop := range s.Properties {
+			prop := prop
+			// Read only properties must not be sent in requests.
+			if g.request && !required[name] {
+				if p := Resolve(g.doc, &prop); p != nil && p.ReadOnly {
+					continue
+				}
+			}
+			obj[name] = g.example(&prop, depth+1)
+		}
+		return obj
+	case "array":
+		n := s.MinItems
+		if n == 0 {
+			n = 1
+		}
+		if depth >= MaxDepth || s.Items == nil {
+			return []interface{}{}
+		}
+		elems := make([]interface{}, n)
+		for i := range elems {
+			elems[i] = g.example(s.Items, depth+1)
+		}
+		return elems
+	case "integer":
+		return math.Ceil(Number(s.Minimum, s.ExclusiveMinimum, s.Maximum, s.ExclusiveMaximum, 1))
+	case "number":
+		return Number(s.Minimum, s.ExclusiveMinimum, s.Maximum, s.ExclusiveMaximum, 1)
+	case "boolean":
+		return true
+	case "string":
+		switch s.Format {
+		case "date":
+			return "2016-01-01"
+		case "date-time":
+			return "2016-01-01T00:00:00Z"
+		case "byte":
+			return "dGVzdA=="
+		case "uuid":
+			return "00000000-0000-4000-8000-000000000000"
+		case "email":
+			return "test@example.com"
+		}
+		v := "test"
+		for len(v) < s.MinLength {
+			v += "x"
+		}
+		return v
+	}
+	return nil
+}
@@ -0,0 +1,342 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/internal/jsonpointer"
+	"github.com/ericchiang/swaggopher/internal/mapkeys"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// Loss records a keyword of an imported schema which Swagger can't express,
+// and was dropped or approximated.
+type Loss struct {
+	// Schema is the name the schema was imported under.
+	Schema string `json:"schema"`
+	// Path is a JSON pointer to the keyword in the schema.
+	Path string `json:"path"`
+	// Message describes what was lost.
+	Message string `json:"message"`
+}
+
+func (l Loss) String() string {
+	return fileName(l.Schema) + "#" + l.Path + ": " + l.Message
+}
+
+// Import adds JSON Schema documents, keyed by name, to the definitions of a
+// document, using the default options. See Options.Import.
+func Import(doc *spec.Swagger, schemas map[string]json.RawMessage) ([]Loss, error) {
+	return Options{}.Import(doc, schemas)
+}
+
+// Import adds JSON Schema documents of any draft, keyed by name, to the
+// definitions of a document. It's the reverse of Export: each document becomes
+// the definition of its name, and the definitions it holds under "$defs" or
+// "definitions" become definitions of their own names.
+//
+// References to the documents are rewritten to refer to their definitions. A
+// document is referred to by its name followed by ".json", such as
+// "Pet.json", resolved against Options.BaseURI if it's set, or by its $id.
+// Keywords are converted to Swagger's:
+//
+//	type: [string, "null"]      type string with x-nullable: true
+//	anyOf or oneOf with null    the other schema with x-nullable: true
+//	const                       an enum of one value
+//	examples                    example, the first of them
+//	exclusiveMaximum: 10        maximum 10 with exclusiveMaximum: true
+//
+// Keywords Swagger doesn't support, such as oneOf, not and patternProperties,
+// are dropped and reported as losses. It's an error to import a definition
+// which the document already has, or a reference to a document which isn't
+// imported.
+func (o Options) Import(doc *spec.Swagger, schemas map[string]json.RawMessage) ([]Loss, error) {
+	names := make([]string, 0, len(schemas))
+	for name := range schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	im := &importer{files: make(map[string]string), defs: make(map[string]map[string]interface{})}
+	docs := make(map[string]map[string]interface{}, len(names))
+	for _, name := range names {
+		var v map[string]interface{}
+		if err := json.Unmarshal(schemas[name], &v); err != nil {
+			return nil, fmt.Errorf("jsonschema: schema %s: %v", name, err)
+		}
+		docs[name] = v
+		im.files[fileName(name)] = name
+		im.files[o.BaseURI+fileName(name)] = name
+		for _, key := range []string{"$id", "id"} {
+			if id, ok := v[key].(string); ok && id != "" {
+				im.files[strings.TrimSuffix(id, "#")] = name
+			}
+		}
+	}
+	for _, name := range names {
+		im.schema = name
+		if err := im.add(name, im.convert("", docs[name], true)); err != nil {
+			return nil, err
+		}
+	}
+	if im.err != nil {
+		return nil, im.err
+	}
+
+	defNames := make([]string, 0, len(im.defs))
+	for name := range im.defs {
+		if _, ok := doc.Definitions[name]; ok {
+			return nil, fmt.Errorf("jsonschema: definition %s already exists", name)
+		}
+		defNames = append(defNames, name)
+	}
+	sort.Strings(defNames)
+	if doc.Definitions == nil {
+		doc.Definitions = make(spec.Definitions, len(defNames))
+	}
+	for _, name := range defNames {
+		data, err := json.Marshal(im.defs[name])
+		if err != nil {
+			return nil, fmt.Errorf("jsonschema: definition %s: %v", name, err)
+		}
+		var s spec.Schema
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, fmt.Errorf("jsonschema: definition %s: %v", name, err)
+		}
+		doc.Definitions[name] = s
+	}
+	return im.losses, nil
+}
+
+type importer struct {
+	// files maps the names documents can be referred to by to the names they
+	// were imported under.
+	files map[string]string
+	// defs holds the converted definitions, keyed by name.
+	defs map[string]map[string]interface{}
+
+	// schema is the name of the document being converted.
+	schema string
+	losses []Loss
+	// err is the first reference which couldn't be resolved.
+	err error
+}
+
+func (im *importer) lose(path, format string, v ...interface{}) {
+	im.losses = append(im.losses, Loss{Schema: im.schema, Path: path, Message: fmt.Sprintf(format, v...)})
+}
+
+// add adds a converted definition. Documents may hold the same definition
+// under "$defs", but not different ones with the same name.
+func (im *importer) add(name string, s map[string]interface{}) error {
+	if prev, ok := im.defs[name]; ok && !reflect.DeepEqual(prev, s) {
+		return fmt.Errorf("jsonschema: schema %s: definition %s is imported more than once", im.schema, name)
+	}
+	im.defs[name] = s
+	return nil
+}
+
+// swaggerKeywords are the keywords a Swagger Schema Object shares with JSON
+// Schema, which are kept as they are.
+var swaggerKeywords = map[string]bool{
+	"format": true, "title": true, "description": true, "default": true,
+	"multipleOf": true, "maxLength": true, "minLength": true, "pattern": true,
+	"maxItems": true, "minItems": true, "uniqueItems": true,
+	"maxProperties": true, "minProperties": true, "required": true,
+	"enum": true, "readOnly": true,
+}
+
+// ignoredKeywords are dropped without being reported, since they only
+// identify or annotate a document.
+var ignoredKeywords = map[string]bool{
+	"$schema": true, "$id": true, "id": true, "$comment": true,
+}
+
+// convert converts a schema at a pointer of the document being imported.
+// The definitions of a document's root are added to im.defs.
+func (im *importer) convert(path string, s map[string]interface{}, root bool) map[string]interface{} {
+	out := make(map[string]interface{}, len(s))
+	nullable := false
+	for _, key := range mapkeys.Sorted(s) {
+		v := s[key]
+		at := jsonpointer.Join(path, key)
+		switch {
+		case swaggerKeywords[key] || strings.HasPrefix(key, "x-"):
+			out[key] = v
+		case ignoredKeywords[key]:
+		case key == "$ref":
+			if ref, ok := v.(string); ok {
+				out[key] = im.ref(at, ref)
+			}
+		case key == "$defs" || key == "definitions":
+			defs, _ := v.(map[string]interface{})
+			if !root {
+				im.lose(at, "%s is only supported at the root of a schema", key)
+				continue
+			}
+			for _, name := range mapkeys.Sorted(defs) {
+				if def, ok := defs[name].(map[string]interface{}); ok {
+					if err := im.add(name, im.convert(jsonpointer.Join(at, name), def, false)); err != nil && im.err == nil {
+						im.err = err
+					}
+				}
+			}
+		case key == "type":
+			switch t := v.(type) {
+			case string:
+				if t == "null" {
+					im.lose(at, "the null type is not supported")
+					continue
+				}
+				out[key] = t
+			case []interface{}:
+				var types []string
+				for _, elem := range t {
+					if elem == "null" {
+						nullable = true
+					} else if elem, ok := elem.(string); ok {
+						types = append(types, elem)
+					}
+				}
+				if len(types) == 1 {
+					out[key] = types[0]
+				} else if len(types) > 1 {
+					im.lose(at, "multiple types are not supported")
+				}
+			}
+		case key == "items":
+			if sub, ok := v.(map[string]interface{}); ok {
+				out[key] = im.convert(at, sub, false)
+			} else {
+				im.lose(at, "tuples are not supported")
+			}
+		case key == "additionalProperties":
+			if sub, ok := v.(map[string]interface{}); ok {
+				out[key] = im.convert(at, sub, false)
+			} else {
+				out[key] = v
+			}
+		case key == "properties":
+			props, _ := v.(map[string]interface{})
+			m := make(map[string]interface{}, len(props))
+			for name, sub := range props {
+				if sub, ok := sub.(map[string]interface{}); ok {
+					m[name] = im.convert(jsonpointer.Join(at, name), sub, false)
+				}
+			}
+			out[key] = m
+		case key == "allOf":
+			list, _ := v.([]interface{})
+			out[key] = im.convertList(at, list)
+		case key == "anyOf" || key == "oneOf":
+			list, _ := v.([]interface{})
+			sub, ok := nullableSchema(list)
+			if !ok {
+				im.lose(at, "%s is not supported", key)
+				continue
+			}
+			nullable = true
+			sub = im.convert(jsonpointer.Join(at, "0"), sub, false)
+			if _, ok := sub["$ref"]; ok {
+				// Keywords beside a reference are ignored, so it's
+				// wrapped instead.
+				allOf, _ := out["allOf"].([]interface{})
+				out["allOf"] = append(allOf, sub)
+				continue
+			}
+			for k, v := range sub {
+				if _, ok := out[k]; !ok {
+					out[k] = v
+				}
+			}
+		case key == "const":
+			out["enum"] = []interface{}{v}
+		case key == "examples":
+			if list, ok := v.([]interface{}); ok && len(list) > 0 {
+				out["example"] = list[0]
+			}
+		case key == "maximum" || key == "minimum":
+			// A numeric exclusiveMaximum or exclusiveMinimum, which
+			// sorts first, has already set the limit.
+			if _, ok := out[key]; !ok {
+				out[key] = v
+			}
+		case key == "exclusiveMaximum" || key == "exclusiveMinimum":
+			limit := "maximum"
+			if key == "exclusiveMinimum" {
+				limit = "minimum"
+			}
+			switch v := v.(type) {
+			case bool:
+				out[key] = v
+			case float64:
+				out[limit], out[key] = v, true
+			}
+		default:
+			im.lose(at, "%s is not supported", key)
+		}
+	}
+	if nullable {
+		out["x-nullable"] = true
+	}
+	return out
+}
+
+func (im *importer) convertList(path string, list []interface{}) []interface{} {
+	out := make([]interface{}, 0, len(list))
+	for i, sub := range list {
+		if sub, ok := sub.(map[string]interface{}); ok {
+			out = append(out, im.convert(jsonpointer.Join(path, fmt.Sprint(i)), sub, false))
+		}
+	}
+	return out
+}
+
+// nullableSchema returns the other schema of an anyOf or oneOf between a
+// schema and null, the way nullable values are written in later drafts.
+func nullableSchema(list []interface{}) (map[string]interface{}, bool) {
+	if len(list) != 2 {
+		return nil, false
+	}
+	for i, sub := range list {
+		if m, ok := sub.(map[string]interface{}); ok && len(m) == 1 && m["type"] == "null" {
+			other, ok := list[1-i].(map[string]interface{})
+			return other, ok
+		}
+	}
+	return nil, false
+}
+
+// ref rewrites a reference to an imported document, or to a definition it
+// holds, to refer to the document's definitions.
+func (im *importer) ref(path, ref string) string {
+	file, fragment := ref, ""
+	if i := strings.Index(ref, "#"); i >= 0 {
+		file, fragment = ref[:i], ref[i+1:]
+	}
+	name := im.schema
+	if file != "" {
+		var ok bool
+		if name, ok = im.files[strings.TrimPrefix(file, "./")]; !ok {
+			if im.err == nil {
+				im.err = fmt.Errorf("jsonschema: schema %s: %s: reference %q is not to an imported schema", im.schema, path, ref)
+			}
+			return ref
+		}
+	}
+	if fragment != "" && !strings.HasPrefix(fragment, "/") {
+		if im.err == nil {
+			im.err = fmt.Errorf("jsonschema: schema %s: %s: reference %q is not a JSON pointer", im.schema, path, ref)
+		}
+		return ref
+	}
+	for _, defs := range []string{"/$defs/", "/definitions/"} {
+		if strings.HasPrefix(fragment, defs) {
+			return "#/definitions/" + strings.TrimPrefix(fragment, defs)
+		}
+	}
+	return "#" + jsonpointer.Join("/definitions", name) + fragment
+}
@@ -0,0 +1,220 @@
+/*
+Package jsonschema exports the definitions of a document as standalone JSON
+Schema documents, for validators and other tools which don't read Swagger.
+
+Each definition becomes a document named after it, and references between
+definitions are rewritten to refer to those documents: "#/definitions/Pet"
+becomes "Pet.json", resolved against Options.BaseURI if it's set. Keywords
+only Swagger knows, such as discriminator, xml and vendor extensions, are
+dropped, and the rest are converted to the chosen draft:
+
+	x-nullable: true        the type also allows null
+	example                 examples, from draft-07 on
+	exclusiveMaximum: true  exclusiveMaximum holding the maximum, from draft-07 on
+	readOnly                dropped for draft-04, which doesn't have it
+
+Import goes the other way, adding JSON Schema documents to a document's
+definitions so specs can be composed from existing schemas.
+*/
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/internal/jsonpointer"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// Draft is a version of JSON Schema.
+type Draft string
+
+// The drafts Export can write.
+const (
+	Draft04     Draft = "draft-04"
+	Draft07     Draft = "draft-07"
+	Draft202012 Draft = "2020-12"
+)
+
+var metaSchemas = map[Draft]string{
+	Draft04:     "http://json-schema.org/draft-04/schema#",
+	Draft07:     "http://json-schema.org/draft-07/schema#",
+	Draft202012: "https://json-schema.org/draft/2020-12/schema",
+}
+
+// Options configures Export. The zero value is valid.
+type Options struct {
+	// Draft is the version of JSON Schema to write. The default is Draft04,
+	// which Swagger's schemas are based on.
+	Draft Draft
+	// BaseURI, if set, is the URI the documents are published under, such as
+	// "https://example.com/schemas/". Each document's $id (or id, for
+	// draft-04) is its name resolved against it, and so are references.
+	// Without it, references are relative, such as "Pet.json".
+	BaseURI string
+}
+
+// Export converts each definition of a document to a draft-04 JSON Schema
+// document, keyed by the definition's name. Definitions which can't be
+// encoded as JSON are left out; Options.Export reports why.
+func Export(doc *spec.Swagger) map[string]json.RawMessage {
+	schemas, _ := Options{}.Export(doc)
+	return schemas
+}
+
+// Export converts each definition of a document to a JSON Schema document of
+// the chosen draft, keyed by the definition's name. It returns the documents
+// it could convert, and an error naming the first definition it couldn't.
+func (o Options) Export(doc *spec.Swagger) (map[string]json.RawMessage, error) {
+	if o.Draft == "" {
+		o.Draft = Draft04
+	}
+	meta, ok := metaSchemas[o.Draft]
+	if !ok {
+		return nil, fmt.Errorf("jsonschema: unknown draft %q", o.Draft)
+	}
+
+	names := make([]string, 0, len(doc.Definitions))
+	for name := range doc.Definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	schemas := make(map[string]json.RawMessage, len(names))
+	var firstErr error
+	for _, name := range names {
+		data, err := o.export(meta, name, doc.Definitions[name])
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("jsonschema: definition %s: %v", name, err)
+			}
+			continue
+		}
+		schemas[name] = data
+	}
+	return schemas, firstErr
+}
+
+func (o Options) export(meta, name string, s spec.Schema) (json.RawMessage, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	var v map[string]interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	v = o.convert(v)
+	v["$schema"] = meta
+	if o.BaseURI != "" {
+		id := "$id"
+		if o.Draft == Draft04 {
+			id = "id"
+		}
+		v[id] = o.BaseURI + fileName(name)
+	}
+	return json.Marshal(v)
+}
+
+// fileName returns the name of the document a definition is exported as.
+func fileName(name string) string {
+	return name + ".json"
+}
+
+// convert rewrites a schema and its subschemas for the draft.
+func (o Options) convert(s map[string]interface{}) map[string]interface{} {
+	for key, v := range s {
+		switch {
+		case key == "$ref":
+			if ref, ok := v.(string); ok {
+				s[key] = o.ref(ref)
+			}
+		case key == "properties" || key == "patternProperties" || key == "definitions":
+			if m, ok := v.(map[string]interface{}); ok {
+				for name, sub := range m {
+					if sub, ok := sub.(map[string]interface{}); ok {
+						m[name] = o.convert(sub)
+					}
+				}
+			}
+		case key == "items" || key == "additionalProperties" || key == "not":
+			if sub, ok := v.(map[string]interface{}); ok {
+				s[key] = o.convert(sub)
+			}
+		case key == "allOf" || key == "anyOf" || key == "oneOf":
+			if list, ok := v.([]interface{}); ok {
+				for i, sub := range list {
+					if sub, ok := sub.(map[string]interface{}); ok {
+						list[i] = o.convert(sub)
+					}
+				}
+			}
+		case key == "discriminator" || key == "xml" || key == "externalDocs":
+			delete(s, key)
+		case key == "x-nullable":
+			delete(s, key)
+			if nullable, _ := v.(bool); nullable {
+				allowNull(s)
+			}
+		case strings.HasPrefix(key, "x-"):
+			delete(s, key)
+		case key == "example":
+			delete(s, key)
+			if o.Draft != Draft04 {
+				s["examples"] = []interface{}{v}
+			}
+		case key == "readOnly":
+			if o.Draft == Draft04 {
+				delete(s, key)
+			}
+		}
+	}
+	if o.Draft != Draft04 {
+		exclusive(s, "exclusiveMaximum", "maximum")
+		exclusive(s, "exclusiveMinimum", "minimum")
+	}
+	return s
+}
+
+// ref rewrites a reference to a definition to refer to its exported document.
+// Other references are kept.
+func (o Options) ref(ref string) string {
+	if !strings.HasPrefix(ref, "#/definitions/") {
+		return ref
+	}
+	rest := strings.TrimPrefix(ref, "#/definitions/")
+	name, pointer := rest, ""
+	if i := strings.Index(rest, "/"); i >= 0 {
+		name, pointer = rest[:i], "#"+rest[i:]
+	}
+	return o.BaseURI + fileName(jsonpointer.Unescape(name)) + pointer
+}
+
+// allowNull changes a schema to also allow null.
+func allowNull(s map[string]interface{}) {
+	switch t := s["type"].(type) {
+	case string:
+		s["type"] = []interface{}{t, "null"}
+	case []interface{}:
+		s["type"] = append(t, "null")
+	}
+	if enum, ok := s["enum"].([]interface{}); ok {
+		s["enum"] = append(enum, nil)
+	}
+}
+
+// exclusive converts draft-04's boolean exclusiveMaximum or exclusiveMinimum
+// to the numeric form of later drafts.
+func exclusive(s map[string]interface{}, key, limit string) {
+	v, ok := s[key].(bool)
+	if !ok {
+		return
+	}
+	delete(s, key)
+	if n, ok := s[limit]; ok && v {
+		s[key] = n
+		delete(s, limit)
+	}
+}
@@ -0,0 +1,220 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+const petstore = `
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+paths: {}
+definitions:
+  Pet:
+    type: object
+    discriminator: kind
+    required: [name, kind]
+    properties:
+      name: {type: string, example: Rex}
+      kind: {type: string}
+      id: {type: integer, readOnly: true}
+      age: {type: integer, minimum: 0, maximum: 30, exclusiveMaximum: true}
+      owner: {$ref: '#/definitions/Owner'}
+      nickname: {type: string, x-nullable: true}
+    x-go-name: Animal
+  Owner:
+    type: object
+    properties:
+      pets: {type: array, items: {$ref: '#/definitions/Pet'}}
+      name: {$ref: '#/definitions/Pet/properties/name'}
+`
+
+func TestExport(t *testing.T) {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(petstore), &s); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		opts Options
+		want map[string]string
+	}{
+		{
+			Options{},
+			map[string]string{
+				"Pet": `{
+					"$schema": "http://json-schema.org/draft-04/schema#",
+					"type": "object",
+					"required": ["name", "kind"],
+					"properties": {
+						"name": {"type": "string"},
+						"kind": {"type": "string"},
+						"id": {"type": "integer"},
+						"age": {"type": "integer", "minimum": 0, "maximum": 30, "exclusiveMaximum": true},
+						"owner": {"$ref": "Owner.json"},
+						"nickname": {"type": ["string", "null"]}
+					}
+				}`,
+				"Owner": `{
+					"$schema": "http://json-schema.org/draft-04/schema#",
+					"type": "object",
+					"properties": {
+						"pets": {"type": "array", "items": {"$ref": "Pet.json"}},
+						"name": {"$ref": "Pet.json#/properties/name"}
+					}
+				}`,
+			},
+		},
+		{
+			Options{Draft: Draft202012, BaseURI: "https://example.com/schemas/"},
+			map[string]string{
+				"Pet": `{
+					"$schema": "https://json-schema.org/draft/2020-12/schema",
+					"$id": "https://example.com/schemas/Pet.json",
+					"type": "object",
+					"required": ["name", "kind"],
+					"properties": {
+						"name": {"type": "string", "examples": ["Rex"]},
+						"kind": {"type": "string"},
+						"id": {"type": "integer", "readOnly": true},
+						"age": {"type": "integer", "minimum": 0, "exclusiveMaximum": 30},
+						"owner": {"$ref": "https://example.com/schemas/Owner.json"},
+						"nickname": {"type": ["string", "null"]}
+					}
+				}`,
+				"Owner": `{
+					"$schema": "https://json-schema.org/draft/2020-12/schema",
+					"$id": "https://example.com/schemas/Owner.json",
+					"type": "object",
+					"properties": {
+						"pets": {"type": "array", "items": {"$ref": "https://example.com/schemas/Pet.json"}},
+						"name": {"$ref": "https://example.com/schemas/Pet.json#/properties/name"}
+					}
+				}`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		got, err := tt.opts.Export(&s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%+v: want %d schemas, got %d", tt.opts, len(tt.want), len(got))
+		}
+		for name, want := range tt.want {
+			var g, w interface{}
+			if err := json.Unmarshal(got[name], &g); err != nil {
+				t.Fatalf("%+v: %s: %v", tt.opts, name, err)
+			}
+			if err := json.Unmarshal([]byte(want), &w); err != nil {
+				t.Fatal(err)
+			}
+			if diff := pretty.Compare(g, w); diff != "" {
+				t.Errorf("%+v: %s: want != got: %s", tt.opts, name, diff)
+			}
+		}
+	}
+
+	if _, err := (Options{Draft: "draft-03"}).Export(&s); err == nil {
+		t.Errorf("expected an error for an unknown draft")
+	}
+}
+
+func TestImport(t *testing.T) {
+	schemas := map[string]json.RawMessage{
+		"Pet": json.RawMessage(`{
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"$id": "https://example.com/schemas/Pet.json",
+			"type": "object",
+			"required": ["name"],
+			"properties": {
+				"name": {"type": "string", "examples": ["Rex", "Fido"]},
+				"kind": {"const": "dog"},
+				"age": {"type": "integer", "minimum": 0, "exclusiveMaximum": 30},
+				"owner": {"anyOf": [{"$ref": "Owner.json"}, {"type": "null"}]},
+				"nickname": {"type": ["string", "null"]},
+				"tags": {"type": "array", "items": {"$ref": "#/$defs/Tag"}},
+				"size": {"oneOf": [{"type": "integer"}, {"type": "string"}]}
+			},
+			"$defs": {
+				"Tag": {"type": "string", "x-go-name": "Label"}
+			}
+		}`),
+		"Owner": json.RawMessage(`{
+			"$schema": "http://json-schema.org/draft-04/schema#",
+			"type": "object",
+			"properties": {
+				"pets": {"type": "array", "items": {"$ref": "https://example.com/schemas/Pet.json"}},
+				"name": {"$ref": "Pet.json#/properties/name"},
+				"friend": {"$ref": "#"}
+			}
+		}`),
+	}
+	var doc spec.Swagger
+	losses, err := Import(&doc, schemas)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantLosses := []string{"Pet.json#/properties/size/oneOf: oneOf is not supported"}
+	var gotLosses []string
+	for _, l := range losses {
+		gotLosses = append(gotLosses, l.String())
+	}
+	if diff := pretty.Compare(gotLosses, wantLosses); diff != "" {
+		t.Errorf("losses: want != got: %s", diff)
+	}
+
+	const want = `
+Pet:
+  type: object
+  required: [name]
+  properties:
+    name: {type: string, example: Rex}
+    kind: {enum: [dog]}
+    age: {type: integer, minimum: 0, maximum: 30, exclusiveMaximum: true}
+    owner: {allOf: [{$ref: '#/definitions/Owner'}], x-nullable: true}
+    nickname: {type: string, x-nullable: true}
+    tags: {type: array, items: {$ref: '#/definitions/Tag'}}
+    size: {}
+Owner:
+  type: object
+  properties:
+    pets: {type: array, items: {$ref: '#/definitions/Pet'}}
+    name: {$ref: '#/definitions/Pet/properties/name'}
+    friend: {$ref: '#/definitions/Owner'}
+Tag: {type: string, x-go-name: Label}
+`
+	var wantDefs spec.Definitions
+	if err := spec.UnmarshalYAML([]byte(want), &wantDefs); err != nil {
+		t.Fatal(err)
+	}
+	if diff := pretty.Compare(doc.Definitions, wantDefs); diff != "" {
+		t.Errorf("definitions: want != got: %s", diff)
+	}
+
+	errTests := []struct {
+		schemas map[string]json.RawMessage
+		want    string
+	}{
+		{
+			map[string]json.RawMessage{"Pet": json.RawMessage(`{"properties": {"owner": {"$ref": "Owner.json"}}}`)},
+			`jsonschema: schema Pet: /properties/owner/$ref: reference "Owner.json" is not to an imported schema`,
+		},
+		{
+			map[string]json.RawMessage{"Owner": json.RawMessage(`{"type": "object"}`)},
+			"jsonschema: definition Owner already exists",
+		},
+	}
+	for _, tt := range errTests {
+		_, err := Import(&doc, tt.schemas)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("want error %q, got %v", tt.want, err)
+		}
+	}
+}
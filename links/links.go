@@ -0,0 +1,224 @@
+/*
+Package links describes how the responses of one operation lead to others.
+
+Swagger 2.0 has no equivalent of OpenAPI 3.0's links, so responses declare
+them with the "x-links" extension, a map of names to 3.0 Link Objects:
+
+	/pets/{petId}:
+	  get:
+	    operationId: getPet
+	    responses:
+	      200:
+	        description: A pet.
+	        schema: {$ref: '#/definitions/Pet'}
+	        x-links:
+	          owner:
+	            operationId: getOwner
+	            parameters:
+	              ownerId: $response.body#/ownerId
+
+A link names the operation it calls by operationId, or by operationRef, a
+reference to the operation within the document such as
+"#/paths/~1owners~1{ownerId}/get". The values of its parameters and request
+body are either literals or runtime expressions, such as
+"$request.path.petId", "$response.header.Location" or
+"$response.body#/ownerId". Parameters are named as in the target operation,
+optionally prefixed by their location, such as "path.ownerId".
+*/
+package links
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/internal/jsonpointer"
+	"github.com/ericchiang/swaggopher/internal/mapkeys"
+	"github.com/ericchiang/swaggopher/spec"
+	"github.com/ericchiang/swaggopher/spec3"
+)
+
+// Extension is the vendor extension of a response declaring its links.
+const Extension = "x-links"
+
+// Parse returns the links a response declares, keyed by name, or an error if
+// they can't be decoded.
+func Parse(r *spec.Response) (map[string]spec3.Link, error) {
+	ext, ok := r.Extensions[Extension]
+	if !ok {
+		return nil, nil
+	}
+	data, err := json.Marshal(ext)
+	if err != nil {
+		return nil, err
+	}
+	var links map[string]spec3.Link
+	if err := json.Unmarshal(data, &links); err != nil {
+		return nil, fmt.Errorf("%s: %v", Extension, err)
+	}
+	return links, nil
+}
+
+// Expression is a runtime expression, which refers to part of the request or
+// response a link is followed from.
+type Expression struct {
+	// Source is "url", "method", "statusCode", "request" or "response".
+	Source string
+	// In is the part of a request or response the value is in: "path",
+	// "query", "header" or "body" for requests, and "header" or "body" for
+	// responses.
+	In string
+	// Name is the name of a parameter or header.
+	Name string
+	// Pointer is a JSON pointer into a body. It's empty for the whole body.
+	Pointer string
+}
+
+func (e Expression) String() string {
+	switch {
+	case e.In == "body" && e.Pointer == "":
+		return "$" + e.Source + ".body"
+	case e.In == "body":
+		return "$" + e.Source + ".body#" + e.Pointer
+	case e.In != "":
+		return "$" + e.Source + "." + e.In + "." + e.Name
+	}
+	return "$" + e.Source
+}
+
+// IsExpression reports if a link's parameter or request body value is a
+// runtime expression, rather than a literal.
+func IsExpression(v interface{}) bool {
+	s, ok := v.(string)
+	return ok && strings.HasPrefix(s, "$")
+}
+
+// ParseExpression parses a runtime expression, such as "$request.path.id".
+func ParseExpression(s string) (*Expression, error) {
+	switch s {
+	case "$url", "$method", "$statusCode":
+		return &Expression{Source: s[1:]}, nil
+	}
+	var e Expression
+	rest := ""
+	switch {
+	case strings.HasPrefix(s, "$request."):
+		e.Source, rest = "request", strings.TrimPrefix(s, "$request.")
+	case strings.HasPrefix(s, "$response."):
+		e.Source, rest = "response", strings.TrimPrefix(s, "$response.")
+	default:
+		return nil, fmt.Errorf("invalid runtime expression %q", s)
+	}
+	if rest == "body" || strings.HasPrefix(rest, "body#") {
+		e.In = "body"
+		e.Pointer = strings.TrimPrefix(strings.TrimPrefix(rest, "body"), "#")
+		if e.Pointer != "" && !strings.HasPrefix(e.Pointer, "/") {
+			return nil, fmt.Errorf("invalid JSON pointer in runtime expression %q", s)
+		}
+		return &e, nil
+	}
+	i := strings.Index(rest, ".")
+	if i < 0 || i == len(rest)-1 {
+		return nil, fmt.Errorf("invalid runtime expression %q", s)
+	}
+	e.In, e.Name = rest[:i], rest[i+1:]
+	switch {
+	case e.In == "header":
+	case e.Source == "request" && (e.In == "path" || e.In == "query"):
+	default:
+		return nil, fmt.Errorf("invalid runtime expression %q: unknown source %q", s, e.Source+"."+e.In)
+	}
+	return &e, nil
+}
+
+// Target returns the operation a link calls, along with its path and lower
+// case method.
+func Target(doc *spec.Swagger, l *spec3.Link) (path, method string, op *spec.Operation, err error) {
+	switch {
+	case l.OperationId != "" && l.OperationRef != "":
+		return "", "", nil, fmt.Errorf("operationId and operationRef are mutually exclusive")
+	case l.OperationId != "":
+		op, path, method, ok := doc.OperationByID(l.OperationId)
+		if !ok {
+			return "", "", nil, fmt.Errorf("no operation has operationId %q", l.OperationId)
+		}
+		return path, method, op, nil
+	case l.OperationRef != "":
+		tokens := jsonpointer.Split(l.OperationRef)
+		if !strings.HasPrefix(l.OperationRef, "#/paths/") || len(tokens) != 3 {
+			return "", "", nil, fmt.Errorf("operationRef %q must refer to an operation of this document", l.OperationRef)
+		}
+		item, ok := doc.Paths[tokens[1]]
+		if ok {
+			if op := item.Operation(tokens[2]); op != nil {
+				return tokens[1], tokens[2], op, nil
+			}
+		}
+		return "", "", nil, fmt.Errorf("operationRef %q doesn't refer to an operation", l.OperationRef)
+	}
+	return "", "", nil, fmt.Errorf("operationId or operationRef is required")
+}
+
+// Parameter returns the parameter of an operation a link's parameter sets,
+// given by name, or by location and name such as "path.id". Parameters
+// referenced from the document's parameters are resolved.
+func Parameter(doc *spec.Swagger, path string, op *spec.Operation, name string) (*spec.Parameter, bool) {
+	in := ""
+	if i := strings.Index(name, "."); i >= 0 {
+		switch name[:i] {
+		case "path", "query", "header", "formData":
+			in, name = name[:i], name[i+1:]
+		}
+	}
+	item := doc.Paths[path]
+	for _, p := range doc.OperationParameters(&item, op) {
+		if p.Name == name && p.In != "body" && (in == "" || p.In == in) {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// Body returns the body parameter of an operation, which a link's request body
+// sets.
+func Body(doc *spec.Swagger, path string, op *spec.Operation) (*spec.Parameter, bool) {
+	item := doc.Paths[path]
+	for _, p := range doc.OperationParameters(&item, op) {
+		if p.In == "body" {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// Check returns the problems with a link: an operation it calls which doesn't
+// exist, parameters the operation doesn't have, and invalid runtime
+// expressions. Messages are relative to the link.
+func Check(doc *spec.Swagger, l *spec3.Link) []string {
+	path, _, op, err := Target(doc, l)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	var problems []string
+	for _, name := range mapkeys.Sorted(l.Parameters) {
+		if _, ok := Parameter(doc, path, op, name); !ok {
+			problems = append(problems, fmt.Sprintf("parameter %q isn't a parameter of the target operation", name))
+		}
+		if v := l.Parameters[name]; IsExpression(v) {
+			if _, err := ParseExpression(v.(string)); err != nil {
+				problems = append(problems, fmt.Sprintf("parameter %q: %v", name, err))
+			}
+		}
+	}
+	if l.RequestBody != nil {
+		if _, ok := Body(doc, path, op); !ok {
+			problems = append(problems, "requestBody is set, but the target operation has no body parameter")
+		}
+		if IsExpression(l.RequestBody) {
+			if _, err := ParseExpression(l.RequestBody.(string)); err != nil {
+				problems = append(problems, fmt.Sprintf("requestBody: %v", err))
+			}
+		}
+	}
+	return problems
+}
@@ -0,0 +1,167 @@
+package links
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/ericchiang/swaggopher/spec"
+	"github.com/ericchiang/swaggopher/spec3"
+)
+
+const doc = `
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+paths:
+  /pets/{id}:
+    parameters:
+    - {name: id, in: path, required: true, type: integer}
+    get:
+      operationId: getPet
+      responses:
+        200:
+          description: A pet.
+          x-links:
+            owner:
+              operationId: getOwner
+              parameters:
+                ownerId: $response.body#/ownerId
+    put:
+      operationId: updatePet
+      parameters:
+      - {name: pet, in: body, schema: {type: object}}
+      - $ref: '#/parameters/trace'
+      responses:
+        200: {description: Updated.}
+  /owners/{ownerId}:
+    get:
+      operationId: getOwner
+      parameters:
+      - {name: ownerId, in: path, required: true, type: integer}
+      responses:
+        200: {description: An owner.}
+parameters:
+  trace: {name: X-Trace, in: header, type: string}
+`
+
+func parse(t *testing.T) *spec.Swagger {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(doc), &s); err != nil {
+		t.Fatal(err)
+	}
+	return &s
+}
+
+func TestParse(t *testing.T) {
+	s := parse(t)
+	r := s.Paths["/pets/{id}"].Get.Responses["200"]
+	got, err := Parse(&r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]spec3.Link{
+		"owner": {
+			OperationId: "getOwner",
+			Parameters:  map[string]interface{}{"ownerId": "$response.body#/ownerId"},
+		},
+	}
+	if diff := pretty.Compare(want, got); diff != "" {
+		t.Errorf("want != got: %s", diff)
+	}
+}
+
+func TestParseExpression(t *testing.T) {
+	tests := []struct {
+		expr    string
+		want    *Expression
+		wantErr bool
+	}{
+		{expr: "$statusCode", want: &Expression{Source: "statusCode"}},
+		{expr: "$request.path.id", want: &Expression{Source: "request", In: "path", Name: "id"}},
+		{expr: "$request.header.X-Trace", want: &Expression{Source: "request", In: "header", Name: "X-Trace"}},
+		{expr: "$response.body", want: &Expression{Source: "response", In: "body"}},
+		{expr: "$response.body#/owner/id", want: &Expression{Source: "response", In: "body", Pointer: "/owner/id"}},
+		{expr: "$response.path.id", wantErr: true},
+		{expr: "$response.body#owner", wantErr: true},
+		{expr: "$request.query", wantErr: true},
+		{expr: "$body", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseExpression(tt.expr)
+		if err != nil {
+			if !tt.wantErr {
+				t.Errorf("%s: %v", tt.expr, err)
+			}
+			continue
+		}
+		if tt.wantErr {
+			t.Errorf("%s: expected error", tt.expr)
+			continue
+		}
+		if diff := pretty.Compare(tt.want, got); diff != "" {
+			t.Errorf("%s: want != got: %s", tt.expr, diff)
+		}
+		if s := got.String(); s != tt.expr {
+			t.Errorf("%s: String() returned %q", tt.expr, s)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	s := parse(t)
+	tests := []struct {
+		link spec3.Link
+		want []string
+	}{
+		{
+			link: spec3.Link{
+				OperationId: "getOwner",
+				Parameters:  map[string]interface{}{"path.ownerId": "$response.body#/ownerId"},
+			},
+		},
+		{
+			// Path item parameters, referenced parameters and bodies.
+			link: spec3.Link{
+				OperationRef: "#/paths/~1pets~1{id}/put",
+				Parameters: map[string]interface{}{
+					"id":      "$request.path.id",
+					"X-Trace": "abc",
+				},
+				RequestBody: "$response.body",
+			},
+		},
+		{
+			link: spec3.Link{OperationId: "getOwner", OperationRef: "#/paths/~1owners~1{ownerId}/get"},
+			want: []string{"operationId and operationRef are mutually exclusive"},
+		},
+		{
+			link: spec3.Link{OperationRef: "#/paths/~1owners~1{ownerId}/post"},
+			want: []string{`operationRef "#/paths/~1owners~1{ownerId}/post" doesn't refer to an operation`},
+		},
+		{
+			link: spec3.Link{OperationRef: "other.yaml#/paths/~1owners/get"},
+			want: []string{`operationRef "other.yaml#/paths/~1owners/get" must refer to an operation of this document`},
+		},
+		{
+			link: spec3.Link{
+				OperationId: "getOwner",
+				Parameters:  map[string]interface{}{"query.ownerId": 1, "id": "$url.id"},
+				RequestBody: map[string]interface{}{"name": "x"},
+			},
+			want: []string{
+				`parameter "id" isn't a parameter of the target operation`,
+				`parameter "id": invalid runtime expression "$url.id"`,
+				`parameter "query.ownerId" isn't a parameter of the target operation`,
+				"requestBody is set, but the target operation has no body parameter",
+			},
+		},
+	}
+	for i, tt := range tests {
+		got := Check(s, &tt.link)
+		if diff := pretty.Compare(tt.want, got); diff != "" {
+			t.Errorf("case %d: want != got: %s", i, diff)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package lint
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+)
+
+// Baseline records findings that are already known, such as legacy problems
+// in an existing document, so that only newly introduced findings fail a
+// build. Findings are matched on their rule, path and message.
+type Baseline struct {
+	Findings []Finding `json:"findings"`
+}
+
+// NewBaseline returns a baseline recording every given finding.
+func NewBaseline(findings []Finding) *Baseline {
+	b := &Baseline{Findings: make([]Finding, len(findings))}
+	for i, f := range findings {
+		// Fixes aren't needed to match findings and would only be noise.
+		f.Fix = nil
+		b.Findings[i] = f
+	}
+	sortFindings(b.Findings)
+	return b
+}
+
+// ReadBaseline decodes a baseline written by Write.
+func ReadBaseline(r io.Reader) (*Baseline, error) {
+	var b Baseline
+	if err := json.NewDecoder(r).Decode(&b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// LoadBaseline reads a baseline file. If the file doesn't exist an empty
+// baseline is returned.
+func LoadBaseline(path string) (*Baseline, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Baseline{}, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	return ReadBaseline(f)
+}
+
+// Write encodes the baseline as indented JSON, suitable for checking in.
+func (b *Baseline) Write(w io.Writer) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// Save writes the baseline to a file.
+func (b *Baseline) Save(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Filter splits findings into those which are new and those which are already
+// recorded by the baseline. A baseline entry matches at most one finding, so a
+// problem which is duplicated after the baseline was taken is reported as new.
+func (b *Baseline) Filter(findings []Finding) (fresh, known []Finding) {
+	counts := b.counts()
+	for _, f := range findings {
+		if counts[f.key()] > 0 {
+			counts[f.key()]--
+			known = append(known, f)
+			continue
+		}
+		fresh = append(fresh, f)
+	}
+	return fresh, known
+}
+
+// Resolved returns the entries of the baseline which no longer appear in
+// findings.
+func (b *Baseline) Resolved(findings []Finding) []Finding {
+	counts := make(map[findingKey]int)
+	for _, f := range findings {
+		counts[f.key()]++
+	}
+	var resolved []Finding
+	for _, f := range b.Findings {
+		if counts[f.key()] > 0 {
+			counts[f.key()]--
+			continue
+		}
+		resolved = append(resolved, f)
+	}
+	return resolved
+}
+
+// Update returns a baseline with resolved entries removed. New findings are
+// not added, so the baseline only ever shrinks as legacy problems are fixed.
+// Use NewBaseline to accept every current finding.
+func (b *Baseline) Update(findings []Finding) *Baseline {
+	_, known := b.Filter(findings)
+	return NewBaseline(known)
+}
+
+func (b *Baseline) counts() map[findingKey]int {
+	counts := make(map[findingKey]int, len(b.Findings))
+	for _, f := range b.Findings {
+		counts[f.key()]++
+	}
+	return counts
+}
+
+// findingKey holds the fields a baseline matches findings on.
+type findingKey struct {
+	rule, path, message string
+}
+
+func (f Finding) key() findingKey {
+	return findingKey{f.Rule, f.Path, f.Message}
+}
+
+// sortFindings orders findings by path, then rule, then message, so baseline
+// files are stable across runs.
+func sortFindings(findings []Finding) {
+	sort.Slice(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		if a.Rule != b.Rule {
+			return a.Rule < b.Rule
+		}
+		return a.Message < b.Message
+	})
+}
@@ -0,0 +1,40 @@
+package lint
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestBaseline(t *testing.T) {
+	legacy := Finding{Rule: "operation-summary", Path: "/paths/~1pets/get", Message: "operation has no summary"}
+	fixed := Finding{Rule: "operation-summary", Path: "/paths/~1pets/post", Message: "operation has no summary"}
+	added := Finding{Rule: "operation-summary", Path: "/paths/~1users/get", Message: "operation has no summary"}
+
+	b := NewBaseline([]Finding{fixed, legacy})
+
+	var buf bytes.Buffer
+	if err := b.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ReadBaseline(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	current := []Finding{legacy, added, legacy}
+	fresh, known := b.Filter(current)
+	if diff := pretty.Compare(fresh, []Finding{added, legacy}); diff != "" {
+		t.Errorf("fresh: want != got: %s", diff)
+	}
+	if diff := pretty.Compare(known, []Finding{legacy}); diff != "" {
+		t.Errorf("known: want != got: %s", diff)
+	}
+	if diff := pretty.Compare(b.Resolved(current), []Finding{fixed}); diff != "" {
+		t.Errorf("resolved: want != got: %s", diff)
+	}
+	if diff := pretty.Compare(b.Update(current).Findings, []Finding{legacy}); diff != "" {
+		t.Errorf("update: want != got: %s", diff)
+	}
+}
@@ -0,0 +1,149 @@
+package lint
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/ericchiang/swaggopher/internal/jsonpointer"
+	"github.com/ericchiang/swaggopher/internal/mapkeys"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// BudgetRule is the ID of the rule which checks a document against a Budget.
+const BudgetRule = "budget"
+
+// Budget limits the size and complexity of a document, such as to keep it
+// within what the code generators its consumers use can handle. A zero limit
+// is unlimited. It's read from the budget of a configuration file:
+//
+//	budget:
+//	  maxProperties: 50
+//	  maxDepth: 5
+//	  maxOperationsPerTag: 30
+//	  maxEnum: 100
+type Budget struct {
+	// MaxProperties is the most properties a schema may declare.
+	MaxProperties int `json:"maxProperties,omitempty" yaml:"maxProperties,omitempty"`
+	// MaxDepth is the deepest schemas may nest through properties, items,
+	// additionalProperties and allOf. A schema without nested schemas has a
+	// depth of 1. References aren't followed, since the definitions they refer
+	// to are checked on their own.
+	MaxDepth int `json:"maxDepth,omitempty" yaml:"maxDepth,omitempty"`
+	// MaxOperationsPerTag is the most operations which may share a tag.
+	MaxOperationsPerTag int `json:"maxOperationsPerTag,omitempty" yaml:"maxOperationsPerTag,omitempty"`
+	// MaxEnum is the most values the enum of a schema or parameter may hold.
+	MaxEnum int `json:"maxEnum,omitempty" yaml:"maxEnum,omitempty"`
+}
+
+// NewBudgetRule returns a rule which reports the parts of a document over a
+// budget. Its ID is BudgetRule.
+func NewBudgetRule(b Budget) Rule {
+	return NewRule(BudgetRule, b.check)
+}
+
+func (b Budget) check(s *spec.Swagger) []Finding {
+	var findings []Finding
+	report := func(pointer, format string, args ...interface{}) {
+		findings = append(findings, Finding{Path: pointer, Message: fmt.Sprintf(format, args...)})
+	}
+
+	for _, name := range mapkeys.Sorted(s.Definitions) {
+		schema := s.Definitions[name]
+		b.schema(&schema, jsonpointer.Join("/definitions", name), 1, report)
+	}
+	for _, name := range mapkeys.Sorted(s.Parameters) {
+		p := s.Parameters[name]
+		b.parameter(&p, jsonpointer.Join("/parameters", name), report)
+	}
+	for _, name := range mapkeys.Sorted(s.Responses) {
+		if schema := s.Responses[name].Schema; schema != nil {
+			b.schema(schema, jsonpointer.Join("/responses", name, "schema"), 1, report)
+		}
+	}
+	for _, path := range mapkeys.Sorted(s.Paths) {
+		for i := range s.Paths[path].Parameters {
+			b.parameter(&s.Paths[path].Parameters[i], jsonpointer.Join("/paths", path, "parameters", strconv.Itoa(i)), report)
+		}
+	}
+	for _, op := range s.SortedOperations() {
+		for i := range op.Parameters {
+			b.parameter(&op.Parameters[i], jsonpointer.Join(op.Pointer(), "parameters", strconv.Itoa(i)), report)
+		}
+		for _, code := range mapkeys.Sorted(op.Responses) {
+			if schema := op.Responses[code].Schema; schema != nil {
+				b.schema(schema, jsonpointer.Join(op.Pointer(), "responses", code, "schema"), 1, report)
+			}
+		}
+	}
+
+	if b.MaxOperationsPerTag > 0 {
+		declared := make(map[string]string)
+		for i, t := range s.Tags {
+			declared[t.Name] = jsonpointer.Join("/tags", strconv.Itoa(i))
+		}
+		counts := make(map[string]int)
+		// over holds the tags over the budget, in the order they went over.
+		var over []string
+		for _, op := range s.SortedOperations() {
+			for i, t := range op.Tags {
+				counts[t]++
+				if counts[t] != b.MaxOperationsPerTag+1 {
+					continue
+				}
+				over = append(over, t)
+				// Tags are reported where they're declared, or otherwise on
+				// the first operation over the budget.
+				if _, ok := declared[t]; !ok {
+					declared[t] = jsonpointer.Join(op.Pointer(), "tags", strconv.Itoa(i))
+				}
+			}
+		}
+		for _, t := range over {
+			report(declared[t], "tag %q has %d operations, more than the budget of %d", t, counts[t], b.MaxOperationsPerTag)
+		}
+	}
+	return findings
+}
+
+// parameter checks the schema of a body parameter, or the enum of any other.
+func (b Budget) parameter(p *spec.Parameter, pointer string, report func(pointer, format string, args ...interface{})) {
+	if p.Schema != nil {
+		b.schema(p.Schema, jsonpointer.Join(pointer, "schema"), 1, report)
+		return
+	}
+	b.enum(len(p.Enum), pointer, report)
+}
+
+func (b Budget) schema(s *spec.Schema, pointer string, depth int, report func(pointer, format string, args ...interface{})) {
+	if s.Ref != "" {
+		return
+	}
+	if b.MaxDepth > 0 && depth > b.MaxDepth {
+		report(pointer, "schema is nested %d deep, more than the budget of %d", depth, b.MaxDepth)
+		return
+	}
+	if b.MaxProperties > 0 && len(s.Properties) > b.MaxProperties {
+		report(pointer, "schema has %d properties, more than the budget of %d", len(s.Properties), b.MaxProperties)
+	}
+	b.enum(len(s.Enum), pointer, report)
+
+	for _, name := range mapkeys.Sorted(s.Properties) {
+		prop := s.Properties[name]
+		b.schema(&prop, jsonpointer.Join(pointer, "properties", name), depth+1, report)
+	}
+	if s.Items != nil {
+		b.schema(s.Items, jsonpointer.Join(pointer, "items"), depth+1, report)
+	}
+	if ap := s.AdditionalProperties; ap != nil && ap.Schema != nil {
+		b.schema(ap.Schema, jsonpointer.Join(pointer, "additionalProperties"), depth+1, report)
+	}
+	for i := range s.AllOf {
+		b.schema(&s.AllOf[i], jsonpointer.Join(pointer, "allOf", strconv.Itoa(i)), depth+1, report)
+	}
+}
+
+func (b Budget) enum(n int, pointer string, report func(pointer, format string, args ...interface{})) {
+	if b.MaxEnum > 0 && n > b.MaxEnum {
+		report(pointer, "enum has %d values, more than the budget of %d", n, b.MaxEnum)
+	}
+}
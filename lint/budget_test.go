@@ -0,0 +1,125 @@
+package lint
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+func TestBudget(t *testing.T) {
+	var s spec.Swagger
+	err := yaml.Unmarshal([]byte(`
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+tags:
+- name: pets
+paths:
+  /pets:
+    get:
+      tags: [pets, store]
+      parameters:
+      - {name: sort, in: query, type: string, enum: [name, age, weight]}
+      responses:
+        200:
+          description: OK
+          schema:
+            type: array
+            items:
+              type: object
+              properties:
+                owner: {type: object, properties: {name: {type: string}}}
+    post:
+      tags: [pets, store]
+      responses: {201: {description: Created}}
+  /pets/{id}:
+    get:
+      tags: [pets, store]
+      responses: {200: {description: OK, schema: {$ref: '#/definitions/Pet'}}}
+definitions:
+  Pet:
+    type: object
+    properties:
+      id: {type: integer}
+      name: {type: string}
+      status: {type: string, enum: [available, sold]}
+`), &s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rs := Recommended()
+	if got := rs.Check(&s); len(filter(got, BudgetRule)) != 0 {
+		t.Errorf("expected no budget findings without a budget, got %v", got)
+	}
+	config, err := ParseConfig([]byte(`
+budget:
+  maxProperties: 2
+  maxDepth: 3
+  maxOperationsPerTag: 2
+  maxEnum: 2
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.Configure(config); err != nil {
+		t.Fatal(err)
+	}
+	want := []Finding{
+		{
+			Rule:     BudgetRule,
+			Path:     "/definitions/Pet",
+			Message:  "schema has 3 properties, more than the budget of 2",
+			Severity: Warning,
+		},
+		{
+			Rule:     BudgetRule,
+			Path:     "/paths/~1pets/get/parameters/0",
+			Message:  "enum has 3 values, more than the budget of 2",
+			Severity: Warning,
+		},
+		{
+			Rule:     BudgetRule,
+			Path:     "/paths/~1pets/get/responses/200/schema/items/properties/owner/properties/name",
+			Message:  "schema is nested 4 deep, more than the budget of 3",
+			Severity: Warning,
+		},
+		{
+			Rule:     BudgetRule,
+			Path:     "/paths/~1pets~1{id}/get/tags/1",
+			Message:  `tag "store" has 3 operations, more than the budget of 2`,
+			Severity: Warning,
+		},
+		{
+			Rule:     BudgetRule,
+			Path:     "/tags/0",
+			Message:  `tag "pets" has 3 operations, more than the budget of 2`,
+			Severity: Warning,
+		},
+	}
+	if diff := pretty.Compare(filter(rs.Check(&s), BudgetRule), want); diff != "" {
+		t.Errorf("want != got: %s", diff)
+	}
+
+	// A budget is also registered in a rule set without the rule.
+	rs = NewRuleSet()
+	if err := rs.Configure(&Config{Budget: &Budget{MaxEnum: 1}}); err != nil {
+		t.Fatal(err)
+	}
+	if got := rs.Check(&s); len(got) != 2 {
+		t.Errorf("expected 2 enum findings, got %v", got)
+	}
+}
+
+func filter(findings []Finding, rule string) []Finding {
+	var matched []Finding
+	for _, f := range findings {
+		if f.Rule == rule {
+			matched = append(matched, f)
+		}
+	}
+	return matched
+}
@@ -0,0 +1,177 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/ericchiang/swaggopher/internal/jsonpointer"
+	"github.com/ericchiang/swaggopher/internal/rawdoc"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// PatchOperation is a single RFC 6902 JSON Patch operation. Only the "add",
+// "replace" and "remove" operations are produced by this package.
+type PatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// Fixable returns the findings which have a fix.
+func Fixable(findings []Finding) []Finding {
+	var fixable []Finding
+	for _, f := range findings {
+		if len(f.Fix) > 0 {
+			fixable = append(fixable, f)
+		}
+	}
+	return fixable
+}
+
+// ApplyFixes applies the fixes of the given findings to a JSON or YAML document
+// and returns the updated document in the same format. Findings without a fix
+// are ignored.
+//
+// The document is decoded with spec.UnmarshalLossless and written back in its
+// original key order, with fields outside the specification kept, so only the
+// values the fixes change differ. Comments and formatting aren't preserved.
+func ApplyFixes(data []byte, findings []Finding) ([]byte, error) {
+	var orig spec.Swagger
+	if err := spec.UnmarshalLossless(data, &orig); err != nil {
+		return nil, err
+	}
+	encoded, err := json.Marshal(&orig)
+	if err != nil {
+		return nil, err
+	}
+	var doc interface{}
+	if err := json.Unmarshal(encoded, &doc); err != nil {
+		return nil, err
+	}
+	for _, f := range findings {
+		for _, op := range f.Fix {
+			if doc, err = applyPatch(doc, op); err != nil {
+				return nil, fmt.Errorf("lint: fixing %s: %s %s: %v", f, op.Op, op.Path, err)
+			}
+		}
+	}
+	if encoded, err = json.Marshal(doc); err != nil {
+		return nil, err
+	}
+	var fixed spec.Swagger
+	if err := spec.UnmarshalLossless(encoded, &fixed); err != nil {
+		return nil, err
+	}
+	// Keys the fixes added follow those of the original document.
+	fixed.KeyOrder = orig.KeyOrder
+
+	if rawdoc.IsJSON(data) {
+		out, err := json.MarshalIndent(&fixed, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(out, '\n'), nil
+	}
+	return yaml.Marshal(&fixed)
+}
+
+// applyPatch applies a single operation, returning the new root value.
+func applyPatch(doc interface{}, op PatchOperation) (interface{}, error) {
+	tokens := jsonpointer.Split(op.Path)
+	if len(tokens) == 0 {
+		switch op.Op {
+		case "add", "replace":
+			return op.Value, nil
+		}
+		return nil, fmt.Errorf("cannot %s the document root", op.Op)
+	}
+
+	parent := doc
+	for _, token := range tokens[:len(tokens)-1] {
+		child, ok := lookup(parent, token)
+		if !ok {
+			return nil, fmt.Errorf("no value at %s", op.Path)
+		}
+		parent = child
+	}
+	last := tokens[len(tokens)-1]
+
+	switch parent := parent.(type) {
+	case map[string]interface{}:
+		_, exists := parent[last]
+		switch op.Op {
+		case "add":
+			parent[last] = op.Value
+		case "replace":
+			if !exists {
+				return nil, fmt.Errorf("no value at %s", op.Path)
+			}
+			parent[last] = op.Value
+		case "remove":
+			if !exists {
+				return nil, fmt.Errorf("no value at %s", op.Path)
+			}
+			delete(parent, last)
+		default:
+			return nil, fmt.Errorf("unsupported operation")
+		}
+		return doc, nil
+	case []interface{}:
+		// Arrays change length, so the updated array must be stored back in
+		// its own parent.
+		var updated []interface{}
+		i, err := arrayIndex(last, len(parent), op.Op == "add")
+		if err != nil {
+			return nil, err
+		}
+		switch op.Op {
+		case "add":
+			updated = append(append(append([]interface{}{}, parent[:i]...), op.Value), parent[i:]...)
+		case "replace":
+			parent[i] = op.Value
+			return doc, nil
+		case "remove":
+			updated = append(append([]interface{}{}, parent[:i]...), parent[i+1:]...)
+		default:
+			return nil, fmt.Errorf("unsupported operation")
+		}
+		parentPath := jsonpointer.Join("", tokens[:len(tokens)-1]...)
+		return applyPatch(doc, PatchOperation{Op: "replace", Path: parentPath, Value: updated})
+	}
+	return nil, fmt.Errorf("no value at %s", op.Path)
+}
+
+func lookup(v interface{}, token string) (interface{}, bool) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		child, ok := v[token]
+		return child, ok
+	case []interface{}:
+		i, err := arrayIndex(token, len(v), false)
+		if err != nil {
+			return nil, false
+		}
+		return v[i], true
+	}
+	return nil, false
+}
+
+// arrayIndex parses an array reference token. If adding, the index may refer
+// to the end of the array.
+func arrayIndex(token string, n int, adding bool) (int, error) {
+	if adding && token == "-" {
+		return n, nil
+	}
+	i, err := strconv.Atoi(token)
+	max := n - 1
+	if adding {
+		max = n
+	}
+	if err != nil || i < 0 || i > max {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	return i, nil
+}
@@ -0,0 +1,158 @@
+package lint
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+func TestCheckAndFix(t *testing.T) {
+	data := []byte(`
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+paths:
+  /pets/{petId}:
+    get:
+      summary: Get a pet.
+      description: Get a pet.
+      responses: {200: {description: OK}}
+    delete:
+      operationId: Delete_Pet
+      summary: Delete a pet.
+      responses: {204: {description: Deleted.}}
+    put:
+      operationId: updatePetByID
+      summary: Update a pet.
+      description: Update a pet.
+      responses: {200: {description: OK}}
+`)
+	var s spec.Swagger
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		t.Fatal(err)
+	}
+	want := []Finding{
+		{
+			Rule:     "operation-description",
+			Path:     "/paths/~1pets~1{petId}/delete",
+			Message:  "operation has no description",
+			Severity: Warning,
+			Fix:      []PatchOperation{{Op: "add", Path: "/paths/~1pets~1{petId}/delete/description", Value: DescriptionPlaceholder}},
+		},
+		{
+			Rule:     "operation-id-casing",
+			Path:     "/paths/~1pets~1{petId}/delete/operationId",
+			Message:  `operationId "Delete_Pet" is not lowerCamelCase`,
+			Severity: Warning,
+			Fix:      []PatchOperation{{Op: "replace", Path: "/paths/~1pets~1{petId}/delete/operationId", Value: "deletePet"}},
+		},
+		{
+			Rule:     "operation-id",
+			Path:     "/paths/~1pets~1{petId}/get",
+			Message:  "operation has no operationId",
+			Severity: Error,
+			Fix:      []PatchOperation{{Op: "add", Path: "/paths/~1pets~1{petId}/get/operationId", Value: "getPetsPetId"}},
+		},
+	}
+	findings := Check(&s)
+	if diff := pretty.Compare(findings, want); diff != "" {
+		t.Fatalf("want != got: %s", diff)
+	}
+
+	fixed, err := ApplyFixes(data, findings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var after spec.Swagger
+	if err := yaml.Unmarshal(fixed, &after); err != nil {
+		t.Fatal(err)
+	}
+	if findings := Check(&after); len(findings) != 0 {
+		t.Errorf("expected fixes to resolve all findings, got %v", findings)
+	}
+	if got := after.Paths["/pets/{petId}"].Get.OperationId; got != "getPetsPetId" {
+		t.Errorf("expected operationId getPetsPetId, got %q", got)
+	}
+}
+
+func TestApplyFixesKeepsOrder(t *testing.T) {
+	data := []byte(`{
+  "swagger": "2.0",
+  "info": {"version": "1.0", "title": "Pets"},
+  "paths": {
+    "/pets": {"get": {"responses": {"200": {"description": "OK"}}, "operationId": "ListPets", "owner": "pets"}}
+  }
+}`)
+	fixed, err := ApplyFixes(data, []Finding{{Fix: []PatchOperation{
+		{Op: "replace", Path: "/paths/~1pets/get/operationId", Value: "listPets"},
+		{Op: "add", Path: "/paths/~1pets/get/description", Value: "List pets."},
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Keys keep their order, fields outside the specification are kept, and
+	// added keys follow the rest.
+	want := `{
+  "swagger": "2.0",
+  "info": {
+    "version": "1.0",
+    "title": "Pets"
+  },
+  "paths": {
+    "/pets": {
+      "get": {
+        "responses": {
+          "200": {
+            "description": "OK"
+          }
+        },
+        "operationId": "listPets",
+        "owner": "pets",
+        "description": "List pets."
+      }
+    }
+  }
+}
+`
+	if got := string(fixed); got != want {
+		t.Errorf("want %s, got %s", want, got)
+	}
+}
+
+func TestApplyPatch(t *testing.T) {
+	tests := []struct {
+		doc     string
+		op      PatchOperation
+		want    string
+		wantErr bool
+	}{
+		{doc: `{"a":{}}`, op: PatchOperation{Op: "add", Path: "/a/b", Value: 1.0}, want: `{"a":{"b":1}}`},
+		{doc: `{"a":[1,3]}`, op: PatchOperation{Op: "add", Path: "/a/1", Value: 2.0}, want: `{"a":[1,2,3]}`},
+		{doc: `{"a":[1]}`, op: PatchOperation{Op: "add", Path: "/a/-", Value: 2.0}, want: `{"a":[1,2]}`},
+		{doc: `{"a":[1,2]}`, op: PatchOperation{Op: "remove", Path: "/a/0"}, want: `{"a":[2]}`},
+		{doc: `{"a~b":1}`, op: PatchOperation{Op: "replace", Path: "/a~0b", Value: 2.0}, want: `{"a~b":2}`},
+		{doc: `{"a":1}`, op: PatchOperation{Op: "replace", Path: "/b", Value: 2.0}, wantErr: true},
+		{doc: `{"a":[1]}`, op: PatchOperation{Op: "add", Path: "/a/5", Value: 2.0}, wantErr: true},
+		{doc: `{}`, op: PatchOperation{Op: "add", Path: "/a/b", Value: 2.0}, wantErr: true},
+	}
+	for i, tt := range tests {
+		got, err := ApplyFixes([]byte(tt.doc), []Finding{{Fix: []PatchOperation{tt.op}}})
+		if err != nil {
+			if !tt.wantErr {
+				t.Errorf("case %d: unexpected error: %v", i, err)
+			}
+			continue
+		}
+		if tt.wantErr {
+			t.Errorf("case %d: expected error", i)
+			continue
+		}
+		want, _ := ApplyFixes([]byte(tt.want), nil)
+		if string(got) != string(want) {
+			t.Errorf("case %d: want=%s, got=%s", i, want, got)
+		}
+	}
+}
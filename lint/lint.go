@@ -0,0 +1,52 @@
+/*
+Package lint reports style and quality problems in Swagger documents.
+
+Each problem is checked by a Rule, and rules are collected in a RuleSet which
+assigns them a severity. Recommended returns the built in rules, to which custom
+rules can be added:
+
+	rs := lint.Recommended()
+	rs.Register(lint.NewRule("response-examples", checkExamples), lint.Hint)
+	rs.SetSeverity("kebab-case-paths", lint.Off)
+	findings := rs.Check(doc)
+*/
+package lint
+
+import (
+	"fmt"
+
+	"github.com/ericchiang/swaggopher/validate"
+)
+
+// Finding is a single problem reported against a document.
+type Finding struct {
+	// Rule is the ID of the rule that produced the finding.
+	Rule string `json:"rule"`
+	// Path is a JSON pointer to the offending value.
+	Path string `json:"path"`
+	// Message describes the problem.
+	Message string `json:"message"`
+	// Severity is the severity the rule was configured with.
+	Severity Severity `json:"severity,omitempty"`
+	// Fix, if set, is a JSON Patch which resolves the finding. See ApplyFixes.
+	Fix []PatchOperation `json:"fix,omitempty"`
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s (%s)", f.Path, f.Message, f.Rule)
+}
+
+// ValidationRule is the rule ID given to findings converted from validation
+// errors.
+const ValidationRule = "validation"
+
+// FromValidation converts validation errors to findings so they can be
+// reported, baselined and suppressed alongside lint findings. The findings have
+// Error severity.
+func FromValidation(errs []validate.ValidationError) []Finding {
+	findings := make([]Finding, len(errs))
+	for i, err := range errs {
+		findings[i] = Finding{Rule: ValidationRule, Path: err.Path, Message: err.Message, Severity: Error}
+	}
+	return findings
+}
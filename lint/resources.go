@@ -0,0 +1,24 @@
+package lint
+
+import (
+	"github.com/ericchiang/swaggopher/catalog"
+	"github.com/ericchiang/swaggopher/internal/jsonpointer"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// resourceCoverage reports resources whose verbs are asymmetric, such as a
+// resource which can be updated but not read. Findings are reported on the
+// member path of the resource if it has one.
+func resourceCoverage(s *spec.Swagger) []Finding {
+	var findings []Finding
+	for _, r := range catalog.Resources(s) {
+		path := r.Item
+		if path == "" {
+			path = r.Collection
+		}
+		for _, gap := range r.Gaps() {
+			findings = append(findings, Finding{Path: jsonpointer.Join("/paths", path), Message: gap})
+		}
+	}
+	return findings
+}
@@ -0,0 +1,36 @@
+package lint
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+func TestResourceCoverage(t *testing.T) {
+	var s spec.Swagger
+	err := yaml.Unmarshal([]byte(`
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+paths:
+  /pets:
+    get: {responses: {200: {description: OK}}}
+  /pets/{petId}:
+    delete: {responses: {204: {description: Deleted}}}
+  /settings:
+    put: {responses: {200: {description: OK}}}
+`), &s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Finding{
+		{Path: "/paths/~1pets~1{petId}", Message: "resource /pets can be deleted but not read"},
+		{Path: "/paths/~1settings", Message: "resource /settings can be updated but not read"},
+	}
+	if diff := pretty.Compare(resourceCoverage(&s), want); diff != "" {
+		t.Errorf("want != got: %s", diff)
+	}
+}
@@ -0,0 +1,67 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/internal/jsonpointer"
+	"github.com/ericchiang/swaggopher/internal/mapkeys"
+	"github.com/ericchiang/swaggopher/router"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// RoutingRule is the ID of the rule which checks a document's paths against
+// the policies of a router.
+const RoutingRule = "routing"
+
+// NewRoutingRule returns a rule which reports paths a router with the given
+// options can't tell apart: paths which differ only in a trailing slash, unless
+// trailing slashes are strict or redirected, and paths which differ only in the
+// case of a literal segment, if case is ignored or redirected. Its ID is
+// RoutingRule.
+func NewRoutingRule(opts router.Options) Rule {
+	return NewRule(RoutingRule, func(s *spec.Swagger) []Finding {
+		return routing(s, opts)
+	})
+}
+
+var templateVariable = regexp.MustCompile(`\{[^{}]*\}`)
+
+func routing(s *spec.Swagger, opts router.Options) []Finding {
+	ignoreSlash := opts.TrailingSlash == "" || opts.TrailingSlash == router.IgnoreSlash
+	foldCase := opts.Case == router.IgnoreCase || opts.Case == router.RedirectCase
+
+	var findings []Finding
+	first := make(map[string]string)
+	for _, path := range mapkeys.Sorted(s.Paths) {
+		key := templateVariable.ReplaceAllString(path, "{}")
+		if ignoreSlash && len(key) > 1 {
+			key = strings.TrimSuffix(key, "/")
+		}
+		if foldCase {
+			key = strings.ToLower(key)
+		}
+		prev, ok := first[key]
+		if !ok {
+			first[key] = path
+			continue
+		}
+		a, b := templateVariable.ReplaceAllString(prev, "{}"), templateVariable.ReplaceAllString(path, "{}")
+		var diffs []string
+		if strings.TrimSuffix(a, "/") != strings.TrimSuffix(b, "/") {
+			diffs = append(diffs, "case")
+		}
+		if strings.HasSuffix(a, "/") != strings.HasSuffix(b, "/") {
+			diffs = append(diffs, "a trailing slash")
+		}
+		// Paths differing only in their variables' names are reported by
+		// validation.
+		if len(diffs) == 0 {
+			continue
+		}
+		msg := fmt.Sprintf("path %s differs from %s only in %s, which the router ignores", path, prev, strings.Join(diffs, " and "))
+		findings = append(findings, Finding{Path: jsonpointer.Join("/paths", path), Message: msg})
+	}
+	return findings
+}
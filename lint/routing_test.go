@@ -0,0 +1,52 @@
+package lint
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/ericchiang/swaggopher/router"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+func TestRouting(t *testing.T) {
+	var s spec.Swagger
+	err := yaml.Unmarshal([]byte(`
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+paths:
+  /pets: {}
+  /pets/: {}
+  /Pets/{id}: {}
+  /pets/{petId}: {}
+  /owners: {}
+  /Owners/: {}
+`), &s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		opts router.Options
+		want []Finding
+	}{
+		{router.Options{}, []Finding{
+			{Path: "/paths/~1pets~1", Message: "path /pets/ differs from /pets only in a trailing slash, which the router ignores"},
+		}},
+		{router.Options{TrailingSlash: router.StrictSlash}, nil},
+		{router.Options{TrailingSlash: router.RedirectSlash, Case: router.IgnoreCase}, []Finding{
+			{Path: "/paths/~1pets~1{petId}", Message: "path /pets/{petId} differs from /Pets/{id} only in case, which the router ignores"},
+		}},
+		{router.Options{Case: router.RedirectCase}, []Finding{
+			{Path: "/paths/~1owners", Message: "path /owners differs from /Owners/ only in case and a trailing slash, which the router ignores"},
+			{Path: "/paths/~1pets~1", Message: "path /pets/ differs from /pets only in a trailing slash, which the router ignores"},
+			{Path: "/paths/~1pets~1{petId}", Message: "path /pets/{petId} differs from /Pets/{id} only in case, which the router ignores"},
+		}},
+	}
+	for i, tt := range tests {
+		if diff := pretty.Compare(NewRoutingRule(tt.opts).Check(&s), tt.want); diff != "" {
+			t.Errorf("case %d: want != got: %s", i, diff)
+		}
+	}
+}
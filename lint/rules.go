@@ -0,0 +1,243 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/ericchiang/swaggopher/internal/jsonpointer"
+	"github.com/ericchiang/swaggopher/internal/mapkeys"
+	"github.com/ericchiang/swaggopher/router"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// DescriptionPlaceholder is the description added by fixes for undocumented
+// operations. It's easy to search for when filling in the real thing.
+const DescriptionPlaceholder = "TODO: describe this operation."
+
+// Check runs the Recommended rules against a document.
+func Check(s *spec.Swagger) []Finding {
+	return Recommended().Check(s)
+}
+
+// Recommended returns a rule set holding the built in rules:
+//
+//	operation-id           error  every operation has an operationId
+//	operation-id-casing    warn   operationIds are lowerCamelCase
+//	operation-description  warn   every operation has a description
+//	operation-summary      warn   every operation has a summary
+//	operation-tag-defined  warn   operation tags are declared by the top level tags
+//	kebab-case-paths       info   path segments are kebab-case
+//	budget                 warn   the document is within a Budget
+//	routing                warn   the router can tell every path apart
+//	resource-coverage      hint   resources can be read if they can be changed
+//
+// Findings of the operation-id, operation-id-casing and operation-description
+// rules carry fixes which can be applied with ApplyFixes. Custom rules can be
+// registered alongside the built in ones, and any rule's severity changed. The
+// budget rule's Budget is unlimited, so it reports nothing until one is set by
+// Configure. The routing rule assumes a router with the default options until
+// Configure sets others.
+func Recommended() *RuleSet {
+	rs := NewRuleSet()
+	for _, r := range []struct {
+		rule Rule
+		sev  Severity
+	}{
+		{NewRule("operation-id", operationIDRule("operation-id")), Error},
+		{NewRule("operation-id-casing", operationIDRule("operation-id-casing")), Warning},
+		{NewRule("operation-description", operationDescription), Warning},
+		{NewRule("operation-summary", operationSummary), Warning},
+		{NewRule("operation-tag-defined", operationTagDefined), Warning},
+		{NewRule("kebab-case-paths", kebabCasePaths), Info},
+		{NewBudgetRule(Budget{}), Warning},
+		{NewRoutingRule(router.Options{}), Warning},
+		{NewRule("resource-coverage", resourceCoverage), Hint},
+	} {
+		if err := rs.Register(r.rule, r.sev); err != nil {
+			panic(err)
+		}
+	}
+	return rs
+}
+
+// operationIDRule returns the findings of a single operationId rule. Missing
+// and badly cased IDs are checked together so that generated and renamed IDs
+// never collide with each other.
+func operationIDRule(rule string) func(s *spec.Swagger) []Finding {
+	return func(s *spec.Swagger) []Finding {
+		var findings []Finding
+		for _, f := range operationIDs(s) {
+			if f.Rule == rule {
+				findings = append(findings, f)
+			}
+		}
+		return findings
+	}
+}
+
+func operationIDs(s *spec.Swagger) []Finding {
+	var findings []Finding
+
+	ops := s.SortedOperations()
+	ids := make(map[string]bool)
+	for _, op := range ops {
+		ids[op.OperationId] = true
+	}
+	for _, op := range ops {
+		if op.OperationId == "" {
+			id := uniqueID(camelCase(op.Method+" "+op.Path), ids)
+			findings = append(findings, Finding{
+				Rule:    "operation-id",
+				Path:    op.Pointer(),
+				Message: "operation has no operationId",
+				Fix:     []PatchOperation{{Op: "add", Path: jsonpointer.Join(op.Pointer(), "operationId"), Value: id}},
+			})
+		} else if !isLowerCamelCase(op.OperationId) {
+			id := camelCase(op.OperationId)
+			f := Finding{
+				Rule:    "operation-id-casing",
+				Path:    jsonpointer.Join(op.Pointer(), "operationId"),
+				Message: fmt.Sprintf("operationId %q is not lowerCamelCase", op.OperationId),
+			}
+			// Renaming onto an existing ID would break uniqueness, so such
+			// findings must be fixed by hand.
+			if id != "" && !ids[id] {
+				ids[id] = true
+				f.Fix = []PatchOperation{{Op: "replace", Path: f.Path, Value: id}}
+			}
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+func operationDescription(s *spec.Swagger) []Finding {
+	var findings []Finding
+	for _, op := range s.SortedOperations() {
+		if op.Description == "" {
+			findings = append(findings, Finding{
+				Path:    op.Pointer(),
+				Message: "operation has no description",
+				Fix:     []PatchOperation{{Op: "add", Path: jsonpointer.Join(op.Pointer(), "description"), Value: DescriptionPlaceholder}},
+			})
+		}
+	}
+	return findings
+}
+
+func operationSummary(s *spec.Swagger) []Finding {
+	var findings []Finding
+	for _, op := range s.SortedOperations() {
+		if op.Summary == "" {
+			findings = append(findings, Finding{Path: op.Pointer(), Message: "operation has no summary"})
+		}
+	}
+	return findings
+}
+
+func operationTagDefined(s *spec.Swagger) []Finding {
+	defined := make(map[string]bool)
+	for _, t := range s.Tags {
+		defined[t.Name] = true
+	}
+	var findings []Finding
+	for _, op := range s.SortedOperations() {
+		for i, t := range op.Tags {
+			if !defined[t] {
+				findings = append(findings, Finding{
+					Path:    jsonpointer.Join(op.Pointer(), "tags", strconv.Itoa(i)),
+					Message: fmt.Sprintf("tag %q is not defined at the top level", t),
+				})
+			}
+		}
+	}
+	return findings
+}
+
+// kebabSegment matches a path segment made up of lower case words separated by
+// hyphens, such as "pet-owners". Dots are allowed for extensions like
+// "openapi.json".
+var kebabSegment = regexp.MustCompile(`^[a-z0-9]+([-.][a-z0-9]+)*$`)
+
+func kebabCasePaths(s *spec.Swagger) []Finding {
+	var findings []Finding
+	for _, path := range mapkeys.Sorted(s.Paths) {
+		for _, seg := range strings.Split(path, "/") {
+			if seg == "" || (strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}")) {
+				continue
+			}
+			if !kebabSegment.MatchString(seg) {
+				findings = append(findings, Finding{
+					Path:    jsonpointer.Join("/paths", path),
+					Message: fmt.Sprintf("path segment %q is not kebab-case", seg),
+				})
+			}
+		}
+	}
+	return findings
+}
+
+// camelCase joins the words of s, split on anything other than a letter or
+// digit, as lowerCamelCase. Existing capitals are treated as word breaks, so
+// "list_Pets" and "ListPets" both become "listPets".
+func camelCase(s string) string {
+	var words []string
+	word := []rune{}
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = word[:0]
+		}
+	}
+	runes := []rune(s)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && i > 0 && unicode.IsLower(runes[i-1]):
+			flush()
+			word = append(word, r)
+		default:
+			word = append(word, r)
+		}
+	}
+	flush()
+
+	id := ""
+	for i, w := range words {
+		r := []rune(strings.ToLower(w))
+		if i > 0 {
+			r[0] = unicode.ToUpper(r[0])
+		}
+		id += string(r)
+	}
+	return id
+}
+
+// isLowerCamelCase reports if s is made up of letters and digits and starts
+// with a lower case letter. Initialisms such as "getPetByID" are allowed.
+func isLowerCamelCase(s string) bool {
+	for i, r := range s {
+		if i == 0 && !unicode.IsLower(r) {
+			return false
+		}
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// uniqueID returns id, or id with a numeric suffix if it's already taken, and
+// records it as taken.
+func uniqueID(id string, taken map[string]bool) string {
+	unique := id
+	for n := 2; taken[unique]; n++ {
+		unique = fmt.Sprintf("%s%d", id, n)
+	}
+	taken[unique] = true
+	return unique
+}
@@ -0,0 +1,249 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/ericchiang/swaggopher/internal/logutil"
+	"github.com/ericchiang/swaggopher/internal/rawdoc"
+	"github.com/ericchiang/swaggopher/router"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// Rule checks a document for a single kind of problem.
+type Rule interface {
+	// ID identifies the rule in findings, suppressions and configuration,
+	// such as "operation-summary".
+	ID() string
+	// Check returns the problems found in a document. The Rule and Severity
+	// of each finding are filled in by RuleSet.
+	Check(doc *spec.Swagger) []Finding
+}
+
+// NewRule returns a rule implemented by a function.
+func NewRule(id string, check func(doc *spec.Swagger) []Finding) Rule {
+	return &funcRule{id, check}
+}
+
+type funcRule struct {
+	id    string
+	check func(doc *spec.Swagger) []Finding
+}
+
+func (r *funcRule) ID() string                        { return r.id }
+func (r *funcRule) Check(doc *spec.Swagger) []Finding { return r.check(doc) }
+
+// Severity is how serious a finding is. Severities are ordered, so a build can
+// fail on findings at or above a threshold.
+type Severity int
+
+const (
+	// Off disables a rule.
+	Off Severity = iota
+	Hint
+	Info
+	Warning
+	Error
+)
+
+var severityNames = []string{"off", "hint", "info", "warn", "error"}
+
+func (s Severity) String() string {
+	if s < 0 || int(s) >= len(severityNames) {
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+	return severityNames[s]
+}
+
+// ParseSeverity parses the name of a severity: "off", "hint", "info", "warn" or
+// "error".
+func ParseSeverity(name string) (Severity, error) {
+	for i, n := range severityNames {
+		if n == name {
+			return Severity(i), nil
+		}
+	}
+	return Off, fmt.Errorf("lint: unknown severity %q, must be one of %s", name, strings.Join(severityNames, ", "))
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (s *Severity) UnmarshalText(b []byte) error {
+	sev, err := ParseSeverity(string(b))
+	if err != nil {
+		return err
+	}
+	*s = sev
+	return nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (s *Severity) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var name string
+	if err := unmarshal(&name); err != nil {
+		return err
+	}
+	return s.UnmarshalText([]byte(name))
+}
+
+// RuleSet is a collection of rules and the severity each one reports at.
+type RuleSet struct {
+	rules      []Rule
+	severities map[string]Severity
+	logger     spec.Logger
+}
+
+// NewRuleSet returns an empty rule set.
+func NewRuleSet() *RuleSet {
+	return &RuleSet{severities: make(map[string]Severity)}
+}
+
+// Register adds a rule to the set. It's an error to register two rules with the
+// same ID.
+func (rs *RuleSet) Register(r Rule, sev Severity) error {
+	id := r.ID()
+	if id == "" {
+		return fmt.Errorf("lint: rule has no ID")
+	}
+	if _, ok := rs.severities[id]; ok {
+		return fmt.Errorf("lint: rule %q is already registered", id)
+	}
+	rs.rules = append(rs.rules, r)
+	rs.severities[id] = sev
+	return nil
+}
+
+// SetSeverity changes the severity of a registered rule. A severity of Off
+// disables the rule.
+func (rs *RuleSet) SetSeverity(id string, sev Severity) error {
+	if _, ok := rs.severities[id]; !ok {
+		return fmt.Errorf("lint: unknown rule %q", id)
+	}
+	rs.severities[id] = sev
+	return nil
+}
+
+// SetLogger sets a Logger which Check tells how many findings each enabled
+// rule reported. A nil Logger, the default, logs nothing.
+func (rs *RuleSet) SetLogger(l spec.Logger) {
+	rs.logger = l
+}
+
+// Rules returns the IDs of the registered rules in the order they were
+// registered.
+func (rs *RuleSet) Rules() []string {
+	ids := make([]string, len(rs.rules))
+	for i, r := range rs.rules {
+		ids[i] = r.ID()
+	}
+	return ids
+}
+
+// Severity returns the severity of a registered rule.
+func (rs *RuleSet) Severity(id string) (Severity, bool) {
+	sev, ok := rs.severities[id]
+	return sev, ok
+}
+
+// Configure applies the severities, budget and routing policy of a
+// configuration file. A budget replaces the rule with the ID BudgetRule, and a
+// routing policy the rule with the ID RoutingRule, which are registered with
+// Warning severity if they aren't already.
+func (rs *RuleSet) Configure(c *Config) error {
+	if c.Budget != nil {
+		rs.replace(NewBudgetRule(*c.Budget), Warning)
+	}
+	if c.Routing != nil {
+		rs.replace(NewRoutingRule(*c.Routing), Warning)
+	}
+	ids := make([]string, 0, len(c.Rules))
+	for id := range c.Rules {
+		ids = append(ids, id)
+	}
+	// Sort so the same unknown rule is reported each time.
+	sort.Strings(ids)
+	for _, id := range ids {
+		if err := rs.SetSeverity(id, c.Rules[id]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replace replaces the registered rule with the same ID as r, keeping its
+// severity, or registers r with a severity.
+func (rs *RuleSet) replace(r Rule, sev Severity) {
+	for i := range rs.rules {
+		if rs.rules[i].ID() == r.ID() {
+			rs.rules[i] = r
+			return
+		}
+	}
+	rs.rules = append(rs.rules, r)
+	rs.severities[r.ID()] = sev
+}
+
+// Check runs every enabled rule against a document, returning the findings
+// ordered by path.
+func (rs *RuleSet) Check(s *spec.Swagger) []Finding {
+	var findings []Finding
+	for _, r := range rs.rules {
+		sev := rs.severities[r.ID()]
+		if sev == Off {
+			continue
+		}
+		found := r.Check(s)
+		for _, f := range found {
+			f.Rule = r.ID()
+			f.Severity = sev
+			findings = append(findings, f)
+		}
+		logutil.Printf(rs.logger, "lint: %s: %d finding(s)", r.ID(), len(found))
+	}
+	sortFindings(findings)
+	return findings
+}
+
+// Config overrides the severities of rules and sets the budget and routing
+// policy, as read from a configuration file:
+//
+//	rules:
+//	  operation-summary: error
+//	  kebab-case-paths: off
+//	budget:
+//	  maxProperties: 50
+//	routing:
+//	  trailingSlash: strict
+//	  case: ignore
+type Config struct {
+	Rules map[string]Severity `json:"rules" yaml:"rules"`
+	// Budget, if set, is the budget checked by the rule with the ID
+	// BudgetRule.
+	Budget *Budget `json:"budget,omitempty" yaml:"budget,omitempty"`
+	// Routing, if set, are the options of the router the rule with the ID
+	// RoutingRule checks paths against.
+	Routing *router.Options `json:"routing,omitempty" yaml:"routing,omitempty"`
+}
+
+// ParseConfig decodes a JSON or YAML configuration file.
+func ParseConfig(data []byte) (*Config, error) {
+	var c Config
+	var err error
+	if rawdoc.IsJSON(data) {
+		err = json.Unmarshal(data, &c)
+	} else {
+		err = yaml.Unmarshal(data, &c)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("lint: parsing config: %v", err)
+	}
+	return &c, nil
+}
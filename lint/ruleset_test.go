@@ -0,0 +1,146 @@
+package lint
+
+import (
+	"bytes"
+	"log"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+func TestRuleSet(t *testing.T) {
+	var s spec.Swagger
+	err := yaml.Unmarshal([]byte(`
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+tags:
+- name: pets
+paths:
+  /petOwners/{ownerId}:
+    get:
+      operationId: getPetOwner
+      description: Get a pet owner.
+      tags: [pets, owners]
+      responses: {200: {description: OK}}
+`), &s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rs := Recommended()
+	noExamples := NewRule("response-examples", func(doc *spec.Swagger) []Finding {
+		return []Finding{{Path: "/paths/~1petOwners~1{ownerId}/get/responses/200", Message: "response has no examples"}}
+	})
+	if err := rs.Register(noExamples, Hint); err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.Register(noExamples, Hint); err == nil {
+		t.Errorf("expected error registering a rule twice")
+	}
+
+	config, err := ParseConfig([]byte(`
+rules:
+  operation-summary: error
+  kebab-case-paths: off
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.Configure(config); err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.SetSeverity("no-such-rule", Error); err == nil {
+		t.Errorf("expected error configuring an unknown rule")
+	}
+
+	want := []Finding{
+		{
+			Rule:     "operation-summary",
+			Path:     "/paths/~1petOwners~1{ownerId}/get",
+			Message:  "operation has no summary",
+			Severity: Error,
+		},
+		{
+			Rule:     "response-examples",
+			Path:     "/paths/~1petOwners~1{ownerId}/get/responses/200",
+			Message:  "response has no examples",
+			Severity: Hint,
+		},
+		{
+			Rule:     "operation-tag-defined",
+			Path:     "/paths/~1petOwners~1{ownerId}/get/tags/1",
+			Message:  `tag "owners" is not defined at the top level`,
+			Severity: Warning,
+		},
+	}
+	if diff := pretty.Compare(rs.Check(&s), want); diff != "" {
+		t.Errorf("want != got: %s", diff)
+	}
+
+	if err := rs.SetSeverity("kebab-case-paths", Info); err != nil {
+		t.Fatal(err)
+	}
+	var kebab []Finding
+	for _, f := range rs.Check(&s) {
+		if f.Rule == "kebab-case-paths" {
+			kebab = append(kebab, f)
+		}
+	}
+	wantKebab := []Finding{{
+		Rule:     "kebab-case-paths",
+		Path:     "/paths/~1petOwners~1{ownerId}",
+		Message:  `path segment "petOwners" is not kebab-case`,
+		Severity: Info,
+	}}
+	if diff := pretty.Compare(kebab, wantKebab); diff != "" {
+		t.Errorf("kebab-case-paths: want != got: %s", diff)
+	}
+}
+
+func TestRuleSetLogger(t *testing.T) {
+	rs := NewRuleSet()
+	for _, r := range []struct {
+		id  string
+		n   int
+		sev Severity
+	}{
+		{"one", 1, Warning},
+		{"none", 0, Warning},
+		{"disabled", 1, Off},
+	} {
+		n := r.n
+		rule := NewRule(r.id, func(doc *spec.Swagger) []Finding {
+			return make([]Finding, n)
+		})
+		if err := rs.Register(rule, r.sev); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var logs bytes.Buffer
+	rs.SetLogger(log.New(&logs, "", 0))
+	rs.Check(&spec.Swagger{})
+	want := "lint: one: 1 finding(s)\nlint: none: 0 finding(s)\n"
+	if logs.String() != want {
+		t.Errorf("want logs %q, got %q", want, logs.String())
+	}
+}
+
+func TestParseSeverity(t *testing.T) {
+	for _, sev := range []Severity{Off, Hint, Info, Warning, Error} {
+		got, err := ParseSeverity(sev.String())
+		if err != nil {
+			t.Errorf("%s: %v", sev, err)
+			continue
+		}
+		if got != sev {
+			t.Errorf("want=%s, got=%s", sev, got)
+		}
+	}
+	if _, err := ParseSeverity("fatal"); err == nil {
+		t.Errorf("expected error parsing unknown severity")
+	}
+}
@@ -0,0 +1,119 @@
+package lint
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ericchiang/swaggopher/internal/jsonpointer"
+	"github.com/ericchiang/swaggopher/internal/rawdoc"
+)
+
+// IgnoreExtension is the vendor extension used to suppress findings. Its value
+// is a rule ID or a list of rule IDs, and it applies to the object it's declared
+// on and everything nested within it:
+//
+//	paths:
+//	  /legacy_pets:
+//	    x-lint-ignore: [kebab-case-paths]
+const IgnoreExtension = "x-lint-ignore"
+
+// Suppression is a single rule ignored by an IgnoreExtension.
+type Suppression struct {
+	// Path is a JSON pointer to the object declaring the suppression.
+	Path string `json:"path"`
+	// Rule is the ignored rule ID.
+	Rule string `json:"rule"`
+	// Findings holds the findings that were suppressed. It is populated by
+	// Suppress; a suppression with no findings is no longer needed.
+	Findings []Finding `json:"findings,omitempty"`
+}
+
+// Suppressions returns every suppression declared in a JSON or YAML document,
+// ordered by path.
+func Suppressions(data []byte) ([]Suppression, error) {
+	doc, err := rawdoc.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	var sups []Suppression
+	if err := collectSuppressions(doc, "", &sups); err != nil {
+		return nil, err
+	}
+	return sups, nil
+}
+
+func collectSuppressions(v interface{}, path string, sups *[]Suppression) error {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if ignore, ok := v[IgnoreExtension]; ok {
+			rules, err := ruleList(ignore)
+			if err != nil {
+				return fmt.Errorf("lint: %s: invalid %s: %v", jsonpointer.Join(path, IgnoreExtension), IgnoreExtension, err)
+			}
+			for _, rule := range rules {
+				*sups = append(*sups, Suppression{Path: path, Rule: rule})
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if err := collectSuppressions(v[key], jsonpointer.Join(path, key), sups); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for i, elem := range v {
+			if err := collectSuppressions(elem, jsonpointer.Join(path, fmt.Sprint(i)), sups); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func ruleList(v interface{}) ([]string, error) {
+	switch v := v.(type) {
+	case string:
+		return []string{v}, nil
+	case []interface{}:
+		rules := make([]string, len(v))
+		for i, elem := range v {
+			rule, ok := elem.(string)
+			if !ok {
+				return nil, fmt.Errorf("expected rule ID, got %v", elem)
+			}
+			rules[i] = rule
+		}
+		return rules, nil
+	}
+	return nil, fmt.Errorf("expected a rule ID or list of rule IDs")
+}
+
+// Suppress removes the findings matched by a suppression. It returns the
+// remaining findings, and the suppressions annotated with the findings each
+// one removed so they can be reported rather than accumulating unseen.
+func Suppress(findings []Finding, sups []Suppression) ([]Finding, []Suppression) {
+	report := make([]Suppression, len(sups))
+	for i, s := range sups {
+		report[i] = Suppression{Path: s.Path, Rule: s.Rule}
+	}
+
+	var kept []Finding
+	for _, f := range findings {
+		suppressed := false
+		for i := range report {
+			s := &report[i]
+			if s.Rule == f.Rule && jsonpointer.HasPrefix(f.Path, s.Path) {
+				s.Findings = append(s.Findings, f)
+				suppressed = true
+			}
+		}
+		if !suppressed {
+			kept = append(kept, f)
+		}
+	}
+	return kept, report
+}
@@ -0,0 +1,59 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+const suppressDoc = `
+swagger: "2.0"
+info:
+  title: Pets
+  version: "1.0"
+paths:
+  /legacy_pets:
+    x-lint-ignore: [kebab-case-paths, operation-summary]
+    get:
+      responses:
+        "200":
+          description: ok
+  /pets:
+    get:
+      x-lint-ignore: operation-summary
+      responses:
+        "200":
+          description: ok
+`
+
+func TestSuppress(t *testing.T) {
+	sups, err := Suppressions([]byte(suppressDoc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantSups := []Suppression{
+		{Path: "/paths/~1legacy_pets", Rule: "kebab-case-paths"},
+		{Path: "/paths/~1legacy_pets", Rule: "operation-summary"},
+		{Path: "/paths/~1pets/get", Rule: "operation-summary"},
+	}
+	if diff := pretty.Compare(sups, wantSups); diff != "" {
+		t.Fatalf("suppressions: want != got: %s", diff)
+	}
+
+	kebab := Finding{Rule: "kebab-case-paths", Path: "/paths/~1legacy_pets", Message: "path is not kebab-case"}
+	summary := Finding{Rule: "operation-summary", Path: "/paths/~1legacy_pets/get", Message: "operation has no summary"}
+	tags := Finding{Rule: "operation-tags", Path: "/paths/~1pets/get", Message: "operation has no tags"}
+
+	kept, report := Suppress([]Finding{kebab, summary, tags}, sups)
+	if diff := pretty.Compare(kept, []Finding{tags}); diff != "" {
+		t.Errorf("kept: want != got: %s", diff)
+	}
+	wantReport := []Suppression{
+		{Path: "/paths/~1legacy_pets", Rule: "kebab-case-paths", Findings: []Finding{kebab}},
+		{Path: "/paths/~1legacy_pets", Rule: "operation-summary", Findings: []Finding{summary}},
+		{Path: "/paths/~1pets/get", Rule: "operation-summary"},
+	}
+	if diff := pretty.Compare(report, wantReport); diff != "" {
+		t.Errorf("report: want != got: %s", diff)
+	}
+}
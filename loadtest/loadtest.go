@@ -0,0 +1,363 @@
+/*
+Package loadtest generates load test plans from a document, so that performance
+tests send the requests the document describes and check for its responses.
+
+Plan builds a request for each operation from the document's examples,
+defaults and enums, generating values from their schemas where there are none,
+and weighs operations by how often each should be sent:
+
+	targets, err := loadtest.Plan("https://api.example.com", doc, loadtest.Options{
+		Weights: map[string]int{"listPets": 8, "getPet": 4, "createPet": 1},
+	})
+	if err != nil {
+		// Handle error.
+	}
+	err = loadtest.WriteK6(w, targets)
+
+The plan can be written as a k6 script, which picks requests at random in
+proportion to their weights and checks their status against the documented
+responses, as a targets file for Vegeta's JSON format, or as a shell script
+sending each request once with curl.
+
+Warmup returns only the requests which are safe to send to a newly deployed
+server, those of GET operations whose path parameters have known values, for
+priming caches and connection pools from a deployment hook.
+*/
+package loadtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/fixture"
+	"github.com/ericchiang/swaggopher/internal/mapkeys"
+	"github.com/ericchiang/swaggopher/internal/synth"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// Options configures Plan. The zero value is valid.
+type Options struct {
+	// Weights maps the operationId, or method and path such as
+	// "GET /pets/{id}", of operations to how often they're sent relative to
+	// others. Operations not listed have a weight of 1, and a weight of 0
+	// leaves an operation out of the plan.
+	Weights map[string]int
+
+	// Value returns the string encoded value to send for a parameter, such as
+	// the ID of a resource known to exist. If it returns false, or is nil,
+	// required parameters and those with defaults are sent with their default,
+	// their first enum value or a value generated from their type, and other
+	// parameters are left out.
+	Value func(method, path string, p *spec.Parameter) (string, bool)
+
+	// Header is added to every request, for example to hold credentials.
+	Header http.Header
+}
+
+// Target is a request of a plan.
+type Target struct {
+	// Name is the operationId of the operation, or its method and path.
+	Name   string
+	Method string
+	URL    string
+	Header http.Header
+	Body   []byte
+	// Weight is how often the request is sent relative to others.
+	Weight int
+	// Status holds the operation's documented 2xx and 3xx status codes. If
+	// it's empty, any status below 400 is expected.
+	Status []int
+}
+
+// Plan returns a request for each operation of a document, ordered by path and
+// then method, sent to baseURL joined with the document's basePath.
+func Plan(baseURL string, s *spec.Swagger, opts Options) ([]Target, error) {
+	return plan(baseURL, s, opts, nil)
+}
+
+// plan returns the targets of the operations include accepts, or of every
+// operation if it's nil.
+func plan(baseURL string, s *spec.Swagger, opts Options, include func(method, path string, item *spec.PathItem, op *spec.Operation) bool) ([]Target, error) {
+	weights := make(map[string]int, len(opts.Weights))
+	for _, k := range mapkeys.Sorted(opts.Weights) {
+		method, path, _, ok := fixture.Find(s, k)
+		if !ok {
+			return nil, fmt.Errorf("loadtest: weight for %s: no such operation", k)
+		}
+		if opts.Weights[k] < 0 {
+			return nil, fmt.Errorf("loadtest: weight for %s must not be negative", k)
+		}
+		weights[strings.ToUpper(method)+" "+path] = opts.Weights[k]
+	}
+
+	base := strings.TrimSuffix(baseURL, "/") + strings.TrimSuffix(s.BasePath, "/")
+	var (
+		targets []Target
+		err     error
+	)
+	s.RangeOperations(func(path, method string, op *spec.Operation) bool {
+		item := s.Paths[path]
+		name := strings.ToUpper(method) + " " + path
+		weight, ok := weights[name]
+		if !ok {
+			weight = 1
+		}
+		if weight == 0 || (include != nil && !include(method, path, &item, op)) {
+			return true
+		}
+		if op.OperationId != "" {
+			name = op.OperationId
+		}
+		var t Target
+		if t, err = target(s, base, path, method, &item, op, opts); err != nil {
+			err = fmt.Errorf("loadtest: %s: %v", name, err)
+			return false
+		}
+		t.Name, t.Weight = name, weight
+		targets = append(targets, t)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return targets, nil
+}
+
+func target(s *spec.Swagger, base, path, method string, item *spec.PathItem, op *spec.Operation, opts Options) (Target, error) {
+	query := url.Values{}
+	header := http.Header{}
+	form := url.Values{}
+	var (
+		body     interface{}
+		hasBody  bool
+		hasFile  bool
+		consumes = op.Consumes
+	)
+	if len(consumes) == 0 {
+		consumes = s.Consumes
+	}
+
+	reqPath := path
+	for _, p := range s.OperationParameters(item, op) {
+		if p.Ref != "" {
+			return Target{}, fmt.Errorf("parameter %q not found", p.Ref)
+		}
+		if p.In == "body" {
+			if p.Schema != nil {
+				body, hasBody = synth.Example(s, p.Schema, true), true
+			}
+			continue
+		}
+		values, err := value(method, path, p, opts)
+		if err != nil {
+			return Target{}, fmt.Errorf("parameter %s: %v", p.Name, err)
+		}
+		if values == nil {
+			continue
+		}
+		switch p.In {
+		case "path":
+			reqPath = strings.Replace(reqPath, "{"+p.Name+"}", url.PathEscape(values[0]), -1)
+		case "query":
+			query[p.Name] = values
+		case "header":
+			header[http.CanonicalHeaderKey(p.Name)] = values
+		case "formData":
+			hasFile = hasFile || p.Type == "file"
+			form[p.Name] = values
+		}
+	}
+
+	t := Target{Method: strings.ToUpper(method), URL: base + reqPath, Header: header}
+	if len(query) > 0 {
+		t.URL += "?" + query.Encode()
+	}
+	for k, v := range opts.Header {
+		header[k] = append([]string(nil), v...)
+	}
+	switch {
+	case hasBody:
+		data, err := json.Marshal(body)
+		if err != nil {
+			return Target{}, err
+		}
+		t.Body = data
+		header.Set("Content-Type", "application/json")
+		for _, mt := range consumes {
+			if strings.Contains(mt, "json") {
+				header.Set("Content-Type", mt)
+				break
+			}
+		}
+	case hasFile || contains(consumes, "multipart/form-data"):
+		var buf bytes.Buffer
+		w := multipart.NewWriter(&buf)
+		names := make([]string, 0, len(form))
+		for name := range form {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			for _, v := range form[name] {
+				if err := w.WriteField(name, v); err != nil {
+					return Target{}, err
+				}
+			}
+		}
+		if err := w.Close(); err != nil {
+			return Target{}, err
+		}
+		t.Body = buf.Bytes()
+		header.Set("Content-Type", w.FormDataContentType())
+	case len(form) > 0:
+		t.Body = []byte(form.Encode())
+		header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	for code := range op.Responses {
+		if n, err := strconv.Atoi(code); err == nil && n >= 200 && n < 400 {
+			t.Status = append(t.Status, n)
+		}
+	}
+	sort.Ints(t.Status)
+	return t, nil
+}
+
+// value returns the values to send for a parameter, or nil if an optional
+// parameter should be left out.
+func value(method, path string, p *spec.Parameter, opts Options) ([]string, error) {
+	if opts.Value != nil {
+		if v, ok := opts.Value(method, path, p); ok {
+			return []string{v}, nil
+		}
+	}
+	if !p.Required && p.Default == nil {
+		return nil, nil
+	}
+	if p.Type == "file" {
+		return []string{"test"}, nil
+	}
+	return synth.Parameter(p)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteCurl writes a shell script which sends each target once with curl, and
+// exits with an error if any response has an error status.
+func WriteCurl(w io.Writer, targets []Target) error {
+	if _, err := io.WriteString(w, "#!/bin/sh\nset -e\n"); err != nil {
+		return err
+	}
+	for _, t := range targets {
+		cmd := "curl -fsS -o /dev/null -X " + t.Method
+		names := make([]string, 0, len(t.Header))
+		for name := range t.Header {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			for _, v := range t.Header[name] {
+				cmd += " -H " + shellQuote(name+": "+v)
+			}
+		}
+		if t.Body != nil {
+			cmd += " --data-binary " + shellQuote(string(t.Body))
+		}
+		if _, err := fmt.Fprintf(w, "%s %s\n", cmd, shellQuote(t.URL)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// shellQuote quotes a string for POSIX shells.
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// WriteVegeta writes the targets in the JSON format of Vegeta's attack command,
+// one per line, for use with "vegeta attack -format=json". Vegeta sends its
+// targets in turn, so each is written as many times as its weight.
+func WriteVegeta(w io.Writer, targets []Target) error {
+	enc := json.NewEncoder(w)
+	for _, t := range targets {
+		v := struct {
+			Method string              `json:"method"`
+			URL    string              `json:"url"`
+			Body   []byte              `json:"body,omitempty"`
+			Header map[string][]string `json:"header,omitempty"`
+		}{t.Method, t.URL, t.Body, t.Header}
+		for i := 0; i < t.Weight; i++ {
+			if err := enc.Encode(v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// k6Script is the script WriteK6 writes, after the list of requests.
+const k6Script = `
+const total = requests.reduce((sum, r) => sum + r.weight, 0);
+
+export default function () {
+  let n = Math.random() * total;
+  const r = requests.find((r) => (n -= r.weight) < 0) || requests[requests.length - 1];
+  const res = http.request(r.method, r.url, r.body, { headers: r.headers, tags: { name: r.name } });
+  check(res, {
+    [r.name + ' status is documented']: (res) =>
+      r.status.length > 0 ? r.status.includes(res.status) : res.status < 400,
+  });
+}
+`
+
+// WriteK6 writes a k6 script which sends the targets at random, in proportion
+// to their weights, and checks each response's status. Run it with, for
+// example, "k6 run --vus 10 --duration 30s script.js".
+func WriteK6(w io.Writer, targets []Target) error {
+	type request struct {
+		Name    string            `json:"name"`
+		Weight  int               `json:"weight"`
+		Method  string            `json:"method"`
+		URL     string            `json:"url"`
+		Body    *string           `json:"body"`
+		Headers map[string]string `json:"headers"`
+		Status  []int             `json:"status"`
+	}
+	requests := make([]request, len(targets))
+	for i, t := range targets {
+		r := request{Name: t.Name, Weight: t.Weight, Method: t.Method, URL: t.URL, Headers: map[string]string{}, Status: t.Status}
+		if t.Body != nil {
+			body := string(t.Body)
+			r.Body = &body
+		}
+		for k, v := range t.Header {
+			r.Headers[k] = strings.Join(v, ", ")
+		}
+		if r.Status == nil {
+			r.Status = []int{}
+		}
+		requests[i] = r
+	}
+	data, err := json.MarshalIndent(requests, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "import http from 'k6/http';\nimport { check } from 'k6';\n\nconst requests = %s;\n%s", data, k6Script)
+	return err
+}
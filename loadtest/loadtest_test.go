@@ -0,0 +1,192 @@
+package loadtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+const doc = `
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+basePath: /v1
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      parameters:
+      - {name: limit, in: query, type: integer, minimum: 5, required: true}
+      - {name: tag, in: query, type: string}
+      responses:
+        200: {description: Pets.}
+    post:
+      operationId: createPet
+      parameters:
+      - {name: pet, in: body, schema: {$ref: '#/definitions/Pet'}}
+      responses:
+        201: {description: Created.}
+        400: {description: Invalid.}
+  /pets/{petId}:
+    parameters:
+    - {name: petId, in: path, required: true, type: integer}
+    get:
+      responses:
+        default: {description: A pet.}
+    delete:
+      operationId: deletePet
+      responses:
+        204: {description: Deleted.}
+definitions:
+  Pet:
+    type: object
+    required: [name]
+    properties:
+      id: {type: integer, readOnly: true}
+      name: {type: string, example: Rex}
+`
+
+func load(t *testing.T) *spec.Swagger {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(doc), &s); err != nil {
+		t.Fatal(err)
+	}
+	return &s
+}
+
+func TestPlan(t *testing.T) {
+	s := load(t)
+	opts := Options{
+		Weights: map[string]int{"listPets": 5, "GET /pets/{petId}": 3, "deletePet": 0},
+		Value: func(method, path string, p *spec.Parameter) (string, bool) {
+			return "42", p.Name == "petId"
+		},
+		Header: http.Header{"Authorization": {"Bearer token"}},
+	}
+	got, err := Plan("http://localhost:8080/", s, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Target{
+		{
+			Name:   "listPets",
+			Method: "GET",
+			URL:    "http://localhost:8080/v1/pets?limit=5",
+			Header: http.Header{"Authorization": {"Bearer token"}},
+			Weight: 5,
+			Status: []int{200},
+		},
+		{
+			Name:   "createPet",
+			Method: "POST",
+			URL:    "http://localhost:8080/v1/pets",
+			Header: http.Header{"Authorization": {"Bearer token"}, "Content-Type": {"application/json"}},
+			Body:   []byte(`{"name":"Rex"}`),
+			Weight: 1,
+			Status: []int{201},
+		},
+		{
+			Name:   "GET /pets/{petId}",
+			Method: "GET",
+			URL:    "http://localhost:8080/v1/pets/42",
+			Header: http.Header{"Authorization": {"Bearer token"}},
+			Weight: 3,
+		},
+	}
+	if diff := pretty.Compare(got, want); diff != "" {
+		t.Errorf("want != got: %s", diff)
+	}
+
+	for _, weights := range []map[string]int{{"getPet": 1}, {"listPets": -1}} {
+		if _, err := Plan("http://localhost", s, Options{Weights: weights}); err == nil {
+			t.Errorf("%v: expected error", weights)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	targets, err := Plan("http://localhost", load(t), Options{Weights: map[string]int{"listPets": 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteVegeta(&buf, targets); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	// listPets is written three times, and the other operations once each.
+	if len(lines) != 6 {
+		t.Fatalf("expected 6 targets, got %d:\n%s", len(lines), &buf)
+	}
+	var post struct {
+		Method string              `json:"method"`
+		URL    string              `json:"url"`
+		Body   []byte              `json:"body"`
+		Header map[string][]string `json:"header"`
+	}
+	if err := json.Unmarshal([]byte(lines[3]), &post); err != nil {
+		t.Fatal(err)
+	}
+	if post.Method != "POST" || string(post.Body) != `{"name":"Rex"}` || post.Header["Content-Type"][0] != "application/json" {
+		t.Errorf("unexpected target: %s", lines[3])
+	}
+
+	buf.Reset()
+	if err := WriteK6(&buf, targets); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"import http from 'k6/http';",
+		`"name": "listPets",` + "\n    \"weight\": 3,",
+		`"body": "{\"name\":\"Rex\"}",`,
+		`"status": [` + "\n      201\n    ]",
+		"export default function () {",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected script to contain %q, got:\n%s", want, &buf)
+		}
+	}
+}
+
+func TestWarmup(t *testing.T) {
+	s := load(t)
+	got, err := Warmup("http://localhost", s, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The GET operation with a path parameter is left out, since there's no
+	// value for it.
+	if len(got) != 1 || got[0].Name != "listPets" {
+		t.Errorf("expected only listPets, got %+v", got)
+	}
+
+	opts := Options{
+		Value: func(method, path string, p *spec.Parameter) (string, bool) {
+			return "42", p.Name == "petId"
+		},
+		Header: http.Header{"X-Warmup": {"it's me"}},
+	}
+	if got, err = Warmup("http://localhost", s, opts); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := WriteCurl(&buf, got); err != nil {
+		t.Fatal(err)
+	}
+	want := `#!/bin/sh
+set -e
+curl -fsS -o /dev/null -X GET -H 'X-Warmup: it'\''s me' 'http://localhost/v1/pets?limit=5'
+curl -fsS -o /dev/null -X GET -H 'X-Warmup: it'\''s me' 'http://localhost/v1/pets/42'
+`
+	if buf.String() != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, &buf)
+	}
+}
@@ -0,0 +1,34 @@
+package loadtest
+
+import (
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// Warmup returns requests which are safe to send to a newly deployed server to
+// warm it up, such as from a deployment hook: one for each GET operation, since
+// GET requests mustn't have side effects.
+//
+// Parameters are given values as by Plan. Operations with path parameters are
+// left out unless every one has a value from opts.Value, a default or an enum,
+// since a generated value, such as an ID, is unlikely to name a resource which
+// exists. Operations given a weight of 0 are also left out, and the other
+// weights are kept, so the requests can be sent in proportion to them.
+func Warmup(baseURL string, s *spec.Swagger, opts Options) ([]Target, error) {
+	return plan(baseURL, s, opts, func(method, path string, item *spec.PathItem, op *spec.Operation) bool {
+		if method != "get" {
+			return false
+		}
+		for _, p := range s.OperationParameters(item, op) {
+			if p.In != "path" || p.Default != nil || len(p.Enum) > 0 {
+				continue
+			}
+			if opts.Value == nil {
+				return false
+			}
+			if _, ok := opts.Value(method, path, p); !ok {
+				return false
+			}
+		}
+		return true
+	})
+}
@@ -0,0 +1,364 @@
+/*
+Package merge combines the documents of several services into one, such as
+the document published by an API gateway in front of them.
+
+Each document's paths are prefixed with its basePath, and optionally a
+gateway prefix, so the merged document's paths are the full paths requests
+are routed by. Its definitions, parameters, responses, security schemes and
+tags may be prefixed too, with references to them rewritten, to keep names
+from different services apart. Names which still collide are an error, unless
+both documents define the same value, as is common for shared models.
+
+Defaults declared at the top of a document, such as its consumes, produces,
+schemes and security requirements, are copied to its operations, since the
+merged document can only hold one set.
+*/
+package merge
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/internal/jsonpointer"
+	"github.com/ericchiang/swaggopher/internal/mapkeys"
+	"github.com/ericchiang/swaggopher/internal/refs"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// Options configures how documents are merged.
+type Options struct {
+	// Info describes the merged document. It defaults to the first document's.
+	Info *spec.Info
+	// Host, BasePath and Schemes are those of the merged document. Paths are
+	// relative to BasePath, which is typically empty.
+	Host     string
+	BasePath string
+	Schemes  []string
+	// Prefixes holds the prefixes of each document, in the order they're
+	// passed to Merge. Documents past the end of the list aren't prefixed.
+	Prefixes []Prefix
+}
+
+// Prefix namespaces the contents of a document.
+type Prefix struct {
+	// Path is prepended to the document's paths, before its basePath, such as
+	// "/pets" for a service the gateway routes "/pets/..." to.
+	Path string
+	// Name is prepended to the names of the document's definitions,
+	// parameters, responses and security schemes, such as "Pets".
+	Name string
+	// Tag is prepended to the document's tags, such as "pets.".
+	Tag string
+}
+
+// Merge combines documents into one. The documents aren't modified.
+func Merge(docs ...*spec.Swagger) (*spec.Swagger, error) {
+	return Options{}.Merge(docs...)
+}
+
+// Merge combines documents into one. The documents aren't modified.
+func (o Options) Merge(docs ...*spec.Swagger) (*spec.Swagger, error) {
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("merge: no documents")
+	}
+	m := &merger{
+		doc: &spec.Swagger{
+			Swagger:  "2.0",
+			Info:     o.Info,
+			Host:     o.Host,
+			BasePath: o.BasePath,
+			Schemes:  o.Schemes,
+			Paths:    make(spec.Paths),
+		},
+		templates:  make(map[string]string),
+		owners:     make(map[string]int),
+		operations: make(map[string]string),
+	}
+	if m.doc.Info == nil {
+		m.doc.Info = docs[0].Info
+	}
+	for i, s := range docs {
+		// Copy the document so renaming doesn't modify the original.
+		data, err := json.Marshal(s)
+		if err != nil {
+			return nil, fmt.Errorf("merge: copying %s: %v", describe(i, s), err)
+		}
+		var doc spec.Swagger
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("merge: copying %s: %v", describe(i, s), err)
+		}
+		var p Prefix
+		if i < len(o.Prefixes) {
+			p = o.Prefixes[i]
+		}
+		if err := m.add(i, &doc, p); err != nil {
+			return nil, fmt.Errorf("merge: %v", err)
+		}
+	}
+	return m.doc, nil
+}
+
+// describe names a document in errors.
+func describe(i int, s *spec.Swagger) string {
+	if s.Info != nil && s.Info.Title != "" {
+		return fmt.Sprintf("document %d (%s)", i+1, s.Info.Title)
+	}
+	return fmt.Sprintf("document %d", i+1)
+}
+
+type merger struct {
+	doc  *spec.Swagger
+	docs []*spec.Swagger
+	// templates maps path templates, with their parameters' names removed, to
+	// the paths of the merged document, since paths which only differ by
+	// parameter names match the same requests.
+	templates map[string]string
+	// owners holds the index of the document each path, definition,
+	// parameter, response, security scheme and tag came from, keyed by
+	// section and name.
+	owners map[string]int
+	// operations maps operation IDs to the operations which declare them.
+	operations map[string]string
+}
+
+var pathParam = regexp.MustCompile(`\{[^}]*\}`)
+
+func (m *merger) add(i int, doc *spec.Swagger, p Prefix) error {
+	m.docs = append(m.docs, doc)
+	r := renamer{prefix: p.Name}
+	r.rename(doc)
+
+	basePath := strings.TrimSuffix(doc.BasePath, "/")
+	for _, path := range mapkeys.Sorted(doc.Paths) {
+		item := doc.Paths[path]
+		full := p.Path + basePath + path
+		template := pathParam.ReplaceAllString(full, "{}")
+		if other, ok := m.templates[template]; ok {
+			return fmt.Errorf("path %s of %s conflicts with path %s of %s", full, describe(i, doc), other, m.owner("paths", other))
+		}
+		m.templates[template] = full
+
+		for _, method := range spec.Methods {
+			op := item.Operation(method)
+			if op == nil {
+				continue
+			}
+			if op.OperationId != "" {
+				if other, ok := m.operations[op.OperationId]; ok {
+					return fmt.Errorf("operation ID %q of %s %s is also used by %s", op.OperationId, strings.ToUpper(method), full, other)
+				}
+				m.operations[op.OperationId] = strings.ToUpper(method) + " " + full
+			}
+			if op.Consumes == nil {
+				op.Consumes = doc.Consumes
+			}
+			if op.Produces == nil {
+				op.Produces = doc.Produces
+			}
+			if op.Schemes == nil {
+				op.Schemes = doc.Schemes
+			}
+			if op.Security == nil {
+				op.Security = doc.Security
+			}
+			for j, tag := range op.Tags {
+				op.Tags[j] = p.Tag + tag
+			}
+		}
+		m.owners["paths "+full] = i
+		m.doc.Paths[full] = item
+	}
+
+	for _, name := range mapkeys.Sorted(doc.Definitions) {
+		if m.doc.Definitions == nil {
+			m.doc.Definitions = make(spec.Definitions)
+		}
+		if err := m.put(i, "definitions", name, doc.Definitions[name], m.doc.Definitions); err != nil {
+			return err
+		}
+	}
+	for _, name := range mapkeys.Sorted(doc.Parameters) {
+		if m.doc.Parameters == nil {
+			m.doc.Parameters = make(spec.ParametersDefinitions)
+		}
+		if err := m.put(i, "parameters", name, doc.Parameters[name], m.doc.Parameters); err != nil {
+			return err
+		}
+	}
+	for _, name := range mapkeys.Sorted(doc.Responses) {
+		if m.doc.Responses == nil {
+			m.doc.Responses = make(spec.ResponsesDefinitions)
+		}
+		if err := m.put(i, "responses", name, doc.Responses[name], m.doc.Responses); err != nil {
+			return err
+		}
+	}
+	for _, name := range mapkeys.Sorted(doc.SecurityDefinitions) {
+		if m.doc.SecurityDefinitions == nil {
+			m.doc.SecurityDefinitions = make(spec.SecurityDefinitions)
+		}
+		if err := m.put(i, "securityDefinitions", name, doc.SecurityDefinitions[name], m.doc.SecurityDefinitions); err != nil {
+			return err
+		}
+	}
+
+	for _, tag := range doc.Tags {
+		tag.Name = p.Tag + tag.Name
+		key := "tags " + tag.Name
+		if j, ok := m.owners[key]; ok {
+			for _, other := range m.doc.Tags {
+				if other.Name == tag.Name && !reflect.DeepEqual(other, tag) {
+					return fmt.Errorf("tag %q of %s differs from the one in %s", tag.Name, describe(i, doc), describe(j, m.docs[j]))
+				}
+			}
+			continue
+		}
+		m.owners[key] = i
+		m.doc.Tags = append(m.doc.Tags, tag)
+	}
+	return nil
+}
+
+// owner describes the document a value of the merged document came from.
+func (m *merger) owner(section, name string) string {
+	j := m.owners[section+" "+name]
+	return describe(j, m.docs[j])
+}
+
+// put adds a named value to a section of the merged document, which is a map
+// of the value's type. A value with the same name is only allowed if it's the
+// same.
+func (m *merger) put(i int, section, name string, val, dst interface{}) error {
+	key := section + " " + name
+	dv := reflect.ValueOf(dst)
+	if j, ok := m.owners[key]; ok {
+		if !reflect.DeepEqual(dv.MapIndex(reflect.ValueOf(name)).Interface(), val) {
+			return fmt.Errorf("%s %s of %s differs from the one in %s", section, name, describe(i, m.docs[i]), describe(j, m.docs[j]))
+		}
+		return nil
+	}
+	m.owners[key] = i
+	dv.SetMapIndex(reflect.ValueOf(name), reflect.ValueOf(val))
+	return nil
+}
+
+// renamer prefixes the names of a document's definitions, parameters,
+// responses and security schemes, and rewrites references to them.
+type renamer struct {
+	prefix string
+}
+
+func (r renamer) rename(doc *spec.Swagger) {
+	if r.prefix == "" {
+		return
+	}
+	if doc.Definitions != nil {
+		defs := make(spec.Definitions)
+		for name, s := range doc.Definitions {
+			r.schema(&s)
+			defs[r.prefix+name] = s
+		}
+		doc.Definitions = defs
+	}
+	if doc.Parameters != nil {
+		params := make(spec.ParametersDefinitions)
+		for name, p := range doc.Parameters {
+			r.parameter(&p)
+			params[r.prefix+name] = p
+		}
+		doc.Parameters = params
+	}
+	if doc.Responses != nil {
+		resps := make(spec.ResponsesDefinitions)
+		for name, resp := range doc.Responses {
+			r.response(&resp)
+			resps[r.prefix+name] = resp
+		}
+		doc.Responses = resps
+	}
+	if doc.SecurityDefinitions != nil {
+		schemes := make(spec.SecurityDefinitions)
+		for name, scheme := range doc.SecurityDefinitions {
+			schemes[r.prefix+name] = scheme
+		}
+		doc.SecurityDefinitions = schemes
+	}
+	doc.Security = r.security(doc.Security)
+
+	for path, item := range doc.Paths {
+		for i := range item.Parameters {
+			r.parameter(&item.Parameters[i])
+		}
+		for _, method := range spec.Methods {
+			op := item.Operation(method)
+			if op == nil {
+				continue
+			}
+			for i := range op.Parameters {
+				r.parameter(&op.Parameters[i])
+			}
+			for code, resp := range op.Responses {
+				r.response(&resp)
+				op.Responses[code] = resp
+			}
+			op.Security = r.security(op.Security)
+		}
+		doc.Paths[path] = item
+	}
+}
+
+// ref rewrites a local reference to a section of the document.
+func (r renamer) ref(ref, section string) string {
+	prefix := "#/" + section + "/"
+	if !strings.HasPrefix(ref, prefix) {
+		return ref
+	}
+	tokens := jsonpointer.Split(strings.TrimPrefix(ref, "#"))
+	tokens[1] = r.prefix + tokens[1]
+	return "#" + jsonpointer.Join("", tokens...)
+}
+
+func (r renamer) security(reqs []spec.SecurityRequirement) []spec.SecurityRequirement {
+	for i, req := range reqs {
+		renamed := make(spec.SecurityRequirement)
+		for name, scopes := range req {
+			renamed[r.prefix+name] = scopes
+		}
+		reqs[i] = renamed
+	}
+	return reqs
+}
+
+func (r renamer) parameter(p *spec.Parameter) {
+	p.Ref = r.ref(p.Ref, "parameters")
+	if p.Schema != nil {
+		r.schema(p.Schema)
+	}
+}
+
+func (r renamer) response(resp *spec.Response) {
+	resp.Ref = r.ref(resp.Ref, "responses")
+	if resp.Schema != nil {
+		r.schema(resp.Schema)
+	}
+}
+
+func (r renamer) schema(s *spec.Schema) {
+	s.Ref = r.ref(refs.SchemaRef(s.Ref), "definitions")
+	if s.Items != nil {
+		r.schema(s.Items)
+	}
+	for i := range s.AllOf {
+		r.schema(&s.AllOf[i])
+	}
+	for name, prop := range s.Properties {
+		r.schema(&prop)
+		s.Properties[name] = prop
+	}
+	if ap := s.AdditionalProperties; ap != nil && ap.Schema != nil {
+		r.schema(ap.Schema)
+	}
+}
@@ -0,0 +1,206 @@
+package merge
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+func parse(t *testing.T, data string) *spec.Swagger {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(data), &s); err != nil {
+		t.Fatal(err)
+	}
+	return &s
+}
+
+const pets = `
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+basePath: /v1
+produces: [application/json]
+securityDefinitions:
+  key: {type: apiKey, name: X-Key, in: header}
+security:
+- key: []
+tags:
+- {name: pets, description: Pets.}
+paths:
+  /pets/{id}:
+    get:
+      operationId: getPet
+      tags: [pets]
+      parameters:
+      - $ref: '#/parameters/id'
+      responses:
+        200: {description: A pet., schema: {$ref: '#/definitions/Pet'}}
+        default: {$ref: '#/responses/Error'}
+parameters:
+  id: {name: id, in: path, required: true, type: integer}
+responses:
+  Error: {description: An error., schema: {$ref: '#/definitions/Error'}}
+definitions:
+  Pet:
+    type: object
+    properties:
+      owner: {$ref: Owner}
+  Owner: {type: object}
+  Error: {type: object, properties: {message: {type: string}}}
+`
+
+const owners = `
+swagger: "2.0"
+info: {title: Owners, version: "1.0"}
+tags:
+- {name: owners}
+paths:
+  /owners:
+    get:
+      operationId: listOwners
+      tags: [owners]
+      responses:
+        200: {description: Owners., schema: {type: array, items: {$ref: '#/definitions/Owner'}}}
+definitions:
+  Owner: {type: object, properties: {name: {type: string}}}
+  Error: {type: object, properties: {message: {type: string}}}
+`
+
+func TestMerge(t *testing.T) {
+	opts := Options{
+		Host: "api.example.com",
+		Prefixes: []Prefix{
+			{Path: "/pets-service", Name: "Pets", Tag: "pets."},
+		},
+	}
+	in := parse(t, pets)
+	got, err := opts.Merge(in, parse(t, owners))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := parse(t, `
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+host: api.example.com
+tags:
+- {name: pets.pets, description: Pets.}
+- {name: owners}
+paths:
+  /pets-service/v1/pets/{id}:
+    get:
+      operationId: getPet
+      tags: [pets.pets]
+      produces: [application/json]
+      security:
+      - Petskey: []
+      parameters:
+      - $ref: '#/parameters/Petsid'
+      responses:
+        200: {description: A pet., schema: {$ref: '#/definitions/PetsPet'}}
+        default: {$ref: '#/responses/PetsError'}
+  /owners:
+    get:
+      operationId: listOwners
+      tags: [owners]
+      responses:
+        200: {description: Owners., schema: {type: array, items: {$ref: '#/definitions/Owner'}}}
+securityDefinitions:
+  Petskey: {type: apiKey, name: X-Key, in: header}
+parameters:
+  Petsid: {name: id, in: path, required: true, type: integer}
+responses:
+  PetsError: {description: An error., schema: {$ref: '#/definitions/PetsError'}}
+definitions:
+  PetsPet:
+    type: object
+    properties:
+      owner: {$ref: '#/definitions/PetsOwner'}
+  PetsOwner: {type: object}
+  PetsError: {type: object, properties: {message: {type: string}}}
+  Owner: {type: object, properties: {name: {type: string}}}
+  Error: {type: object, properties: {message: {type: string}}}
+`)
+	if diff := pretty.Compare(want, got); diff != "" {
+		t.Errorf("merged document: %s", diff)
+	}
+
+	// The inputs aren't modified.
+	if diff := pretty.Compare(parse(t, pets), in); diff != "" {
+		t.Errorf("input modified: %s", diff)
+	}
+}
+
+func TestMergeErrors(t *testing.T) {
+	tests := []struct {
+		docs []string
+		want string
+	}{
+		{
+			// Both services define Owner differently.
+			docs: []string{pets, owners},
+			want: `merge: definitions Owner of document 2 (Owners) differs from the one in document 1 (Pets)`,
+		},
+		{
+			docs: []string{owners, `
+swagger: "2.0"
+info: {title: People, version: "1.0"}
+paths:
+  /owners: {}
+`},
+			want: `merge: path /owners of document 2 (People) conflicts with path /owners of document 1 (Owners)`,
+		},
+		{
+			docs: []string{pets, `
+swagger: "2.0"
+info: {title: Pets v2, version: "2.0"}
+basePath: /v2
+paths:
+  /pets/{petId}:
+    get:
+      operationId: getPet
+      responses:
+        200: {description: A pet.}
+`},
+			want: `merge: operation ID "getPet" of GET /v2/pets/{petId} is also used by GET /v1/pets/{id}`,
+		},
+		{
+			docs: []string{`
+swagger: "2.0"
+info: {title: A, version: "1.0"}
+paths:
+  /a/{id}: {}
+`, `
+swagger: "2.0"
+info: {title: B, version: "1.0"}
+paths:
+  /a/{name}: {}
+`},
+			want: `merge: path /a/{name} of document 2 (B) conflicts with path /a/{id} of document 1 (A)`,
+		},
+		{
+			docs: []string{owners, `
+swagger: "2.0"
+info: {title: People, version: "1.0"}
+paths: {}
+tags:
+- {name: owners, description: People who own pets.}
+`},
+			want: `merge: tag "owners" of document 2 (People) differs from the one in document 1 (Owners)`,
+		},
+	}
+	for i, test := range tests {
+		var docs []*spec.Swagger
+		for _, doc := range test.docs {
+			docs = append(docs, parse(t, doc))
+		}
+		_, err := Merge(docs...)
+		if err == nil {
+			t.Errorf("case %d: expected error %q", i, test.want)
+		} else if err.Error() != test.want {
+			t.Errorf("case %d: want error %q, got %q", i, test.want, err)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// Authenticator verifies the credentials requests present for a document's
+// security schemes. Each function is passed the name of the scheme in
+// securityDefinitions, and returns an error if the credentials are invalid.
+type Authenticator struct {
+	// APIKey checks the key of an apiKey scheme, read from the header or
+	// query parameter the scheme names. If nil, apiKey schemes can't be
+	// satisfied.
+	APIKey func(r *http.Request, scheme, key string) error
+	// Basic checks the user and password of a basic scheme. If nil, basic
+	// schemes can't be satisfied.
+	Basic func(r *http.Request, scheme, user, password string) error
+	// Bearer checks the bearer token of an oauth2 scheme, and returns the
+	// scopes it grants. If nil, oauth2 schemes can't be satisfied.
+	Bearer func(r *http.Request, scheme, token string) (scopes []string, err error)
+}
+
+// Auth returns middleware which enforces the security requirements of a
+// document, using the default options. See Options.Authenticate.
+func Auth(doc *spec.Swagger, a Authenticator) func(http.Handler) http.Handler {
+	return Options{}.Authenticate(doc, a)
+}
+
+// Authenticate returns middleware which only calls the handler it wraps for
+// requests satisfying one of their operation's security requirements, the
+// operation's own or the document's. A requirement is satisfied if the
+// request presents valid credentials for every scheme it names, and, for
+// oauth2 schemes, the token grants every scope it lists.
+//
+// Requests without valid credentials are answered with a 401 and a
+// WWW-Authenticate header, and those whose token lacks a scope with a 403.
+// Requests are routed as by ValidateRequests, and those which don't match an
+// operation are answered the same way.
+func (o Options) Authenticate(doc *spec.Swagger, a Authenticator) func(http.Handler) http.Handler {
+	router := o.router()
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			m, err := router.Route(doc, r)
+			if err != nil {
+				writeRouteError(w, err)
+				return
+			}
+			reqs, err := doc.ResolveSecurity(m.Operation)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, &Error{Message: err.Error()})
+				return
+			}
+			if len(reqs) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			var first *authFailure
+			for _, req := range reqs {
+				f := a.check(r, req)
+				if f == nil {
+					next.ServeHTTP(w, r)
+					return
+				}
+				// A token lacking scopes is reported over missing
+				// credentials, since the client can't fix it by
+				// authenticating again.
+				if first == nil || (f.forbidden && !first.forbidden) {
+					first = f
+				}
+			}
+			if first.forbidden {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope="%s"`, strings.Join(first.scopes, " ")))
+				writeError(w, http.StatusForbidden, &Error{Message: first.message})
+				return
+			}
+			for _, c := range challenges(reqs) {
+				w.Header().Add("WWW-Authenticate", c)
+			}
+			writeError(w, http.StatusUnauthorized, &Error{Message: first.message})
+		})
+	}
+}
+
+// authFailure is why a request doesn't satisfy a security requirement.
+type authFailure struct {
+	message string
+	// forbidden is set if the credentials are valid, but the token lacks
+	// the scopes listed by the requirement.
+	forbidden bool
+	scopes    []string
+}
+
+// check returns why a request doesn't satisfy a requirement, or nil if it
+// does.
+func (a Authenticator) check(r *http.Request, req spec.Requirement) *authFailure {
+	for _, applied := range req {
+		if f := a.checkScheme(r, &applied); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+func (a Authenticator) checkScheme(r *http.Request, applied *spec.AppliedScheme) *authFailure {
+	scheme := &applied.Scheme
+	invalid := func(format string, v ...interface{}) *authFailure {
+		return &authFailure{message: fmt.Sprintf("security scheme %s: ", applied.Name) + fmt.Sprintf(format, v...)}
+	}
+	if in, name, ok := scheme.APIKey(); ok {
+		var key string
+		switch in {
+		case "header":
+			key = r.Header.Get(name)
+		case "query":
+			key = r.URL.Query().Get(name)
+		}
+		if key == "" {
+			return invalid("missing %s %s", in, name)
+		}
+		if a.APIKey == nil {
+			return invalid("API keys aren't accepted")
+		}
+		if err := a.APIKey(r, applied.Name, key); err != nil {
+			return invalid("%v", err)
+		}
+		return nil
+	}
+	if scheme.IsBasic() {
+		user, password, ok := r.BasicAuth()
+		if !ok {
+			return invalid("missing basic credentials")
+		}
+		if a.Basic == nil {
+			return invalid("basic credentials aren't accepted")
+		}
+		if err := a.Basic(r, applied.Name, user, password); err != nil {
+			return invalid("%v", err)
+		}
+		return nil
+	}
+	if _, ok := scheme.OAuth2(); ok {
+		token := bearerToken(r)
+		if token == "" {
+			return invalid("missing bearer token")
+		}
+		if a.Bearer == nil {
+			return invalid("bearer tokens aren't accepted")
+		}
+		granted, err := a.Bearer(r, applied.Name, token)
+		if err != nil {
+			return invalid("%v", err)
+		}
+		var missing []string
+		for _, scope := range applied.Scopes {
+			if !contains(granted, scope) {
+				missing = append(missing, scope)
+			}
+		}
+		if len(missing) > 0 {
+			f := invalid("token lacks scopes %s", strings.Join(missing, ", "))
+			f.forbidden, f.scopes = true, applied.Scopes
+			return f
+		}
+		return nil
+	}
+	return invalid("unsupported type %q", scheme.Type)
+}
+
+// bearerToken returns the token of an "Authorization: Bearer" header.
+func bearerToken(r *http.Request) string {
+	h := r.Header.Get("Authorization")
+	if len(h) < len("Bearer ") || !strings.EqualFold(h[:len("Bearer ")], "Bearer ") {
+		return ""
+	}
+	return strings.TrimSpace(h[len("Bearer "):])
+}
+
+// challenges returns the WWW-Authenticate challenges of the basic and oauth2
+// schemes of a list of requirements. API keys have no standard challenge.
+func challenges(reqs []spec.Requirement) []string {
+	var basic, bearer bool
+	for _, req := range reqs {
+		for _, applied := range req {
+			if applied.Scheme.IsBasic() {
+				basic = true
+			}
+			if _, ok := applied.Scheme.OAuth2(); ok {
+				bearer = true
+			}
+		}
+	}
+	var cs []string
+	if basic {
+		cs = append(cs, "Basic")
+	}
+	if bearer {
+		cs = append(cs, "Bearer")
+	}
+	return cs
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+func TestAuth(t *testing.T) {
+	var s spec.Swagger
+	err := yaml.Unmarshal([]byte(`
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+securityDefinitions:
+  key: {type: apiKey, in: query, name: api_key}
+  basic: {type: basic}
+  oauth: {type: oauth2, flow: implicit, authorizationUrl: "https://example.com/auth", scopes: {read: Read., write: Write.}}
+security:
+- key: []
+paths:
+  /pets:
+    get: {responses: {200: {description: OK}}}
+    post:
+      security:
+      - oauth: [write]
+      - basic: []
+      responses: {201: {description: Created}}
+  /health:
+    get:
+      security: []
+      responses: {200: {description: OK}}
+`), &s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := Authenticator{
+		APIKey: func(r *http.Request, scheme, key string) error {
+			if key != "secret" {
+				return errors.New("unknown key")
+			}
+			return nil
+		},
+		Basic: func(r *http.Request, scheme, user, password string) error {
+			if user != "admin" || password != "hunter2" {
+				return errors.New("wrong password")
+			}
+			return nil
+		},
+		Bearer: func(r *http.Request, scheme, token string) ([]string, error) {
+			switch token {
+			case "reader":
+				return []string{"read"}, nil
+			case "writer":
+				return []string{"read", "write"}, nil
+			}
+			return nil, errors.New("invalid token")
+		},
+	}
+	h := Auth(&s, a)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		method, path  string
+		header        map[string]string
+		basic         bool
+		wantStatus    int
+		wantChallenge string
+	}{
+		{method: "GET", path: "/health", wantStatus: http.StatusOK},
+		{method: "GET", path: "/pets?api_key=secret", wantStatus: http.StatusOK},
+		{method: "GET", path: "/pets", wantStatus: http.StatusUnauthorized},
+		{method: "GET", path: "/pets?api_key=guess", wantStatus: http.StatusUnauthorized},
+		{method: "POST", path: "/pets", header: map[string]string{"Authorization": "Bearer writer"}, wantStatus: http.StatusOK},
+		{method: "POST", path: "/pets", basic: true, wantStatus: http.StatusOK},
+		{method: "POST", path: "/pets", header: map[string]string{"Authorization": "Bearer reader"}, wantStatus: http.StatusForbidden, wantChallenge: `Bearer error="insufficient_scope", scope="write"`},
+		{method: "POST", path: "/pets", header: map[string]string{"Authorization": "Bearer forged"}, wantStatus: http.StatusUnauthorized, wantChallenge: "Basic"},
+		{method: "DELETE", path: "/pets", wantStatus: http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, tt.path, nil)
+		for k, v := range tt.header {
+			r.Header.Set(k, v)
+		}
+		if tt.basic {
+			r.SetBasicAuth("admin", "hunter2")
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s %s: want status %d, got %d: %s", tt.method, tt.path, tt.wantStatus, w.Code, w.Body)
+		}
+		if got := w.Header().Get("WWW-Authenticate"); tt.wantChallenge != "" && got != tt.wantChallenge {
+			t.Errorf("%s %s: want challenge %q, got %q", tt.method, tt.path, tt.wantChallenge, got)
+		}
+	}
+
+	// Without a verifier, keys are rejected rather than accepted, so they
+	// can't stand in for the alternative requirement's token.
+	s.Paths["/pets"].Post.Security = []spec.SecurityRequirement{{"key": {}}, {"oauth": {"write"}}}
+	h = Auth(&s, Authenticator{Bearer: a.Bearer})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/pets?api_key=anything", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("POST /pets without an API key verifier: want status %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+}
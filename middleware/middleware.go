@@ -0,0 +1,166 @@
+/*
+Package middleware provides net/http middleware which enforces a document.
+
+Validator wraps a handler so it's only called for requests which satisfy the
+document. Requests are routed to operations by path and method as the proxy
+does, and their path, query, header, form and body parameters are checked
+against the operation's:
+
+	http.ListenAndServe(":8080", middleware.Validator(doc)(mux))
+
+Requests which fail are answered without calling the handler. The body is
+JSON with a message and, for requests with invalid parameters, the problem
+with each:
+
+	{
+	  "message": "request does not match the document",
+	  "errors": [
+	    {"in": "query", "name": "limit", "message": "coerce: limit: invalid value \"ten\": ..."}
+	  ]
+	}
+
+Auth enforces the document's security requirements, checking credentials with
+the functions of an Authenticator:
+
+	auth := middleware.Authenticator{
+		Bearer: func(r *http.Request, scheme, token string) ([]string, error) {
+			return verify(r.Context(), token) // returns the token's scopes
+		},
+	}
+	http.ListenAndServe(":8080", middleware.Auth(doc, auth)(mux))
+
+ResponseValidator checks the responses of a handler instead, reporting those
+which drift from the document. CheckResponses uses it to fail tests:
+
+	srv := httptest.NewServer(middleware.CheckResponses(t, doc)(handler))
+*/
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ericchiang/swaggopher/coerce"
+	"github.com/ericchiang/swaggopher/runtime"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// Error is the body of a response to a request which doesn't satisfy the
+// document.
+type Error struct {
+	Message string `json:"message"`
+	// Errors holds a problem for each invalid parameter.
+	Errors []Problem `json:"errors,omitempty"`
+}
+
+// Problem is an invalid parameter of a request.
+type Problem struct {
+	// In and Name identify the parameter, such as "query" and "limit". Both
+	// are empty if the request couldn't be read.
+	In      string `json:"in,omitempty"`
+	Name    string `json:"name,omitempty"`
+	Message string `json:"message"`
+}
+
+// Options configures the middleware. The zero value uses the default
+// components of package runtime.
+type Options struct {
+	// Router matches requests to operations. If nil, runtime.DefaultRouter
+	// is used.
+	Router runtime.Router
+	// Validator checks requests and responses. If nil, a validator from
+	// runtime.NewCompilingValidator is used, which compiles the parameter
+	// and response schemas of operations as they're first used.
+	Validator runtime.Validator
+	// CompiledOperations bounds the operations whose compiled schemas the
+	// default validator keeps, dropping the least recently used.
+	// If zero, DefaultCompiledOperations is used, and if negative, the
+	// number is unbounded. It's ignored if Validator is set.
+	CompiledOperations int
+	// Coerce controls how strictly the default validator parses parameter
+	// values. It's ignored if Validator is set.
+	Coerce coerce.Options
+}
+
+// DefaultCompiledOperations is the number of operations whose compiled schemas
+// the default validator keeps.
+const DefaultCompiledOperations = 1000
+
+func (o Options) router() runtime.Router {
+	if o.Router == nil {
+		return runtime.DefaultRouter
+	}
+	return o.Router
+}
+
+func (o Options) validator() runtime.Validator {
+	if o.Validator != nil {
+		return o.Validator
+	}
+	n := o.CompiledOperations
+	if n == 0 {
+		n = DefaultCompiledOperations
+	}
+	return runtime.ValidatorOptions{Coerce: o.Coerce}.NewCompilingValidator(n)
+}
+
+// Validator returns middleware which checks requests against a document before
+// calling the handler it wraps, using the default options. See
+// Options.ValidateRequests.
+func Validator(doc *spec.Swagger) func(http.Handler) http.Handler {
+	return Options{}.ValidateRequests(doc)
+}
+
+// ValidateRequests returns middleware which checks requests against a document before
+// calling the handler it wraps. Requests which don't match a path are answered
+// with a 404, those using a method the path doesn't support with a 405, and
+// those whose parameters don't satisfy the operation with a 400.
+//
+// The body of valid requests is read, then replaced so the handler can still
+// read it.
+func (o Options) ValidateRequests(doc *spec.Swagger) func(http.Handler) http.Handler {
+	router, validator := o.router(), o.validator()
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			m, err := router.Route(doc, r)
+			if err != nil {
+				writeRouteError(w, err)
+				return
+			}
+			problems := validator.ValidateRequest(doc, m, r)
+			if len(problems) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			e := &Error{Message: "request does not match the document"}
+			for _, p := range problems {
+				e.Errors = append(e.Errors, Problem(p))
+			}
+			writeError(w, http.StatusBadRequest, e)
+		})
+	}
+}
+
+// status returns the status of a routing error, which is a 404 unless the
+// router says otherwise.
+func status(err error) int {
+	if e, ok := err.(*runtime.RouteError); ok {
+		return e.Status
+	}
+	return http.StatusNotFound
+}
+
+// writeRouteError answers a request which couldn't be routed, redirecting it
+// if the router says to.
+func writeRouteError(w http.ResponseWriter, err error) {
+	if e, ok := err.(*runtime.RouteError); ok && e.Location != "" {
+		w.Header().Set("Location", e.Location)
+	}
+	writeError(w, status(err), &Error{Message: err.Error()})
+}
+
+func writeError(w http.ResponseWriter, code int, e *Error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(e)
+}
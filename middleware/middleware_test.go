@@ -0,0 +1,236 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/ericchiang/swaggopher/runtime"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+const petstore = `
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+basePath: /v1
+paths:
+  /pets:
+    get:
+      parameters:
+      - {name: limit, in: query, type: integer}
+      - {name: X-Request-Id, in: header, required: true, type: string}
+      responses:
+        200: {description: Pets.}
+    post:
+      parameters:
+      - {name: pet, in: body, required: true, schema: {$ref: '#/definitions/Pet'}}
+      responses:
+        201: {description: Created.}
+  /pets/{petId}:
+    parameters:
+    - {name: petId, in: path, required: true, type: integer}
+    get:
+      responses:
+        200: {description: A pet., schema: {$ref: '#/definitions/Pet'}}
+  /search:
+    get:
+      parameters:
+      - {name: kind, in: query, type: string, enum: [cat, dog]}
+      - {name: code, in: query, type: string, pattern: '^[a-z]+$', minLength: 2, maxLength: 4}
+      - {name: age, in: query, type: integer, minimum: 1, maximum: 30, multipleOf: 2}
+      - {name: ids, in: query, type: array, items: {type: integer}, minItems: 2, maxItems: 3, uniqueItems: true}
+      responses:
+        200: {description: Pets.}
+  /trees:
+    post:
+      parameters:
+      - {name: tree, in: body, required: true, schema: {$ref: '#/definitions/Node'}}
+      responses:
+        201: {description: Created.}
+definitions:
+  Pet:
+    type: object
+    required: [name]
+    properties:
+      name: {type: string}
+      age: {type: integer}
+  Node:
+    type: object
+    properties:
+      name: {type: string}
+      child: {$ref: '#/definitions/Node'}
+`
+
+func TestValidator(t *testing.T) {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(petstore), &s); err != nil {
+		t.Fatal(err)
+	}
+	h := Validator(&s)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The handler can still read the body.
+		data, _ := ioutil.ReadAll(r.Body)
+		w.Write(data)
+	}))
+
+	tests := []struct {
+		method, path, body string
+		header             map[string]string
+		wantStatus         int
+		// wantErrors holds the "in" and "name" of each reported problem.
+		wantErrors [][2]string
+	}{
+		{
+			method:     "GET",
+			path:       "/v1/pets?limit=10",
+			header:     map[string]string{"X-Request-Id": "1"},
+			wantStatus: http.StatusOK,
+		},
+		{
+			method:     "GET",
+			path:       "/v1/pets?limit=ten",
+			wantStatus: http.StatusBadRequest,
+			wantErrors: [][2]string{{"query", "limit"}, {"header", "X-Request-Id"}},
+		},
+		{
+			method:     "POST",
+			path:       "/v1/pets",
+			body:       `{"name": "Rex", "age": 3}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			method:     "POST",
+			path:       "/v1/pets",
+			body:       `{"age": "three"}`,
+			wantStatus: http.StatusBadRequest,
+			wantErrors: [][2]string{{"body", "pet"}, {"body", "pet"}},
+		},
+		{
+			method:     "GET",
+			path:       "/v1/pets/rex",
+			wantStatus: http.StatusBadRequest,
+			wantErrors: [][2]string{{"path", "petId"}},
+		},
+		{
+			method:     "GET",
+			path:       "/v1/search?kind=cat&code=abc&age=4&ids=1,2",
+			wantStatus: http.StatusOK,
+		},
+		{method: "GET", path: "/v1/search?kind=cow", wantStatus: http.StatusBadRequest, wantErrors: [][2]string{{"query", "kind"}}},
+		{method: "GET", path: "/v1/search?code=AB", wantStatus: http.StatusBadRequest, wantErrors: [][2]string{{"query", "code"}}},
+		{method: "GET", path: "/v1/search?code=abcde", wantStatus: http.StatusBadRequest, wantErrors: [][2]string{{"query", "code"}}},
+		{method: "GET", path: "/v1/search?code=a", wantStatus: http.StatusBadRequest, wantErrors: [][2]string{{"query", "code"}}},
+		{method: "GET", path: "/v1/search?age=32", wantStatus: http.StatusBadRequest, wantErrors: [][2]string{{"query", "age"}}},
+		{method: "GET", path: "/v1/search?age=0", wantStatus: http.StatusBadRequest, wantErrors: [][2]string{{"query", "age"}}},
+		{method: "GET", path: "/v1/search?age=3", wantStatus: http.StatusBadRequest, wantErrors: [][2]string{{"query", "age"}}},
+		{method: "GET", path: "/v1/search?ids=1", wantStatus: http.StatusBadRequest, wantErrors: [][2]string{{"query", "ids"}}},
+		{method: "GET", path: "/v1/search?ids=1,2,3,4", wantStatus: http.StatusBadRequest, wantErrors: [][2]string{{"query", "ids"}}},
+		{method: "GET", path: "/v1/search?ids=1,1", wantStatus: http.StatusBadRequest, wantErrors: [][2]string{{"query", "ids"}}},
+		{
+			method:     "POST",
+			path:       "/v1/trees",
+			body:       strings.Repeat(`{"child": `, 12) + `{"name": 1}` + strings.Repeat("}", 12),
+			wantStatus: http.StatusBadRequest,
+			wantErrors: [][2]string{{"body", "tree"}},
+		},
+		{method: "GET", path: "/v1/toys", wantStatus: http.StatusNotFound},
+		{method: "DELETE", path: "/v1/pets", wantStatus: http.StatusMethodNotAllowed},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest(test.method, test.path, strings.NewReader(test.body))
+		for k, v := range test.header {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		name := test.method + " " + test.path
+		if w.Code != test.wantStatus {
+			t.Errorf("%s: want status %d, got %d: %s", name, test.wantStatus, w.Code, w.Body)
+			continue
+		}
+		if w.Code == http.StatusOK {
+			if got := w.Body.String(); got != test.body {
+				t.Errorf("%s: handler read body %q, want %q", name, got, test.body)
+			}
+			continue
+		}
+		var e Error
+		if err := json.Unmarshal(w.Body.Bytes(), &e); err != nil {
+			t.Errorf("%s: decoding error: %v", name, err)
+			continue
+		}
+		if e.Message == "" {
+			t.Errorf("%s: expected an error message", name)
+		}
+		var got [][2]string
+		for _, p := range e.Errors {
+			if p.Message == "" {
+				t.Errorf("%s: %s parameter %s has no message", name, p.In, p.Name)
+			}
+			got = append(got, [2]string{p.In, p.Name})
+		}
+		if diff := pretty.Compare(test.wantErrors, got); diff != "" {
+			t.Errorf("%s: errors: %s", name, diff)
+		}
+	}
+}
+
+type fixedRouter struct{ m *runtime.Match }
+
+func (f fixedRouter) Route(doc *spec.Swagger, r *http.Request) (*runtime.Match, error) {
+	return f.m, nil
+}
+
+type rejectAll struct{}
+
+func (rejectAll) ValidateRequest(doc *spec.Swagger, m *runtime.Match, r *http.Request) []runtime.Problem {
+	return []runtime.Problem{{Message: "rejected " + m.String()}}
+}
+
+func (rejectAll) ValidateResponse(doc *spec.Swagger, op *spec.Operation, code int, header http.Header, body []byte) []string {
+	return []string{"rejected"}
+}
+
+func TestValidatorOptions(t *testing.T) {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(petstore), &s); err != nil {
+		t.Fatal(err)
+	}
+	item := s.Paths["/pets"]
+	opts := Options{
+		Router:    fixedRouter{&runtime.Match{Template: "/pets", Method: "GET", Item: &item, Operation: item.Get}},
+		Validator: rejectAll{},
+	}
+	h := opts.ValidateRequests(&s)(http.NotFoundHandler())
+
+	// Any request is routed to GET /pets, then rejected.
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/v1/toys", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("want status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+	var e Error
+	if err := json.Unmarshal(w.Body.Bytes(), &e); err != nil {
+		t.Fatal(err)
+	}
+	want := []Problem{{Message: "rejected GET /pets"}}
+	if diff := pretty.Compare(want, e.Errors); diff != "" {
+		t.Errorf("errors: %s", diff)
+	}
+
+	var got []string
+	h = opts.ValidateResponses(&s, func(r *http.Request, problems []string) {
+		got = append(got, problems...)
+	})(http.NotFoundHandler())
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/toys", nil))
+	if diff := pretty.Compare([]string{"rejected"}, got); diff != "" {
+		t.Errorf("response problems: %s", diff)
+	}
+}
@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+
+	"github.com/ericchiang/swaggopher/runtime"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// ResponseValidator returns middleware which checks the responses of the
+// handler it wraps, using the default options. See Options.ValidateResponses.
+func ResponseValidator(doc *spec.Swagger, report func(r *http.Request, problems []string)) func(http.Handler) http.Handler {
+	return Options{}.ValidateResponses(doc, report)
+}
+
+// ValidateResponses returns middleware which checks the responses of the
+// handler it wraps against the operations requests are routed to. Responses
+// with an undocumented status, a content type the operation doesn't produce,
+// or a JSON body which doesn't conform to the response's schema are passed to
+// report with a description of each problem.
+//
+// Responses are passed through unchanged as they're written, and checked once
+// the handler returns. Requests which don't match an operation aren't checked.
+func (o Options) ValidateResponses(doc *spec.Swagger, report func(r *http.Request, problems []string)) func(http.Handler) http.Handler {
+	router, validator := o.router(), o.validator()
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			m, err := router.Route(doc, r)
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			rec := &recorder{ResponseWriter: w}
+			next.ServeHTTP(rec, r)
+			if rec.status == 0 {
+				// The handler wrote nothing, which net/http answers with a 200.
+				rec.status, rec.header = http.StatusOK, cloneHeader(w.Header())
+			}
+			if problems := checkResponse(validator, doc, m, rec); len(problems) > 0 {
+				report(r, problems)
+			}
+		})
+	}
+}
+
+// TB is the part of testing.TB used by CheckResponses.
+type TB interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// CheckResponses returns ResponseValidator middleware which fails a test for
+// each response that doesn't match the document, using the default options.
+// See Options.CheckResponses.
+func CheckResponses(t TB, doc *spec.Swagger) func(http.Handler) http.Handler {
+	return Options{}.CheckResponses(t, doc)
+}
+
+// CheckResponses returns ResponseValidator middleware which fails a test for
+// each response that doesn't match the document. It's intended to wrap the
+// handler under test in an httptest.Server:
+//
+//	srv := httptest.NewServer(middleware.CheckResponses(t, doc)(handler))
+func (o Options) CheckResponses(t TB, doc *spec.Swagger) func(http.Handler) http.Handler {
+	return o.ValidateResponses(doc, func(r *http.Request, problems []string) {
+		t.Helper()
+		for _, p := range problems {
+			t.Errorf("%s %s: response does not match the document: %s", r.Method, r.URL.Path, p)
+		}
+	})
+}
+
+func checkResponse(v runtime.Validator, doc *spec.Swagger, m *runtime.Match, rec *recorder) []string {
+	var problems []string
+	if ct := rec.header.Get("Content-Type"); ct != "" && rec.body.Len() > 0 {
+		produces := m.Operation.Produces
+		if len(produces) == 0 {
+			produces = doc.Produces
+		}
+		if len(produces) > 0 && !producible(produces, ct) {
+			problems = append(problems, fmt.Sprintf("content type %q is not one the operation produces", ct))
+		}
+	}
+	return append(problems, v.ValidateResponse(doc, m.Operation, rec.status, rec.header, rec.body.Bytes())...)
+}
+
+// producible reports whether a content type is one of a list of media types.
+func producible(produces []string, contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, p := range produces {
+		if mt, _, err := mime.ParseMediaType(p); err == nil && mt == mediaType {
+			return true
+		}
+	}
+	return false
+}
+
+// recorder passes a response through while recording its status, headers
+// and body.
+type recorder struct {
+	http.ResponseWriter
+	status int
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (r *recorder) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+		r.header = cloneHeader(r.ResponseWriter.Header())
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *recorder) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.WriteHeader(http.StatusOK)
+	}
+	r.body.Write(p)
+	return r.ResponseWriter.Write(p)
+}
+
+// cloneHeader copies a header so later changes by the handler aren't seen.
+func cloneHeader(h http.Header) http.Header {
+	c := make(http.Header, len(h))
+	for k, v := range h {
+		c[k] = append([]string(nil), v...)
+	}
+	return c
+}
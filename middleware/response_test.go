@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+func TestResponseValidator(t *testing.T) {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(petstore), &s); err != nil {
+		t.Fatal(err)
+	}
+	s.Produces = []string{"application/json"}
+
+	tests := []struct {
+		name    string
+		path    string
+		handler http.HandlerFunc
+		want    []string
+	}{
+		{
+			name: "documented",
+			path: "/v1/pets/1",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(w, `{"name": "Rex"}`)
+			},
+		},
+		{
+			name: "undocumented status",
+			path: "/v1/pets/1",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusTeapot)
+			},
+			want: []string{"status 418 is not documented"},
+		},
+		{
+			name: "content type",
+			path: "/v1/pets/1",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+				fmt.Fprint(w, "Rex")
+			},
+			want: []string{`content type "text/plain; charset=utf-8" is not one the operation produces`},
+		},
+		{
+			name: "schema",
+			path: "/v1/pets/1",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(w, `{"age": 3}`)
+			},
+			want: []string{`body: missing required property "name"`},
+		},
+		{
+			name: "not routed",
+			path: "/v1/toys",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusTeapot)
+			},
+		},
+	}
+	for _, test := range tests {
+		var got []string
+		h := ResponseValidator(&s, func(r *http.Request, problems []string) {
+			got = append(got, problems...)
+		})(test.handler)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", test.path, nil))
+		if diff := pretty.Compare(test.want, got); diff != "" {
+			t.Errorf("%s: problems: %s", test.name, diff)
+		}
+		// The response is passed through unchanged.
+		want := httptest.NewRecorder()
+		test.handler(want, httptest.NewRequest("GET", test.path, nil))
+		if w.Code != want.Code || w.Body.String() != want.Body.String() {
+			t.Errorf("%s: want response %d %q, got %d %q", test.name, want.Code, want.Body, w.Code, w.Body)
+		}
+	}
+}
+
+type fakeTB []string
+
+func (t *fakeTB) Helper() {}
+
+func (t *fakeTB) Errorf(format string, args ...interface{}) {
+	*t = append(*t, fmt.Sprintf(format, args...))
+}
+
+func TestCheckResponses(t *testing.T) {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(petstore), &s); err != nil {
+		t.Fatal(err)
+	}
+	var tb fakeTB
+	srv := httptest.NewServer(CheckResponses(&tb, &s)(http.NotFoundHandler()))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/v1/pets/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	want := fakeTB{"GET /v1/pets/1: response does not match the document: status 404 is not documented"}
+	if diff := pretty.Compare(want, tb); diff != "" {
+		t.Errorf("test failures: %s", diff)
+	}
+}
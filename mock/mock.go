@@ -0,0 +1,329 @@
+/*
+Package mock serves a document without any real behavior, so clients can be
+developed and tested against an API before it's implemented.
+
+NewServer returns a handler which routes requests to the document's operations
+and rejects those which don't satisfy an operation's parameters with a 400.
+The rest are answered with the operation's lowest documented successful
+status, or its default response, and a body taken from the response's
+examples:
+
+	responses:
+	  200:
+	    description: A pet.
+	    schema: {$ref: '#/definitions/Pet'}
+	    examples:
+	      application/json: {id: 1, name: Rex}
+
+If the response has no example for a media type the request accepts, a value
+is generated from the response's schema, preferring the examples, defaults
+and enum values of the schemas it's composed of. Set Options.Examples to
+generate varied values with package examplegen instead. Each response is
+generated with a fresh generator, so a schema's value depends only on the
+seed and stays the same across requests. Headers the response declares
+with a default, such as Cache-Control, are set to it.
+
+Clients can ask for another documented response with the Prefer header, for
+example to develop against an error:
+
+	Prefer: code=404
+
+Set Options.Cache to cache the responses to GET requests as package cache
+describes, so clients can be developed against an API's caching.
+*/
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/cache"
+	"github.com/ericchiang/swaggopher/coerce"
+	"github.com/ericchiang/swaggopher/examplegen"
+	"github.com/ericchiang/swaggopher/internal/httpcheck"
+	"github.com/ericchiang/swaggopher/internal/jsonpointer"
+	"github.com/ericchiang/swaggopher/internal/synth"
+	"github.com/ericchiang/swaggopher/runtime"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// Options configures a mock server. The zero value uses the default
+// components of package runtime.
+type Options struct {
+	// Router matches requests to operations. If nil, runtime.DefaultRouter
+	// is used.
+	Router runtime.Router
+	// Validator checks requests. If nil, runtime.DefaultValidator is used.
+	Validator runtime.Validator
+	// Coerce controls how strictly the default validator parses parameter
+	// values. It's ignored if Validator is set.
+	Coerce coerce.Options
+	// Examples, if set, configures the generator of bodies for responses
+	// without examples. Its Doc defaults to the served document.
+	Examples *examplegen.Options
+	// Cache, if set, stores the responses to GET requests which the document
+	// says may be cached, as package cache describes, and serves them without
+	// checking the request. Responses are also keyed by the Accept and Prefer
+	// headers, which choose between them. If the document's cache extensions are invalid,
+	// every request is answered with a 500 reporting them.
+	Cache runtime.Cache
+	// Clock tells the time for cached responses. If nil,
+	// runtime.SystemClock is used.
+	Clock runtime.Clock
+}
+
+// NewServer returns a handler which implements a document with example
+// responses, using the default options.
+func NewServer(doc *spec.Swagger) http.Handler {
+	return Options{}.NewServer(doc)
+}
+
+// NewServer returns a handler which implements a document with example
+// responses.
+func (o Options) NewServer(doc *spec.Swagger) http.Handler {
+	s := &server{doc: doc, router: o.Router, validator: o.Validator}
+	if o.Examples != nil {
+		opts := *o.Examples
+		if opts.Doc == nil {
+			opts.Doc = doc
+		}
+		s.examples = &opts
+	}
+	if s.router == nil {
+		s.router = runtime.DefaultRouter
+	}
+	if s.validator == nil {
+		s.validator = runtime.ValidatorOptions{Coerce: o.Coerce}.NewValidator()
+	}
+	if o.Cache == nil {
+		return s
+	}
+	if o.Clock == nil {
+		o.Clock = runtime.SystemClock
+	}
+	// The response chosen depends on the Accept and Prefer headers.
+	h, err := cache.Handler(doc, s, cache.Options{
+		Backend: o.Cache,
+		Now:     o.Clock.Now,
+		Router:  s.router,
+		Vary:    []string{"Accept", "Prefer"},
+	})
+	if err != nil {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s.error(w, http.StatusInternalServerError, "%s", err)
+		})
+	}
+	return h
+}
+
+type server struct {
+	doc       *spec.Swagger
+	router    runtime.Router
+	validator runtime.Validator
+	examples  *examplegen.Options
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m, err := s.router.Route(s.doc, r)
+	if err != nil {
+		code := http.StatusNotFound
+		if e, ok := err.(*runtime.RouteError); ok {
+			code = e.Status
+			if e.Location != "" {
+				w.Header().Set("Location", e.Location)
+			}
+		}
+		s.error(w, code, "%s", err)
+		return
+	}
+	if msg := httpcheck.Message(s.validator.ValidateRequest(s.doc, m, r)); msg != "" {
+		s.error(w, http.StatusBadRequest, "%s", msg)
+		return
+	}
+
+	code, resp := success(m.Operation)
+	if prefer, ok := preferredCode(r); ok {
+		if code, resp, ok = documented(m.Operation, prefer); !ok {
+			s.error(w, http.StatusBadRequest, "%s does not document a %d response", m, prefer)
+			return
+		}
+	}
+	if resp != nil && resp.Ref != "" {
+		if target, ok := s.doc.Responses[jsonpointer.Unescape(strings.TrimPrefix(resp.Ref, "#/responses/"))]; ok {
+			resp = &target
+		}
+	}
+	if resp != nil {
+		for name, h := range resp.Headers {
+			if h.Default != nil {
+				w.Header().Set(name, fmt.Sprint(h.Default))
+			}
+		}
+	}
+	if resp == nil || code == http.StatusNoContent {
+		w.WriteHeader(code)
+		return
+	}
+
+	produces := m.Operation.Produces
+	if len(produces) == 0 {
+		produces = s.doc.Produces
+	}
+	if mediaType, example, ok := pickExample(resp.Examples, r.Header.Get("Accept"), produces); ok {
+		s.write(w, code, mediaType, example)
+		return
+	}
+	if resp.Schema == nil {
+		w.WriteHeader(code)
+		return
+	}
+	s.write(w, code, "application/json", s.example(resp.Schema))
+}
+
+// example returns a body for a response without examples.
+func (s *server) example(schema *spec.Schema) interface{} {
+	if s.examples == nil {
+		return synth.Example(s.doc, schema, false)
+	}
+	return examplegen.New(*s.examples).Generate(schema)
+}
+
+// write encodes a body as JSON, unless it's a string of a media type which
+// isn't JSON.
+func (s *server) write(w http.ResponseWriter, code int, mediaType string, body interface{}) {
+	w.Header().Set("Content-Type", mediaType)
+	w.WriteHeader(code)
+	if text, ok := body.(string); ok && !isJSON(mediaType) {
+		fmt.Fprint(w, text)
+		return
+	}
+	json.NewEncoder(w).Encode(body)
+}
+
+func (s *server) error(w http.ResponseWriter, code int, format string, args ...interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"message": fmt.Sprintf(format, args...)})
+}
+
+// success returns the response a mock answers an operation with: the lowest
+// documented 2xx response, falling back to the default response. If there's
+// neither, it returns a 200 and nil.
+func success(op *spec.Operation) (int, *spec.Response) {
+	var codes []int
+	for code := range op.Responses {
+		if n, err := strconv.Atoi(code); err == nil && n >= 200 && n < 300 {
+			codes = append(codes, n)
+		}
+	}
+	if len(codes) > 0 {
+		sort.Ints(codes)
+		r := op.Responses[strconv.Itoa(codes[0])]
+		return codes[0], &r
+	}
+	if r, ok := op.Responses["default"]; ok {
+		return http.StatusOK, &r
+	}
+	return http.StatusOK, nil
+}
+
+// documented returns the response an operation documents for a status code,
+// or its default response.
+func documented(op *spec.Operation, code int) (int, *spec.Response, bool) {
+	if r, ok := op.Responses[strconv.Itoa(code)]; ok {
+		return code, &r, true
+	}
+	if r, ok := op.Responses["default"]; ok {
+		return code, &r, true
+	}
+	return 0, nil, false
+}
+
+// preferredCode returns the status code a request asks for with a Prefer
+// header such as "code=404".
+func preferredCode(r *http.Request) (int, bool) {
+	for _, v := range r.Header["Prefer"] {
+		for _, pref := range strings.Split(v, ",") {
+			pref = strings.TrimSpace(pref)
+			if !strings.HasPrefix(pref, "code=") {
+				continue
+			}
+			if code, err := strconv.Atoi(strings.TrimPrefix(pref, "code=")); err == nil {
+				return code, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// pickExample returns the example whose media type the request accepts,
+// preferring the order the request lists them in, then the order the operation
+// produces them in. Requests without an Accept header accept any.
+func pickExample(examples spec.Example, accept string, produces []string) (string, interface{}, bool) {
+	if len(examples) == 0 {
+		return "", nil, false
+	}
+	available := make([]string, 0, len(examples))
+	for mediaType := range examples {
+		available = append(available, mediaType)
+	}
+	sort.Slice(available, func(i, j int) bool {
+		pi, pj := index(produces, available[i]), index(produces, available[j])
+		if pi != pj {
+			return pi < pj
+		}
+		return available[i] < available[j]
+	})
+	if strings.TrimSpace(accept) == "" {
+		accept = "*/*"
+	}
+	for _, a := range strings.Split(accept, ",") {
+		want, _, err := mime.ParseMediaType(strings.TrimSpace(a))
+		if err != nil {
+			continue
+		}
+		for _, mediaType := range available {
+			if accepts(want, mediaType) {
+				return mediaType, examples[mediaType], true
+			}
+		}
+	}
+	return "", nil, false
+}
+
+// accepts reports whether a media range, such as "application/*", includes a
+// media type.
+func accepts(mediaRange, mediaType string) bool {
+	mt, _, err := mime.ParseMediaType(mediaType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case mediaRange == "*/*" || mediaRange == mt:
+		return true
+	case strings.HasSuffix(mediaRange, "/*"):
+		return strings.HasPrefix(mt, strings.TrimSuffix(mediaRange, "*"))
+	}
+	return false
+}
+
+// index returns the position of a media type in a list, or the length of the
+// list if it's not there.
+func index(list []string, mediaType string) int {
+	for i, v := range list {
+		if v == mediaType {
+			return i
+		}
+	}
+	return len(list)
+}
+
+func isJSON(mediaType string) bool {
+	mt, _, _ := mime.ParseMediaType(mediaType)
+	return strings.HasSuffix(mt, "json")
+}
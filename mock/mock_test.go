@@ -0,0 +1,247 @@
+package mock
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/ericchiang/swaggopher/cache"
+	"github.com/ericchiang/swaggopher/examplegen"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+const petstore = `
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+basePath: /v1
+produces: [application/json, application/xml]
+paths:
+  /pets:
+    get:
+      parameters:
+      - {name: limit, in: query, type: integer}
+      responses:
+        200:
+          description: Pets.
+          schema: {type: array, items: {$ref: '#/definitions/Pet'}}
+          headers:
+            Cache-Control: {type: string, default: no-cache}
+    post:
+      parameters:
+      - {name: pet, in: body, required: true, schema: {$ref: '#/definitions/Pet'}}
+      responses:
+        201: {description: Created.}
+  /pets/{petId}:
+    get:
+      parameters:
+      - {name: petId, in: path, required: true, type: integer}
+      responses:
+        200:
+          description: A pet.
+          schema: {$ref: '#/definitions/Pet'}
+          examples:
+            application/json: {name: Rex}
+            application/xml: <pet><name>Rex</name></pet>
+        404:
+          description: Not found.
+          examples:
+            application/json: {message: no such pet}
+definitions:
+  Pet:
+    type: object
+    required: [name]
+    properties:
+      name: {type: string, example: Fido}
+`
+
+func TestServer(t *testing.T) {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(petstore), &s); err != nil {
+		t.Fatal(err)
+	}
+	h := NewServer(&s)
+
+	tests := []struct {
+		method, path, body string
+		header             map[string]string
+		wantStatus         int
+		wantType           string
+		wantBody           string
+		wantHeader         map[string]string
+	}{
+		{
+			// Synthesized from the schema.
+			method:     "GET",
+			path:       "/v1/pets",
+			wantStatus: http.StatusOK,
+			wantType:   "application/json",
+			wantBody:   `[{"name":"Fido"}]` + "\n",
+			wantHeader: map[string]string{"Cache-Control": "no-cache"},
+		},
+		{
+			method:     "GET",
+			path:       "/v1/pets/1",
+			wantStatus: http.StatusOK,
+			wantType:   "application/json",
+			wantBody:   `{"name":"Rex"}` + "\n",
+		},
+		{
+			method:     "GET",
+			path:       "/v1/pets/1",
+			header:     map[string]string{"Accept": "application/xml"},
+			wantStatus: http.StatusOK,
+			wantType:   "application/xml",
+			wantBody:   "<pet><name>Rex</name></pet>",
+		},
+		{
+			method:     "GET",
+			path:       "/v1/pets/1",
+			header:     map[string]string{"Prefer": "code=404"},
+			wantStatus: http.StatusNotFound,
+			wantType:   "application/json",
+			wantBody:   `{"message":"no such pet"}` + "\n",
+		},
+		{
+			method:     "GET",
+			path:       "/v1/pets/1",
+			header:     map[string]string{"Prefer": "code=500"},
+			wantStatus: http.StatusBadRequest,
+		},
+		{method: "POST", path: "/v1/pets", body: `{"name": "Rex"}`, wantStatus: http.StatusCreated},
+		{method: "POST", path: "/v1/pets", body: `{}`, wantStatus: http.StatusBadRequest},
+		{method: "GET", path: "/v1/pets?limit=ten", wantStatus: http.StatusBadRequest},
+		{method: "GET", path: "/v1/toys", wantStatus: http.StatusNotFound},
+		{method: "DELETE", path: "/v1/pets", wantStatus: http.StatusMethodNotAllowed},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest(test.method, test.path, strings.NewReader(test.body))
+		for k, v := range test.header {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		name := test.method + " " + test.path
+		if w.Code != test.wantStatus {
+			t.Errorf("%s %v: want status %d, got %d: %s", name, test.header, test.wantStatus, w.Code, w.Body)
+			continue
+		}
+		if test.wantType != "" {
+			if got := w.Header().Get("Content-Type"); got != test.wantType {
+				t.Errorf("%s %v: want content type %q, got %q", name, test.header, test.wantType, got)
+			}
+			if got := w.Body.String(); got != test.wantBody {
+				t.Errorf("%s %v: want body %q, got %q", name, test.header, test.wantBody, got)
+			}
+		}
+		for k, v := range test.wantHeader {
+			if got := w.Header().Get(k); got != v {
+				t.Errorf("%s: want header %s: %q, got %q", name, k, v, got)
+			}
+		}
+	}
+}
+
+func TestServerExamples(t *testing.T) {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(petstore), &s); err != nil {
+		t.Fatal(err)
+	}
+	get := func(h http.Handler) string {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/v1/pets", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("want status 200, got %d: %s", w.Code, w.Body)
+		}
+		return w.Body.String()
+	}
+
+	h := Options{Examples: &examplegen.Options{Seed: 3, Realistic: true}}.NewServer(&s)
+	first := get(h)
+	if second := get(h); first != second {
+		t.Errorf("responses differ between requests: %q and %q", first, second)
+	}
+	var pets []struct{ Name string }
+	if err := json.Unmarshal([]byte(first), &pets); err != nil {
+		t.Fatal(err)
+	}
+	if len(pets) == 0 || !strings.Contains(pets[0].Name, " ") {
+		t.Errorf("expected pets with realistic names, got %s", first)
+	}
+	if other := get(Options{Examples: &examplegen.Options{Seed: 4, Realistic: true}}.NewServer(&s)); other == first {
+		t.Errorf("different seeds generated the same response: %s", first)
+	}
+	// Responses with examples still use them.
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/v1/pets/1", nil))
+	if got, want := w.Body.String(), `{"name":"Rex"}`+"\n"; got != want {
+		t.Errorf("want body %q, got %q", want, got)
+	}
+}
+
+func TestServerCache(t *testing.T) {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(petstore), &s); err != nil {
+		t.Fatal(err)
+	}
+	s.Paths["/pets/{petId}"].Get.Extensions = map[string]interface{}{cache.TTLExtension: "30s"}
+
+	backend := &countingCache{Memory: cache.NewMemory(0)}
+	clock := &fakeClock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	h := Options{Cache: backend, Clock: clock}.NewServer(&s)
+	for i, elapsed := range []time.Duration{0, 10 * time.Second, 30 * time.Second} {
+		clock.now = clock.now.Add(elapsed)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/v1/pets/1", nil))
+		if got, want := w.Body.String(), `{"name":"Rex"}`+"\n"; w.Code != http.StatusOK || got != want {
+			t.Errorf("request %d: want 200 %q, got %d %q", i, want, w.Code, got)
+		}
+	}
+	// The response expires 30s after the first request, so only the third
+	// is stored again.
+	if backend.sets != 2 {
+		t.Errorf("want 2 responses stored, got %d", backend.sets)
+	}
+	// The response chosen depends on the Accept and Prefer headers, so
+	// requests asking for another aren't served the cached one.
+	for _, tt := range []struct{ header, value, want string }{
+		{"Accept", "application/xml", "<pet><name>Rex</name></pet>"},
+		{"Prefer", "code=404", `{"message":"no such pet"}` + "\n"},
+	} {
+		r := httptest.NewRequest("GET", "/v1/pets/1", nil)
+		r.Header.Set(tt.header, tt.value)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if got := w.Body.String(); got != tt.want {
+			t.Errorf("%s: %s: want %q, got %q", tt.header, tt.value, tt.want, got)
+		}
+	}
+
+	s.Paths["/pets/{petId}"].Get.Extensions[cache.TTLExtension] = "soon"
+	w := httptest.NewRecorder()
+	Options{Cache: backend}.NewServer(&s).ServeHTTP(w, httptest.NewRequest("GET", "/v1/pets/1", nil))
+	if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), cache.TTLExtension) {
+		t.Errorf("invalid extension: want a 500 naming %s, got %d %s", cache.TTLExtension, w.Code, w.Body)
+	}
+}
+
+type countingCache struct {
+	*cache.Memory
+	sets int
+}
+
+func (c *countingCache) Set(key string, e *cache.Entry) {
+	c.sets++
+	c.Memory.Set(key, e)
+}
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
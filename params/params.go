@@ -0,0 +1,328 @@
+/*
+Package params encodes and decodes the values of non-body parameters as the
+specification describes: path variables, query strings, headers and form
+fields, with arrays joined by their collectionFormat.
+
+Decoding reads a parameter's strings from a request, applies its default when
+it's absent, and converts them to the types coerce.Value returns:
+
+	values, err := params.Decode(r, vars, op.Parameters)
+	limit, _ := values["limit"].(int64)
+
+Encoding is the inverse, building the path, query, header and form of a
+request from values of those types:
+
+	enc, err := params.Encode("/pets/{petId}", op.Parameters, map[string]interface{}{
+		"petId": int64(1),
+		"tags":  []interface{}{"cat", "dog"},
+	})
+
+The code generators write the same encodings into generated clients and
+servers.
+*/
+package params
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/coerce"
+	"github.com/ericchiang/swaggopher/internal/mapkeys"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// Options configures how values are decoded. The zero value only accepts the
+// canonical encodings defined by the specification.
+type Options struct {
+	coerce.Options
+}
+
+// Decode decodes the values of parameters from a request using the default
+// options. See Options.Decode.
+func Decode(r *http.Request, vars map[string]string, params []spec.Parameter) (map[string]interface{}, error) {
+	return Options{}.Decode(r, vars, params)
+}
+
+// Parse converts the strings given for a parameter using the default options.
+// See Options.Parse.
+func Parse(p *spec.Parameter, values []string) (interface{}, error) {
+	return Options{}.Parse(p, values)
+}
+
+// Decode decodes the values of parameters from a request, keyed by name. vars
+// holds the values of the path's variables. Body parameters and references
+// are skipped, and parameters which are absent and have no default are left
+// out.
+//
+// File parameters are decoded as *multipart.FileHeader. The request's form is
+// parsed if it hasn't been already.
+func (o Options) Decode(r *http.Request, vars map[string]string, params []spec.Parameter) (map[string]interface{}, error) {
+	if r.PostForm == nil {
+		if err := r.ParseMultipartForm(32 << 20); err != nil && err != http.ErrNotMultipart {
+			return nil, fmt.Errorf("params: parsing form: %v", err)
+		}
+	}
+	decoded := make(map[string]interface{})
+	for i := range params {
+		p := &params[i]
+		if p.Ref != "" || p.In == "body" {
+			continue
+		}
+		if p.Type == "file" {
+			if r.MultipartForm != nil && len(r.MultipartForm.File[p.Name]) > 0 {
+				decoded[p.Name] = r.MultipartForm.File[p.Name][0]
+			} else if p.Required {
+				return nil, missing(p)
+			}
+			continue
+		}
+		values, _ := Lookup(r, vars, p)
+		v, err := o.Parse(p, values)
+		if err != nil {
+			return nil, err
+		}
+		if v != nil {
+			decoded[p.Name] = v
+		}
+	}
+	return decoded, nil
+}
+
+// Lookup returns the strings given for a parameter by a request, and whether
+// it was given at all. Form values are read from r.PostForm and, for file
+// parameters, r.MultipartForm, which must already be parsed.
+func Lookup(r *http.Request, vars map[string]string, p *spec.Parameter) ([]string, bool) {
+	var values []string
+	switch p.In {
+	case "path":
+		v, ok := vars[p.Name]
+		if !ok {
+			return nil, false
+		}
+		values = []string{v}
+	case "query":
+		values = r.URL.Query()[p.Name]
+	case "header":
+		values = r.Header[http.CanonicalHeaderKey(p.Name)]
+	case "formData":
+		if p.Type == "file" {
+			ok := r.MultipartForm != nil && len(r.MultipartForm.File[p.Name]) > 0
+			return nil, ok
+		}
+		values = r.PostForm[p.Name]
+	}
+	return values, values != nil
+}
+
+// Parse converts the strings given for a parameter to the type coerce.Value
+// returns. Arrays with the multi collectionFormat take each value as an
+// element, and other parameters only use the first.
+//
+// If no values are given, Parse returns the parameter's default, an error if
+// it's required, or nil. An empty value of a parameter with allowEmptyValue is
+// treated the same way, unless its type is string.
+func (o Options) Parse(p *spec.Parameter, values []string) (interface{}, error) {
+	if len(values) == 0 || (p.AllowEmptyValue && values[0] == "" && p.Type != "string") {
+		if p.Default != nil {
+			return o.defaultValue(p)
+		}
+		if p.Required {
+			return nil, missing(p)
+		}
+		return nil, nil
+	}
+	if p.Type == "array" && p.CollectionFormat == "multi" {
+		if p.Items == nil {
+			return nil, fmt.Errorf("params: %s parameter %s does not declare its items", p.In, p.Name)
+		}
+		elems := make([]interface{}, len(values))
+		for i, s := range values {
+			v, err := o.Value(fmt.Sprintf("%s[%d]", p.Name, i), s, p.Items)
+			if err != nil {
+				return nil, err
+			}
+			elems[i] = v
+		}
+		return elems, nil
+	}
+	return o.Parameter(p, values[0])
+}
+
+// defaultValue converts a parameter's default, as decoded from the document,
+// to the type Parse returns.
+func (o Options) defaultValue(p *spec.Parameter) (interface{}, error) {
+	items := items(p)
+	if p.Type != "array" {
+		s, err := coerce.Format(p.Default, items)
+		if err != nil {
+			return nil, fmt.Errorf("params: default of %s parameter %s: %v", p.In, p.Name, err)
+		}
+		return o.Value(p.Name, s, items)
+	}
+	def, ok := p.Default.([]interface{})
+	if !ok || p.Items == nil {
+		return nil, fmt.Errorf("params: default of %s parameter %s is not an array", p.In, p.Name)
+	}
+	elems := make([]interface{}, len(def))
+	for i, d := range def {
+		s, err := coerce.Format(d, p.Items)
+		if err != nil {
+			return nil, fmt.Errorf("params: default of %s parameter %s: %v", p.In, p.Name, err)
+		}
+		if elems[i], err = o.Value(fmt.Sprintf("%s[%d]", p.Name, i), s, p.Items); err != nil {
+			return nil, err
+		}
+	}
+	return elems, nil
+}
+
+// Format encodes a value of a type Parse returns as the strings to send for a
+// parameter. Arrays with the multi collectionFormat have a string for each
+// element, and other values have one.
+func Format(p *spec.Parameter, v interface{}) ([]string, error) {
+	items := items(p)
+	if elems, ok := v.([]interface{}); ok && p.Type == "array" && p.CollectionFormat == "multi" {
+		if p.Items == nil {
+			return nil, fmt.Errorf("params: %s parameter %s does not declare its items", p.In, p.Name)
+		}
+		values := make([]string, len(elems))
+		for i, elem := range elems {
+			s, err := coerce.Format(elem, p.Items)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = s
+		}
+		return values, nil
+	}
+	s, err := coerce.Format(v, items)
+	if err != nil {
+		return nil, err
+	}
+	return []string{s}, nil
+}
+
+// File is the value of a file parameter to encode.
+type File struct {
+	// Name is the file name sent with the contents.
+	Name string
+	Body io.Reader
+}
+
+// Encoded holds the encoded parameters of a request.
+type Encoded struct {
+	// Path is the path template with its variables replaced by their escaped
+	// values.
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Form   url.Values
+	Files  map[string]File
+}
+
+// Encode encodes the values of parameters, keyed by name, for a request to a
+// path template. Values must be of the types Parse returns, or File for file
+// parameters. It's an error for a required parameter to have no value, unless
+// it has a default the server will apply.
+func Encode(path string, params []spec.Parameter, values map[string]interface{}) (*Encoded, error) {
+	enc := &Encoded{
+		Path:   path,
+		Query:  make(url.Values),
+		Header: make(http.Header),
+		Form:   make(url.Values),
+		Files:  make(map[string]File),
+	}
+	for i := range params {
+		p := &params[i]
+		if p.Ref != "" || p.In == "body" {
+			continue
+		}
+		v, ok := values[p.Name]
+		if !ok || v == nil {
+			if p.Required && (p.Default == nil || p.In == "path") {
+				return nil, missing(p)
+			}
+			continue
+		}
+		if p.Type == "file" {
+			f, ok := v.(File)
+			if !ok {
+				return nil, fmt.Errorf("params: file parameter %s must be a params.File, got %T", p.Name, v)
+			}
+			enc.Files[p.Name] = f
+			continue
+		}
+		strs, err := Format(p, v)
+		if err != nil {
+			return nil, fmt.Errorf("params: %s parameter %s: %v", p.In, p.Name, err)
+		}
+		switch p.In {
+		case "path":
+			enc.Path = strings.Replace(enc.Path, "{"+p.Name+"}", url.PathEscape(strs[0]), -1)
+		case "query":
+			enc.Query[p.Name] = strs
+		case "header":
+			enc.Header[http.CanonicalHeaderKey(p.Name)] = strs
+		case "formData":
+			enc.Form[p.Name] = strs
+		}
+	}
+	return enc, nil
+}
+
+// Body returns the encoded form as a request body and its content type:
+// multipart/form-data if there are files, and otherwise
+// application/x-www-form-urlencoded. It returns a nil body if there's no form.
+func (e *Encoded) Body() (io.Reader, string, error) {
+	if len(e.Files) == 0 {
+		if len(e.Form) == 0 {
+			return nil, "", nil
+		}
+		return strings.NewReader(e.Form.Encode()), "application/x-www-form-urlencoded", nil
+	}
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	for _, name := range mapkeys.Sorted(e.Form) {
+		for _, v := range e.Form[name] {
+			if err := w.WriteField(name, v); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+	for _, name := range mapkeys.Sorted(e.Files) {
+		f := e.Files[name]
+		part, err := w.CreateFormFile(name, f.Name)
+		if err != nil {
+			return nil, "", err
+		}
+		if _, err := io.Copy(part, f.Body); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+	return &buf, w.FormDataContentType(), nil
+}
+
+func items(p *spec.Parameter) *spec.Items {
+	return &spec.Items{
+		Type:             p.Type,
+		Format:           p.Format,
+		Items:            p.Items,
+		CollectionFormat: p.CollectionFormat,
+		Maximum:          p.Maximum,
+		ExclusiveMaximum: p.ExclusiveMaximum,
+		Minimum:          p.Minimum,
+		ExclusiveMinimum: p.ExclusiveMinimum,
+	}
+}
+
+func missing(p *spec.Parameter) error {
+	return fmt.Errorf("params: missing required %s parameter %s", p.In, p.Name)
+}
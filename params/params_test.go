@@ -0,0 +1,184 @@
+package params
+
+import (
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+const operation = `
+parameters:
+- {name: petId, in: path, required: true, type: integer, format: int64}
+- {name: tags, in: query, type: array, items: {type: string}, collectionFormat: pipes}
+- {name: status, in: query, type: array, items: {type: string}, collectionFormat: multi}
+- {name: limit, in: query, type: integer, default: 20, maximum: 100}
+- {name: ids, in: query, type: array, items: {type: integer}, default: [1, 2]}
+- {name: since, in: header, type: string, format: date-time}
+- {name: X-Trace, in: header, type: boolean, allowEmptyValue: true}
+- {name: note, in: formData, type: string}
+- {name: pet, in: body, schema: {type: object}}
+`
+
+func parameters(t *testing.T) []spec.Parameter {
+	var op spec.Operation
+	if err := yaml.Unmarshal([]byte(operation), &op); err != nil {
+		t.Fatal(err)
+	}
+	return op.Parameters
+}
+
+func TestDecode(t *testing.T) {
+	params := parameters(t)
+	r := httptest.NewRequest("POST", "/pets/7?tags=cat|dog&status=sold&status=available", strings.NewReader("note=good+boy"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Set("Since", "2026-10-15T12:00:00Z")
+	r.Header.Set("X-Trace", "")
+
+	got, err := Decode(r, map[string]string{"petId": "7"}, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"petId":  int64(7),
+		"tags":   []interface{}{"cat", "dog"},
+		"status": []interface{}{"sold", "available"},
+		"limit":  int64(20),
+		"ids":    []interface{}{int64(1), int64(2)},
+		"since":  time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC),
+		"note":   "good boy",
+	}
+	if diff := pretty.Compare(got, want); diff != "" {
+		t.Errorf("want != got: %s", diff)
+	}
+
+	for _, tt := range []struct {
+		url  string
+		vars map[string]string
+		want string
+	}{
+		{"/pets", nil, "params: missing required path parameter petId"},
+		{"/pets/7?limit=101", map[string]string{"petId": "7"}, `coerce: limit: invalid value "101": must be less than or equal to 100`},
+		{"/pets/7?status=sold&status=", map[string]string{"petId": "7"}, ""},
+	} {
+		_, err := Decode(httptest.NewRequest("GET", tt.url, nil), tt.vars, params)
+		if got := errString(err); got != tt.want {
+			t.Errorf("%s: want error %q, got %q", tt.url, tt.want, got)
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	params := parameters(t)
+	values := map[string]interface{}{
+		"petId":  int64(7),
+		"tags":   []interface{}{"cat", "dog"},
+		"status": []interface{}{"sold", "available"},
+		"since":  time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC),
+		"note":   "good boy",
+	}
+	enc, err := Encode("/pets/{petId}", params, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Encoded{
+		Path:   "/pets/7",
+		Query:  url.Values{"tags": {"cat|dog"}, "status": {"sold", "available"}},
+		Header: http.Header{"Since": {"2026-10-15T12:00:00Z"}},
+		Form:   url.Values{"note": {"good boy"}},
+		Files:  map[string]File{},
+	}
+	if diff := pretty.Compare(enc, want); diff != "" {
+		t.Errorf("want != got: %s", diff)
+	}
+
+	// Decoding what was encoded gives back the values, with defaults.
+	body, contentType, err := enc.Body()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest("POST", enc.Path+"?"+enc.Query.Encode(), body)
+	r.Header = enc.Header
+	r.Header.Set("Content-Type", contentType)
+	got, err := Decode(r, map[string]string{"petId": "7"}, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values["limit"] = int64(20)
+	values["ids"] = []interface{}{int64(1), int64(2)}
+	if diff := pretty.Compare(got, values); diff != "" {
+		t.Errorf("round trip: want != got: %s", diff)
+	}
+
+	if _, err := Encode("/pets/{petId}", params, nil); errString(err) != "params: missing required path parameter petId" {
+		t.Errorf("unexpected error encoding without a required parameter: %v", err)
+	}
+}
+
+func TestFiles(t *testing.T) {
+	params := []spec.Parameter{
+		{Name: "name", In: "formData", Type: "string"},
+		{Name: "photo", In: "formData", Type: "file", Required: true},
+	}
+	enc, err := Encode("/photos", params, map[string]interface{}{
+		"name":  "Rex",
+		"photo": File{Name: "rex.png", Body: strings.NewReader("PNG")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, contentType, err := enc.Body()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(contentType, "multipart/form-data; boundary=") {
+		t.Errorf("unexpected content type %q", contentType)
+	}
+	r := httptest.NewRequest("POST", "/photos", body)
+	r.Header.Set("Content-Type", contentType)
+	got, err := Decode(r, nil, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got["name"] != "Rex" {
+		t.Errorf("want name Rex, got %v", got["name"])
+	}
+	fh, ok := got["photo"].(*multipart.FileHeader)
+	if !ok {
+		t.Fatalf("want a *multipart.FileHeader, got %T", got["photo"])
+	}
+	f, err := fh.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fh.Filename != "rex.png" || string(data) != "PNG" {
+		t.Errorf("unexpected file %s: %q", fh.Filename, data)
+	}
+
+	_, err = Decode(httptest.NewRequest("POST", "/photos", nil), nil, params)
+	if errString(err) != "params: missing required formData parameter photo" {
+		t.Errorf("unexpected error decoding without a required file: %v", err)
+	}
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
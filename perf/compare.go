@@ -0,0 +1,81 @@
+package perf
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Thresholds are the largest increases of each measure, as fractions of the
+// base's, which aren't regressions. For example, a Time of 0.1 allows a
+// benchmark to become 10% slower. A negative threshold ignores its measure.
+type Thresholds struct {
+	Time   float64
+	Allocs float64
+	Bytes  float64
+}
+
+// DefaultThresholds allow for the noise of timing on shared machines, while
+// allocations, which are deterministic, are held more tightly.
+var DefaultThresholds = Thresholds{Time: 0.2, Allocs: 0.05, Bytes: 0.1}
+
+// Regression is a measure of a benchmark which increased beyond its
+// threshold.
+type Regression struct {
+	// Name is the benchmark's, such as "parse/petstore.yaml".
+	Name string `json:"name"`
+	// Measure is "ns/op", "allocs/op" or "B/op".
+	Measure string `json:"measure"`
+	Base    int64  `json:"base"`
+	Head    int64  `json:"head"`
+}
+
+// Change returns the increase of the measure, as a fraction of the base's.
+func (r Regression) Change() float64 {
+	if r.Base == 0 {
+		return 1
+	}
+	return float64(r.Head-r.Base) / float64(r.Base)
+}
+
+func (r Regression) String() string {
+	return fmt.Sprintf("%s: %s increased from %d to %d (%+.1f%%)", r.Name, r.Measure, r.Base, r.Head, 100*r.Change())
+}
+
+// Compare returns the regressions of head, the report of a change, from base,
+// sorted by benchmark. Benchmarks only one of the reports has are ignored.
+// It's an error to compare reports of different corpora.
+func Compare(base, head *Report, t Thresholds) ([]Regression, error) {
+	if base.Corpus != head.Corpus {
+		return nil, fmt.Errorf("perf: reports are of different corpora, %s and %s", base.Corpus, head.Corpus)
+	}
+	results := make(map[string]Result, len(base.Results))
+	for _, r := range base.Results {
+		results[r.Name] = r
+	}
+	var regressions []Regression
+	for _, h := range head.Results {
+		b, ok := results[h.Name]
+		if !ok {
+			continue
+		}
+		for _, m := range []struct {
+			name       string
+			base, head int64
+			threshold  float64
+		}{
+			{"ns/op", b.NsPerOp, h.NsPerOp, t.Time},
+			{"allocs/op", b.AllocsPerOp, h.AllocsPerOp, t.Allocs},
+			{"B/op", b.BytesPerOp, h.BytesPerOp, t.Bytes},
+		} {
+			if m.threshold < 0 || m.head <= m.base {
+				continue
+			}
+			r := Regression{Name: h.Name, Measure: m.name, Base: m.base, Head: m.head}
+			if r.Change() > m.threshold {
+				regressions = append(regressions, r)
+			}
+		}
+	}
+	sort.SliceStable(regressions, func(i, j int) bool { return regressions[i].Name < regressions[j].Name })
+	return regressions, nil
+}
@@ -0,0 +1,339 @@
+/*
+Package perf measures how long parsing, validating and flattening documents
+takes, and how much they allocate, over a pinned corpus, so that changes which
+make large documents slower can be caught before they're released.
+
+The corpus is the documents of a directory, testdata by default, and documents
+generated with hundreds and thousands of paths and definitions. Run benchmarks
+each operation on each document and returns a Report, which is written as
+JSON:
+
+	docs, err := perf.Corpus("perf/testdata")
+	if err != nil {
+		// Handle error.
+	}
+	report, err := perf.Run(docs, perf.Options{Count: 5})
+	if err != nil {
+		// Handle error.
+	}
+	err = json.NewEncoder(w).Encode(report)
+
+Compare compares the report of a change with that of its base, returning the
+benchmarks which regressed beyond thresholds. Reports of different corpora
+can't be compared, since their timings aren't of the same documents.
+Command swaggopher-perf runs and compares reports from the command line, and
+the benchmarks can also be run with "go test -bench . ./perf".
+*/
+package perf
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ericchiang/swaggopher/resolver"
+	"github.com/ericchiang/swaggopher/spec"
+	"github.com/ericchiang/swaggopher/validate"
+)
+
+// Document is a document of the corpus.
+type Document struct {
+	// Name identifies the document in results, such as its file name.
+	Name string
+	// Data holds the JSON or YAML document.
+	Data []byte
+}
+
+// GeneratedSizes are the numbers of paths and definitions of the generated
+// documents Corpus adds.
+var GeneratedSizes = []int{100, 1000}
+
+// Corpus returns the documents of a directory, followed by the generated
+// documents of GeneratedSizes.
+func Corpus(dir string) ([]Document, error) {
+	docs, err := Load(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range GeneratedSizes {
+		docs = append(docs, Generate(n))
+	}
+	return docs, nil
+}
+
+// Load returns the JSON and YAML documents of a directory, sorted by name.
+func Load(dir string) ([]Document, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("perf: %v", err)
+	}
+	var docs []Document
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		if _, ok := spec.FormatOf(f.Name()); !ok {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("perf: %v", err)
+		}
+		docs = append(docs, Document{Name: f.Name(), Data: data})
+	}
+	return docs, nil
+}
+
+// Generate returns a JSON document with n paths, each with an operation to
+// list and to create a resource, and n definitions of the resources, which
+// refer to shared definitions. The same n always generates the same document.
+func Generate(n int) Document {
+	paths := make(map[string]interface{}, n)
+	defs := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		name := "Resource" + strconv.Itoa(i)
+		ref := map[string]interface{}{"$ref": "#/definitions/" + name}
+		props := map[string]interface{}{
+			"id":       map[string]interface{}{"type": "integer", "format": "int64"},
+			"name":     map[string]interface{}{"type": "string", "maxLength": 64},
+			"tags":     map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+			"owner":    map[string]interface{}{"$ref": "#/definitions/User"},
+			"metadata": map[string]interface{}{"$ref": "#/definitions/Metadata"},
+		}
+		defs[name] = map[string]interface{}{"type": "object", "required": []string{"id", "name"}, "properties": props}
+		paths["/resources"+strconv.Itoa(i)] = map[string]interface{}{
+			"get": map[string]interface{}{
+				"operationId": "list" + name,
+				"parameters": []interface{}{
+					map[string]interface{}{"name": "limit", "in": "query", "type": "integer", "minimum": 1},
+				},
+				"responses": map[string]interface{}{
+					"200": map[string]interface{}{"description": "OK", "schema": map[string]interface{}{"type": "array", "items": ref}},
+				},
+			},
+			"post": map[string]interface{}{
+				"operationId": "create" + name,
+				"parameters": []interface{}{
+					map[string]interface{}{"name": "body", "in": "body", "required": true, "schema": ref},
+				},
+				"responses": map[string]interface{}{
+					"201": map[string]interface{}{"description": "Created", "schema": ref},
+				},
+			},
+		}
+	}
+	defs["User"] = map[string]interface{}{
+		"type":     "object",
+		"required": []string{"id"},
+		"properties": map[string]interface{}{
+			"id":    map[string]interface{}{"type": "string", "format": "uuid"},
+			"email": map[string]interface{}{"type": "string", "format": "email"},
+		},
+	}
+	defs["Metadata"] = map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"created": map[string]interface{}{"type": "string", "format": "date-time"},
+			"creator": map[string]interface{}{"$ref": "#/definitions/User"},
+			"labels":  map[string]interface{}{"type": "object", "additionalProperties": map[string]interface{}{"type": "string"}},
+		},
+	}
+	data, _ := json.Marshal(map[string]interface{}{
+		"swagger":     "2.0",
+		"info":        map[string]interface{}{"title": "Generated", "version": "1.0"},
+		"paths":       paths,
+		"definitions": defs,
+	})
+	return Document{Name: "generated-" + strconv.Itoa(n) + ".json", Data: data}
+}
+
+// Benchmark is an operation whose performance is measured.
+type Benchmark struct {
+	// Name identifies the benchmark in results.
+	Name string
+	// Prepare returns the operation to measure on a document. The work
+	// Prepare does itself isn't measured.
+	Prepare func(data []byte) (op func() error, err error)
+}
+
+// Benchmarks are the operations Run measures.
+var Benchmarks = []Benchmark{
+	{Name: "parse", Prepare: prepareParse},
+	{Name: "validate", Prepare: prepareValidate},
+	{Name: "flatten", Prepare: prepareFlatten},
+}
+
+func parse(data []byte) (*spec.Swagger, error) {
+	return spec.LoadReader(bytes.NewReader(data))
+}
+
+func prepareParse(data []byte) (func() error, error) {
+	return func() error {
+		_, err := parse(data)
+		return err
+	}, nil
+}
+
+func prepareValidate(data []byte) (func() error, error) {
+	s, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+	return func() error {
+		validate.ValidateDocument(s)
+		validate.ValidateSemantics(s)
+		return nil
+	}, nil
+}
+
+// prepareFlatten parses the document each time before flattening it, since
+// Flatten modifies it. Leaving parsing out of the measurement would leave the
+// benchmark running for many times longer than it measures.
+func prepareFlatten(data []byte) (func() error, error) {
+	return func() error {
+		s, err := parse(data)
+		if err != nil {
+			return err
+		}
+		return resolver.Flatten(s)
+	}, nil
+}
+
+// Report holds the results of a run.
+type Report struct {
+	// Corpus is a digest of the documents benchmarked.
+	Corpus    string   `json:"corpus"`
+	GoVersion string   `json:"goVersion"`
+	GOOS      string   `json:"goos"`
+	GOARCH    string   `json:"goarch"`
+	Results   []Result `json:"results"`
+}
+
+// Result is the performance of a benchmark on a document.
+type Result struct {
+	// Name is the benchmark's name and the document's, such as
+	// "parse/petstore.yaml".
+	Name string `json:"name"`
+	// N is the number of times the benchmark ran.
+	N           int   `json:"n"`
+	NsPerOp     int64 `json:"nsPerOp"`
+	AllocsPerOp int64 `json:"allocsPerOp"`
+	BytesPerOp  int64 `json:"bytesPerOp"`
+}
+
+// Options configures a run.
+type Options struct {
+	// Filter, if set, only runs the benchmarks whose names match it.
+	Filter *regexp.Regexp
+	// Count is the number of times each benchmark is run, of which the
+	// fastest is reported, to discount noise from the rest of the machine.
+	// If zero, each is run once.
+	Count int
+}
+
+// Run runs each benchmark on each document.
+func Run(docs []Document, o Options) (*Report, error) {
+	report := &Report{
+		Corpus:    Digest(docs),
+		GoVersion: runtime.Version(),
+		GOOS:      runtime.GOOS,
+		GOARCH:    runtime.GOARCH,
+	}
+	for _, d := range docs {
+		if _, err := parse(d.Data); err != nil {
+			return nil, fmt.Errorf("perf: %s: %v", d.Name, err)
+		}
+	}
+	for _, bench := range Benchmarks {
+		for _, d := range docs {
+			name := bench.Name + "/" + d.Name
+			if o.Filter != nil && !o.Filter.MatchString(name) {
+				continue
+			}
+			op, err := bench.Prepare(d.Data)
+			if err != nil {
+				return nil, fmt.Errorf("perf: %s: %v", name, err)
+			}
+			var best Result
+			for i := 0; i < o.Count || i == 0; i++ {
+				r, err := measure(op)
+				if err != nil {
+					return nil, fmt.Errorf("perf: %s: %v", name, err)
+				}
+				if i == 0 || r.NsPerOp < best.NsPerOp {
+					best = r
+				}
+			}
+			best.Name = name
+			report.Results = append(report.Results, best)
+		}
+	}
+	return report, nil
+}
+
+// benchTime is how long each benchmark runs for, as with the default
+// -benchtime of go test.
+const benchTime = time.Second
+
+// measure runs an operation repeatedly for at least benchTime, and reports
+// the time and memory each run takes. Like go test, it starts with a single
+// run and grows the number of runs until they take long enough.
+func measure(op func() error) (Result, error) {
+	n := int64(1)
+	for {
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		start := time.Now()
+		for i := int64(0); i < n; i++ {
+			if err := op(); err != nil {
+				return Result{}, err
+			}
+		}
+		elapsed := time.Since(start)
+		runtime.ReadMemStats(&after)
+		if elapsed >= benchTime || n >= 1e9 {
+			return Result{
+				N:           int(n),
+				NsPerOp:     elapsed.Nanoseconds() / n,
+				AllocsPerOp: int64(after.Mallocs-before.Mallocs) / n,
+				BytesPerOp:  int64(after.TotalAlloc-before.TotalAlloc) / n,
+			}, nil
+		}
+		// Aim 20% past benchTime, growing by at least one run and at most a
+		// hundredfold.
+		next := 100 * n
+		if ns := elapsed.Nanoseconds(); ns > 0 && int64(benchTime)*n/ns*6/5 < next {
+			next = int64(benchTime) * n / ns * 6 / 5
+		}
+		if next <= n {
+			next = n + 1
+		}
+		if next > 1e9 {
+			next = 1e9
+		}
+		n = next
+	}
+}
+
+// Digest returns a digest of documents' names and contents, identifying a
+// corpus.
+func Digest(docs []Document) string {
+	sorted := append([]Document(nil), docs...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	h := sha256.New()
+	for _, d := range sorted {
+		fmt.Fprintf(h, "%s\x00%d\x00", d.Name, len(d.Data))
+		h.Write(d.Data)
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,130 @@
+package perf
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+
+	"github.com/ericchiang/swaggopher/validate"
+)
+
+func BenchmarkCorpus(b *testing.B) {
+	docs, err := Corpus("testdata")
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, bench := range Benchmarks {
+		for _, d := range docs {
+			prepare, data := bench.Prepare, d.Data
+			b.Run(bench.Name+"/"+d.Name, func(b *testing.B) {
+				op, err := prepare(data)
+				if err != nil {
+					b.Fatal(err)
+				}
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if err := op(); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestCorpus(t *testing.T) {
+	docs, err := Corpus("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 3+len(GeneratedSizes) {
+		t.Fatalf("expected %d documents, got %d", 3+len(GeneratedSizes), len(docs))
+	}
+	for _, d := range docs {
+		s, err := parse(d.Data)
+		if err != nil {
+			t.Errorf("%s: %v", d.Name, err)
+			continue
+		}
+		for _, e := range append(validate.ValidateDocument(s), validate.ValidateSemantics(s)...) {
+			t.Errorf("%s: %v", d.Name, e)
+		}
+	}
+	if !bytes.Equal(Generate(10).Data, Generate(10).Data) {
+		t.Errorf("generated documents differ")
+	}
+
+	again, err := Corpus("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Digest(docs) != Digest(again) {
+		t.Errorf("digests of the same corpus differ")
+	}
+	if Digest(docs) == Digest(docs[1:]) {
+		t.Errorf("digests of different corpora are the same")
+	}
+}
+
+func TestRun(t *testing.T) {
+	if testing.Short() {
+		t.Skip("benchmarks take a second each")
+	}
+	docs := []Document{Generate(10)}
+	report, err := Run(docs, Options{Filter: regexp.MustCompile(`^parse/`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Corpus != Digest(docs) {
+		t.Errorf("expected the corpus %s, got %s", Digest(docs), report.Corpus)
+	}
+	if len(report.Results) != 1 {
+		t.Fatalf("expected one result, got %v", report.Results)
+	}
+	r := report.Results[0]
+	if r.Name != "parse/generated-10.json" || r.N == 0 || r.NsPerOp == 0 || r.AllocsPerOp == 0 {
+		t.Errorf("unexpected result %+v", r)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	base := &Report{Corpus: "a", Results: []Result{
+		{Name: "parse/a.yaml", NsPerOp: 1000, AllocsPerOp: 100, BytesPerOp: 1000},
+		{Name: "parse/b.yaml", NsPerOp: 1000, AllocsPerOp: 100, BytesPerOp: 1000},
+		{Name: "validate/a.yaml", NsPerOp: 1000, AllocsPerOp: 0, BytesPerOp: 0},
+		{Name: "flatten/a.yaml", NsPerOp: 1000},
+	}}
+	head := &Report{Corpus: "a", Results: []Result{
+		// Within the thresholds.
+		{Name: "parse/a.yaml", NsPerOp: 1100, AllocsPerOp: 105, BytesPerOp: 500},
+		// Slower, and allocating more.
+		{Name: "parse/b.yaml", NsPerOp: 1500, AllocsPerOp: 110, BytesPerOp: 1000},
+		// Allocating where it didn't.
+		{Name: "validate/a.yaml", NsPerOp: 900, AllocsPerOp: 1, BytesPerOp: 16},
+		// Not in the base.
+		{Name: "parse/c.yaml", NsPerOp: 1000},
+	}}
+	got, err := Compare(base, head, Thresholds{Time: 0.2, Allocs: 0.05, Bytes: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"parse/b.yaml: ns/op increased from 1000 to 1500 (+50.0%)",
+		"parse/b.yaml: allocs/op increased from 100 to 110 (+10.0%)",
+		"validate/a.yaml: allocs/op increased from 0 to 1 (+100.0%)",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d regressions, got %v", len(want), got)
+	}
+	for i, r := range got {
+		if r.String() != want[i] {
+			t.Errorf("regression %d: expected %q, got %q", i, want[i], r)
+		}
+	}
+
+	head.Corpus = "b"
+	if _, err := Compare(base, head, DefaultThresholds); err == nil {
+		t.Errorf("expected an error comparing reports of different corpora")
+	}
+}
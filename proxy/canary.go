@@ -0,0 +1,144 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// CanaryExtension is the vendor extension routing some of an operation's
+// requests to an alternate upstream. It may be declared on an operation or its
+// path item:
+//
+//	x-canary:
+//	  upstream: http://pets-v2.internal
+//	  weight: 5
+//	  header: X-Canary
+const CanaryExtension = "x-canary"
+
+// Canary routes a subset of an operation's requests to alternate upstreams. A
+// request is sent to the canary if it sets Header, or otherwise with a
+// probability of Weight percent.
+type Canary struct {
+	// Upstreams lists the canary's URLs, which are used in turn.
+	Upstreams []string
+	// Weight is the percentage, from 0 to 100, of requests sent to the canary.
+	Weight int
+	// Header, if set, sends every request with the header to the canary. If
+	// Value is also set, the header must have that value.
+	Header string
+	Value  string
+
+	pool *pool
+}
+
+// compile checks a canary and parses its upstreams.
+func (c *Canary) compile() error {
+	if c.Weight < 0 || c.Weight > 100 {
+		return fmt.Errorf("weight must be between 0 and 100, got %d", c.Weight)
+	}
+	if c.Weight == 0 && c.Header == "" {
+		return fmt.Errorf("weight or header is required")
+	}
+	list := make([]interface{}, len(c.Upstreams))
+	for i, u := range c.Upstreams {
+		list[i] = u
+	}
+	var err error
+	c.pool, err = newPool(list)
+	return err
+}
+
+func (c *Canary) matches(r *http.Request, intn func(n int) int) bool {
+	if c.Header != "" {
+		if v := r.Header.Get(c.Header); v != "" && (c.Value == "" || v == c.Value) {
+			return true
+		}
+	}
+	return c.Weight > 0 && intn(100) < c.Weight
+}
+
+// parseCanary decodes the value of a canary extension.
+func parseCanary(ext interface{}) (*Canary, error) {
+	data, err := json.Marshal(ext)
+	if err != nil {
+		return nil, err
+	}
+	var raw struct {
+		Upstream interface{} `json:"upstream"`
+		Weight   int         `json:"weight"`
+		Header   string      `json:"header"`
+		Value    string      `json:"value"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	c := &Canary{Weight: raw.Weight, Header: raw.Header, Value: raw.Value}
+	switch u := raw.Upstream.(type) {
+	case string:
+		c.Upstreams = []string{u}
+	case []interface{}:
+		for _, v := range u {
+			s, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("upstream: expected a URL, got %v", v)
+			}
+			c.Upstreams = append(c.Upstreams, s)
+		}
+	case nil:
+		return nil, fmt.Errorf("upstream is required")
+	default:
+		return nil, fmt.Errorf("upstream: expected a URL or list of URLs, got %v", u)
+	}
+	return c, nil
+}
+
+// SetCanary starts routing requests for an operation, identified by its method
+// and path template such as "GET /pets/{petId}", to a canary. It replaces any
+// canary the operation already has, including one declared by the document. A
+// nil canary stops routing to the operation's canary.
+func (p *Proxy) SetCanary(op string, c *Canary) error {
+	if c != nil {
+		copied := *c
+		copied.Upstreams = append([]string(nil), c.Upstreams...)
+		if err := copied.compile(); err != nil {
+			return fmt.Errorf("proxy: canary for %s: %v", op, err)
+		}
+		c = &copied
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.current.pools[op]; !ok {
+		return fmt.Errorf("proxy: unknown operation %q", op)
+	}
+	if c == nil {
+		delete(p.current.canaries, op)
+	} else {
+		p.current.canaries[op] = c
+	}
+	return nil
+}
+
+// Canaries returns the operations which have a canary, in order.
+func (p *Proxy) Canaries() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ops := make([]string, 0, len(p.current.canaries))
+	for op := range p.current.canaries {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+	return ops
+}
+
+// canary returns the canary's upstreams if a request should be sent to them.
+func (p *Proxy) canary(st *state, op string, r *http.Request) *pool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	c, ok := st.canaries[op]
+	if !ok || !c.matches(r, p.intn) {
+		return nil
+	}
+	return c.pool
+}
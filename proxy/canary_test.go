@@ -0,0 +1,127 @@
+package proxy
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+const canaries = `
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+paths:
+  /pets:
+    get:
+      x-canary: {upstream: %s, weight: 50, header: X-Canary}
+      responses: {200: {description: OK}}
+  /owners:
+    get:
+      responses: {200: {description: OK}}
+`
+
+// alternating is a rand.Source which alternates between the lowest and
+// highest percentiles.
+type alternating struct{ n int }
+
+func (a *alternating) Int63() int64 {
+	a.n++
+	if a.n%2 == 1 {
+		return 0
+	}
+	// rand.Intn(100) takes the top 31 bits modulo 100.
+	return 99 << 32
+}
+
+func (a *alternating) Seed(int64) {}
+
+func TestCanary(t *testing.T) {
+	upstream := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, name)
+		}))
+	}
+	stable, canary := upstream("stable"), upstream("canary")
+	defer stable.Close()
+	defer canary.Close()
+
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(fmt.Sprintf(canaries, canary.URL)), &s); err != nil {
+		t.Fatal(err)
+	}
+	p, err := New(&s, Options{Upstreams: []string{stable.URL}, Rand: new(alternating)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+
+	send := func(path string, header http.Header) string {
+		req, err := http.NewRequest("GET", srv.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header = header
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(body)
+	}
+
+	canaryHeader := http.Header{"X-Canary": {"1"}}
+	tests := []struct {
+		path   string
+		header http.Header
+		want   string
+	}{
+		{"/pets", nil, "canary"},
+		{"/pets", nil, "stable"},
+		{"/pets", canaryHeader, "canary"},
+		{"/owners", canaryHeader, "stable"},
+	}
+	for i, tt := range tests {
+		if got := send(tt.path, tt.header); got != tt.want {
+			t.Errorf("case %d: %s: want upstream %s, got %s", i, tt.path, tt.want, got)
+		}
+	}
+	if got := p.Metrics().Operations["GET /pets"].Canary; got != 2 {
+		t.Errorf("expected 2 requests routed to the canary, got %d", got)
+	}
+
+	if err := p.SetCanary("GET /owners", &Canary{Upstreams: []string{canary.URL}, Header: "X-Canary", Value: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.SetCanary("GET /pets", nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := p.Canaries(); len(got) != 1 || got[0] != "GET /owners" {
+		t.Errorf("expected only GET /owners to have a canary, got %v", got)
+	}
+	if got := send("/owners", canaryHeader); got != "canary" {
+		t.Errorf("owners: want upstream canary, got %s", got)
+	}
+	if got := send("/owners", http.Header{"X-Canary": {"2"}}); got != "stable" {
+		t.Errorf("owners: want upstream stable for other header values, got %s", got)
+	}
+	if got := send("/pets", canaryHeader); got != "stable" {
+		t.Errorf("pets: want upstream stable after removing its canary, got %s", got)
+	}
+
+	if err := p.SetCanary("GET /users", &Canary{Upstreams: []string{canary.URL}, Weight: 10}); err == nil {
+		t.Errorf("expected error for unknown operation")
+	}
+	if err := p.SetCanary("GET /pets", &Canary{Upstreams: []string{canary.URL}, Weight: 120}); err == nil {
+		t.Errorf("expected error for weight over 100")
+	}
+}
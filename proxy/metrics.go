@@ -0,0 +1,125 @@
+package proxy
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ericchiang/swaggopher/admin"
+	"github.com/ericchiang/swaggopher/internal/httpcheck"
+)
+
+// Counts records the traffic seen by a proxy, or by one of its operations.
+type Counts struct {
+	Requests int64 `json:"requests"`
+	// Unmatched counts requests which weren't routed to any operation.
+	Unmatched          int64 `json:"unmatched,omitempty"`
+	RequestViolations  int64 `json:"requestViolations"`
+	ResponseViolations int64 `json:"responseViolations"`
+	UpstreamErrors     int64 `json:"upstreamErrors"`
+	// Timeouts counts requests which exceeded their policy's timeout.
+	Timeouts int64 `json:"timeouts"`
+	// Rejected counts requests which weren't forwarded because of their
+	// policy's concurrency limit or circuit breaker.
+	Rejected int64 `json:"rejected"`
+	// Canary counts requests routed to a canary.
+	Canary int64 `json:"canary"`
+	// CacheHits counts requests served from the cache.
+	CacheHits int64 `json:"cacheHits"`
+	// Latency is the total time spent forwarding requests and waiting for
+	// their responses.
+	Latency time.Duration `json:"latency"`
+}
+
+// Metrics holds the totals of a proxy and the counts of each operation, keyed by
+// method and path template such as "GET /pets/{petId}". It can be published
+// with expvar:
+//
+//	expvar.Publish("proxy", expvar.Func(func() interface{} { return p.Metrics() }))
+type Metrics struct {
+	Counts
+	Operations map[string]Counts `json:"operations"`
+}
+
+// Metrics returns a snapshot of the proxy's metrics.
+func (p *Proxy) Metrics() Metrics {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	m := Metrics{
+		Counts:     p.metrics.Counts,
+		Operations: make(map[string]Counts, len(p.metrics.Operations)),
+	}
+	for op, c := range p.metrics.Operations {
+		m.Operations[op] = c
+	}
+	return m
+}
+
+// count updates the proxy's totals and, if op is set, the operation's counts.
+func (p *Proxy) count(op string, update func(c *Counts)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	update(&p.metrics.Counts)
+	if op != "" {
+		c := p.metrics.Operations[op]
+		update(&c)
+		p.metrics.Operations[op] = c
+	}
+}
+
+// Status implements admin.Component, reporting the proxy's document, routes and
+// metrics.
+func (p *Proxy) Status() admin.Status {
+	m := p.Metrics()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	st := p.current
+	s := admin.Status{
+		Documents: []admin.Document{st.document()},
+		Counters:  m,
+	}
+	for i := len(p.draining) - 1; i >= 0; i-- {
+		s.Documents = append(s.Documents, p.draining[i].document())
+	}
+	for op, pool := range st.pools {
+		i := strings.Index(op, " ")
+		r := admin.Route{
+			Method:    op[:i],
+			Path:      op[i+1:],
+			Upstreams: pool.strings(),
+			Requests:  m.Operations[op].Requests,
+		}
+		item := st.doc.Paths[r.Path]
+		if o := httpcheck.Operation(&item, r.Method); o != nil {
+			r.OperationID = o.OperationId
+		}
+		if c, ok := st.canaries[op]; ok {
+			r.Canary = c.pool.strings()
+		}
+		s.Routes = append(s.Routes, r)
+	}
+	sort.Slice(s.Routes, func(i, j int) bool {
+		if s.Routes[i].Path != s.Routes[j].Path {
+			return s.Routes[i].Path < s.Routes[j].Path
+		}
+		return s.Routes[i].Method < s.Routes[j].Method
+	})
+	return s
+}
+
+func (st *state) document() admin.Document {
+	d := admin.Document{Hash: st.hash, Loaded: st.loaded, Generation: st.generation, InFlight: st.inflight, Footprint: st.footprint}
+	if st.doc.Info != nil {
+		d.Title = st.doc.Info.Title
+		d.Version = st.doc.Info.Version
+	}
+	return d
+}
+
+func (p *pool) strings() []string {
+	list := make([]string, len(p.urls))
+	for i, u := range p.urls {
+		list[i] = u.String()
+	}
+	return list
+}
@@ -0,0 +1,651 @@
+/*
+Package proxy implements a reverse proxy which validates traffic against a
+document.
+
+Requests are routed to an operation of the document, checked against its
+parameters, and forwarded to the operation's upstream. Responses are checked
+against the operation's documented responses before they're returned. In
+Enforce mode requests which don't match the document are rejected, and responses
+which don't match are replaced with a 502. In Observe mode problems are only
+logged and counted, which is useful for finding out how far an existing API
+strays from its document before enforcing it.
+
+An operation's upstream is read from the "x-upstream" extension of the
+operation, its path item or the document, in that order. The extension's value
+is a URL or a list of URLs, which are used in turn:
+
+	x-upstream: [http://pets-1.internal, http://pets-2.internal]
+	paths:
+	  /pets/{petId}/photos:
+	    x-upstream: http://photos.internal
+
+Without an extension, the document's host and schemes are used.
+
+Timeouts, concurrency limits and circuit breakers are declared the same way
+with the "x-resiliency" extension. See Policy. Some of an operation's requests
+can be routed to an alternate upstream with the "x-canary" extension, or at
+runtime with SetCanary. If Options.Cache is set, GET responses are cached as
+package cache describes, and if Options.Usage is set requests are counted per
+API key as package usage describes.
+
+Requests are checked against the variant of their operation they select, if
+any. See package variant.
+
+The document can be replaced without a restart with Reload, and the proxy
+implements admin.Component so its state can be served by package admin.
+*/
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ericchiang/swaggopher/cache"
+	"github.com/ericchiang/swaggopher/coerce"
+	"github.com/ericchiang/swaggopher/internal/httpcheck"
+	"github.com/ericchiang/swaggopher/internal/logutil"
+	"github.com/ericchiang/swaggopher/runtime"
+	"github.com/ericchiang/swaggopher/spec"
+	"github.com/ericchiang/swaggopher/transform"
+	"github.com/ericchiang/swaggopher/usage"
+	"github.com/ericchiang/swaggopher/variant"
+)
+
+// UpstreamExtension is the vendor extension declaring the upstreams of a
+// document, path item or operation.
+const UpstreamExtension = "x-upstream"
+
+// Mode determines what the proxy does with traffic which doesn't match the
+// document.
+type Mode int
+
+const (
+	// Enforce rejects requests which don't match the document with a 4xx,
+	// and replaces responses which don't match with a 502.
+	Enforce Mode = iota
+	// Observe forwards all traffic unchanged, logging and counting problems.
+	Observe
+)
+
+// Options configures a Proxy. The zero value enforces the document and routes
+// to the upstreams it declares.
+type Options struct {
+	Mode Mode
+	// Upstreams, if set, replaces every upstream declared by the document
+	// other than those of canaries.
+	Upstreams []string
+	// Transport is used to make requests to upstreams. If nil,
+	// http.DefaultTransport is used.
+	Transport http.RoundTripper
+	// Rules are applied to requests before they're validated, so clients of
+	// an old version of the API can be served by the current one. See
+	// transform.FromSpec.
+	Rules []transform.Rule
+	// OnRetire, if set, is called once every request served by a replaced
+	// document has completed, with the generation of that document. See
+	// Reload.
+	OnRetire func(generation int)
+	// Cache, if set, stores the responses to GET requests which the document
+	// says may be cached. Cached responses are served without checking the
+	// request or calling the upstream.
+	Cache cache.Backend
+	// Usage, if set, counts the requests each API key makes to each
+	// operation, including those served from the cache. See package usage.
+	Usage usage.Store
+	// Logger, if set, receives a line for each problem found.
+	Logger spec.Logger
+
+	// Router matches requests to operations. If nil, runtime.DefaultRouter
+	// is used.
+	Router runtime.Router
+	// Validator checks requests and responses. If nil,
+	// runtime.DefaultValidator is used.
+	Validator runtime.Validator
+	// Coerce controls how strictly the default validator parses parameter
+	// values. It's ignored if Validator is set.
+	Coerce coerce.Options
+	// Resolver, if set, resolves a copy of each document before it's loaded,
+	// so the proxy can serve documents which refer to others.
+	Resolver runtime.Resolver
+	// Clock tells the time for circuit breakers, cached responses, latency
+	// metrics and load times. If nil, runtime.SystemClock is used.
+	Clock runtime.Clock
+	// Rand decides which requests are sent to canaries by weight. If nil, a
+	// source seeded with the current time is used.
+	Rand rand.Source
+}
+
+// Proxy is a validating reverse proxy. Use New to construct one.
+type Proxy struct {
+	opts  Options
+	proxy *httputil.ReverseProxy
+	now   func() time.Time
+
+	// mu guards the fields below it.
+	mu      sync.Mutex
+	current *state
+	// draining holds the replaced states which are still serving requests.
+	draining []*state
+	metrics  Metrics
+	intn     func(n int) int
+}
+
+// state is what the proxy derives from a document. Requests are served by the
+// state current when they arrive, even if the document is reloaded while
+// they're in flight.
+type state struct {
+	doc     *spec.Swagger
+	hash    string
+	loaded  time.Time
+	handler http.Handler
+	// footprint is the estimated memory the document retains.
+	footprint int64
+	// generation counts the documents loaded before this one. It's set, like
+	// inflight, under the proxy's mu.
+	generation int
+	inflight   int
+
+	// pools holds the upstreams of each operation, keyed by the method and
+	// path template.
+	pools map[string]*pool
+	// fallback receives unmatched requests in Observe mode. It may be nil.
+	fallback *pool
+	// guards holds the resiliency policies of operations which declare one,
+	// keyed like pools.
+	guards map[string]*guard
+	// canaries holds the canaries of operations, keyed like pools. It's
+	// guarded by the proxy's mu.
+	canaries map[string]*Canary
+}
+
+// New returns a proxy for a document. An error is returned if an upstream URL is
+// invalid, or an operation has no upstream.
+func New(doc *spec.Swagger, opts Options) (*Proxy, error) {
+	if opts.Router == nil {
+		opts.Router = runtime.DefaultRouter
+	}
+	if opts.Validator == nil {
+		opts.Validator = runtime.ValidatorOptions{Coerce: opts.Coerce}.NewValidator()
+	}
+	if opts.Clock == nil {
+		opts.Clock = runtime.SystemClock
+	}
+	if opts.Rand == nil {
+		opts.Rand = rand.NewSource(time.Now().UnixNano())
+	}
+	p := &Proxy{
+		opts: opts,
+		now:  opts.Clock.Now,
+		intn: rand.New(opts.Rand).Intn,
+		metrics: Metrics{
+			Operations: make(map[string]Counts),
+		},
+	}
+	p.proxy = &httputil.ReverseProxy{
+		Director:       p.direct,
+		Transport:      opts.Transport,
+		ModifyResponse: p.checkResponse,
+		ErrorHandler:   p.upstreamError,
+	}
+	var err error
+	if p.current, err = p.load(doc); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Reload replaces the proxy's document. The document is checked as New checks
+// it, and if it's invalid the proxy keeps the old one. Circuit breakers start
+// closed, and canaries set with SetCanary are replaced by those the document
+// declares.
+//
+// Each document loaded is a new generation, starting from zero for the one
+// passed to New. Requests already in flight complete using the generation they
+// arrived under, and once the last of them has the old generation is retired and
+// Options.OnRetire is called.
+func (p *Proxy) Reload(doc *spec.Swagger) error {
+	st, err := p.load(doc)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	old := p.current
+	st.generation = old.generation + 1
+	p.current = st
+	retired := old.inflight == 0
+	if !retired {
+		p.draining = append(p.draining, old)
+	}
+	p.mu.Unlock()
+	if retired {
+		p.retire(old)
+	}
+	return nil
+}
+
+// Generation returns the generation of the proxy's current document.
+func (p *Proxy) Generation() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.current.generation
+}
+
+// release records that a request served by a state has completed, retiring the
+// state if it's been replaced and this was its last request.
+func (p *Proxy) release(st *state) {
+	p.mu.Lock()
+	st.inflight--
+	retired := st != p.current && st.inflight == 0
+	if retired {
+		for i, d := range p.draining {
+			if d == st {
+				p.draining = append(p.draining[:i], p.draining[i+1:]...)
+				break
+			}
+		}
+	}
+	p.mu.Unlock()
+	if retired {
+		p.retire(st)
+	}
+}
+
+func (p *Proxy) retire(st *state) {
+	logutil.Printf(p.opts.Logger, "proxy: generation %d retired", st.generation)
+	if p.opts.OnRetire != nil {
+		p.opts.OnRetire(st.generation)
+	}
+}
+
+// load derives the proxy's state from a document.
+func (p *Proxy) load(doc *spec.Swagger) (*state, error) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("proxy: %v", err)
+	}
+	if p.opts.Resolver != nil {
+		resolved := new(spec.Swagger)
+		if err := json.Unmarshal(data, resolved); err != nil {
+			return nil, fmt.Errorf("proxy: %v", err)
+		}
+		if err := p.opts.Resolver.Resolve(resolved); err != nil {
+			return nil, fmt.Errorf("proxy: %v", err)
+		}
+		doc = resolved
+	}
+	sum := sha256.Sum256(data)
+	st := &state{
+		doc:       doc,
+		hash:      hex.EncodeToString(sum[:]),
+		loaded:    p.now(),
+		footprint: spec.Footprint(doc).Bytes,
+		pools:     make(map[string]*pool),
+		guards:    make(map[string]*guard),
+		canaries:  make(map[string]*Canary),
+	}
+
+	var fallback interface{}
+	if len(p.opts.Upstreams) > 0 {
+		list := make([]interface{}, len(p.opts.Upstreams))
+		for i, u := range p.opts.Upstreams {
+			list[i] = u
+		}
+		fallback = list
+	} else if ext, ok := doc.Extensions[UpstreamExtension]; ok {
+		fallback = ext
+	} else if doc.Host != "" {
+		fallback = scheme(doc.Schemes) + "://" + doc.Host
+	}
+	if fallback != nil {
+		if st.fallback, err = newPool(fallback); err != nil {
+			return nil, fmt.Errorf("proxy: %s: %v", UpstreamExtension, err)
+		}
+	}
+
+	for template, item := range doc.Paths {
+		for _, method := range []string{"GET", "PUT", "POST", "DELETE", "OPTIONS", "HEAD", "PATCH"} {
+			op := httpcheck.Operation(&item, method)
+			if op == nil {
+				continue
+			}
+			key := method + " " + template
+			if err := p.addGuard(st, key, op, &item); err != nil {
+				return nil, err
+			}
+			if err := addCanary(st, key, op, &item); err != nil {
+				return nil, err
+			}
+			if err := checkVariants(key, op); err != nil {
+				return nil, err
+			}
+			ext, ok := op.Extensions[UpstreamExtension]
+			if !ok {
+				ext, ok = item.Extensions[UpstreamExtension]
+			}
+			if !ok || len(p.opts.Upstreams) > 0 {
+				if st.fallback == nil {
+					return nil, fmt.Errorf("proxy: %s has no upstream: set host, %s or Options.Upstreams", key, UpstreamExtension)
+				}
+				st.pools[key] = st.fallback
+				continue
+			}
+			if st.pools[key], err = newPool(ext); err != nil {
+				return nil, fmt.Errorf("proxy: %s: %s: %v", key, UpstreamExtension, err)
+			}
+		}
+	}
+
+	var next http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p.serve(st, w, r)
+	})
+	if p.opts.Cache != nil {
+		next, err = cache.Handler(doc, next, cache.Options{
+			Backend: p.opts.Cache,
+			OnHit:   func(op string) { p.count(op, func(c *Counts) { c.Requests++; c.CacheHits++ }) },
+			Now:     func() time.Time { return p.now() },
+			Router:  p.opts.Router,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("proxy: %v", err)
+		}
+	}
+	if p.opts.Usage != nil {
+		next = usage.Handler(doc, next, usage.Options{Store: p.opts.Usage, Router: p.opts.Router})
+	}
+	if st.handler, err = transform.Handler(p.opts.Rules, next); err != nil {
+		return nil, fmt.Errorf("proxy: %v", err)
+	}
+	return st, nil
+}
+
+// addGuard enforces the resiliency policy of an operation, if it has one.
+func (p *Proxy) addGuard(st *state, key string, op *spec.Operation, item *spec.PathItem) error {
+	ext, ok := op.Extensions[ResiliencyExtension]
+	if !ok {
+		ext, ok = item.Extensions[ResiliencyExtension]
+	}
+	if !ok {
+		ext, ok = st.doc.Extensions[ResiliencyExtension]
+	}
+	if !ok {
+		return nil
+	}
+	policy, err := parsePolicy(ext)
+	if err != nil {
+		return fmt.Errorf("proxy: %s: %s: %v", key, ResiliencyExtension, err)
+	}
+	st.guards[key] = newGuard(*policy, func() time.Time { return p.now() })
+	return nil
+}
+
+// addCanary routes some of an operation's requests to its canary, if it declares
+// one.
+func addCanary(st *state, key string, op *spec.Operation, item *spec.PathItem) error {
+	ext, ok := op.Extensions[CanaryExtension]
+	if !ok {
+		ext, ok = item.Extensions[CanaryExtension]
+	}
+	if !ok {
+		return nil
+	}
+	c, err := parseCanary(ext)
+	if err == nil {
+		err = c.compile()
+	}
+	if err != nil {
+		return fmt.Errorf("proxy: %s: %s: %v", key, CanaryExtension, err)
+	}
+	st.canaries[key] = c
+	return nil
+}
+
+// checkVariants checks that an operation's variants can be routed to.
+func checkVariants(key string, op *spec.Operation) error {
+	list, err := variant.Parse(op)
+	if err != nil {
+		return fmt.Errorf("proxy: %s: %v", key, err)
+	}
+	for _, c := range variant.Conflicts(list) {
+		return fmt.Errorf("proxy: %s: %s: variant %d conflicts with variant %d", key, variant.Extension, c.Index, c.Other)
+	}
+	return nil
+}
+
+// scheme returns the scheme used to reach a document's host, preferring https.
+func scheme(schemes []string) string {
+	for _, s := range schemes {
+		if s == "https" {
+			return s
+		}
+	}
+	if len(schemes) > 0 {
+		return schemes[0]
+	}
+	return "http"
+}
+
+// pool is a list of upstreams used in turn.
+type pool struct {
+	urls []*url.URL
+	next uint32
+}
+
+// newPool parses the value of an upstream extension, a URL or list of URLs.
+func newPool(ext interface{}) (*pool, error) {
+	var raw []string
+	switch ext := ext.(type) {
+	case string:
+		raw = []string{ext}
+	case []interface{}:
+		for _, v := range ext {
+			s, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("expected a URL, got %v", v)
+			}
+			raw = append(raw, s)
+		}
+	default:
+		return nil, fmt.Errorf("expected a URL or list of URLs, got %v", ext)
+	}
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("no upstreams listed")
+	}
+	p := &pool{}
+	for _, s := range raw {
+		u, err := url.Parse(s)
+		if err != nil {
+			return nil, err
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("upstream %q must be an absolute URL", s)
+		}
+		p.urls = append(p.urls, u)
+	}
+	return p, nil
+}
+
+func (p *pool) pick() *url.URL {
+	n := atomic.AddUint32(&p.next, 1)
+	return p.urls[int(n-1)%len(p.urls)]
+}
+
+type contextKey int
+
+const (
+	matchKey contextKey = iota
+	stateKey
+	upstreamKey
+	outcomeKey
+)
+
+// outcome records how an upstream responded to a request.
+type outcome struct {
+	status int
+	err    error
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	st := p.current
+	st.inflight++
+	p.mu.Unlock()
+	defer p.release(st)
+	st.handler.ServeHTTP(w, r)
+}
+
+func (p *Proxy) serve(st *state, w http.ResponseWriter, r *http.Request) {
+	m, err := p.opts.Router.Route(st.doc, r)
+	if err != nil {
+		p.count("", func(c *Counts) { c.Requests++; c.Unmatched++ })
+		logutil.Printf(p.opts.Logger, "proxy: %s %s: %v", r.Method, r.URL.Path, err)
+		if p.opts.Mode == Enforce || st.fallback == nil {
+			code := http.StatusNotFound
+			if e, ok := err.(*runtime.RouteError); ok {
+				code = e.Status
+				if e.Location != "" {
+					w.Header().Set("Location", e.Location)
+				}
+			}
+			writeError(w, code, err.Error())
+			return
+		}
+		p.forward(st, w, r, nil, st.fallback, nil)
+		return
+	}
+	op := m.String()
+	p.count(op, func(c *Counts) { c.Requests++ })
+
+	if msg := httpcheck.Message(p.opts.Validator.ValidateRequest(st.doc, m, r)); msg != "" {
+		p.count(op, func(c *Counts) { c.RequestViolations++ })
+		logutil.Printf(p.opts.Logger, "proxy: %s: invalid request: %s", op, msg)
+		if p.opts.Mode == Enforce {
+			writeError(w, http.StatusBadRequest, msg)
+			return
+		}
+	}
+	g := st.guards[op]
+	if g != nil {
+		if msg := g.acquire(); msg != "" {
+			p.count(op, func(c *Counts) { c.Rejected++ })
+			logutil.Printf(p.opts.Logger, "proxy: %s: rejected: %s", op, msg)
+			writeError(w, http.StatusServiceUnavailable, msg)
+			return
+		}
+	}
+	upstreams := st.pools[op]
+	if c := p.canary(st, op, r); c != nil {
+		p.count(op, func(c *Counts) { c.Canary++ })
+		upstreams = c
+	}
+	p.forward(st, w, r, m, upstreams, g)
+}
+
+// forward sends a request to one of a pool of upstreams. If the request was
+// routed to an operation with a resiliency policy, g enforces it and must
+// already have been acquired.
+func (p *Proxy) forward(st *state, w http.ResponseWriter, r *http.Request, m *httpcheck.Match, upstreams *pool, g *guard) {
+	out := &outcome{}
+	ctx := context.WithValue(r.Context(), upstreamKey, upstreams.pick())
+	ctx = context.WithValue(ctx, stateKey, st)
+	ctx = context.WithValue(ctx, outcomeKey, out)
+	if m != nil {
+		ctx = context.WithValue(ctx, matchKey, m)
+	}
+	if g != nil && g.policy.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, g.policy.Timeout)
+		defer cancel()
+	}
+	start := p.now()
+	p.proxy.ServeHTTP(w, r.WithContext(ctx))
+	if g != nil {
+		g.finish(out.err != nil || out.status >= 500)
+	}
+	if m != nil {
+		elapsed := p.now().Sub(start)
+		p.count(m.String(), func(c *Counts) { c.Latency += elapsed })
+	}
+}
+
+// direct points a request at its upstream.
+func (p *Proxy) direct(r *http.Request) {
+	target := r.Context().Value(upstreamKey).(*url.URL)
+	r.URL.Scheme = target.Scheme
+	r.URL.Host = target.Host
+	r.URL.Path = strings.TrimSuffix(target.Path, "/") + r.URL.Path
+	r.URL.RawPath = ""
+	// Send the upstream's own host name, as it may serve several.
+	r.Host = ""
+}
+
+// checkResponse validates a response from an upstream before it's returned.
+func (p *Proxy) checkResponse(resp *http.Response) error {
+	resp.Request.Context().Value(outcomeKey).(*outcome).status = resp.StatusCode
+	m, ok := resp.Request.Context().Value(matchKey).(*httpcheck.Match)
+	if !ok {
+		return nil
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(data))
+
+	st := resp.Request.Context().Value(stateKey).(*state)
+	problems := p.opts.Validator.ValidateResponse(st.doc, m.Operation, resp.StatusCode, resp.Header, data)
+	if len(problems) == 0 {
+		return nil
+	}
+	op := m.String()
+	msg := strings.Join(problems, "; ")
+	p.count(op, func(c *Counts) { c.ResponseViolations++ })
+	logutil.Printf(p.opts.Logger, "proxy: %s: invalid response: %s", op, msg)
+	if p.opts.Mode == Observe {
+		return nil
+	}
+
+	body, _ := json.Marshal(map[string]string{"message": "upstream response does not match the document: " + msg})
+	body = append(body, '\n')
+	resp.StatusCode = http.StatusBadGateway
+	resp.Status = fmt.Sprintf("%d %s", http.StatusBadGateway, http.StatusText(http.StatusBadGateway))
+	resp.Header = http.Header{"Content-Type": {"application/json"}}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	return nil
+}
+
+func (p *Proxy) upstreamError(w http.ResponseWriter, r *http.Request, err error) {
+	op := ""
+	if m, ok := r.Context().Value(matchKey).(*httpcheck.Match); ok {
+		op = m.String()
+	}
+	r.Context().Value(outcomeKey).(*outcome).err = err
+	logutil.Printf(p.opts.Logger, "proxy: %s %s: %v", r.Method, r.URL.Path, err)
+	if r.Context().Err() == context.DeadlineExceeded {
+		p.count(op, func(c *Counts) { c.Timeouts++ })
+		writeError(w, http.StatusGatewayTimeout, "upstream request timed out")
+		return
+	}
+	p.count(op, func(c *Counts) { c.UpstreamErrors++ })
+	writeError(w, http.StatusBadGateway, "upstream request failed")
+}
+
+func writeError(w http.ResponseWriter, code int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"message": msg})
+}
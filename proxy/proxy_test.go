@@ -0,0 +1,567 @@
+package proxy
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/ericchiang/swaggopher/cache"
+	"github.com/ericchiang/swaggopher/runtime"
+	"github.com/ericchiang/swaggopher/spec"
+	"github.com/ericchiang/swaggopher/transform"
+	"github.com/ericchiang/swaggopher/usage"
+	"github.com/ericchiang/swaggopher/variant"
+)
+
+const petstore = `
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+basePath: /v1
+paths:
+  /pets:
+    get:
+      parameters:
+      - {name: limit, in: query, type: integer}
+      responses:
+        200:
+          description: Pets.
+          schema: {type: array, items: {$ref: '#/definitions/Pet'}}
+  /pets/{petId}:
+    parameters:
+    - {name: petId, in: path, required: true, type: integer}
+    get:
+      responses:
+        200: {description: A pet., schema: {$ref: '#/definitions/Pet'}}
+  /photos:
+    x-upstream: %s
+    get:
+      responses:
+        200: {description: Photos.}
+definitions:
+  Pet:
+    type: object
+    required: [name]
+    properties:
+      name: {type: string}
+`
+
+type logger []string
+
+func (l *logger) Printf(format string, v ...interface{}) {
+	*l = append(*l, fmt.Sprintf(format, v...))
+}
+
+func TestProxy(t *testing.T) {
+	pets := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/pets":
+			fmt.Fprint(w, `[{"name": "Fido"}]`)
+		case "/v1/pets/1":
+			fmt.Fprint(w, `{"name": "Rex"}`)
+		case "/v1/pets/2":
+			// Missing the required name.
+			fmt.Fprint(w, `{}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer pets.Close()
+	photos := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "photos")
+	}))
+	defer photos.Close()
+
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(fmt.Sprintf(petstore, photos.URL)), &s); err != nil {
+		t.Fatal(err)
+	}
+	s.Host = strings.TrimPrefix(pets.URL, "http://")
+
+	tests := []struct {
+		mode     Mode
+		path     string
+		wantCode int
+		wantBody string
+	}{
+		{Enforce, "/v1/pets?limit=10", 200, `[{"name": "Fido"}]`},
+		{Enforce, "/v1/pets?limit=ten", 400, ""},
+		{Enforce, "/v1/pets/1", 200, `{"name": "Rex"}`},
+		{Enforce, "/v1/pets/2", 502, ""},
+		{Enforce, "/v1/owners", 404, ""},
+		{Enforce, "/v1/photos", 200, "photos"},
+		{Observe, "/v1/pets?limit=ten", 200, `[{"name": "Fido"}]`},
+		{Observe, "/v1/pets/2", 200, `{}`},
+		{Observe, "/v1/owners", 404, "404 page not found\n"},
+	}
+	for i, tt := range tests {
+		p, err := New(&s, Options{Mode: tt.mode})
+		if err != nil {
+			t.Fatal(err)
+		}
+		srv := httptest.NewServer(p)
+		resp, err := http.Get(srv.URL + tt.path)
+		if err != nil {
+			srv.Close()
+			t.Errorf("case %d: %v", i, err)
+			continue
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		srv.Close()
+		if err != nil {
+			t.Errorf("case %d: %v", i, err)
+			continue
+		}
+		if resp.StatusCode != tt.wantCode {
+			t.Errorf("case %d: %s: want status %d, got %d: %s", i, tt.path, tt.wantCode, resp.StatusCode, body)
+			continue
+		}
+		if tt.wantBody != "" && string(body) != tt.wantBody {
+			t.Errorf("case %d: %s: want body %q, got %q", i, tt.path, tt.wantBody, body)
+		}
+	}
+}
+
+func TestProxyMetrics(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"name": "Rex"}`)
+	}))
+	defer upstream.Close()
+
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(fmt.Sprintf(petstore, upstream.URL)), &s); err != nil {
+		t.Fatal(err)
+	}
+	var l logger
+	p, err := New(&s, Options{
+		Mode:      Observe,
+		Upstreams: []string{upstream.URL},
+		Rules:     []transform.Rule{{Path: "/v0/pet/{petId}", RewritePath: "/v1/pets/{petId}"}},
+		Logger:    &l,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+
+	for _, path := range []string{"/v0/pet/1", "/v1/pets/rex", "/v1/pets"} {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("%s: expected status 200, got %d", path, resp.StatusCode)
+		}
+	}
+
+	m := p.Metrics()
+	for op, c := range m.Operations {
+		if c.Latency <= 0 {
+			t.Errorf("%s: expected latency to be recorded", op)
+		}
+		c.Latency = 0
+		m.Operations[op] = c
+	}
+	m.Latency = 0
+	want := Metrics{
+		Counts: Counts{Requests: 3, RequestViolations: 1, ResponseViolations: 1},
+		Operations: map[string]Counts{
+			"GET /pets/{petId}": {Requests: 2, RequestViolations: 1},
+			"GET /pets":         {Requests: 1, ResponseViolations: 1},
+		},
+	}
+	if diff := pretty.Compare(m, want); diff != "" {
+		t.Errorf("want != got: %s", diff)
+	}
+	wantLog := []string{
+		`proxy: GET /pets/{petId}: invalid request: coerce: petId: invalid value "rex": not a valid integer`,
+		"proxy: GET /pets: invalid response: body: expected an array, got an object",
+	}
+	if diff := pretty.Compare(l, wantLog); diff != "" {
+		t.Errorf("log: want != got: %s", diff)
+	}
+}
+
+func TestNew(t *testing.T) {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(fmt.Sprintf(petstore, "photos.internal")), &s); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(&s, Options{}); err == nil {
+		t.Errorf("expected error for relative upstream URL")
+	}
+	s.Paths["/photos"] = spec.PathItem{Get: s.Paths["/photos"].Get}
+	if _, err := New(&s, Options{}); err == nil {
+		t.Errorf("expected error for operations without an upstream")
+	}
+}
+
+func TestProxyCache(t *testing.T) {
+	calls := 0
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "max-age=60")
+		fmt.Fprint(w, `{"name": "Rex"}`)
+	}))
+	defer upstream.Close()
+
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(fmt.Sprintf(petstore, upstream.URL)), &s); err != nil {
+		t.Fatal(err)
+	}
+	p, err := New(&s, Options{Upstreams: []string{upstream.URL}, Cache: cache.NewMemory(10)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+
+	for i := 0; i < 3; i++ {
+		resp, err := http.Get(srv.URL + "/v1/pets/1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || string(body) != `{"name": "Rex"}` {
+			t.Errorf("request %d: got %d %s", i, resp.StatusCode, body)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected the upstream to be called once, got %d", calls)
+	}
+	if got := p.Metrics().Operations["GET /pets/{petId}"]; got.Requests != 3 || got.CacheHits != 2 {
+		t.Errorf("expected 3 requests and 2 cache hits, got %+v", got)
+	}
+}
+
+func TestProxyUsage(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "max-age=60")
+		fmt.Fprint(w, `{"name": "Rex"}`)
+	}))
+	defer upstream.Close()
+
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(fmt.Sprintf(petstore, upstream.URL)), &s); err != nil {
+		t.Fatal(err)
+	}
+	s.SecurityDefinitions = spec.SecurityDefinitions{"key": {Type: "apiKey", In: "query", Name: "key"}}
+	s.Security = []spec.SecurityRequirement{{"key": {}}}
+	store := usage.NewMemory()
+	p, err := New(&s, Options{Upstreams: []string{upstream.URL}, Cache: cache.NewMemory(10), Usage: store})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+
+	for _, path := range []string{"/v1/pets/1?key=alice", "/v1/pets/1?key=alice", "/v1/pets/rex?key=bob"} {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	// Requests served from the cache are counted too.
+	want := []usage.Record{
+		{Key: usage.Key{APIKey: "alice", Operation: "GET /pets/{petId}"}, Counts: usage.Counts{Requests: 2}},
+		{Key: usage.Key{APIKey: "bob", Operation: "GET /pets/{petId}"}, Counts: usage.Counts{Requests: 1, Errors: 1}},
+	}
+	if diff := pretty.Compare(want, usage.Query(store, "", "")); diff != "" {
+		t.Errorf("usage: %s", diff)
+	}
+}
+
+func TestProxyVariants(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"name": "Rex"}`)
+	}))
+	defer upstream.Close()
+
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(fmt.Sprintf(petstore, upstream.URL)), &s); err != nil {
+		t.Fatal(err)
+	}
+	get := s.Paths["/pets/{petId}"].Get
+	get.Extensions = map[string]interface{}{
+		variant.Extension: []interface{}{
+			map[string]interface{}{
+				"x-when": map[string]interface{}{"header": "X-API-Version", "value": "2"},
+				"responses": map[string]interface{}{
+					"200": map[string]interface{}{
+						"description": "A pet with an ID.",
+						"schema":      map[string]interface{}{"type": "object", "required": []interface{}{"id"}},
+					},
+				},
+			},
+		},
+	}
+	p, err := New(&s, Options{Upstreams: []string{upstream.URL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+
+	for _, test := range []struct {
+		version string
+		want    int
+	}{
+		{version: "", want: http.StatusOK},
+		{version: "1", want: http.StatusOK},
+		// The second version requires an ID the upstream doesn't send.
+		{version: "2", want: http.StatusBadGateway},
+	} {
+		req, _ := http.NewRequest("GET", srv.URL+"/v1/pets/1", nil)
+		if test.version != "" {
+			req.Header.Set("X-API-Version", test.version)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != test.want {
+			t.Errorf("version %q: want status %d, got %d", test.version, test.want, resp.StatusCode)
+		}
+	}
+
+	// Conflicting variants are rejected.
+	get.Extensions[variant.Extension] = []interface{}{
+		map[string]interface{}{"x-when": map[string]interface{}{"header": "X-API-Version"}, "responses": map[string]interface{}{}},
+		map[string]interface{}{"x-when": map[string]interface{}{"header": "X-API-Version", "value": "3"}, "responses": map[string]interface{}{}},
+	}
+	if err := p.Reload(&s); err == nil {
+		t.Errorf("expected error reloading a document with conflicting variants")
+	}
+}
+
+type rebase string
+
+func (b rebase) Resolve(doc *spec.Swagger) error {
+	doc.BasePath = string(b)
+	return nil
+}
+
+type countingValidator struct {
+	runtime.Validator
+	requests, responses int
+}
+
+func (v *countingValidator) ValidateRequest(doc *spec.Swagger, m *runtime.Match, r *http.Request) []runtime.Problem {
+	v.requests++
+	return v.Validator.ValidateRequest(doc, m, r)
+}
+
+func (v *countingValidator) ValidateResponse(doc *spec.Swagger, op *spec.Operation, code int, header http.Header, body []byte) []string {
+	v.responses++
+	return v.Validator.ValidateResponse(doc, op, code, header, body)
+}
+
+func TestProxyComponents(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `[]`)
+	}))
+	defer upstream.Close()
+
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(fmt.Sprintf(petstore, upstream.URL)), &s); err != nil {
+		t.Fatal(err)
+	}
+	v := &countingValidator{Validator: runtime.DefaultValidator}
+	p, err := New(&s, Options{
+		Upstreams: []string{upstream.URL},
+		Resolver:  rebase("/v2"),
+		Validator: v,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.BasePath != "/v1" {
+		t.Errorf("expected the resolver to be given a copy of the document, got basePath %s", s.BasePath)
+	}
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+
+	for path, want := range map[string]int{"/v2/pets": http.StatusOK, "/v1/pets": http.StatusNotFound} {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("%s: want status %d, got %d", path, want, resp.StatusCode)
+		}
+	}
+	if v.requests != 1 || v.responses != 1 {
+		t.Errorf("expected one request and response to be validated, got %d and %d", v.requests, v.responses)
+	}
+}
+
+func TestProxyReload(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"name": "Rex"}`)
+	}))
+	defer upstream.Close()
+
+	parse := func(doc string) *spec.Swagger {
+		var s spec.Swagger
+		if err := yaml.Unmarshal([]byte(doc), &s); err != nil {
+			t.Fatal(err)
+		}
+		return &s
+	}
+	p, err := New(parse(fmt.Sprintf(petstore, upstream.URL)), Options{Upstreams: []string{upstream.URL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+	get := func(path string) int {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if code := get("/v1/pets/1"); code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", code)
+	}
+	before := p.Status()
+	if got := len(before.Routes); got != 3 {
+		t.Errorf("expected 3 routes, got %d", got)
+	}
+	if r := before.Routes[1]; r.Path != "/pets/{petId}" || r.Requests != 1 || len(r.Upstreams) != 1 {
+		t.Errorf("unexpected route %+v", r)
+	}
+
+	v2 := strings.Replace(fmt.Sprintf(petstore, upstream.URL), "/pets/{petId}:", "/animals/{petId}:", 1)
+	v2 = strings.Replace(v2, `version: "1.0"`, `version: "2.0"`, 1)
+	if err := p.Reload(parse(v2)); err != nil {
+		t.Fatal(err)
+	}
+	if code := get("/v1/pets/1"); code != http.StatusNotFound {
+		t.Errorf("expected the old path to be removed, got status %d", code)
+	}
+	if code := get("/v1/animals/1"); code != http.StatusOK {
+		t.Errorf("expected the new path to be served, got status %d", code)
+	}
+	after := p.Status().Documents[0]
+	if after.Version != "2.0" || after.Hash == before.Documents[0].Hash {
+		t.Errorf("expected a new version and hash, got %+v", after)
+	}
+	if after.Footprint <= 0 {
+		t.Errorf("expected the document's footprint to be estimated, got %d", after.Footprint)
+	}
+
+	invalid := parse(v2)
+	invalid.Extensions = map[string]interface{}{ResiliencyExtension: map[string]interface{}{"timeout": "soon"}}
+	if err := p.Reload(invalid); err == nil {
+		t.Errorf("expected an error reloading a document with an invalid policy")
+	}
+	if got := p.Status().Documents[0].Version; got != "2.0" {
+		t.Errorf("expected a failed reload to keep the old document, got version %q", got)
+	}
+}
+
+func TestProxyReloadDrains(t *testing.T) {
+	arrived := make(chan struct{})
+	release := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/pets/1" {
+			arrived <- struct{}{}
+			<-release
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"name": "Rex"}`)
+	}))
+	defer upstream.Close()
+
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(fmt.Sprintf(petstore, upstream.URL)), &s); err != nil {
+		t.Fatal(err)
+	}
+	retired := make(chan int, 2)
+	p, err := New(&s, Options{
+		Upstreams: []string{upstream.URL},
+		OnRetire:  func(generation int) { retired <- generation },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+
+	done := make(chan int)
+	go func() {
+		resp, err := http.Get(srv.URL + "/v1/pets/1")
+		if err != nil {
+			done <- 0
+			return
+		}
+		resp.Body.Close()
+		done <- resp.StatusCode
+	}()
+	<-arrived
+
+	// The old route table serves the request in flight, even though the new
+	// document removes its path.
+	v2 := strings.Replace(fmt.Sprintf(petstore, upstream.URL), "/pets/{petId}:", "/animals/{petId}:", 1)
+	var s2 spec.Swagger
+	if err := yaml.Unmarshal([]byte(v2), &s2); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Reload(&s2); err != nil {
+		t.Fatal(err)
+	}
+	if got := p.Generation(); got != 1 {
+		t.Errorf("expected generation 1, got %d", got)
+	}
+	docs := p.Status().Documents
+	if len(docs) != 2 || docs[0].Generation != 1 || docs[1].Generation != 0 || docs[1].InFlight != 1 {
+		t.Errorf("expected generation 0 to be draining, got %+v", docs)
+	}
+	select {
+	case g := <-retired:
+		t.Fatalf("generation %d retired with a request in flight", g)
+	default:
+	}
+
+	close(release)
+	if code := <-done; code != http.StatusOK {
+		t.Errorf("expected the request in flight to succeed, got status %d", code)
+	}
+	if g := <-retired; g != 0 {
+		t.Errorf("expected generation 0 to retire, got %d", g)
+	}
+	if docs := p.Status().Documents; len(docs) != 1 {
+		t.Errorf("expected only the current document after draining, got %+v", docs)
+	}
+
+	// Without requests in flight, the old generation retires at once.
+	if err := p.Reload(&s); err != nil {
+		t.Fatal(err)
+	}
+	if g := <-retired; g != 1 {
+		t.Errorf("expected generation 1 to retire, got %d", g)
+	}
+}
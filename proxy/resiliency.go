@@ -0,0 +1,156 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ResiliencyExtension is the vendor extension declaring the resiliency policy of
+// a document, path item or operation. The most specific policy applies:
+//
+//	x-resiliency:
+//	  timeout: 2s
+//	  maxConcurrent: 50
+//	  circuitBreaker:
+//	    failures: 5
+//	    cooldown: 30s
+const ResiliencyExtension = "x-resiliency"
+
+// Policy limits how an operation's upstream is called. The zero value applies no
+// limits.
+type Policy struct {
+	// Timeout bounds how long a request to the upstream may take, including
+	// reading its response. Requests which time out receive a 504.
+	Timeout time.Duration
+	// MaxConcurrent bounds how many requests to the operation may be in
+	// flight at once. Requests over the limit receive a 503.
+	MaxConcurrent int
+	// CircuitBreaker, if set, stops calling an upstream which keeps failing.
+	CircuitBreaker *CircuitBreaker
+}
+
+// CircuitBreaker opens after a number of consecutive failures, rejecting
+// requests with a 503 without calling the upstream. After the cooldown a single
+// trial request is let through: if it succeeds the circuit closes, otherwise it
+// opens for another cooldown. Upstream errors, timeouts and 5xx responses are
+// failures.
+type CircuitBreaker struct {
+	Failures int
+	Cooldown time.Duration
+}
+
+// parsePolicy decodes the value of a resiliency extension.
+func parsePolicy(ext interface{}) (*Policy, error) {
+	data, err := json.Marshal(ext)
+	if err != nil {
+		return nil, err
+	}
+	var raw struct {
+		Timeout        string `json:"timeout"`
+		MaxConcurrent  int    `json:"maxConcurrent"`
+		CircuitBreaker *struct {
+			Failures int    `json:"failures"`
+			Cooldown string `json:"cooldown"`
+		} `json:"circuitBreaker"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	p := &Policy{MaxConcurrent: raw.MaxConcurrent}
+	if raw.Timeout != "" {
+		if p.Timeout, err = time.ParseDuration(raw.Timeout); err != nil {
+			return nil, fmt.Errorf("timeout: %v", err)
+		}
+	}
+	if p.MaxConcurrent < 0 {
+		return nil, fmt.Errorf("maxConcurrent must not be negative")
+	}
+	if cb := raw.CircuitBreaker; cb != nil {
+		if cb.Failures <= 0 {
+			return nil, fmt.Errorf("circuitBreaker: failures must be positive")
+		}
+		cooldown, err := time.ParseDuration(cb.Cooldown)
+		if err != nil {
+			return nil, fmt.Errorf("circuitBreaker: cooldown: %v", err)
+		}
+		p.CircuitBreaker = &CircuitBreaker{Failures: cb.Failures, Cooldown: cooldown}
+	}
+	return p, nil
+}
+
+// guard enforces an operation's policy.
+type guard struct {
+	policy Policy
+	now    func() time.Time
+	// sem holds a token for each request in flight. It's nil if concurrency
+	// isn't limited.
+	sem chan struct{}
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	trial     bool
+}
+
+func newGuard(p Policy, now func() time.Time) *guard {
+	g := &guard{policy: p, now: now}
+	if p.MaxConcurrent > 0 {
+		g.sem = make(chan struct{}, p.MaxConcurrent)
+	}
+	return g
+}
+
+// acquire reserves a slot for a request, returning a message describing why the
+// request was rejected if it can't be made. A successful acquire must be
+// followed by a call to finish.
+func (g *guard) acquire() string {
+	trial := false
+	if cb := g.policy.CircuitBreaker; cb != nil {
+		g.mu.Lock()
+		if g.failures >= cb.Failures {
+			if g.trial || g.now().Before(g.openUntil) {
+				g.mu.Unlock()
+				return "circuit breaker is open"
+			}
+			g.trial, trial = true, true
+		}
+		g.mu.Unlock()
+	}
+	if g.sem != nil {
+		select {
+		case g.sem <- struct{}{}:
+		default:
+			if trial {
+				g.mu.Lock()
+				g.trial = false
+				g.mu.Unlock()
+			}
+			return fmt.Sprintf("more than %d concurrent requests", g.policy.MaxConcurrent)
+		}
+	}
+	return ""
+}
+
+// finish releases a request's slot and records whether it failed.
+func (g *guard) finish(failed bool) {
+	if g.sem != nil {
+		<-g.sem
+	}
+	cb := g.policy.CircuitBreaker
+	if cb == nil {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.trial = false
+	if !failed {
+		g.failures = 0
+		return
+	}
+	g.failures++
+	if g.failures >= cb.Failures {
+		g.openUntil = g.now().Add(cb.Cooldown)
+	}
+}
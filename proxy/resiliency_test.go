@@ -0,0 +1,165 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+const resilient = `
+swagger: "2.0"
+info: {title: Slow, version: "1.0"}
+x-resiliency:
+  circuitBreaker: {failures: 2, cooldown: 1m}
+paths:
+  /slow:
+    get:
+      x-resiliency: {timeout: 20ms}
+      responses: {200: {description: OK}}
+  /busy:
+    x-resiliency: {maxConcurrent: 1}
+    get:
+      responses: {200: {description: OK}}
+  /flaky:
+    get:
+      responses: {default: {description: Anything.}}
+`
+
+func get(t *testing.T, url string) int {
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestResiliency(t *testing.T) {
+	var (
+		flaky    int32 = 1
+		busy           = make(chan struct{})
+		received       = make(chan struct{})
+	)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow":
+			select {
+			case <-time.After(time.Second):
+			case <-r.Context().Done():
+			}
+		case "/busy":
+			received <- struct{}{}
+			<-busy
+		case "/flaky":
+			if atomic.LoadInt32(&flaky) == 1 {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		}
+	}))
+	defer upstream.Close()
+
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(resilient), &s); err != nil {
+		t.Fatal(err)
+	}
+	clock := &fakeClock{now: time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)}
+	p, err := New(&s, Options{Upstreams: []string{upstream.URL}, Clock: clock})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+
+	if code := get(t, srv.URL+"/slow"); code != http.StatusGatewayTimeout {
+		t.Errorf("slow: want status 504, got %d", code)
+	}
+
+	done := make(chan int)
+	go func() { done <- get(t, srv.URL+"/busy") }()
+	<-received
+	if code := get(t, srv.URL+"/busy"); code != http.StatusServiceUnavailable {
+		t.Errorf("busy: want status 503 while a request is in flight, got %d", code)
+	}
+	close(busy)
+	if code := <-done; code != http.StatusOK {
+		t.Errorf("busy: want status 200, got %d", code)
+	}
+
+	for i, want := range []int{500, 500, 503, 503} {
+		if code := get(t, srv.URL+"/flaky"); code != want {
+			t.Errorf("flaky request %d: want status %d, got %d", i, want, code)
+		}
+	}
+	clock.Add(time.Minute)
+	atomic.StoreInt32(&flaky, 0)
+	for i, want := range []int{200, 200} {
+		if code := get(t, srv.URL+"/flaky"); code != want {
+			t.Errorf("flaky request after cooldown %d: want status %d, got %d", i, want, code)
+		}
+	}
+
+	m := p.Metrics()
+	if m.Timeouts != 1 || m.Rejected != 3 {
+		t.Errorf("want 1 timeout and 3 rejected requests, got %d and %d", m.Timeouts, m.Rejected)
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		ext     string
+		want    Policy
+		wantErr bool
+	}{
+		{ext: `{timeout: 1s, maxConcurrent: 5}`, want: Policy{Timeout: time.Second, MaxConcurrent: 5}},
+		{ext: `{circuitBreaker: {failures: 3, cooldown: 10s}}`, want: Policy{CircuitBreaker: &CircuitBreaker{3, 10 * time.Second}}},
+		{ext: `{timeout: soon}`, wantErr: true},
+		{ext: `{maxConcurrent: -1}`, wantErr: true},
+		{ext: `{circuitBreaker: {cooldown: 10s}}`, wantErr: true},
+	}
+	for i, tt := range tests {
+		var s spec.Swagger
+		if err := yaml.Unmarshal([]byte("x-resiliency: "+tt.ext), &s); err != nil {
+			t.Fatal(err)
+		}
+		got, err := parsePolicy(s.Extensions[ResiliencyExtension])
+		if err != nil {
+			if !tt.wantErr {
+				t.Errorf("case %d: unexpected error: %v", i, err)
+			}
+			continue
+		}
+		if tt.wantErr {
+			t.Errorf("case %d: expected error", i)
+			continue
+		}
+		if diff := pretty.Compare(got, tt.want); diff != "" {
+			t.Errorf("case %d: want != got: %s", i, diff)
+		}
+	}
+}
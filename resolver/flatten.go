@@ -0,0 +1,61 @@
+package resolver
+
+import (
+	"fmt"
+
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// DefaultMaxDepth is how many times Flatten expands a recursive schema within
+// itself unless WithMaxDepth is given.
+const DefaultMaxDepth = 3
+
+// Cycles is how Flatten handles a recursive schema once it has been expanded
+// the maximum number of times.
+type Cycles int
+
+const (
+	// TruncateCycles replaces the recursive reference with an empty schema,
+	// which allows any value.
+	TruncateCycles Cycles = iota
+	// RejectCycles makes recursive schemas an error.
+	RejectCycles
+	// KeepCycles leaves the recursive reference in place, as Resolve does.
+	KeepCycles
+)
+
+func (c Cycles) String() string {
+	switch c {
+	case TruncateCycles:
+		return "truncate"
+	case RejectCycles:
+		return "reject"
+	case KeepCycles:
+		return "keep"
+	}
+	return fmt.Sprintf("Cycles(%d)", int(c))
+}
+
+// WithMaxDepth sets how many times Flatten expands a recursive schema within
+// itself. It's ignored by Resolve.
+func WithMaxDepth(n int) Option {
+	return func(r *resolver) { r.maxDepth = n }
+}
+
+// WithCycles sets how Flatten handles recursive schemas. It defaults to
+// TruncateCycles and is ignored by Resolve.
+func WithCycles(c Cycles) Option {
+	return func(r *resolver) { r.cycles = c }
+}
+
+// Flatten replaces every reference in a document with a copy of its target,
+// like Resolve, but also expands recursive schemas, for consumers which can't
+// follow references at all. A recursive schema is expanded within itself up to
+// the maximum depth, after which the recursion is handled as WithCycles
+// configures. Unless cycles are kept, the flattened document has no
+// references left.
+func Flatten(doc *spec.Swagger, opts ...Option) error {
+	r := newResolver(append([]Option{WithMaxDepth(DefaultMaxDepth)}, opts...))
+	r.flatten = true
+	return r.resolve(doc)
+}
@@ -0,0 +1,196 @@
+package resolver
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ericchiang/swaggopher/internal/logutil"
+	"github.com/ericchiang/swaggopher/internal/refs"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// HTTPLoader fetches documents over HTTP and HTTPS, and reads file paths from
+// disk. Its Load method can be passed to WithLoader, and it implements
+// runtime.Loader.
+//
+// Fetched documents are cached along with their ETag and Last-Modified
+// headers. Later fetches of the same URL send them back in If-None-Match and
+// If-Modified-Since headers, and a 304 Not Modified response is answered from
+// the cache, so a hosted document is only downloaded again when it changes.
+//
+// An HTTPLoader is safe for concurrent use. The zero value is valid, and caches
+// in memory for its lifetime.
+type HTTPLoader struct {
+	// Client sends requests. It defaults to http.DefaultClient.
+	Client *http.Client
+	// Timeout bounds each fetch, including reading the response body. Zero
+	// means no timeout other than the Client's.
+	Timeout time.Duration
+	// Auth is called with every request before it's sent, to add credentials
+	// such as an Authorization header.
+	Auth func(req *http.Request) error
+	// CacheDir, if set, is a directory the cache is also kept in, so that it
+	// lasts between processes. It's created if it doesn't exist.
+	CacheDir string
+	// Logger, if set, receives a line for each URL fetched, saying whether
+	// the cached copy was used.
+	Logger spec.Logger
+
+	mu    sync.Mutex
+	cache map[string]*cachedDocument
+}
+
+// cachedDocument is a fetched document and the validators to revalidate it
+// with.
+type cachedDocument struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	Body         []byte `json:"body"`
+}
+
+// Load returns the document at a file path or an http or https URL.
+func (l *HTTPLoader) Load(location string) ([]byte, error) {
+	if !refs.IsURL(location) {
+		return ioutil.ReadFile(location)
+	}
+	cached := l.cached(location)
+
+	ctx := context.Background()
+	if l.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, l.Timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequest("GET", location, nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if cached != nil {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+	if l.Auth != nil {
+		if err := l.Auth(req); err != nil {
+			return nil, fmt.Errorf("fetching %s: %v", location, err)
+		}
+	}
+	client := l.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		logutil.Printf(l.Logger, "resolver: %s not modified, using the cached copy", location)
+		return cached.Body, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("fetching %s: %s", location, resp.Status)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: %v", location, err)
+	}
+	logutil.Printf(l.Logger, "resolver: fetched %s (%d bytes)", location, len(body))
+	doc := &cachedDocument{
+		URL:          location,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Body:         body,
+	}
+	if doc.ETag != "" || doc.LastModified != "" {
+		if err := l.store(doc); err != nil {
+			return nil, err
+		}
+	}
+	return body, nil
+}
+
+// cached returns the cached copy of a URL's document, looking in CacheDir if
+// it isn't held in memory.
+func (l *HTTPLoader) cached(location string) *cachedDocument {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if doc, ok := l.cache[location]; ok {
+		return doc
+	}
+	if l.CacheDir == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(l.cacheFile(location))
+	if err != nil {
+		return nil
+	}
+	// An unreadable entry is treated as a miss, and replaced by the next
+	// successful fetch.
+	doc := new(cachedDocument)
+	if err := json.Unmarshal(data, doc); err != nil || doc.URL != location {
+		return nil
+	}
+	l.remember(doc)
+	return doc
+}
+
+func (l *HTTPLoader) store(doc *cachedDocument) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.remember(doc)
+	if l.CacheDir == "" {
+		return nil
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(l.CacheDir, 0755); err != nil {
+		return err
+	}
+	// Write to a temporary file first, so a concurrent process never reads a
+	// partial entry.
+	tmp, err := ioutil.TempFile(l.CacheDir, "tmp-")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), l.cacheFile(doc.URL))
+}
+
+func (l *HTTPLoader) remember(doc *cachedDocument) {
+	if l.cache == nil {
+		l.cache = make(map[string]*cachedDocument)
+	}
+	l.cache[doc.URL] = doc
+}
+
+// cacheFile returns the file a URL's document is cached in, named after its
+// hash.
+func (l *HTTPLoader) cacheFile(location string) string {
+	sum := sha256.Sum256([]byte(location))
+	return filepath.Join(l.CacheDir, hex.EncodeToString(sum[:])+".json")
+}
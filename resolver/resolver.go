@@ -0,0 +1,346 @@
+/*
+Package resolver dereferences the JSON References in Swagger documents.
+
+References may point within the document, such as "#/definitions/Pet",
+to other files, such as "common.yaml#/definitions/Error", or to remote
+documents over HTTP. Relative references are resolved against the location of
+the document they appear in, which for the root document is set with WithBase.
+
+Remote documents are fetched with http.DefaultClient unless another Loader is
+given. HTTPLoader adds credentials to requests, bounds how long they take, and
+caches documents so they're only downloaded again when they change:
+
+	l := &resolver.HTTPLoader{Timeout: 10 * time.Second, Auth: addToken}
+	err := resolver.Resolve(doc, resolver.WithBase(url), resolver.WithLoader(l.Load))
+*/
+package resolver
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+
+	"github.com/ericchiang/swaggopher/internal/jsonpointer"
+	"github.com/ericchiang/swaggopher/internal/logutil"
+	"github.com/ericchiang/swaggopher/internal/refs"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// Loader fetches the JSON or YAML document at a location, which is either a
+// file path or an absolute URL.
+type Loader func(location string) ([]byte, error)
+
+// Load calls l.
+func (l Loader) Load(location string) ([]byte, error) {
+	return l(location)
+}
+
+// Option configures Resolve.
+type Option func(*resolver)
+
+// WithBase sets the file path or URL of the document being resolved. Relative
+// references are resolved against it. It defaults to the current directory.
+func WithBase(location string) Option {
+	return func(r *resolver) { r.base = location }
+}
+
+// WithLoader overrides how referenced documents are fetched. The default reads
+// files from disk and fetches http and https URLs with http.DefaultClient.
+func WithLoader(l Loader) Option {
+	return func(r *resolver) { r.load = l }
+}
+
+// WithLogger logs each document fetched and each recursive reference left in
+// place.
+func WithLogger(l spec.Logger) Option {
+	return func(r *resolver) { r.logger = l }
+}
+
+// Resolve replaces every reference in a document with a copy of the Schema,
+// Parameter, Response or Path Item it refers to.
+//
+// Recursive schemas, such as a tree node with children of the same type, can't
+// be fully expanded. A reference which would recurse is left in place, pointing
+// at its target in the document, and is an error if the target is in another
+// file. Circular references between parameters, responses and path items are
+// always an error.
+func Resolve(doc *spec.Swagger, opts ...Option) error {
+	return newResolver(opts).resolve(doc)
+}
+
+func newResolver(opts []Option) *resolver {
+	r := &resolver{load: DefaultLoader, active: make(map[string]int)}
+	for _, opt := range opts {
+		opt(r)
+	}
+	r.docs = refs.Documents{Load: r.load, Logger: r.logger, Prefix: "resolver"}
+	return r
+}
+
+func (r *resolver) resolve(doc *spec.Swagger) error {
+	defer logutil.Phase(r.logger, "resolve")()
+	if r.base != "" && !refs.IsURL(r.base) {
+		r.base = filepath.Clean(r.base)
+	}
+
+	// Snapshot the document so local references see its original values.
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("resolver: %v", err)
+	}
+	var root interface{}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return fmt.Errorf("resolver: %v", err)
+	}
+	r.docs.Set(r.base, root)
+
+	if err := r.document(doc); err != nil {
+		return fmt.Errorf("resolver: %v", err)
+	}
+	return nil
+}
+
+type resolver struct {
+	base   string
+	load   Loader
+	logger spec.Logger
+	// docs caches decoded documents by location.
+	docs refs.Documents
+	// active counts the references currently being resolved, keyed by their
+	// location and fragment, to detect cycles.
+	active map[string]int
+
+	// flatten, maxDepth and cycles configure Flatten.
+	flatten  bool
+	maxDepth int
+	cycles   Cycles
+}
+
+func (r *resolver) document(doc *spec.Swagger) error {
+	// Reusable objects are marked active while they're resolved so that a
+	// definition which refers to itself is detected immediately.
+	for name, s := range doc.Definitions {
+		key := r.base + "#" + jsonpointer.Join("/definitions", name)
+		r.active[key]++
+		err := r.schema(r.base, &s)
+		r.active[key]--
+		if err != nil {
+			return fmt.Errorf("definitions %s: %v", name, err)
+		}
+		doc.Definitions[name] = s
+	}
+	for name, p := range doc.Parameters {
+		key := r.base + "#" + jsonpointer.Join("/parameters", name)
+		r.active[key]++
+		err := r.parameter(r.base, &p)
+		r.active[key]--
+		if err != nil {
+			return fmt.Errorf("parameters %s: %v", name, err)
+		}
+		doc.Parameters[name] = p
+	}
+	for name, resp := range doc.Responses {
+		key := r.base + "#" + jsonpointer.Join("/responses", name)
+		r.active[key]++
+		err := r.response(r.base, &resp)
+		r.active[key]--
+		if err != nil {
+			return fmt.Errorf("responses %s: %v", name, err)
+		}
+		doc.Responses[name] = resp
+	}
+	for path, item := range doc.Paths {
+		if err := r.pathItem(r.base, &item); err != nil {
+			return fmt.Errorf("paths %s: %v", path, err)
+		}
+		doc.Paths[path] = item
+	}
+	return nil
+}
+
+func (r *resolver) pathItem(base string, item *spec.PathItem) error {
+	if item.Ref != "" {
+		var target spec.PathItem
+		_, _, cyclic, err := r.follow(base, item.Ref, 0, &target, func(loc string) error {
+			return r.pathItem(loc, &target)
+		})
+		if err != nil {
+			return err
+		}
+		if cyclic {
+			return fmt.Errorf("circular reference %q", item.Ref)
+		}
+		*item = target
+		return nil
+	}
+	for i := range item.Parameters {
+		if err := r.parameter(base, &item.Parameters[i]); err != nil {
+			return err
+		}
+	}
+	for _, method := range spec.Methods {
+		op := item.Operation(method)
+		if op == nil {
+			continue
+		}
+		for i := range op.Parameters {
+			if err := r.parameter(base, &op.Parameters[i]); err != nil {
+				return err
+			}
+		}
+		for code, resp := range op.Responses {
+			if err := r.response(base, &resp); err != nil {
+				return err
+			}
+			op.Responses[code] = resp
+		}
+	}
+	return nil
+}
+
+func (r *resolver) parameter(base string, p *spec.Parameter) error {
+	if p.Ref != "" {
+		var target spec.Parameter
+		_, _, cyclic, err := r.follow(base, p.Ref, 0, &target, func(loc string) error {
+			return r.parameter(loc, &target)
+		})
+		if err != nil {
+			return err
+		}
+		if cyclic {
+			return fmt.Errorf("circular reference %q", p.Ref)
+		}
+		*p = target
+		return nil
+	}
+	if p.Schema != nil {
+		return r.schema(base, p.Schema)
+	}
+	return nil
+}
+
+func (r *resolver) response(base string, resp *spec.Response) error {
+	if resp.Ref != "" {
+		var target spec.Response
+		_, _, cyclic, err := r.follow(base, resp.Ref, 0, &target, func(loc string) error {
+			return r.response(loc, &target)
+		})
+		if err != nil {
+			return err
+		}
+		if cyclic {
+			return fmt.Errorf("circular reference %q", resp.Ref)
+		}
+		*resp = target
+		return nil
+	}
+	if resp.Schema != nil {
+		return r.schema(base, resp.Schema)
+	}
+	return nil
+}
+
+func (r *resolver) schema(base string, s *spec.Schema) error {
+	if s.Ref != "" {
+		ref := refs.SchemaRef(s.Ref)
+		repeats := 0
+		if r.flatten {
+			repeats = r.maxDepth
+		}
+		var target spec.Schema
+		loc, fragment, cyclic, err := r.follow(base, ref, repeats, &target, func(loc string) error {
+			return r.schema(loc, &target)
+		})
+		if err != nil {
+			return err
+		}
+		if cyclic {
+			if r.flatten {
+				switch r.cycles {
+				case TruncateCycles:
+					*s = spec.Schema{}
+					return nil
+				case RejectCycles:
+					return fmt.Errorf("recursive reference %q", s.Ref)
+				}
+			}
+			if loc != r.base {
+				return fmt.Errorf("circular reference %q can't be linked outside of %s", s.Ref, loc)
+			}
+			logutil.Printf(r.logger, "resolver: %s: recursive reference kept", s.Ref)
+			s.Ref = "#" + fragment
+			return nil
+		}
+		*s = target
+		return nil
+	}
+
+	if s.Items != nil {
+		if err := r.schema(base, s.Items); err != nil {
+			return err
+		}
+	}
+	for i := range s.AllOf {
+		if err := r.schema(base, &s.AllOf[i]); err != nil {
+			return err
+		}
+	}
+	for name, prop := range s.Properties {
+		if err := r.schema(base, &prop); err != nil {
+			return err
+		}
+		s.Properties[name] = prop
+	}
+	if ap := s.AdditionalProperties; ap != nil && ap.Schema != nil {
+		if err := r.schema(base, ap.Schema); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// follow decodes the value ref refers to into v, then calls resolve with the
+// location of the document it came from to resolve any references within it. It
+// returns the location and fragment of the reference, and reports if the
+// reference is already being resolved more than repeats times, in which case v
+// is left untouched.
+func (r *resolver) follow(base, ref string, repeats int, v interface{}, resolve func(loc string) error) (loc, fragment string, cyclic bool, err error) {
+	loc, fragment, err = refs.Locate(base, ref)
+	if err != nil {
+		return "", "", false, err
+	}
+	key := loc + "#" + fragment
+	if r.active[key] > repeats {
+		return loc, fragment, true, nil
+	}
+
+	if err := r.docs.Get(loc, fragment, ref, v); err != nil {
+		return "", "", false, err
+	}
+
+	r.active[key]++
+	defer func() { r.active[key]-- }()
+	if err := resolve(loc); err != nil {
+		return "", "", false, err
+	}
+	return loc, fragment, false, nil
+}
+
+// DefaultLoader reads files from disk and fetches http and https URLs with
+// http.DefaultClient.
+func DefaultLoader(location string) ([]byte, error) {
+	if !refs.IsURL(location) {
+		return ioutil.ReadFile(location)
+	}
+	resp, err := http.Get(location)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: %s", location, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
@@ -0,0 +1,276 @@
+package resolver
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+func load(t *testing.T, path string) *spec.Swagger {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s spec.Swagger
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		t.Fatal(err)
+	}
+	return &s
+}
+
+func TestResolve(t *testing.T) {
+	s := load(t, "testdata/swagger.yaml")
+	if err := Resolve(s, WithBase("testdata/swagger.yaml")); err != nil {
+		t.Fatal(err)
+	}
+
+	str := spec.Schema{Type: "string"}
+	owner := spec.Schema{Type: "object", Properties: map[string]spec.Schema{"name": str}}
+	pet := spec.Schema{Type: "object", Properties: map[string]spec.Schema{"name": str, "owner": owner}}
+
+	get := s.Paths["/pets"].Get
+	if diff := pretty.Compare(get.Parameters, []spec.Parameter{{Name: "limit", In: "query", Type: "integer"}}); diff != "" {
+		t.Errorf("parameters: want != got: %s", diff)
+	}
+	wantResponses := spec.Responses{
+		"200": {
+			Description: "A list of pets.",
+			Schema:      &spec.Schema{Type: "array", Items: &pet},
+		},
+		"default": {
+			Description: "An error.",
+			Schema:      &spec.Schema{Type: "object", Properties: map[string]spec.Schema{"message": str}},
+		},
+	}
+	if diff := pretty.Compare(get.Responses, wantResponses); diff != "" {
+		t.Errorf("responses: want != got: %s", diff)
+	}
+
+	owners := s.Paths["/owners"]
+	if owners.Ref != "" || owners.Get == nil {
+		t.Fatalf("expected /owners to be loaded from owners.yaml, got %+v", owners)
+	}
+	if diff := pretty.Compare(owners.Get.Responses["200"].Schema, &spec.Schema{Type: "array", Items: &owner}); diff != "" {
+		t.Errorf("owners: want != got: %s", diff)
+	}
+
+	// Recursive schemas stay linked to their definition.
+	node := s.Definitions["Node"].Properties["children"].Items
+	if node == nil || node.Ref != "#/definitions/Node" {
+		t.Errorf("expected recursive reference to be kept, got %+v", node)
+	}
+}
+
+func TestResolveRemote(t *testing.T) {
+	docs := map[string]string{
+		"https://example.com/specs/common.yaml": `
+definitions:
+  Error:
+    type: object
+    properties:
+      code: {$ref: 'types/code.yaml'}
+`,
+		"https://example.com/specs/types/code.yaml": `type: integer`,
+	}
+	loader := func(location string) ([]byte, error) {
+		data, ok := docs[location]
+		if !ok {
+			return nil, fmt.Errorf("not found: %s", location)
+		}
+		return []byte(data), nil
+	}
+
+	s := &spec.Swagger{
+		Definitions: spec.Definitions{
+			"Error": {Ref: "common.yaml#/definitions/Error"},
+		},
+	}
+	if err := Resolve(s, WithBase("https://example.com/specs/swagger.yaml"), WithLoader(loader)); err != nil {
+		t.Fatal(err)
+	}
+	want := spec.Schema{Type: "object", Properties: map[string]spec.Schema{"code": {Type: "integer"}}}
+	if diff := pretty.Compare(s.Definitions["Error"], want); diff != "" {
+		t.Errorf("want != got: %s", diff)
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	tests := []*spec.Swagger{
+		// Missing local definition.
+		{Definitions: spec.Definitions{"A": {Ref: "#/definitions/B"}}},
+		// Missing file.
+		{Definitions: spec.Definitions{"A": {Ref: "missing.yaml#/definitions/B"}}},
+		// Recursive schema in another file.
+		{Definitions: spec.Definitions{"A": {Ref: "cycle.yaml#/definitions/A"}}},
+		// Circular parameters.
+		{Parameters: spec.ParametersDefinitions{
+			"a": {Ref: "#/parameters/b"},
+			"b": {Ref: "#/parameters/a"},
+		}},
+	}
+	for i, s := range tests {
+		if err := Resolve(s, WithBase("testdata/swagger.yaml")); err == nil {
+			t.Errorf("case %d: expected error", i)
+		}
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	// depth returns how many times a Node is nested within the schema.
+	depth := func(s spec.Schema) int {
+		n := 0
+		for {
+			items := s.Properties["children"].Items
+			if items == nil || items.Type != "object" {
+				return n
+			}
+			s = *items
+			n++
+		}
+	}
+
+	s := load(t, "testdata/swagger.yaml")
+	if err := Flatten(s, WithBase("testdata/swagger.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	node := s.Definitions["Node"]
+	if got := depth(node); got != DefaultMaxDepth {
+		t.Errorf("want Node expanded %d times, got %d", DefaultMaxDepth, got)
+	}
+	for i := 0; i < DefaultMaxDepth; i++ {
+		node = *node.Properties["children"].Items
+	}
+	if diff := pretty.Compare(node.Properties["children"].Items, &spec.Schema{}); diff != "" {
+		t.Errorf("want recursion truncated to an empty schema: %s", diff)
+	}
+
+	s = load(t, "testdata/swagger.yaml")
+	if err := Flatten(s, WithBase("testdata/swagger.yaml"), WithMaxDepth(1), WithCycles(KeepCycles)); err != nil {
+		t.Fatal(err)
+	}
+	node = s.Definitions["Node"]
+	if got := depth(node); got != 1 {
+		t.Errorf("want Node expanded once, got %d", got)
+	}
+	if ref := node.Properties["children"].Items.Properties["children"].Items.Ref; ref != "#/definitions/Node" {
+		t.Errorf("expected recursive reference to be kept, got %q", ref)
+	}
+
+	s = load(t, "testdata/swagger.yaml")
+	err := Flatten(s, WithBase("testdata/swagger.yaml"), WithCycles(RejectCycles))
+	if want := `resolver: definitions Node: recursive reference "#/definitions/Node"`; err == nil || err.Error() != want {
+		t.Errorf("want error %q, got %v", want, err)
+	}
+
+	// Recursive schemas in other files can be flattened.
+	s = &spec.Swagger{Definitions: spec.Definitions{"A": {Ref: "cycle.yaml#/definitions/A"}}}
+	if err := Flatten(s, WithBase("testdata/swagger.yaml"), WithMaxDepth(0)); err != nil {
+		t.Fatal(err)
+	}
+	want := spec.Schema{Type: "object", Properties: map[string]spec.Schema{"a": {}}}
+	if diff := pretty.Compare(s.Definitions["A"], want); diff != "" {
+		t.Errorf("want != got: %s", diff)
+	}
+}
+
+func TestHTTPLoader(t *testing.T) {
+	var downloads, requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/slow.yaml":
+			time.Sleep(200 * time.Millisecond)
+		case "/common.yaml":
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			downloads++
+			w.Header().Set("ETag", `"v1"`)
+			io.WriteString(w, "definitions:\n  Code: {type: integer}\n")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "resolver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	auth := func(req *http.Request) error {
+		req.Header.Set("Authorization", "Bearer secret")
+		return nil
+	}
+	var logs, resolveLogs bytes.Buffer
+	l := &HTTPLoader{Auth: auth, CacheDir: dir, Timeout: 100 * time.Millisecond, Logger: log.New(&logs, "", 0)}
+
+	s := &spec.Swagger{
+		Definitions: spec.Definitions{"Code": {Ref: "common.yaml#/definitions/Code"}},
+	}
+	if err := Resolve(s, WithBase(srv.URL+"/swagger.yaml"), WithLoader(l.Load), WithLogger(log.New(&resolveLogs, "", 0))); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(resolveLogs.String(), "\n"), "\n")
+	if len(lines) != 3 || lines[0] != "resolve: started" ||
+		lines[1] != "resolver: loaded "+srv.URL+"/common.yaml (37 bytes)" ||
+		!strings.HasPrefix(lines[2], "resolve: finished in ") {
+		t.Errorf("unexpected resolver logs %q", resolveLogs.String())
+	}
+	if diff := pretty.Compare(s.Definitions["Code"], spec.Schema{Type: "integer"}); diff != "" {
+		t.Errorf("want != got: %s", diff)
+	}
+
+	// Later fetches, including by another loader sharing the cache directory,
+	// are revalidated rather than downloaded again.
+	for _, loader := range []*HTTPLoader{l, {Auth: auth, CacheDir: dir}} {
+		data, err := loader.Load(srv.URL + "/common.yaml")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), "Code") {
+			t.Errorf("unexpected document: %s", data)
+		}
+	}
+	if downloads != 1 || requests != 3 {
+		t.Errorf("expected 3 requests and 1 download, got %d and %d", requests, downloads)
+	}
+	wantLogs := fmt.Sprintf(`resolver: fetched %[1]s/common.yaml (37 bytes)
+resolver: %[1]s/common.yaml not modified, using the cached copy
+`, srv.URL)
+	if logs.String() != wantLogs {
+		t.Errorf("want logs:\n%s\ngot:\n%s", wantLogs, logs.String())
+	}
+
+	for _, tt := range []struct {
+		l    *HTTPLoader
+		path string
+	}{
+		{&HTTPLoader{}, "/common.yaml"},
+		{l, "/missing.yaml"},
+		{l, "/slow.yaml"},
+	} {
+		if _, err := tt.l.Load(srv.URL + tt.path); err == nil {
+			t.Errorf("%s: expected error", tt.path)
+		}
+	}
+}
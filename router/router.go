@@ -0,0 +1,436 @@
+/*
+Package router matches HTTP requests to the operations of a document.
+
+A Router is compiled once from a document's paths, then resolves a method and
+URL path to the operation which handles it and the values of the path's
+variables:
+
+	rt := router.New(doc)
+	m, err := rt.Match("GET", "/v1/pets/7")
+	// m.Template == "/pets/{petId}", m.Vars["petId"] == "7"
+
+The document's basePath is removed before matching. Each segment of a path is
+matched against the templates' literal segments first, then segments mixing
+literals and variables such as "{id}.json", and finally segments which are a
+single variable, so "/pets/mine" matches before "/pets/{petId}" whichever is
+declared first. A later segment which doesn't match backtracks to the next
+candidate for an earlier one.
+
+By default "/pets" and "/pets/" are the same path, and literal segments are
+case sensitive. Options change both, and can redirect requests which only match
+once their trailing slash or case is corrected:
+
+	rt := router.Options{TrailingSlash: router.RedirectSlash}.New(doc)
+*/
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/internal/mapkeys"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// Match is the operation a request was routed to.
+type Match struct {
+	// Template is the path template, such as "/pets/{petId}".
+	Template string
+	// Method is the upper case HTTP method.
+	Method    string
+	Item      *spec.PathItem
+	Operation *spec.Operation
+	// Vars holds the values of the template's variables.
+	Vars map[string]string
+}
+
+// String returns the method and template, such as "GET /pets/{petId}".
+func (m *Match) String() string {
+	return m.Method + " " + m.Template
+}
+
+// Error is returned when no operation handles a request.
+type Error struct {
+	// Status is http.StatusNotFound if no path matches,
+	// http.StatusMethodNotAllowed if the path doesn't support the method, or
+	// http.StatusPermanentRedirect if the request should be redirected.
+	Status  int
+	Message string
+	// Location is the path to redirect to, if Status is
+	// http.StatusPermanentRedirect.
+	Location string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// SlashPolicy is how paths with and without a trailing slash are matched.
+type SlashPolicy string
+
+const (
+	// IgnoreSlash treats "/pets" and "/pets/" as the same path, preferring
+	// the template with the same trailing slash if both are declared. It's
+	// the default.
+	IgnoreSlash SlashPolicy = "ignore"
+	// StrictSlash treats "/pets" and "/pets/" as distinct paths.
+	StrictSlash SlashPolicy = "strict"
+	// RedirectSlash treats "/pets" and "/pets/" as distinct paths, but
+	// redirects a path which only matches once its trailing slash is added or
+	// removed.
+	RedirectSlash SlashPolicy = "redirect"
+)
+
+// CasePolicy is how the case of literal segments is matched.
+type CasePolicy string
+
+const (
+	// SensitiveCase matches literal segments only in the case they're
+	// declared in. It's the default.
+	SensitiveCase CasePolicy = "sensitive"
+	// IgnoreCase matches literal segments in any case, preferring the
+	// declared case if templates differ only in case.
+	IgnoreCase CasePolicy = "ignore"
+	// RedirectCase redirects a path which only matches in another case to
+	// the declared case.
+	RedirectCase CasePolicy = "redirect"
+)
+
+// Options configures a Router. The zero value ignores trailing slashes and
+// matches case.
+type Options struct {
+	TrailingSlash SlashPolicy `json:"trailingSlash,omitempty" yaml:"trailingSlash,omitempty"`
+	Case          CasePolicy  `json:"case,omitempty" yaml:"case,omitempty"`
+}
+
+// Router matches requests to the operations of a document. It's safe for
+// concurrent use, and doesn't see changes made to the document after New.
+type Router struct {
+	opts     Options
+	basePath string
+	root     *node
+}
+
+// node is a segment of the path templates. A path template's item is held by
+// the node of its last segment.
+type node struct {
+	// static holds the children which are literal segments.
+	static map[string]*node
+	// patterns holds the children mixing literals and variables, most
+	// specific first.
+	patterns []*pattern
+	// variable is the child which is a single variable. Templates naming
+	// the variable differently share it, and the names are taken from the
+	// template matched.
+	variable *node
+
+	// template and item are those of the template ending in this node
+	// without a trailing slash, and slashTemplate and slashItem those of the
+	// one with.
+	template      string
+	item          *spec.PathItem
+	slashTemplate string
+	slashItem     *spec.PathItem
+}
+
+// pattern is a segment mixing literals and variables, such as "{id}.json".
+type pattern struct {
+	segment string
+	// re matches values of the segment, and fold matches them in any case.
+	re    *regexp.Regexp
+	fold  *regexp.Regexp
+	names []string
+	next  *node
+}
+
+// expand returns the segment with its variables replaced by values, in order.
+func (p *pattern) expand(values []string) string {
+	i := 0
+	return variablePattern.ReplaceAllStringFunc(p.segment, func(string) string {
+		v := values[i]
+		i++
+		return v
+	})
+}
+
+// New compiles the paths of a document with the default options.
+func New(doc *spec.Swagger) *Router {
+	return Options{}.New(doc)
+}
+
+// New compiles the paths of a document.
+func (o Options) New(doc *spec.Swagger) *Router {
+	rt := &Router{
+		opts:     o,
+		basePath: strings.TrimSuffix(doc.BasePath, "/"),
+		root:     new(node),
+	}
+	templates := make([]string, 0, len(doc.Paths))
+	for t := range doc.Paths {
+		templates = append(templates, t)
+	}
+	// Sort so that, of equivalent templates, the same one always wins.
+	sort.Strings(templates)
+	for _, t := range templates {
+		item := doc.Paths[t]
+		n := rt.root
+		for _, seg := range segments(t) {
+			n = n.child(seg)
+		}
+		if hasSlash(t) {
+			if n.slashItem == nil {
+				n.slashTemplate, n.slashItem = t, &item
+			}
+		} else if n.item == nil {
+			n.template, n.item = t, &item
+		}
+	}
+	return rt
+}
+
+// child returns the child for a template segment, adding it if needed.
+func (n *node) child(seg string) *node {
+	start, end := strings.Index(seg, "{"), strings.LastIndex(seg, "}")
+	switch {
+	case start < 0 || end < start:
+		if n.static == nil {
+			n.static = make(map[string]*node)
+		}
+		if n.static[seg] == nil {
+			n.static[seg] = new(node)
+		}
+		return n.static[seg]
+	case isVariable(seg):
+		if n.variable == nil {
+			n.variable = new(node)
+		}
+		return n.variable
+	}
+	for _, p := range n.patterns {
+		if p.segment == seg {
+			return p.next
+		}
+	}
+	p := compile(seg)
+	n.patterns = append(n.patterns, p)
+	// Longer literals are more specific, so "{id}.json" is tried before
+	// "{id}.{ext}".
+	sort.SliceStable(n.patterns, func(i, j int) bool {
+		return literalLen(n.patterns[i].segment) > literalLen(n.patterns[j].segment)
+	})
+	return p.next
+}
+
+var variablePattern = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// isVariable reports whether a template segment is a single variable, such as
+// "{id}".
+func isVariable(seg string) bool {
+	return strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") && strings.Count(seg, "{") == 1
+}
+
+func compile(seg string) *pattern {
+	p := &pattern{segment: seg, next: new(node)}
+	var b strings.Builder
+	b.WriteString("^")
+	last := 0
+	for _, m := range variablePattern.FindAllStringSubmatchIndex(seg, -1) {
+		b.WriteString(regexp.QuoteMeta(seg[last:m[0]]))
+		b.WriteString("(.+?)")
+		p.names = append(p.names, seg[m[2]:m[3]])
+		last = m[1]
+	}
+	b.WriteString(regexp.QuoteMeta(seg[last:]))
+	b.WriteString("$")
+	p.re = regexp.MustCompile(b.String())
+	p.fold = regexp.MustCompile("(?i)" + b.String())
+	return p
+}
+
+func literalLen(seg string) int {
+	return len(variablePattern.ReplaceAllString(seg, ""))
+}
+
+// Route returns the operation which handles a request, using its method and
+// the escaped form of its URL's path. A redirect's Location keeps the
+// request's query.
+func (rt *Router) Route(r *http.Request) (*Match, error) {
+	m, err := rt.Match(r.Method, r.URL.EscapedPath())
+	if e, ok := err.(*Error); ok && e.Location != "" && r.URL.RawQuery != "" {
+		e.Location += "?" + r.URL.RawQuery
+	}
+	return m, err
+}
+
+// Match returns the operation which handles a method and URL path. The path may
+// be escaped, and each variable's value is unescaped, so "/files/a%2Fb" gives
+// the variable of "/files/{name}" the value "a/b". If no operation handles the
+// request the error is an *Error.
+func (rt *Router) Match(method, path string) (*Match, error) {
+	full := path
+	if rt.basePath != "" {
+		if !strings.HasPrefix(path, rt.basePath+"/") {
+			return nil, &Error{Status: http.StatusNotFound, Message: fmt.Sprintf("no path matches %s", unescape(path))}
+		}
+		path = strings.TrimPrefix(path, rt.basePath)
+	}
+	vars := make(map[string]string)
+	segs := segments(path)
+	canonical := make([]string, len(segs))
+	n, folded := rt.root.match(segs, canonical, vars, rt.opts.Case == IgnoreCase || rt.opts.Case == RedirectCase)
+	if n == nil {
+		return nil, &Error{Status: http.StatusNotFound, Message: fmt.Sprintf("no path matches %s", unescape(path))}
+	}
+
+	slash := hasSlash(path)
+	template, item := n.get(slash)
+	moved := false
+	if item == nil {
+		switch rt.opts.TrailingSlash {
+		case StrictSlash:
+			return nil, &Error{Status: http.StatusNotFound, Message: fmt.Sprintf("no path matches %s", unescape(path))}
+		case RedirectSlash:
+			moved = true
+		}
+		slash = !slash
+		template, item = n.get(slash)
+	}
+	if moved || (folded && rt.opts.Case == RedirectCase) {
+		if rt.opts.Case != RedirectCase {
+			canonical = segs
+		}
+		location := rt.basePath + "/" + strings.Join(canonical, "/")
+		if slash && len(canonical) > 0 {
+			location += "/"
+		}
+		return nil, &Error{
+			Status:   http.StatusPermanentRedirect,
+			Message:  fmt.Sprintf("%s has moved to %s", unescape(full), unescape(location)),
+			Location: location,
+		}
+	}
+
+	// Segments which are a single variable are named by the template, since
+	// templates naming them differently share their nodes.
+	for i, seg := range segments(template) {
+		if isVariable(seg) {
+			vars[seg[1:len(seg)-1]] = unescape(segs[i])
+		}
+	}
+
+	op := Operation(item, method)
+	if op == nil {
+		return nil, &Error{Status: http.StatusMethodNotAllowed, Message: fmt.Sprintf("%s does not support %s", template, method)}
+	}
+	return &Match{
+		Template:  template,
+		Method:    strings.ToUpper(method),
+		Item:      item,
+		Operation: op,
+		Vars:      vars,
+	}, nil
+}
+
+// match returns the node of a template matching the escaped segments of a
+// path, setting vars to the values of the variables in segments mixing
+// literals and variables, and canonical to the segments as the template
+// declares them. If fold is set, literal segments which only match in another
+// case are matched, and folded reports whether any were.
+func (n *node) match(segs, canonical []string, vars map[string]string, fold bool) (found *node, folded bool) {
+	if len(segs) == 0 {
+		if n.item == nil && n.slashItem == nil {
+			return nil, false
+		}
+		return n, false
+	}
+	seg, rest := segs[0], segs[1:]
+	value := unescape(seg)
+	if next := n.static[value]; next != nil {
+		if found, folded := next.match(rest, canonical[1:], vars, fold); found != nil {
+			canonical[0] = seg
+			return found, folded
+		}
+	}
+	if fold {
+		for _, lit := range mapkeys.Sorted(n.static) {
+			if lit == value || !strings.EqualFold(lit, value) {
+				continue
+			}
+			if found, _ := n.static[lit].match(rest, canonical[1:], vars, fold); found != nil {
+				canonical[0] = url.PathEscape(lit)
+				return found, true
+			}
+		}
+	}
+	if value == "" {
+		return nil, false
+	}
+	for _, p := range n.patterns {
+		m := p.re.FindStringSubmatch(value)
+		if m == nil && fold {
+			m = p.fold.FindStringSubmatch(value)
+		}
+		if m == nil {
+			continue
+		}
+		if found, folded := p.next.match(rest, canonical[1:], vars, fold); found != nil {
+			for i, name := range p.names {
+				vars[name] = m[i+1]
+			}
+			canonical[0] = seg
+			if !p.re.MatchString(value) {
+				canonical[0] = url.PathEscape(p.expand(m[1:]))
+				folded = true
+			}
+			return found, folded
+		}
+	}
+	if n.variable != nil {
+		if found, folded := n.variable.match(rest, canonical[1:], vars, fold); found != nil {
+			canonical[0] = seg
+			return found, folded
+		}
+	}
+	return nil, false
+}
+
+// get returns the template and item ending in the node with or without a
+// trailing slash.
+func (n *node) get(slash bool) (string, *spec.PathItem) {
+	if slash {
+		return n.slashTemplate, n.slashItem
+	}
+	return n.template, n.item
+}
+
+// hasSlash reports whether a path other than "/" ends with a slash.
+func hasSlash(path string) bool {
+	return len(path) > 1 && strings.HasSuffix(path, "/")
+}
+
+// segments splits a path or template into its segments, ignoring leading and
+// trailing slashes.
+func segments(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+func unescape(s string) string {
+	if u, err := url.PathUnescape(s); err == nil {
+		return u
+	}
+	return s
+}
+
+// Operation returns the operation of a path item for an HTTP method, or nil if
+// there isn't one.
+func Operation(item *spec.PathItem, method string) *spec.Operation {
+	return item.Operation(strings.ToLower(method))
+}
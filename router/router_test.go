@@ -0,0 +1,172 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+const doc = `
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+basePath: /v1
+paths:
+  /:
+    get: {operationId: root, responses: {200: {description: OK}}}
+  /pets:
+    get: {operationId: listPets, responses: {200: {description: OK}}}
+  /pets/{petId}:
+    get: {operationId: getPet, responses: {200: {description: OK}}}
+    delete: {operationId: deletePet, responses: {204: {description: Deleted}}}
+  /pets/mine:
+    get: {operationId: myPets, responses: {200: {description: OK}}}
+  /pets/{petId}.json:
+    get: {operationId: getPetJSON, responses: {200: {description: OK}}}
+  /pets/{petId}/photos/{photoId}:
+    get: {operationId: getPhoto, responses: {200: {description: OK}}}
+  /pets/mine/toys:
+    get: {operationId: myToys, responses: {200: {description: OK}}}
+  /files/{name}:
+    get: {operationId: getFile, responses: {200: {description: OK}}}
+  /owners/{id}:
+    get: {operationId: getOwner, responses: {200: {description: OK}}}
+  /owners/{ownerId}/pets:
+    get: {operationId: listOwnerPets, responses: {200: {description: OK}}}
+`
+
+func TestMatch(t *testing.T) {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(doc), &s); err != nil {
+		t.Fatal(err)
+	}
+	rt := New(&s)
+
+	tests := []struct {
+		method, path string
+		wantID       string
+		wantVars     map[string]string
+		wantStatus   int
+	}{
+		{"GET", "/v1/", "root", map[string]string{}, 0},
+		{"GET", "/v1/pets", "listPets", map[string]string{}, 0},
+		{"GET", "/v1/pets/", "listPets", map[string]string{}, 0},
+		{"GET", "/v1/pets/7", "getPet", map[string]string{"petId": "7"}, 0},
+		{"delete", "/v1/pets/7", "deletePet", map[string]string{"petId": "7"}, 0},
+		{"GET", "/v1/pets/mine", "myPets", map[string]string{}, 0},
+		{"GET", "/v1/pets/7.json", "getPetJSON", map[string]string{"petId": "7"}, 0},
+		// "mine" matches the literal segment first, then backtracks to the
+		// variable since /pets/mine has no photos.
+		{"GET", "/v1/pets/mine/photos/3", "getPhoto", map[string]string{"petId": "mine", "photoId": "3"}, 0},
+		{"GET", "/v1/pets/mine/toys", "myToys", map[string]string{}, 0},
+		{"GET", "/v1/files/a%2Fb%20c", "getFile", map[string]string{"name": "a/b c"}, 0},
+		// Sibling templates naming a variable differently share its node,
+		// but each match uses its own template's name.
+		{"GET", "/v1/owners/7", "getOwner", map[string]string{"id": "7"}, 0},
+		{"GET", "/v1/owners/7/pets", "listOwnerPets", map[string]string{"ownerId": "7"}, 0},
+		{"GET", "/pets", "", nil, http.StatusNotFound},
+		{"GET", "/v1/owners", "", nil, http.StatusNotFound},
+		{"GET", "/v1/pets//photos/3", "", nil, http.StatusNotFound},
+		{"POST", "/v1/pets/7", "", nil, http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		m, err := rt.Match(tt.method, tt.path)
+		if tt.wantStatus != 0 {
+			rerr, ok := err.(*Error)
+			if !ok || rerr.Status != tt.wantStatus {
+				t.Errorf("%s %s: want status %d, got %v", tt.method, tt.path, tt.wantStatus, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s %s: %v", tt.method, tt.path, err)
+			continue
+		}
+		if m.Operation.OperationId != tt.wantID {
+			t.Errorf("%s %s: want %s, got %s", tt.method, tt.path, tt.wantID, m)
+		}
+		if diff := pretty.Compare(m.Vars, tt.wantVars); diff != "" {
+			t.Errorf("%s %s: vars: want != got: %s", tt.method, tt.path, diff)
+		}
+	}
+
+	m, err := rt.Route(httptest.NewRequest("GET", "/v1/files/a%2Fb", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.String(); got != "GET /files/{name}" || m.Vars["name"] != "a/b" {
+		t.Errorf("unexpected match %s with vars %v", got, m.Vars)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	var s spec.Swagger
+	err := yaml.Unmarshal([]byte(`
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+basePath: /v1
+paths:
+  /pets:
+    get: {operationId: listPets, responses: {200: {description: OK}}}
+  /owners/:
+    get: {operationId: listOwners, responses: {200: {description: OK}}}
+  /owners/{ownerId}/Pets:
+    get: {operationId: ownerPets, responses: {200: {description: OK}}}
+  /files/{name}.JSON:
+    get: {operationId: getFile, responses: {200: {description: OK}}}
+`), &s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		opts         Options
+		path         string
+		wantID       string
+		wantStatus   int
+		wantLocation string
+	}{
+		{Options{}, "/v1/pets/", "listPets", 0, ""},
+		{Options{}, "/v1/owners", "listOwners", 0, ""},
+		{Options{}, "/v1/PETS", "", http.StatusNotFound, ""},
+		{Options{TrailingSlash: StrictSlash}, "/v1/pets", "listPets", 0, ""},
+		{Options{TrailingSlash: StrictSlash}, "/v1/pets/", "", http.StatusNotFound, ""},
+		{Options{TrailingSlash: RedirectSlash}, "/v1/pets/", "", http.StatusPermanentRedirect, "/v1/pets"},
+		{Options{TrailingSlash: RedirectSlash}, "/v1/owners", "", http.StatusPermanentRedirect, "/v1/owners/"},
+		{Options{Case: IgnoreCase}, "/v1/PETS", "listPets", 0, ""},
+		{Options{Case: IgnoreCase}, "/v1/files/a.json", "getFile", 0, ""},
+		{Options{Case: RedirectCase}, "/v1/Owners/Rex/pets", "", http.StatusPermanentRedirect, "/v1/owners/Rex/Pets"},
+		{Options{Case: RedirectCase}, "/v1/files/A.json", "", http.StatusPermanentRedirect, "/v1/files/A.JSON"},
+		{Options{Case: RedirectCase, TrailingSlash: RedirectSlash}, "/v1/Pets/", "", http.StatusPermanentRedirect, "/v1/pets"},
+		{Options{Case: IgnoreCase, TrailingSlash: RedirectSlash}, "/v1/Pets/", "", http.StatusPermanentRedirect, "/v1/Pets"},
+	}
+	for _, tt := range tests {
+		m, err := tt.opts.New(&s).Match("GET", tt.path)
+		if tt.wantStatus != 0 {
+			rerr, ok := err.(*Error)
+			if !ok || rerr.Status != tt.wantStatus || rerr.Location != tt.wantLocation {
+				t.Errorf("%+v %s: want status %d to %q, got %#v", tt.opts, tt.path, tt.wantStatus, tt.wantLocation, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%+v %s: %v", tt.opts, tt.path, err)
+			continue
+		}
+		if m.Operation.OperationId != tt.wantID {
+			t.Errorf("%+v %s: want %s, got %s", tt.opts, tt.path, tt.wantID, m)
+		}
+	}
+
+	// Redirects keep the query.
+	rt := Options{TrailingSlash: RedirectSlash}.New(&s)
+	_, err = rt.Route(httptest.NewRequest("GET", "/v1/pets/?limit=5", nil))
+	if e, ok := err.(*Error); !ok || e.Location != "/v1/pets?limit=5" {
+		t.Errorf("unexpected error %#v", err)
+	}
+}
@@ -0,0 +1,345 @@
+/*
+Package runtime declares the components the proxy, mock server and middleware
+are built from, so they can be replaced or faked in tests.
+
+Each interface is small and has a default implementation, which is used when
+an Options struct leaves the component unset:
+
+	Router     DefaultRouter       matches requests to operations
+	Validator  DefaultValidator    checks requests and responses
+	Resolver   NewResolver         replaces references in documents
+	Loader     resolver.Loader     fetches referenced documents
+	Cache      cache.NewMemory     stores responses
+	Clock      SystemClock         tells the time
+
+Components which make random choices, such as the proxy's canary routing and
+package examplegen, accept a rand.Source in their options, so tests can fix
+the choices they make along with the time.
+
+For example, a test can route every request to a single operation without
+depending on how paths are matched:
+
+	type fixedRouter struct{ m *runtime.Match }
+
+	func (f fixedRouter) Route(doc *spec.Swagger, r *http.Request) (*runtime.Match, error) {
+		return f.m, nil
+	}
+
+	h := middleware.Options{Router: fixedRouter{m}}.ValidateRequests(doc)(handler)
+
+Routers and validators are passed the document with each call, rather than
+being bound to one, so they keep working when a proxy reloads its document.
+*/
+package runtime
+
+import (
+	"container/list"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ericchiang/swaggopher/coerce"
+	"github.com/ericchiang/swaggopher/internal/httpcheck"
+	"github.com/ericchiang/swaggopher/resolver"
+	"github.com/ericchiang/swaggopher/router"
+	"github.com/ericchiang/swaggopher/spec"
+	"github.com/ericchiang/swaggopher/variant"
+)
+
+// Match is the operation a request was routed to.
+type Match = httpcheck.Match
+
+// RouteError is returned by routers when no operation handles a request. Its
+// Status is http.StatusNotFound, http.StatusMethodNotAllowed, or
+// http.StatusPermanentRedirect with a Location to redirect to.
+type RouteError = httpcheck.Error
+
+// Problem is a way a request doesn't satisfy its operation's parameters.
+type Problem = httpcheck.Problem
+
+// Router matches requests to the operations of a document.
+type Router interface {
+	// Route returns the operation which handles a request. If there's none
+	// the error should be a *RouteError.
+	Route(doc *spec.Swagger, r *http.Request) (*Match, error)
+}
+
+// Validator checks requests and responses against the operations they're
+// routed to.
+type Validator interface {
+	// ValidateRequest returns every way a request doesn't satisfy the
+	// parameters of its operation. If it reads the request's body it must
+	// replace it so the body can still be read.
+	ValidateRequest(doc *spec.Swagger, m *Match, r *http.Request) []Problem
+	// ValidateResponse returns every way a response doesn't match those
+	// documented by an operation.
+	ValidateResponse(doc *spec.Swagger, op *spec.Operation, code int, header http.Header, body []byte) []string
+}
+
+// Resolver replaces the references of a document with their targets.
+type Resolver interface {
+	Resolve(doc *spec.Swagger) error
+}
+
+// Loader fetches the document at a location, which is either a file path or an
+// absolute URL. resolver.Loader implements it.
+type Loader interface {
+	Load(location string) ([]byte, error)
+}
+
+// CacheEntry is a cached response.
+type CacheEntry struct {
+	Status  int
+	Header  http.Header
+	Body    []byte
+	Expires time.Time
+}
+
+// Cache stores cached responses. Implementations must be safe for concurrent
+// use.
+type Cache interface {
+	// Get returns the entry stored for a key. Entries which have expired may
+	// be returned, and are ignored.
+	Get(key string) (*CacheEntry, bool)
+	// Set stores an entry for a key, replacing any existing entry.
+	Set(key string, e *CacheEntry)
+	// DeletePrefix removes the entries whose keys begin with prefix.
+	DeletePrefix(prefix string)
+}
+
+// Clock tells the time.
+type Clock interface {
+	Now() time.Time
+}
+
+// DefaultRouter matches a request's path against the document's path
+// templates, after removing the basePath. Literal segments are preferred to
+// variables, and operations with variants are resolved to the variant the
+// request selects. See packages router and variant.
+//
+// A document's paths are compiled the first time one of its requests is
+// routed, and its variants decoded the first time they're selected from, so
+// a variant is the same *spec.Operation for each request selecting it. Those
+// of the most recently routed documents are kept. They're compiled again if
+// paths are added to or removed from the document, or its basePath changes;
+// other changes to the paths of a document which has been routed aren't seen.
+var DefaultRouter Router = NewRouter(router.Options{})
+
+// NewRouter returns a router like DefaultRouter which matches trailing slashes
+// and case as opts says. Redirects are returned as a *RouteError with a
+// Location.
+func NewRouter(opts router.Options) Router {
+	return &documentRouter{opts: opts, order: list.New(), entries: make(map[*spec.Swagger]*list.Element)}
+}
+
+// routedDocuments is the number of documents whose compiled paths a router
+// keeps.
+const routedDocuments = 16
+
+type documentRouter struct {
+	opts router.Options
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[*spec.Swagger]*list.Element
+}
+
+type routerEntry struct {
+	doc *spec.Swagger
+	// paths and basePath are those of the document when it was compiled.
+	paths    int
+	basePath string
+	router   *router.Router
+	variants *variant.Selector
+}
+
+func (d *documentRouter) Route(doc *spec.Swagger, r *http.Request) (*Match, error) {
+	e := d.entry(doc)
+	return httpcheck.Route(e.router, e.variants, r)
+}
+
+// entry returns the compiled paths of a document, compiling them if they
+// aren't cached or are out of date.
+func (d *documentRouter) entry(doc *spec.Swagger) *routerEntry {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if el, ok := d.entries[doc]; ok {
+		e := el.Value.(*routerEntry)
+		if e.paths == len(doc.Paths) && e.basePath == doc.BasePath {
+			d.order.MoveToFront(el)
+			return e
+		}
+		d.order.Remove(el)
+		delete(d.entries, doc)
+	}
+	e := &routerEntry{
+		doc:      doc,
+		paths:    len(doc.Paths),
+		basePath: doc.BasePath,
+		router:   d.opts.New(doc),
+		variants: new(variant.Selector),
+	}
+	d.entries[doc] = d.order.PushFront(e)
+	if d.order.Len() > routedDocuments {
+		oldest := d.order.Back()
+		d.order.Remove(oldest)
+		delete(d.entries, oldest.Value.(*routerEntry).doc)
+	}
+	return e
+}
+
+// DefaultValidator checks path, query, header, form and JSON body parameters
+// against their declared types, and responses against their documented
+// statuses and JSON schemas.
+var DefaultValidator Validator = documentValidator{}
+
+// ValidatorOptions configures the validators NewValidator and
+// NewCompilingValidator return. The zero value only accepts the canonical
+// encodings of parameter values, as DefaultValidator does.
+type ValidatorOptions struct {
+	// Coerce controls how strictly the values of non-body parameters are
+	// parsed.
+	Coerce coerce.Options
+	// MaxBodyBytes is the size of the largest request body validated. Larger
+	// bodies are reported as a problem. If zero, a default of 10 MiB is used.
+	MaxBodyBytes int64
+}
+
+func (o ValidatorOptions) checkOptions() httpcheck.Options {
+	return httpcheck.Options{Coerce: o.Coerce, MaxBodyBytes: o.MaxBodyBytes}
+}
+
+// NewValidator returns a Validator like DefaultValidator which parses
+// parameter values as the options say.
+func (o ValidatorOptions) NewValidator() Validator {
+	return documentValidator{opts: o.checkOptions()}
+}
+
+type documentValidator struct {
+	opts httpcheck.Options
+}
+
+func (v documentValidator) ValidateRequest(doc *spec.Swagger, m *Match, r *http.Request) []Problem {
+	return v.opts.Check(doc, m, r)
+}
+
+func (documentValidator) ValidateResponse(doc *spec.Swagger, op *spec.Operation, code int, header http.Header, body []byte) []string {
+	return httpcheck.Response(doc, op, code, header, body)
+}
+
+// NewCompilingValidator returns a Validator like DefaultValidator which compiles
+// the schemas of an operation's parameters the first time one of its requests
+// is checked, and those of its responses the first time one of its responses
+// is, rather than resolving them each time. Those of the max most recently
+// used operations are kept. Services with many operations only pay for
+// compiling those which are used. If max is zero or less, every operation's
+// are kept.
+//
+// Operations are identified by their document and address, so the schemas of a
+// reloaded document are compiled anew, and those of the old one evicted as
+// they fall out of use. Routers returned by NewRouter give each variant a
+// single address; routers decoding variants for each request should be used
+// with a bounded max, since each request's variant takes a place.
+func NewCompilingValidator(max int) Validator {
+	return ValidatorOptions{}.NewCompilingValidator(max)
+}
+
+// NewCompilingValidator returns a Validator like the package's
+// NewCompilingValidator which parses parameter values as the options say.
+func (o ValidatorOptions) NewCompilingValidator(max int) Validator {
+	return &compilingValidator{
+		max:     max,
+		opts:    o.checkOptions(),
+		order:   list.New(),
+		entries: make(map[compiledKey]*list.Element),
+	}
+}
+
+type compilingValidator struct {
+	max  int
+	opts httpcheck.Options
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[compiledKey]*list.Element
+}
+
+type compiledKey struct {
+	doc *spec.Swagger
+	op  *spec.Operation
+}
+
+// compiledEntry holds the compiled schemas of an operation, each compiled on
+// first use.
+type compiledEntry struct {
+	key compiledKey
+
+	parametersOnce sync.Once
+	parameters     *httpcheck.Parameters
+	responsesOnce  sync.Once
+	responses      *httpcheck.Responses
+}
+
+func (c *compilingValidator) ValidateRequest(doc *spec.Swagger, m *Match, r *http.Request) []Problem {
+	e := c.entry(doc, m.Operation)
+	e.parametersOnce.Do(func() {
+		e.parameters = c.opts.CompileParameters(doc, m.Item, m.Operation)
+	})
+	return e.parameters.Check(m, r)
+}
+
+func (c *compilingValidator) ValidateResponse(doc *spec.Swagger, op *spec.Operation, code int, header http.Header, body []byte) []string {
+	e := c.entry(doc, op)
+	e.responsesOnce.Do(func() {
+		e.responses = httpcheck.CompileResponses(doc, op)
+	})
+	return e.responses.Check(code, header, body)
+}
+
+// entry returns the entry of an operation, adding it if it isn't cached.
+// Schemas are compiled outside the cache's lock, so operations compiling
+// don't hold up others.
+func (c *compilingValidator) entry(doc *spec.Swagger, op *spec.Operation) *compiledEntry {
+	key := compiledKey{doc, op}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.order.MoveToFront(el)
+		return el.Value.(*compiledEntry)
+	}
+	e := &compiledEntry{key: key}
+	c.entries[key] = c.order.PushFront(e)
+	if c.max > 0 && c.order.Len() > c.max {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*compiledEntry).key)
+	}
+	return e
+}
+
+// NewResolver returns a Resolver which resolves references relative to base,
+// fetching other documents with l. If l is nil, files are read from disk and
+// URLs fetched with http.DefaultClient. See resolver.Resolve.
+func NewResolver(base string, l Loader) Resolver {
+	var opts []resolver.Option
+	if base != "" {
+		opts = append(opts, resolver.WithBase(base))
+	}
+	if l != nil {
+		opts = append(opts, resolver.WithLoader(l.Load))
+	}
+	return resolverFunc(func(doc *spec.Swagger) error {
+		return resolver.Resolve(doc, opts...)
+	})
+}
+
+type resolverFunc func(doc *spec.Swagger) error
+
+func (f resolverFunc) Resolve(doc *spec.Swagger) error { return f(doc) }
+
+// SystemClock is the Clock of the host.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
@@ -0,0 +1,288 @@
+package runtime
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/ericchiang/swaggopher/coerce"
+	"github.com/ericchiang/swaggopher/router"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+const petstore = `
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+basePath: /v1
+paths:
+  /pets/{petId}:
+    get:
+      parameters:
+      - {name: petId, in: path, required: true, type: integer}
+      responses:
+        200: {description: A pet., schema: {$ref: 'pet.yaml#/Pet'}}
+`
+
+type loaderFunc func(location string) ([]byte, error)
+
+func (f loaderFunc) Load(location string) ([]byte, error) { return f(location) }
+
+func TestDefaults(t *testing.T) {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(petstore), &s); err != nil {
+		t.Fatal(err)
+	}
+
+	var loaded []string
+	l := loaderFunc(func(location string) ([]byte, error) {
+		loaded = append(loaded, location)
+		if location != "testdata/pet.yaml" {
+			return nil, fmt.Errorf("not found: %s", location)
+		}
+		return []byte("Pet: {type: object, required: [name], properties: {name: {type: string}}}"), nil
+	})
+	if err := NewResolver("testdata/swagger.yaml", l).Resolve(&s); err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != 1 {
+		t.Errorf("expected pet.yaml to be loaded once, got %v", loaded)
+	}
+
+	r := httptest.NewRequest("GET", "/v1/pets/one", nil)
+	m, err := DefaultRouter.Route(&s, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.String(); got != "GET /pets/{petId}" {
+		t.Errorf("want route GET /pets/{petId}, got %s", got)
+	}
+	if problems := DefaultValidator.ValidateRequest(&s, m, r); len(problems) != 1 || problems[0].Name != "petId" {
+		t.Errorf("expected petId to be invalid, got %v", problems)
+	}
+	header := http.Header{"Content-Type": {"application/json"}}
+	if problems := DefaultValidator.ValidateResponse(&s, m.Operation, 200, header, []byte(`{}`)); len(problems) != 1 {
+		t.Errorf("expected the resolved schema to require a name, got %v", problems)
+	}
+
+	_, err = DefaultRouter.Route(&s, httptest.NewRequest("GET", "/v1/toys", nil))
+	if e, ok := err.(*RouteError); !ok || e.Status != http.StatusNotFound {
+		t.Errorf("expected a 404 route error, got %#v", err)
+	}
+}
+
+func TestRouterCache(t *testing.T) {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(tree), &s); err != nil {
+		t.Fatal(err)
+	}
+	d := NewRouter(router.Options{}).(*documentRouter)
+	if _, err := d.Route(&s, httptest.NewRequest("GET", "/trees", nil)); err != nil {
+		t.Fatal(err)
+	}
+	compiled := d.entry(&s).router
+	if _, err := d.Route(&s, httptest.NewRequest("GET", "/leaves", nil)); err != nil {
+		t.Fatal(err)
+	}
+	if d.entry(&s).router != compiled {
+		t.Errorf("expected the document's paths to be compiled once")
+	}
+
+	// Adding a path compiles the document again.
+	s.Paths["/roots"] = spec.PathItem{Get: s.Paths["/trees"].Get}
+	if _, err := d.Route(&s, httptest.NewRequest("GET", "/roots", nil)); err != nil {
+		t.Errorf("expected the added path to be routed, got %v", err)
+	}
+
+	for i := 0; i < routedDocuments; i++ {
+		d.entry(&spec.Swagger{})
+	}
+	if n := len(d.entries); n != routedDocuments {
+		t.Errorf("expected %d documents to be kept, got %d", routedDocuments, n)
+	}
+	if _, ok := d.entries[&s]; ok {
+		t.Errorf("expected the least recently routed document to be evicted")
+	}
+}
+
+const tree = `
+swagger: "2.0"
+info: {title: Trees, version: "1.0"}
+paths:
+  /trees:
+    get:
+      responses:
+        200: {$ref: '#/responses/Tree'}
+        default: {description: An error., schema: {type: object, required: [message]}}
+    post:
+      parameters:
+      - {name: tree, in: body, required: true, schema: {$ref: '#/definitions/Node'}}
+      - {name: height, in: query, type: integer, maximum: 100}
+      responses:
+        201: {description: Planted.}
+  /leaves:
+    get:
+      responses:
+        200: {description: A leaf., schema: {type: string, enum: [green, brown]}}
+      x-variants:
+      - x-when: {header: X-API-Version, value: "2"}
+        responses:
+          200: {description: Leaves., schema: {type: array, items: {type: string}}}
+responses:
+  Tree: {description: A tree., schema: {$ref: '#/definitions/Node'}}
+definitions:
+  Node:
+    type: object
+    required: [name]
+    properties:
+      name: {type: string, pattern: '^[a-z]+$'}
+      children: {type: array, items: {$ref: '#/definitions/Node'}}
+    additionalProperties: {type: integer}
+`
+
+func TestCompilingValidator(t *testing.T) {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(tree), &s); err != nil {
+		t.Fatal(err)
+	}
+	trees, leaves := s.Paths["/trees"].Get, s.Paths["/leaves"].Get
+	header := http.Header{"Content-Type": {"application/json"}}
+	tests := []struct {
+		op   *spec.Operation
+		code int
+		body string
+	}{
+		{trees, 200, `{"name": "oak", "children": [{"name": "elm", "children": [{"name": "Ash"}]}]}`},
+		{trees, 200, `{"name": "oak", "children": [{}], "age": 1.5}`},
+		{trees, 500, `{}`},
+		{trees, 500, `{"message": "oops"}`},
+		{leaves, 200, `"red"`},
+		{leaves, 404, `{}`},
+	}
+	v := NewCompilingValidator(1)
+	for i, test := range tests {
+		want := DefaultValidator.ValidateResponse(&s, test.op, test.code, header, []byte(test.body))
+		got := v.ValidateResponse(&s, test.op, test.code, header, []byte(test.body))
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("case %d: compiled schemas found %q, want %q", i, got, want)
+		}
+	}
+	if n := len(v.(*compilingValidator).entries); n != 1 {
+		t.Errorf("expected one operation's schemas to be kept, got %d", n)
+	}
+
+	requests := []struct {
+		path, body string
+	}{
+		{"/trees", `{"name": "oak", "children": [{"name": "elm"}]}`},
+		{"/trees?height=200", `{"name": "oak", "children": [{"name": "Elm"}]}`},
+		{"/trees?height=tall", ``},
+	}
+	for _, test := range requests {
+		newRequest := func() *http.Request {
+			return httptest.NewRequest("POST", test.path, strings.NewReader(test.body))
+		}
+		m, err := DefaultRouter.Route(&s, newRequest())
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := DefaultValidator.ValidateRequest(&s, m, newRequest())
+		got := v.ValidateRequest(&s, m, newRequest())
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("POST %s: compiled schemas found %v, want %v", test.path, got, want)
+		}
+	}
+
+	// Each request for a variant is routed to the same operation, so its
+	// schemas are compiled once.
+	v = NewCompilingValidator(-1)
+	for i := 0; i < 3; i++ {
+		r := httptest.NewRequest("GET", "/leaves", nil)
+		r.Header.Set("X-API-Version", "2")
+		m, err := DefaultRouter.Route(&s, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := v.ValidateResponse(&s, m.Operation, 200, header, []byte(`["green"]`)); len(got) != 0 {
+			t.Errorf("expected the variant's response to be valid, got %q", got)
+		}
+	}
+	if n := len(v.(*compilingValidator).entries); n != 1 {
+		t.Errorf("expected one variant's schemas to be kept, got %d", n)
+	}
+}
+
+func TestValidatorOptions(t *testing.T) {
+	var s spec.Swagger
+	doc := `
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+paths:
+  /pets:
+    get:
+      parameters:
+      - {name: sold, in: query, type: boolean}
+      responses:
+        200: {description: Pets.}
+`
+	if err := yaml.Unmarshal([]byte(doc), &s); err != nil {
+		t.Fatal(err)
+	}
+	lenient := ValidatorOptions{Coerce: coerce.Options{LenientBoolean: true}}
+	tests := []struct {
+		name string
+		v    Validator
+		want int
+	}{
+		{"default", DefaultValidator, 1},
+		{"strict compiling", NewCompilingValidator(-1), 1},
+		{"lenient", lenient.NewValidator(), 0},
+		{"lenient compiling", lenient.NewCompilingValidator(-1), 0},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest("GET", "/pets?sold=1", nil)
+		m, err := DefaultRouter.Route(&s, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := test.v.ValidateRequest(&s, m, r); len(got) != test.want {
+			t.Errorf("%s: want %d problems, got %v", test.name, test.want, got)
+		}
+	}
+}
+
+func TestValidatorOptionsMaxBodyBytes(t *testing.T) {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(tree), &s); err != nil {
+		t.Fatal(err)
+	}
+	body := `{"name": "oak", "children": [{"name": "elm"}]}`
+	tests := []struct {
+		max  int64
+		want []Problem
+	}{
+		{0, nil},
+		{int64(len(body)), nil},
+		{10, []Problem{{In: "body", Message: "request body is larger than 10 bytes"}}},
+	}
+	for _, test := range tests {
+		v := ValidatorOptions{MaxBodyBytes: test.max}.NewValidator()
+		r := httptest.NewRequest("POST", "/trees", strings.NewReader(body))
+		m, err := DefaultRouter.Route(&s, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := v.ValidateRequest(&s, m, r); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("max %d: want %v, got %v", test.max, test.want, got)
+		}
+		// The body is left whole for the handler either way.
+		if data, err := ioutil.ReadAll(r.Body); err != nil || string(data) != body {
+			t.Errorf("max %d: want the body %q, got %q, %v", test.max, body, data, err)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+/*
+Package skew simulates version skew between the clients and servers of an API.
+
+While a change rolls out, clients built from one version of a document talk to
+servers implementing the other. Check tries both combinations: requests built
+from the old document are sent to a mock server implementing the new one, and
+requests built from the new document to a mock of the old one. A client keeps
+working if every request it makes is accepted, and every response matches the
+version of the document it was built from.
+*/
+package skew
+
+import (
+	"context"
+	"net/http/httptest"
+
+	"github.com/ericchiang/swaggopher/contract"
+	"github.com/ericchiang/swaggopher/mock"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// Options configures Check. The zero value is valid.
+type Options struct {
+	// Value supplies the values of parameters, as for contract.Options. It's
+	// only needed for parameters whose values can't be generated from their
+	// types, such as strings restricted by a pattern.
+	Value func(op contract.Operation, p *spec.Parameter) (string, bool)
+	// Logger, if set, receives a line for each request sent.
+	Logger spec.Logger
+}
+
+// Result holds the outcome of both combinations of client and server.
+type Result struct {
+	// OldClient holds the results of a client built from the old document
+	// calling a server which implements the new one. Failures mean servers
+	// can't be upgraded before all clients are.
+	OldClient *contract.Report
+	// NewClient holds the results of a client built from the new document
+	// calling a server which implements the old one. Failures mean clients
+	// can't be upgraded before all servers are, which is expected if the new
+	// document adds operations.
+	NewClient *contract.Report
+}
+
+// OK reports if clients of either version work with servers of the other.
+func (r *Result) OK() bool {
+	return r.OldClient.OK() && r.NewClient.OK()
+}
+
+// Check simulates clients of each version of a document calling servers of the
+// other.
+func Check(ctx context.Context, old, new *spec.Swagger, opts Options) (*Result, error) {
+	oldClient, err := run(ctx, old, new, opts)
+	if err != nil {
+		return nil, err
+	}
+	newClient, err := run(ctx, new, old, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{OldClient: oldClient, NewClient: newClient}, nil
+}
+
+// run sends the positive cases generated from the client's document to a mock
+// of the server's.
+func run(ctx context.Context, client, server *spec.Swagger, opts Options) (*contract.Report, error) {
+	srv := httptest.NewServer(mock.NewServer(server))
+	defer srv.Close()
+	return contract.Run(ctx, srv.URL, client, contract.Options{
+		Client:       srv.Client(),
+		Value:        opts.Value,
+		SkipNegative: true,
+		Logger:       opts.Logger,
+	})
+}
@@ -0,0 +1,132 @@
+package skew
+
+import (
+	"context"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/ericchiang/swaggopher/contract"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+const v1 = `
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+basePath: /api
+paths:
+  /pets:
+    post:
+      operationId: createPet
+      parameters:
+      - name: pet
+        in: body
+        required: true
+        schema: {$ref: '#/definitions/NewPet'}
+      responses:
+        201:
+          description: Created.
+          schema: {$ref: '#/definitions/Pet'}
+  /pets/{petId}:
+    get:
+      operationId: getPet
+      parameters:
+      - {name: petId, in: path, required: true, type: integer}
+      responses:
+        200:
+          description: A pet.
+          schema: {$ref: '#/definitions/Pet'}
+definitions:
+  NewPet:
+    type: object
+    required: [name]
+    properties:
+      name: {type: string}
+  Pet:
+    type: object
+    required: [id, name]
+    properties:
+      id: {type: integer}
+      name: {type: string}
+`
+
+func parse(t *testing.T, doc string) *spec.Swagger {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(doc), &s); err != nil {
+		t.Fatal(err)
+	}
+	return &s
+}
+
+// failures returns the failing cases of a report, keyed by operation.
+func failures(r *contract.Report) map[string][]string {
+	got := make(map[string][]string)
+	for _, op := range r.Operations {
+		for _, c := range op.Cases {
+			got[op.OperationID] = append(got[op.OperationID], c.Failures...)
+		}
+	}
+	for id, f := range got {
+		if len(f) == 0 {
+			delete(got, id)
+		}
+	}
+	return got
+}
+
+func TestCheck(t *testing.T) {
+	old := parse(t, v1)
+
+	// Compatible changes: an optional field and a new optional parameter.
+	compatible := parse(t, v1)
+	newPet := compatible.Definitions["NewPet"]
+	newPet.Properties["tag"] = spec.Schema{Type: "string"}
+	get := compatible.Paths["/pets/{petId}"].Get
+	get.Parameters = append(get.Parameters, spec.Parameter{Name: "verbose", In: "query", Type: "boolean"})
+
+	result, err := Check(context.Background(), old, compatible, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.OK() {
+		t.Errorf("expected compatible versions, got old client failures %v, new client failures %v",
+			failures(result.OldClient), failures(result.NewClient))
+	}
+
+	// Breaking changes: a newly required request field, a response field
+	// which is no longer returned, and a new operation.
+	breaking := parse(t, v1)
+	newPet = breaking.Definitions["NewPet"]
+	newPet.Required = append(newPet.Required, "species")
+	newPet.Properties["species"] = spec.Schema{Type: "string"}
+	pet := breaking.Definitions["Pet"]
+	pet.Required = []string{"id"}
+	delete(pet.Properties, "name")
+	breaking.Definitions["NewPet"], breaking.Definitions["Pet"] = newPet, pet
+	breaking.Paths["/owners"] = spec.PathItem{
+		Get: &spec.Operation{
+			OperationId: "listOwners",
+			Responses:   spec.Responses{"200": {Description: "Owners."}},
+		},
+	}
+
+	result, err = Check(context.Background(), old, breaking, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{
+		"createPet": {"status 400 is not documented"},
+		"getPet":    {`body: missing required property "name"`},
+	}
+	if diff := pretty.Compare(failures(result.OldClient), want); diff != "" {
+		t.Errorf("old client: want != got: %s", diff)
+	}
+	want = map[string][]string{
+		"listOwners": {"status 404 is not documented"},
+	}
+	if diff := pretty.Compare(failures(result.NewClient), want); diff != "" {
+		t.Errorf("new client: want != got: %s", diff)
+	}
+}
@@ -0,0 +1,60 @@
+package spec
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// AdditionalProperties is the value of a schema's "additionalProperties" field,
+// which may either be a boolean or a schema.
+type AdditionalProperties struct {
+	// Allowed is the boolean form of the field. It's ignored if Schema is set.
+	Allowed bool
+	// Schema the values of additional properties must validate against.
+	Schema *Schema
+}
+
+// MarshalJSON implements json.Marshaler.
+func (a AdditionalProperties) MarshalJSON() ([]byte, error) {
+	if a.Schema != nil {
+		return json.Marshal(a.Schema)
+	}
+	return json.Marshal(a.Allowed)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (a *AdditionalProperties) UnmarshalJSON(b []byte) error {
+	if t := bytes.TrimSpace(b); bytes.Equal(t, []byte("true")) || bytes.Equal(t, []byte("false")) {
+		*a = AdditionalProperties{}
+		return json.Unmarshal(b, &a.Allowed)
+	}
+	var s Schema
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	*a = AdditionalProperties{Allowed: true, Schema: &s}
+	return nil
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (a AdditionalProperties) MarshalYAML() (interface{}, error) {
+	if a.Schema != nil {
+		return a.Schema, nil
+	}
+	return a.Allowed, nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (a *AdditionalProperties) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var b bool
+	if err := unmarshal(&b); err == nil {
+		*a = AdditionalProperties{Allowed: b}
+		return nil
+	}
+	var s Schema
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	*a = AdditionalProperties{Allowed: true, Schema: &s}
+	return nil
+}
@@ -0,0 +1,151 @@
+package spec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/internal/rawdoc"
+)
+
+// The generated types which support vendor extensions encode them with the
+// helpers below. Each is passed a copy of the type without its methods, so the
+// fixed fields can be encoded without recursing into the custom marshalers.
+
+// isExtension reports if a field name is a vendor extension.
+func isExtension(name string) bool {
+	return strings.HasPrefix(name, "x-")
+}
+
+func sortedExtensions(ext map[string]interface{}) ([]string, error) {
+	names := make([]string, 0, len(ext))
+	for name := range ext {
+		if !isExtension(name) {
+			return nil, fmt.Errorf("spec: extension %q must begin with \"x-\"", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// marshalJSON encodes v, then appends the extensions to the encoded object in
+// name order.
+func marshalJSON(v interface{}, ext map[string]interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || len(ext) == 0 {
+		return data, err
+	}
+	names, err := sortedExtensions(ext)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.Write(data[:len(data)-1])
+	for i, name := range names {
+		if i > 0 || len(data) > 2 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+		val, err := json.Marshal(ext[name])
+		if err != nil {
+			return nil, fmt.Errorf("spec: encoding extension %q: %v", name, err)
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(val)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// unmarshalJSON decodes an object into v and returns its extensions.
+func unmarshalJSON(data []byte, v interface{}) (map[string]interface{}, error) {
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	var ext map[string]interface{}
+	for name, raw := range fields {
+		if !isExtension(name) {
+			continue
+		}
+		var val interface{}
+		if err := json.Unmarshal(raw, &val); err != nil {
+			return nil, err
+		}
+		if ext == nil {
+			ext = make(map[string]interface{})
+		}
+		ext[name] = val
+	}
+	return ext, nil
+}
+
+// marshalYAML returns the fields of v, in order, followed by the extensions in
+// name order.
+func marshalYAML(v interface{}, ext map[string]interface{}) (interface{}, error) {
+	if len(ext) == 0 {
+		return v, nil
+	}
+	names, err := sortedExtensions(ext)
+	if err != nil {
+		return nil, err
+	}
+	data, err := rawdoc.MarshalYAML(v)
+	if err != nil {
+		return nil, err
+	}
+	var fields rawdoc.MapSlice
+	if err := rawdoc.UnmarshalYAML(data, &fields); err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		val := ext[name]
+		if raw, ok := val.(json.RawMessage); ok {
+			// Kept by UnmarshalRaw.
+			if err := json.Unmarshal(raw, &val); err != nil {
+				return nil, err
+			}
+		}
+		fields = append(fields, rawdoc.MapItem{Key: name, Value: val})
+	}
+	return fields, nil
+}
+
+// unmarshalYAML decodes a mapping into v and returns its extensions. Their
+// values are converted to those decoded by encoding/json, so that documents
+// read from either format hold the same values.
+func unmarshalYAML(unmarshal func(interface{}) error, v interface{}) (map[string]interface{}, error) {
+	if err := unmarshal(v); err != nil {
+		return nil, err
+	}
+	var fields map[interface{}]interface{}
+	if err := unmarshal(&fields); err != nil {
+		return nil, err
+	}
+	var ext map[string]interface{}
+	for key, raw := range fields {
+		name, ok := key.(string)
+		if !ok || !isExtension(name) {
+			continue
+		}
+		val, err := rawdoc.Normalize(raw)
+		if err != nil {
+			return nil, err
+		}
+		if ext == nil {
+			ext = make(map[string]interface{})
+		}
+		ext[name] = val
+	}
+	return ext, nil
+}
@@ -0,0 +1,169 @@
+package spec
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Usage is an estimate of the memory a document retains, in bytes.
+type Usage struct {
+	// Bytes is the estimate for the whole document.
+	Bytes int64 `json:"bytes"`
+	// Sections holds the estimates of the document's top-level fields, keyed
+	// by their JSON names, such as "paths" and "definitions". Fields which
+	// aren't in JSON, such as "extensions", are keyed by their Go names in
+	// lower camel case. Empty fields are omitted.
+	Sections map[string]int64 `json:"sections"`
+}
+
+// Largest returns the names of the n largest sections, largest first. If n is
+// negative, every section is returned.
+func (u Usage) Largest(n int) []string {
+	names := make([]string, 0, len(u.Sections))
+	for name := range u.Sections {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if u.Sections[names[i]] != u.Sections[names[j]] {
+			return u.Sections[names[i]] > u.Sections[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	if n >= 0 && n < len(names) {
+		names = names[:n]
+	}
+	return names
+}
+
+// Footprint estimates the memory a document retains, so operators of services
+// holding many documents can set capacity limits and find the documents, and
+// the sections of them, taking the most.
+//
+// The estimate is the size of each value the document holds, as the Go runtime
+// lays it out, and of the strings, slices, maps and pointers it refers to.
+// Values referred to more than once, such as the index of operations, are
+// counted once, with the first section referring to them. Allocator overhead
+// isn't counted, so the estimate is a lower bound, though typically within a
+// few percent of what a heap profile reports.
+func Footprint(s *Swagger) Usage {
+	u := Usage{Sections: make(map[string]int64)}
+	if s == nil {
+		return u
+	}
+	e := &estimator{seen: make(map[uintptr]bool)}
+	v := reflect.ValueOf(s).Elem()
+	t := v.Type()
+	u.Bytes = int64(t.Size())
+	for i := 0; i < t.NumField(); i++ {
+		f := v.Field(i)
+		n := e.indirect(f)
+		u.Bytes += n
+		if n == 0 && f.IsZero() {
+			continue
+		}
+		u.Sections[sectionName(t.Field(i))] += int64(f.Type().Size()) + n
+	}
+	return u
+}
+
+// sectionName returns the JSON name of a field of a document, or its Go name
+// in lower camel case.
+func sectionName(f reflect.StructField) string {
+	if name := strings.Split(f.Tag.Get("json"), ",")[0]; name != "" && name != "-" {
+		return name
+	}
+	r, n := utf8.DecodeRuneInString(f.Name)
+	return string(unicode.ToLower(r)) + f.Name[n:]
+}
+
+// estimator sums the sizes of the values a value refers to.
+type estimator struct {
+	// seen holds the addresses of the values already counted.
+	seen map[uintptr]bool
+}
+
+// indirect returns the size of the memory v refers to, not counting v itself,
+// whose size is counted by the value holding it.
+func (e *estimator) indirect(v reflect.Value) int64 {
+	switch v.Kind() {
+	case reflect.String:
+		if v.Len() == 0 {
+			return 0
+		}
+		// Strings decoded from a document don't share their data.
+		return int64(v.Len())
+	case reflect.Ptr:
+		if v.IsNil() || e.seen[v.Pointer()] {
+			return 0
+		}
+		e.seen[v.Pointer()] = true
+		elem := v.Elem()
+		return int64(elem.Type().Size()) + e.indirect(elem)
+	case reflect.Interface:
+		if v.IsNil() {
+			return 0
+		}
+		elem := v.Elem()
+		n := e.indirect(elem)
+		// Values other than pointers are boxed in their own allocation.
+		if elem.Kind() != reflect.Ptr {
+			n += int64(elem.Type().Size())
+		}
+		return n
+	case reflect.Slice:
+		if v.IsNil() || e.seen[v.Pointer()] {
+			return 0
+		}
+		e.seen[v.Pointer()] = true
+		n := int64(v.Cap()) * int64(v.Type().Elem().Size())
+		for i := 0; i < v.Len(); i++ {
+			n += e.indirect(v.Index(i))
+		}
+		return n
+	case reflect.Array:
+		var n int64
+		for i := 0; i < v.Len(); i++ {
+			n += e.indirect(v.Index(i))
+		}
+		return n
+	case reflect.Struct:
+		var n int64
+		for i := 0; i < v.NumField(); i++ {
+			n += e.indirect(v.Field(i))
+		}
+		return n
+	case reflect.Map:
+		if v.IsNil() || e.seen[v.Pointer()] {
+			return 0
+		}
+		e.seen[v.Pointer()] = true
+		n := mapSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			n += e.indirect(iter.Key()) + e.indirect(iter.Value())
+		}
+		return n
+	}
+	return 0
+}
+
+// mapSize estimates the size of a map with n entries: its header and its
+// buckets, each of which holds eight entries, filled to the runtime's load
+// factor of 6.5 on average.
+func mapSize(t reflect.Type, n int) int64 {
+	const (
+		header      = 48
+		bucketSize  = 8
+		loadFactor  = 6.5
+		pointerSize = 8
+	)
+	buckets := 1
+	for float64(n) > loadFactor*float64(buckets) {
+		buckets *= 2
+	}
+	bucket := bucketSize + bucketSize*(int64(t.Key().Size())+int64(t.Elem().Size())) + pointerSize
+	return header + int64(buckets)*bucket
+}
@@ -8,10 +8,15 @@ package main
 import (
 	"bytes"
 	"errors"
+	"flag"
 	"fmt"
+	"go/format"
+	"io"
 	"io/ioutil"
+	"log"
 	"os"
 	"strings"
+	"time"
 	"unicode"
 	"unicode/utf8"
 
@@ -48,13 +53,44 @@ var omitType = map[string]bool{
 	"Reference": true,
 }
 
-// canBeReference refers to
+// canBeReference holds the objects which may be replaced by a Reference Object.
+// Each gets a "$ref" field, except for Schema which lists its own.
 var canBeReference = map[string]bool{
 	"Parameter": true,
 	"Response":  true,
 	"Schema":    true,
 }
 
+// jsonSchemaTypes holds the types of the JSON Schema properties which the Schema
+// Object lists by name, rather than in its table of fixed fields.
+var jsonSchemaTypes = map[string]string{
+	"$ref":                 "string",
+	"format":               "string",
+	"title":                "string",
+	"description":          "string",
+	"default":              "*",
+	"multipleOf":           "number",
+	"maximum":              "number",
+	"exclusiveMaximum":     "boolean",
+	"minimum":              "number",
+	"exclusiveMinimum":     "boolean",
+	"maxLength":            "integer",
+	"minLength":            "integer",
+	"pattern":              "string",
+	"maxItems":             "integer",
+	"minItems":             "integer",
+	"uniqueItems":          "boolean",
+	"maxProperties":        "integer",
+	"minProperties":        "integer",
+	"required":             "[string]",
+	"enum":                 "[*]",
+	"type":                 "string",
+	"items":                "Schema Object",
+	"allOf":                "[Schema Object]",
+	"properties":           "map[string]Schema",
+	"additionalProperties": "AdditionalProperties",
+}
+
 var typeMappings = map[string]string{
 	"string":  "string",
 	"number":  "float64",
@@ -65,6 +101,102 @@ var typeMappings = map[string]string{
 	"[*]":     "[]interface{}",
 }
 
+// pointerFields are numeric fields where the zero value is meaningful, such as
+// "minimum: 0" or "maxLength: 0", and must be distinguishable from an unset
+// field.
+var pointerFields = map[string]bool{
+	"maximum":       true,
+	"minimum":       true,
+	"maxLength":     true,
+	"maxItems":      true,
+	"maxProperties": true,
+}
+
+// extensible finds the objects whose patterned fields include vendor extensions,
+// those matching "^x-". Objects generated as maps, such as Paths, are skipped.
+func extensible(schema *html.Node) map[string]bool {
+	found := make(map[string]bool)
+	var name string
+	for c := schema.NextSibling; c != nil && c.DataAtom != atom.H3; c = c.NextSibling {
+		switch c.DataAtom {
+		case atom.H4:
+			name = objTypeName(text(c))
+		case atom.Table:
+			if specialType(name) {
+				continue
+			}
+			for _, td := range findAll(c, byAtom(atom.Td)) {
+				if strings.HasPrefix(text(td), "^x-") {
+					found[name] = true
+				}
+			}
+		}
+	}
+	return found
+}
+
+// lowerName lowercases the leading capitals of a type name, such as "XML" to
+// "xml" and "PathItem" to "pathItem", to name an unexported copy of the type.
+func lowerName(s string) string {
+	n := 0
+	for n < len(s) && unicode.IsUpper(rune(s[n])) {
+		n++
+	}
+	if n > 1 && n < len(s) {
+		n--
+	}
+	return strings.ToLower(s[:n]) + s[n:]
+}
+
+// writeMarshalers writes methods which encode and decode a type's
+// Extensions field alongside its fixed fields. Objects which may be replaced by a
+// Reference Object, other than Schema, only encode their "$ref" field when it's
+// set: their remaining fields include ones the spec requires, such as a
+// Parameter's "name" and "in", which would otherwise be written as empty values.
+func writeMarshalers(w io.Writer, name string) {
+	recv := strings.ToLower(name[:1])
+	alias := lowerName(name)
+	ref := ""
+	if canBeReference[name] && name != "Schema" {
+		ref = recv + ".Ref != \"\""
+	}
+
+	fmt.Fprintf(w, "\n// MarshalJSON implements json.Marshaler.\nfunc (%s %s) MarshalJSON() ([]byte, error) {\n", recv, name)
+	if ref != "" {
+		fmt.Fprintf(w, "if %s {\nreturn marshalJSON(Reference{Ref: %s.Ref}, nil)\n}\n", ref, recv)
+	}
+	if name == "Swagger" {
+		// Documents decoded by UnmarshalOrdered or UnmarshalLossless are
+		// written with their original key order and unknown fields.
+		fmt.Fprintf(w, "type %s %s\ndata, err := marshalJSON(%s(%s), %s.Extensions)\n", alias, name, alias, recv, recv)
+		fmt.Fprintf(w, "if err != nil || !%s.preserved() {\nreturn data, err\n}\nreturn %s.restoreJSON(data)\n}\n", recv, recv)
+	} else if name == "Operation" {
+		fmt.Fprintf(w, "type %s %s\nif %s.unsecured() {\nreturn marshalUnsecuredJSON(%s(%s), %s.Extensions)\n}\n", alias, name, recv, alias, recv, recv)
+		fmt.Fprintf(w, "return marshalJSON(%s(%s), %s.Extensions)\n}\n", alias, recv, recv)
+	} else {
+		fmt.Fprintf(w, "type %s %s\nreturn marshalJSON(%s(%s), %s.Extensions)\n}\n", alias, name, alias, recv, recv)
+	}
+
+	fmt.Fprintf(w, "\n// UnmarshalJSON implements json.Unmarshaler.\nfunc (%s *%s) UnmarshalJSON(b []byte) error {\n", recv, name)
+	fmt.Fprintf(w, "type %s %s\nvar err error\n%s.Extensions, err = unmarshalJSON(b, (*%s)(%s))\nreturn err\n}\n", alias, name, recv, alias, recv)
+
+	fmt.Fprintf(w, "\n// MarshalYAML implements yaml.Marshaler.\nfunc (%s %s) MarshalYAML() (interface{}, error) {\n", recv, name)
+	if ref != "" {
+		fmt.Fprintf(w, "if %s {\nreturn Reference{Ref: %s.Ref}, nil\n}\n", ref, recv)
+	}
+	if name == "Swagger" {
+		fmt.Fprintf(w, "if %s.preserved() {\nreturn %s.yamlDocument()\n}\n", recv, recv)
+	}
+	fmt.Fprintf(w, "type %s %s\n", alias, name)
+	if name == "Operation" {
+		fmt.Fprintf(w, "if %s.unsecured() {\nreturn marshalUnsecuredYAML(%s(%s), %s.Extensions)\n}\n", recv, alias, recv, recv)
+	}
+	fmt.Fprintf(w, "return marshalYAML(%s(%s), %s.Extensions)\n}\n", alias, recv, recv)
+
+	fmt.Fprintf(w, "\n// UnmarshalYAML implements yaml.Unmarshaler.\nfunc (%s *%s) UnmarshalYAML(unmarshal func(interface{}) error) error {\n", recv, name)
+	fmt.Fprintf(w, "type %s %s\nvar err error\n%s.Extensions, err = unmarshalYAML(unmarshal, (*%s)(%s))\nreturn err\n}\n", alias, name, recv, alias, recv)
+}
+
 func objName(s string) string {
 	if s == "$ref" {
 		return "Ref"
@@ -117,11 +249,23 @@ func wrapStringAfter(s string, i int) []string {
 }
 
 func main() {
+	verbose := flag.Bool("v", false, "log each generated type and how long generation took")
+	dryRun := flag.Bool("dry-run", false, "report whether schema.go would change without writing it")
+	flag.Parse()
+
+	logf := func(format string, v ...interface{}) {}
+	if *verbose {
+		logger := log.New(os.Stderr, "gen: ", 0)
+		logf = logger.Printf
+	}
+	start := time.Now()
+
 	root, err := parseFile("2.0.html")
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(2)
 	}
+	logf("parsed 2.0.html in %s", time.Since(start))
 
 	// find a node that has the child <a href="#schema">
 	matcher := func(n *html.Node) bool {
@@ -140,31 +284,80 @@ func main() {
 	doc.WriteString(`// This file was generated by gen.go. DO NOT EDIT.
 
 package spec
+
+import "encoding/json"
 `)
 
 	commentStrings := make(map[string]string)
+	extensions := extensible(schema)
+	var withExtensions []string
 
-	var name string
+	var (
+		name       string
+		listFields []field
+	)
 
 	parseTable := func(c *html.Node) {
-		table := nextSibling(c, byAtom(atom.Table))
-		if table == nil {
+		tables := followingTables(c)
+		if len(tables) == 0 {
 			fmt.Fprintf(os.Stderr, "<table> does not follow field fields for %s\n", name)
 			os.Exit(2)
 		}
-		p, err := newTableParser(table)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "table %s failed %v\n", name, err)
-			os.Exit(2)
-		}
 
 		fmt.Fprintln(&doc, "\n"+commentStrings[name])
 
 		fmt.Fprintln(&doc, "type", name, "struct {")
-		for _, field := range p.fields() {
+		n := 0
+		if canBeReference[name] && name != "Schema" {
+			fmt.Fprintln(&doc, field{
+				Name:        "$ref",
+				Type:        "string",
+				Description: "A JSON Reference to a " + name + " Object defined elsewhere, such as in the top level " + strings.ToLower(name) + "s. If set, the remaining fields are unused.",
+			})
+			n++
+		}
+		for _, field := range listFields {
 			fmt.Fprintln(&doc, field)
+			n++
+		}
+		listFields = nil
+		for i, table := range tables {
+			p, err := newTableParser(table)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "table %s failed %v\n", name, err)
+				os.Exit(2)
+			}
+			for _, field := range p.fields() {
+				// Tables after the first only apply conditionally, such as the
+				// Parameter Object's 'If "in" is "body"', so are never required.
+				if i > 0 {
+					field.Required = false
+				}
+				fmt.Fprintln(&doc, field)
+				n++
+			}
+		}
+		if extensions[name] {
+			fmt.Fprintln(&doc, "\t// Vendor extensions, fields whose names begin with \"x-\". The values are")
+			fmt.Fprintln(&doc, "\t// those decoded by encoding/json.")
+			fmt.Fprintln(&doc, "\tExtensions map[string]interface{} `json:\"-\" yaml:\"-\"`")
+			withExtensions = append(withExtensions, name)
+			n++
+		}
+		if name == "Swagger" {
+			fmt.Fprintln(&doc, "\t// The order of the keys of the document's objects, recorded by")
+			fmt.Fprintln(&doc, "\t// UnmarshalOrdered. If set, the document is encoded in that order.")
+			fmt.Fprintln(&doc, "\tKeyOrder KeyOrder `json:\"-\" yaml:\"-\"`")
+			fmt.Fprintln(&doc, "\t// Fields which aren't part of the specification, keyed by their JSON")
+			fmt.Fprintln(&doc, "\t// pointers, recorded by UnmarshalLossless. They're written back when")
+			fmt.Fprintln(&doc, "\t// the document is encoded.")
+			fmt.Fprintln(&doc, "\tUnknown map[string]json.RawMessage `json:\"-\" yaml:\"-\"`")
+			fmt.Fprintln(&doc, "\t// The index of OperationByID, built when it's first called.")
+			fmt.Fprintln(&doc, "\toperations *operationIndex")
+			n += 2
 		}
 		fmt.Fprintln(&doc, "}")
+		logf("generated type %s with %d fields from %d table(s)", name, n, len(tables))
 	}
 
 	for c := schema.NextSibling; c != nil && c.DataAtom != atom.H3; c = c.NextSibling {
@@ -182,6 +375,14 @@ package spec
 				lines = append(lines, "// "+strings.Join(wrapStringAfter(text(s), 85), "\n// "))
 				commentStrings[name] = strings.Join(lines, "\n//\n")
 			}
+			// The Schema Object lists the JSON Schema properties it supports
+			// before its table of fixed fields.
+			if name == "Schema" {
+				if listFields, err = jsonSchemaFields(c); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(2)
+				}
+			}
 			// For some reason "Header Object" does not have a "Fixed Fields" field.
 			if name == "Header" {
 				parseTable(c)
@@ -195,11 +396,36 @@ package spec
 	}
 	for _, t := range specialTypes {
 		fmt.Fprintf(&doc, "\n%s\ntype %s %s\n", commentStrings[t.Name], t.Name, t.Val)
+		logf("generated type %s as %s", t.Name, t.Val)
+	}
+	for _, name := range withExtensions {
+		writeMarshalers(&doc, name)
+	}
+	src, err := format.Source(doc.Bytes())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to format schema.go", err)
+		os.Exit(2)
+	}
+	if *dryRun {
+		existing, err := ioutil.ReadFile("schema.go")
+		switch {
+		case os.IsNotExist(err):
+			fmt.Println("would create schema.go")
+		case err != nil:
+			fmt.Fprintln(os.Stderr, "failed to read schema.go", err)
+			os.Exit(2)
+		case bytes.Equal(existing, src):
+			fmt.Println("schema.go is unchanged")
+		default:
+			fmt.Println("would update schema.go")
+		}
+		return
 	}
-	if err := ioutil.WriteFile("schema.go", doc.Bytes(), 0644); err != nil {
+	if err := ioutil.WriteFile("schema.go", src, 0644); err != nil {
 		fmt.Fprintln(os.Stderr, "failed to write schema.go", err)
 		os.Exit(2)
 	}
+	logf("wrote schema.go in %s", time.Since(start))
 }
 
 type field struct {
@@ -216,7 +442,52 @@ func (f field) String() string {
 	}
 	commentLines := wrapStringAfter(f.Description, 80)
 	comment := "\t// " + strings.Join(commentLines, "\n\t// ")
-	return fmt.Sprintf("%s\n\t%s %s `json:\"%s\" yaml:\"%s\"`", comment, objName(f.Name), fieldType(objTypeName(f.Type)), name, name)
+	typ := fieldType(objTypeName(f.Type))
+	if pointerFields[f.Name] {
+		typ = "*" + typ
+	}
+	return fmt.Sprintf("%s\n\t%s %s `json:\"%s\" yaml:\"%s\"`", comment, objName(f.Name), typ, name, name)
+}
+
+// jsonSchemaFields parses the lists of JSON Schema properties that follow the
+// Schema Object's heading. Each list item holds a property name, optionally
+// followed by a description such as "$ref - As a JSON Reference".
+func jsonSchemaFields(n *html.Node) ([]field, error) {
+	// The first list is taken directly from JSON Schema, the second has been
+	// adjusted to refer to the Schema Object.
+	defaults := []string{
+		"Follows the JSON Schema definition.",
+		"Follows the JSON Schema definition, using the Schema Object in place of JSON Schema.",
+	}
+	var (
+		fields []field
+		lists  int
+	)
+	for s := n.NextSibling; s != nil && s.DataAtom != atom.H5; s = s.NextSibling {
+		if s.Type != html.ElementNode || s.DataAtom != atom.Ul {
+			continue
+		}
+		if lists >= len(defaults) {
+			return nil, errors.New("unexpected list in Schema Object")
+		}
+		for _, li := range findAll(s, byAtom(atom.Li)) {
+			txt := text(li)
+			name, desc := txt, defaults[lists]
+			if i := strings.IndexAny(txt, " ("); i >= 0 {
+				name = txt[:i]
+				desc = strings.TrimSpace(txt[i:])
+				desc = strings.TrimPrefix(desc, "- ")
+				desc = strings.TrimSuffix(strings.TrimPrefix(desc, "("), ")") + "."
+			}
+			typ, ok := jsonSchemaTypes[name]
+			if !ok {
+				return nil, fmt.Errorf("no type known for Schema Object property %q", name)
+			}
+			fields = append(fields, field{Name: name, Type: typ, Description: desc})
+		}
+		lists++
+	}
+	return fields, nil
 }
 
 const (
@@ -348,6 +619,24 @@ func nextSibling(n *html.Node, match func(n *html.Node) bool) *html.Node {
 	return nil
 }
 
+// followingTables returns the tables that follow n, stopping at the next
+// heading.
+func followingTables(n *html.Node) []*html.Node {
+	var tables []*html.Node
+	for s := n.NextSibling; s != nil; s = s.NextSibling {
+		if s.Type != html.ElementNode {
+			continue
+		}
+		switch s.DataAtom {
+		case atom.H4, atom.H5:
+			return tables
+		case atom.Table:
+			tables = append(tables, s)
+		}
+	}
+	return tables
+}
+
 func byAtom(a atom.Atom) func(n *html.Node) bool {
 	return func(n *html.Node) bool {
 		return n.Type == html.ElementNode && n.DataAtom == a
@@ -0,0 +1,137 @@
+package spec
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/internal/logutil"
+	"github.com/ericchiang/swaggopher/internal/rawdoc"
+)
+
+// Format is an encoding of a document.
+type Format int
+
+const (
+	JSON Format = iota
+	YAML
+)
+
+func (f Format) String() string {
+	switch f {
+	case JSON:
+		return "json"
+	case YAML:
+		return "yaml"
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// FormatOf returns the format a file's extension names: YAML for ".yaml" and
+// ".yml", and JSON for ".json". It returns false for other extensions.
+func FormatOf(path string) (Format, bool) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return JSON, true
+	case ".yaml", ".yml":
+		return YAML, true
+	}
+	return 0, false
+}
+
+// LoadOptions configures how documents are loaded.
+type LoadOptions struct {
+	// Logger, if set, receives a line for each document loaded.
+	Logger Logger
+}
+
+// Load reads a JSON or YAML document from a file. The format is chosen by the
+// file's extension, or for other extensions by the file's contents.
+func Load(path string) (*Swagger, error) {
+	return LoadOptions{}.Load(path)
+}
+
+// Load reads a JSON or YAML document from a file. The format is chosen by the
+// file's extension, or for other extensions by the file's contents.
+func (o LoadOptions) Load(path string) (*Swagger, error) {
+	defer logutil.Phase(o.Logger, "load")()
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, ok := FormatOf(path)
+	if !ok {
+		f = sniff(data)
+	}
+	s, err := decode(data, f)
+	if err != nil {
+		return nil, fmt.Errorf("spec: %s: %v", path, err)
+	}
+	logutil.Printf(o.Logger, "spec: loaded %s as %s (%d bytes)", path, f, len(data))
+	return s, nil
+}
+
+// LoadReader reads a JSON or YAML document, choosing the format by its
+// contents.
+func LoadReader(r io.Reader) (*Swagger, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	s, err := decode(data, sniff(data))
+	if err != nil {
+		return nil, fmt.Errorf("spec: %v", err)
+	}
+	return s, nil
+}
+
+// Save writes the document to a file in a format, such as the one FormatOf
+// returns for the path. JSON is indented by two spaces.
+func (s *Swagger) Save(path string, f Format) error {
+	data, err := s.Encode(f)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// Encode returns the document encoded in a format.
+func (s *Swagger) Encode(f Format) ([]byte, error) {
+	switch f {
+	case JSON:
+		data, err := json.MarshalIndent(s, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	case YAML:
+		return MarshalYAML(s)
+	}
+	return nil, fmt.Errorf("spec: unknown format %s", f)
+}
+
+// sniff returns the format of a document's contents. Since JSON is also YAML,
+// anything which doesn't look like a JSON object is decoded as YAML.
+func sniff(data []byte) Format {
+	if rawdoc.IsJSON(data) {
+		return JSON
+	}
+	return YAML
+}
+
+func decode(data []byte, f Format) (*Swagger, error) {
+	s := new(Swagger)
+	var err error
+	if f == JSON {
+		err = json.Unmarshal(data, s)
+	} else {
+		err = UnmarshalYAML(data, s)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
@@ -0,0 +1,10 @@
+package spec
+
+// Logger receives diagnostic output from the loaders, resolvers, validators and
+// generators in this repository, such as which files were fetched, which rules
+// fired and how long each phase took. *log.Logger satisfies this interface.
+//
+// Components are silent unless they're given a Logger.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
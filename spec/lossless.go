@@ -0,0 +1,106 @@
+package spec
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/ericchiang/swaggopher/internal/jsonpointer"
+	"github.com/ericchiang/swaggopher/internal/rawdoc"
+)
+
+// UnmarshalLossless decodes a JSON or YAML document into s like
+// UnmarshalOrdered, and also keeps the fields which aren't part of the
+// specification in s.Unknown, rather than dropping them as json.Unmarshal and
+// yaml.Unmarshal do.
+//
+// Encoding the document writes the unknown fields back in their original
+// place, so tools can transform documents which use non-standard fields
+// without losing them. Unknown fields of objects removed after decoding are
+// dropped with them.
+func UnmarshalLossless(data []byte, s *Swagger) error {
+	doc, err := rawdoc.Decode(data)
+	if err != nil {
+		return err
+	}
+	var paths []string
+	unknownFields(reflect.TypeOf(s).Elem(), doc, "", &paths)
+	if err := UnmarshalOrdered(data, s); err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+	s.Unknown = make(map[string]json.RawMessage, len(paths))
+	for _, p := range paths {
+		val, ok := valueAt(doc, jsonpointer.Split(p))
+		if !ok {
+			continue
+		}
+		raw, err := json.Marshal(val)
+		if err != nil {
+			return err
+		}
+		s.Unknown[p] = raw
+	}
+	return nil
+}
+
+// valueAt returns the value a pointer's tokens refer to in a decoded document.
+func valueAt(v interface{}, tokens []string) (interface{}, bool) {
+	for _, tok := range tokens {
+		switch val := v.(type) {
+		case map[string]interface{}:
+			elem, ok := val[tok]
+			if !ok {
+				return nil, false
+			}
+			v = elem
+		case []interface{}:
+			i, err := strconv.Atoi(tok)
+			if err != nil || i < 0 || i >= len(val) {
+				return nil, false
+			}
+			v = val[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+type unknownField struct {
+	name  string
+	value json.RawMessage
+}
+
+// unknownByParent groups unknown fields by the pointer of the object holding
+// them, in name order.
+func unknownByParent(unknown map[string]json.RawMessage) map[string][]unknownField {
+	if len(unknown) == 0 {
+		return nil
+	}
+	byParent := make(map[string][]unknownField)
+	for p, val := range unknown {
+		tokens := jsonpointer.Split(p)
+		if len(tokens) == 0 {
+			continue
+		}
+		parent := jsonpointer.Join("", tokens[:len(tokens)-1]...)
+		byParent[parent] = append(byParent[parent], unknownField{tokens[len(tokens)-1], val})
+	}
+	for _, fields := range byParent {
+		sort.Slice(fields, func(i, j int) bool { return fields[i].name < fields[j].name })
+	}
+	return byParent
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
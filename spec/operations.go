@@ -0,0 +1,237 @@
+package spec
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ericchiang/swaggopher/internal/jsonpointer"
+)
+
+// operationIndex maps operationIds to the operations of a document.
+type operationIndex struct {
+	mu   sync.Mutex
+	byID map[string]indexedOperation
+}
+
+type indexedOperation struct {
+	path, method string
+	op           *Operation
+}
+
+// indexMu guards the creation of documents' operation indexes. Each index has
+// a lock of its own for lookups.
+var indexMu sync.Mutex
+
+// RangeOperations calls f with every operation of the document, its path and
+// its method, such as "get". Paths are visited in order, and the operations of
+// a path in the order of Methods. It stops if f returns false.
+//
+// The operations are those held by the document, so changes f makes to them
+// are kept.
+func (s *Swagger) RangeOperations(f func(path, method string, op *Operation) bool) {
+	for _, path := range s.sortedPaths() {
+		item := s.Paths[path]
+		ok := item.RangeOperations(func(method string, op *Operation) bool {
+			return f(path, method, op)
+		})
+		if !ok {
+			return
+		}
+	}
+}
+
+// PathOperation is an operation of a document, with the path and method it's
+// declared under.
+type PathOperation struct {
+	Path   string
+	Method string
+	Item   *PathItem
+	*Operation
+}
+
+// Pointer returns the JSON pointer to the operation, such as
+// "/paths/~1pets/get".
+func (o PathOperation) Pointer() string {
+	return jsonpointer.Join("/paths", o.Path, o.Method)
+}
+
+// SortedOperations returns the document's operations ordered by path, then by
+// method name, so "delete" comes before "get". Unlike RangeOperations, the
+// order doesn't depend on Methods, so reports listing operations stay in
+// alphabetical order.
+func (s *Swagger) SortedOperations() []PathOperation {
+	methods := append([]string(nil), Methods...)
+	sort.Strings(methods)
+	var ops []PathOperation
+	for _, path := range s.sortedPaths() {
+		item := s.Paths[path]
+		for _, method := range methods {
+			if op := item.Operation(method); op != nil {
+				ops = append(ops, PathOperation{Path: path, Method: method, Item: &item, Operation: op})
+			}
+		}
+	}
+	return ops
+}
+
+func (s *Swagger) sortedPaths() []string {
+	paths := make([]string, 0, len(s.Paths))
+	for path := range s.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Methods lists the HTTP methods a path item can hold an operation for, in the
+// order they're visited by RangeOperations.
+var Methods = []string{"get", "put", "post", "delete", "options", "head", "patch"}
+
+// RangeOperations calls f with each operation of the path item and its
+// method, in the order of Methods. It stops if f returns false, and reports
+// whether every operation was visited.
+func (p *PathItem) RangeOperations(f func(method string, op *Operation) bool) bool {
+	for _, method := range Methods {
+		if op := p.Operation(method); op != nil && !f(method, op) {
+			return false
+		}
+	}
+	return true
+}
+
+// Operation returns the path item's operation for a method, such as "get", or
+// nil if it has none.
+func (p *PathItem) Operation(method string) *Operation {
+	if dst := p.operation(method); dst != nil {
+		return *dst
+	}
+	return nil
+}
+
+// SetOperation sets, or with a nil op removes, the path item's operation for
+// a method. It reports false if the method isn't one of Methods.
+func (p *PathItem) SetOperation(method string, op *Operation) bool {
+	dst := p.operation(method)
+	if dst == nil {
+		return false
+	}
+	*dst = op
+	return true
+}
+
+func (p *PathItem) operation(method string) **Operation {
+	switch method {
+	case "get":
+		return &p.Get
+	case "put":
+		return &p.Put
+	case "post":
+		return &p.Post
+	case "delete":
+		return &p.Delete
+	case "options":
+		return &p.Options
+	case "head":
+		return &p.Head
+	case "patch":
+		return &p.Patch
+	}
+	return nil
+}
+
+// OperationParameters returns the parameters of an operation merged with
+// those of its path item, with references resolved by ResolveParameter. The
+// operation's parameters come first, in the order they're declared, followed
+// by those of the path item the operation doesn't override by declaring a
+// parameter with the same name and location.
+//
+// References which can't be resolved are returned as they are, so callers can
+// report them.
+func (s *Swagger) OperationParameters(item *PathItem, op *Operation) []*Parameter {
+	var params []*Parameter
+	seen := make(map[string]bool)
+	for _, list := range [][]Parameter{op.Parameters, item.Parameters} {
+		for i := range list {
+			p, ok := s.ResolveParameter(&list[i])
+			key := p.In + "/" + p.Name
+			if !ok {
+				key = p.Ref
+			}
+			if !seen[key] {
+				seen[key] = true
+				params = append(params, p)
+			}
+		}
+	}
+	return params
+}
+
+// ResolveParameter returns the parameter a reference to the document's
+// parameters, such as "#/parameters/limit", refers to. A parameter which
+// isn't a reference is returned as it is. If the reference can't be resolved,
+// the parameter is returned with ok set to false.
+func (s *Swagger) ResolveParameter(p *Parameter) (resolved *Parameter, ok bool) {
+	if p.Ref == "" {
+		return p, true
+	}
+	if !strings.HasPrefix(p.Ref, "#/parameters/") {
+		return p, false
+	}
+	target, ok := s.Parameters[jsonpointer.Unescape(strings.TrimPrefix(p.Ref, "#/parameters/"))]
+	if !ok {
+		return p, false
+	}
+	return &target, true
+}
+
+// OperationByID returns the operation with an operationId, its path and its
+// method, such as "get". If operationIds aren't unique, the first operation
+// RangeOperations visits is returned.
+//
+// Operations are looked up in an index built on first use. It's rebuilt when
+// a lookup finds the operation it indexed has been removed or renamed, but not
+// when an ID isn't indexed, so an operation added or given an ID since the
+// index was built may not be found until then. Lookups are safe for concurrent
+// use, but not while the document is being changed.
+func (s *Swagger) OperationByID(id string) (op *Operation, path, method string, ok bool) {
+	index := s.operationIndex()
+	index.mu.Lock()
+	defer index.mu.Unlock()
+
+	e, ok := index.byID[id]
+	if index.byID != nil && (!ok || e.current(s, id)) {
+		return e.op, e.path, e.method, ok
+	}
+	index.byID = make(map[string]indexedOperation)
+	s.RangeOperations(func(path, method string, op *Operation) bool {
+		if _, ok := index.byID[op.OperationId]; !ok && op.OperationId != "" {
+			index.byID[op.OperationId] = indexedOperation{path, method, op}
+		}
+		return true
+	})
+	e, ok = index.byID[id]
+	return e.op, e.path, e.method, ok
+}
+
+// operationIndex returns the document's operation index, creating an empty one
+// if it has none.
+func (s *Swagger) operationIndex() *operationIndex {
+	indexMu.Lock()
+	defer indexMu.Unlock()
+	if s.operations == nil {
+		s.operations = &operationIndex{}
+	}
+	return s.operations
+}
+
+// current reports if an indexed operation is still in the document, and still
+// has the ID it was indexed by.
+func (e indexedOperation) current(s *Swagger, id string) bool {
+	item, ok := s.Paths[e.path]
+	if !ok {
+		return false
+	}
+	op := item.Operation(e.method)
+	return op == e.op && op.OperationId == id
+}
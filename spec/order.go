@@ -0,0 +1,289 @@
+package spec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/ericchiang/swaggopher/internal/jsonpointer"
+	"github.com/ericchiang/swaggopher/internal/rawdoc"
+)
+
+// KeyOrder records the order keys appear in the objects of a document, keyed
+// by the JSON pointer of each object, such as "/paths" or
+// "/definitions/Pet/properties". The document itself is keyed by "".
+type KeyOrder map[string][]string
+
+// UnmarshalOrdered decodes a JSON or YAML document into s, like json.Unmarshal
+// or yaml.Unmarshal, and records the order of its keys in s.KeyOrder.
+//
+// Paths, definitions, responses and the other maps of the document are Go maps,
+// which encoding/json and YAML encoders write in key order. A document decoded
+// by UnmarshalOrdered is instead written with every object's keys in their
+// original order, so that tools which edit a document and write it back only
+// change what they edited. Keys which weren't in the original document, such as
+// paths added after decoding, follow those which were.
+func UnmarshalOrdered(data []byte, s *Swagger) error {
+	order := make(KeyOrder)
+	if rawdoc.IsJSON(data) {
+		if err := json.Unmarshal(data, s); err != nil {
+			return err
+		}
+		if err := order.recordJSON("", data); err != nil {
+			return err
+		}
+	} else {
+		if err := rawdoc.UnmarshalYAML(data, s); err != nil {
+			return err
+		}
+		var doc rawdoc.MapSlice
+		if err := rawdoc.UnmarshalYAML(data, &doc); err != nil {
+			return err
+		}
+		order.recordYAML("", doc)
+	}
+	s.KeyOrder = order
+	return nil
+}
+
+func (k KeyOrder) recordJSON(pointer string, data []byte) error {
+	switch firstByte(data) {
+	case '{':
+		keys, vals, err := decodeObject(data)
+		if err != nil {
+			return err
+		}
+		k[pointer] = keys
+		for i, key := range keys {
+			if err := k.recordJSON(jsonpointer.Join(pointer, key), vals[i]); err != nil {
+				return err
+			}
+		}
+	case '[':
+		var elems []json.RawMessage
+		if err := json.Unmarshal(data, &elems); err != nil {
+			return err
+		}
+		for i, elem := range elems {
+			if err := k.recordJSON(jsonpointer.Join(pointer, strconv.Itoa(i)), elem); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// recordYAML records the keys of a document decoded into a rawdoc.MapSlice, whose
+// nested mappings are decoded as rawdoc.MapSlice too.
+func (k KeyOrder) recordYAML(pointer string, v interface{}) {
+	switch v := v.(type) {
+	case rawdoc.MapSlice:
+		keys := make([]string, len(v))
+		for i, item := range v {
+			key, ok := item.Key.(string)
+			if !ok {
+				key = fmt.Sprint(item.Key)
+			}
+			keys[i] = key
+			k.recordYAML(jsonpointer.Join(pointer, key), item.Value)
+		}
+		k[pointer] = keys
+	case []interface{}:
+		for i, elem := range v {
+			k.recordYAML(jsonpointer.Join(pointer, strconv.Itoa(i)), elem)
+		}
+	}
+}
+
+// sorted returns the indexes of an object's keys in the order to write them:
+// those recorded in their recorded order, then the rest in their given order.
+func (k KeyOrder) sorted(pointer string, keys []string) []int {
+	index := make([]int, len(keys))
+	for i := range index {
+		index[i] = i
+	}
+	recorded := k[pointer]
+	if len(recorded) == 0 {
+		return index
+	}
+	rank := make(map[string]int, len(recorded))
+	for i, key := range recorded {
+		if _, ok := rank[key]; !ok {
+			rank[key] = i
+		}
+	}
+	rankOf := func(key string) int {
+		if r, ok := rank[key]; ok {
+			return r
+		}
+		return len(recorded)
+	}
+	sort.SliceStable(index, func(i, j int) bool {
+		return rankOf(keys[index[i]]) < rankOf(keys[index[j]])
+	})
+	return index
+}
+
+// preserved reports if a document was decoded by UnmarshalOrdered or
+// UnmarshalLossless, so has details to restore when it's encoded.
+func (s Swagger) preserved() bool {
+	return s.KeyOrder != nil || s.Unknown != nil
+}
+
+// restoreJSON rewrites an encoded document with its keys in the recorded order
+// and its unknown fields added back.
+func (s Swagger) restoreJSON(data []byte) ([]byte, error) {
+	r := &restorer{order: s.KeyOrder, unknown: unknownByParent(s.Unknown)}
+	var buf bytes.Buffer
+	if err := r.writeJSON(&buf, "", data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// yamlDocument returns a document as nested rawdoc.MapSlice values, with its
+// recorded order and unknown fields restored.
+func (s Swagger) yamlDocument() (interface{}, error) {
+	data, err := s.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return yamlValue(data)
+}
+
+type restorer struct {
+	order KeyOrder
+	// unknown holds the unknown fields of each object, keyed by the
+	// object's pointer.
+	unknown map[string][]unknownField
+}
+
+func (r *restorer) writeJSON(buf *bytes.Buffer, pointer string, data []byte) error {
+	switch firstByte(data) {
+	case '{':
+		keys, vals, err := decodeObject(data)
+		if err != nil {
+			return err
+		}
+		for _, f := range r.unknown[pointer] {
+			if !contains(keys, f.name) {
+				keys = append(keys, f.name)
+				vals = append(vals, f.value)
+			}
+		}
+		buf.WriteByte('{')
+		for n, i := range r.order.sorted(pointer, keys) {
+			if n > 0 {
+				buf.WriteByte(',')
+			}
+			key, err := json.Marshal(keys[i])
+			if err != nil {
+				return err
+			}
+			buf.Write(key)
+			buf.WriteByte(':')
+			if err := r.writeJSON(buf, jsonpointer.Join(pointer, keys[i]), vals[i]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case '[':
+		var elems []json.RawMessage
+		if err := json.Unmarshal(data, &elems); err != nil {
+			return err
+		}
+		buf.WriteByte('[')
+		for i, elem := range elems {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := r.writeJSON(buf, jsonpointer.Join(pointer, strconv.Itoa(i)), elem); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	default:
+		buf.Write(bytes.TrimSpace(data))
+	}
+	return nil
+}
+
+// yamlValue converts encoded JSON to the values YAML encoders write, keeping
+// the order of object keys.
+func yamlValue(data []byte) (interface{}, error) {
+	switch firstByte(data) {
+	case '{':
+		keys, vals, err := decodeObject(data)
+		if err != nil {
+			return nil, err
+		}
+		m := make(rawdoc.MapSlice, len(keys))
+		for i, key := range keys {
+			val, err := yamlValue(vals[i])
+			if err != nil {
+				return nil, err
+			}
+			m[i] = rawdoc.MapItem{Key: key, Value: val}
+		}
+		return m, nil
+	case '[':
+		var elems []json.RawMessage
+		if err := json.Unmarshal(data, &elems); err != nil {
+			return nil, err
+		}
+		s := make([]interface{}, len(elems))
+		for i, elem := range elems {
+			val, err := yamlValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			s[i] = val
+		}
+		return s, nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// decodeObject returns the keys of an encoded JSON object, in order, and their
+// encoded values.
+func decodeObject(data []byte) ([]string, []json.RawMessage, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if _, err := dec.Token(); err != nil {
+		return nil, nil, err
+	}
+	var (
+		keys []string
+		vals []json.RawMessage
+	)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return nil, nil, fmt.Errorf("spec: expected an object key, got %v", tok)
+		}
+		var val json.RawMessage
+		if err := dec.Decode(&val); err != nil {
+			return nil, nil, err
+		}
+		keys = append(keys, key)
+		vals = append(vals, val)
+	}
+	return keys, vals, nil
+}
+
+func firstByte(data []byte) byte {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return 0
+	}
+	return data[0]
+}
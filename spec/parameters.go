@@ -0,0 +1,74 @@
+package spec
+
+// ValueSchema returns the schema a parameter's value must satisfy: a body
+// parameter's schema, or for other parameters, one built from the type,
+// format and validations they declare.
+func (p *Parameter) ValueSchema() *Schema {
+	if p.In == "body" {
+		return p.Schema
+	}
+	return &Schema{
+		Type:             p.Type,
+		Format:           p.Format,
+		Items:            p.Items.ValueSchema(),
+		Maximum:          p.Maximum,
+		ExclusiveMaximum: p.ExclusiveMaximum,
+		Minimum:          p.Minimum,
+		ExclusiveMinimum: p.ExclusiveMinimum,
+		MaxLength:        p.MaxLength,
+		MinLength:        p.MinLength,
+		Pattern:          p.Pattern,
+		MaxItems:         p.MaxItems,
+		MinItems:         p.MinItems,
+		UniqueItems:      p.UniqueItems,
+		Enum:             p.Enum,
+		MultipleOf:       p.MultipleOf,
+	}
+}
+
+// ValueSchema returns the schema a response header's value must satisfy, built
+// from the type, format and validations it declares.
+func (h *Header) ValueSchema() *Schema {
+	return &Schema{
+		Type:             h.Type,
+		Format:           h.Format,
+		Items:            h.Items.ValueSchema(),
+		Maximum:          h.Maximum,
+		ExclusiveMaximum: h.ExclusiveMaximum,
+		Minimum:          h.Minimum,
+		ExclusiveMinimum: h.ExclusiveMinimum,
+		MaxLength:        h.MaxLength,
+		MinLength:        h.MinLength,
+		Pattern:          h.Pattern,
+		MaxItems:         h.MaxItems,
+		MinItems:         h.MinItems,
+		UniqueItems:      h.UniqueItems,
+		Enum:             h.Enum,
+		MultipleOf:       h.MultipleOf,
+	}
+}
+
+// ValueSchema returns the schema the items of an array must satisfy, or nil
+// if t is nil.
+func (t *Items) ValueSchema() *Schema {
+	if t == nil {
+		return nil
+	}
+	return &Schema{
+		Type:             t.Type,
+		Format:           t.Format,
+		Items:            t.Items.ValueSchema(),
+		Maximum:          t.Maximum,
+		ExclusiveMaximum: t.ExclusiveMaximum,
+		Minimum:          t.Minimum,
+		ExclusiveMinimum: t.ExclusiveMinimum,
+		MaxLength:        t.MaxLength,
+		MinLength:        t.MinLength,
+		Pattern:          t.Pattern,
+		MaxItems:         t.MaxItems,
+		MinItems:         t.MinItems,
+		UniqueItems:      t.UniqueItems,
+		Enum:             t.Enum,
+		MultipleOf:       t.MultipleOf,
+	}
+}
@@ -0,0 +1,268 @@
+package spec
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/internal/jsonpointer"
+)
+
+// Lookup returns the value an RFC 6901 JSON pointer refers to within a document,
+// such as "/paths/~1pets/get/responses/200". Pointers may also be given as URI
+// fragments, like "#/definitions/Pet", as used by "$ref" values.
+//
+// The value is the one held by the field, map or list the pointer refers to:
+// "/paths/~1pets" returns a PathItem, "/paths/~1pets/get" returns an *Operation
+// and "/paths/~1pets/get/tags" returns a []string. Values which aren't pointers,
+// maps or slices are copies, so changes should be made with Set. Vendor
+// extensions are addressed like other fields.
+//
+// An error is returned if the pointer refers to a nil pointer, map or slice, a
+// missing map key or list index, or a field the type doesn't have.
+func Lookup(doc *Swagger, pointer string) (interface{}, error) {
+	tokens, err := splitPointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	v := reflect.ValueOf(doc)
+	for i, tok := range tokens {
+		if v, err = child(v, tok); err != nil {
+			return nil, fmt.Errorf("spec: %s: %v", jsonpointer.Join("", tokens[:i+1]...), err)
+		}
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		if v.IsNil() {
+			return nil, fmt.Errorf("spec: %s: not found", jsonpointer.Join("", tokens...))
+		}
+	}
+	return v.Interface(), nil
+}
+
+// Set replaces the value a JSON pointer refers to within a document, following
+// the same rules as Lookup. Nil pointers and maps along the way are allocated,
+// missing map keys are added and "-" appends to a list.
+//
+// The value may be of the type Lookup would return, a pointer to it or a value
+// of that type's element, such as a Schema for an *Schema field. Other values
+// are converted by encoding them as JSON and decoding the result into the
+// field's type, so a map[string]interface{} may set an *Operation. A nil value
+// sets the zero value.
+func Set(doc *Swagger, pointer string, value interface{}) error {
+	tokens, err := splitPointer(pointer)
+	if err != nil {
+		return err
+	}
+	if len(tokens) == 0 {
+		return assign(reflect.ValueOf(doc).Elem(), value)
+	}
+	return set(reflect.ValueOf(doc).Elem(), tokens, 0, value)
+}
+
+func splitPointer(pointer string) ([]string, error) {
+	if strings.HasPrefix(pointer, "#") {
+		p, err := url.PathUnescape(pointer)
+		if err != nil {
+			return nil, fmt.Errorf("spec: invalid JSON pointer %q: %v", pointer, err)
+		}
+		pointer = strings.TrimPrefix(p, "#")
+	}
+	if pointer != "" && !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("spec: invalid JSON pointer %q: must start with /", pointer)
+	}
+	if pointer == "" {
+		return nil, nil
+	}
+	return jsonpointer.Split(pointer), nil
+}
+
+// child returns the value a reference token refers to within v.
+func child(v reflect.Value, tok string) (reflect.Value, error) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}, fmt.Errorf("not found")
+		}
+		v = v.Elem()
+	}
+	if v.Type() == additionalPropertiesType {
+		// Only the schema form has children.
+		if v = v.FieldByName("Schema"); v.IsNil() {
+			return reflect.Value{}, fmt.Errorf("not found")
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		if f, ok := field(v.Type(), tok); ok {
+			return v.FieldByIndex(f.Index), nil
+		}
+		if ext := v.FieldByName("Extensions"); isExtension(tok) && ext.IsValid() {
+			return child(ext, tok)
+		}
+		return reflect.Value{}, fmt.Errorf("%s has no field %q", v.Type().Name(), tok)
+	case reflect.Map:
+		val := v.MapIndex(reflect.ValueOf(tok))
+		if !val.IsValid() {
+			return reflect.Value{}, fmt.Errorf("not found")
+		}
+		return val, nil
+	case reflect.Slice:
+		i, err := index(tok, v.Len())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if i == v.Len() {
+			return reflect.Value{}, fmt.Errorf("not found")
+		}
+		return v.Index(i), nil
+	}
+	return reflect.Value{}, fmt.Errorf("%s is not an object or array", v.Type())
+}
+
+// set assigns value to the element tokens refer to within v, which must be
+// settable. i is the index of the token being followed.
+func set(v reflect.Value, tokens []string, i int, value interface{}) error {
+	if i == len(tokens) {
+		if err := assign(v, value); err != nil {
+			return fmt.Errorf("spec: %s: %v", jsonpointer.Join("", tokens...), err)
+		}
+		return nil
+	}
+	tok := tokens[i]
+	errorf := func(format string, args ...interface{}) error {
+		return fmt.Errorf("spec: %s: %s", jsonpointer.Join("", tokens[:i+1]...), fmt.Sprintf(format, args...))
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return set(v.Elem(), tokens, i, value)
+	case reflect.Interface:
+		if v.IsNil() {
+			return fmt.Errorf("spec: %s: not found", jsonpointer.Join("", tokens[:i]...))
+		}
+		// The value held by an interface isn't settable, so update a copy.
+		elem := reflect.New(v.Elem().Type()).Elem()
+		elem.Set(v.Elem())
+		if err := set(elem, tokens, i, value); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	}
+	if v.Type() == additionalPropertiesType {
+		v.FieldByName("Allowed").SetBool(true)
+		return set(v.FieldByName("Schema"), tokens, i, value)
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		if f, ok := field(v.Type(), tok); ok {
+			return set(v.FieldByIndex(f.Index), tokens, i+1, value)
+		}
+		if ext := v.FieldByName("Extensions"); isExtension(tok) && ext.IsValid() {
+			return set(ext, tokens, i, value)
+		}
+		return errorf("%s has no field %q", v.Type().Name(), tok)
+	case reflect.Map:
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		key := reflect.ValueOf(tok)
+		// Map values aren't addressable, so update a copy.
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if val := v.MapIndex(key); val.IsValid() {
+			elem.Set(val)
+		}
+		if err := set(elem, tokens, i+1, value); err != nil {
+			return err
+		}
+		v.SetMapIndex(key, elem)
+		return nil
+	case reflect.Slice:
+		n, err := index(tok, v.Len())
+		if err != nil {
+			return errorf("%v", err)
+		}
+		if n == v.Len() {
+			if i != len(tokens)-1 {
+				return errorf("not found")
+			}
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := set(elem, tokens, i+1, value); err != nil {
+				return err
+			}
+			v.Set(reflect.Append(v, elem))
+			return nil
+		}
+		return set(v.Index(n), tokens, i+1, value)
+	}
+	return errorf("%s is not an object or array", v.Type())
+}
+
+// assign sets v to value, converting it to v's type if needed.
+func assign(v reflect.Value, value interface{}) error {
+	t := v.Type()
+	if value == nil {
+		v.Set(reflect.Zero(t))
+		return nil
+	}
+	rv := reflect.ValueOf(value)
+	switch {
+	case rv.Type().AssignableTo(t):
+		v.Set(rv)
+		return nil
+	case t.Kind() == reflect.Ptr && rv.Type().AssignableTo(t.Elem()):
+		p := reflect.New(t.Elem())
+		p.Elem().Set(rv)
+		v.Set(p)
+		return nil
+	case rv.Kind() == reflect.Ptr && !rv.IsNil() && rv.Elem().Type().AssignableTo(t):
+		v.Set(rv.Elem())
+		return nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	p := reflect.New(t)
+	if err := json.Unmarshal(data, p.Interface()); err != nil {
+		return fmt.Errorf("can't set %s to %T: %v", t, value, err)
+	}
+	v.Set(p.Elem())
+	return nil
+}
+
+// field returns the struct field with the given JSON name.
+func field(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if n := strings.Split(f.Tag.Get("json"), ",")[0]; n == name && n != "-" {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// index parses a reference token as an index into a list of length n. The
+// token "-" refers to the element after the last.
+func index(tok string, n int) (int, error) {
+	if tok == "-" {
+		return n, nil
+	}
+	i, err := strconv.Atoi(tok)
+	if err != nil || i < 0 || (tok != "0" && strings.HasPrefix(tok, "0")) {
+		return 0, fmt.Errorf("invalid array index %q", tok)
+	}
+	if i >= n {
+		return 0, fmt.Errorf("index %d out of range", i)
+	}
+	return i, nil
+}
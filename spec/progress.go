@@ -0,0 +1,7 @@
+package spec
+
+// ProgressFunc is called by long running operations, such as bundling, code
+// generation and validating many documents, each time a unit of work finishes.
+// done is the number of items completed out of total, and item names the one
+// that just finished.
+type ProgressFunc func(done, total int, item string)
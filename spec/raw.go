@@ -0,0 +1,172 @@
+package spec
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/internal/rawdoc"
+)
+
+// RawOptions selects the untyped values UnmarshalRaw keeps as encoded JSON.
+type RawOptions struct {
+	// Fields names the untyped fields whose values are kept, out of "default",
+	// "enum", "example" and "examples". Each value of an enum or of a response's
+	// examples is kept separately.
+	Fields []string
+	// Extensions keeps the values of vendor extensions.
+	Extensions bool
+}
+
+// UnmarshalRaw decodes a JSON or YAML document into v, which should be a pointer
+// to one of the types in this package, keeping every default, enum, example and
+// vendor extension value as a json.RawMessage rather than an interface{} tree.
+//
+// Raw values are written back unchanged by encoding/json, which preserves
+// details such as the precision of numbers and the order of keys, and hold
+// less memory than their decoded form. Tools which only pass these values
+// through should use it. Values read from YAML are converted to JSON first.
+func UnmarshalRaw(data []byte, v interface{}) error {
+	return RawOptions{
+		Fields:     []string{"default", "enum", "example", "examples"},
+		Extensions: true,
+	}.Unmarshal(data, v)
+}
+
+// Unmarshal decodes a JSON or YAML document into v, keeping the values selected
+// by the options as json.RawMessage.
+//
+// Documents holding raw defaults, enums or examples should be encoded as JSON
+// or with MarshalYAML, since YAML encoders write a json.RawMessage as a list of
+// bytes.
+func (o RawOptions) Unmarshal(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("spec: UnmarshalRaw requires a non-nil pointer, got %T", v)
+	}
+	if !rawdoc.IsJSON(data) {
+		doc, err := rawdoc.Decode(data)
+		if err != nil {
+			return err
+		}
+		if data, err = json.Marshal(doc); err != nil {
+			return err
+		}
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return err
+	}
+	fields := make(map[string]bool)
+	for _, name := range o.Fields {
+		fields[name] = true
+	}
+	keepRaw(rv.Elem(), data, fields, o.Extensions)
+	return nil
+}
+
+// keepRaw walks a decoded value alongside the JSON it was decoded from,
+// replacing the selected values with their encoded form. Like unknownFields,
+// JSON of the wrong shape is ignored, since the decoder has already accepted it.
+func keepRaw(v reflect.Value, data []byte, fields map[string]bool, extensions bool) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Type() == additionalPropertiesType {
+		keepRaw(v.FieldByName("Schema"), data, fields, extensions)
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		var obj map[string]json.RawMessage
+		if json.Unmarshal(data, &obj) != nil {
+			return
+		}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name := strings.Split(f.Tag.Get("json"), ",")[0]
+			switch {
+			case f.Name == "Extensions" && name == "-":
+				if !extensions {
+					continue
+				}
+				ext := v.Field(i)
+				for key, val := range obj {
+					if isExtension(key) && !ext.IsNil() {
+						ext.SetMapIndex(reflect.ValueOf(key), reflect.ValueOf(val))
+					}
+				}
+			case name == "" || name == "-":
+			default:
+				val, ok := obj[name]
+				if !ok {
+					continue
+				}
+				if fields[name] {
+					setRaw(v.Field(i), val)
+				} else {
+					keepRaw(v.Field(i), val, fields, extensions)
+				}
+			}
+		}
+	case reflect.Map:
+		var obj map[string]json.RawMessage
+		if v.IsNil() || json.Unmarshal(data, &obj) != nil {
+			return
+		}
+		for key, val := range obj {
+			k := reflect.ValueOf(key)
+			elem := v.MapIndex(k)
+			if !elem.IsValid() {
+				continue
+			}
+			// Map values aren't addressable, so update a copy.
+			e := reflect.New(v.Type().Elem()).Elem()
+			e.Set(elem)
+			keepRaw(e, val, fields, extensions)
+			v.SetMapIndex(k, e)
+		}
+	case reflect.Slice:
+		var arr []json.RawMessage
+		if json.Unmarshal(data, &arr) != nil {
+			return
+		}
+		for i := 0; i < len(arr) && i < v.Len(); i++ {
+			keepRaw(v.Index(i), arr[i], fields, extensions)
+		}
+	}
+}
+
+// setRaw stores the encoded form of an untyped field: a single value, a list
+// of values or a map of them.
+func setRaw(v reflect.Value, data json.RawMessage) {
+	switch t := v.Type(); {
+	case t.Kind() == reflect.Interface:
+		v.Set(reflect.ValueOf(data))
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Interface:
+		var arr []json.RawMessage
+		if json.Unmarshal(data, &arr) != nil {
+			return
+		}
+		raw := reflect.MakeSlice(t, len(arr), len(arr))
+		for i, val := range arr {
+			raw.Index(i).Set(reflect.ValueOf(val))
+		}
+		v.Set(raw)
+	case t.Kind() == reflect.Map && t.Elem().Kind() == reflect.Interface:
+		var obj map[string]json.RawMessage
+		if json.Unmarshal(data, &obj) != nil {
+			return
+		}
+		raw := reflect.MakeMap(t)
+		for key, val := range obj {
+			raw.SetMapIndex(reflect.ValueOf(key), reflect.ValueOf(val))
+		}
+		v.Set(raw)
+	}
+}
@@ -2,6 +2,8 @@
 
 package spec
 
+import "encoding/json"
+
 // This is the root document object for the API specification. It combines what
 // previously was the Resource Listing and API Declaration (version 1.2 and earlier)
 // together into one document.
@@ -55,6 +57,18 @@ type Swagger struct {
 	Tags []Tag `json:"tags,omitempty" yaml:"tags,omitempty"`
 	// Additional external documentation.
 	ExternalDocs *ExternalDocumentation `json:"externalDocs,omitempty" yaml:"externalDocs,omitempty"`
+	// Vendor extensions, fields whose names begin with "x-". The values are
+	// those decoded by encoding/json.
+	Extensions map[string]interface{} `json:"-" yaml:"-"`
+	// The order of the keys of the document's objects, recorded by
+	// UnmarshalOrdered. If set, the document is encoded in that order.
+	KeyOrder KeyOrder `json:"-" yaml:"-"`
+	// Fields which aren't part of the specification, keyed by their JSON
+	// pointers, recorded by UnmarshalLossless. They're written back when
+	// the document is encoded.
+	Unknown map[string]json.RawMessage `json:"-" yaml:"-"`
+	// The index of OperationByID, built when it's first called.
+	operations *operationIndex
 }
 
 // The object provides metadata about the API. The metadata can be used by the clients
@@ -73,6 +87,9 @@ type Info struct {
 	// Required Provides the version of the application API (not to be confused with
 	// the specification version).
 	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+	// Vendor extensions, fields whose names begin with "x-". The values are
+	// those decoded by encoding/json.
+	Extensions map[string]interface{} `json:"-" yaml:"-"`
 }
 
 // Contact information for the exposed API.
@@ -84,6 +101,9 @@ type Contact struct {
 	// The email address of the contact person/organization. MUST be in the format of
 	// an email address.
 	Email string `json:"email,omitempty" yaml:"email,omitempty"`
+	// Vendor extensions, fields whose names begin with "x-". The values are
+	// those decoded by encoding/json.
+	Extensions map[string]interface{} `json:"-" yaml:"-"`
 }
 
 // License information for the exposed API.
@@ -92,6 +112,9 @@ type License struct {
 	Name string `json:"name" yaml:"name"`
 	// A URL to the license used for the API. MUST be in the format of a URL.
 	Url string `json:"url,omitempty" yaml:"url,omitempty"`
+	// Vendor extensions, fields whose names begin with "x-". The values are
+	// those decoded by encoding/json.
+	Extensions map[string]interface{} `json:"-" yaml:"-"`
 }
 
 // Describes the operations available on a single path. A Path Item may be empty, due to
@@ -123,6 +146,9 @@ type PathItem struct {
 	// the Reference Object to link to parameters that are defined at the Swagger
 	// Object's parameters. There can be one "body" parameter at most.
 	Parameters []Parameter `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	// Vendor extensions, fields whose names begin with "x-". The values are
+	// those decoded by encoding/json.
+	Extensions map[string]interface{} `json:"-" yaml:"-"`
 }
 
 // Describes a single API operation on a path.
@@ -172,6 +198,9 @@ type Operation struct {
 	// overrides any declared top-level security. To remove a top-level security
 	// declaration, an empty array can be used.
 	Security []SecurityRequirement `json:"security,omitempty" yaml:"security,omitempty"`
+	// Vendor extensions, fields whose names begin with "x-". The values are
+	// those decoded by encoding/json.
+	Extensions map[string]interface{} `json:"-" yaml:"-"`
 }
 
 // Allows referencing an external resource for extended documentation.
@@ -181,6 +210,9 @@ type ExternalDocumentation struct {
 	Description string `json:"description,omitempty" yaml:"description,omitempty"`
 	// The URL for the target documentation. Value MUST be in the format of a URL.
 	Url string `json:"url" yaml:"url"`
+	// Vendor extensions, fields whose names begin with "x-". The values are
+	// those decoded by encoding/json.
+	Extensions map[string]interface{} `json:"-" yaml:"-"`
 }
 
 // Describes a single operation parameter.
@@ -189,6 +221,9 @@ type ExternalDocumentation struct {
 //
 // There are five possible parameter types.
 type Parameter struct {
+	// A JSON Reference to a Parameter Object defined elsewhere, such as in the top
+	// level parameters. If set, the remaining fields are unused.
+	Ref string `json:"$ref,omitempty" yaml:"$ref,omitempty"`
 	// The name of the parameter. Parameter names are case sensitive. If in is "path",
 	// the name field MUST correspond to the associated path segment from the path
 	// field in the Paths Object. See Path Templating for further information.For all
@@ -204,6 +239,64 @@ type Parameter struct {
 	// this property is required and its value MUST be true. Otherwise, the property
 	// MAY be included and its default value is false.
 	Required bool `json:"required,omitempty" yaml:"required,omitempty"`
+	// The schema defining the type used for the body parameter.
+	Schema *Schema `json:"schema,omitempty" yaml:"schema,omitempty"`
+	// The type of the parameter. Since the parameter is not located at the request
+	// body, it is limited to simple types (that is, not an object). The value MUST be
+	// one of "string", "number", "integer", "boolean", "array" or "file". If type is
+	// "file", the consumes MUST be either "multipart/form-data", "
+	// application/x-www-form-urlencoded" or both and the parameter MUST be in"formData".
+	Type string `json:"type,omitempty" yaml:"type,omitempty"`
+	// The extending format for the previously mentioned type. See Data Type Formats
+	// for further details.
+	Format string `json:"format,omitempty" yaml:"format,omitempty"`
+	// Sets the ability to pass empty-valued parameters. This is valid only for either
+	// query or formData parameters and allows you to send a parameter with a name only
+	// or  an empty value. Default value is false.
+	AllowEmptyValue bool `json:"allowEmptyValue,omitempty" yaml:"allowEmptyValue,omitempty"`
+	// Required if type is "array". Describes the type of items in the array.
+	Items *Items `json:"items,omitempty" yaml:"items,omitempty"`
+	// Determines the format of the array if type array is used. Possible values are:
+	// csv - comma separated values foo,bar. ssv - space separated values foo bar. tsv
+	// - tab separated values foo\tbar. pipes - pipe separated values foo|bar. multi -
+	// corresponds to multiple parameter instances instead of multiple values for a
+	// single instance foo=bar&foo=baz. This is valid only for parameters in "query" or
+	// "formData".  Default value is csv.
+	CollectionFormat string `json:"collectionFormat,omitempty" yaml:"collectionFormat,omitempty"`
+	// Declares the value of the parameter that the server will use if none is
+	// provided, for example a "count" to control the number of results per page might
+	// default to 100 if not supplied by the client in the request. (Note: "default"
+	// has no meaning for required parameters.)  See
+	// http://json-schema.org/latest/json-schema-validation.html#anchor101. Unlike JSON
+	// Schema this value MUST conform to the defined type for this parameter.
+	Default interface{} `json:"default,omitempty" yaml:"default,omitempty"`
+	// See http://json-schema.org/latest/json-schema-validation.html#anchor17.
+	Maximum *float64 `json:"maximum,omitempty" yaml:"maximum,omitempty"`
+	// See http://json-schema.org/latest/json-schema-validation.html#anchor17.
+	ExclusiveMaximum bool `json:"exclusiveMaximum,omitempty" yaml:"exclusiveMaximum,omitempty"`
+	// See http://json-schema.org/latest/json-schema-validation.html#anchor21.
+	Minimum *float64 `json:"minimum,omitempty" yaml:"minimum,omitempty"`
+	// See http://json-schema.org/latest/json-schema-validation.html#anchor21.
+	ExclusiveMinimum bool `json:"exclusiveMinimum,omitempty" yaml:"exclusiveMinimum,omitempty"`
+	// See http://json-schema.org/latest/json-schema-validation.html#anchor26.
+	MaxLength *int `json:"maxLength,omitempty" yaml:"maxLength,omitempty"`
+	// See http://json-schema.org/latest/json-schema-validation.html#anchor29.
+	MinLength int `json:"minLength,omitempty" yaml:"minLength,omitempty"`
+	// See http://json-schema.org/latest/json-schema-validation.html#anchor33.
+	Pattern string `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	// See http://json-schema.org/latest/json-schema-validation.html#anchor42.
+	MaxItems *int `json:"maxItems,omitempty" yaml:"maxItems,omitempty"`
+	// See http://json-schema.org/latest/json-schema-validation.html#anchor45.
+	MinItems int `json:"minItems,omitempty" yaml:"minItems,omitempty"`
+	// See http://json-schema.org/latest/json-schema-validation.html#anchor49.
+	UniqueItems bool `json:"uniqueItems,omitempty" yaml:"uniqueItems,omitempty"`
+	// See http://json-schema.org/latest/json-schema-validation.html#anchor76.
+	Enum []interface{} `json:"enum,omitempty" yaml:"enum,omitempty"`
+	// See http://json-schema.org/latest/json-schema-validation.html#anchor14.
+	MultipleOf float64 `json:"multipleOf,omitempty" yaml:"multipleOf,omitempty"`
+	// Vendor extensions, fields whose names begin with "x-". The values are
+	// those decoded by encoding/json.
+	Extensions map[string]interface{} `json:"-" yaml:"-"`
 }
 
 // A limited subset of JSON-Schema's items object. It is used by parameter definitions
@@ -228,21 +321,21 @@ type Items struct {
 	// Schema this value MUST conform to the defined type for the data type.
 	Default interface{} `json:"default,omitempty" yaml:"default,omitempty"`
 	// See http://json-schema.org/latest/json-schema-validation.html#anchor17.
-	Maximum float64 `json:"maximum,omitempty" yaml:"maximum,omitempty"`
+	Maximum *float64 `json:"maximum,omitempty" yaml:"maximum,omitempty"`
 	// See http://json-schema.org/latest/json-schema-validation.html#anchor17.
 	ExclusiveMaximum bool `json:"exclusiveMaximum,omitempty" yaml:"exclusiveMaximum,omitempty"`
 	// See http://json-schema.org/latest/json-schema-validation.html#anchor21.
-	Minimum float64 `json:"minimum,omitempty" yaml:"minimum,omitempty"`
+	Minimum *float64 `json:"minimum,omitempty" yaml:"minimum,omitempty"`
 	// See http://json-schema.org/latest/json-schema-validation.html#anchor21.
 	ExclusiveMinimum bool `json:"exclusiveMinimum,omitempty" yaml:"exclusiveMinimum,omitempty"`
 	// See http://json-schema.org/latest/json-schema-validation.html#anchor26.
-	MaxLength int `json:"maxLength,omitempty" yaml:"maxLength,omitempty"`
+	MaxLength *int `json:"maxLength,omitempty" yaml:"maxLength,omitempty"`
 	// See http://json-schema.org/latest/json-schema-validation.html#anchor29.
 	MinLength int `json:"minLength,omitempty" yaml:"minLength,omitempty"`
 	// See http://json-schema.org/latest/json-schema-validation.html#anchor33.
 	Pattern string `json:"pattern,omitempty" yaml:"pattern,omitempty"`
 	// See http://json-schema.org/latest/json-schema-validation.html#anchor42.
-	MaxItems int `json:"maxItems,omitempty" yaml:"maxItems,omitempty"`
+	MaxItems *int `json:"maxItems,omitempty" yaml:"maxItems,omitempty"`
 	// See http://json-schema.org/latest/json-schema-validation.html#anchor45.
 	MinItems int `json:"minItems,omitempty" yaml:"minItems,omitempty"`
 	// See http://json-schema.org/latest/json-schema-validation.html#anchor49.
@@ -251,10 +344,16 @@ type Items struct {
 	Enum []interface{} `json:"enum,omitempty" yaml:"enum,omitempty"`
 	// See http://json-schema.org/latest/json-schema-validation.html#anchor14.
 	MultipleOf float64 `json:"multipleOf,omitempty" yaml:"multipleOf,omitempty"`
+	// Vendor extensions, fields whose names begin with "x-". The values are
+	// those decoded by encoding/json.
+	Extensions map[string]interface{} `json:"-" yaml:"-"`
 }
 
 // Describes a single response from an API Operation.
 type Response struct {
+	// A JSON Reference to a Response Object defined elsewhere, such as in the top
+	// level responses. If set, the remaining fields are unused.
+	Ref string `json:"$ref,omitempty" yaml:"$ref,omitempty"`
 	// A short description of the response. GFM syntax can be used for rich text representation.
 	Description string `json:"description" yaml:"description"`
 	// A definition of the response structure. It can be a primitive, an array or an
@@ -266,9 +365,11 @@ type Response struct {
 	Headers Headers `json:"headers,omitempty" yaml:"headers,omitempty"`
 	// An example of the response message.
 	Examples Example `json:"examples,omitempty" yaml:"examples,omitempty"`
+	// Vendor extensions, fields whose names begin with "x-". The values are
+	// those decoded by encoding/json.
+	Extensions map[string]interface{} `json:"-" yaml:"-"`
 }
 
-
 type Header struct {
 	// A short description of the header.
 	Description string `json:"description,omitempty" yaml:"description,omitempty"`
@@ -291,21 +392,21 @@ type Header struct {
 	// Schema this value MUST conform to the defined type for the header.
 	Default interface{} `json:"default,omitempty" yaml:"default,omitempty"`
 	// See http://json-schema.org/latest/json-schema-validation.html#anchor17.
-	Maximum float64 `json:"maximum,omitempty" yaml:"maximum,omitempty"`
+	Maximum *float64 `json:"maximum,omitempty" yaml:"maximum,omitempty"`
 	// See http://json-schema.org/latest/json-schema-validation.html#anchor17.
 	ExclusiveMaximum bool `json:"exclusiveMaximum,omitempty" yaml:"exclusiveMaximum,omitempty"`
 	// See http://json-schema.org/latest/json-schema-validation.html#anchor21.
-	Minimum float64 `json:"minimum,omitempty" yaml:"minimum,omitempty"`
+	Minimum *float64 `json:"minimum,omitempty" yaml:"minimum,omitempty"`
 	// See http://json-schema.org/latest/json-schema-validation.html#anchor21.
 	ExclusiveMinimum bool `json:"exclusiveMinimum,omitempty" yaml:"exclusiveMinimum,omitempty"`
 	// See http://json-schema.org/latest/json-schema-validation.html#anchor26.
-	MaxLength int `json:"maxLength,omitempty" yaml:"maxLength,omitempty"`
+	MaxLength *int `json:"maxLength,omitempty" yaml:"maxLength,omitempty"`
 	// See http://json-schema.org/latest/json-schema-validation.html#anchor29.
 	MinLength int `json:"minLength,omitempty" yaml:"minLength,omitempty"`
 	// See http://json-schema.org/latest/json-schema-validation.html#anchor33.
 	Pattern string `json:"pattern,omitempty" yaml:"pattern,omitempty"`
 	// See http://json-schema.org/latest/json-schema-validation.html#anchor42.
-	MaxItems int `json:"maxItems,omitempty" yaml:"maxItems,omitempty"`
+	MaxItems *int `json:"maxItems,omitempty" yaml:"maxItems,omitempty"`
 	// See http://json-schema.org/latest/json-schema-validation.html#anchor45.
 	MinItems int `json:"minItems,omitempty" yaml:"minItems,omitempty"`
 	// See http://json-schema.org/latest/json-schema-validation.html#anchor49.
@@ -314,6 +415,9 @@ type Header struct {
 	Enum []interface{} `json:"enum,omitempty" yaml:"enum,omitempty"`
 	// See http://json-schema.org/latest/json-schema-validation.html#anchor14.
 	MultipleOf float64 `json:"multipleOf,omitempty" yaml:"multipleOf,omitempty"`
+	// Vendor extensions, fields whose names begin with "x-". The values are
+	// those decoded by encoding/json.
+	Extensions map[string]interface{} `json:"-" yaml:"-"`
 }
 
 // Allows adding meta data to a single tag that is used by the Operation Object. It is
@@ -325,6 +429,9 @@ type Tag struct {
 	Description string `json:"description,omitempty" yaml:"description,omitempty"`
 	// Additional external documentation for this tag.
 	ExternalDocs *ExternalDocumentation `json:"externalDocs,omitempty" yaml:"externalDocs,omitempty"`
+	// Vendor extensions, fields whose names begin with "x-". The values are
+	// those decoded by encoding/json.
+	Extensions map[string]interface{} `json:"-" yaml:"-"`
 }
 
 // A simple object to allow referencing other definitions in the specification. It can
@@ -350,6 +457,56 @@ type Reference struct {
 // The following properties are taken directly from the JSON Schema definition and
 // follow the same specifications:
 type Schema struct {
+	// As a JSON Reference.
+	Ref string `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	// See Data Type Formats for further details.
+	Format string `json:"format,omitempty" yaml:"format,omitempty"`
+	// Follows the JSON Schema definition.
+	Title string `json:"title,omitempty" yaml:"title,omitempty"`
+	// GFM syntax can be used for rich text representation.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// Unlike JSON Schema, the value MUST conform to the defined type for the Schema Object.
+	Default interface{} `json:"default,omitempty" yaml:"default,omitempty"`
+	// Follows the JSON Schema definition.
+	MultipleOf float64 `json:"multipleOf,omitempty" yaml:"multipleOf,omitempty"`
+	// Follows the JSON Schema definition.
+	Maximum *float64 `json:"maximum,omitempty" yaml:"maximum,omitempty"`
+	// Follows the JSON Schema definition.
+	ExclusiveMaximum bool `json:"exclusiveMaximum,omitempty" yaml:"exclusiveMaximum,omitempty"`
+	// Follows the JSON Schema definition.
+	Minimum *float64 `json:"minimum,omitempty" yaml:"minimum,omitempty"`
+	// Follows the JSON Schema definition.
+	ExclusiveMinimum bool `json:"exclusiveMinimum,omitempty" yaml:"exclusiveMinimum,omitempty"`
+	// Follows the JSON Schema definition.
+	MaxLength *int `json:"maxLength,omitempty" yaml:"maxLength,omitempty"`
+	// Follows the JSON Schema definition.
+	MinLength int `json:"minLength,omitempty" yaml:"minLength,omitempty"`
+	// Follows the JSON Schema definition.
+	Pattern string `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	// Follows the JSON Schema definition.
+	MaxItems *int `json:"maxItems,omitempty" yaml:"maxItems,omitempty"`
+	// Follows the JSON Schema definition.
+	MinItems int `json:"minItems,omitempty" yaml:"minItems,omitempty"`
+	// Follows the JSON Schema definition.
+	UniqueItems bool `json:"uniqueItems,omitempty" yaml:"uniqueItems,omitempty"`
+	// Follows the JSON Schema definition.
+	MaxProperties *int `json:"maxProperties,omitempty" yaml:"maxProperties,omitempty"`
+	// Follows the JSON Schema definition.
+	MinProperties int `json:"minProperties,omitempty" yaml:"minProperties,omitempty"`
+	// Follows the JSON Schema definition.
+	Required []string `json:"required,omitempty" yaml:"required,omitempty"`
+	// Follows the JSON Schema definition.
+	Enum []interface{} `json:"enum,omitempty" yaml:"enum,omitempty"`
+	// Follows the JSON Schema definition.
+	Type string `json:"type,omitempty" yaml:"type,omitempty"`
+	// Follows the JSON Schema definition, using the Schema Object in place of JSON Schema.
+	Items *Schema `json:"items,omitempty" yaml:"items,omitempty"`
+	// Follows the JSON Schema definition, using the Schema Object in place of JSON Schema.
+	AllOf []Schema `json:"allOf,omitempty" yaml:"allOf,omitempty"`
+	// Follows the JSON Schema definition, using the Schema Object in place of JSON Schema.
+	Properties map[string]Schema `json:"properties,omitempty" yaml:"properties,omitempty"`
+	// Follows the JSON Schema definition, using the Schema Object in place of JSON Schema.
+	AdditionalProperties *AdditionalProperties `json:"additionalProperties,omitempty" yaml:"additionalProperties,omitempty"`
 	// Adds support for polymorphism. The discriminator is the schema property name
 	// that is used to differentiate between other schema that inherit this schema. The
 	// property name used MUST be defined at this schema and it MUST be in the required
@@ -368,6 +525,9 @@ type Schema struct {
 	ExternalDocs *ExternalDocumentation `json:"externalDocs,omitempty" yaml:"externalDocs,omitempty"`
 	// A free-form property to include a an example of an instance for this schema.
 	Example interface{} `json:"example,omitempty" yaml:"example,omitempty"`
+	// Vendor extensions, fields whose names begin with "x-". The values are
+	// those decoded by encoding/json.
+	Extensions map[string]interface{} `json:"-" yaml:"-"`
 }
 
 // A metadata object that allows for more fine-tuned XML model definitions.
@@ -393,6 +553,9 @@ type XML struct {
 	// Default value is false. The definition takes effect only when defined alongside
 	// type being array (outside the items).
 	Wrapped bool `json:"wrapped,omitempty" yaml:"wrapped,omitempty"`
+	// Vendor extensions, fields whose names begin with "x-". The values are
+	// those decoded by encoding/json.
+	Extensions map[string]interface{} `json:"-" yaml:"-"`
 }
 
 // Allows the definition of a security scheme that can be used by the operations.
@@ -418,6 +581,9 @@ type SecurityScheme struct {
 	TokenUrl string `json:"tokenUrl" yaml:"tokenUrl"`
 	// The available scopes for the OAuth2 security scheme.
 	Scopes Scopes `json:"scopes" yaml:"scopes"`
+	// Vendor extensions, fields whose names begin with "x-". The values are
+	// those decoded by encoding/json.
+	Extensions map[string]interface{} `json:"-" yaml:"-"`
 }
 
 // An object to hold data types that can be consumed and produced by operations. These
@@ -474,3 +640,448 @@ type SecurityRequirement map[string][]string
 
 // Lists the headers that can be sent as part of a response.
 type Headers map[string]Header
+
+// MarshalJSON implements json.Marshaler.
+func (s Swagger) MarshalJSON() ([]byte, error) {
+	type swagger Swagger
+	data, err := marshalJSON(swagger(s), s.Extensions)
+	if err != nil || !s.preserved() {
+		return data, err
+	}
+	return s.restoreJSON(data)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *Swagger) UnmarshalJSON(b []byte) error {
+	type swagger Swagger
+	var err error
+	s.Extensions, err = unmarshalJSON(b, (*swagger)(s))
+	return err
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (s Swagger) MarshalYAML() (interface{}, error) {
+	if s.preserved() {
+		return s.yamlDocument()
+	}
+	type swagger Swagger
+	return marshalYAML(swagger(s), s.Extensions)
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (s *Swagger) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type swagger Swagger
+	var err error
+	s.Extensions, err = unmarshalYAML(unmarshal, (*swagger)(s))
+	return err
+}
+
+// MarshalJSON implements json.Marshaler.
+func (i Info) MarshalJSON() ([]byte, error) {
+	type info Info
+	return marshalJSON(info(i), i.Extensions)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (i *Info) UnmarshalJSON(b []byte) error {
+	type info Info
+	var err error
+	i.Extensions, err = unmarshalJSON(b, (*info)(i))
+	return err
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (i Info) MarshalYAML() (interface{}, error) {
+	type info Info
+	return marshalYAML(info(i), i.Extensions)
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (i *Info) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type info Info
+	var err error
+	i.Extensions, err = unmarshalYAML(unmarshal, (*info)(i))
+	return err
+}
+
+// MarshalJSON implements json.Marshaler.
+func (c Contact) MarshalJSON() ([]byte, error) {
+	type contact Contact
+	return marshalJSON(contact(c), c.Extensions)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (c *Contact) UnmarshalJSON(b []byte) error {
+	type contact Contact
+	var err error
+	c.Extensions, err = unmarshalJSON(b, (*contact)(c))
+	return err
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (c Contact) MarshalYAML() (interface{}, error) {
+	type contact Contact
+	return marshalYAML(contact(c), c.Extensions)
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (c *Contact) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type contact Contact
+	var err error
+	c.Extensions, err = unmarshalYAML(unmarshal, (*contact)(c))
+	return err
+}
+
+// MarshalJSON implements json.Marshaler.
+func (l License) MarshalJSON() ([]byte, error) {
+	type license License
+	return marshalJSON(license(l), l.Extensions)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (l *License) UnmarshalJSON(b []byte) error {
+	type license License
+	var err error
+	l.Extensions, err = unmarshalJSON(b, (*license)(l))
+	return err
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (l License) MarshalYAML() (interface{}, error) {
+	type license License
+	return marshalYAML(license(l), l.Extensions)
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (l *License) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type license License
+	var err error
+	l.Extensions, err = unmarshalYAML(unmarshal, (*license)(l))
+	return err
+}
+
+// MarshalJSON implements json.Marshaler.
+func (p PathItem) MarshalJSON() ([]byte, error) {
+	type pathItem PathItem
+	return marshalJSON(pathItem(p), p.Extensions)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (p *PathItem) UnmarshalJSON(b []byte) error {
+	type pathItem PathItem
+	var err error
+	p.Extensions, err = unmarshalJSON(b, (*pathItem)(p))
+	return err
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (p PathItem) MarshalYAML() (interface{}, error) {
+	type pathItem PathItem
+	return marshalYAML(pathItem(p), p.Extensions)
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (p *PathItem) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type pathItem PathItem
+	var err error
+	p.Extensions, err = unmarshalYAML(unmarshal, (*pathItem)(p))
+	return err
+}
+
+// MarshalJSON implements json.Marshaler.
+func (o Operation) MarshalJSON() ([]byte, error) {
+	type operation Operation
+	if o.unsecured() {
+		return marshalUnsecuredJSON(operation(o), o.Extensions)
+	}
+	return marshalJSON(operation(o), o.Extensions)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (o *Operation) UnmarshalJSON(b []byte) error {
+	type operation Operation
+	var err error
+	o.Extensions, err = unmarshalJSON(b, (*operation)(o))
+	return err
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (o Operation) MarshalYAML() (interface{}, error) {
+	type operation Operation
+	if o.unsecured() {
+		return marshalUnsecuredYAML(operation(o), o.Extensions)
+	}
+	return marshalYAML(operation(o), o.Extensions)
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (o *Operation) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type operation Operation
+	var err error
+	o.Extensions, err = unmarshalYAML(unmarshal, (*operation)(o))
+	return err
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e ExternalDocumentation) MarshalJSON() ([]byte, error) {
+	type externalDocumentation ExternalDocumentation
+	return marshalJSON(externalDocumentation(e), e.Extensions)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (e *ExternalDocumentation) UnmarshalJSON(b []byte) error {
+	type externalDocumentation ExternalDocumentation
+	var err error
+	e.Extensions, err = unmarshalJSON(b, (*externalDocumentation)(e))
+	return err
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (e ExternalDocumentation) MarshalYAML() (interface{}, error) {
+	type externalDocumentation ExternalDocumentation
+	return marshalYAML(externalDocumentation(e), e.Extensions)
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (e *ExternalDocumentation) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type externalDocumentation ExternalDocumentation
+	var err error
+	e.Extensions, err = unmarshalYAML(unmarshal, (*externalDocumentation)(e))
+	return err
+}
+
+// MarshalJSON implements json.Marshaler.
+func (p Parameter) MarshalJSON() ([]byte, error) {
+	if p.Ref != "" {
+		return marshalJSON(Reference{Ref: p.Ref}, nil)
+	}
+	type parameter Parameter
+	return marshalJSON(parameter(p), p.Extensions)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (p *Parameter) UnmarshalJSON(b []byte) error {
+	type parameter Parameter
+	var err error
+	p.Extensions, err = unmarshalJSON(b, (*parameter)(p))
+	return err
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (p Parameter) MarshalYAML() (interface{}, error) {
+	if p.Ref != "" {
+		return Reference{Ref: p.Ref}, nil
+	}
+	type parameter Parameter
+	return marshalYAML(parameter(p), p.Extensions)
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (p *Parameter) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type parameter Parameter
+	var err error
+	p.Extensions, err = unmarshalYAML(unmarshal, (*parameter)(p))
+	return err
+}
+
+// MarshalJSON implements json.Marshaler.
+func (i Items) MarshalJSON() ([]byte, error) {
+	type items Items
+	return marshalJSON(items(i), i.Extensions)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (i *Items) UnmarshalJSON(b []byte) error {
+	type items Items
+	var err error
+	i.Extensions, err = unmarshalJSON(b, (*items)(i))
+	return err
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (i Items) MarshalYAML() (interface{}, error) {
+	type items Items
+	return marshalYAML(items(i), i.Extensions)
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (i *Items) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type items Items
+	var err error
+	i.Extensions, err = unmarshalYAML(unmarshal, (*items)(i))
+	return err
+}
+
+// MarshalJSON implements json.Marshaler.
+func (r Response) MarshalJSON() ([]byte, error) {
+	if r.Ref != "" {
+		return marshalJSON(Reference{Ref: r.Ref}, nil)
+	}
+	type response Response
+	return marshalJSON(response(r), r.Extensions)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (r *Response) UnmarshalJSON(b []byte) error {
+	type response Response
+	var err error
+	r.Extensions, err = unmarshalJSON(b, (*response)(r))
+	return err
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (r Response) MarshalYAML() (interface{}, error) {
+	if r.Ref != "" {
+		return Reference{Ref: r.Ref}, nil
+	}
+	type response Response
+	return marshalYAML(response(r), r.Extensions)
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (r *Response) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type response Response
+	var err error
+	r.Extensions, err = unmarshalYAML(unmarshal, (*response)(r))
+	return err
+}
+
+// MarshalJSON implements json.Marshaler.
+func (h Header) MarshalJSON() ([]byte, error) {
+	type header Header
+	return marshalJSON(header(h), h.Extensions)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (h *Header) UnmarshalJSON(b []byte) error {
+	type header Header
+	var err error
+	h.Extensions, err = unmarshalJSON(b, (*header)(h))
+	return err
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (h Header) MarshalYAML() (interface{}, error) {
+	type header Header
+	return marshalYAML(header(h), h.Extensions)
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (h *Header) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type header Header
+	var err error
+	h.Extensions, err = unmarshalYAML(unmarshal, (*header)(h))
+	return err
+}
+
+// MarshalJSON implements json.Marshaler.
+func (t Tag) MarshalJSON() ([]byte, error) {
+	type tag Tag
+	return marshalJSON(tag(t), t.Extensions)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (t *Tag) UnmarshalJSON(b []byte) error {
+	type tag Tag
+	var err error
+	t.Extensions, err = unmarshalJSON(b, (*tag)(t))
+	return err
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (t Tag) MarshalYAML() (interface{}, error) {
+	type tag Tag
+	return marshalYAML(tag(t), t.Extensions)
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (t *Tag) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type tag Tag
+	var err error
+	t.Extensions, err = unmarshalYAML(unmarshal, (*tag)(t))
+	return err
+}
+
+// MarshalJSON implements json.Marshaler.
+func (s Schema) MarshalJSON() ([]byte, error) {
+	type schema Schema
+	return marshalJSON(schema(s), s.Extensions)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *Schema) UnmarshalJSON(b []byte) error {
+	type schema Schema
+	var err error
+	s.Extensions, err = unmarshalJSON(b, (*schema)(s))
+	return err
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (s Schema) MarshalYAML() (interface{}, error) {
+	type schema Schema
+	return marshalYAML(schema(s), s.Extensions)
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (s *Schema) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type schema Schema
+	var err error
+	s.Extensions, err = unmarshalYAML(unmarshal, (*schema)(s))
+	return err
+}
+
+// MarshalJSON implements json.Marshaler.
+func (x XML) MarshalJSON() ([]byte, error) {
+	type xml XML
+	return marshalJSON(xml(x), x.Extensions)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (x *XML) UnmarshalJSON(b []byte) error {
+	type xml XML
+	var err error
+	x.Extensions, err = unmarshalJSON(b, (*xml)(x))
+	return err
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (x XML) MarshalYAML() (interface{}, error) {
+	type xml XML
+	return marshalYAML(xml(x), x.Extensions)
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (x *XML) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type xml XML
+	var err error
+	x.Extensions, err = unmarshalYAML(unmarshal, (*xml)(x))
+	return err
+}
+
+// MarshalJSON implements json.Marshaler.
+func (s SecurityScheme) MarshalJSON() ([]byte, error) {
+	type securityScheme SecurityScheme
+	return marshalJSON(securityScheme(s), s.Extensions)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *SecurityScheme) UnmarshalJSON(b []byte) error {
+	type securityScheme SecurityScheme
+	var err error
+	s.Extensions, err = unmarshalJSON(b, (*securityScheme)(s))
+	return err
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (s SecurityScheme) MarshalYAML() (interface{}, error) {
+	type securityScheme SecurityScheme
+	return marshalYAML(securityScheme(s), s.Extensions)
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (s *SecurityScheme) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type securityScheme SecurityScheme
+	var err error
+	s.Extensions, err = unmarshalYAML(unmarshal, (*securityScheme)(s))
+	return err
+}
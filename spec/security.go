@@ -0,0 +1,155 @@
+package spec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/ericchiang/swaggopher/internal/rawdoc"
+)
+
+// EffectiveSecurity returns the security requirements of an operation: its
+// own if it declares any, or the document's. An operation which declares an
+// empty list requires no security, even if the document does.
+func (s *Swagger) EffectiveSecurity(op *Operation) []SecurityRequirement {
+	if op.Security != nil {
+		return op.Security
+	}
+	return s.Security
+}
+
+// unsecured reports if an operation declares an empty list of security
+// requirements. The list is dropped by omitempty, so its marshalers write it
+// explicitly; otherwise the operation would inherit the document's security
+// once decoded again.
+func (o *Operation) unsecured() bool {
+	return o.Security != nil && len(o.Security) == 0
+}
+
+// marshalUnsecuredJSON is marshalJSON for an operation with an empty list of
+// security requirements.
+func marshalUnsecuredJSON(v interface{}, ext map[string]interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.Write(data[:len(data)-1])
+	if len(data) > 2 {
+		buf.WriteByte(',')
+	}
+	buf.WriteString(`"security":[]}`)
+	return marshalJSON(json.RawMessage(buf.Bytes()), ext)
+}
+
+// marshalUnsecuredYAML is marshalYAML for an operation with an empty list of
+// security requirements.
+func marshalUnsecuredYAML(v interface{}, ext map[string]interface{}) (interface{}, error) {
+	data, err := rawdoc.MarshalYAML(v)
+	if err != nil {
+		return nil, err
+	}
+	var fields rawdoc.MapSlice
+	if err := rawdoc.UnmarshalYAML(data, &fields); err != nil {
+		return nil, err
+	}
+	fields = append(fields, rawdoc.MapItem{Key: "security", Value: []interface{}{}})
+	return marshalYAML(fields, ext)
+}
+
+// AppliedScheme is a security scheme named by a security requirement, with
+// the scopes the requirement asks for.
+type AppliedScheme struct {
+	// Name is the scheme's key in the document's securityDefinitions.
+	Name   string
+	Scheme SecurityScheme
+	// Scopes are the OAuth2 scopes the requirement lists.
+	Scopes []string
+}
+
+// Requirement is a resolved security requirement: a request must satisfy
+// every scheme. An empty requirement is satisfied by any request, making
+// security optional.
+type Requirement []AppliedScheme
+
+// ResolveSecurity returns the effective security requirements of an operation
+// with their schemes resolved against securityDefinitions. A request must
+// satisfy one of the requirements, and none are returned if the operation
+// requires no security. The schemes of a requirement are sorted by name.
+//
+// It returns an error if a requirement names a scheme which isn't defined.
+func (s *Swagger) ResolveSecurity(op *Operation) ([]Requirement, error) {
+	reqs := s.EffectiveSecurity(op)
+	if len(reqs) == 0 {
+		return nil, nil
+	}
+	resolved := make([]Requirement, 0, len(reqs))
+	for _, req := range reqs {
+		names := make([]string, 0, len(req))
+		for name := range req {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		r := make(Requirement, 0, len(names))
+		for _, name := range names {
+			scheme, ok := s.SecurityDefinitions[name]
+			if !ok {
+				return nil, fmt.Errorf("spec: security scheme %q is not defined", name)
+			}
+			r = append(r, AppliedScheme{Name: name, Scheme: scheme, Scopes: req[name]})
+		}
+		resolved = append(resolved, r)
+	}
+	return resolved, nil
+}
+
+// IsBasic reports if the scheme is HTTP basic authentication.
+func (s *SecurityScheme) IsBasic() bool {
+	return s.Type == "basic"
+}
+
+// APIKey returns where an apiKey scheme's key is sent, "header" or "query",
+// and the name of the header or query parameter. ok is false if the scheme
+// isn't an apiKey scheme.
+func (s *SecurityScheme) APIKey() (in, name string, ok bool) {
+	if s.Type != "apiKey" {
+		return "", "", false
+	}
+	return s.In, s.Name, true
+}
+
+// OAuth2Flow is the flow of an oauth2 scheme.
+type OAuth2Flow struct {
+	// Flow is "implicit", "password", "application" or "accessCode".
+	Flow             string
+	AuthorizationURL string
+	TokenURL         string
+	Scopes           Scopes
+}
+
+// UsesAuthorization reports if the flow sends the user to the authorization
+// URL, as the implicit and accessCode flows do.
+func (f *OAuth2Flow) UsesAuthorization() bool {
+	return f.Flow == "implicit" || f.Flow == "accessCode"
+}
+
+// UsesToken reports if the flow requests tokens from the token URL, as every
+// flow but implicit does.
+func (f *OAuth2Flow) UsesToken() bool {
+	return f.Flow != "implicit"
+}
+
+// OAuth2 returns the flow of an oauth2 scheme. ok is false if the scheme isn't
+// an oauth2 scheme.
+func (s *SecurityScheme) OAuth2() (flow *OAuth2Flow, ok bool) {
+	if s.Type != "oauth2" {
+		return nil, false
+	}
+	return &OAuth2Flow{
+		Flow:             s.Flow,
+		AuthorizationURL: s.AuthorizationUrl,
+		TokenURL:         s.TokenUrl,
+		Scopes:           s.Scopes,
+	}, true
+}
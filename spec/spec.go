@@ -1,5 +1,23 @@
 /*
 Package spec defines Go mappings for the OpenAPI-Specification (Swagger).
+
+Load and LoadReader read a document in either JSON or YAML, and Save writes
+one back:
+
+	doc, err := spec.Load("swagger.yaml")
+	if err != nil {
+		// Handle error.
+	}
+	err = doc.Save("swagger.json", spec.JSON)
+
+The package depends only on the standard library and a YAML engine,
+gopkg.in/yaml.v2, so programs which only parse documents can import it without
+the dependencies of the rest of swaggopher. Programs which only read JSON can
+leave out the YAML engine too by building with the noyaml tag:
+
+	go build -tags noyaml
+
+In such builds, reading or writing YAML returns ErrNoYAML.
 */
 package spec
 
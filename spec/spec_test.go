@@ -1,13 +1,22 @@
 package spec
 
 import (
+	"bytes"
 	"encoding/json"
+	"go/build"
 	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
 
 	"gopkg.in/yaml.v2"
 
 	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/ericchiang/swaggopher/internal/rawdoc"
 )
 
 func TestSimpleParse(t *testing.T) {
@@ -35,13 +44,26 @@ func TestSimpleParse(t *testing.T) {
 					Responses: Responses{
 						"200": {
 							Description: "A list of pets.",
-							Schema:      &Schema{},
+							Schema: &Schema{
+								Type:  "array",
+								Items: &Schema{Ref: "#/definitions/Pet"},
+							},
 						},
 					},
 				},
 			},
 		},
-		Definitions: Definitions{"Pet": Schema{}},
+		Definitions: Definitions{
+			"Pet": Schema{
+				Type:     "object",
+				Required: []string{"id", "name"},
+				Properties: map[string]Schema{
+					"id":   {Type: "integer", Format: "int64"},
+					"name": {Type: "string"},
+					"tag":  {Type: "string"},
+				},
+			},
+		},
 	}
 
 	tests := []struct {
@@ -80,3 +102,1211 @@ func TestSimpleParse(t *testing.T) {
 		}()
 	}
 }
+
+func TestReferenceRoundTrip(t *testing.T) {
+	doc := Swagger{
+		Swagger: "2.0",
+		Info:    &Info{Title: "Pets", Version: "1.0"},
+		Paths: Paths{
+			"/pets": PathItem{Ref: "paths.yaml#/pets"},
+			"/pets/{petId}": PathItem{
+				Parameters: []Parameter{{Ref: "#/parameters/petId"}},
+				Get: &Operation{
+					Responses: Responses{
+						"200":     {Description: "A pet.", Schema: &Schema{Ref: "#/definitions/Pet"}},
+						"default": {Ref: "#/responses/Error"},
+					},
+				},
+			},
+		},
+		Parameters: ParametersDefinitions{
+			"petId": {Name: "petId", In: "path", Required: true, Type: "string"},
+		},
+		Responses: ResponsesDefinitions{
+			"Error": {Description: "An error."},
+		},
+		Definitions: Definitions{
+			"Pet": {Type: "object", Properties: map[string]Schema{"owner": {Ref: "#/definitions/Owner"}}},
+		},
+	}
+
+	tests := []struct {
+		marshal   func(interface{}) ([]byte, error)
+		unmarshal func([]byte, interface{}) error
+		// refs are fragments each encoded reference must appear as.
+		refs []string
+	}{
+		{
+			marshal:   json.Marshal,
+			unmarshal: json.Unmarshal,
+			refs: []string{
+				`{"$ref":"#/parameters/petId"}`,
+				`{"$ref":"#/responses/Error"}`,
+				`{"$ref":"paths.yaml#/pets"}`,
+			},
+		},
+		{
+			marshal:   yaml.Marshal,
+			unmarshal: yaml.Unmarshal,
+			refs: []string{
+				"- $ref: '#/parameters/petId'\n",
+				"default:\n          $ref: '#/responses/Error'\n",
+				"/pets:\n    $ref: paths.yaml#/pets\n",
+			},
+		},
+	}
+	for i, tt := range tests {
+		data, err := tt.marshal(doc)
+		if err != nil {
+			t.Errorf("case %d: marshal: %v", i, err)
+			continue
+		}
+		for _, ref := range tt.refs {
+			if !strings.Contains(string(data), ref) {
+				t.Errorf("case %d: expected output to contain %q, got:\n%s", i, ref, data)
+			}
+		}
+		var got Swagger
+		if err := tt.unmarshal(data, &got); err != nil {
+			t.Errorf("case %d: unmarshal: %v", i, err)
+			continue
+		}
+		if diff := pretty.Compare(got, doc); diff != "" {
+			t.Errorf("case %d: want != got: %s", i, diff)
+		}
+	}
+}
+
+func TestExtensions(t *testing.T) {
+	const doc = `
+swagger: "2.0"
+info:
+  title: Pets
+  version: "1.0"
+  x-logo: {url: logo.png, width: 120}
+paths:
+  /pets:
+    get:
+      x-rate-limit: 100
+      responses:
+        200:
+          description: Pets.
+          x-cache: true
+          schema:
+            type: array
+            x-nullable: false
+            items: {$ref: '#/definitions/Pet'}
+x-tags: [a, b]
+`
+	want := Swagger{
+		Swagger: "2.0",
+		Info: &Info{
+			Title:      "Pets",
+			Version:    "1.0",
+			Extensions: map[string]interface{}{"x-logo": map[string]interface{}{"url": "logo.png", "width": 120.0}},
+		},
+		Paths: Paths{
+			"/pets": PathItem{
+				Get: &Operation{
+					Responses: Responses{
+						"200": {
+							Description: "Pets.",
+							Schema: &Schema{
+								Type:       "array",
+								Items:      &Schema{Ref: "#/definitions/Pet"},
+								Extensions: map[string]interface{}{"x-nullable": false},
+							},
+							Extensions: map[string]interface{}{"x-cache": true},
+						},
+					},
+					Extensions: map[string]interface{}{"x-rate-limit": 100.0},
+				},
+			},
+		},
+		Extensions: map[string]interface{}{"x-tags": []interface{}{"a", "b"}},
+	}
+
+	var got Swagger
+	if err := yaml.Unmarshal([]byte(doc), &got); err != nil {
+		t.Fatal(err)
+	}
+	if diff := pretty.Compare(got, want); diff != "" {
+		t.Errorf("yaml: want != got: %s", diff)
+	}
+
+	// Extensions must survive encoding to and from either format.
+	encodings := []struct {
+		marshal   func(interface{}) ([]byte, error)
+		unmarshal func([]byte, interface{}) error
+	}{
+		{json.Marshal, json.Unmarshal},
+		{yaml.Marshal, yaml.Unmarshal},
+	}
+	for i, e := range encodings {
+		data, err := e.marshal(want)
+		if err != nil {
+			t.Errorf("case %d: marshal: %v", i, err)
+			continue
+		}
+		var got Swagger
+		if err := e.unmarshal(data, &got); err != nil {
+			t.Errorf("case %d: unmarshal: %v", i, err)
+			continue
+		}
+		if diff := pretty.Compare(got, want); diff != "" {
+			t.Errorf("case %d: want != got: %s", i, diff)
+		}
+	}
+
+	// Extensions follow the fixed fields.
+	data, err := json.Marshal(Info{Title: "Pets", Version: "1.0", Extensions: map[string]interface{}{"x-b": 1, "x-a": "v"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"title":"Pets","version":"1.0","x-a":"v","x-b":1}`; string(data) != want {
+		t.Errorf("expected %s, got %s", want, data)
+	}
+	data, err = json.Marshal(Tag{Extensions: map[string]interface{}{"x-a": 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"name":"","x-a":1}`; string(data) != want {
+		t.Errorf("expected %s, got %s", want, data)
+	}
+	if _, err := json.Marshal(Contact{Extensions: map[string]interface{}{"vendor": 1}}); err == nil {
+		t.Errorf("expected error encoding an extension without the x- prefix")
+	}
+}
+
+func TestUnmarshalStrict(t *testing.T) {
+	requireYAML(t)
+	tests := []struct {
+		doc     string
+		unknown []string
+	}{
+		{
+			doc: `
+swagger: "2.0"
+info: {title: Pets, version: "1.0", x-logo: logo.png}
+paths:
+  /pets:
+    get:
+      summry: List pets.
+      parameters:
+      - {name: limit, in: query, type: integer, maximum: 10, minimun: 1}
+      responses:
+        200:
+          description: Pets.
+          schema:
+            type: object
+            additionalProperties: {type: string, formt: uuid}
+            properties:
+              name: {type: string, x-order: 1}
+definitions:
+  Pet: {type: object, requried: [name]}
+host-name: example.com
+`,
+			unknown: []string{
+				"/definitions/Pet/requried",
+				"/host-name",
+				"/paths/~1pets/get/parameters/0/minimun",
+				"/paths/~1pets/get/responses/200/schema/additionalProperties/formt",
+				"/paths/~1pets/get/summry",
+			},
+		},
+		{
+			doc: `{"swagger": "2.0", "info": {"title": "Pets", "version": "1.0"}, "paths": {}, "x-internal": true}`,
+		},
+		{
+			doc:     `{"swagger": "2.0", "info": {"title": "Pets", "verison": "1.0", "contact": {"e-mail": "a@example.com"}}}`,
+			unknown: []string{"/info/contact/e-mail", "/info/verison"},
+		},
+	}
+	for i, tt := range tests {
+		var s Swagger
+		err := UnmarshalStrict([]byte(tt.doc), &s)
+		if tt.unknown == nil {
+			if err != nil {
+				t.Errorf("case %d: %v", i, err)
+			} else if s.Info == nil || s.Info.Title != "Pets" {
+				t.Errorf("case %d: document wasn't decoded", i)
+			}
+			continue
+		}
+		uerr, ok := err.(*UnknownFieldsError)
+		if !ok {
+			t.Errorf("case %d: expected *UnknownFieldsError, got %v", i, err)
+			continue
+		}
+		if diff := pretty.Compare(uerr.Paths, tt.unknown); diff != "" {
+			t.Errorf("case %d: want != got: %s", i, diff)
+		}
+	}
+}
+
+func TestUnmarshalRaw(t *testing.T) {
+	requireYAML(t)
+	doc := `{
+  "swagger": "2.0",
+  "info": {"title": "Pets", "version": "1.0", "x-logo": {"url": "logo.png",  "alt": "Pets"}},
+  "paths": {
+    "/pets": {
+      "get": {
+        "parameters": [{"name": "limit", "in": "query", "type": "integer", "default": 20.0}],
+        "responses": {
+          "200": {"description": "Pets.", "examples": {"application/json": [{"id": 12345678901234567890}]}}
+        }
+      }
+    }
+  },
+  "definitions": {
+    "Pet": {
+      "type": "object",
+      "properties": {"status": {"type": "string", "enum": ["available", "sold"], "example": "sold"}}
+    }
+  }
+}`
+	var s Swagger
+	if err := UnmarshalRaw([]byte(doc), &s); err != nil {
+		t.Fatal(err)
+	}
+	raw := func(v interface{}) string {
+		r, ok := v.(json.RawMessage)
+		if !ok {
+			t.Errorf("expected json.RawMessage, got %T", v)
+		}
+		return string(r)
+	}
+	op := s.Paths["/pets"].Get
+	tests := []struct {
+		got, want string
+	}{
+		{raw(s.Info.Extensions["x-logo"]), `{"url": "logo.png",  "alt": "Pets"}`},
+		{raw(op.Parameters[0].Default), `20.0`},
+		{raw(op.Responses["200"].Examples["application/json"]), `[{"id": 12345678901234567890}]`},
+		{raw(s.Definitions["Pet"].Properties["status"].Enum[1]), `"sold"`},
+		{raw(s.Definitions["Pet"].Properties["status"].Example), `"sold"`},
+	}
+	for _, test := range tests {
+		if test.got != test.want {
+			t.Errorf("want raw value %s, got %s", test.want, test.got)
+		}
+	}
+
+	// Only the selected values are kept raw.
+	var p Parameter
+	opts := RawOptions{Fields: []string{"default"}}
+	if err := opts.Unmarshal([]byte("name: limit\nin: query\ntype: integer\ndefault: 20\nenum: [10, 20]\nx-order: 1\n"), &p); err != nil {
+		t.Fatal(err)
+	}
+	if got := raw(p.Default); got != "20" {
+		t.Errorf("want raw default 20, got %s", got)
+	}
+	if _, ok := p.Enum[0].(float64); !ok {
+		t.Errorf("expected decoded enum values, got %T", p.Enum[0])
+	}
+	if _, ok := p.Extensions["x-order"].(float64); !ok {
+		t.Errorf("expected decoded extension value, got %T", p.Extensions["x-order"])
+	}
+
+	// Raw values are written back as they were read.
+	data, err := json.Marshal(op.Parameters[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"name":"limit","in":"query","type":"integer","default":20.0}`; string(data) != want {
+		t.Errorf("want %s, got %s", want, data)
+	}
+	data, err = yaml.Marshal(s.Info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "\n  url: logo.png\n") {
+		t.Errorf("expected a decoded x-logo, got %s", data)
+	}
+}
+
+const pointerDoc = `
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+paths:
+  /pets:
+    get:
+      tags: [pets]
+      responses:
+        200:
+          description: The pets.
+          schema: {type: array, items: {$ref: '#/definitions/Pet'}}
+      x-rate-limit: {requests: 10, per: [second]}
+definitions:
+  Pet:
+    type: object
+    additionalProperties: {type: string}
+`
+
+func TestLookup(t *testing.T) {
+	var s Swagger
+	if err := yaml.Unmarshal([]byte(pointerDoc), &s); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		pointer string
+		want    interface{}
+		wantErr string
+	}{
+		{pointer: "/info/title", want: "Pets"},
+		{pointer: "#/paths/~1pets/get/tags/0", want: "pets"},
+		{pointer: "#/paths/~1pets/get/responses/200/description", want: "The pets."},
+		{pointer: "/paths/~1pets/get/responses/200/schema/items", want: &Schema{Ref: "#/definitions/Pet"}},
+		{pointer: "/paths/~1pets/get/x-rate-limit/per/0", want: "second"},
+		{pointer: "/definitions/Pet/additionalProperties/type", want: "string"},
+		{pointer: "#/definitions/%50et/type", want: "object"},
+		{pointer: "/paths/~1pets/put", wantErr: "spec: /paths/~1pets/put: not found"},
+		{pointer: "/paths/~1pets/get/tags/1", wantErr: "spec: /paths/~1pets/get/tags/1: index 1 out of range"},
+		{pointer: "/paths/~1pets/get/tags/01", wantErr: `spec: /paths/~1pets/get/tags/01: invalid array index "01"`},
+		{pointer: "/info/name", wantErr: `spec: /info/name: Info has no field "name"`},
+		{pointer: "/info/title/0", wantErr: "spec: /info/title/0: string is not an object or array"},
+		{pointer: "info", wantErr: `spec: invalid JSON pointer "info": must start with /`},
+	}
+	for _, tt := range tests {
+		got, err := Lookup(&s, tt.pointer)
+		if err != nil {
+			if err.Error() != tt.wantErr {
+				t.Errorf("%s: want error %q, got %q", tt.pointer, tt.wantErr, err)
+			}
+			continue
+		}
+		if tt.wantErr != "" {
+			t.Errorf("%s: expected error %q", tt.pointer, tt.wantErr)
+			continue
+		}
+		if diff := pretty.Compare(tt.want, got); diff != "" {
+			t.Errorf("%s: want != got: %s", tt.pointer, diff)
+		}
+	}
+
+	// Pointers, maps and slices refer to the document.
+	got, err := Lookup(&s, "/paths/~1pets/get")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got.(*Operation).Summary = "List pets."
+	if s.Paths["/pets"].Get.Summary != "List pets." {
+		t.Errorf("operation returned by Lookup isn't part of the document")
+	}
+}
+
+func TestSet(t *testing.T) {
+	var s Swagger
+	if err := yaml.Unmarshal([]byte(pointerDoc), &s); err != nil {
+		t.Fatal(err)
+	}
+	sets := []struct {
+		pointer string
+		value   interface{}
+	}{
+		{"/info/title", "Petstore"},
+		{"/paths/~1pets/get/tags/-", "store"},
+		{"/paths/~1pets/get/responses/200/schema/items", Schema{Ref: "#/definitions/Animal"}},
+		{"/paths/~1pets/get/x-rate-limit/per/0", "minute"},
+		{"/paths/~1pets/get/x-internal", true},
+		{"/paths/~1pets~1{id}/get", map[string]interface{}{
+			"operationId": "getPet",
+			"responses":   map[string]interface{}{"200": map[string]interface{}{"description": "A pet."}},
+		}},
+		{"/definitions/Pet/properties/name/type", "string"},
+		{"/definitions/Pet/additionalProperties", false},
+		{"/host", nil},
+	}
+	for _, tt := range sets {
+		if err := Set(&s, tt.pointer, tt.value); err != nil {
+			t.Fatalf("%s: %v", tt.pointer, err)
+		}
+	}
+	var want Swagger
+	if err := yaml.Unmarshal([]byte(`
+swagger: "2.0"
+info: {title: Petstore, version: "1.0"}
+paths:
+  /pets:
+    get:
+      tags: [pets, store]
+      responses:
+        200:
+          description: The pets.
+          schema: {type: array, items: {$ref: '#/definitions/Animal'}}
+      x-rate-limit: {requests: 10, per: [minute]}
+      x-internal: true
+  /pets/{id}:
+    get:
+      operationId: getPet
+      responses:
+        200: {description: A pet.}
+definitions:
+  Pet:
+    type: object
+    properties:
+      name: {type: string}
+    additionalProperties: false
+`), &want); err != nil {
+		t.Fatal(err)
+	}
+	if diff := pretty.Compare(want, s); diff != "" {
+		t.Errorf("want != got: %s", diff)
+	}
+
+	for _, tt := range []struct {
+		pointer string
+		value   interface{}
+		want    string
+	}{
+		{"/info/version", []string{"1"}, "spec: /info/version: can't set string to []string: json: cannot unmarshal array into Go value of type string"},
+		{"/paths/~1pets/get/tags/5", "x", "spec: /paths/~1pets/get/tags/5: index 5 out of range"},
+		{"/paths/~1pets/get/x-missing/a", "x", "spec: /paths/~1pets/get/x-missing: not found"},
+		{"/paths/~1pets/get/name", "x", `spec: /paths/~1pets/get/name: Operation has no field "name"`},
+	} {
+		err := Set(&s, tt.pointer, tt.value)
+		if err == nil {
+			t.Errorf("%s: expected error %q", tt.pointer, tt.want)
+		} else if err.Error() != tt.want {
+			t.Errorf("%s: want error %q, got %q", tt.pointer, tt.want, err)
+		}
+	}
+}
+
+type recorder struct {
+	BaseVisitor
+	visited []string
+	skip    string
+}
+
+func (r *recorder) VisitOperation(pointer string, op *Operation) error {
+	r.visited = append(r.visited, "operation "+pointer)
+	return nil
+}
+
+func (r *recorder) VisitParameter(pointer string, p *Parameter) error {
+	r.visited = append(r.visited, "parameter "+pointer)
+	return nil
+}
+
+func (r *recorder) VisitSchema(pointer string, s *Schema) error {
+	r.visited = append(r.visited, "schema "+pointer)
+	if pointer == r.skip {
+		return SkipChildren
+	}
+	return nil
+}
+
+const walkDoc = `
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+paths:
+  /pets/{id}:
+    parameters:
+    - {name: id, in: path, required: true, type: integer}
+    put:
+      parameters:
+      - {name: pet, in: body, schema: {$ref: '#/definitions/Pet'}}
+      responses:
+        200:
+          description: The pet.
+          schema: {$ref: '#/definitions/Pet'}
+definitions:
+  Pet:
+    type: object
+    properties:
+      tags: {type: array, items: {type: string}}
+      name: {type: string}
+`
+
+func TestWalk(t *testing.T) {
+	var s Swagger
+	if err := yaml.Unmarshal([]byte(walkDoc), &s); err != nil {
+		t.Fatal(err)
+	}
+	r := &recorder{skip: "/definitions/Pet/properties/tags"}
+	if err := Walk(&s, r); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"parameter /paths/~1pets~1{id}/parameters/0",
+		"operation /paths/~1pets~1{id}/put",
+		"parameter /paths/~1pets~1{id}/put/parameters/0",
+		"schema /paths/~1pets~1{id}/put/parameters/0/schema",
+		"schema /paths/~1pets~1{id}/put/responses/200/schema",
+		"schema /definitions/Pet",
+		"schema /definitions/Pet/properties/name",
+		"schema /definitions/Pet/properties/tags",
+	}
+	if diff := pretty.Compare(want, r.visited); diff != "" {
+		t.Errorf("want != got: %s", diff)
+	}
+}
+
+type describer struct{ BaseVisitor }
+
+func (describer) VisitSchema(pointer string, s *Schema) error {
+	if s.Ref == "" && s.Description == "" {
+		s.Description = pointer
+	}
+	return nil
+}
+
+func TestWalkMutate(t *testing.T) {
+	var s Swagger
+	if err := yaml.Unmarshal([]byte(walkDoc), &s); err != nil {
+		t.Fatal(err)
+	}
+	if err := (WalkOptions{Mutate: true}).Walk(&s, describer{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct{ pointer, want string }{
+		{"/definitions/Pet/description", "/definitions/Pet"},
+		{"/definitions/Pet/properties/name/description", "/definitions/Pet/properties/name"},
+		{"/definitions/Pet/properties/tags/items/description", "/definitions/Pet/properties/tags/items"},
+		{"/paths/~1pets~1{id}/put/responses/200/schema/description", ""},
+	} {
+		got, err := Lookup(&s, tt.pointer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: want %q, got %q", tt.pointer, tt.want, got)
+		}
+	}
+}
+
+func TestOperationByID(t *testing.T) {
+	var s Swagger
+	err := yaml.Unmarshal([]byte(`
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+paths:
+  /pets:
+    get: {operationId: listPets, responses: {200: {description: OK}}}
+    post: {operationId: createPet, responses: {201: {description: Created}}}
+  /pets/{id}:
+    get: {operationId: getPet, responses: {200: {description: OK}}}
+    delete: {responses: {204: {description: Deleted}}}
+`), &s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	s.RangeOperations(func(path, method string, op *Operation) bool {
+		got = append(got, method+" "+path+" "+op.OperationId)
+		return true
+	})
+	want := []string{"get /pets listPets", "post /pets createPet", "get /pets/{id} getPet", "delete /pets/{id} "}
+	if diff := pretty.Compare(got, want); diff != "" {
+		t.Errorf("RangeOperations: want != got: %s", diff)
+	}
+
+	op, path, method, ok := s.OperationByID("getPet")
+	if !ok || path != "/pets/{id}" || method != "get" || op != s.Paths["/pets/{id}"].Get {
+		t.Errorf("getPet: got %v %s %s %v", op, path, method, ok)
+	}
+	if _, _, _, ok := s.OperationByID("deletePet"); ok {
+		t.Errorf("found deletePet before it was named")
+	}
+
+	// The index is rebuilt once a lookup finds an operation it indexed has
+	// been removed or renamed, but not when an ID isn't indexed.
+	s.Paths["/pets"].Get.OperationId = "searchPets"
+	delete(s.Paths, "/pets/{id}")
+	lookups := []struct {
+		id   string
+		want bool
+	}{
+		{"searchPets", false},
+		{"getPet", false},
+		{"searchPets", true},
+		{"listPets", false},
+		{"createPet", true},
+	}
+	for _, tt := range lookups {
+		if _, _, _, ok := s.OperationByID(tt.id); ok != tt.want {
+			t.Errorf("%s: want found %v, got %v", tt.id, tt.want, ok)
+		}
+	}
+}
+
+func TestOperationParameters(t *testing.T) {
+	var s Swagger
+	err := yaml.Unmarshal([]byte(`
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+parameters:
+  limit: {name: limit, in: query, type: integer}
+paths:
+  /pets/{id}:
+    parameters:
+      - {name: id, in: path, required: true, type: string}
+      - {name: X-Trace, in: header, type: string}
+    get:
+      parameters:
+        - $ref: "#/parameters/limit"
+        - {name: X-Trace, in: header, type: integer}
+        - $ref: "#/parameters/missing"
+      responses: {200: {description: OK}}
+`), &s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	item := s.Paths["/pets/{id}"]
+	var got []string
+	for _, p := range s.OperationParameters(&item, item.Get) {
+		got = append(got, p.In+" "+p.Name+" "+p.Type+p.Ref)
+	}
+	want := []string{"query limit integer", "header X-Trace integer", "  #/parameters/missing", "path id string"}
+	if diff := pretty.Compare(got, want); diff != "" {
+		t.Errorf("OperationParameters: want != got: %s", diff)
+	}
+
+	if p, ok := s.ResolveParameter(&Parameter{Ref: "#/definitions/limit"}); ok || p.Ref != "#/definitions/limit" {
+		t.Errorf("resolved a reference to a definition: %v %v", p, ok)
+	}
+
+	if !item.SetOperation("put", &Operation{OperationId: "replacePet"}) || item.Operation("put") != item.Put {
+		t.Errorf("SetOperation didn't set put")
+	}
+	if item.SetOperation("trace", &Operation{}) || item.Operation("trace") != nil {
+		t.Errorf("SetOperation accepted trace")
+	}
+	var methods []string
+	item.RangeOperations(func(method string, op *Operation) bool {
+		methods = append(methods, method)
+		return true
+	})
+	if diff := pretty.Compare(methods, []string{"get", "put"}); diff != "" {
+		t.Errorf("RangeOperations: want != got: %s", diff)
+	}
+}
+
+func TestValueSchema(t *testing.T) {
+	three := 3
+	p := Parameter{
+		Name:      "ids",
+		In:        "query",
+		Type:      "array",
+		MaxItems:  &three,
+		Items:     &Items{Type: "string", Format: "uuid", Pattern: "^[a-f0-9-]+$"},
+		MinLength: 1,
+	}
+	want := &Schema{
+		Type:      "array",
+		MaxItems:  &three,
+		Items:     &Schema{Type: "string", Format: "uuid", Pattern: "^[a-f0-9-]+$"},
+		MinLength: 1,
+	}
+	if diff := pretty.Compare(p.ValueSchema(), want); diff != "" {
+		t.Errorf("ValueSchema: want != got: %s", diff)
+	}
+
+	body := Parameter{Name: "pet", In: "body", Schema: &Schema{Ref: "#/definitions/Pet"}}
+	if got := body.ValueSchema(); got != body.Schema {
+		t.Errorf("ValueSchema of a body: want its schema, got %v", got)
+	}
+}
+
+func TestResolveSecurity(t *testing.T) {
+	var s Swagger
+	err := yaml.Unmarshal([]byte(`
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+securityDefinitions:
+  key: {type: apiKey, in: header, name: X-API-Key}
+  basic: {type: basic}
+  oauth: {type: oauth2, flow: accessCode, authorizationUrl: "https://example.com/auth", tokenUrl: "https://example.com/token", scopes: {write: Write.}}
+security:
+- key: []
+paths:
+  /pets:
+    get: {responses: {200: {description: OK}}}
+    post:
+      security:
+      - {oauth: [write], key: []}
+      - basic: []
+      responses: {201: {description: Created}}
+    delete:
+      security: []
+      responses: {204: {description: Deleted}}
+    put:
+      security: [{missing: []}]
+      responses: {200: {description: OK}}
+`), &s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	item := s.Paths["/pets"]
+	defs := s.SecurityDefinitions
+
+	got, err := s.ResolveSecurity(item.Get)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := pretty.Compare(got, []Requirement{{{Name: "key", Scheme: defs["key"], Scopes: []string{}}}}); diff != "" {
+		t.Errorf("get: want != got: %s", diff)
+	}
+	got, err = s.ResolveSecurity(item.Post)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Requirement{
+		{{Name: "key", Scheme: defs["key"], Scopes: []string{}}, {Name: "oauth", Scheme: defs["oauth"], Scopes: []string{"write"}}},
+		{{Name: "basic", Scheme: defs["basic"], Scopes: []string{}}},
+	}
+	if diff := pretty.Compare(got, want); diff != "" {
+		t.Errorf("post: want != got: %s", diff)
+	}
+	if got, err := s.ResolveSecurity(item.Delete); err != nil || got != nil {
+		t.Errorf("delete: want no requirements, got %v, %v", got, err)
+	}
+	if _, err := s.ResolveSecurity(item.Put); err == nil || err.Error() != `spec: security scheme "missing" is not defined` {
+		t.Errorf("put: unexpected error %v", err)
+	}
+
+	key, basic, oauth := defs["key"], defs["basic"], defs["oauth"]
+	if in, name, ok := key.APIKey(); !ok || in != "header" || name != "X-API-Key" {
+		t.Errorf("APIKey: got %q %q %v", in, name, ok)
+	}
+	if _, _, ok := basic.APIKey(); ok || !basic.IsBasic() || key.IsBasic() {
+		t.Errorf("basic scheme misidentified")
+	}
+	flow, ok := oauth.OAuth2()
+	if !ok || flow.TokenURL != "https://example.com/token" || !flow.UsesAuthorization() || !flow.UsesToken() {
+		t.Errorf("OAuth2: got %+v, %v", flow, ok)
+	}
+	if _, ok := key.OAuth2(); ok {
+		t.Errorf("apiKey scheme reported as oauth2")
+	}
+}
+
+func TestSecurityRoundTrip(t *testing.T) {
+	s := Swagger{
+		Swagger:  "2.0",
+		Security: []SecurityRequirement{{"key": {}}},
+		Paths: Paths{
+			"/pets": {
+				Get:    &Operation{Responses: Responses{"200": {Description: "OK"}}},
+				Delete: &Operation{Security: []SecurityRequirement{}, Responses: Responses{"204": {Description: "Deleted"}}, Extensions: map[string]interface{}{"x-public": true}},
+			},
+		},
+	}
+	codecs := []struct {
+		name      string
+		marshal   func(interface{}) ([]byte, error)
+		unmarshal func([]byte, interface{}) error
+	}{
+		{"json", json.Marshal, json.Unmarshal},
+		{"yaml", yaml.Marshal, yaml.Unmarshal},
+	}
+	for _, c := range codecs {
+		if c.name == "yaml" {
+			// The json case has run by now, so only the yaml one is skipped.
+			requireYAML(t)
+		}
+		data, err := c.marshal(&s)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		var got Swagger
+		if err := c.unmarshal(data, &got); err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		item := got.Paths["/pets"]
+		if reqs := got.EffectiveSecurity(item.Delete); reqs == nil || len(reqs) != 0 {
+			t.Errorf("%s: delete: want an empty list of requirements, got %v", c.name, reqs)
+		}
+		if item.Delete.Extensions["x-public"] != true {
+			t.Errorf("%s: delete: lost extensions %v", c.name, item.Delete.Extensions)
+		}
+		if reqs := got.EffectiveSecurity(item.Get); len(reqs) != 1 {
+			t.Errorf("%s: get: want the document's requirements, got %v", c.name, reqs)
+		}
+	}
+}
+
+func TestUnmarshalOrdered(t *testing.T) {
+	doc := `{"swagger":"2.0","info":{"title":"Pets","version":"1.0"},` +
+		`"paths":{"/pets/{id}":{"get":{"responses":{"404":{"description":"Not found."},"200":{"description":"The pet."}}}},` +
+		`"/owners":{"get":{"responses":{"default":{"description":"Owners."}}}}},` +
+		`"definitions":{"Zebra":{"type":"object"},"Pet":{"type":"object","required":["name"],"properties":{"name":{"type":"string"},"id":{"type":"integer"}}}},` +
+		`"x-owner":"platform"}`
+
+	var s Swagger
+	if err := UnmarshalOrdered([]byte(doc), &s); err != nil {
+		t.Fatal(err)
+	}
+	got, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != doc {
+		t.Errorf("round trip changed document:\nwant %s\ngot  %s", doc, got)
+	}
+
+	// Keys added after decoding follow the original ones.
+	s.Paths["/cats"] = PathItem{Get: &Operation{Responses: Responses{"200": {Description: "Cats."}}}}
+	s.Paths["/pets/{id}"].Get.Responses["201"] = Response{Description: "Created."}
+	got, err = json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Replace(doc, `{"description":"The pet."}}`, `{"description":"The pet."},"201":{"description":"Created."}}`, 1)
+	want = strings.Replace(want, `"description":"Owners."}}}}}`, `"description":"Owners."}}}},"/cats":{"get":{"responses":{"200":{"description":"Cats."}}}}}`, 1)
+	if string(got) != want {
+		t.Errorf("after adding keys:\nwant %s\ngot  %s", want, got)
+	}
+
+	// Without a recorded order, keys are written sorted.
+	s.KeyOrder = nil
+	got, err = json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(got), `"paths":{"/cats"`) || !strings.Contains(string(got), `"definitions":{"Pet"`) {
+		t.Errorf("expected sorted keys, got %s", got)
+	}
+}
+
+func TestUnmarshalLossless(t *testing.T) {
+	doc := `{"swagger":"2.0","info":{"title":"Pets","version":"1.0","audience":"internal"},` +
+		`"paths":{"/pets":{"get":{"operationId":"listPets","rateLimit":{"rps":10},"responses":{"200":{"description":"Pets."}}}},` +
+		`"/owners":{"get":{"cacheable":true,"responses":{"200":{"description":"Owners."}}}}},` +
+		`"definitions":{"Pet":{"type":"object","nullable":true}},` +
+		`"servers":["https://pets.example.com"]}`
+
+	var s Swagger
+	if err := UnmarshalLossless([]byte(doc), &s); err != nil {
+		t.Fatal(err)
+	}
+	wantUnknown := []string{
+		"/definitions/Pet/nullable",
+		"/info/audience",
+		"/paths/~1owners/get/cacheable",
+		"/paths/~1pets/get/rateLimit",
+		"/servers",
+	}
+	var gotUnknown []string
+	for p := range s.Unknown {
+		gotUnknown = append(gotUnknown, p)
+	}
+	sort.Strings(gotUnknown)
+	if diff := pretty.Compare(wantUnknown, gotUnknown); diff != "" {
+		t.Errorf("unknown fields: want != got: %s", diff)
+	}
+
+	got, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != doc {
+		t.Errorf("round trip changed document:\nwant %s\ngot  %s", doc, got)
+	}
+
+	// Unknown fields are dropped with the objects holding them.
+	delete(s.Paths, "/owners")
+	got, err = json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(got), "cacheable") || !strings.Contains(string(got), `"rateLimit":{"rps":10}`) {
+		t.Errorf("after removing a path: %s", got)
+	}
+}
+
+func TestYAMLParity(t *testing.T) {
+	requireYAML(t)
+	files, err := filepath.Glob("testdata/*.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var s Swagger
+		if err := UnmarshalYAML(data, &s); err != nil {
+			t.Errorf("%s: unmarshal: %v", file, err)
+			continue
+		}
+		out, err := MarshalYAML(s)
+		if err != nil {
+			t.Errorf("%s: marshal: %v", file, err)
+			continue
+		}
+		// The YAML encoding should hold the same values as the JSON one.
+		jsonData, err := json.Marshal(s)
+		if err != nil {
+			t.Errorf("%s: marshal JSON: %v", file, err)
+			continue
+		}
+		want, err := rawdoc.Decode(jsonData)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := rawdoc.Decode(out)
+		if err != nil {
+			t.Errorf("%s: decoding output: %v\n%s", file, err, out)
+			continue
+		}
+		if diff := pretty.Compare(got, want); diff != "" {
+			t.Errorf("%s: YAML and JSON encodings differ: %s", file, diff)
+		}
+		var roundTrip Swagger
+		if err := UnmarshalYAML(out, &roundTrip); err != nil {
+			t.Errorf("%s: unmarshal output: %v", file, err)
+			continue
+		}
+		if diff := pretty.Compare(roundTrip, s); diff != "" {
+			t.Errorf("%s: round trip: want != got: %s", file, diff)
+		}
+	}
+
+	// Both formats of the same document should decode to the same values.
+	yamlData, err := ioutil.ReadFile("testdata/petstore-minimal.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonData, err := ioutil.ReadFile("testdata/petstore-minimal.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fromYAML, fromJSON Swagger
+	if err := UnmarshalYAML(yamlData, &fromYAML); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(jsonData, &fromJSON); err != nil {
+		t.Fatal(err)
+	}
+	if diff := pretty.Compare(fromYAML, fromJSON); diff != "" {
+		t.Errorf("petstore-minimal: YAML != JSON: %s", diff)
+	}
+}
+
+func TestYAMLUntypedValues(t *testing.T) {
+	requireYAML(t)
+	const doc = `
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+paths:
+  /pets:
+    get:
+      parameters:
+      - {name: limit, in: query, type: integer, default: 20}
+      responses:
+        200:
+          description: Pets.
+          examples:
+            application/json: [{id: 1, tags: {color: brown}}]
+`
+	var s Swagger
+	if err := UnmarshalYAML([]byte(doc), &s); err != nil {
+		t.Fatal(err)
+	}
+	op := s.Paths["/pets"].Get
+	if diff := pretty.Compare(op.Parameters[0].Default, float64(20)); diff != "" {
+		t.Errorf("default: want != got: %s", diff)
+	}
+	wantExamples := []interface{}{
+		map[string]interface{}{"id": float64(1), "tags": map[string]interface{}{"color": "brown"}},
+	}
+	if diff := pretty.Compare(op.Responses["200"].Examples["application/json"], wantExamples); diff != "" {
+		t.Errorf("examples: want != got: %s", diff)
+	}
+	if _, ok := op.Responses["200"].Examples["application/json"].([]interface{})[0].(map[string]interface{}); !ok {
+		t.Errorf("expected examples to hold map[string]interface{}")
+	}
+
+	// Values kept by UnmarshalRaw are written as YAML, not as lists of bytes.
+	var raw Swagger
+	if err := UnmarshalRaw([]byte(doc), &raw); err != nil {
+		t.Fatal(err)
+	}
+	out, err := MarshalYAML(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var roundTrip Swagger
+	if err := UnmarshalYAML(out, &roundTrip); err != nil {
+		t.Fatalf("unmarshal output: %v\n%s", err, out)
+	}
+	if diff := pretty.Compare(roundTrip, s); diff != "" {
+		t.Errorf("round trip of raw values: want != got: %s", diff)
+	}
+
+	if err := UnmarshalYAML([]byte(doc), s); err == nil {
+		t.Errorf("expected error unmarshaling into a non-pointer")
+	}
+}
+
+func TestLoadSave(t *testing.T) {
+	requireYAML(t)
+	var logs bytes.Buffer
+	fromJSON, err := LoadOptions{Logger: log.New(&logs, "", 0)}.Load("testdata/petstore-minimal.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(logs.String(), "\n"), "\n")
+	if len(lines) != 3 || lines[0] != "load: started" ||
+		!strings.HasPrefix(lines[1], "spec: loaded testdata/petstore-minimal.json as json (") ||
+		!strings.HasPrefix(lines[2], "load: finished in ") {
+		t.Errorf("unexpected log output %q", logs.String())
+	}
+	fromYAML, err := Load("testdata/petstore-minimal.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := pretty.Compare(fromYAML, fromJSON); diff != "" {
+		t.Errorf("Load: YAML != JSON: %s", diff)
+	}
+	for _, file := range []string{"testdata/petstore-minimal.json", "testdata/petstore-minimal.yaml"} {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := LoadReader(bytes.NewReader(data))
+		if err != nil {
+			t.Errorf("LoadReader %s: %v", file, err)
+			continue
+		}
+		if diff := pretty.Compare(got, fromJSON); diff != "" {
+			t.Errorf("LoadReader %s: want != got: %s", file, diff)
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "spec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	tests := []struct {
+		name   string
+		format Format
+		// prefix is how the saved file should begin.
+		prefix string
+	}{
+		{"pets.json", JSON, "{\n  \"swagger\": \"2.0\""},
+		{"pets.yaml", YAML, "swagger:"},
+		{"pets.YML", YAML, "swagger:"},
+		// Files without a known extension are sniffed when loading.
+		{"pets", JSON, "{"},
+		{"pets.txt", YAML, "swagger:"},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.name)
+		if err := fromJSON.Save(path, tt.format); err != nil {
+			t.Errorf("%s: save: %v", tt.name, err)
+			continue
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(string(data), tt.prefix) {
+			t.Errorf("%s: expected file to begin with %q, got:\n%s", tt.name, tt.prefix, data)
+		}
+		got, err := Load(path)
+		if err != nil {
+			t.Errorf("%s: load: %v", tt.name, err)
+			continue
+		}
+		if diff := pretty.Compare(got, fromJSON); diff != "" {
+			t.Errorf("%s: round trip: want != got: %s", tt.name, diff)
+		}
+	}
+
+	if err := fromJSON.Save(filepath.Join(dir, "pets.xml"), Format(7)); err == nil {
+		t.Errorf("expected error saving in an unknown format")
+	}
+	if _, err := Load(filepath.Join(dir, "missing.json")); err == nil {
+		t.Errorf("expected error loading a missing file")
+	}
+}
+
+func TestCoreImports(t *testing.T) {
+	const module = "github.com/ericchiang/swaggopher/"
+	tests := []struct {
+		tags []string
+		// The imports allowed besides the standard library.
+		allowed []string
+	}{
+		{allowed: []string{"gopkg.in/yaml.v2"}},
+		{tags: []string{"noyaml"}},
+	}
+	for _, test := range tests {
+		ctx := build.Default
+		ctx.BuildTags = test.tags
+		dirs := []string{"."}
+		for i := 0; i < len(dirs); i++ {
+			pkg, err := ctx.ImportDir(dirs[i], 0)
+			if err != nil {
+				t.Fatalf("tags %v: %s: %v", test.tags, dirs[i], err)
+			}
+			for _, path := range pkg.Imports {
+				switch {
+				case strings.HasPrefix(path, module+"internal/"):
+					dirs = append(dirs, filepath.Join("..", strings.TrimPrefix(path, module)))
+				case !strings.Contains(strings.Split(path, "/")[0], "."):
+					// The standard library.
+				case !contains(test.allowed, path):
+					t.Errorf("tags %v: %s imports %s", test.tags, dirs[i], path)
+				}
+			}
+		}
+	}
+}
+
+// requireYAML skips tests which read or write YAML in builds with the noyaml
+// tag.
+func requireYAML(t *testing.T) {
+	t.Helper()
+	if _, err := rawdoc.MarshalYAML(nil); err == rawdoc.ErrNoYAML {
+		t.Skip("built with the noyaml tag")
+	}
+}
+
+func TestFootprint(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/petstore-expanded.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s Swagger
+	if err := json.Unmarshal(data, &s); err != nil {
+		t.Fatal(err)
+	}
+	u := Footprint(&s)
+	var sum int64
+	for _, n := range u.Sections {
+		sum += n
+	}
+	if sum == 0 || sum > u.Bytes {
+		t.Errorf("sections sum to %d bytes, expected between 1 and the document's %d", sum, u.Bytes)
+	}
+	if got := u.Largest(2); !contains(got, "paths") || !contains(got, "definitions") {
+		t.Errorf("expected paths and definitions to be the largest sections, got %v of %v", got, u.Sections)
+	}
+	if _, ok := u.Sections["securityDefinitions"]; ok {
+		t.Errorf("expected no section for empty securityDefinitions")
+	}
+
+	// A definition grows its section by at least the size of its strings.
+	s.Definitions["Big"] = Schema{Description: strings.Repeat("x", 1<<16)}
+	grown := Footprint(&s)
+	if d := grown.Sections["definitions"] - u.Sections["definitions"]; d < 1<<16 {
+		t.Errorf("expected definitions to grow by at least %d bytes, grew by %d", 1<<16, d)
+	}
+
+	// Values referred to more than once are counted once.
+	op := &Operation{Description: strings.Repeat("x", 1<<16)}
+	shared := Swagger{Paths: Paths{"/a": {Get: op}, "/b": {Get: op}}}
+	once := Swagger{Paths: Paths{"/a": {Get: op}, "/b": {}}}
+	if a, b := Footprint(&shared).Bytes, Footprint(&once).Bytes; a != b {
+		t.Errorf("expected a shared operation to be counted once, got %d bytes, want %d", a, b)
+	}
+}
@@ -0,0 +1,109 @@
+package spec
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/internal/jsonpointer"
+	"github.com/ericchiang/swaggopher/internal/rawdoc"
+)
+
+// UnknownFieldsError is returned by UnmarshalStrict when a document holds fields
+// which aren't part of the specification, such as misspelled keys.
+type UnknownFieldsError struct {
+	// Paths holds a JSON pointer to each unknown field, in sorted order.
+	Paths []string
+}
+
+func (e *UnknownFieldsError) Error() string {
+	return "spec: unknown fields: " + strings.Join(e.Paths, ", ")
+}
+
+// UnmarshalStrict decodes a JSON or YAML document into v, which should be a
+// pointer to one of the types in this package, such as *Swagger. Unlike
+// json.Unmarshal and yaml.Unmarshal, which ignore fields they don't know about,
+// it returns an *UnknownFieldsError listing every unknown field. Vendor
+// extensions are allowed on the objects which support them.
+func UnmarshalStrict(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("spec: UnmarshalStrict requires a non-nil pointer, got %T", v)
+	}
+	doc, err := rawdoc.Decode(data)
+	if err != nil {
+		return err
+	}
+	var unknown []string
+	unknownFields(rv.Type().Elem(), doc, "", &unknown)
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return &UnknownFieldsError{Paths: unknown}
+	}
+	if rawdoc.IsJSON(data) {
+		return json.Unmarshal(data, v)
+	}
+	return rawdoc.UnmarshalYAML(data, v)
+}
+
+var additionalPropertiesType = reflect.TypeOf(AdditionalProperties{})
+
+// unknownFields walks a decoded document alongside the type it's being decoded
+// into, recording the path of each field the type doesn't declare. Values of
+// the wrong type are ignored, and left for the decoder to report.
+func unknownFields(t reflect.Type, v interface{}, path string, unknown *[]string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == additionalPropertiesType {
+		// Either a boolean or a schema.
+		t = reflect.TypeOf(Schema{})
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return
+		}
+		fields := make(map[string]reflect.Type)
+		extensions := false
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name := strings.Split(f.Tag.Get("json"), ",")[0]
+			switch {
+			case f.Name == "Extensions" && name == "-":
+				extensions = true
+			case name != "" && name != "-":
+				fields[name] = f.Type
+			}
+		}
+		for key, val := range obj {
+			p := jsonpointer.Join(path, key)
+			if ft, ok := fields[key]; ok {
+				unknownFields(ft, val, p, unknown)
+			} else if !extensions || !isExtension(key) {
+				*unknown = append(*unknown, p)
+			}
+		}
+	case reflect.Map:
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return
+		}
+		for key, val := range obj {
+			unknownFields(t.Elem(), val, jsonpointer.Join(path, key), unknown)
+		}
+	case reflect.Slice:
+		arr, ok := v.([]interface{})
+		if !ok {
+			return
+		}
+		for i, val := range arr {
+			unknownFields(t.Elem(), val, jsonpointer.Join(path, strconv.Itoa(i)), unknown)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package spec
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/ericchiang/swaggopher/internal/jsonpointer"
+	"github.com/ericchiang/swaggopher/internal/mapkeys"
+)
+
+// SkipChildren may be returned by a Visitor's methods to stop Walk from visiting
+// the children of the node, such as the operations of a path item or the
+// properties of a schema. Walk doesn't return it.
+var SkipChildren = errors.New("skip children")
+
+// Visitor's methods are called by Walk with each node of a document, and the
+// JSON pointer of the node, such as "/paths/~1pets/get". An error other than
+// SkipChildren stops the walk and is returned by Walk.
+//
+// Embed BaseVisitor to only implement some of the methods.
+type Visitor interface {
+	VisitPathItem(pointer string, item *PathItem) error
+	VisitOperation(pointer string, op *Operation) error
+	VisitParameter(pointer string, p *Parameter) error
+	VisitResponse(pointer string, r *Response) error
+	VisitHeader(pointer string, h *Header) error
+	VisitSchema(pointer string, s *Schema) error
+}
+
+// BaseVisitor implements every method of Visitor by doing nothing.
+type BaseVisitor struct{}
+
+func (BaseVisitor) VisitPathItem(pointer string, item *PathItem) error { return nil }
+func (BaseVisitor) VisitOperation(pointer string, op *Operation) error { return nil }
+func (BaseVisitor) VisitParameter(pointer string, p *Parameter) error  { return nil }
+func (BaseVisitor) VisitResponse(pointer string, r *Response) error    { return nil }
+func (BaseVisitor) VisitHeader(pointer string, h *Header) error        { return nil }
+func (BaseVisitor) VisitSchema(pointer string, s *Schema) error        { return nil }
+
+// WalkOptions configures Walk.
+type WalkOptions struct {
+	// Mutate stores the changes visitors make to the nodes they're passed in
+	// the document. Without it, nodes must not be modified, since those held
+	// in maps, such as path items and definitions, are passed as copies.
+	Mutate bool
+}
+
+// Walk calls the visitor with every path item, operation, parameter, response,
+// header and schema of a document. Parents are visited before their children,
+// and maps in the order of their keys. References aren't followed, so a node
+// with a "$ref" is visited as it appears.
+func Walk(doc *Swagger, v Visitor) error {
+	return WalkOptions{}.Walk(doc, v)
+}
+
+// Walk calls the visitor with every node of a document, as described by the
+// Walk function.
+func (o WalkOptions) Walk(doc *Swagger, v Visitor) error {
+	w := &walker{v: v, mutate: o.Mutate}
+	for _, path := range mapkeys.Sorted(doc.Paths) {
+		item := doc.Paths[path]
+		if err := w.pathItem(jsonpointer.Join("/paths", path), &item); err != nil {
+			return err
+		}
+		if w.mutate {
+			doc.Paths[path] = item
+		}
+	}
+	for _, name := range mapkeys.Sorted(doc.Parameters) {
+		p := doc.Parameters[name]
+		if err := w.parameter(jsonpointer.Join("/parameters", name), &p); err != nil {
+			return err
+		}
+		if w.mutate {
+			doc.Parameters[name] = p
+		}
+	}
+	for _, name := range mapkeys.Sorted(doc.Responses) {
+		r := doc.Responses[name]
+		if err := w.response(jsonpointer.Join("/responses", name), &r); err != nil {
+			return err
+		}
+		if w.mutate {
+			doc.Responses[name] = r
+		}
+	}
+	for _, name := range mapkeys.Sorted(doc.Definitions) {
+		s := doc.Definitions[name]
+		if err := w.schema(jsonpointer.Join("/definitions", name), &s); err != nil {
+			return err
+		}
+		if w.mutate {
+			doc.Definitions[name] = s
+		}
+	}
+	return nil
+}
+
+type walker struct {
+	v      Visitor
+	mutate bool
+}
+
+// visit returns whether to visit the children of a node, given the error its
+// visitor returned.
+func visit(err error) (bool, error) {
+	if err == SkipChildren {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (w *walker) pathItem(pointer string, item *PathItem) error {
+	if ok, err := visit(w.v.VisitPathItem(pointer, item)); !ok {
+		return err
+	}
+	if err := w.parameters(pointer, item.Parameters); err != nil {
+		return err
+	}
+	var err error
+	item.RangeOperations(func(method string, op *Operation) bool {
+		err = w.operation(jsonpointer.Join(pointer, method), op)
+		return err == nil
+	})
+	return err
+}
+
+func (w *walker) operation(pointer string, op *Operation) error {
+	if ok, err := visit(w.v.VisitOperation(pointer, op)); !ok {
+		return err
+	}
+	if err := w.parameters(pointer, op.Parameters); err != nil {
+		return err
+	}
+	for _, code := range mapkeys.Sorted(op.Responses) {
+		r := op.Responses[code]
+		if err := w.response(jsonpointer.Join(pointer, "responses", code), &r); err != nil {
+			return err
+		}
+		if w.mutate {
+			op.Responses[code] = r
+		}
+	}
+	return nil
+}
+
+func (w *walker) parameters(pointer string, params []Parameter) error {
+	for i := range params {
+		if err := w.parameter(jsonpointer.Join(pointer, "parameters", strconv.Itoa(i)), &params[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *walker) parameter(pointer string, p *Parameter) error {
+	if ok, err := visit(w.v.VisitParameter(pointer, p)); !ok {
+		return err
+	}
+	if p.Schema != nil {
+		return w.schema(jsonpointer.Join(pointer, "schema"), p.Schema)
+	}
+	return nil
+}
+
+func (w *walker) response(pointer string, r *Response) error {
+	if ok, err := visit(w.v.VisitResponse(pointer, r)); !ok {
+		return err
+	}
+	for _, name := range mapkeys.Sorted(r.Headers) {
+		h := r.Headers[name]
+		if err := w.v.VisitHeader(jsonpointer.Join(pointer, "headers", name), &h); err != nil && err != SkipChildren {
+			return err
+		}
+		if w.mutate {
+			r.Headers[name] = h
+		}
+	}
+	if r.Schema != nil {
+		return w.schema(jsonpointer.Join(pointer, "schema"), r.Schema)
+	}
+	return nil
+}
+
+func (w *walker) schema(pointer string, s *Schema) error {
+	if ok, err := visit(w.v.VisitSchema(pointer, s)); !ok {
+		return err
+	}
+	if s.Items != nil {
+		if err := w.schema(jsonpointer.Join(pointer, "items"), s.Items); err != nil {
+			return err
+		}
+	}
+	for i := range s.AllOf {
+		if err := w.schema(jsonpointer.Join(pointer, "allOf", strconv.Itoa(i)), &s.AllOf[i]); err != nil {
+			return err
+		}
+	}
+	for _, name := range mapkeys.Sorted(s.Properties) {
+		prop := s.Properties[name]
+		if err := w.schema(jsonpointer.Join(pointer, "properties", name), &prop); err != nil {
+			return err
+		}
+		if w.mutate {
+			s.Properties[name] = prop
+		}
+	}
+	if s.AdditionalProperties != nil && s.AdditionalProperties.Schema != nil {
+		return w.schema(jsonpointer.Join(pointer, "additionalProperties"), s.AdditionalProperties.Schema)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package spec
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/ericchiang/swaggopher/internal/rawdoc"
+)
+
+// ErrNoYAML is returned when reading or writing YAML in a build with the noyaml
+// tag, which leaves out the YAML engine.
+var ErrNoYAML = rawdoc.ErrNoYAML
+
+// MarshalYAML encodes v, which should be one of the types in this package, as
+// YAML holding exactly the values json.Marshal would encode.
+//
+// The types' yaml.Marshaler implementations cover references and vendor
+// extensions, but gopkg.in/yaml.v2 writes a json.RawMessage kept by
+// UnmarshalRaw as a list of bytes, and knows nothing of values only encoded
+// through MarshalJSON. MarshalYAML encodes v as JSON first, so every custom
+// JSON encoding, including the key order and unknown fields kept by
+// UnmarshalOrdered and UnmarshalLossless, carries over to YAML.
+func MarshalYAML(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := yamlValue(data)
+	if err != nil {
+		return nil, err
+	}
+	return rawdoc.MarshalYAML(doc)
+}
+
+// UnmarshalYAML decodes a YAML document into v, which should be a pointer to one
+// of the types in this package, holding exactly the values json.Unmarshal
+// would decode from the equivalent JSON.
+//
+// yaml.Unmarshal decodes untyped values, such as defaults, enums and examples,
+// as map[interface{}]interface{} and int, where json.Unmarshal decodes
+// map[string]interface{} and float64. Documents decoded by UnmarshalYAML hold
+// the latter whichever format they were read from, so they compare equal and
+// encode the same. JSON documents, which are valid YAML, are also accepted.
+func UnmarshalYAML(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("spec: UnmarshalYAML requires a non-nil pointer, got %T", v)
+	}
+	doc, err := rawdoc.Decode(data)
+	if err != nil {
+		return err
+	}
+	if data, err = json.Marshal(doc); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
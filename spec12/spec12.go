@@ -0,0 +1,296 @@
+/*
+Package spec12 defines Go mappings for version 1.2 of the Swagger Specification.
+
+https://github.com/OAI/OpenAPI-Specification/blob/main/versions/1.2.md
+
+A 1.2 API is described by a resource listing, which lists the API's resources,
+and an API declaration for each resource, which describes its operations and
+models. Package convert translates them to a single 2.0 document.
+*/
+package spec12
+
+// The Resource Listing serves as the root document for the API description. It
+// contains general information about the API and an inventory of the available
+// resources.
+type ResourceListing struct {
+	// Specifies the Swagger Specification version being used, such as "1.2".
+	SwaggerVersion string `json:"swaggerVersion" yaml:"swaggerVersion"`
+	// Lists the resources to be described by this specification implementation.
+	Apis []Resource `json:"apis" yaml:"apis"`
+	// Provides the version of the application API (not to be confused by the
+	// specification version).
+	ApiVersion string `json:"apiVersion,omitempty" yaml:"apiVersion,omitempty"`
+	// Provides metadata about the API. The metadata can be used by the clients if
+	// needed, and can be presented in the Swagger-UI for convenience.
+	Info *Info `json:"info,omitempty" yaml:"info,omitempty"`
+	// Provides information about the authorization schemes allowed on this API.
+	Authorizations map[string]Authorization `json:"authorizations,omitempty" yaml:"authorizations,omitempty"`
+}
+
+// The Resource object describes a resource API endpoint in the application.
+type Resource struct {
+	// A relative path to the API declaration from the path used to retrieve this
+	// Resource Listing. This path does not necessarily have to correspond to the
+	// URL which actually serves this resource in the API but rather where the
+	// resource listing itself is served. The value SHOULD be in a relative
+	// (URL) path format.
+	Path string `json:"path" yaml:"path"`
+	// A short description of the resource.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+// The object provides metadata about the API.
+type Info struct {
+	// The title of the application.
+	Title string `json:"title" yaml:"title"`
+	// A short description of the application.
+	Description string `json:"description" yaml:"description"`
+	// A URL to the Terms of Service of the API.
+	TermsOfServiceUrl string `json:"termsOfServiceUrl,omitempty" yaml:"termsOfServiceUrl,omitempty"`
+	// An email to be used for API-related correspondence.
+	Contact string `json:"contact,omitempty" yaml:"contact,omitempty"`
+	// The license name used for the API.
+	License string `json:"license,omitempty" yaml:"license,omitempty"`
+	// A URL to the license used for the API.
+	LicenseUrl string `json:"licenseUrl,omitempty" yaml:"licenseUrl,omitempty"`
+}
+
+// The Authorization object provides information about a specific authorization
+// scheme.
+type Authorization struct {
+	// The type of the authorization scheme. Values MUST be either "basicAuth",
+	// "apiKey" or "oauth2".
+	Type string `json:"type" yaml:"type"`
+	// Denotes how the API key must be passed. Valid values are "header" or
+	// "query". Required if type is "apiKey".
+	PassAs string `json:"passAs,omitempty" yaml:"passAs,omitempty"`
+	// The name of the header or query parameter to be used when passing the API
+	// key. Required if type is "apiKey".
+	Keyname string `json:"keyname,omitempty" yaml:"keyname,omitempty"`
+	// A list of supported OAuth2 scopes.
+	Scopes []Scope `json:"scopes,omitempty" yaml:"scopes,omitempty"`
+	// Detailed information about the grant types supported by the OAuth2
+	// authorization scheme. Required if type is "oauth2".
+	GrantTypes *GrantTypes `json:"grantTypes,omitempty" yaml:"grantTypes,omitempty"`
+}
+
+// Describes an OAuth2 authorization scope.
+type Scope struct {
+	// The name of the scope.
+	Scope string `json:"scope" yaml:"scope"`
+	// A short description of the scope.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+// Provides details regarding the OAuth2 grant types that are supported by the
+// API.
+type GrantTypes struct {
+	// The Implicit Grant flow definition.
+	Implicit *Implicit `json:"implicit,omitempty" yaml:"implicit,omitempty"`
+	// The Authorization Code Grant flow definition.
+	AuthorizationCode *AuthorizationCode `json:"authorization_code,omitempty" yaml:"authorization_code,omitempty"`
+}
+
+// Provides details regarding the OAuth2's Implicit Grant flow type.
+type Implicit struct {
+	// The login endpoint definition.
+	LoginEndpoint LoginEndpoint `json:"loginEndpoint" yaml:"loginEndpoint"`
+	// An optional alternative name to standard "access_token" OAuth2 parameter.
+	TokenName string `json:"tokenName,omitempty" yaml:"tokenName,omitempty"`
+}
+
+// Provides details regarding the OAuth2's Authorization Code Grant flow type.
+type AuthorizationCode struct {
+	// The token request endpoint definition.
+	TokenRequestEndpoint TokenRequestEndpoint `json:"tokenRequestEndpoint" yaml:"tokenRequestEndpoint"`
+	// The token endpoint definition.
+	TokenEndpoint TokenEndpoint `json:"tokenEndpoint" yaml:"tokenEndpoint"`
+}
+
+// The Login Endpoint object provides details on the endpoint on which the
+// resource owner logs in.
+type LoginEndpoint struct {
+	// The URL of the authorization endpoint for the implicit grant flow.
+	Url string `json:"url" yaml:"url"`
+}
+
+// Provides details regarding the OAuth2's Authorization Code Grant flow type.
+type TokenRequestEndpoint struct {
+	// The URL of the authorization endpoint for the authentication code grant
+	// flow.
+	Url string `json:"url" yaml:"url"`
+	// An optional alternative name to standard "client_id" OAuth2 parameter.
+	ClientIdName string `json:"clientIdName,omitempty" yaml:"clientIdName,omitempty"`
+	// An optional alternative name to the standard "client_secret" OAuth2
+	// parameter.
+	ClientSecretName string `json:"clientSecretName,omitempty" yaml:"clientSecretName,omitempty"`
+}
+
+// Provides details regarding the OAuth2's Authorization Code Grant flow type.
+type TokenEndpoint struct {
+	// The URL of the token endpoint for the authentication code grant flow.
+	Url string `json:"url" yaml:"url"`
+	// An optional alternative name to standard "access_token" OAuth2 parameter.
+	TokenName string `json:"tokenName,omitempty" yaml:"tokenName,omitempty"`
+}
+
+// The API Declaration provides information about an API exposed on a resource.
+// There should be one file per Resource described.
+type APIDeclaration struct {
+	// Specifies the Swagger Specification version being used, such as "1.2".
+	SwaggerVersion string `json:"swaggerVersion" yaml:"swaggerVersion"`
+	// Provides the version of the application API (not to be confused by the
+	// specification version).
+	ApiVersion string `json:"apiVersion,omitempty" yaml:"apiVersion,omitempty"`
+	// The root URL serving the API.
+	BasePath string `json:"basePath" yaml:"basePath"`
+	// The relative path to the resource, from the basePath, which this API
+	// Specification describes.
+	ResourcePath string `json:"resourcePath,omitempty" yaml:"resourcePath,omitempty"`
+	// A list of the APIs exposed on this resource.
+	Apis []API `json:"apis" yaml:"apis"`
+	// A list of the models available to this resource, keyed by their IDs.
+	Models map[string]Model `json:"models,omitempty" yaml:"models,omitempty"`
+	// A list of MIME types the APIs on this resource can produce.
+	Produces []string `json:"produces,omitempty" yaml:"produces,omitempty"`
+	// A list of MIME types the APIs on this resource can consume.
+	Consumes []string `json:"consumes,omitempty" yaml:"consumes,omitempty"`
+	// A list of authorizations schemes required for the operations listed in
+	// this API declaration, keyed by the names declared by the resource
+	// listing.
+	Authorizations map[string][]Scope `json:"authorizations,omitempty" yaml:"authorizations,omitempty"`
+}
+
+// The API Object describes one or more operations on a single path.
+type API struct {
+	// The relative path to the operation, from the basePath, which this
+	// operation describes. The value SHOULD be in a relative (URL) path format.
+	Path string `json:"path" yaml:"path"`
+	// A short description of the resource.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// A list of the API operations available on this path.
+	Operations []Operation `json:"operations" yaml:"operations"`
+}
+
+// DataType holds the fields which describe the type of a parameter, a model's
+// property or an operation's return value.
+type DataType struct {
+	// The return type of the operation, or the type of the parameter or
+	// property. It's either a primitive, "array", "File", "void" or the ID of a
+	// model. Exactly one of Type and Ref is set.
+	Type string `json:"type,omitempty" yaml:"type,omitempty"`
+	// The ID of a model.
+	Ref string `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	// Fine-tuned primitive type definition.
+	Format string `json:"format,omitempty" yaml:"format,omitempty"`
+	// The default value to be used for the field. Strictly a string, though
+	// many documents use other JSON types.
+	DefaultValue interface{} `json:"defaultValue,omitempty" yaml:"defaultValue,omitempty"`
+	// A fixed list of possible values.
+	Enum []interface{} `json:"enum,omitempty" yaml:"enum,omitempty"`
+	// The minimum valid value for the type, inclusive, as a string.
+	Minimum string `json:"minimum,omitempty" yaml:"minimum,omitempty"`
+	// The maximum valid value for the type, inclusive, as a string.
+	Maximum string `json:"maximum,omitempty" yaml:"maximum,omitempty"`
+	// The type definition of the values in the container. Required if type is
+	// "array".
+	Items *Items `json:"items,omitempty" yaml:"items,omitempty"`
+	// A flag to note whether the container allows duplicate values or not.
+	UniqueItems bool `json:"uniqueItems,omitempty" yaml:"uniqueItems,omitempty"`
+}
+
+// The Items object describes the values of an array.
+type Items struct {
+	// A primitive type. Exactly one of Type and Ref is set.
+	Type string `json:"type,omitempty" yaml:"type,omitempty"`
+	// The ID of a model.
+	Ref string `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	// Fine-tuned primitive type definition.
+	Format string `json:"format,omitempty" yaml:"format,omitempty"`
+}
+
+// The Operation Object describes a single operation on a path.
+type Operation struct {
+	// The return type of the operation. Type "void" declares that the
+	// operation returns no value.
+	DataType `yaml:",inline"`
+	// The HTTP method required to invoke this operation.
+	Method string `json:"method" yaml:"method"`
+	// A short summary of what the operation does.
+	Summary string `json:"summary,omitempty" yaml:"summary,omitempty"`
+	// A verbose explanation of the operation behavior.
+	Notes string `json:"notes,omitempty" yaml:"notes,omitempty"`
+	// A unique id for the operation that can be used by tools reading the
+	// output for further and easier manipulation.
+	Nickname string `json:"nickname" yaml:"nickname"`
+	// A list of authorizations required to execute this operation. An empty
+	// object means no authorization is required; if absent, those of the API
+	// declaration apply.
+	Authorizations map[string][]Scope `json:"authorizations,omitempty" yaml:"authorizations,omitempty"`
+	// The inputs to the operation.
+	Parameters []Parameter `json:"parameters" yaml:"parameters"`
+	// Lists the possible response statuses that can return from the operation.
+	ResponseMessages []ResponseMessage `json:"responseMessages,omitempty" yaml:"responseMessages,omitempty"`
+	// A list of MIME types this operation can produce.
+	Produces []string `json:"produces,omitempty" yaml:"produces,omitempty"`
+	// A list of MIME types this operation can consume.
+	Consumes []string `json:"consumes,omitempty" yaml:"consumes,omitempty"`
+	// Declares this operation to be deprecated, "true" or "false".
+	Deprecated string `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+}
+
+// The Parameter Object describes a single parameter to be sent in an operation.
+type Parameter struct {
+	// The type of the parameter. Body parameters may use a model, and form
+	// parameters may use "File".
+	DataType `yaml:",inline"`
+	// The type of the parameter, "path", "query", "body", "header" or "form".
+	ParamType string `json:"paramType" yaml:"paramType"`
+	// The unique name for the parameter. Body parameters are named "body".
+	Name string `json:"name" yaml:"name"`
+	// A brief description of this parameter.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// A flag to note whether this parameter is required. If paramType is
+	// "path" then this field MUST be true.
+	Required bool `json:"required,omitempty" yaml:"required,omitempty"`
+	// Another way to allow multiple values for a "query", "header" or "path"
+	// parameter.
+	AllowMultiple bool `json:"allowMultiple,omitempty" yaml:"allowMultiple,omitempty"`
+}
+
+// The Response Message Object describes a single possible response message
+// that can be returned from the operation call.
+type ResponseMessage struct {
+	// The HTTP status code returned.
+	Code int `json:"code" yaml:"code"`
+	// The explanation for the status code.
+	Message string `json:"message" yaml:"message"`
+	// The return type for the given response, the ID of a model.
+	ResponseModel string `json:"responseModel,omitempty" yaml:"responseModel,omitempty"`
+}
+
+// A Model Object holds the definition of a new model for this API Declaration.
+type Model struct {
+	// A unique identifier for the model. This MUST be the name given to the
+	// model.
+	Id string `json:"id" yaml:"id"`
+	// A brief description of this model.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// A definition of which properties MUST exist when a model instance is
+	// produced.
+	Required []string `json:"required,omitempty" yaml:"required,omitempty"`
+	// A list of properties (fields) that are part of the model.
+	Properties map[string]Property `json:"properties" yaml:"properties"`
+	// List of the model IDs that inherit from this model.
+	SubTypes []string `json:"subTypes,omitempty" yaml:"subTypes,omitempty"`
+	// The name of the property which decides which sub-type a model instance
+	// is. MUST be included if SubTypes is set.
+	Discriminator string `json:"discriminator,omitempty" yaml:"discriminator,omitempty"`
+}
+
+// A Property Object holds the definition of a new property for a model.
+type Property struct {
+	DataType `yaml:",inline"`
+	// A brief description of this property.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
@@ -0,0 +1,68 @@
+package spec12
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestParse(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/api-docs.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var listing ResourceListing
+	if err := json.Unmarshal(data, &listing); err != nil {
+		t.Fatal(err)
+	}
+	if len(listing.Apis) != 2 || listing.Apis[0].Path != "/pet" {
+		t.Errorf("unexpected resources %+v", listing.Apis)
+	}
+	if a := listing.Authorizations["oauth2"]; a.GrantTypes == nil || a.GrantTypes.Implicit == nil {
+		t.Errorf("expected an implicit grant, got %+v", a)
+	}
+
+	data, err = ioutil.ReadFile("testdata/pet.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decl APIDeclaration
+	if err := json.Unmarshal(data, &decl); err != nil {
+		t.Fatal(err)
+	}
+	want := Operation{
+		DataType: DataType{Type: "array", Items: &Items{Ref: "Pet"}},
+		Method:   "GET",
+		Summary:  "Finds Pets by status",
+		Nickname: "findPetsByStatus",
+		Parameters: []Parameter{
+			{
+				DataType: DataType{
+					Type:         "string",
+					DefaultValue: "available",
+					Enum:         []interface{}{"available", "pending", "sold"},
+				},
+				ParamType:     "query",
+				Name:          "status",
+				Required:      true,
+				AllowMultiple: true,
+			},
+		},
+	}
+	if diff := pretty.Compare(want, decl.Apis[2].Operations[0]); diff != "" {
+		t.Errorf("operation: %s", diff)
+	}
+
+	// JSON is valid YAML, and both decoders should agree.
+	var fromYAML APIDeclaration
+	if err := yaml.Unmarshal(data, &fromYAML); err != nil {
+		t.Fatal(err)
+	}
+	if diff := pretty.Compare(decl, fromYAML); diff != "" {
+		t.Errorf("decoding YAML: %s", diff)
+	}
+}
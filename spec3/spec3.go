@@ -0,0 +1,537 @@
+/*
+Package spec3 defines Go mappings for version 3.0 of the OpenAPI Specification.
+
+https://github.com/OAI/OpenAPI-Specification/blob/master/versions/3.0.3.md
+*/
+package spec3
+
+// This is the root document object of the OpenAPI document.
+type OpenAPI struct {
+	// This string MUST be the semantic version number of the OpenAPI Specification
+	// version that the OpenAPI document uses, such as "3.0.3".
+	OpenAPI string `json:"openapi" yaml:"openapi"`
+	// Provides metadata about the API. The metadata MAY be used by tooling as required.
+	Info *Info `json:"info" yaml:"info"`
+	// An array of Server Objects, which provide connectivity information to a target
+	// server. If not provided, the default value is a Server Object with a url value of /.
+	Servers []Server `json:"servers,omitempty" yaml:"servers,omitempty"`
+	// The available paths and operations for the API.
+	Paths Paths `json:"paths" yaml:"paths"`
+	// An element to hold various schemas for the specification.
+	Components *Components `json:"components,omitempty" yaml:"components,omitempty"`
+	// A declaration of which security mechanisms can be used across the API.
+	Security []SecurityRequirement `json:"security,omitempty" yaml:"security,omitempty"`
+	// A list of tags used by the specification with additional metadata.
+	Tags []Tag `json:"tags,omitempty" yaml:"tags,omitempty"`
+	// Additional external documentation.
+	ExternalDocs *ExternalDocumentation `json:"externalDocs,omitempty" yaml:"externalDocs,omitempty"`
+}
+
+// The object provides metadata about the API.
+type Info struct {
+	// The title of the application.
+	Title string `json:"title" yaml:"title"`
+	// A short description of the application. CommonMark syntax MAY be used for rich
+	// text representation.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// A URL to the Terms of Service for the API. MUST be in the format of a URL.
+	TermsOfService string `json:"termsOfService,omitempty" yaml:"termsOfService,omitempty"`
+	// The contact information for the exposed API.
+	Contact *Contact `json:"contact,omitempty" yaml:"contact,omitempty"`
+	// The license information for the exposed API.
+	License *License `json:"license,omitempty" yaml:"license,omitempty"`
+	// The version of the OpenAPI document (which is distinct from the OpenAPI
+	// Specification version or the API implementation version).
+	Version string `json:"version" yaml:"version"`
+}
+
+// Contact information for the exposed API.
+type Contact struct {
+	// The identifying name of the contact person/organization.
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// The URL pointing to the contact information.
+	Url string `json:"url,omitempty" yaml:"url,omitempty"`
+	// The email address of the contact person/organization.
+	Email string `json:"email,omitempty" yaml:"email,omitempty"`
+}
+
+// License information for the exposed API.
+type License struct {
+	// The license name used for the API.
+	Name string `json:"name" yaml:"name"`
+	// A URL to the license used for the API.
+	Url string `json:"url,omitempty" yaml:"url,omitempty"`
+}
+
+// An object representing a Server.
+type Server struct {
+	// A URL to the target host. This URL supports Server Variables and MAY be
+	// relative, to indicate that the host location is relative to the location where
+	// the OpenAPI document is being served.
+	Url string `json:"url" yaml:"url"`
+	// An optional string describing the host designated by the URL.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// A map between a variable name and its value. The value is used for
+	// substitution in the server's URL template.
+	Variables map[string]ServerVariable `json:"variables,omitempty" yaml:"variables,omitempty"`
+}
+
+// An object representing a Server Variable for server URL template substitution.
+type ServerVariable struct {
+	// An enumeration of string values to be used if the substitution options are
+	// from a limited set.
+	Enum []string `json:"enum,omitempty" yaml:"enum,omitempty"`
+	// The default value to use for substitution, which SHALL be sent if an alternate
+	// value is not supplied.
+	Default string `json:"default" yaml:"default"`
+	// An optional description for the server variable.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+// Holds a set of reusable objects for different aspects of the OAS. All objects
+// defined within the components object will have no effect on the API unless they
+// are explicitly referenced from properties outside the components object.
+type Components struct {
+	// An object to hold reusable Schema Objects.
+	Schemas map[string]Schema `json:"schemas,omitempty" yaml:"schemas,omitempty"`
+	// An object to hold reusable Response Objects.
+	Responses map[string]Response `json:"responses,omitempty" yaml:"responses,omitempty"`
+	// An object to hold reusable Parameter Objects.
+	Parameters map[string]Parameter `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	// An object to hold reusable Example Objects.
+	Examples map[string]Example `json:"examples,omitempty" yaml:"examples,omitempty"`
+	// An object to hold reusable Request Body Objects.
+	RequestBodies map[string]RequestBody `json:"requestBodies,omitempty" yaml:"requestBodies,omitempty"`
+	// An object to hold reusable Header Objects.
+	Headers map[string]Header `json:"headers,omitempty" yaml:"headers,omitempty"`
+	// An object to hold reusable Security Scheme Objects.
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty" yaml:"securitySchemes,omitempty"`
+	// An object to hold reusable Link Objects.
+	Links map[string]Link `json:"links,omitempty" yaml:"links,omitempty"`
+	// An object to hold reusable Callback Objects.
+	Callbacks map[string]Callback `json:"callbacks,omitempty" yaml:"callbacks,omitempty"`
+}
+
+// Holds the relative paths to the individual endpoints and their operations. The
+// path is appended to the URL from the Server Object in order to construct the
+// full URL.
+type Paths map[string]PathItem
+
+// Describes the operations available on a single path.
+type PathItem struct {
+	// Allows for an external definition of this path item.
+	Ref string `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	// An optional, string summary, intended to apply to all operations in this path.
+	Summary string `json:"summary,omitempty" yaml:"summary,omitempty"`
+	// An optional, string description, intended to apply to all operations in this
+	// path.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// A definition of a GET operation on this path.
+	Get *Operation `json:"get,omitempty" yaml:"get,omitempty"`
+	// A definition of a PUT operation on this path.
+	Put *Operation `json:"put,omitempty" yaml:"put,omitempty"`
+	// A definition of a POST operation on this path.
+	Post *Operation `json:"post,omitempty" yaml:"post,omitempty"`
+	// A definition of a DELETE operation on this path.
+	Delete *Operation `json:"delete,omitempty" yaml:"delete,omitempty"`
+	// A definition of a OPTIONS operation on this path.
+	Options *Operation `json:"options,omitempty" yaml:"options,omitempty"`
+	// A definition of a HEAD operation on this path.
+	Head *Operation `json:"head,omitempty" yaml:"head,omitempty"`
+	// A definition of a PATCH operation on this path.
+	Patch *Operation `json:"patch,omitempty" yaml:"patch,omitempty"`
+	// A definition of a TRACE operation on this path.
+	Trace *Operation `json:"trace,omitempty" yaml:"trace,omitempty"`
+	// An alternative server array to service all operations in this path.
+	Servers []Server `json:"servers,omitempty" yaml:"servers,omitempty"`
+	// A list of parameters that are applicable for all the operations described under
+	// this path.
+	Parameters []Parameter `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+}
+
+// Describes a single API operation on a path.
+type Operation struct {
+	// A list of tags for API documentation control.
+	Tags []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	// A short summary of what the operation does.
+	Summary string `json:"summary,omitempty" yaml:"summary,omitempty"`
+	// A verbose explanation of the operation behavior.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// Additional external documentation for this operation.
+	ExternalDocs *ExternalDocumentation `json:"externalDocs,omitempty" yaml:"externalDocs,omitempty"`
+	// Unique string used to identify the operation. The id MUST be unique among all
+	// operations described in the API.
+	OperationId string `json:"operationId,omitempty" yaml:"operationId,omitempty"`
+	// A list of parameters that are applicable for this operation.
+	Parameters []Parameter `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	// The request body applicable for this operation.
+	RequestBody *RequestBody `json:"requestBody,omitempty" yaml:"requestBody,omitempty"`
+	// The list of possible responses as they are returned from executing this
+	// operation.
+	Responses Responses `json:"responses" yaml:"responses"`
+	// A map of possible out-of band callbacks related to the parent operation.
+	Callbacks map[string]Callback `json:"callbacks,omitempty" yaml:"callbacks,omitempty"`
+	// Declares this operation to be deprecated.
+	Deprecated bool `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+	// A declaration of which security mechanisms can be used for this operation.
+	Security []SecurityRequirement `json:"security,omitempty" yaml:"security,omitempty"`
+	// An alternative server array to service this operation.
+	Servers []Server `json:"servers,omitempty" yaml:"servers,omitempty"`
+}
+
+// Allows referencing an external resource for extended documentation.
+type ExternalDocumentation struct {
+	// A short description of the target documentation.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// The URL for the target documentation.
+	Url string `json:"url" yaml:"url"`
+}
+
+// Describes a single operation parameter. A unique parameter is defined by a
+// combination of a name and location.
+type Parameter struct {
+	// A reference to a parameter defined in components.
+	Ref string `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	// The name of the parameter. Parameter names are case sensitive.
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// The location of the parameter. Possible values are "query", "header", "path"
+	// or "cookie".
+	In string `json:"in,omitempty" yaml:"in,omitempty"`
+	// A brief description of the parameter.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// Determines whether this parameter is mandatory. If the parameter location is
+	// "path", this property is REQUIRED and its value MUST be true.
+	Required bool `json:"required,omitempty" yaml:"required,omitempty"`
+	// Specifies that a parameter is deprecated and SHOULD be transitioned out of
+	// usage.
+	Deprecated bool `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+	// Sets the ability to pass empty-valued parameters. This is valid only for query
+	// parameters.
+	AllowEmptyValue bool `json:"allowEmptyValue,omitempty" yaml:"allowEmptyValue,omitempty"`
+	// Describes how the parameter value will be serialized depending on the type of
+	// the parameter value.
+	Style string `json:"style,omitempty" yaml:"style,omitempty"`
+	// When this is true, parameter values of type array or object generate separate
+	// parameters for each value of the array or key-value pair of the map.
+	Explode *bool `json:"explode,omitempty" yaml:"explode,omitempty"`
+	// Determines whether the parameter value SHOULD allow reserved characters to be
+	// included without percent-encoding.
+	AllowReserved bool `json:"allowReserved,omitempty" yaml:"allowReserved,omitempty"`
+	// The schema defining the type used for the parameter.
+	Schema *Schema `json:"schema,omitempty" yaml:"schema,omitempty"`
+	// Example of the parameter's potential value.
+	Example interface{} `json:"example,omitempty" yaml:"example,omitempty"`
+	// Examples of the parameter's potential value.
+	Examples map[string]Example `json:"examples,omitempty" yaml:"examples,omitempty"`
+	// A map containing the representations for the parameter. The map MUST only
+	// contain one entry.
+	Content map[string]MediaType `json:"content,omitempty" yaml:"content,omitempty"`
+}
+
+// Describes a single request body.
+type RequestBody struct {
+	// A reference to a request body defined in components.
+	Ref string `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	// A brief description of the request body.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// The content of the request body. The key is a media type or media type range
+	// and the value describes it.
+	Content map[string]MediaType `json:"content,omitempty" yaml:"content,omitempty"`
+	// Determines if the request body is required in the request.
+	Required bool `json:"required,omitempty" yaml:"required,omitempty"`
+}
+
+// Each Media Type Object provides schema and examples for the media type
+// identified by its key.
+type MediaType struct {
+	// The schema defining the content of the request, response, or parameter.
+	Schema *Schema `json:"schema,omitempty" yaml:"schema,omitempty"`
+	// Example of the media type.
+	Example interface{} `json:"example,omitempty" yaml:"example,omitempty"`
+	// Examples of the media type.
+	Examples map[string]Example `json:"examples,omitempty" yaml:"examples,omitempty"`
+	// A map between a property name and its encoding information. The encoding
+	// object SHALL only apply to requestBody objects when the media type is multipart
+	// or application/x-www-form-urlencoded.
+	Encoding map[string]Encoding `json:"encoding,omitempty" yaml:"encoding,omitempty"`
+}
+
+// A single encoding definition applied to a single schema property.
+type Encoding struct {
+	// The Content-Type for encoding a specific property.
+	ContentType string `json:"contentType,omitempty" yaml:"contentType,omitempty"`
+	// A map allowing additional information to be provided as headers, for example
+	// Content-Disposition.
+	Headers map[string]Header `json:"headers,omitempty" yaml:"headers,omitempty"`
+	// Describes how a specific property value will be serialized depending on its
+	// type.
+	Style string `json:"style,omitempty" yaml:"style,omitempty"`
+	// When this is true, property values of type array or object generate separate
+	// parameters for each value of the array, or key-value-pair of the map.
+	Explode *bool `json:"explode,omitempty" yaml:"explode,omitempty"`
+	// Determines whether the parameter value SHOULD allow reserved characters to be
+	// included without percent-encoding.
+	AllowReserved bool `json:"allowReserved,omitempty" yaml:"allowReserved,omitempty"`
+}
+
+// A container for the expected responses of an operation. The container maps a
+// HTTP response code, or "default", to the expected response.
+type Responses map[string]Response
+
+// Describes a single response from an API Operation, including design-time,
+// static links to operations based on the response.
+type Response struct {
+	// A reference to a response defined in components.
+	Ref string `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	// A short description of the response.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// Maps a header name to its definition.
+	Headers map[string]Header `json:"headers,omitempty" yaml:"headers,omitempty"`
+	// A map containing descriptions of potential response payloads. The key is a
+	// media type or media type range and the value describes it.
+	Content map[string]MediaType `json:"content,omitempty" yaml:"content,omitempty"`
+	// A map of operations links that can be followed from the response.
+	Links map[string]Link `json:"links,omitempty" yaml:"links,omitempty"`
+}
+
+// A map of possible out-of band callbacks related to the parent operation. Each
+// key is a runtime expression that identifies a URL to use for the callback
+// operation.
+type Callback map[string]PathItem
+
+// An example of a value.
+type Example struct {
+	// A reference to an example defined in components.
+	Ref string `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	// Short description for the example.
+	Summary string `json:"summary,omitempty" yaml:"summary,omitempty"`
+	// Long description for the example.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// Embedded literal example. The value field and externalValue field are mutually
+	// exclusive.
+	Value interface{} `json:"value,omitempty" yaml:"value,omitempty"`
+	// A URL that points to the literal example.
+	ExternalValue string `json:"externalValue,omitempty" yaml:"externalValue,omitempty"`
+}
+
+// The Link object represents a possible design-time link for a response.
+type Link struct {
+	// A reference to a link defined in components.
+	Ref string `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	// A relative or absolute URI reference to an OAS operation. This field is
+	// mutually exclusive of the operationId field.
+	OperationRef string `json:"operationRef,omitempty" yaml:"operationRef,omitempty"`
+	// The name of an existing, resolvable OAS operation, as defined with a unique
+	// operationId.
+	OperationId string `json:"operationId,omitempty" yaml:"operationId,omitempty"`
+	// A map representing parameters to pass to an operation as specified with
+	// operationId or identified via operationRef.
+	Parameters map[string]interface{} `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	// A literal value or runtime expression to use as a request body when calling
+	// the target operation.
+	RequestBody interface{} `json:"requestBody,omitempty" yaml:"requestBody,omitempty"`
+	// A description of the link.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// A server object to be used by the target operation.
+	Server *Server `json:"server,omitempty" yaml:"server,omitempty"`
+}
+
+// The Header Object follows the structure of the Parameter Object, except that
+// name MUST NOT be specified and in MUST NOT be specified, it is implicitly in
+// header.
+type Header struct {
+	// A reference to a header defined in components.
+	Ref string `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	// A brief description of the header.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// Determines whether this header is mandatory.
+	Required bool `json:"required,omitempty" yaml:"required,omitempty"`
+	// Specifies that a header is deprecated and SHOULD be transitioned out of usage.
+	Deprecated bool `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+	// Describes how the header value will be serialized. The only valid value is
+	// "simple".
+	Style string `json:"style,omitempty" yaml:"style,omitempty"`
+	// When this is true, header values of type array or object generate a single
+	// header with comma separated values.
+	Explode *bool `json:"explode,omitempty" yaml:"explode,omitempty"`
+	// The schema defining the type used for the header.
+	Schema *Schema `json:"schema,omitempty" yaml:"schema,omitempty"`
+	// Example of the header's potential value.
+	Example interface{} `json:"example,omitempty" yaml:"example,omitempty"`
+	// Examples of the header's potential value.
+	Examples map[string]Example `json:"examples,omitempty" yaml:"examples,omitempty"`
+	// A map containing the representations for the header.
+	Content map[string]MediaType `json:"content,omitempty" yaml:"content,omitempty"`
+}
+
+// Adds metadata to a single tag that is used by the Operation Object.
+type Tag struct {
+	// The name of the tag.
+	Name string `json:"name" yaml:"name"`
+	// A short description for the tag.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// Additional external documentation for this tag.
+	ExternalDocs *ExternalDocumentation `json:"externalDocs,omitempty" yaml:"externalDocs,omitempty"`
+}
+
+// The Schema Object allows the definition of input and output data types. This
+// object is an extended subset of the JSON Schema Specification Wright Draft 00.
+type Schema struct {
+	// A reference to a schema, such as "#/components/schemas/Pet".
+	Ref string `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+
+	// The following properties are taken directly from the JSON Schema definition
+	// and follow the same specifications.
+	Title            string        `json:"title,omitempty" yaml:"title,omitempty"`
+	MultipleOf       float64       `json:"multipleOf,omitempty" yaml:"multipleOf,omitempty"`
+	Maximum          *float64      `json:"maximum,omitempty" yaml:"maximum,omitempty"`
+	ExclusiveMaximum bool          `json:"exclusiveMaximum,omitempty" yaml:"exclusiveMaximum,omitempty"`
+	Minimum          *float64      `json:"minimum,omitempty" yaml:"minimum,omitempty"`
+	ExclusiveMinimum bool          `json:"exclusiveMinimum,omitempty" yaml:"exclusiveMinimum,omitempty"`
+	MaxLength        *int          `json:"maxLength,omitempty" yaml:"maxLength,omitempty"`
+	MinLength        int           `json:"minLength,omitempty" yaml:"minLength,omitempty"`
+	Pattern          string        `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	MaxItems         *int          `json:"maxItems,omitempty" yaml:"maxItems,omitempty"`
+	MinItems         int           `json:"minItems,omitempty" yaml:"minItems,omitempty"`
+	UniqueItems      bool          `json:"uniqueItems,omitempty" yaml:"uniqueItems,omitempty"`
+	MaxProperties    *int          `json:"maxProperties,omitempty" yaml:"maxProperties,omitempty"`
+	MinProperties    int           `json:"minProperties,omitempty" yaml:"minProperties,omitempty"`
+	Required         []string      `json:"required,omitempty" yaml:"required,omitempty"`
+	Enum             []interface{} `json:"enum,omitempty" yaml:"enum,omitempty"`
+
+	// Value MUST be a string. Multiple types via an array are not supported.
+	Type string `json:"type,omitempty" yaml:"type,omitempty"`
+	// Inline or referenced schemas which the value MUST validate against all of.
+	AllOf []Schema `json:"allOf,omitempty" yaml:"allOf,omitempty"`
+	// Inline or referenced schemas which the value MUST validate against exactly one
+	// of.
+	OneOf []Schema `json:"oneOf,omitempty" yaml:"oneOf,omitempty"`
+	// Inline or referenced schemas which the value MUST validate against at least
+	// one of.
+	AnyOf []Schema `json:"anyOf,omitempty" yaml:"anyOf,omitempty"`
+	// Inline or referenced schema which the value MUST NOT validate against.
+	Not *Schema `json:"not,omitempty" yaml:"not,omitempty"`
+	// MUST be present if the type is "array".
+	Items *Schema `json:"items,omitempty" yaml:"items,omitempty"`
+	// Property definitions of an object.
+	Properties map[string]Schema `json:"properties,omitempty" yaml:"properties,omitempty"`
+	// Value can be boolean or object.
+	AdditionalProperties *AdditionalProperties `json:"additionalProperties,omitempty" yaml:"additionalProperties,omitempty"`
+	// CommonMark syntax MAY be used for rich text representation.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// See Data Type Formats for further details.
+	Format string `json:"format,omitempty" yaml:"format,omitempty"`
+	// The default value represents what would be assumed by the consumer of the
+	// input as the value of the schema if one is not provided.
+	Default interface{} `json:"default,omitempty" yaml:"default,omitempty"`
+
+	// A true value adds "null" to the allowed type specified by the type keyword.
+	Nullable bool `json:"nullable,omitempty" yaml:"nullable,omitempty"`
+	// Adds support for polymorphism.
+	Discriminator *Discriminator `json:"discriminator,omitempty" yaml:"discriminator,omitempty"`
+	// Declares the property as "read only". It MAY be sent as part of a response but
+	// SHOULD NOT be sent as part of the request.
+	ReadOnly bool `json:"readOnly,omitempty" yaml:"readOnly,omitempty"`
+	// Declares the property as "write only". It MAY be sent as part of a request but
+	// SHOULD NOT be sent as part of the response.
+	WriteOnly bool `json:"writeOnly,omitempty" yaml:"writeOnly,omitempty"`
+	// This MAY be used only on properties schemas. Adds additional metadata to
+	// describe the XML representation of this property.
+	Xml *XML `json:"xml,omitempty" yaml:"xml,omitempty"`
+	// Additional external documentation for this schema.
+	ExternalDocs *ExternalDocumentation `json:"externalDocs,omitempty" yaml:"externalDocs,omitempty"`
+	// A free-form property to include an example of an instance for this schema.
+	Example interface{} `json:"example,omitempty" yaml:"example,omitempty"`
+	// Specifies that a schema is deprecated and SHOULD be transitioned out of usage.
+	Deprecated bool `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+}
+
+// When request bodies or response payloads may be one of a number of different
+// schemas, a discriminator object can be used to aid in serialization,
+// deserialization, and validation.
+type Discriminator struct {
+	// The name of the property in the payload that will hold the discriminator
+	// value.
+	PropertyName string `json:"propertyName" yaml:"propertyName"`
+	// An object to hold mappings between payload values and schema names or
+	// references.
+	Mapping map[string]string `json:"mapping,omitempty" yaml:"mapping,omitempty"`
+}
+
+// A metadata object that allows for more fine-tuned XML model definitions.
+type XML struct {
+	// Replaces the name of the element/attribute used for the described schema
+	// property.
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// The URI of the namespace definition.
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	// The prefix to be used for the name.
+	Prefix string `json:"prefix,omitempty" yaml:"prefix,omitempty"`
+	// Declares whether the property definition translates to an attribute instead of
+	// an element.
+	Attribute bool `json:"attribute,omitempty" yaml:"attribute,omitempty"`
+	// MAY be used only for an array definition. Signifies whether the array is
+	// wrapped.
+	Wrapped bool `json:"wrapped,omitempty" yaml:"wrapped,omitempty"`
+}
+
+// Defines a security scheme that can be used by the operations.
+type SecurityScheme struct {
+	// A reference to a security scheme defined in components.
+	Ref string `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	// The type of the security scheme. Valid values are "apiKey", "http", "oauth2",
+	// "openIdConnect".
+	Type string `json:"type,omitempty" yaml:"type,omitempty"`
+	// A short description for security scheme.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// The name of the header, query or cookie parameter to be used. Applies to
+	// "apiKey".
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// The location of the API key. Valid values are "query", "header" or "cookie".
+	// Applies to "apiKey".
+	In string `json:"in,omitempty" yaml:"in,omitempty"`
+	// The name of the HTTP Authorization scheme to be used in the Authorization
+	// header as defined in RFC7235. Applies to "http".
+	Scheme string `json:"scheme,omitempty" yaml:"scheme,omitempty"`
+	// A hint to the client to identify how the bearer token is formatted. Applies to
+	// "http" with a "bearer" scheme.
+	BearerFormat string `json:"bearerFormat,omitempty" yaml:"bearerFormat,omitempty"`
+	// An object containing configuration information for the flow types supported.
+	// Applies to "oauth2".
+	Flows *OAuthFlows `json:"flows,omitempty" yaml:"flows,omitempty"`
+	// OpenId Connect URL to discover OAuth2 configuration values. Applies to
+	// "openIdConnect".
+	OpenIdConnectUrl string `json:"openIdConnectUrl,omitempty" yaml:"openIdConnectUrl,omitempty"`
+}
+
+// Allows configuration of the supported OAuth Flows.
+type OAuthFlows struct {
+	// Configuration for the OAuth Implicit flow.
+	Implicit *OAuthFlow `json:"implicit,omitempty" yaml:"implicit,omitempty"`
+	// Configuration for the OAuth Resource Owner Password flow.
+	Password *OAuthFlow `json:"password,omitempty" yaml:"password,omitempty"`
+	// Configuration for the OAuth Client Credentials flow.
+	ClientCredentials *OAuthFlow `json:"clientCredentials,omitempty" yaml:"clientCredentials,omitempty"`
+	// Configuration for the OAuth Authorization Code flow.
+	AuthorizationCode *OAuthFlow `json:"authorizationCode,omitempty" yaml:"authorizationCode,omitempty"`
+}
+
+// Configuration details for a supported OAuth Flow.
+type OAuthFlow struct {
+	// The authorization URL to be used for this flow. Applies to "implicit" and
+	// "authorizationCode".
+	AuthorizationUrl string `json:"authorizationUrl,omitempty" yaml:"authorizationUrl,omitempty"`
+	// The token URL to be used for this flow. Applies to "password",
+	// "clientCredentials" and "authorizationCode".
+	TokenUrl string `json:"tokenUrl,omitempty" yaml:"tokenUrl,omitempty"`
+	// The URL to be used for obtaining refresh tokens.
+	RefreshUrl string `json:"refreshUrl,omitempty" yaml:"refreshUrl,omitempty"`
+	// The available scopes for the OAuth2 security scheme. A map between the scope
+	// name and a short description for it.
+	Scopes map[string]string `json:"scopes" yaml:"scopes"`
+}
+
+// Lists the required security schemes to execute this operation. Each name MUST
+// correspond to a security scheme declared in the Security Schemes under the
+// Components Object.
+type SecurityRequirement map[string][]string
@@ -0,0 +1,161 @@
+package spec3
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestParse(t *testing.T) {
+	integer := func(format string) *Schema { return &Schema{Type: "integer", Format: format} }
+	str := Schema{Type: "string"}
+
+	petstore := OpenAPI{
+		OpenAPI: "3.0.0",
+		Info: &Info{
+			Version: "1.0.0",
+			Title:   "Swagger Petstore",
+			License: &License{Name: "MIT"},
+		},
+		Servers: []Server{{Url: "http://petstore.swagger.io/v1"}},
+		Paths: Paths{
+			"/pets": PathItem{
+				Get: &Operation{
+					Summary:     "List all pets",
+					OperationId: "listPets",
+					Tags:        []string{"pets"},
+					Parameters: []Parameter{{
+						Name:        "limit",
+						In:          "query",
+						Description: "How many items to return at one time (max 100)",
+						Schema:      integer("int32"),
+					}},
+					Responses: Responses{
+						"200": {
+							Description: "A paged array of pets",
+							Headers: map[string]Header{
+								"x-next": {
+									Description: "A link to the next page of responses",
+									Schema:      &str,
+								},
+							},
+							Content: map[string]MediaType{
+								"application/json": {Schema: &Schema{Ref: "#/components/schemas/Pets"}},
+							},
+						},
+						"default": {
+							Description: "unexpected error",
+							Content: map[string]MediaType{
+								"application/json": {Schema: &Schema{Ref: "#/components/schemas/Error"}},
+							},
+						},
+					},
+				},
+				Post: &Operation{
+					Summary:     "Create a pet",
+					OperationId: "createPets",
+					Tags:        []string{"pets"},
+					RequestBody: &RequestBody{
+						Required: true,
+						Content: map[string]MediaType{
+							"application/json": {Schema: &Schema{Ref: "#/components/schemas/Pet"}},
+						},
+					},
+					Responses: Responses{"201": {Description: "Null response"}},
+				},
+			},
+		},
+		Components: &Components{
+			Schemas: map[string]Schema{
+				"Pet": {
+					Type:     "object",
+					Required: []string{"id", "name"},
+					Properties: map[string]Schema{
+						"id":   *integer("int64"),
+						"name": str,
+						"tag":  str,
+					},
+				},
+				"Pets": {
+					Type:  "array",
+					Items: &Schema{Ref: "#/components/schemas/Pet"},
+				},
+				"Error": {
+					Type:                 "object",
+					AdditionalProperties: &AdditionalProperties{Allowed: false},
+					Required:             []string{"code", "message"},
+					Properties: map[string]Schema{
+						"code":    *integer("int32"),
+						"message": str,
+					},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		file      string
+		unmarshal func([]byte, interface{}) error
+	}{
+		{"testdata/petstore.json", json.Unmarshal},
+		{"testdata/petstore.yaml", yaml.Unmarshal},
+	}
+	for i, tt := range tests {
+		data, err := ioutil.ReadFile(tt.file)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		var got OpenAPI
+		if err := tt.unmarshal(data, &got); err != nil {
+			t.Errorf("failed to parse %s: %v", tt.file, err)
+			continue
+		}
+		if diff := pretty.Compare(got, petstore); diff != "" {
+			t.Errorf("case %d: want != got: %s", i, diff)
+		}
+	}
+}
+
+func TestAdditionalProperties(t *testing.T) {
+	tests := []struct {
+		data string
+		want AdditionalProperties
+	}{
+		{`true`, AdditionalProperties{Allowed: true}},
+		{`false`, AdditionalProperties{Allowed: false}},
+		{`{"type":"string"}`, AdditionalProperties{Allowed: true, Schema: &Schema{Type: "string"}}},
+	}
+	for i, tt := range tests {
+		var got AdditionalProperties
+		if err := json.Unmarshal([]byte(tt.data), &got); err != nil {
+			t.Errorf("case %d: json: %v", i, err)
+			continue
+		}
+		if diff := pretty.Compare(got, tt.want); diff != "" {
+			t.Errorf("case %d: json: want != got: %s", i, diff)
+		}
+		out, err := json.Marshal(got)
+		if err != nil {
+			t.Errorf("case %d: json: %v", i, err)
+			continue
+		}
+		if string(out) != tt.data {
+			t.Errorf("case %d: json round trip: want %s got %s", i, tt.data, out)
+		}
+
+		// JSON is valid YAML.
+		got = AdditionalProperties{}
+		if err := yaml.Unmarshal([]byte(tt.data), &got); err != nil {
+			t.Errorf("case %d: yaml: %v", i, err)
+			continue
+		}
+		if diff := pretty.Compare(got, tt.want); diff != "" {
+			t.Errorf("case %d: yaml: want != got: %s", i, diff)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package spec31
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/ericchiang/swaggopher/spec3"
+)
+
+// The Schema Object allows the definition of input and output data types. It is
+// a superset of JSON Schema Specification Draft 2020-12.
+//
+// JSON Schema allows the booleans true and false anywhere a schema is expected,
+// meaning "always valid" and "never valid" respectively. These are represented
+// by setting Boolean, in which case all other fields are ignored.
+type Schema struct {
+	// Boolean is set if the schema is the literal true or false.
+	Boolean *bool `json:"-" yaml:"-"`
+
+	// Core vocabulary.
+	Schema        string            `json:"$schema,omitempty" yaml:"$schema,omitempty"`
+	Id            string            `json:"$id,omitempty" yaml:"$id,omitempty"`
+	Ref           string            `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Anchor        string            `json:"$anchor,omitempty" yaml:"$anchor,omitempty"`
+	DynamicRef    string            `json:"$dynamicRef,omitempty" yaml:"$dynamicRef,omitempty"`
+	DynamicAnchor string            `json:"$dynamicAnchor,omitempty" yaml:"$dynamicAnchor,omitempty"`
+	Defs          map[string]Schema `json:"$defs,omitempty" yaml:"$defs,omitempty"`
+	Comment       string            `json:"$comment,omitempty" yaml:"$comment,omitempty"`
+
+	// Applicator vocabulary.
+	AllOf                 []Schema            `json:"allOf,omitempty" yaml:"allOf,omitempty"`
+	AnyOf                 []Schema            `json:"anyOf,omitempty" yaml:"anyOf,omitempty"`
+	OneOf                 []Schema            `json:"oneOf,omitempty" yaml:"oneOf,omitempty"`
+	Not                   *Schema             `json:"not,omitempty" yaml:"not,omitempty"`
+	If                    *Schema             `json:"if,omitempty" yaml:"if,omitempty"`
+	Then                  *Schema             `json:"then,omitempty" yaml:"then,omitempty"`
+	Else                  *Schema             `json:"else,omitempty" yaml:"else,omitempty"`
+	DependentSchemas      map[string]Schema   `json:"dependentSchemas,omitempty" yaml:"dependentSchemas,omitempty"`
+	PrefixItems           []Schema            `json:"prefixItems,omitempty" yaml:"prefixItems,omitempty"`
+	Items                 *Schema             `json:"items,omitempty" yaml:"items,omitempty"`
+	Contains              *Schema             `json:"contains,omitempty" yaml:"contains,omitempty"`
+	Properties            map[string]Schema   `json:"properties,omitempty" yaml:"properties,omitempty"`
+	PatternProperties     map[string]Schema   `json:"patternProperties,omitempty" yaml:"patternProperties,omitempty"`
+	AdditionalProperties  *Schema             `json:"additionalProperties,omitempty" yaml:"additionalProperties,omitempty"`
+	PropertyNames         *Schema             `json:"propertyNames,omitempty" yaml:"propertyNames,omitempty"`
+	UnevaluatedItems      *Schema             `json:"unevaluatedItems,omitempty" yaml:"unevaluatedItems,omitempty"`
+	UnevaluatedProperties *Schema             `json:"unevaluatedProperties,omitempty" yaml:"unevaluatedProperties,omitempty"`
+	DependentRequired     map[string][]string `json:"dependentRequired,omitempty" yaml:"dependentRequired,omitempty"`
+
+	// Validation vocabulary.
+	Type             Types         `json:"type,omitempty" yaml:"type,omitempty"`
+	Const            interface{}   `json:"const,omitempty" yaml:"const,omitempty"`
+	Enum             []interface{} `json:"enum,omitempty" yaml:"enum,omitempty"`
+	MultipleOf       float64       `json:"multipleOf,omitempty" yaml:"multipleOf,omitempty"`
+	Maximum          *float64      `json:"maximum,omitempty" yaml:"maximum,omitempty"`
+	ExclusiveMaximum *float64      `json:"exclusiveMaximum,omitempty" yaml:"exclusiveMaximum,omitempty"`
+	Minimum          *float64      `json:"minimum,omitempty" yaml:"minimum,omitempty"`
+	ExclusiveMinimum *float64      `json:"exclusiveMinimum,omitempty" yaml:"exclusiveMinimum,omitempty"`
+	MaxLength        *int          `json:"maxLength,omitempty" yaml:"maxLength,omitempty"`
+	MinLength        int           `json:"minLength,omitempty" yaml:"minLength,omitempty"`
+	Pattern          string        `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	MaxItems         *int          `json:"maxItems,omitempty" yaml:"maxItems,omitempty"`
+	MinItems         int           `json:"minItems,omitempty" yaml:"minItems,omitempty"`
+	UniqueItems      bool          `json:"uniqueItems,omitempty" yaml:"uniqueItems,omitempty"`
+	MaxContains      *int          `json:"maxContains,omitempty" yaml:"maxContains,omitempty"`
+	MinContains      *int          `json:"minContains,omitempty" yaml:"minContains,omitempty"`
+	MaxProperties    *int          `json:"maxProperties,omitempty" yaml:"maxProperties,omitempty"`
+	MinProperties    int           `json:"minProperties,omitempty" yaml:"minProperties,omitempty"`
+	Required         []string      `json:"required,omitempty" yaml:"required,omitempty"`
+
+	// Format, content and meta-data vocabularies.
+	Format           string        `json:"format,omitempty" yaml:"format,omitempty"`
+	ContentEncoding  string        `json:"contentEncoding,omitempty" yaml:"contentEncoding,omitempty"`
+	ContentMediaType string        `json:"contentMediaType,omitempty" yaml:"contentMediaType,omitempty"`
+	ContentSchema    *Schema       `json:"contentSchema,omitempty" yaml:"contentSchema,omitempty"`
+	Title            string        `json:"title,omitempty" yaml:"title,omitempty"`
+	Description      string        `json:"description,omitempty" yaml:"description,omitempty"`
+	Default          interface{}   `json:"default,omitempty" yaml:"default,omitempty"`
+	Deprecated       bool          `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+	ReadOnly         bool          `json:"readOnly,omitempty" yaml:"readOnly,omitempty"`
+	WriteOnly        bool          `json:"writeOnly,omitempty" yaml:"writeOnly,omitempty"`
+	Examples         []interface{} `json:"examples,omitempty" yaml:"examples,omitempty"`
+
+	// Adds support for polymorphism.
+	Discriminator *spec3.Discriminator `json:"discriminator,omitempty" yaml:"discriminator,omitempty"`
+	// Adds additional metadata to describe the XML representation of this property.
+	Xml *spec3.XML `json:"xml,omitempty" yaml:"xml,omitempty"`
+	// Additional external documentation for this schema.
+	ExternalDocs *spec3.ExternalDocumentation `json:"externalDocs,omitempty" yaml:"externalDocs,omitempty"`
+	// A free-form property to include an example of an instance for this schema.
+	// Deprecated in favor of Examples.
+	Example interface{} `json:"example,omitempty" yaml:"example,omitempty"`
+}
+
+// schema has the same fields as Schema but none of its methods, so it can be
+// encoded without recursing into the custom marshalers.
+type schema Schema
+
+// MarshalJSON implements json.Marshaler.
+func (s Schema) MarshalJSON() ([]byte, error) {
+	if s.Boolean != nil {
+		return json.Marshal(*s.Boolean)
+	}
+	return json.Marshal(schema(s))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *Schema) UnmarshalJSON(b []byte) error {
+	if t := bytes.TrimSpace(b); bytes.Equal(t, []byte("true")) || bytes.Equal(t, []byte("false")) {
+		var v bool
+		if err := json.Unmarshal(b, &v); err != nil {
+			return err
+		}
+		*s = Schema{Boolean: &v}
+		return nil
+	}
+	var v schema
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	*s = Schema(v)
+	return nil
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (s Schema) MarshalYAML() (interface{}, error) {
+	if s.Boolean != nil {
+		return *s.Boolean, nil
+	}
+	return schema(s), nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (s *Schema) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var b bool
+	if err := unmarshal(&b); err == nil {
+		*s = Schema{Boolean: &b}
+		return nil
+	}
+	var v schema
+	if err := unmarshal(&v); err != nil {
+		return err
+	}
+	*s = Schema(v)
+	return nil
+}
+
+// Types is the value of a schema's "type" keyword. JSON Schema allows either a
+// single type name or an array of them, such as ["string", "null"]. A single
+// type is always encoded as a string.
+type Types []string
+
+// Has reports if t includes the named type.
+func (t Types) Has(name string) bool {
+	for _, n := range t {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// MarshalJSON implements json.Marshaler.
+func (t Types) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (t *Types) UnmarshalJSON(b []byte) error {
+	var name string
+	if err := json.Unmarshal(b, &name); err == nil {
+		*t = Types{name}
+		return nil
+	}
+	var names []string
+	if err := json.Unmarshal(b, &names); err != nil {
+		return err
+	}
+	*t = Types(names)
+	return nil
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (t Types) MarshalYAML() (interface{}, error) {
+	if len(t) == 1 {
+		return t[0], nil
+	}
+	return []string(t), nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (t *Types) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var name string
+	if err := unmarshal(&name); err == nil {
+		*t = Types{name}
+		return nil
+	}
+	var names []string
+	if err := unmarshal(&names); err != nil {
+		return err
+	}
+	*t = Types(names)
+	return nil
+}
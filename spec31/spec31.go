@@ -0,0 +1,278 @@
+/*
+Package spec31 defines Go mappings for version 3.1 of the OpenAPI Specification.
+
+https://github.com/OAI/OpenAPI-Specification/blob/main/versions/3.1.0.md
+
+Objects which are unchanged from 3.0 are shared with package spec3. The Schema
+Object is a superset of JSON Schema draft 2020-12.
+*/
+package spec31
+
+import "github.com/ericchiang/swaggopher/spec3"
+
+// This is the root object of the OpenAPI document.
+type OpenAPI struct {
+	// This string MUST be the version number of the OpenAPI Specification that the
+	// OpenAPI document uses, such as "3.1.0".
+	OpenAPI string `json:"openapi" yaml:"openapi"`
+	// Provides metadata about the API. The metadata MAY be used by tooling as required.
+	Info *Info `json:"info" yaml:"info"`
+	// The default value for the $schema keyword within Schema Objects contained
+	// within this OAS document. This MUST be in the form of a URI.
+	JsonSchemaDialect string `json:"jsonSchemaDialect,omitempty" yaml:"jsonSchemaDialect,omitempty"`
+	// An array of Server Objects, which provide connectivity information to a target
+	// server.
+	Servers []spec3.Server `json:"servers,omitempty" yaml:"servers,omitempty"`
+	// The available paths and operations for the API.
+	Paths Paths `json:"paths,omitempty" yaml:"paths,omitempty"`
+	// The incoming webhooks that MAY be received as part of this API and that the
+	// API consumer MAY choose to implement.
+	Webhooks map[string]PathItem `json:"webhooks,omitempty" yaml:"webhooks,omitempty"`
+	// An element to hold various schemas for the document.
+	Components *Components `json:"components,omitempty" yaml:"components,omitempty"`
+	// A declaration of which security mechanisms can be used across the API.
+	Security []spec3.SecurityRequirement `json:"security,omitempty" yaml:"security,omitempty"`
+	// A list of tags used by the document with additional metadata.
+	Tags []spec3.Tag `json:"tags,omitempty" yaml:"tags,omitempty"`
+	// Additional external documentation.
+	ExternalDocs *spec3.ExternalDocumentation `json:"externalDocs,omitempty" yaml:"externalDocs,omitempty"`
+}
+
+// The object provides metadata about the API.
+type Info struct {
+	// The title of the API.
+	Title string `json:"title" yaml:"title"`
+	// A short summary of the API.
+	Summary string `json:"summary,omitempty" yaml:"summary,omitempty"`
+	// A description of the API. CommonMark syntax MAY be used for rich text
+	// representation.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// A URL to the Terms of Service for the API. This MUST be in the form of a URL.
+	TermsOfService string `json:"termsOfService,omitempty" yaml:"termsOfService,omitempty"`
+	// The contact information for the exposed API.
+	Contact *spec3.Contact `json:"contact,omitempty" yaml:"contact,omitempty"`
+	// The license information for the exposed API.
+	License *License `json:"license,omitempty" yaml:"license,omitempty"`
+	// The version of the OpenAPI document.
+	Version string `json:"version" yaml:"version"`
+}
+
+// License information for the exposed API.
+type License struct {
+	// The license name used for the API.
+	Name string `json:"name" yaml:"name"`
+	// An SPDX license expression for the API. The identifier field is mutually
+	// exclusive of the url field.
+	Identifier string `json:"identifier,omitempty" yaml:"identifier,omitempty"`
+	// A URL to the license used for the API.
+	Url string `json:"url,omitempty" yaml:"url,omitempty"`
+}
+
+// Holds a set of reusable objects for different aspects of the OAS.
+type Components struct {
+	// An object to hold reusable Schema Objects.
+	Schemas map[string]Schema `json:"schemas,omitempty" yaml:"schemas,omitempty"`
+	// An object to hold reusable Response Objects.
+	Responses map[string]Response `json:"responses,omitempty" yaml:"responses,omitempty"`
+	// An object to hold reusable Parameter Objects.
+	Parameters map[string]Parameter `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	// An object to hold reusable Example Objects.
+	Examples map[string]spec3.Example `json:"examples,omitempty" yaml:"examples,omitempty"`
+	// An object to hold reusable Request Body Objects.
+	RequestBodies map[string]RequestBody `json:"requestBodies,omitempty" yaml:"requestBodies,omitempty"`
+	// An object to hold reusable Header Objects.
+	Headers map[string]Header `json:"headers,omitempty" yaml:"headers,omitempty"`
+	// An object to hold reusable Security Scheme Objects.
+	SecuritySchemes map[string]spec3.SecurityScheme `json:"securitySchemes,omitempty" yaml:"securitySchemes,omitempty"`
+	// An object to hold reusable Link Objects.
+	Links map[string]spec3.Link `json:"links,omitempty" yaml:"links,omitempty"`
+	// An object to hold reusable Callback Objects.
+	Callbacks map[string]Callback `json:"callbacks,omitempty" yaml:"callbacks,omitempty"`
+	// An object to hold reusable Path Item Objects.
+	PathItems map[string]PathItem `json:"pathItems,omitempty" yaml:"pathItems,omitempty"`
+}
+
+// Holds the relative paths to the individual endpoints and their operations.
+type Paths map[string]PathItem
+
+// Describes the operations available on a single path.
+type PathItem struct {
+	// Allows for a referenced definition of this path item.
+	Ref string `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	// An optional, string summary, intended to apply to all operations in this path.
+	Summary string `json:"summary,omitempty" yaml:"summary,omitempty"`
+	// An optional, string description, intended to apply to all operations in this
+	// path.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// A definition of a GET operation on this path.
+	Get *Operation `json:"get,omitempty" yaml:"get,omitempty"`
+	// A definition of a PUT operation on this path.
+	Put *Operation `json:"put,omitempty" yaml:"put,omitempty"`
+	// A definition of a POST operation on this path.
+	Post *Operation `json:"post,omitempty" yaml:"post,omitempty"`
+	// A definition of a DELETE operation on this path.
+	Delete *Operation `json:"delete,omitempty" yaml:"delete,omitempty"`
+	// A definition of a OPTIONS operation on this path.
+	Options *Operation `json:"options,omitempty" yaml:"options,omitempty"`
+	// A definition of a HEAD operation on this path.
+	Head *Operation `json:"head,omitempty" yaml:"head,omitempty"`
+	// A definition of a PATCH operation on this path.
+	Patch *Operation `json:"patch,omitempty" yaml:"patch,omitempty"`
+	// A definition of a TRACE operation on this path.
+	Trace *Operation `json:"trace,omitempty" yaml:"trace,omitempty"`
+	// An alternative server array to service all operations in this path.
+	Servers []spec3.Server `json:"servers,omitempty" yaml:"servers,omitempty"`
+	// A list of parameters that are applicable for all the operations described under
+	// this path.
+	Parameters []Parameter `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+}
+
+// Describes a single API operation on a path.
+type Operation struct {
+	// A list of tags for API documentation control.
+	Tags []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	// A short summary of what the operation does.
+	Summary string `json:"summary,omitempty" yaml:"summary,omitempty"`
+	// A verbose explanation of the operation behavior.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// Additional external documentation for this operation.
+	ExternalDocs *spec3.ExternalDocumentation `json:"externalDocs,omitempty" yaml:"externalDocs,omitempty"`
+	// Unique string used to identify the operation.
+	OperationId string `json:"operationId,omitempty" yaml:"operationId,omitempty"`
+	// A list of parameters that are applicable for this operation.
+	Parameters []Parameter `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	// The request body applicable for this operation.
+	RequestBody *RequestBody `json:"requestBody,omitempty" yaml:"requestBody,omitempty"`
+	// The list of possible responses as they are returned from executing this
+	// operation. Unlike 3.0, responses are optional.
+	Responses Responses `json:"responses,omitempty" yaml:"responses,omitempty"`
+	// A map of possible out-of band callbacks related to the parent operation.
+	Callbacks map[string]Callback `json:"callbacks,omitempty" yaml:"callbacks,omitempty"`
+	// Declares this operation to be deprecated.
+	Deprecated bool `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+	// A declaration of which security mechanisms can be used for this operation.
+	Security []spec3.SecurityRequirement `json:"security,omitempty" yaml:"security,omitempty"`
+	// An alternative server array to service this operation.
+	Servers []spec3.Server `json:"servers,omitempty" yaml:"servers,omitempty"`
+}
+
+// Describes a single operation parameter.
+type Parameter struct {
+	// A reference to a parameter defined in components.
+	Ref string `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	// The name of the parameter. Parameter names are case sensitive.
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// The location of the parameter. Possible values are "query", "header", "path"
+	// or "cookie".
+	In string `json:"in,omitempty" yaml:"in,omitempty"`
+	// A brief description of the parameter.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// Determines whether this parameter is mandatory.
+	Required bool `json:"required,omitempty" yaml:"required,omitempty"`
+	// Specifies that a parameter is deprecated and SHOULD be transitioned out of
+	// usage.
+	Deprecated bool `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+	// Sets the ability to pass empty-valued parameters.
+	AllowEmptyValue bool `json:"allowEmptyValue,omitempty" yaml:"allowEmptyValue,omitempty"`
+	// Describes how the parameter value will be serialized.
+	Style string `json:"style,omitempty" yaml:"style,omitempty"`
+	// When this is true, parameter values of type array or object generate separate
+	// parameters for each value of the array or key-value pair of the map.
+	Explode *bool `json:"explode,omitempty" yaml:"explode,omitempty"`
+	// Determines whether the parameter value SHOULD allow reserved characters.
+	AllowReserved bool `json:"allowReserved,omitempty" yaml:"allowReserved,omitempty"`
+	// The schema defining the type used for the parameter.
+	Schema *Schema `json:"schema,omitempty" yaml:"schema,omitempty"`
+	// Example of the parameter's potential value.
+	Example interface{} `json:"example,omitempty" yaml:"example,omitempty"`
+	// Examples of the parameter's potential value.
+	Examples map[string]spec3.Example `json:"examples,omitempty" yaml:"examples,omitempty"`
+	// A map containing the representations for the parameter.
+	Content map[string]MediaType `json:"content,omitempty" yaml:"content,omitempty"`
+}
+
+// Describes a single request body.
+type RequestBody struct {
+	// A reference to a request body defined in components.
+	Ref string `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	// A brief description of the request body.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// The content of the request body.
+	Content map[string]MediaType `json:"content,omitempty" yaml:"content,omitempty"`
+	// Determines if the request body is required in the request.
+	Required bool `json:"required,omitempty" yaml:"required,omitempty"`
+}
+
+// Each Media Type Object provides schema and examples for the media type
+// identified by its key.
+type MediaType struct {
+	// The schema defining the content of the request, response, or parameter.
+	Schema *Schema `json:"schema,omitempty" yaml:"schema,omitempty"`
+	// Example of the media type.
+	Example interface{} `json:"example,omitempty" yaml:"example,omitempty"`
+	// Examples of the media type.
+	Examples map[string]spec3.Example `json:"examples,omitempty" yaml:"examples,omitempty"`
+	// A map between a property name and its encoding information.
+	Encoding map[string]Encoding `json:"encoding,omitempty" yaml:"encoding,omitempty"`
+}
+
+// A single encoding definition applied to a single schema property.
+type Encoding struct {
+	// The Content-Type for encoding a specific property.
+	ContentType string `json:"contentType,omitempty" yaml:"contentType,omitempty"`
+	// A map allowing additional information to be provided as headers.
+	Headers map[string]Header `json:"headers,omitempty" yaml:"headers,omitempty"`
+	// Describes how a specific property value will be serialized.
+	Style string `json:"style,omitempty" yaml:"style,omitempty"`
+	// When this is true, property values of type array or object generate separate
+	// parameters for each value.
+	Explode *bool `json:"explode,omitempty" yaml:"explode,omitempty"`
+	// Determines whether the parameter value SHOULD allow reserved characters.
+	AllowReserved bool `json:"allowReserved,omitempty" yaml:"allowReserved,omitempty"`
+}
+
+// A container for the expected responses of an operation.
+type Responses map[string]Response
+
+// Describes a single response from an API Operation.
+type Response struct {
+	// A reference to a response defined in components.
+	Ref string `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	// A description of the response.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// Maps a header name to its definition.
+	Headers map[string]Header `json:"headers,omitempty" yaml:"headers,omitempty"`
+	// A map containing descriptions of potential response payloads.
+	Content map[string]MediaType `json:"content,omitempty" yaml:"content,omitempty"`
+	// A map of operations links that can be followed from the response.
+	Links map[string]spec3.Link `json:"links,omitempty" yaml:"links,omitempty"`
+}
+
+// A map of possible out-of band callbacks related to the parent operation.
+type Callback map[string]PathItem
+
+// The Header Object follows the structure of the Parameter Object, except that
+// name and in MUST NOT be specified.
+type Header struct {
+	// A reference to a header defined in components.
+	Ref string `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	// A brief description of the header.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// Determines whether this header is mandatory.
+	Required bool `json:"required,omitempty" yaml:"required,omitempty"`
+	// Specifies that a header is deprecated and SHOULD be transitioned out of usage.
+	Deprecated bool `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+	// Describes how the header value will be serialized.
+	Style string `json:"style,omitempty" yaml:"style,omitempty"`
+	// When this is true, header values of type array or object generate a single
+	// header with comma separated values.
+	Explode *bool `json:"explode,omitempty" yaml:"explode,omitempty"`
+	// The schema defining the type used for the header.
+	Schema *Schema `json:"schema,omitempty" yaml:"schema,omitempty"`
+	// Example of the header's potential value.
+	Example interface{} `json:"example,omitempty" yaml:"example,omitempty"`
+	// Examples of the header's potential value.
+	Examples map[string]spec3.Example `json:"examples,omitempty" yaml:"examples,omitempty"`
+	// A map containing the representations for the header.
+	Content map[string]MediaType `json:"content,omitempty" yaml:"content,omitempty"`
+}
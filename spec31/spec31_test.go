@@ -0,0 +1,106 @@
+package spec31
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestParse(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/webhook-example.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got OpenAPI
+	if err := yaml.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	zero := 0.0
+	no := false
+	want := OpenAPI{
+		OpenAPI: "3.1.0",
+		Info: &Info{
+			Title:   "Webhook Example",
+			Version: "1.0.0",
+			License: &License{Name: "MIT", Identifier: "MIT"},
+		},
+		Webhooks: map[string]PathItem{
+			"newPet": {
+				Post: &Operation{
+					RequestBody: &RequestBody{
+						Description: "Information about a new pet in the system",
+						Content: map[string]MediaType{
+							"application/json": {Schema: &Schema{Ref: "#/components/schemas/Pet"}},
+						},
+					},
+					Responses: Responses{
+						"200": {Description: "Return a 200 status to indicate that the data was received successfully"},
+					},
+				},
+			},
+		},
+		Components: &Components{
+			Schemas: map[string]Schema{
+				"Pet": {
+					Required: []string{"id", "name"},
+					Properties: map[string]Schema{
+						"id":   {Type: Types{"integer"}, Format: "int64", ExclusiveMinimum: &zero},
+						"name": {Type: Types{"string"}},
+						"tag":  {Type: Types{"string", "null"}, Examples: []interface{}{"dog", nil}},
+						"kind": {Const: "pet"},
+					},
+					AdditionalProperties: &Schema{Boolean: &no},
+				},
+			},
+		},
+	}
+	if diff := pretty.Compare(got, want); diff != "" {
+		t.Errorf("want != got: %s", diff)
+	}
+
+	// Round trip through JSON to exercise the custom marshalers.
+	out, err := json.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var again OpenAPI
+	if err := json.Unmarshal(out, &again); err != nil {
+		t.Fatal(err)
+	}
+	if diff := pretty.Compare(again, want); diff != "" {
+		t.Errorf("json round trip: want != got: %s", diff)
+	}
+}
+
+func TestTypes(t *testing.T) {
+	tests := []struct {
+		data string
+		want Types
+	}{
+		{`"string"`, Types{"string"}},
+		{`["string","null"]`, Types{"string", "null"}},
+	}
+	for i, tt := range tests {
+		var got Types
+		if err := json.Unmarshal([]byte(tt.data), &got); err != nil {
+			t.Errorf("case %d: %v", i, err)
+			continue
+		}
+		if diff := pretty.Compare(got, tt.want); diff != "" {
+			t.Errorf("case %d: want != got: %s", i, diff)
+		}
+		out, err := json.Marshal(got)
+		if err != nil {
+			t.Errorf("case %d: %v", i, err)
+			continue
+		}
+		if string(out) != tt.data {
+			t.Errorf("case %d: round trip: want %s got %s", i, tt.data, out)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package spectest
+
+import (
+	"fmt"
+	"strings"
+)
+
+// context is the number of unchanged lines shown around changed ones.
+const context = 3
+
+// maxCells bounds the table used to find the longest common subsequence of
+// the changed lines. Beyond it, they're shown as entirely removed and added.
+const maxCells = 1 << 22
+
+// Diff returns the differences between two values, as printed by Sprint, or
+// "" if they print the same. Lines only want has are prefixed by "-" and
+// those only got has by "+". Each group of changes is headed by a JSON
+// pointer to the object or list it starts in.
+func Diff(want, got interface{}) string {
+	a, b := lines(want), lines(got)
+	edits := diff(a, b)
+
+	var out strings.Builder
+	for i := 0; i < len(edits); {
+		if edits[i].op == ' ' {
+			i++
+			continue
+		}
+		// A hunk runs from context lines before a change to context lines
+		// after the last change within twice that of the one before.
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for j := i; j < len(edits) && j <= end+2*context; j++ {
+			if edits[j].op != ' ' {
+				end = j
+			}
+		}
+		stop := end + context + 1
+		if stop > len(edits) {
+			stop = len(edits)
+		}
+		fmt.Fprintf(&out, "@@ #%s @@\n", edits[i].line.pointer)
+		for _, e := range edits[start:stop] {
+			fmt.Fprintf(&out, "%c%s\n", e.op, e.line.text)
+		}
+		i = stop
+	}
+	return out.String()
+}
+
+type edit struct {
+	// op is ' ' for a line both have, '-' for one only a has and '+' for one
+	// only b has.
+	op   byte
+	line line
+}
+
+// diff returns the edits turning a into b, comparing lines by their text.
+func diff(a, b []line) []edit {
+	var prefix, suffix []edit
+	for len(a) > 0 && len(b) > 0 && a[0].text == b[0].text {
+		prefix = append(prefix, edit{' ', b[0]})
+		a, b = a[1:], b[1:]
+	}
+	for len(a) > 0 && len(b) > 0 && a[len(a)-1].text == b[len(b)-1].text {
+		suffix = append([]edit{{' ', b[len(b)-1]}}, suffix...)
+		a, b = a[:len(a)-1], b[:len(b)-1]
+	}
+
+	edits := prefix
+	if (len(a)+1)*(len(b)+1) > maxCells {
+		for _, l := range a {
+			edits = append(edits, edit{'-', l})
+		}
+		for _, l := range b {
+			edits = append(edits, edit{'+', l})
+		}
+		return append(edits, suffix...)
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i].text == b[j].text {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i].text == b[j].text:
+			edits = append(edits, edit{' ', b[j]})
+			i, j = i+1, j+1
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			edits = append(edits, edit{'-', a[i]})
+			i++
+		default:
+			edits = append(edits, edit{'+', b[j]})
+			j++
+		}
+	}
+	return append(edits, suffix...)
+}
@@ -0,0 +1,270 @@
+/*
+Package spectest prints documents, and the values they hold, for the failure
+messages of tests.
+
+Sprint prints a value in the shape of the YAML documents tests are written in,
+with fields named by their JSON tags, vendor extensions beside the fields they
+extend and map keys sorted, so that the same value always prints the same:
+
+	paths:
+	  /pets/{petId}:
+	    get:
+	      parameters:
+	        - {name: "petId", in: "path", required: true, type: "integer"}
+	      responses:
+	        200: {description: "A pet.", schema: {$ref: "#/definitions/Pet"}}
+
+Empty fields are left out, and anything which fits on a line, such as a
+reference, is printed on one. Nil and empty lists and maps are told apart,
+since documents give them different meanings, such as an operation's
+"security: []".
+
+Diff compares two values by what Sprint prints, and only shows the lines
+which differ, headed by a JSON pointer to where they are:
+
+	if diff := spectest.Diff(want, got); diff != "" {
+		t.Errorf("unexpected document (-want +got):\n%s", diff)
+	}
+*/
+package spectest
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/internal/jsonpointer"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// width is the length of the longest line a value is printed on, beyond
+// which it's printed over several.
+const width = 80
+
+// Sprint prints a value, such as a document or a schema.
+func Sprint(v interface{}) string {
+	var b strings.Builder
+	for _, l := range lines(v) {
+		b.WriteString(l.text)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// line is a line of a printed value.
+type line struct {
+	text string
+	// pointer is a JSON pointer to the object or list the line is in.
+	pointer string
+}
+
+func lines(v interface{}) []line {
+	n := build(reflect.ValueOf(v))
+	if s, ok := n.inline(0); ok {
+		return []line{{text: s}}
+	}
+	var out []line
+	n.block(&out, "", "")
+	return out
+}
+
+// node is a value being printed: a scalar, or an object or list of nodes.
+type node struct {
+	kind     kind
+	scalar   string
+	keys     []string
+	children []*node
+}
+
+type kind int
+
+const (
+	scalar kind = iota
+	object
+	list
+)
+
+var additionalProperties = reflect.TypeOf(spec.AdditionalProperties{})
+
+func build(v reflect.Value) *node {
+	if !v.IsValid() {
+		return &node{scalar: "null"}
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return &node{scalar: "null"}
+		}
+		return build(v.Elem())
+	case reflect.Struct:
+		if s, ok := v.Interface().(fmt.Stringer); ok && v.Type().NumField() > 0 && v.Type().Field(0).PkgPath != "" {
+			// Structs with unexported fields, such as time.Time, are
+			// printed as they describe themselves.
+			return &node{scalar: strconv.Quote(s.String())}
+		}
+		if v.Type() == additionalProperties {
+			// Like its JSON, the boolean form is only printed if there's
+			// no schema.
+			if s := v.FieldByName("Schema"); !s.IsNil() {
+				return build(s)
+			}
+			return build(v.FieldByName("Allowed"))
+		}
+		n := &node{kind: object}
+		structFields(n, v)
+		return n
+	case reflect.Map:
+		if v.IsNil() {
+			return &node{scalar: "null"}
+		}
+		n := &node{kind: object}
+		mapEntries(n, v)
+		return n
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return &node{scalar: "null"}
+		}
+		n := &node{kind: list}
+		for i := 0; i < v.Len(); i++ {
+			n.keys = append(n.keys, strconv.Itoa(i))
+			n.children = append(n.children, build(v.Index(i)))
+		}
+		return n
+	case reflect.String:
+		return &node{scalar: strconv.Quote(v.String())}
+	case reflect.Float32, reflect.Float64:
+		return &node{scalar: strconv.FormatFloat(v.Float(), 'g', -1, 64)}
+	}
+	return &node{scalar: fmt.Sprint(v.Interface())}
+}
+
+// structFields adds the fields of a struct to an object, named as they are
+// in JSON. Empty fields are left out, and a field holding vendor extensions,
+// which JSON encoding inlines, has its entries added instead.
+func structFields(n *node, v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		fv := v.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		switch {
+		case name == "-" && f.Name == "Extensions" && fv.Kind() == reflect.Map:
+			mapEntries(n, fv)
+			continue
+		case name == "-":
+			continue
+		case f.Anonymous && name == "" && fv.Kind() == reflect.Struct:
+			structFields(n, fv)
+			continue
+		case name == "":
+			name = f.Name
+		}
+		if empty(fv) {
+			continue
+		}
+		n.keys = append(n.keys, name)
+		n.children = append(n.children, build(fv))
+	}
+}
+
+func mapEntries(n *node, v reflect.Value) {
+	keys := make([]string, 0, v.Len())
+	values := make(map[string]reflect.Value, v.Len())
+	for _, k := range v.MapKeys() {
+		key := fmt.Sprint(k.Interface())
+		keys = append(keys, key)
+		values[key] = v.MapIndex(k)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		n.keys = append(n.keys, key)
+		n.children = append(n.children, build(values[key]))
+	}
+}
+
+// empty reports whether a field holds its zero value. Lists and maps which
+// are empty, but not nil, aren't.
+func empty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		return v.IsNil()
+	}
+	return v.IsZero()
+}
+
+// inline prints a node on a single line, starting at a column. ok is false if
+// it doesn't fit, or holds a list of objects or lists.
+func (n *node) inline(column int) (s string, ok bool) {
+	if n.kind == scalar {
+		return n.scalar, true
+	}
+	var b strings.Builder
+	open, close := "{", "}"
+	if n.kind == list {
+		open, close = "[", "]"
+	}
+	b.WriteString(open)
+	for i, c := range n.children {
+		if n.kind == list && c.kind != scalar {
+			// Lists of objects are printed an element to a line, so
+			// that a change to one element is a change to one line.
+			return "", false
+		}
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		if n.kind == object {
+			b.WriteString(key(n.keys[i]) + ": ")
+		}
+		s, ok := c.inline(column + b.Len())
+		if !ok {
+			return "", false
+		}
+		b.WriteString(s)
+		if column+b.Len() > width {
+			return "", false
+		}
+	}
+	b.WriteString(close)
+	return b.String(), column+b.Len() <= width
+}
+
+// block prints an object or list over several lines, indented by indent.
+func (n *node) block(out *[]line, pointer, indent string) {
+	for i, c := range n.children {
+		prefix := indent + "- "
+		if n.kind == object {
+			prefix = indent + key(n.keys[i]) + ":"
+			if s, ok := c.inline(len(prefix) + 1); ok {
+				*out = append(*out, line{text: prefix + " " + s, pointer: pointer})
+				continue
+			}
+			*out = append(*out, line{text: prefix, pointer: pointer})
+			c.block(out, jsonpointer.Join(pointer, n.keys[i]), indent+"  ")
+			continue
+		}
+		if s, ok := c.inline(len(prefix)); ok {
+			*out = append(*out, line{text: prefix + s, pointer: pointer})
+			continue
+		}
+		// The first line of a list's element follows its dash, as in YAML.
+		start := len(*out)
+		c.block(out, jsonpointer.Join(pointer, n.keys[i]), indent+"  ")
+		(*out)[start].text = prefix + strings.TrimPrefix((*out)[start].text, indent+"  ")
+	}
+}
+
+// key quotes an object's key if it would otherwise be ambiguous, such as
+// one which starts like a list or contains ": ".
+func key(k string) string {
+	if k == "" || strings.TrimSpace(k) != k || strings.ContainsAny(k[:1], "{[\"-") ||
+		strings.Contains(k, ": ") || strings.Contains(k, ", ") || strings.HasSuffix(k, ":") || strings.Contains(k, "\n") {
+		return strconv.Quote(k)
+	}
+	return k
+}
@@ -0,0 +1,116 @@
+package spectest
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+const doc = `
+swagger: "2.0"
+info: {title: Pets, version: "1.0", x-audience: public}
+paths:
+  /pets/{petId}:
+    get:
+      operationId: getPet
+      security: []
+      parameters:
+        - {name: petId, in: path, required: true, type: integer}
+      responses:
+        200:
+          description: A pet.
+          schema: {$ref: '#/definitions/Pet'}
+definitions:
+  Pet:
+    type: object
+    required: [name]
+    additionalProperties: false
+    properties:
+      name: {type: string, description: 'The name the pet answers to, which is usually short.'}
+      tags: {type: array, items: {type: string}}
+`
+
+const printed = `swagger: "2.0"
+info: {title: "Pets", version: "1.0", x-audience: "public"}
+paths:
+  /pets/{petId}:
+    get:
+      operationId: "getPet"
+      parameters:
+        - {name: "petId", in: "path", required: true, type: "integer"}
+      responses:
+        200: {description: "A pet.", schema: {$ref: "#/definitions/Pet"}}
+      security: []
+definitions:
+  Pet:
+    required: ["name"]
+    type: "object"
+    properties:
+      name:
+        description: "The name the pet answers to, which is usually short."
+        type: "string"
+      tags: {type: "array", items: {type: "string"}}
+    additionalProperties: false
+`
+
+func TestSprint(t *testing.T) {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(doc), &s); err != nil {
+		t.Fatal(err)
+	}
+	if got := Sprint(&s); got != printed {
+		t.Errorf("printed:\n%s\nwant:\n%s", got, printed)
+	}
+
+	for _, test := range []struct {
+		v    interface{}
+		want string
+	}{
+		{v: nil, want: "null\n"},
+		{v: []string(nil), want: "null\n"},
+		{v: []string{}, want: "[]\n"},
+		{v: spec.Schema{}, want: "{}\n"},
+		{v: map[string]interface{}{"b": 1.5, "a": []interface{}{true}}, want: "{a: [true], b: 1.5}\n"},
+		{v: map[string]int{"/pets": 1, "-": 2, "a: b": 3}, want: "{\"-\": 2, /pets: 1, \"a: b\": 3}\n"},
+	} {
+		if got := Sprint(test.v); got != test.want {
+			t.Errorf("Sprint(%#v) = %q, want %q", test.v, got, test.want)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	var want, got spec.Swagger
+	if err := yaml.Unmarshal([]byte(doc), &want); err != nil {
+		t.Fatal(err)
+	}
+	if err := yaml.Unmarshal([]byte(doc), &got); err != nil {
+		t.Fatal(err)
+	}
+	if diff := Diff(&want, &got); diff != "" {
+		t.Errorf("expected no differences, got:\n%s", diff)
+	}
+
+	pet := got.Definitions["Pet"]
+	pet.Required = nil
+	got.Definitions["Pet"] = pet
+	got.Paths["/pets/{petId}"].Get.Security = nil
+
+	const wantDiff = `@@ #/paths/~1pets~1{petId}/get @@
+         - {name: "petId", in: "path", required: true, type: "integer"}
+       responses:
+         200: {description: "A pet.", schema: {$ref: "#/definitions/Pet"}}
+-      security: []
+ definitions:
+   Pet:
+-    required: ["name"]
+     type: "object"
+     properties:
+       name:
+`
+	if diff := Diff(&want, &got); diff != wantDiff {
+		t.Errorf("diff:\n%s\nwant:\n%s", diff, wantDiff)
+	}
+}
@@ -0,0 +1,363 @@
+/*
+Package subset exports the part of a document a consumer uses.
+
+The exported document keeps only the selected operations, and the definitions,
+parameters, responses, security schemes and tags they refer to. It's suitable
+for generating slim clients, or for documentation scoped to what a consumer
+has access to.
+*/
+package subset
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/consumer"
+	"github.com/ericchiang/swaggopher/internal/jsonpointer"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// Selection determines which operations are exported. An operation is kept if
+// it matches any of the fields.
+type Selection struct {
+	// OperationIDs selects operations by ID.
+	OperationIDs []string
+	// Tags selects operations with any of the tags.
+	Tags []string
+	// Paths selects the operations of paths matching any of the globs. A "*"
+	// matches any characters within a path segment, as with path.Match, and a
+	// trailing "/**" matches any number of segments, so "/admin/**" selects
+	// "/admin" and every path below it.
+	Paths []string
+	// Contract selects the operations a consumer calls. Parameters the consumer
+	// doesn't send are dropped unless they're required, as are responses it
+	// doesn't handle. A "default" response is kept if any status the consumer
+	// handles falls back to it.
+	Contract *consumer.Contract
+}
+
+// Export returns a copy of s containing only the selected operations and what
+// they refer to. The input document isn't modified. An error is returned if the
+// selection is empty or names an operation which doesn't exist.
+func Export(s *spec.Swagger, sel Selection) (*spec.Swagger, error) {
+	if len(sel.OperationIDs) == 0 && len(sel.Tags) == 0 && len(sel.Paths) == 0 && sel.Contract == nil {
+		return nil, fmt.Errorf("subset: no operations selected")
+	}
+	globs := make(map[string]bool)
+	for _, glob := range sel.Paths {
+		if _, err := path.Match(strings.TrimSuffix(glob, "/**"), ""); err != nil {
+			return nil, fmt.Errorf("subset: invalid path glob %q", glob)
+		}
+		globs[glob] = false
+	}
+
+	// Copy the document so trimming operations doesn't modify the original.
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, fmt.Errorf("subset: copying document: %v", err)
+	}
+	var doc spec.Swagger
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("subset: copying document: %v", err)
+	}
+
+	ids := make(map[string]bool)
+	for _, id := range sel.OperationIDs {
+		ids[id] = false
+	}
+	tags := make(map[string]bool)
+	for _, t := range sel.Tags {
+		tags[t] = true
+	}
+	var (
+		interactions []consumer.Interaction
+		matched      []bool
+	)
+	if sel.Contract != nil {
+		interactions = sel.Contract.Interactions
+		matched = make([]bool, len(interactions))
+	}
+
+	paths := make(spec.Paths)
+	for p, item := range doc.Paths {
+		pathSelected := false
+		for glob := range globs {
+			if matchPath(glob, p) {
+				globs[glob] = true
+				pathSelected = true
+			}
+		}
+		keep := false
+		for _, method := range spec.Methods {
+			op := item.Operation(method)
+			if op == nil {
+				continue
+			}
+			selected := pathSelected
+			if _, ok := ids[op.OperationId]; ok && op.OperationId != "" {
+				ids[op.OperationId] = true
+				selected = true
+			}
+			for _, t := range op.Tags {
+				if tags[t] {
+					selected = true
+				}
+			}
+			for i, in := range interactions {
+				if matches(in, p, method, op) {
+					matched[i] = true
+					if !selected {
+						trim(&doc, op, in)
+					}
+					selected = true
+				}
+			}
+			if !selected {
+				item.SetOperation(method, nil)
+				continue
+			}
+			keep = true
+		}
+		if keep {
+			paths[p] = item
+		}
+	}
+
+	var missing []string
+	for id, found := range ids {
+		if !found {
+			missing = append(missing, id)
+		}
+	}
+	for glob, found := range globs {
+		if !found {
+			missing = append(missing, "path "+glob)
+		}
+	}
+	for i, in := range interactions {
+		if !matched[i] {
+			missing = append(missing, in.String())
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("subset: operations not found: %s", strings.Join(missing, ", "))
+	}
+
+	out := doc
+	out.Paths = paths
+	out.Definitions, out.Parameters, out.Responses = nil, nil, nil
+	if err := copyReferenced(&out, &doc); err != nil {
+		return nil, err
+	}
+	out.SecurityDefinitions = usedSecurity(&out, doc.SecurityDefinitions)
+	out.Tags = usedTags(&out, doc.Tags)
+	return &out, nil
+}
+
+// matchPath reports if a path matches a glob of Selection.Paths.
+func matchPath(glob, p string) bool {
+	prefix := strings.TrimSuffix(glob, "/**")
+	switch prefix {
+	case glob:
+		ok, _ := path.Match(glob, p)
+		return ok
+	case "":
+		return true
+	}
+	// Match the path itself or any of its ancestors against the prefix.
+	for {
+		if ok, _ := path.Match(prefix, p); ok {
+			return true
+		}
+		i := strings.LastIndex(p, "/")
+		if i <= 0 {
+			return false
+		}
+		p = p[:i]
+	}
+}
+
+func matches(in consumer.Interaction, path, method string, op *spec.Operation) bool {
+	if in.OperationID != "" {
+		return in.OperationID == op.OperationId
+	}
+	return in.Path == path && strings.ToLower(in.Method) == method
+}
+
+// trim removes the optional parameters a consumer doesn't send and the
+// responses it doesn't handle.
+func trim(doc *spec.Swagger, op *spec.Operation, in consumer.Interaction) {
+	sent := make(map[string]bool)
+	for _, name := range in.Parameters {
+		sent[name] = true
+	}
+	var params []spec.Parameter
+	for _, p := range op.Parameters {
+		target := p
+		if p.Ref != "" {
+			if t, ok := doc.Parameters[jsonpointer.Unescape(strings.TrimPrefix(p.Ref, "#/parameters/"))]; ok {
+				target = t
+			}
+		}
+		if target.Required || sent[target.Name] || target.Name == "" {
+			params = append(params, p)
+		}
+	}
+	op.Parameters = params
+
+	if len(in.Responses) == 0 {
+		return
+	}
+	responses := make(spec.Responses)
+	for code := range in.Responses {
+		if r, ok := op.Responses[code]; ok {
+			responses[code] = r
+		} else if r, ok := op.Responses["default"]; ok {
+			responses["default"] = r
+		}
+	}
+	op.Responses = responses
+}
+
+// copyReferenced adds the definitions, parameters and responses of src which
+// are referenced, directly or indirectly, by the paths of dst.
+func copyReferenced(dst, src *spec.Swagger) error {
+	var queue []string
+	seen := make(map[string]bool)
+	add := func(v interface{}) error {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("subset: %v", err)
+		}
+		var generic interface{}
+		if err := json.Unmarshal(data, &generic); err != nil {
+			return fmt.Errorf("subset: %v", err)
+		}
+		for _, ref := range refs(generic, nil) {
+			if !seen[ref] {
+				seen[ref] = true
+				queue = append(queue, ref)
+			}
+		}
+		return nil
+	}
+
+	if err := add(dst.Paths); err != nil {
+		return err
+	}
+	for len(queue) > 0 {
+		ref := queue[0]
+		queue = queue[1:]
+
+		tokens := jsonpointer.Split(ref)
+		if !strings.HasPrefix(ref, "#/") || len(tokens) < 2 {
+			// References to other documents are left as they are.
+			continue
+		}
+		name := tokens[1]
+		var target interface{}
+		switch tokens[0] {
+		case "definitions":
+			d, ok := src.Definitions[name]
+			if !ok {
+				continue
+			}
+			if dst.Definitions == nil {
+				dst.Definitions = make(spec.Definitions)
+			}
+			dst.Definitions[name] = d
+			target = d
+		case "parameters":
+			p, ok := src.Parameters[name]
+			if !ok {
+				continue
+			}
+			if dst.Parameters == nil {
+				dst.Parameters = make(spec.ParametersDefinitions)
+			}
+			dst.Parameters[name] = p
+			target = p
+		case "responses":
+			r, ok := src.Responses[name]
+			if !ok {
+				continue
+			}
+			if dst.Responses == nil {
+				dst.Responses = make(spec.ResponsesDefinitions)
+			}
+			dst.Responses[name] = r
+			target = r
+		default:
+			continue
+		}
+		if err := add(target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// refs returns the value of every "$ref" field in a decoded JSON value.
+func refs(v interface{}, found []string) []string {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if ref, ok := v["$ref"].(string); ok {
+			found = append(found, ref)
+		}
+		for _, val := range v {
+			found = refs(val, found)
+		}
+	case []interface{}:
+		for _, val := range v {
+			found = refs(val, found)
+		}
+	}
+	return found
+}
+
+// usedSecurity returns the security schemes required by the document or its
+// operations.
+func usedSecurity(s *spec.Swagger, schemes spec.SecurityDefinitions) spec.SecurityDefinitions {
+	used := make(spec.SecurityDefinitions)
+	addAll := func(reqs []spec.SecurityRequirement) {
+		for _, req := range reqs {
+			for name := range req {
+				if scheme, ok := schemes[name]; ok {
+					used[name] = scheme
+				}
+			}
+		}
+	}
+	addAll(s.Security)
+	s.RangeOperations(func(_, _ string, op *spec.Operation) bool {
+		addAll(op.Security)
+		return true
+	})
+	if len(used) == 0 {
+		return nil
+	}
+	return used
+}
+
+// usedTags returns the tags, in their original order, applied to at least one
+// operation.
+func usedTags(s *spec.Swagger, tags []spec.Tag) []spec.Tag {
+	used := make(map[string]bool)
+	s.RangeOperations(func(_, _ string, op *spec.Operation) bool {
+		for _, t := range op.Tags {
+			used[t] = true
+		}
+		return true
+	})
+	var kept []spec.Tag
+	for _, t := range tags {
+		if used[t.Name] {
+			kept = append(kept, t)
+		}
+	}
+	return kept
+}
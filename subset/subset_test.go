@@ -0,0 +1,204 @@
+package subset
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/ericchiang/swaggopher/consumer"
+	"github.com/ericchiang/swaggopher/spec"
+	"github.com/ericchiang/swaggopher/spectest"
+)
+
+const petstore = `
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+tags:
+- {name: pets}
+- {name: store}
+securityDefinitions:
+  key: {type: apiKey, name: X-Key, in: header}
+  oauth: {type: oauth2, flow: implicit, authorizationUrl: "https://example.com/auth", scopes: {}}
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      tags: [pets]
+      parameters:
+      - {$ref: '#/parameters/limit'}
+      - {name: tag, in: query, type: string}
+      responses:
+        200:
+          description: Pets.
+          schema: {type: array, items: {$ref: '#/definitions/Pet'}}
+        default:
+          $ref: '#/responses/Error'
+  /pets/{petId}:
+    parameters:
+    - {name: petId, in: path, required: true, type: string}
+    get:
+      operationId: getPet
+      tags: [pets]
+      security: [{key: []}]
+      responses:
+        200:
+          description: A pet.
+          schema: {$ref: '#/definitions/Pet'}
+  /orders:
+    post:
+      operationId: placeOrder
+      tags: [store]
+      security: [{oauth: []}]
+      parameters:
+      - name: order
+        in: body
+        schema: {$ref: '#/definitions/Order'}
+      responses:
+        201:
+          description: Placed.
+parameters:
+  limit: {name: limit, in: query, type: integer}
+responses:
+  Error:
+    description: An error.
+    schema: {$ref: '#/definitions/Error'}
+definitions:
+  Pet:
+    type: object
+    properties:
+      owner: {$ref: '#/definitions/Owner'}
+  Owner:
+    type: object
+    properties:
+      pets: {type: array, items: {$ref: '#/definitions/Pet'}}
+  Order:
+    type: object
+  Error:
+    type: object
+`
+
+func TestExport(t *testing.T) {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(petstore), &s); err != nil {
+		t.Fatal(err)
+	}
+	contract, err := consumer.Parse([]byte(`
+consumer: reader
+interactions:
+- operationId: listPets
+  responses:
+    200: []
+    500: []
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pets := spec.Definitions{"Pet": s.Definitions["Pet"], "Owner": s.Definitions["Owner"]}
+	tests := []struct {
+		sel  Selection
+		want spec.Swagger
+	}{
+		{
+			sel: Selection{OperationIDs: []string{"getPet"}},
+			want: spec.Swagger{
+				Swagger:             "2.0",
+				Info:                s.Info,
+				Tags:                []spec.Tag{{Name: "pets"}},
+				SecurityDefinitions: spec.SecurityDefinitions{"key": s.SecurityDefinitions["key"]},
+				Paths:               spec.Paths{"/pets/{petId}": s.Paths["/pets/{petId}"]},
+				Definitions:         pets,
+			},
+		},
+		{
+			sel: Selection{Tags: []string{"store"}},
+			want: spec.Swagger{
+				Swagger:             "2.0",
+				Info:                s.Info,
+				Tags:                []spec.Tag{{Name: "store"}},
+				SecurityDefinitions: spec.SecurityDefinitions{"oauth": s.SecurityDefinitions["oauth"]},
+				Paths:               spec.Paths{"/orders": s.Paths["/orders"]},
+				Definitions:         spec.Definitions{"Order": s.Definitions["Order"]},
+			},
+		},
+		{
+			sel: Selection{Paths: []string{"/pets/*"}},
+			want: spec.Swagger{
+				Swagger:             "2.0",
+				Info:                s.Info,
+				Tags:                []spec.Tag{{Name: "pets"}},
+				SecurityDefinitions: spec.SecurityDefinitions{"key": s.SecurityDefinitions["key"]},
+				Paths:               spec.Paths{"/pets/{petId}": s.Paths["/pets/{petId}"]},
+				Definitions:         pets,
+			},
+		},
+		{
+			sel: Selection{Paths: []string{"/pets/**"}},
+			want: spec.Swagger{
+				Swagger:             "2.0",
+				Info:                s.Info,
+				Tags:                []spec.Tag{{Name: "pets"}},
+				SecurityDefinitions: spec.SecurityDefinitions{"key": s.SecurityDefinitions["key"]},
+				Paths:               spec.Paths{"/pets": s.Paths["/pets"], "/pets/{petId}": s.Paths["/pets/{petId}"]},
+				Parameters:          spec.ParametersDefinitions{"limit": s.Parameters["limit"]},
+				Responses:           spec.ResponsesDefinitions{"Error": s.Responses["Error"]},
+				Definitions:         spec.Definitions{"Pet": pets["Pet"], "Owner": pets["Owner"], "Error": s.Definitions["Error"]},
+			},
+		},
+		{
+			// The optional tag and limit parameters aren't sent, and the
+			// reader handles errors through the default response.
+			sel: Selection{Contract: contract},
+			want: spec.Swagger{
+				Swagger: "2.0",
+				Info:    s.Info,
+				Tags:    []spec.Tag{{Name: "pets"}},
+				Paths: spec.Paths{
+					"/pets": spec.PathItem{
+						Get: &spec.Operation{
+							OperationId: "listPets",
+							Tags:        []string{"pets"},
+							Responses:   s.Paths["/pets"].Get.Responses,
+						},
+					},
+				},
+				Responses:   spec.ResponsesDefinitions{"Error": s.Responses["Error"]},
+				Definitions: spec.Definitions{"Pet": pets["Pet"], "Owner": pets["Owner"], "Error": s.Definitions["Error"]},
+			},
+		},
+	}
+	for i, tt := range tests {
+		got, err := Export(&s, tt.sel)
+		if err != nil {
+			t.Errorf("case %d: %v", i, err)
+			continue
+		}
+		if diff := spectest.Diff(tt.want, got); diff != "" {
+			t.Errorf("case %d: (-want +got):\n%s", i, diff)
+		}
+	}
+
+	// The original document must be untouched.
+	if n := len(s.Paths["/pets"].Get.Parameters); n != 2 {
+		t.Errorf("expected listPets to keep its 2 parameters, got %d", n)
+	}
+}
+
+func TestExportErrors(t *testing.T) {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(petstore), &s); err != nil {
+		t.Fatal(err)
+	}
+	tests := []Selection{
+		{},
+		{OperationIDs: []string{"getPet", "deletePet"}},
+		{Paths: []string{"/pets", "/owners/**"}},
+		{Paths: []string{"/pets/[a-"}},
+		{Contract: &consumer.Contract{Interactions: []consumer.Interaction{{Method: "GET", Path: "/owners"}}}},
+	}
+	for i, sel := range tests {
+		if _, err := Export(&s, sel); err == nil {
+			t.Errorf("case %d: expected error", i)
+		}
+	}
+}
@@ -0,0 +1,277 @@
+/*
+Package transform rewrites requests and responses according to declarative
+rules, so that clients of an old version of an API can keep working while the
+implementation moves to a new one.
+
+Rules are declared alongside the spec, either in a separate file or as
+extensions. A document's "x-transforms" extension holds a list of rules, and
+an operation's "x-transform" extension holds a single rule which applies to
+that operation:
+
+	x-transforms:
+	- path: /v1/pets/{petId}
+	  rewritePath: /pets/{petId}
+	paths:
+	  /pets:
+	    get:
+	      x-transform:
+	        renameHeaders: {X-Client: X-Client-Id}
+	        defaultQuery: {limit: "20"}
+
+Handler applies the rules in front of any http.Handler, such as a reverse
+proxy.
+*/
+package transform
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/ericchiang/swaggopher/internal/rawdoc"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// Rule describes the requests it applies to and how to transform them.
+type Rule struct {
+	// Method limits the rule to requests with the method. If empty, requests
+	// with any method match.
+	Method string `json:"method,omitempty" yaml:"method,omitempty"`
+	// Path is a path template such as "/pets/{petId}", which requests must
+	// match. Variables match a single, non-empty path segment.
+	Path string `json:"path" yaml:"path"`
+
+	// RewritePath replaces the request's path. It may use the variables of
+	// Path, such as "/v2/pets/{petId}".
+	RewritePath string `json:"rewritePath,omitempty" yaml:"rewritePath,omitempty"`
+	// RenameHeaders maps the names of request headers to the names they're
+	// forwarded as.
+	RenameHeaders map[string]string `json:"renameHeaders,omitempty" yaml:"renameHeaders,omitempty"`
+	// DefaultQuery holds query parameters to add to requests which don't
+	// already set them.
+	DefaultQuery map[string]string `json:"defaultQuery,omitempty" yaml:"defaultQuery,omitempty"`
+	// RenameResponseHeaders maps the names of response headers to the names
+	// they're returned to the client as.
+	RenameResponseHeaders map[string]string `json:"renameResponseHeaders,omitempty" yaml:"renameResponseHeaders,omitempty"`
+
+	pattern *regexp.Regexp
+	vars    []string
+}
+
+// Config holds a list of rules, as read from a rules file.
+type Config struct {
+	Rules []Rule `json:"rules" yaml:"rules"`
+}
+
+// Parse decodes a JSON or YAML rules file.
+func Parse(data []byte) (*Config, error) {
+	var c Config
+	var err error
+	if rawdoc.IsJSON(data) {
+		err = json.Unmarshal(data, &c)
+	} else {
+		err = yaml.Unmarshal(data, &c)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("transform: parsing rules: %v", err)
+	}
+	return &c, nil
+}
+
+// FromSpec returns the rules declared by a document's "x-transforms" extension,
+// followed by those declared by its operations' "x-transform" extensions in path
+// and method order. The path and method of an operation's rule default to the
+// operation's.
+func FromSpec(s *spec.Swagger) ([]Rule, error) {
+	var rules []Rule
+	if ext, ok := s.Extensions["x-transforms"]; ok {
+		if err := decode(ext, &rules); err != nil {
+			return nil, fmt.Errorf("transform: x-transforms: %v", err)
+		}
+	}
+
+	for _, o := range s.SortedOperations() {
+		ext, ok := o.Extensions["x-transform"]
+		if !ok {
+			continue
+		}
+		method := strings.ToUpper(o.Method)
+		var r Rule
+		if err := decode(ext, &r); err != nil {
+			return nil, fmt.Errorf("transform: %s %s: x-transform: %v", method, o.Path, err)
+		}
+		if r.Path == "" {
+			r.Path = s.BasePath + o.Path
+		}
+		if r.Method == "" {
+			r.Method = method
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// decode converts an extension's value, as decoded by encoding/json, to v.
+func decode(ext interface{}, v interface{}) error {
+	data, err := json.Marshal(ext)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+var variable = regexp.MustCompile(`\{([^{}/]+)\}`)
+
+// compile prepares a rule's path template for matching, and checks that
+// RewritePath only uses variables which Path declares.
+func (r *Rule) compile() error {
+	if !strings.HasPrefix(r.Path, "/") {
+		return fmt.Errorf("path %q must begin with \"/\"", r.Path)
+	}
+	r.vars = nil
+	var pattern bytes.Buffer
+	pattern.WriteString("^")
+	last := 0
+	for _, m := range variable.FindAllStringSubmatchIndex(r.Path, -1) {
+		pattern.WriteString(regexp.QuoteMeta(r.Path[last:m[0]]))
+		pattern.WriteString("([^/]+)")
+		r.vars = append(r.vars, r.Path[m[2]:m[3]])
+		last = m[1]
+	}
+	pattern.WriteString(regexp.QuoteMeta(r.Path[last:]))
+	pattern.WriteString("$")
+	r.pattern = regexp.MustCompile(pattern.String())
+
+	declared := make(map[string]bool)
+	for _, v := range r.vars {
+		declared[v] = true
+	}
+	for _, m := range variable.FindAllStringSubmatch(r.RewritePath, -1) {
+		if !declared[m[1]] {
+			return fmt.Errorf("rewritePath uses variable %q which path %q doesn't declare", m[1], r.Path)
+		}
+	}
+	return nil
+}
+
+// match reports if the rule applies to a request, returning the values of
+// the path's variables.
+func (r *Rule) match(req *http.Request) (map[string]string, bool) {
+	if r.Method != "" && !strings.EqualFold(r.Method, req.Method) {
+		return nil, false
+	}
+	m := r.pattern.FindStringSubmatch(req.URL.Path)
+	if m == nil {
+		return nil, false
+	}
+	vars := make(map[string]string, len(r.vars))
+	for i, name := range r.vars {
+		vars[name] = m[i+1]
+	}
+	return vars, true
+}
+
+// Handler returns a handler which transforms requests and responses according
+// to the first rule matching each request, then calls next. Requests which
+// match no rule are passed through unchanged. An error is returned if a rule is
+// invalid.
+func Handler(rules []Rule, next http.Handler) (http.Handler, error) {
+	compiled := make([]Rule, len(rules))
+	for i, r := range rules {
+		if err := r.compile(); err != nil {
+			return nil, fmt.Errorf("transform: rule %d: %v", i, err)
+		}
+		compiled[i] = r
+	}
+	return &handler{rules: compiled, next: next}, nil
+}
+
+type handler struct {
+	rules []Rule
+	next  http.Handler
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	for i := range h.rules {
+		r := &h.rules[i]
+		vars, ok := r.match(req)
+		if !ok {
+			continue
+		}
+		req = r.request(req, vars)
+		if len(r.RenameResponseHeaders) > 0 {
+			w = &renamingWriter{ResponseWriter: w, rename: r.RenameResponseHeaders}
+		}
+		break
+	}
+	h.next.ServeHTTP(w, req)
+}
+
+// request returns a copy of req with the rule's transformations applied.
+func (r *Rule) request(req *http.Request, vars map[string]string) *http.Request {
+	out := new(http.Request)
+	*out = *req
+	u := *req.URL
+	out.URL = &u
+	out.Header = make(http.Header, len(req.Header))
+	for k, v := range req.Header {
+		out.Header[k] = v
+	}
+
+	if r.RewritePath != "" {
+		out.URL.Path = variable.ReplaceAllStringFunc(r.RewritePath, func(m string) string {
+			return vars[m[1:len(m)-1]]
+		})
+		out.URL.RawPath = ""
+		out.RequestURI = ""
+	}
+	for from, to := range r.RenameHeaders {
+		if v, ok := out.Header[http.CanonicalHeaderKey(from)]; ok {
+			out.Header.Del(from)
+			out.Header[http.CanonicalHeaderKey(to)] = v
+		}
+	}
+	if len(r.DefaultQuery) > 0 {
+		q := out.URL.Query()
+		for name, value := range r.DefaultQuery {
+			if _, ok := q[name]; !ok {
+				q.Set(name, value)
+			}
+		}
+		out.URL.RawQuery = q.Encode()
+	}
+	return out
+}
+
+// renamingWriter renames response headers before they're written.
+type renamingWriter struct {
+	http.ResponseWriter
+	rename      map[string]string
+	wroteHeader bool
+}
+
+func (w *renamingWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		h := w.ResponseWriter.Header()
+		for from, to := range w.rename {
+			if v, ok := h[http.CanonicalHeaderKey(from)]; ok {
+				h.Del(from)
+				h[http.CanonicalHeaderKey(to)] = v
+			}
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *renamingWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
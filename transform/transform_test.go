@@ -0,0 +1,123 @@
+package transform
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+const doc = `
+swagger: "2.0"
+info: {title: Pets, version: "2.0"}
+basePath: /api
+x-transforms:
+- path: /api/v1/pets/{petId}
+  rewritePath: /api/pets/{petId}
+  renameResponseHeaders: {X-Pet-Version: X-Version}
+paths:
+  /pets:
+    get:
+      x-transform:
+        renameHeaders: {X-Client: X-Client-Id}
+        defaultQuery: {limit: "20"}
+      responses:
+        200: {description: Pets.}
+  /pets/{petId}:
+    get:
+      responses:
+        200: {description: A pet.}
+`
+
+// upstream records what it receives and sets a response header.
+type upstream struct {
+	path, query, client string
+}
+
+func (u *upstream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	u.path, u.query, u.client = r.URL.Path, r.URL.RawQuery, r.Header.Get("X-Client-Id")
+	w.Header().Set("X-Pet-Version", "2")
+	w.Write([]byte("ok"))
+}
+
+func TestHandler(t *testing.T) {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(doc), &s); err != nil {
+		t.Fatal(err)
+	}
+	rules, err := FromSpec(&s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		method, target string
+		header         string
+		want           upstream
+		version        string
+	}{
+		{
+			method: "GET", target: "/api/pets", header: "mobile",
+			want: upstream{path: "/api/pets", query: "limit=20", client: "mobile"},
+		},
+		{
+			method: "GET", target: "/api/pets?limit=5",
+			want: upstream{path: "/api/pets", query: "limit=5"},
+		},
+		{
+			// The operation's rule only applies to GET.
+			method: "POST", target: "/api/pets", header: "mobile",
+			want: upstream{path: "/api/pets"},
+		},
+		{
+			method: "GET", target: "/api/v1/pets/7",
+			want:    upstream{path: "/api/pets/7"},
+			version: "2",
+		},
+		{
+			method: "GET", target: "/api/v1/pets/7/owner",
+			want: upstream{path: "/api/v1/pets/7/owner"},
+		},
+	}
+	for i, tt := range tests {
+		up := &upstream{}
+		h, err := Handler(rules, up)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest(tt.method, tt.target, nil)
+		if tt.header != "" {
+			req.Header.Set("X-Client", tt.header)
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		if diff := pretty.Compare(*up, tt.want); diff != "" {
+			t.Errorf("case %d: want != got: %s", i, diff)
+		}
+		if got := rr.Header().Get("X-Version"); got != tt.version {
+			t.Errorf("case %d: expected X-Version %q, got %q", i, tt.version, got)
+		}
+	}
+}
+
+func TestHandlerInvalidRules(t *testing.T) {
+	tests := []string{
+		`rules: [{path: pets}]`,
+		`rules: [{path: "/pets/{id}", rewritePath: "/v2/pets/{petId}"}]`,
+	}
+	for i, tt := range tests {
+		c, err := Parse([]byte(tt))
+		if err != nil {
+			t.Errorf("case %d: %v", i, err)
+			continue
+		}
+		if _, err := Handler(c.Rules, http.NotFoundHandler()); err == nil {
+			t.Errorf("case %d: expected error", i)
+		}
+	}
+}
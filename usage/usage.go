@@ -0,0 +1,344 @@
+/*
+Package usage counts the requests each API key makes to each operation of a
+document, for metering and quotas.
+
+Handler identifies the caller of a request by the apiKey security schemes its
+operation requires, reading the key from the header or query parameter the
+scheme names:
+
+	securityDefinitions:
+	  key:
+	    type: apiKey
+	    in: header
+	    name: X-API-Key
+	security:
+	- key: []
+
+Keys of requests answered with a 401 Unauthorized aren't counted, since they
+weren't accepted, and once a handler has counted MaxKeys distinct keys the
+requests of others are counted against OtherKeys. Callers inventing keys
+therefore can't grow the counts without bound.
+
+Counts are kept by a Store, and can be queried with Query or exported with
+WriteCSV and WritePrometheus. Exports identify keys by KeyID, so they don't
+reveal the keys themselves.
+*/
+package usage
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ericchiang/swaggopher/runtime"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// Key identifies what usage is counted against.
+type Key struct {
+	// APIKey is the key the caller presented, "" if it didn't present one or
+	// the request was unauthorized, or OtherKeys.
+	APIKey string `json:"apiKey"`
+	// Operation is the method and path template, such as "GET /pets/{petId}".
+	Operation string `json:"operation"`
+}
+
+// Counts is the usage recorded for a key.
+type Counts struct {
+	Requests int64 `json:"requests"`
+	// Errors counts requests answered with a status of 400 or above.
+	Errors int64 `json:"errors"`
+}
+
+// Record is the usage of a single key.
+type Record struct {
+	Key
+	Counts
+}
+
+// Store holds usage counts. Implementations must be safe for concurrent use.
+type Store interface {
+	// Add adds c to the counts of a key.
+	Add(k Key, c Counts)
+	// Counts returns the counts of every key with usage.
+	Counts() map[Key]Counts
+}
+
+// Memory is a Store which holds counts in memory.
+type Memory struct {
+	mu     sync.Mutex
+	counts map[Key]Counts
+}
+
+// NewMemory returns an empty Memory.
+func NewMemory() *Memory {
+	return &Memory{counts: make(map[Key]Counts)}
+}
+
+// Add implements Store.
+func (m *Memory) Add(k Key, c Counts) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	total := m.counts[k]
+	total.Requests += c.Requests
+	total.Errors += c.Errors
+	m.counts[k] = total
+}
+
+// Counts implements Store.
+func (m *Memory) Counts() map[Key]Counts {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	counts := make(map[Key]Counts, len(m.counts))
+	for k, c := range m.counts {
+		counts[k] = c
+	}
+	return counts
+}
+
+// Reset clears all counts, such as at the start of a billing period.
+func (m *Memory) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counts = make(map[Key]Counts)
+}
+
+// Query returns the records of a store matching an API key and operation,
+// sorted by API key then operation. An empty apiKey or operation matches any.
+func Query(s Store, apiKey, operation string) []Record {
+	var records []Record
+	for k, c := range s.Counts() {
+		if (apiKey == "" || k.APIKey == apiKey) && (operation == "" || k.Operation == operation) {
+			records = append(records, Record{Key: k, Counts: c})
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].APIKey != records[j].APIKey {
+			return records[i].APIKey < records[j].APIKey
+		}
+		return records[i].Operation < records[j].Operation
+	})
+	return records
+}
+
+// Options configures Handler.
+type Options struct {
+	// Store receives the counts. If nil, a Memory is used.
+	Store Store
+	// Identify, if set, returns the API key of a request in place of the
+	// document's apiKey security schemes. It's called with the method and
+	// path template of the operation the request was routed to.
+	Identify func(r *http.Request, op string) string
+	// Router matches requests to operations. If nil, runtime.DefaultRouter
+	// is used.
+	Router runtime.Router
+	// MaxKeys is the number of distinct API keys counted separately. Once
+	// it's reached, requests presenting other keys are counted against
+	// OtherKeys. It defaults to DefaultMaxKeys, and if it's negative there's
+	// no limit.
+	MaxKeys int
+}
+
+// DefaultMaxKeys is the default number of distinct API keys a handler counts
+// separately.
+const DefaultMaxKeys = 10000
+
+// OtherKeys is the API key the requests of keys beyond a handler's MaxKeys are
+// counted against.
+const OtherKeys = "other"
+
+// Handler returns a handler which counts the requests routed to operations of
+// the document before calling next. Requests which don't match an operation
+// aren't counted.
+func Handler(doc *spec.Swagger, next http.Handler, opts Options) http.Handler {
+	h := &handler{doc: doc, next: next, opts: opts}
+	if h.opts.Store == nil {
+		h.opts.Store = NewMemory()
+	}
+	if h.opts.Router == nil {
+		h.opts.Router = runtime.DefaultRouter
+	}
+	if h.opts.MaxKeys == 0 {
+		h.opts.MaxKeys = DefaultMaxKeys
+	}
+	return h
+}
+
+type handler struct {
+	doc  *spec.Swagger
+	next http.Handler
+	opts Options
+
+	mu sync.Mutex
+	// keys holds the API keys counted separately.
+	keys map[string]bool
+}
+
+// key returns the key a request's usage is counted against.
+func (h *handler) key(apiKey string, status int) string {
+	if apiKey == "" || status == http.StatusUnauthorized {
+		return ""
+	}
+	if h.opts.MaxKeys < 0 {
+		return apiKey
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.keys[apiKey] {
+		return apiKey
+	}
+	if len(h.keys) >= h.opts.MaxKeys {
+		return OtherKeys
+	}
+	if h.keys == nil {
+		h.keys = make(map[string]bool)
+	}
+	h.keys[apiKey] = true
+	return apiKey
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m, err := h.opts.Router.Route(h.doc, r)
+	if err != nil {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+	var apiKey string
+	if h.opts.Identify != nil {
+		apiKey = h.opts.Identify(r, m.String())
+	} else {
+		apiKey = APIKey(h.doc, m.Operation, r)
+	}
+	rec := &recorder{ResponseWriter: w}
+	h.next.ServeHTTP(rec, r)
+	c := Counts{Requests: 1}
+	if rec.status >= 400 {
+		c.Errors = 1
+	}
+	h.opts.Store.Add(Key{APIKey: h.key(apiKey, rec.status), Operation: m.String()}, c)
+}
+
+// APIKey returns the key a request presents for the apiKey security schemes
+// its operation requires, or the document requires if the operation doesn't
+// say. It returns "" if the request presents none of them.
+func APIKey(doc *spec.Swagger, op *spec.Operation, r *http.Request) string {
+	var names []string
+	for _, req := range doc.EffectiveSecurity(op) {
+		for name := range req {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		scheme := doc.SecurityDefinitions[name]
+		in, key, ok := scheme.APIKey()
+		if !ok {
+			continue
+		}
+		var v string
+		switch in {
+		case "header":
+			v = r.Header.Get(key)
+		case "query":
+			v = r.URL.Query().Get(key)
+		}
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// recorder passes a response through while recording its status.
+type recorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *recorder) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *recorder) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(p)
+}
+
+// KeyID returns the identifier of an API key in exports: the first 16
+// hexadecimal digits of its SHA-256 hash. It's stable, so usage can be matched
+// to a key by hashing it, but doesn't reveal the key. The ID of no key is "".
+func KeyID(apiKey string) string {
+	if apiKey == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:8])
+}
+
+// WriteCSV writes records as CSV with a header row. Keys are identified by
+// their KeyID, except OtherKeys.
+func WriteCSV(w io.Writer, records []Record) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"api_key_id", "operation", "requests", "errors"})
+	for _, r := range records {
+		cw.Write([]string{
+			exportedKey(r.APIKey),
+			r.Operation,
+			strconv.FormatInt(r.Requests, 10),
+			strconv.FormatInt(r.Errors, 10),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WritePrometheus writes records in the Prometheus text exposition format, as
+// the counters swaggopher_requests_total and swaggopher_request_errors_total.
+// Keys are identified by their KeyID, except OtherKeys.
+func WritePrometheus(w io.Writer, records []Record) error {
+	metrics := []struct {
+		name, help string
+		value      func(c Counts) int64
+	}{
+		{"swaggopher_requests_total", "Requests by API key and operation.", func(c Counts) int64 { return c.Requests }},
+		{"swaggopher_request_errors_total", "Requests answered with an error status by API key and operation.", func(c Counts) int64 { return c.Errors }},
+	}
+	for _, m := range metrics {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", m.name, m.help, m.name); err != nil {
+			return err
+		}
+		for _, r := range records {
+			_, err := fmt.Fprintf(w, "%s{api_key_id=\"%s\",operation=\"%s\"} %d\n",
+				m.name, exportedKey(r.APIKey), labelValue(r.Operation), m.value(r.Counts))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func exportedKey(apiKey string) string {
+	if apiKey == OtherKeys {
+		return apiKey
+	}
+	return KeyID(apiKey)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func labelValue(s string) string {
+	return labelEscaper.Replace(s)
+}
@@ -0,0 +1,192 @@
+package usage
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+const petstore = `
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+basePath: /v1
+securityDefinitions:
+  header: {type: apiKey, in: header, name: X-API-Key}
+  query: {type: apiKey, in: query, name: key}
+security:
+- header: []
+paths:
+  /pets:
+    get:
+      responses:
+        200: {description: Pets.}
+  /pets/{petId}:
+    parameters:
+    - {name: petId, in: path, required: true, type: integer}
+    get:
+      security:
+      - query: []
+      responses:
+        200: {description: A pet.}
+        404: {description: Not found.}
+`
+
+func TestHandler(t *testing.T) {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(petstore), &s); err != nil {
+		t.Fatal(err)
+	}
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/pets/2" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	store := NewMemory()
+	h := Handler(&s, next, Options{Store: store})
+
+	for _, req := range []struct {
+		path   string
+		header string
+	}{
+		{path: "/v1/pets", header: "alice"},
+		{path: "/v1/pets", header: "alice"},
+		{path: "/v1/pets", header: "bob"},
+		{path: "/v1/pets"},
+		// The operation's requirements replace the document's.
+		{path: "/v1/pets/1?key=bob", header: "alice"},
+		{path: "/v1/pets/2?key=bob"},
+		// Unmatched requests aren't counted.
+		{path: "/v1/toys", header: "alice"},
+	} {
+		r := httptest.NewRequest("GET", req.path, nil)
+		if req.header != "" {
+			r.Header.Set("X-API-Key", req.header)
+		}
+		h.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	want := []Record{
+		{Key: Key{Operation: "GET /pets"}, Counts: Counts{Requests: 1}},
+		{Key: Key{APIKey: "alice", Operation: "GET /pets"}, Counts: Counts{Requests: 2}},
+		{Key: Key{APIKey: "bob", Operation: "GET /pets"}, Counts: Counts{Requests: 1}},
+		{Key: Key{APIKey: "bob", Operation: "GET /pets/{petId}"}, Counts: Counts{Requests: 2, Errors: 1}},
+	}
+	if diff := pretty.Compare(want, Query(store, "", "")); diff != "" {
+		t.Errorf("all records: %s", diff)
+	}
+	if diff := pretty.Compare(want[3:], Query(store, "bob", "GET /pets/{petId}")); diff != "" {
+		t.Errorf("bob's pet lookups: %s", diff)
+	}
+	if got := Query(store, "carol", ""); len(got) != 0 {
+		t.Errorf("expected no records for an unknown key, got %v", got)
+	}
+}
+
+func TestHandlerIdentify(t *testing.T) {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(petstore), &s); err != nil {
+		t.Fatal(err)
+	}
+	store := NewMemory()
+	h := Handler(&s, http.NotFoundHandler(), Options{
+		Store:    store,
+		Identify: func(r *http.Request, op string) string { return r.Header.Get("X-Client") + " " + op },
+	})
+	r := httptest.NewRequest("GET", "/v1/pets", nil)
+	r.Header.Set("X-Client", "mobile")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	want := map[Key]Counts{
+		{APIKey: "mobile GET /pets", Operation: "GET /pets"}: {Requests: 1, Errors: 1},
+	}
+	if diff := pretty.Compare(want, store.Counts()); diff != "" {
+		t.Errorf("counts: %s", diff)
+	}
+	store.Reset()
+	if got := store.Counts(); len(got) != 0 {
+		t.Errorf("expected no counts after Reset, got %v", got)
+	}
+}
+
+func TestHandlerKeys(t *testing.T) {
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(petstore), &s); err != nil {
+		t.Fatal(err)
+	}
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-API-Key") == "mallory" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	})
+	store := NewMemory()
+	h := Handler(&s, next, Options{Store: store, MaxKeys: 2})
+	for _, key := range []string{"alice", "mallory", "bob", "carol", "alice", "dave"} {
+		r := httptest.NewRequest("GET", "/v1/pets", nil)
+		r.Header.Set("X-API-Key", key)
+		h.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	// Rejected keys aren't counted, and keys beyond the limit are counted
+	// together.
+	want := []Record{
+		{Key: Key{Operation: "GET /pets"}, Counts: Counts{Requests: 1, Errors: 1}},
+		{Key: Key{APIKey: "alice", Operation: "GET /pets"}, Counts: Counts{Requests: 2}},
+		{Key: Key{APIKey: "bob", Operation: "GET /pets"}, Counts: Counts{Requests: 1}},
+		{Key: Key{APIKey: OtherKeys, Operation: "GET /pets"}, Counts: Counts{Requests: 2}},
+	}
+	if diff := pretty.Compare(want, Query(store, "", "")); diff != "" {
+		t.Errorf("records: %s", diff)
+	}
+}
+
+func TestExport(t *testing.T) {
+	records := []Record{
+		{Key: Key{APIKey: "alice", Operation: "GET /pets"}, Counts: Counts{Requests: 2}},
+		{Key: Key{APIKey: `a"b`, Operation: "GET /pets/{petId}"}, Counts: Counts{Requests: 3, Errors: 1}},
+		{Key: Key{APIKey: OtherKeys, Operation: "GET /pets"}, Counts: Counts{Requests: 4}},
+	}
+
+	var csv bytes.Buffer
+	if err := WriteCSV(&csv, records); err != nil {
+		t.Fatal(err)
+	}
+	// Keys are exported as their IDs, not the secrets themselves.
+	wantCSV := `api_key_id,operation,requests,errors
+2bd806c97f0e00af,GET /pets,2,0
+39a012772dd5c3ac,GET /pets/{petId},3,1
+other,GET /pets,4,0
+`
+	if got := csv.String(); got != wantCSV {
+		t.Errorf("CSV: want %q, got %q", wantCSV, got)
+	}
+
+	var prom bytes.Buffer
+	if err := WritePrometheus(&prom, records); err != nil {
+		t.Fatal(err)
+	}
+	wantProm := `# HELP swaggopher_requests_total Requests by API key and operation.
+# TYPE swaggopher_requests_total counter
+swaggopher_requests_total{api_key_id="2bd806c97f0e00af",operation="GET /pets"} 2
+swaggopher_requests_total{api_key_id="39a012772dd5c3ac",operation="GET /pets/{petId}"} 3
+swaggopher_requests_total{api_key_id="other",operation="GET /pets"} 4
+# HELP swaggopher_request_errors_total Requests answered with an error status by API key and operation.
+# TYPE swaggopher_request_errors_total counter
+swaggopher_request_errors_total{api_key_id="2bd806c97f0e00af",operation="GET /pets"} 0
+swaggopher_request_errors_total{api_key_id="39a012772dd5c3ac",operation="GET /pets/{petId}"} 1
+swaggopher_request_errors_total{api_key_id="other",operation="GET /pets"} 0
+`
+	if got := prom.String(); got != wantProm {
+		t.Errorf("Prometheus: want %q, got %q", wantProm, got)
+	}
+
+	if got := KeyID(""); got != "" {
+		t.Errorf("expected no key to have no ID, got %q", got)
+	}
+}
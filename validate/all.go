@@ -0,0 +1,116 @@
+package validate
+
+import (
+	"context"
+	"io/ioutil"
+	"runtime"
+	"sync"
+
+	"github.com/ericchiang/swaggopher/internal/logutil"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// Source is a document to validate.
+type Source struct {
+	// Name identifies the document in results, typically its file path.
+	Name string
+	// Data holds the JSON or YAML document. If nil, the document is read from
+	// the file at Name.
+	Data []byte
+}
+
+// Result holds the outcome of validating a single Source.
+type Result struct {
+	Name string
+	// Err is set if the document couldn't be read or parsed, or if validation
+	// was cancelled before the document was checked.
+	Err error
+	// Errors found in the document.
+	Errors []ValidationError
+}
+
+// OK reports if the document was read and has no validation errors.
+func (r Result) OK() bool {
+	return r.Err == nil && len(r.Errors) == 0
+}
+
+// Options controls batch validation.
+type Options struct {
+	// Parallelism bounds how many documents are validated at once. If zero,
+	// runtime.NumCPU() is used.
+	Parallelism int
+	// Progress, if non-nil, is called after each document is validated. Calls
+	// are serialized but happen in completion order, not input order.
+	Progress spec.ProgressFunc
+	// Logger, if non-nil, receives a line for each document validated.
+	Logger spec.Logger
+}
+
+// All validates a batch of documents concurrently. The returned results are
+// in the same order as specs regardless of which documents finish first.
+//
+// If ctx is cancelled, documents which haven't started are not validated and
+// their results hold ctx's error.
+func All(ctx context.Context, specs []Source, opts Options) []Result {
+	defer logutil.Phase(opts.Logger, "validate")()
+	n := opts.Parallelism
+	if n <= 0 {
+		n = runtime.NumCPU()
+	}
+
+	results := make([]Result, len(specs))
+	sem := make(chan struct{}, n)
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		done int
+	)
+	for i, src := range specs {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			for j := i; j < len(specs); j++ {
+				results[j] = Result{Name: specs[j].Name, Err: ctx.Err()}
+			}
+			wg.Wait()
+			return results
+		}
+
+		wg.Add(1)
+		go func(i int, src Source) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			results[i] = validateSource(src)
+			logutil.Printf(opts.Logger, "validated %s: %d error(s)", src.Name, len(results[i].Errors))
+
+			if opts.Progress != nil {
+				mu.Lock()
+				done++
+				opts.Progress(done, len(specs), src.Name)
+				mu.Unlock()
+			}
+		}(i, src)
+	}
+	wg.Wait()
+	return results
+}
+
+func validateSource(src Source) Result {
+	r := Result{Name: src.Name}
+	data := src.Data
+	if data == nil {
+		var err error
+		if data, err = ioutil.ReadFile(src.Name); err != nil {
+			r.Err = err
+			return r
+		}
+	}
+	s, err := parse(data)
+	if err != nil {
+		r.Err = err
+		return r
+	}
+	r.Errors = append(ValidateDocument(s), ValidateSemantics(s)...)
+	return r
+}
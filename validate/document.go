@@ -0,0 +1,311 @@
+package validate
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/internal/jsonpointer"
+	"github.com/ericchiang/swaggopher/internal/mapkeys"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// ValidateDocument checks the structure of a document against the official
+// Swagger 2.0 JSON Schema: required fields, enumerated values such as a
+// parameter's "in", "type" and "collectionFormat", the patterns of path and
+// response keys, and fields which are mutually exclusive.
+//
+// Map keys, such as paths and definition names, are checked in sorted order so
+// the errors are stable between runs.
+func ValidateDocument(s *spec.Swagger) []ValidationError {
+	v := &validator{}
+	v.document(s)
+	return v.errs
+}
+
+var (
+	transferSchemes   = []string{"http", "https", "ws", "wss"}
+	parameterLocation = []string{"query", "header", "path", "formData", "body"}
+	primitiveTypes    = []string{"string", "number", "integer", "boolean", "array"}
+	schemaTypes       = []string{"array", "boolean", "integer", "null", "number", "object", "string"}
+	collectionFormats = []string{"csv", "ssv", "tsv", "pipes"}
+	securityTypes     = []string{"basic", "apiKey", "oauth2"}
+	apiKeyLocations   = []string{"query", "header"}
+	oauth2Flows       = []string{"implicit", "password", "application", "accessCode"}
+
+	// hostPattern matches a host name or IP, optionally with a port, but not a
+	// scheme or path.
+	hostPattern         = regexp.MustCompile(`^[^{}/ :\\]+(?::\d+)?$`)
+	responseCodePattern = regexp.MustCompile(`^([0-9]{3})$|^(default)$`)
+)
+
+type validator struct {
+	errs []ValidationError
+}
+
+func (v *validator) errorf(path, format string, args ...interface{}) {
+	v.errs = append(v.errs, ValidationError{path, fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) required(path, field string, set bool) {
+	if !set {
+		v.errorf(jsonpointer.Join(path, field), "%s is required", field)
+	}
+}
+
+func (v *validator) enum(path, field, value string, allowed []string) {
+	if value == "" {
+		return
+	}
+	if !contains(allowed, value) {
+		v.errorf(jsonpointer.Join(path, field), "%s must be one of %s, got %q", field, strings.Join(allowed, ", "), value)
+	}
+}
+
+func (v *validator) schemes(path string, list []string) {
+	for i, scheme := range list {
+		if !contains(transferSchemes, scheme) {
+			v.errorf(jsonpointer.Join(path, strconv.Itoa(i)), "scheme must be one of %s, got %q", strings.Join(transferSchemes, ", "), scheme)
+		}
+	}
+}
+
+func (v *validator) document(s *spec.Swagger) {
+	if s.Swagger != "2.0" {
+		v.errorf("/swagger", "swagger version must be \"2.0\", got %q", s.Swagger)
+	}
+	if s.Info == nil {
+		v.errorf("/info", "info is required")
+	} else {
+		v.required("/info", "title", s.Info.Title != "")
+		v.required("/info", "version", s.Info.Version != "")
+		if s.Info.License != nil {
+			v.required("/info/license", "name", s.Info.License.Name != "")
+		}
+	}
+	if s.Host != "" && !hostPattern.MatchString(s.Host) {
+		v.errorf("/host", "host must not include a scheme or path, got %q", s.Host)
+	}
+	if s.BasePath != "" && !strings.HasPrefix(s.BasePath, "/") {
+		v.errorf("/basePath", "basePath must begin with \"/\", got %q", s.BasePath)
+	}
+	v.schemes("/schemes", s.Schemes)
+	if s.Paths == nil {
+		v.errorf("/paths", "paths is required")
+	}
+	for _, path := range mapkeys.Sorted(s.Paths) {
+		p := jsonpointer.Join("/paths", path)
+		if !strings.HasPrefix(path, "/") {
+			v.errorf(p, "path must begin with \"/\"")
+		}
+		item := s.Paths[path]
+		v.pathItem(p, &item)
+	}
+	for _, name := range mapkeys.Sorted(s.Definitions) {
+		d := s.Definitions[name]
+		v.schema(jsonpointer.Join("/definitions", name), &d)
+	}
+	for _, name := range mapkeys.Sorted(s.Parameters) {
+		p := s.Parameters[name]
+		v.parameter(jsonpointer.Join("/parameters", name), &p)
+	}
+	for _, name := range mapkeys.Sorted(s.Responses) {
+		r := s.Responses[name]
+		v.response(jsonpointer.Join("/responses", name), &r)
+	}
+	for _, name := range mapkeys.Sorted(s.SecurityDefinitions) {
+		scheme := s.SecurityDefinitions[name]
+		v.securityScheme(jsonpointer.Join("/securityDefinitions", name), &scheme)
+	}
+	for i, t := range s.Tags {
+		v.required(jsonpointer.Join("/tags", strconv.Itoa(i)), "name", t.Name != "")
+	}
+	if s.ExternalDocs != nil {
+		v.required("/externalDocs", "url", s.ExternalDocs.Url != "")
+	}
+}
+
+func (v *validator) pathItem(path string, item *spec.PathItem) {
+	v.parameters(jsonpointer.Join(path, "parameters"), item.Parameters)
+	item.RangeOperations(func(method string, op *spec.Operation) bool {
+		v.operation(jsonpointer.Join(path, method), op)
+		return true
+	})
+}
+
+func (v *validator) operation(path string, op *spec.Operation) {
+	v.schemes(jsonpointer.Join(path, "schemes"), op.Schemes)
+	v.parameters(jsonpointer.Join(path, "parameters"), op.Parameters)
+	if len(op.Responses) == 0 {
+		v.errorf(jsonpointer.Join(path, "responses"), "responses must declare at least one response")
+	}
+	for _, code := range mapkeys.Sorted(op.Responses) {
+		p := jsonpointer.Join(path, "responses", code)
+		if !responseCodePattern.MatchString(code) {
+			v.errorf(p, "response code must be three digits or \"default\", got %q", code)
+		}
+		r := op.Responses[code]
+		v.response(p, &r)
+	}
+	if op.ExternalDocs != nil {
+		v.required(jsonpointer.Join(path, "externalDocs"), "url", op.ExternalDocs.Url != "")
+	}
+}
+
+func (v *validator) parameters(path string, params []spec.Parameter) {
+	for i := range params {
+		v.parameter(jsonpointer.Join(path, strconv.Itoa(i)), &params[i])
+	}
+}
+
+func (v *validator) parameter(path string, p *spec.Parameter) {
+	if p.Ref != "" {
+		return
+	}
+	v.required(path, "name", p.Name != "")
+	v.required(path, "in", p.In != "")
+	v.enum(path, "in", p.In, parameterLocation)
+
+	if p.In == "body" {
+		v.required(path, "schema", p.Schema != nil)
+		if p.Type != "" {
+			v.errorf(jsonpointer.Join(path, "type"), "body parameters must use schema rather than type")
+		}
+		if p.Schema != nil {
+			v.schema(jsonpointer.Join(path, "schema"), p.Schema)
+		}
+		return
+	}
+	if p.In == "" {
+		return
+	}
+	if p.Schema != nil {
+		v.errorf(jsonpointer.Join(path, "schema"), "only body parameters may have a schema")
+	}
+	v.required(path, "type", p.Type != "")
+	types := primitiveTypes
+	if p.In == "formData" {
+		types = append(types[:len(types):len(types)], "file")
+	}
+	v.enum(path, "type", p.Type, types)
+	if p.In == "path" && !p.Required {
+		v.errorf(jsonpointer.Join(path, "required"), "path parameters must be required")
+	}
+	if p.AllowEmptyValue && p.In != "query" && p.In != "formData" {
+		v.errorf(jsonpointer.Join(path, "allowEmptyValue"), "allowEmptyValue is only valid for query and formData parameters")
+	}
+
+	formats := collectionFormats
+	if p.In == "query" || p.In == "formData" {
+		formats = append(formats[:len(formats):len(formats)], "multi")
+	}
+	v.enum(path, "collectionFormat", p.CollectionFormat, formats)
+	v.items(path, p.Type, p.Items)
+}
+
+// items checks the items of an array parameter or header, and the items they
+// hold in turn.
+func (v *validator) items(path, typ string, items *spec.Items) {
+	if typ == "array" {
+		v.required(path, "items", items != nil)
+	}
+	if items == nil {
+		return
+	}
+	p := jsonpointer.Join(path, "items")
+	v.required(p, "type", items.Type != "")
+	v.enum(p, "type", items.Type, primitiveTypes)
+	v.enum(p, "collectionFormat", items.CollectionFormat, collectionFormats)
+	v.items(p, items.Type, items.Items)
+}
+
+func (v *validator) response(path string, r *spec.Response) {
+	if r.Ref != "" {
+		return
+	}
+	v.required(path, "description", r.Description != "")
+	if r.Schema != nil {
+		p := jsonpointer.Join(path, "schema")
+		// Responses may return files, which JSON Schema has no type for.
+		if r.Schema.Type != "file" {
+			v.schema(p, r.Schema)
+		}
+	}
+	for _, name := range mapkeys.Sorted(r.Headers) {
+		h := r.Headers[name]
+		p := jsonpointer.Join(path, "headers", name)
+		v.required(p, "type", h.Type != "")
+		v.enum(p, "type", h.Type, primitiveTypes)
+		v.enum(p, "collectionFormat", h.CollectionFormat, collectionFormats)
+		v.items(p, h.Type, h.Items)
+	}
+}
+
+func (v *validator) schema(path string, s *spec.Schema) {
+	v.enum(path, "type", s.Type, schemaTypes)
+	if s.ExclusiveMaximum && s.Maximum == nil {
+		v.errorf(jsonpointer.Join(path, "exclusiveMaximum"), "exclusiveMaximum requires maximum")
+	}
+	if s.ExclusiveMinimum && s.Minimum == nil {
+		v.errorf(jsonpointer.Join(path, "exclusiveMinimum"), "exclusiveMinimum requires minimum")
+	}
+	if s.Items != nil {
+		v.schema(jsonpointer.Join(path, "items"), s.Items)
+	}
+	for i := range s.AllOf {
+		v.schema(jsonpointer.Join(path, "allOf", strconv.Itoa(i)), &s.AllOf[i])
+	}
+	for _, name := range mapkeys.Sorted(s.Properties) {
+		prop := s.Properties[name]
+		v.schema(jsonpointer.Join(path, "properties", name), &prop)
+	}
+	if ap := s.AdditionalProperties; ap != nil && ap.Schema != nil {
+		v.schema(jsonpointer.Join(path, "additionalProperties"), ap.Schema)
+	}
+}
+
+func (v *validator) securityScheme(path string, s *spec.SecurityScheme) {
+	v.required(path, "type", s.Type != "")
+	v.enum(path, "type", s.Type, securityTypes)
+	switch s.Type {
+	case "apiKey":
+		v.required(path, "name", s.Name != "")
+		v.required(path, "in", s.In != "")
+		v.enum(path, "in", s.In, apiKeyLocations)
+	case "oauth2":
+		v.required(path, "flow", s.Flow != "")
+		v.enum(path, "flow", s.Flow, oauth2Flows)
+		if s.Flow == "implicit" || s.Flow == "accessCode" {
+			v.required(path, "authorizationUrl", s.AuthorizationUrl != "")
+		}
+		if s.Flow == "password" || s.Flow == "application" || s.Flow == "accessCode" {
+			v.required(path, "tokenUrl", s.TokenUrl != "")
+		}
+		v.required(path, "scopes", s.Scopes != nil)
+	}
+	if s.Type != "oauth2" {
+		for _, f := range []struct {
+			name string
+			set  bool
+		}{
+			{"flow", s.Flow != ""},
+			{"authorizationUrl", s.AuthorizationUrl != ""},
+			{"tokenUrl", s.TokenUrl != ""},
+			{"scopes", s.Scopes != nil},
+		} {
+			if f.set {
+				v.errorf(jsonpointer.Join(path, f.name), "%s is only valid for oauth2 security schemes", f.name)
+			}
+		}
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package validate
+
+import (
+	"github.com/ericchiang/swaggopher/internal/formats"
+)
+
+// Format checks the values of a named format, such as "uuid", and chooses the
+// Go type generated for them.
+//
+// The formats defined by the specification, int32, int64, float, double, byte,
+// binary, date, date-time and password, are registered along with email and
+// uuid. Values of byte are base64, date is a full-date and date-time a
+// date-time as defined by RFC 3339, and int32 and float must be in range.
+type Format = formats.Format
+
+// RegisterFormat registers a format, replacing any format already registered
+// with the name, including the built in ones. Values of schemas with the format
+// are then checked by it, by Value and wherever else bodies are checked against
+// schemas, such as by the mock and contract packages, and the models generator
+// uses its GoType.
+//
+// RegisterFormat is usually called from an init function, and is safe for
+// concurrent use.
+//
+//	validate.RegisterFormat("semver", validate.Format{
+//		Type: "string",
+//		Check: func(v interface{}) error {
+//			if !semver.IsValid("v" + v.(string)) {
+//				return errors.New("not a semantic version")
+//			}
+//			return nil
+//		},
+//	})
+func RegisterFormat(name string, f Format) {
+	formats.Register(name, f)
+}
+
+// LookupFormat returns the format registered with a name.
+func LookupFormat(name string) (Format, bool) {
+	return formats.Lookup(name)
+}
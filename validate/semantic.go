@@ -0,0 +1,456 @@
+package validate
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ericchiang/swaggopher/internal/jsonpointer"
+	"github.com/ericchiang/swaggopher/internal/mapkeys"
+	"github.com/ericchiang/swaggopher/links"
+	"github.com/ericchiang/swaggopher/spec"
+	"github.com/ericchiang/swaggopher/variant"
+)
+
+// ValidateSemantics checks the requirements of a document which its structure
+// can't express:
+//
+//   - operationIds are unique
+//   - every variable in a path template has a path parameter, and every path
+//     parameter appears in the template
+//   - no two paths are equivalent, differing only in the names of their
+//     variables, or overlap so that neither is more specific
+//   - parameters are unique within a list
+//   - local "$ref" values point to something which exists
+//   - top level definitions, parameters and responses are referenced
+//   - response codes are valid HTTP statuses
+//   - operation variants can be decoded and don't conflict
+//   - response links call operations which exist, with their parameters
+func ValidateSemantics(s *spec.Swagger) []ValidationError {
+	v := &validator{}
+	v.operationIDs(s)
+	v.pathParameters(s)
+	v.pathTemplates(s)
+	v.references(s)
+	v.responseCodes(s)
+	v.variants(s)
+	v.links(s)
+	return v.errs
+}
+
+func (v *validator) operationIDs(s *spec.Swagger) {
+	first := make(map[string]spec.PathOperation)
+	check := func(o spec.PathOperation, pointer string, id string) {
+		if id == "" {
+			return
+		}
+		if prev, ok := first[id]; ok {
+			v.errorf(jsonpointer.Join(pointer, "operationId"), "operationId %q is also used by %s %s", id, strings.ToUpper(prev.Method), prev.Path)
+			return
+		}
+		first[id] = o
+	}
+	for _, o := range s.SortedOperations() {
+		check(o, o.Pointer(), o.Operation.OperationId)
+		// Variants which can't be parsed are reported by variants.
+		list, _ := variant.Parse(o.Operation)
+		for i, vr := range list {
+			check(o, jsonpointer.Join(o.Pointer(), variant.Extension, strconv.Itoa(i)), vr.Operation.OperationId)
+		}
+	}
+}
+
+func (v *validator) pathParameters(s *spec.Swagger) {
+	for _, path := range mapkeys.Sorted(s.Paths) {
+		item := s.Paths[path]
+		v.uniqueParameters(s, jsonpointer.Join("/paths", path, "parameters"), item.Parameters)
+	}
+
+	for _, o := range s.SortedOperations() {
+		v.uniqueParameters(s, jsonpointer.Join(o.Pointer(), "parameters"), o.Operation.Parameters)
+
+		vars := make(map[string]bool)
+		for _, name := range templateVariables(o.Path) {
+			vars[name] = true
+		}
+		declared := make(map[string]bool)
+		check := func(pointer string, params []spec.Parameter) {
+			for i := range params {
+				p := resolveParameter(s, &params[i])
+				if p == nil || p.In != "path" {
+					continue
+				}
+				declared[p.Name] = true
+				if !vars[p.Name] {
+					v.errorf(jsonpointer.Join(pointer, strconv.Itoa(i)), "path parameter %q does not appear in path %s", p.Name, o.Path)
+				}
+			}
+		}
+		check(jsonpointer.Join(o.Pointer(), "parameters"), o.Operation.Parameters)
+		check(jsonpointer.Join("/paths", o.Path, "parameters"), o.Item.Parameters)
+		for _, name := range templateVariables(o.Path) {
+			if !declared[name] {
+				v.errorf(o.Pointer(), "path variable %q has no path parameter", name)
+			}
+		}
+	}
+}
+
+// uniqueParameters reports parameters in a list with the same name and
+// location as an earlier one.
+func (v *validator) uniqueParameters(s *spec.Swagger, pointer string, params []spec.Parameter) {
+	seen := make(map[string]bool)
+	for i := range params {
+		p := resolveParameter(s, &params[i])
+		if p == nil {
+			continue
+		}
+		key := p.In + "/" + p.Name
+		if seen[key] {
+			v.errorf(jsonpointer.Join(pointer, strconv.Itoa(i)), "%s parameter %q is declared more than once", p.In, p.Name)
+		}
+		seen[key] = true
+	}
+}
+
+// templateVariables returns the names of the variables in a path template,
+// such as "petId" in "/pets/{petId}".
+func templateVariables(path string) []string {
+	var names []string
+	for {
+		start := strings.Index(path, "{")
+		if start < 0 {
+			return names
+		}
+		end := strings.Index(path[start:], "}")
+		if end < 0 {
+			return names
+		}
+		names = append(names, path[start+1:start+end])
+		path = path[start+end+1:]
+	}
+}
+
+// pathTemplates reports paths which the same requests match. Paths which differ
+// only in the names of their variables, such as "/pets/{id}" and
+// "/pets/{petId}", are equivalent, which the specification forbids. Paths
+// which overlap with a variable in each where the other has a literal segment,
+// such as "/pets/{id}/photos" and "/{kind}/mine/photos", are ambiguous since
+// neither is more specific. A literal and a variable in the same place, as in
+// "/pets/mine" and "/pets/{id}", is not ambiguous.
+func (v *validator) pathTemplates(s *spec.Swagger) {
+	paths := mapkeys.Sorted(s.Paths)
+	for i, path := range paths {
+		for _, prev := range paths[:i] {
+			switch overlap(prev, path) {
+			case equivalent:
+				v.errorf(jsonpointer.Join("/paths", path), "path %s is equivalent to %s; use the same variable names and merge their operations", path, prev)
+			case ambiguous:
+				v.errorf(jsonpointer.Join("/paths", path), "path %s overlaps %s, and neither is more specific; make one of them literal where the other has a variable", path, prev)
+			}
+		}
+	}
+}
+
+// Ways path templates can overlap.
+const (
+	disjoint = iota
+	equivalent
+	ambiguous
+	// nested paths overlap, but one is more specific.
+	nested
+)
+
+// overlap returns how two path templates overlap.
+func overlap(a, b string) int {
+	as, bs := strings.Split(a, "/"), strings.Split(b, "/")
+	if len(as) != len(bs) {
+		return disjoint
+	}
+	// aFirst and bFirst record whether each path has a literal segment where
+	// the other has a variable.
+	aFirst, bFirst := false, false
+	for i := range as {
+		aVar, bVar := strings.Contains(as[i], "{"), strings.Contains(bs[i], "{")
+		switch {
+		case !aVar && !bVar:
+			if as[i] != bs[i] {
+				return disjoint
+			}
+		case aVar && bVar:
+			if templateShape(as[i]) != templateShape(bs[i]) {
+				// Differently shaped templates, such as "{id}" and
+				// "{id}.json", may overlap in ways which are hard to
+				// judge, so only literals are compared.
+				return disjoint
+			}
+		case aVar:
+			if !templatePattern(as[i]).MatchString(bs[i]) {
+				return disjoint
+			}
+			bFirst = true
+		default:
+			if !templatePattern(bs[i]).MatchString(as[i]) {
+				return disjoint
+			}
+			aFirst = true
+		}
+	}
+	switch {
+	case aFirst && bFirst:
+		return ambiguous
+	case aFirst || bFirst:
+		return nested
+	}
+	return equivalent
+}
+
+// templateShape replaces the variables of a path segment with "{}".
+func templateShape(segment string) string {
+	var b strings.Builder
+	for _, part := range templateParts(segment) {
+		if part.variable {
+			b.WriteString("{}")
+		} else {
+			b.WriteString(part.text)
+		}
+	}
+	return b.String()
+}
+
+// templatePattern returns a regular expression matching the values of a path
+// segment with variables.
+func templatePattern(segment string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, part := range templateParts(segment) {
+		if part.variable {
+			b.WriteString("[^/]+")
+		} else {
+			b.WriteString(regexp.QuoteMeta(part.text))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+type templatePart struct {
+	text     string
+	variable bool
+}
+
+// templateParts splits a path segment into its literal text and variables.
+func templateParts(segment string) []templatePart {
+	var parts []templatePart
+	for segment != "" {
+		start := strings.Index(segment, "{")
+		end := strings.Index(segment, "}")
+		if start < 0 || end < start {
+			return append(parts, templatePart{text: segment})
+		}
+		if start > 0 {
+			parts = append(parts, templatePart{text: segment[:start]})
+		}
+		parts = append(parts, templatePart{text: segment[start+1 : end], variable: true})
+		segment = segment[end+1:]
+	}
+	return parts
+}
+
+// resolveParameter follows a reference to a top level parameter, returning nil
+// if it can't be resolved.
+func resolveParameter(s *spec.Swagger, p *spec.Parameter) *spec.Parameter {
+	if p.Ref == "" {
+		return p
+	}
+	if !strings.HasPrefix(p.Ref, "#/parameters/") {
+		return nil
+	}
+	target, ok := s.Parameters[jsonpointer.Unescape(strings.TrimPrefix(p.Ref, "#/parameters/"))]
+	if !ok {
+		return nil
+	}
+	return &target
+}
+
+// ref is a "$ref" value and the location of the object holding it.
+type ref struct {
+	pointer, value string
+}
+
+// references reports local references which can't be resolved, and top level
+// definitions, parameters and responses which nothing outside of their own
+// section refers to, directly or indirectly.
+func (v *validator) references(s *spec.Swagger) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		v.errorf("", "encoding document: %v", err)
+		return
+	}
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		v.errorf("", "encoding document: %v", err)
+		return
+	}
+	var refs []ref
+	collectRefs(doc, "", &refs)
+
+	// Refs are found in map order, so sort them for stable errors.
+	sort.Slice(refs, func(i, j int) bool { return refs[i].pointer < refs[j].pointer })
+
+	// The component each reference points to, keyed by the component which
+	// holds the reference. References from outside the top level sections are
+	// keyed by "".
+	edges := make(map[string][]string)
+	for _, r := range refs {
+		if !strings.HasPrefix(r.value, "#") {
+			// References to other documents can't be checked here.
+			continue
+		}
+		target := strings.TrimPrefix(r.value, "#")
+		if _, ok := lookup(doc, target); !ok {
+			v.errorf(jsonpointer.Join(r.pointer, "$ref"), "reference %q does not resolve", r.value)
+			continue
+		}
+		edges[component(r.pointer)] = append(edges[component(r.pointer)], component(target))
+	}
+
+	used := make(map[string]bool)
+	queue := []string{""}
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		for _, target := range edges[c] {
+			if target != "" && !used[target] {
+				used[target] = true
+				queue = append(queue, target)
+			}
+		}
+	}
+	unused := func(section, kind string, names []string) {
+		for _, name := range names {
+			p := jsonpointer.Join("/"+section, name)
+			if !used[p] {
+				v.errorf(p, "%s %q is never referenced", kind, name)
+			}
+		}
+	}
+	unused("definitions", "definition", mapkeys.Sorted(s.Definitions))
+	unused("parameters", "parameter", mapkeys.Sorted(s.Parameters))
+	unused("responses", "response", mapkeys.Sorted(s.Responses))
+}
+
+// component returns the top level definition, parameter or response a pointer
+// is within, such as "/definitions/Pet", or "" for any other location.
+func component(pointer string) string {
+	tokens := jsonpointer.Split(pointer)
+	if len(tokens) < 2 {
+		return ""
+	}
+	switch tokens[0] {
+	case "definitions", "parameters", "responses":
+		return jsonpointer.Join("/"+tokens[0], tokens[1])
+	}
+	return ""
+}
+
+func collectRefs(v interface{}, pointer string, refs *[]ref) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if s, ok := v["$ref"].(string); ok {
+			*refs = append(*refs, ref{pointer, s})
+		}
+		for key, val := range v {
+			collectRefs(val, jsonpointer.Join(pointer, key), refs)
+		}
+	case []interface{}:
+		for i, val := range v {
+			collectRefs(val, jsonpointer.Join(pointer, strconv.Itoa(i)), refs)
+		}
+	}
+}
+
+// lookup evaluates a JSON pointer against a decoded document.
+func lookup(doc interface{}, pointer string) (interface{}, bool) {
+	for _, token := range jsonpointer.Split(pointer) {
+		switch v := doc.(type) {
+		case map[string]interface{}:
+			val, ok := v[token]
+			if !ok {
+				return nil, false
+			}
+			doc = val
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			doc = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return doc, true
+}
+
+func (v *validator) responseCodes(s *spec.Swagger) {
+	for _, o := range s.SortedOperations() {
+		for _, code := range mapkeys.Sorted(o.Operation.Responses) {
+			n, err := strconv.Atoi(code)
+			if err != nil {
+				// Codes which aren't numbers are checked by ValidateDocument.
+				continue
+			}
+			if n < 100 || n > 599 {
+				v.errorf(jsonpointer.Join(o.Pointer(), "responses", code), "response code %s is not a valid HTTP status", code)
+			}
+		}
+	}
+}
+
+func (v *validator) variants(s *spec.Swagger) {
+	for _, o := range s.SortedOperations() {
+		list, err := variant.Parse(o.Operation)
+		if err != nil {
+			v.errorf(jsonpointer.Join(o.Pointer(), variant.Extension), "%v", err)
+			continue
+		}
+		for i, vr := range list {
+			if len(vr.Operation.Responses) == 0 {
+				v.errorf(jsonpointer.Join(o.Pointer(), variant.Extension, strconv.Itoa(i)), "variant has no responses")
+			}
+		}
+		for _, c := range variant.Conflicts(list) {
+			v.errorf(jsonpointer.Join(o.Pointer(), variant.Extension, strconv.Itoa(c.Index), variant.WhenExtension),
+				"variant conflicts with variant %d: both apply to requests with %s", c.Other, list[c.Index].When)
+		}
+	}
+}
+
+func (v *validator) links(s *spec.Swagger) {
+	check := func(pointer string, r *spec.Response) {
+		list, err := links.Parse(r)
+		if err != nil {
+			v.errorf(jsonpointer.Join(pointer, links.Extension), "%v", err)
+			return
+		}
+		for _, name := range mapkeys.Sorted(list) {
+			l := list[name]
+			for _, problem := range links.Check(s, &l) {
+				v.errorf(jsonpointer.Join(pointer, links.Extension, name), "%s", problem)
+			}
+		}
+	}
+	for _, o := range s.SortedOperations() {
+		for _, code := range mapkeys.Sorted(o.Operation.Responses) {
+			r := o.Operation.Responses[code]
+			check(jsonpointer.Join(o.Pointer(), "responses", code), &r)
+		}
+	}
+	for _, name := range mapkeys.Sorted(s.Responses) {
+		r := s.Responses[name]
+		check(jsonpointer.Join("/responses", name), &r)
+	}
+}
@@ -0,0 +1,47 @@
+/*
+Package validate checks Swagger documents against the requirements of the
+specification, and values such as request and response bodies against the
+schemas of a document.
+*/
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/ericchiang/swaggopher/internal/rawdoc"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// ValidationError is a single problem found in a document.
+type ValidationError struct {
+	// Path is a JSON pointer to the offending value, such as
+	// "/paths/~1pets/get/responses".
+	Path string
+	// Message describes the problem.
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// parse decodes a JSON or YAML document.
+func parse(data []byte) (*spec.Swagger, error) {
+	var s spec.Swagger
+	if rawdoc.IsJSON(data) {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, err
+		}
+		return &s, nil
+	}
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
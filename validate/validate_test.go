@@ -0,0 +1,507 @@
+package validate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+func TestAll(t *testing.T) {
+	specs := []Source{
+		{Name: "../spec/testdata/petstore.json"},
+		{Name: "missing-info.yaml", Data: []byte("swagger: \"2.0\"\npaths: {}\n")},
+		{Name: "../spec/testdata/petstore-expanded.yaml"},
+		{Name: "not-a-spec.json", Data: []byte("{")},
+		{Name: "testdata/does-not-exist.json"},
+	}
+
+	got := All(context.Background(), specs, Options{Parallelism: 2})
+	if len(got) != len(specs) {
+		t.Fatalf("expected %d results got %d", len(specs), len(got))
+	}
+	for i, r := range got {
+		if r.Name != specs[i].Name {
+			t.Errorf("result %d: expected name %q got %q", i, specs[i].Name, r.Name)
+		}
+	}
+	if !got[0].OK() || !got[2].OK() {
+		t.Errorf("expected petstore examples to be valid: %v, %v", got[0], got[2])
+	}
+	want := []ValidationError{{"/info", "info is required"}}
+	if diff := pretty.Compare(got[1].Errors, want); diff != "" {
+		t.Errorf("want != got: %s", diff)
+	}
+	if got[3].Err == nil {
+		t.Errorf("expected parse error for malformed document")
+	}
+	if got[4].Err == nil {
+		t.Errorf("expected read error for missing file")
+	}
+}
+
+func TestAllCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	specs := []Source{{Name: "a.json", Data: []byte("{}")}, {Name: "b.json", Data: []byte("{}")}}
+	for _, r := range All(ctx, specs, Options{Parallelism: 1}) {
+		if r.Err == nil && len(r.Errors) == 0 {
+			t.Errorf("%s: expected cancellation or validation errors", r.Name)
+		}
+	}
+}
+
+func TestValidateDocument(t *testing.T) {
+	tests := []struct {
+		doc  string
+		want []ValidationError
+	}{
+		{
+			doc: `
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+host: example.com:8080
+basePath: /v1
+paths:
+  /pets/{petId}:
+    get:
+      parameters:
+      - {name: petId, in: path, required: true, type: integer}
+      - {name: tags, in: query, type: array, items: {type: string}, collectionFormat: multi}
+      - {$ref: '#/parameters/limit'}
+      responses:
+        200: {description: A pet., schema: {$ref: '#/definitions/Pet'}}
+        default: {$ref: '#/responses/Error'}
+parameters:
+  limit: {name: limit, in: query, type: integer}
+responses:
+  Error: {description: An error.}
+definitions:
+  Pet: {type: object, properties: {name: {type: string}}}
+securityDefinitions:
+  key: {type: apiKey, name: X-Key, in: header}
+  oauth: {type: oauth2, flow: accessCode, authorizationUrl: "https://example.com/auth", tokenUrl: "https://example.com/token", scopes: {}}
+`,
+		},
+		{
+			doc: `
+swagger: "2.0"
+info: {title: Pets}
+host: https://example.com/api
+basePath: v1
+schemes: [http, ftp]
+paths:
+  pets:
+    get:
+      parameters:
+      - {name: id, in: path, type: integer}
+      - {name: X-Tags, in: header, type: array, collectionFormat: multi}
+      - {name: body, in: body, type: object}
+      - {name: file, in: query, type: file}
+      - {name: q, in: cookie, type: string}
+      responses:
+        2xx: {description: OK.}
+        404: {schema: {type: list}}
+    post:
+      responses: {}
+definitions:
+  Count: {type: integer, exclusiveMinimum: true}
+securityDefinitions:
+  key: {type: apiKey, in: body, flow: implicit}
+  oauth: {type: oauth2, flow: password}
+`,
+			want: []ValidationError{
+				{"/info/version", "version is required"},
+				{"/host", `host must not include a scheme or path, got "https://example.com/api"`},
+				{"/basePath", `basePath must begin with "/", got "v1"`},
+				{"/schemes/1", `scheme must be one of http, https, ws, wss, got "ftp"`},
+				{"/paths/pets", `path must begin with "/"`},
+				{"/paths/pets/get/parameters/0/required", "path parameters must be required"},
+				{"/paths/pets/get/parameters/1/collectionFormat", `collectionFormat must be one of csv, ssv, tsv, pipes, got "multi"`},
+				{"/paths/pets/get/parameters/1/items", "items is required"},
+				{"/paths/pets/get/parameters/2/schema", "schema is required"},
+				{"/paths/pets/get/parameters/2/type", "body parameters must use schema rather than type"},
+				{"/paths/pets/get/parameters/3/type", `type must be one of string, number, integer, boolean, array, got "file"`},
+				{"/paths/pets/get/parameters/4/in", `in must be one of query, header, path, formData, body, got "cookie"`},
+				{"/paths/pets/get/responses/2xx", `response code must be three digits or "default", got "2xx"`},
+				{"/paths/pets/get/responses/404/description", "description is required"},
+				{"/paths/pets/get/responses/404/schema/type", `type must be one of array, boolean, integer, null, number, object, string, got "list"`},
+				{"/paths/pets/post/responses", "responses must declare at least one response"},
+				{"/definitions/Count/exclusiveMinimum", "exclusiveMinimum requires minimum"},
+				{"/securityDefinitions/key/name", "name is required"},
+				{"/securityDefinitions/key/in", `in must be one of query, header, got "body"`},
+				{"/securityDefinitions/key/flow", "flow is only valid for oauth2 security schemes"},
+				{"/securityDefinitions/oauth/tokenUrl", "tokenUrl is required"},
+				{"/securityDefinitions/oauth/scopes", "scopes is required"},
+			},
+		},
+	}
+	for i, tt := range tests {
+		var s spec.Swagger
+		if err := yaml.Unmarshal([]byte(tt.doc), &s); err != nil {
+			t.Errorf("case %d: %v", i, err)
+			continue
+		}
+		if diff := pretty.Compare(ValidateDocument(&s), tt.want); diff != "" {
+			t.Errorf("case %d: want != got: %s", i, diff)
+		}
+	}
+}
+
+func TestValidateSemantics(t *testing.T) {
+	const doc = `
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      parameters:
+      - {$ref: '#/parameters/limit'}
+      - {name: limit, in: query, type: integer}
+      responses:
+        200: {description: Pets., schema: {type: array, items: {$ref: '#/definitions/Pet'}}}
+        700: {description: Unusual.}
+    post:
+      operationId: listPets
+      parameters:
+      - {name: pet, in: body, schema: {$ref: '#/definitions/NewPet'}}
+      responses:
+        201: {$ref: '#/responses/Created'}
+  /pets/{petId}:
+    parameters:
+    - {name: id, in: path, required: true, type: string}
+    get:
+      responses:
+        200: {description: A pet., schema: {$ref: 'common.yaml#/definitions/Pet'}}
+parameters:
+  limit: {name: limit, in: query, type: integer}
+  offset: {name: offset, in: query, type: integer}
+definitions:
+  Pet:
+    type: object
+    properties:
+      owner: {$ref: '#/definitions/Owner'}
+  Owner:
+    type: object
+  Cycle:
+    type: object
+    properties:
+      next: {$ref: '#/definitions/Cycle'}
+`
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(doc), &s); err != nil {
+		t.Fatal(err)
+	}
+	want := []ValidationError{
+		{"/paths/~1pets/post/operationId", `operationId "listPets" is also used by GET /pets`},
+		{"/paths/~1pets/get/parameters/1", `query parameter "limit" is declared more than once`},
+		{"/paths/~1pets~1{petId}/parameters/0", `path parameter "id" does not appear in path /pets/{petId}`},
+		{"/paths/~1pets~1{petId}/get", `path variable "petId" has no path parameter`},
+		{"/paths/~1pets/post/parameters/0/schema/$ref", `reference "#/definitions/NewPet" does not resolve`},
+		{"/paths/~1pets/post/responses/201/$ref", `reference "#/responses/Created" does not resolve`},
+		{"/definitions/Cycle", `definition "Cycle" is never referenced`},
+		{"/parameters/offset", `parameter "offset" is never referenced`},
+		{"/paths/~1pets/get/responses/700", "response code 700 is not a valid HTTP status"},
+	}
+	if diff := pretty.Compare(ValidateSemantics(&s), want); diff != "" {
+		t.Errorf("want != got: %s", diff)
+	}
+}
+
+func TestValidatePathTemplates(t *testing.T) {
+	const doc = `
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+paths:
+  /pets/{id}:
+    get:
+      parameters: [{name: id, in: path, required: true, type: string}]
+      responses: {200: {description: OK}}
+  /pets/{petId}:
+    delete:
+      parameters: [{name: petId, in: path, required: true, type: string}]
+      responses: {204: {description: Deleted}}
+  /pets/mine:
+    get:
+      responses: {200: {description: OK}}
+  /pets/{id}/photos:
+    get:
+      parameters: [{name: id, in: path, required: true, type: string}]
+      responses: {200: {description: OK}}
+  /{kind}/mine/photos:
+    get:
+      parameters: [{name: kind, in: path, required: true, type: string}]
+      responses: {200: {description: OK}}
+  /pets/{id}.json:
+    get:
+      parameters: [{name: id, in: path, required: true, type: string}]
+      responses: {200: {description: OK}}
+`
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(doc), &s); err != nil {
+		t.Fatal(err)
+	}
+	want := []ValidationError{
+		{"/paths/~1pets~1{petId}", "path /pets/{petId} is equivalent to /pets/{id}; use the same variable names and merge their operations"},
+		{"/paths/~1{kind}~1mine~1photos", "path /{kind}/mine/photos overlaps /pets/{id}/photos, and neither is more specific; make one of them literal where the other has a variable"},
+	}
+	if diff := pretty.Compare(ValidateSemantics(&s), want); diff != "" {
+		t.Errorf("want != got: %s", diff)
+	}
+}
+
+func TestValidateVariants(t *testing.T) {
+	const doc = `
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      responses:
+        200: {description: Pets.}
+      x-variants:
+      - operationId: listPetsV2
+        x-when: {header: X-API-Version, value: "2"}
+        responses:
+          200: {description: Pets.}
+      - operationId: listPets
+        x-when: {header: X-API-Version, value: "2"}
+      - operationId: listPetsHAL
+        x-when: {mediaType: application/hal+json}
+        responses:
+          200: {description: Pets.}
+    post:
+      operationId: createPet
+      responses:
+        201: {description: Created.}
+      x-variants:
+      - operationId: createPetV2
+`
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(doc), &s); err != nil {
+		t.Fatal(err)
+	}
+	want := []ValidationError{
+		{"/paths/~1pets/get/x-variants/1/operationId", `operationId "listPets" is also used by GET /pets`},
+		{"/paths/~1pets/get/x-variants/1", "variant has no responses"},
+		{"/paths/~1pets/get/x-variants/1/x-when", "variant conflicts with variant 0: both apply to requests with X-API-Version: 2"},
+		{"/paths/~1pets/post/x-variants", "x-variants: variant 0: x-when is required"},
+	}
+	if diff := pretty.Compare(ValidateSemantics(&s), want); diff != "" {
+		t.Errorf("want != got: %s", diff)
+	}
+}
+
+func TestValidateLinks(t *testing.T) {
+	const doc = `
+swagger: "2.0"
+info: {title: Pets, version: "1.0"}
+paths:
+  /pets/{id}:
+    get:
+      operationId: getPet
+      parameters:
+      - {name: id, in: path, required: true, type: integer}
+      responses:
+        200:
+          description: A pet.
+          x-links:
+            owner:
+              operationId: getOwner
+              parameters:
+                path.ownerId: $response.body#/ownerId
+            self:
+              operationRef: '#/paths/~1pets~1{id}/get'
+              parameters:
+                id: $request.path.id
+                name: $response.query.name
+            update:
+              operationId: getPet
+              requestBody: $response.body
+            delete:
+              operationId: deletePet
+  /owners/{ownerId}:
+    get:
+      operationId: getOwner
+      parameters:
+      - {name: ownerId, in: path, required: true, type: integer}
+      responses:
+        200: {description: An owner.}
+`
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(doc), &s); err != nil {
+		t.Fatal(err)
+	}
+	want := []ValidationError{
+		{"/paths/~1pets~1{id}/get/responses/200/x-links/delete", `no operation has operationId "deletePet"`},
+		{"/paths/~1pets~1{id}/get/responses/200/x-links/self", `parameter "name" isn't a parameter of the target operation`},
+		{"/paths/~1pets~1{id}/get/responses/200/x-links/self", `parameter "name": invalid runtime expression "$response.query.name": unknown source "response.query"`},
+		{"/paths/~1pets~1{id}/get/responses/200/x-links/update", "requestBody is set, but the target operation has no body parameter"},
+	}
+	if diff := pretty.Compare(ValidateSemantics(&s), want); diff != "" {
+		t.Errorf("want != got: %s", diff)
+	}
+}
+
+func TestValue(t *testing.T) {
+	const doc = `
+definitions:
+  Pet:
+    type: object
+    required: [name]
+    properties:
+      name: {type: string, minLength: 1, maxLength: 10, pattern: '^[A-Z]'}
+      age: {type: integer, minimum: 0, maximum: 30, exclusiveMaximum: true}
+      weight: {type: number, multipleOf: 0.5}
+      tags:
+        type: array
+        maxItems: 2
+        uniqueItems: true
+        items: {type: string, enum: [cat, dog, fish]}
+    additionalProperties: false
+  Named:
+    type: object
+    required: [name]
+    properties:
+      name: {type: string}
+  Owned:
+    allOf:
+    - $ref: '#/definitions/Named'
+    - type: object
+      required: [owner]
+      properties:
+        owner: {type: object, minProperties: 1, additionalProperties: {type: string}}
+  Event:
+    type: object
+    properties:
+      id: {type: string, format: uuid}
+      at: {type: string, format: date-time}
+      day: {type: string, format: date}
+      by: {type: string, format: email}
+      data: {type: string, format: byte}
+      count: {type: integer, format: int32}
+      version: {type: string, format: x-semver}
+  Node:
+    type: object
+    properties:
+      name: {type: string}
+      child: {$ref: '#/definitions/Node'}
+  Blank:
+    type: object
+    properties:
+      note: {type: string, maxLength: 0}
+      tags: {type: array, maxItems: 0, items: {type: string}}
+      meta: {type: object, maxProperties: 0}
+`
+	var s spec.Swagger
+	if err := yaml.Unmarshal([]byte(doc), &s); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		schema string
+		value  string
+		want   []string
+	}{
+		{"Pet", `{"name": "Rex", "age": 3, "weight": 10.5, "tags": ["dog"]}`, nil},
+		{"Pet", `[]`, []string{"expected an object, got an array"}},
+		{"Pet", `{"age": 30}`, []string{
+			`missing required property "name"`,
+			"/age: 30 must be less than 30",
+		}},
+		{"Pet", `{"name": "", "age": -1.5, "weight": 1.2, "color": "brown"}`, []string{
+			`/age: -1.5 must be at least 0`,
+			`/age: expected an integer, got a number`,
+			`property "color" is not allowed`,
+			`/name: length 0 is less than the minimum of 1`,
+			`/name: "" does not match pattern "^[A-Z]"`,
+			`/weight: 1.2 is not a multiple of 0.5`,
+		}},
+		{"Pet", `{"name": "Rexalotlotlot", "tags": ["dog", "dog", "bird"]}`, []string{
+			`/name: length 13 is more than the maximum of 10`,
+			`/tags: 3 items is more than the maximum of 2`,
+			`/tags: items 0 and 1 are equal, but items must be unique`,
+			`/tags/2: value bird is not one of the allowed values`,
+		}},
+		{"Owned", `{"name": "Rex", "owner": {}}`, []string{
+			`/owner: 0 properties is less than the minimum of 1`,
+		}},
+		{"Owned", `{"owner": {"name": 1}}`, []string{
+			`missing required property "name"`,
+			`/owner/name: expected a string, got an integer`,
+		}},
+		{"Event", `{"id": "0b8b4c1e-6f8a-4d2b-9c6e-2f1d3a4b5c6d", "at": "2026-10-15T12:00:00Z", "day": "2026-10-15", "by": "rex@example.com", "data": "cmV4", "count": 2147483647, "version": "1.2.3"}`, nil},
+		{"Event", `{"id": "rex", "at": "2026-10-15", "day": "15/10/2026", "by": "Rex <rex@example.com>", "data": "r3x!", "count": 2147483648, "version": "1.2"}`, []string{
+			`/at: 2026-10-15 is not a valid date-time: parsing time "2026-10-15" as "2006-01-02T15:04:05Z07:00": cannot parse "" as "T"`,
+			`/by: Rex <rex@example.com> is not a valid email: not a bare address`,
+			`/count: 2.147483648e+09 is not a valid int32: out of range`,
+			`/data: r3x! is not a valid byte: illegal base64 data at input byte 3`,
+			`/id: rex is not a valid uuid: does not match ^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`,
+			`/day: 15/10/2026 is not a valid date: parsing time "15/10/2026" as "2006-01-02": cannot parse "15/10/2026" as "2006"`,
+			`/version: 1.2 is not a valid x-semver: expected three numbers`,
+		}},
+		// A maximum of zero only allows empty values.
+		{"Blank", `{"note": "", "tags": [], "meta": {}}`, nil},
+		{"Blank", `{"note": "a", "tags": ["a"], "meta": {"a": 1}}`, []string{
+			`/meta: 1 properties is more than the maximum of 0`,
+			`/note: length 1 is more than the maximum of 0`,
+			`/tags: 1 items is more than the maximum of 0`,
+		}},
+		// Values are checked however deeply a recursive schema nests them.
+		{"Node", strings.Repeat(`{"child": `, 12) + `{"name": 1}` + strings.Repeat("}", 12), []string{
+			strings.Repeat("/child", 12) + "/name: expected a string, got an integer",
+		}},
+	}
+	RegisterFormat("x-semver", Format{
+		Type: "string",
+		Check: func(v interface{}) error {
+			if len(strings.Split(v.(string), ".")) != 3 {
+				return errors.New("expected three numbers")
+			}
+			return nil
+		},
+	})
+	for i, tt := range tests {
+		var v interface{}
+		if err := json.Unmarshal([]byte(tt.value), &v); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, err := range (ValueOptions{Doc: &s}).Value(&spec.Schema{Ref: "#/definitions/" + tt.schema}, v) {
+			got = append(got, err.Error())
+		}
+		sort.Strings(got)
+		sort.Strings(tt.want)
+		if diff := pretty.Compare(got, tt.want); diff != "" {
+			t.Errorf("case %d: want != got: %s", i, diff)
+		}
+	}
+
+	// Values decoded from YAML are accepted, and references without a
+	// document allow anything.
+	var v interface{}
+	if err := yaml.Unmarshal([]byte("{count: 3, items: [1, 2]}"), &v); err != nil {
+		t.Fatal(err)
+	}
+	schema := &spec.Schema{
+		Type: "object",
+		Properties: map[string]spec.Schema{
+			"count": {Type: "integer", Maximum: new(float64)},
+			"items": {Type: "array", Items: &spec.Schema{Ref: "#/definitions/Item"}},
+		},
+	}
+	errs := Value(schema, v)
+	if len(errs) != 1 || errs[0].Error() != "/count: 3 must be at most 0" {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+}
@@ -0,0 +1,51 @@
+package validate
+
+import (
+	"github.com/ericchiang/swaggopher/internal/conform"
+	"github.com/ericchiang/swaggopher/internal/rawdoc"
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+// ValueOptions configures Value. The zero value is valid.
+type ValueOptions struct {
+	// Doc holds the definitions local references, such as "#/definitions/Pet",
+	// refer to. Without it, or if a definition doesn't exist, a reference
+	// allows any value.
+	Doc *spec.Swagger
+}
+
+// Value checks a value decoded from JSON against a schema, such as the body of
+// a request or response, and returns a ValidationError for each way it doesn't
+// conform. Each error's Path is a JSON pointer to the offending part of the
+// value, which is "" for the value itself.
+//
+// The type, enum, required, properties, additionalProperties, items and allOf
+// keywords are checked, along with the constraints on numbers (maximum,
+// minimum, multipleOf), strings (maxLength, minLength, pattern), arrays
+// (maxItems, minItems, uniqueItems) and objects (maxProperties,
+// minProperties). Strings and numbers with a format are checked by the format
+// registered with its name, if any; see RegisterFormat.
+//
+// Values decoded from YAML, which may hold int or map[interface{}]interface{}
+// values, are converted to those encoding/json decodes first.
+func Value(s *spec.Schema, v interface{}) []error {
+	return ValueOptions{}.Value(s, v)
+}
+
+// Value checks a value decoded from JSON against a schema, resolving its
+// references against o.Doc.
+func (o ValueOptions) Value(s *spec.Schema, v interface{}) []error {
+	doc := o.Doc
+	if doc == nil {
+		doc = &spec.Swagger{}
+	}
+	v, err := rawdoc.Normalize(v)
+	if err != nil {
+		return []error{err}
+	}
+	var errs []error
+	for _, m := range conform.Check(doc, s, v, "") {
+		errs = append(errs, ValidationError{Path: m.Path, Message: m.Message})
+	}
+	return errs
+}
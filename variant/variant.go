@@ -0,0 +1,303 @@
+/*
+Package variant routes requests for a single path and method to one of several
+operations, chosen by a version header or media type.
+
+Swagger 2.0 allows one operation per method and path, so header versioned APIs
+declare their other versions with the "x-variants" extension of the default
+operation. Each variant is a complete operation which says when it applies
+with its own "x-when" extension:
+
+	get:
+	  operationId: listPets
+	  responses: ...
+	  x-variants:
+	  - operationId: listPetsV2
+	    x-when: {header: X-API-Version, value: "2"}
+	    responses: ...
+	  - operationId: listPetsHAL
+	    x-when: {mediaType: application/hal+json}
+	    responses: ...
+
+A condition on a header holds if the request has the header, with Value if
+it's set. A condition on a media type holds if it's the type of the request's
+Content-Type or, if the request has no body, the first type it Accepts. Media
+type parameters, such as a profile, must also match. If a variant sets both,
+both must hold. Variants are tried in order, and requests which match none are
+served by the default operation. Variants may not declare the same header value
+or media type as an earlier variant; see Conflicts.
+*/
+package variant
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+const (
+	// Extension is the vendor extension of an operation listing its variants.
+	Extension = "x-variants"
+	// WhenExtension is the vendor extension of a variant declaring when it
+	// applies.
+	WhenExtension = "x-when"
+)
+
+// When is the condition under which a variant applies.
+type When struct {
+	// Header, if set, is a header the request must have.
+	Header string `json:"header,omitempty"`
+	// Value, if set, is the value Header must have.
+	Value string `json:"value,omitempty"`
+	// MediaType, if set, is the media type of the request's body, or the type
+	// it prefers in response.
+	MediaType string `json:"mediaType,omitempty"`
+}
+
+func (w When) String() string {
+	var conds []string
+	if w.Header != "" {
+		if w.Value != "" {
+			conds = append(conds, fmt.Sprintf("%s: %s", w.Header, w.Value))
+		} else {
+			conds = append(conds, w.Header)
+		}
+	}
+	if w.MediaType != "" {
+		conds = append(conds, w.MediaType)
+	}
+	return strings.Join(conds, " and ")
+}
+
+// Variant is an alternative to an operation.
+type Variant struct {
+	When      When
+	Operation *spec.Operation
+}
+
+// Parse returns the variants an operation declares, or an error if they can't
+// be decoded or a variant has no condition.
+func Parse(op *spec.Operation) ([]Variant, error) {
+	ext, ok := op.Extensions[Extension]
+	if !ok {
+		return nil, nil
+	}
+	list, ok := ext.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: expected a list of operations", Extension)
+	}
+	variants := make([]Variant, len(list))
+	for i, item := range list {
+		v, err := parseVariant(item)
+		if err != nil {
+			return nil, fmt.Errorf("%s: variant %d: %v", Extension, i, err)
+		}
+		variants[i] = *v
+	}
+	return variants, nil
+}
+
+func parseVariant(item interface{}) (*Variant, error) {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+	var op spec.Operation
+	if err := json.Unmarshal(data, &op); err != nil {
+		return nil, err
+	}
+	when, err := parseWhen(op.Extensions[WhenExtension])
+	if err != nil {
+		return nil, err
+	}
+	delete(op.Extensions, WhenExtension)
+	if len(op.Extensions) == 0 {
+		op.Extensions = nil
+	}
+	return &Variant{When: *when, Operation: &op}, nil
+}
+
+func parseWhen(ext interface{}) (*When, error) {
+	if ext == nil {
+		return nil, fmt.Errorf("%s is required", WhenExtension)
+	}
+	data, err := json.Marshal(ext)
+	if err != nil {
+		return nil, err
+	}
+	var w When
+	if err := json.Unmarshal(data, &w); err != nil {
+		return nil, fmt.Errorf("%s: %v", WhenExtension, err)
+	}
+	if w.Header == "" && w.MediaType == "" {
+		return nil, fmt.Errorf("%s: header or mediaType is required", WhenExtension)
+	}
+	if w.Value != "" && w.Header == "" {
+		return nil, fmt.Errorf("%s: value requires header", WhenExtension)
+	}
+	if w.MediaType != "" {
+		if _, _, err := mime.ParseMediaType(w.MediaType); err != nil {
+			return nil, fmt.Errorf("%s: mediaType: %v", WhenExtension, err)
+		}
+	}
+	return &w, nil
+}
+
+// Matches reports whether a request satisfies the condition.
+func (w When) Matches(r *http.Request) bool {
+	if w.Header != "" {
+		v := r.Header.Get(w.Header)
+		if v == "" || (w.Value != "" && v != w.Value) {
+			return false
+		}
+	}
+	if w.MediaType != "" {
+		requested := r.Header.Get("Content-Type")
+		if requested == "" {
+			requested = strings.Split(r.Header.Get("Accept"), ",")[0]
+		}
+		if !mediaTypeMatches(w.MediaType, requested) {
+			return false
+		}
+	}
+	return true
+}
+
+// mediaTypeMatches reports whether a requested media type is the declared type
+// and has each of its parameters.
+func mediaTypeMatches(declared, requested string) bool {
+	dt, dparams, err := mime.ParseMediaType(declared)
+	if err != nil {
+		return false
+	}
+	rt, rparams, err := mime.ParseMediaType(strings.TrimSpace(requested))
+	if err != nil || dt != rt {
+		return false
+	}
+	for k, v := range dparams {
+		if rparams[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Select returns the first of an operation's variants whose condition the
+// request satisfies, or nil if there's none. Variants which can't be parsed
+// are ignored, so documents should be checked with Parse first.
+func Select(op *spec.Operation, r *http.Request) *Variant {
+	list, ok := op.Extensions[Extension].([]interface{})
+	if !ok {
+		return nil
+	}
+	for _, item := range list {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		// Only decode the variant's operation once its condition holds.
+		when, err := parseWhen(m[WhenExtension])
+		if err != nil || !when.Matches(r) {
+			continue
+		}
+		v, err := parseVariant(item)
+		if err != nil {
+			continue
+		}
+		return v
+	}
+	return nil
+}
+
+// Selector selects variants as Select does, but decodes each operation's
+// variants once rather than for each request, so requests selecting the same
+// variant are given the same *Variant. It's safe for concurrent use. The zero
+// value is ready to use.
+type Selector struct {
+	mu sync.Mutex
+	// variants holds the decoded variants of each operation, with nil in
+	// place of those which can't be parsed.
+	variants map[*spec.Operation][]*Variant
+}
+
+// Select returns the first of an operation's variants whose condition the
+// request satisfies, or nil if there's none. The operation's variants must
+// not be changed once it's been selected from.
+func (s *Selector) Select(op *spec.Operation, r *http.Request) *Variant {
+	for _, v := range s.parse(op) {
+		if v != nil && v.When.Matches(r) {
+			return v
+		}
+	}
+	return nil
+}
+
+func (s *Selector) parse(op *spec.Operation) []*Variant {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if variants, ok := s.variants[op]; ok {
+		return variants
+	}
+	list, _ := op.Extensions[Extension].([]interface{})
+	variants := make([]*Variant, len(list))
+	for i, item := range list {
+		// Variants which can't be parsed are ignored, as by Select.
+		variants[i], _ = parseVariant(item)
+	}
+	if s.variants == nil {
+		s.variants = make(map[*spec.Operation][]*Variant)
+	}
+	s.variants[op] = variants
+	return variants
+}
+
+// Conflict is a variant which applies to requests another variant of the same
+// operation also applies to.
+type Conflict struct {
+	// Index is the position of the variant in the operation's list.
+	Index int
+	// Other is the position of the earlier variant it conflicts with.
+	Other int
+}
+
+// Conflicts returns the variants whose conditions overlap those of an earlier
+// variant: they constrain the same header, with the same value or without one,
+// or the same media type. Such variants would never be selected for some or all
+// of the requests they describe.
+func Conflicts(variants []Variant) []Conflict {
+	var found []Conflict
+	for i := range variants {
+		for j := 0; j < i; j++ {
+			if overlap(variants[j].When, variants[i].When) {
+				found = append(found, Conflict{Index: i, Other: j})
+				break
+			}
+		}
+	}
+	return found
+}
+
+// overlap reports whether two conditions constrain the same header or media
+// type in ways a single request could satisfy. Conditions on different headers,
+// or one on a header and the other on a media type, don't overlap: the first
+// variant listed takes precedence.
+func overlap(a, b When) bool {
+	shared := false
+	if a.Header != "" && b.Header != "" && strings.EqualFold(a.Header, b.Header) {
+		if a.Value != "" && b.Value != "" && a.Value != b.Value {
+			return false
+		}
+		shared = true
+	}
+	if a.MediaType != "" && b.MediaType != "" {
+		if !mediaTypeMatches(a.MediaType, b.MediaType) && !mediaTypeMatches(b.MediaType, a.MediaType) {
+			return false
+		}
+		shared = true
+	}
+	return shared
+}
@@ -0,0 +1,132 @@
+package variant
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/ericchiang/swaggopher/spec"
+)
+
+const listPets = `
+operationId: listPets
+responses:
+  200: {description: Pets.}
+x-variants:
+- operationId: listPetsV2
+  x-when: {header: X-API-Version, value: "2"}
+  responses:
+    200: {description: Pets with owners.}
+- operationId: listPetsHAL
+  x-when: {mediaType: application/hal+json}
+  responses:
+    200: {description: Pets with links.}
+- operationId: listPetsProfile
+  x-when: {mediaType: 'application/json; profile="compact"'}
+  responses:
+    200: {description: Pet names.}
+`
+
+func parseOperation(t *testing.T, s string) *spec.Operation {
+	var op spec.Operation
+	if err := yaml.Unmarshal([]byte(s), &op); err != nil {
+		t.Fatal(err)
+	}
+	return &op
+}
+
+func TestSelect(t *testing.T) {
+	op := parseOperation(t, listPets)
+	var sel Selector
+	tests := []struct {
+		header map[string]string
+		want   string
+	}{
+		{header: nil, want: ""},
+		{header: map[string]string{"X-API-Version": "2"}, want: "listPetsV2"},
+		{header: map[string]string{"X-API-Version": "3"}, want: ""},
+		{header: map[string]string{"Accept": "application/hal+json, application/json"}, want: "listPetsHAL"},
+		{header: map[string]string{"Accept": "application/json, application/hal+json"}, want: ""},
+		{header: map[string]string{"Content-Type": "application/hal+json"}, want: "listPetsHAL"},
+		{header: map[string]string{"Accept": `application/json; profile="compact"; q=0.9`}, want: "listPetsProfile"},
+		{header: map[string]string{"Accept": "application/json; profile=full"}, want: ""},
+		// Earlier variants take precedence.
+		{header: map[string]string{"X-API-Version": "2", "Accept": "application/hal+json"}, want: "listPetsV2"},
+	}
+	for i, test := range tests {
+		r := httptest.NewRequest("GET", "/pets", nil)
+		for k, v := range test.header {
+			r.Header.Set(k, v)
+		}
+		got := ""
+		if v := Select(op, r); v != nil {
+			got = v.Operation.OperationId
+			if _, ok := v.Operation.Extensions[WhenExtension]; ok {
+				t.Errorf("case %d: expected %s to be removed from the variant's extensions", i, WhenExtension)
+			}
+		}
+		if got != test.want {
+			t.Errorf("case %d: want variant %q, got %q", i, test.want, got)
+		}
+
+		// A Selector selects the same variants, decoding them once.
+		v := sel.Select(op, r)
+		if (v == nil && got != "") || (v != nil && v.Operation.OperationId != got) {
+			t.Errorf("case %d: selector chose %v, want variant %q", i, v, got)
+		}
+		if again := sel.Select(op, r); again != v {
+			t.Errorf("case %d: expected the selector to return the same variant", i)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	variants, err := Parse(parseOperation(t, listPets))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, v := range variants {
+		got = append(got, v.When.String())
+	}
+	want := []string{"X-API-Version: 2", "application/hal+json", `application/json; profile="compact"`}
+	if diff := pretty.Compare(want, got); diff != "" {
+		t.Errorf("conditions: %s", diff)
+	}
+
+	for i, bad := range []string{
+		`x-variants: {operationId: listPetsV2}`,
+		`x-variants: [{operationId: listPetsV2}]`,
+		`x-variants: [{x-when: {value: "2"}}]`,
+		`x-variants: [{x-when: {mediaType: "application/"}}]`,
+	} {
+		if _, err := Parse(parseOperation(t, bad)); err == nil {
+			t.Errorf("case %d: expected error parsing %s", i, bad)
+		}
+	}
+}
+
+func TestConflicts(t *testing.T) {
+	variants := []Variant{
+		{When: When{Header: "X-API-Version", Value: "2"}},
+		{When: When{Header: "x-api-version", Value: "2"}},
+		{When: When{Header: "X-API-Version", Value: "3"}},
+		{When: When{MediaType: "application/hal+json"}},
+		{When: When{Header: "X-Beta"}},
+		{When: When{Header: "X-API-Version", Value: "4", MediaType: "application/hal+json"}},
+		{When: When{Header: "X-API-Version", Value: "4", MediaType: "application/json"}},
+		// A header without a value overlaps every value of the header.
+		{When: When{Header: "X-API-Version"}},
+	}
+	want := []Conflict{
+		{Index: 1, Other: 0},
+		{Index: 5, Other: 3},
+		{Index: 7, Other: 0},
+	}
+	if diff := pretty.Compare(want, Conflicts(variants)); diff != "" {
+		t.Errorf("conflicts: %s", diff)
+	}
+}